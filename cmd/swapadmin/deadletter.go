@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/CrossChain-Bridge/cmd/utils"
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	deadletterCommand = &cli.Command{
+		Action:    deadletter,
+		Name:      "deadletter",
+		Usage:     "admin dead-letter swaps",
+		ArgsUsage: "<list [swapin|swapout]|requeue <swapin|swapout> <txid>>",
+		Description: `
+admin dead-letter swaps (status TxDeadLetter),
+list: list swaps which failed too many times or with permanent errors,
+requeue: move dead-letter swap back to the stage it failed in to retry again.
+`,
+		Flags: commonAdminFlags,
+	}
+)
+
+func deadletter(ctx *cli.Context) error {
+	utils.SetLogger(ctx)
+	method := "deadletter"
+	if ctx.NArg() < 1 || ctx.NArg() > 3 {
+		_ = cli.ShowCommandHelp(ctx, method)
+		fmt.Println()
+		return fmt.Errorf("invalid arguments: %q", ctx.Args())
+	}
+
+	operation := ctx.Args().Get(0)
+	switch operation {
+	case "list":
+		if ctx.NArg() > 2 {
+			return fmt.Errorf("invalid arguments: %q", ctx.Args())
+		}
+	case "requeue":
+		if ctx.NArg() != 3 {
+			return fmt.Errorf("invalid arguments: %q", ctx.Args())
+		}
+	default:
+		return fmt.Errorf("unknown operation '%v'", operation)
+	}
+	if ctx.NArg() > 1 {
+		switch direction := ctx.Args().Get(1); direction {
+		case swapinOp, swapoutOp:
+		default:
+			return fmt.Errorf("unknown direction '%v'", direction)
+		}
+	}
+
+	err := prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := ctx.Args().Slice()
+
+	log.Printf("admin deadletter: %v", params)
+
+	result, err := adminCall(method, params)
+	if err != nil {
+		return err
+	}
+
+	if operation == "list" {
+		fmt.Println(result)
+		return nil
+	}
+	log.Printf("result is '%v'", result)
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/CrossChain-Bridge/cmd/utils"
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	feeCommand = &cli.Command{
+		Action:    fee,
+		Name:      "fee",
+		Usage:     "admin fee overrides of accounts",
+		ArgsUsage: "<set <address> <swapinDiscount> <swapoutDiscount> [memo]|remove <address>|query <address>|list>",
+		Description: `
+admin fee overrides of accounts (eg. partner discount),
+the address is the bind address of swaps,
+discounts are decimals in range [0,1] (eg. 0.3 means 30% off, 1 means free of fee),
+the largest one of the account discount and the active promotions is applied.
+set: set fee discounts of address,
+remove: remove fee override of address,
+query: query fee override of address,
+list: list all fee overrides.
+`,
+		Flags: commonAdminFlags,
+	}
+)
+
+func fee(ctx *cli.Context) error {
+	utils.SetLogger(ctx)
+	method := "fee"
+	if ctx.NArg() < 1 || ctx.NArg() > 5 {
+		_ = cli.ShowCommandHelp(ctx, method)
+		fmt.Println()
+		return fmt.Errorf("invalid arguments: %q", ctx.Args())
+	}
+
+	operation := ctx.Args().Get(0)
+	switch operation {
+	case "set":
+		if ctx.NArg() < 4 {
+			return fmt.Errorf("invalid arguments: %q", ctx.Args())
+		}
+	case "remove", "query":
+		if ctx.NArg() != 2 {
+			return fmt.Errorf("invalid arguments: %q", ctx.Args())
+		}
+	case "list":
+		if ctx.NArg() != 1 {
+			return fmt.Errorf("invalid arguments: %q", ctx.Args())
+		}
+	default:
+		return fmt.Errorf("unknown operation '%v'", operation)
+	}
+
+	err := prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := ctx.Args().Slice()
+
+	log.Printf("admin fee: %v", params)
+
+	result, err := adminCall(method, params)
+
+	log.Printf("result is '%v'", result)
+	return err
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/CrossChain-Bridge/cmd/utils"
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	historyCommand = &cli.Command{
+		Action:    history,
+		Name:      "history",
+		Usage:     "admin print swap history",
+		ArgsUsage: "<txid>",
+		Description: `
+admin print the full status timeline of swapin and swapout with txid,
+including the actor (worker job, admin or api), error text and related tx hashes.
+`,
+		Flags: commonAdminFlags,
+	}
+)
+
+func history(ctx *cli.Context) error {
+	utils.SetLogger(ctx)
+	method := "history"
+	if ctx.NArg() != 1 {
+		_ = cli.ShowCommandHelp(ctx, method)
+		fmt.Println()
+		return fmt.Errorf("invalid arguments: %q", ctx.Args())
+	}
+
+	err := prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	txid := ctx.Args().Get(0)
+
+	log.Printf("admin history: %v", txid)
+
+	params := []string{txid}
+	result, err := adminCall(method, params)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(result)
+	return nil
+}
@@ -30,6 +30,11 @@ func initApp() {
 		reswapCommand,
 		manualCommand,
 		setnonceCommand,
+		webhookCommand,
+		deadletterCommand,
+		feeCommand,
+		refundCommand,
+		historyCommand,
 		utils.LicenseCommand,
 		utils.VersionCommand,
 	}
@@ -13,9 +13,10 @@ var (
 		Action:    maintain,
 		Name:      "maintain",
 		Usage:     "maintain deposit and withdraw switch",
-		ArgsUsage: "<open|close> <deposit|withdraw|both>",
+		ArgsUsage: "<open|close> <deposit|withdraw|both> [pairID]",
 		Description: `
-maintain service, open or close deposit and withdraw
+maintain service, open or close deposit and withdraw,
+maintain all token pairs if pairID is not specified.
 `,
 		Flags: commonAdminFlags,
 	}
@@ -24,7 +25,7 @@ maintain service, open or close deposit and withdraw
 func maintain(ctx *cli.Context) error {
 	utils.SetLogger(ctx)
 	method := "maintain"
-	if ctx.NArg() != 2 {
+	if !(ctx.NArg() == 2 || ctx.NArg() == 3) {
 		_ = cli.ShowCommandHelp(ctx, method)
 		fmt.Println()
 		return fmt.Errorf("invalid arguments: %q", ctx.Args())
@@ -50,9 +51,13 @@ func maintain(ctx *cli.Context) error {
 		return fmt.Errorf("unknown direction '%v'", direction)
 	}
 
-	log.Printf("admin maintain: %v %v", operation, direction)
-
 	params := []string{operation, direction}
+	if ctx.NArg() > 2 {
+		params = append(params, ctx.Args().Get(2))
+	}
+
+	log.Printf("admin maintain: %v", params)
+
 	result, err := adminCall(method, params)
 
 	log.Printf("result is '%v'", result)
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/CrossChain-Bridge/cmd/utils"
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	refundCommand = &cli.Command{
+		Action:    refund,
+		Name:      "refund",
+		Usage:     "admin approve refund",
+		ArgsUsage: "<swapin|swapout> <txid>",
+		Description: `
+admin approve refund of deposit which needs approval (status TxRefundNeedApproval)
+`,
+		Flags: commonAdminFlags,
+	}
+)
+
+func refund(ctx *cli.Context) error {
+	utils.SetLogger(ctx)
+	method := "refund"
+	if ctx.NArg() != 2 {
+		_ = cli.ShowCommandHelp(ctx, method)
+		fmt.Println()
+		return fmt.Errorf("invalid arguments: %q", ctx.Args())
+	}
+
+	err := prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	operation := ctx.Args().Get(0)
+	txid := ctx.Args().Get(1)
+
+	switch operation {
+	case "swapin", "swapout":
+	default:
+		return fmt.Errorf("unknown operation '%v'", operation)
+	}
+
+	log.Printf("admin refund: %v %v", operation, txid)
+
+	params := []string{operation, txid}
+	result, err := adminCall(method, params)
+
+	log.Printf("result is '%v'", result)
+	return err
+}
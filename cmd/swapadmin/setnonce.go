@@ -14,11 +14,14 @@ var (
 		Action:    setnonce,
 		Name:      "setnonce",
 		Usage:     "admin swap nonce",
-		ArgsUsage: "<swapin|swapout> <nonce>",
+		ArgsUsage: "<swapin|swapout> <nonce> [pairID]",
 		Description: `
 admin swap nonce,
 swapin nonce is on destination blockchain,
-swapout nonce is on source blockchain.
+swapout nonce is on source blockchain,
+the nonce is persisted as the next reserved nonce of the dcrm address,
+and the unsent nonce reservations not less than it are released,
+use the default token pair if pairID is not specified.
 `,
 		Flags: commonAdminFlags,
 	}
@@ -27,7 +30,7 @@ swapout nonce is on source blockchain.
 func setnonce(ctx *cli.Context) error {
 	utils.SetLogger(ctx)
 	method := "setnonce"
-	if ctx.NArg() != 2 {
+	if !(ctx.NArg() == 2 || ctx.NArg() == 3) {
 		_ = cli.ShowCommandHelp(ctx, method)
 		fmt.Println()
 		return fmt.Errorf("invalid arguments: %q", ctx.Args())
@@ -52,9 +55,13 @@ func setnonce(ctx *cli.Context) error {
 		return fmt.Errorf("unknown operation '%v'", operation)
 	}
 
-	log.Printf("admin setnonce: %v %v", operation, nonce)
-
 	params := []string{operation, nonce}
+	if ctx.NArg() > 2 {
+		params = append(params, ctx.Args().Get(2))
+	}
+
+	log.Printf("admin setnonce: %v", params)
+
 	result, err := adminCall(method, params)
 
 	log.Printf("result is '%v'", result)
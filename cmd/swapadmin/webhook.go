@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/anyswap/CrossChain-Bridge/cmd/utils"
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	webhookCommand = &cli.Command{
+		Action:    webhook,
+		Name:      "webhook",
+		Usage:     "admin webhook dead-letter list",
+		ArgsUsage: "<deadletters [offset] [limit]|redeliver <key>|remove <key>>",
+		Description: `
+admin webhook dead-letter list,
+deadletters: list deliveries which failed too many times,
+redeliver: move dead delivery back to outbox to deliver again,
+remove: remove delivery from outbox.
+`,
+		Flags: commonAdminFlags,
+	}
+)
+
+func webhook(ctx *cli.Context) error {
+	utils.SetLogger(ctx)
+	method := "webhook"
+	if ctx.NArg() < 1 || ctx.NArg() > 3 {
+		_ = cli.ShowCommandHelp(ctx, method)
+		fmt.Println()
+		return fmt.Errorf("invalid arguments: %q", ctx.Args())
+	}
+
+	operation := ctx.Args().Get(0)
+	switch operation {
+	case "deadletters":
+	case "redeliver", "remove":
+		if ctx.NArg() != 2 {
+			return fmt.Errorf("invalid arguments: %q", ctx.Args())
+		}
+	default:
+		return fmt.Errorf("unknown operation '%v'", operation)
+	}
+
+	err := prepare(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := ctx.Args().Slice()
+
+	log.Printf("admin webhook: %v", params)
+
+	result, err := adminCall(method, params)
+
+	log.Printf("result is '%v'", result)
+	return err
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/anyswap/CrossChain-Bridge/cmd/utils"
 	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/metrics"
 	"github.com/anyswap/CrossChain-Bridge/params"
 	"github.com/anyswap/CrossChain-Bridge/worker"
 	"github.com/urfave/cli/v2"
@@ -18,6 +21,9 @@ var (
 	gitCommit = ""
 	// The app that holds all commands and flags.
 	app = utils.NewApp(clientIdentifier, gitCommit, "the swaporacle command line interface")
+
+	stopMetricsServerTimeout = 10 * time.Second
+	waitJobsFinishTimeout    = time.Minute
 )
 
 func initApp() {
@@ -55,14 +61,31 @@ func swaporacle(ctx *cli.Context) error {
 	if ctx.NArg() > 0 {
 		return fmt.Errorf("invalid command: %q", ctx.Args().Get(0))
 	}
-	exitCh := make(chan struct{})
 	configFile := utils.GetConfigFilePath(ctx)
-	params.LoadConfig(configFile, false)
+	config := params.LoadConfig(configFile, false)
 
 	params.SetDataDir(ctx.String(utils.DataDirFlag.Name))
 
-	worker.StartWork(false)
+	workCtx, cancel := utils.NewSignalContext()
+	defer cancel()
+
+	worker.StartWork(workCtx, false)
 
-	<-exitCh
+	metricsPort := config.Oracle.MetricsPort
+	if metricsPort != 0 {
+		metrics.StartMetricsServer(metricsPort)
+	}
+
+	<-workCtx.Done()
+	log.Info("swaporacle is shutting down")
+	if metricsPort != 0 {
+		stopCtx, stopCancel := context.WithTimeout(context.Background(), stopMetricsServerTimeout)
+		defer stopCancel()
+		if err := metrics.StopMetricsServer(stopCtx); err != nil {
+			log.Warn("stop metrics server failed", "err", err)
+		}
+	}
+	worker.WaitJobsFinish(waitJobsFinishTimeout)
+	log.Info("swaporacle exit")
 	return nil
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"sync"
@@ -11,6 +12,7 @@ import (
 	"github.com/anyswap/CrossChain-Bridge/mongodb"
 	"github.com/anyswap/CrossChain-Bridge/params"
 	"github.com/anyswap/CrossChain-Bridge/rpc/client"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 	"github.com/anyswap/CrossChain-Bridge/tokens/btc"
 	"github.com/anyswap/CrossChain-Bridge/tokens/btc/electrs"
@@ -153,11 +155,13 @@ func (scanner *btcSwapScanner) initMongodb(ctx *cli.Context) {
 	passwd := ctx.String(dbPassFlag.Name)
 	if dbName != "" {
 		mongodb.MongoServerInit([]string{dbURL}, dbName, userName, passwd)
+		storage.SetSwapStore(mongodb.NewSwapStore())
 	}
 }
 
 func (scanner *btcSwapScanner) initBridge() {
 	scanner.bridge = btc.NewCrossChainBridge(true)
+	scanner.bridge.Chain = btc.BitcoinChain
 	scanner.bridge.GatewayConfig = &tokens.GatewayConfig{
 		APIAddress: []string{scanner.gateway},
 	}
@@ -176,15 +180,24 @@ func (scanner *btcSwapScanner) initBridge() {
 		DepositAddress: scanner.depositAddress,
 		Confirmations:  &scanner.stableHeight,
 	}
-	tokens.SrcBridge = scanner.bridge
-	tokens.DstBridge = eth.NewCrossChainBridge(false)
+	scanner.bridge.SetPairID(tokens.DefaultPairID)
+	dstBridge := eth.NewCrossChainBridge(false)
+	dstBridge.SetPairID(tokens.DefaultPairID)
+	err := tokens.AddTokenPair(&tokens.TokenPair{
+		PairID:    tokens.DefaultPairID,
+		SrcBridge: scanner.bridge,
+		DstBridge: dstBridge,
+	})
+	if err != nil {
+		log.Fatalf("add token pair failed. %v", err)
+	}
 }
 
 func (scanner *btcSwapScanner) run() {
 	start := scanner.startHeight
 	wend := scanner.endHeight
 	if wend == 0 {
-		wend = tools.LoopGetLatestBlockNumber(scanner.bridge)
+		wend, _ = tools.LoopGetLatestBlockNumber(context.Background(), scanner.bridge)
 	}
 	if start == 0 {
 		start = wend
@@ -237,14 +250,14 @@ func (scanner *btcSwapScanner) scanRange(job, from, to uint64, wg *sync.WaitGrou
 }
 
 func (scanner *btcSwapScanner) scanPool() {
-	scanner.bridge.StartPoolTransactionScanJob()
+	scanner.bridge.StartPoolTransactionScanJob(context.Background())
 }
 
 func (scanner *btcSwapScanner) scanLoop(from uint64) {
 	stable := scanner.stableHeight
 	log.Info("start scan loop", "from", from, "stable", stable)
 	for {
-		latest := tools.LoopGetLatestBlockNumber(scanner.bridge)
+		latest, _ := tools.LoopGetLatestBlockNumber(context.Background(), scanner.bridge)
 		for h := latest; h > from; h-- {
 			scanner.scanBlock(0, h, true)
 		}
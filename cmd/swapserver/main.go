@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"sort"
 	"time"
 
@@ -11,6 +14,8 @@ import (
 	"github.com/anyswap/CrossChain-Bridge/mongodb"
 	"github.com/anyswap/CrossChain-Bridge/params"
 	rpcserver "github.com/anyswap/CrossChain-Bridge/rpc/server"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/storage/boltdb"
 	"github.com/anyswap/CrossChain-Bridge/worker"
 	"github.com/urfave/cli/v2"
 )
@@ -21,6 +26,9 @@ var (
 	gitCommit = ""
 	// The app that holds all commands and flags.
 	app = utils.NewApp(clientIdentifier, gitCommit, "the swapserver command line interface")
+
+	stopAPIServerTimeout  = 10 * time.Second
+	waitJobsFinishTimeout = 2 * time.Minute
 )
 
 func initApp() {
@@ -58,19 +66,66 @@ func swapserver(ctx *cli.Context) error {
 	if ctx.NArg() > 0 {
 		return fmt.Errorf("invalid command: %q", ctx.Args().Get(0))
 	}
-	exitCh := make(chan struct{})
 	configFile := utils.GetConfigFilePath(ctx)
 	config := params.LoadConfig(configFile, true)
 
 	params.SetDataDir(ctx.String(utils.DataDirFlag.Name))
 
-	dbConfig := config.MongoDB
-	mongodb.MongoServerInit([]string{dbConfig.DBURL}, dbConfig.DBName, dbConfig.UserName, dbConfig.Password)
+	initSwapStore(config)
+
+	workCtx, cancel := utils.NewSignalContext()
+	defer cancel()
 
-	worker.StartWork(true)
+	worker.StartWork(workCtx, true)
 	time.Sleep(100 * time.Millisecond)
 	rpcserver.StartAPIServer()
 
-	<-exitCh
+	select {
+	case <-workCtx.Done():
+	case <-worker.LeadershipLost():
+		// the stopped jobs can not restart, exit to rejoin as a follower
+		cancel()
+		shutdown()
+		return errors.New("swapserver lost leadership")
+	}
+	shutdown()
 	return nil
 }
+
+func shutdown() {
+	log.Info("swapserver is shutting down")
+
+	stopCtx, stopCancel := context.WithTimeout(context.Background(), stopAPIServerTimeout)
+	defer stopCancel()
+	if err := rpcserver.StopAPIServer(stopCtx); err != nil {
+		log.Warn("stop api server failed", "err", err)
+	}
+
+	worker.WaitJobsFinish(waitJobsFinishTimeout)
+
+	if err := storage.CloseSwapStore(); err != nil {
+		log.Warn("close swap storage failed", "err", err)
+	}
+	log.Info("swapserver exit")
+}
+
+func initSwapStore(config *params.ServerConfig) {
+	storageConfig := config.Storage
+	switch storageConfig.Type {
+	case params.StorageTypeBoltDB:
+		dataFile := storageConfig.BoltDBFile
+		if dataFile == "" {
+			dataFile = filepath.Join(params.DataDir, "swapserver.db")
+		}
+		store, err := boltdb.NewSwapStore(dataFile)
+		if err != nil {
+			log.Fatal("open boltdb storage failed", "file", dataFile, "err", err)
+		}
+		storage.SetSwapStore(store)
+	default:
+		dbConfig := config.MongoDB
+		mongodb.MongoServerInit([]string{dbConfig.DBURL}, dbConfig.DBName, dbConfig.UserName, dbConfig.Password)
+		storage.SetSwapStore(mongodb.NewSwapStore())
+	}
+	log.Info("init swap storage success", "type", storageConfig.Type)
+}
@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+)
+
+// NewSignalContext new context which is canceled when receiving interrupt or terminate signal
+func NewSignalContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		defer signal.Stop(signalCh)
+		select {
+		case sig := <-signalCh:
+			log.Info("receive signal, start to shutdown", "signal", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
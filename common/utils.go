@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"math/big"
@@ -90,6 +91,18 @@ func NowMilliStr() string {
 	return strconv.FormatInt((time.Now().UnixNano() / 1e6), 10)
 }
 
+// SleepWithContext sleep duration, returns false if context is done before that
+func SleepWithContext(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
 // MinUint64 get minimum value of x and y
 func MinUint64(x, y uint64) uint64 {
 	if x <= y {
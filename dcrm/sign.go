@@ -4,9 +4,11 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"math/big"
+	"time"
 
 	"github.com/anyswap/CrossChain-Bridge/common"
 	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/metrics"
 	"github.com/anyswap/CrossChain-Bridge/tools/crypto"
 	"github.com/anyswap/CrossChain-Bridge/tools/rlp"
 	"github.com/anyswap/CrossChain-Bridge/types"
@@ -18,8 +20,9 @@ func DoSignOne(msgHash, msgContext string) (string, error) {
 }
 
 // DoSign dcrm sign msgHash with context msgContext
-func DoSign(msgHash, msgContext []string) (string, error) {
+func DoSign(msgHash, msgContext []string) (keyID string, err error) {
 	log.Debug("dcrm DoSign", "msgHash", msgHash, "msgContext", msgContext)
+	defer func(start time.Time) { metrics.ObserveDcrmSign(start, err) }(time.Now())
 	nonce, err := GetSignNonce()
 	if err != nil {
 		return "", err
@@ -6,8 +6,8 @@ import (
 	"time"
 
 	"github.com/anyswap/CrossChain-Bridge/log"
-	"github.com/anyswap/CrossChain-Bridge/mongodb"
 	"github.com/anyswap/CrossChain-Bridge/params"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 	"github.com/anyswap/CrossChain-Bridge/tokens/btc"
 	"github.com/btcsuite/btcd/txscript"
@@ -19,6 +19,7 @@ var (
 	errNotBtcBridge    = newRPCError(-32096, "bridge is not btc")
 	errSwapNotExist    = newRPCError(-32095, "swap not exist")
 	errSwapCannotRetry = newRPCError(-32094, "swap can not retry")
+	errUnknownPairID   = newRPCError(-32093, "unknown token pair id")
 )
 
 func newRPCError(ec rpcjson.ErrorCode, message string) error {
@@ -39,66 +40,101 @@ func GetServerInfo() (*ServerInfo, error) {
 	if config == nil {
 		return nil, nil
 	}
-	return &ServerInfo{
+	pairID := tokens.GetDefaultPairID()
+	info := &ServerInfo{
 		Identifier: config.Identifier,
-		SrcToken:   config.SrcToken,
-		DestToken:  config.DestToken,
+		SrcToken:   tokens.GetTokenConfig(pairID, true),
+		DestToken:  tokens.GetTokenConfig(pairID, false),
+		PairIDs:    tokens.GetAllPairIDs(),
 		Version:    params.VersionWithMeta,
+	}
+	if params.IsHAEnabled() && storage.HasSwapStore() {
+		info.Leader = getLeaderInfo()
+	}
+	return info, nil
+}
+
+func getLeaderInfo() *LeaderInfo {
+	info := &LeaderInfo{Instance: params.GetHAConfig().InstanceID}
+	lease, err := storage.FindLease(storage.LeaderLeaseName)
+	if err != nil {
+		return info
+	}
+	if lease.Expires > time.Now().Unix() {
+		info.Leader = lease.Holder
+		info.IsLeader = lease.Holder == info.Instance
+	}
+	info.Token = lease.Token
+	info.Expires = lease.Expires
+	return info
+}
+
+// GetTokenPairInfo api
+func GetTokenPairInfo(pairID string) (*TokenPairInfo, error) {
+	log.Debug("[api] receive GetTokenPairInfo", "pairID", pairID)
+	pair := tokens.GetTokenPair(pairID)
+	if pair == nil {
+		return nil, errUnknownPairID
+	}
+	return &TokenPairInfo{
+		PairID:    pair.PairID,
+		SrcToken:  tokens.GetTokenConfig(pair.PairID, true),
+		DestToken: tokens.GetTokenConfig(pair.PairID, false),
 	}, nil
 }
 
 // GetSwapStatistics api
 func GetSwapStatistics() (*SwapStatistics, error) {
 	log.Debug("[api] receive GetSwapStatistics")
-	return mongodb.GetSwapStatistics()
+	return storage.GetSwapStatistics()
 }
 
 // GetRawSwapin api
 func GetRawSwapin(txid *string) (*Swap, error) {
-	return mongodb.FindSwapin(*txid)
+	return storage.FindSwapin(*txid)
 }
 
 // GetRawSwapinResult api
 func GetRawSwapinResult(txid *string) (*SwapResult, error) {
-	return mongodb.FindSwapinResult(*txid)
+	return storage.FindSwapinResult(*txid)
 }
 
 // GetSwapin api
 func GetSwapin(txid *string) (*SwapInfo, error) {
 	txidstr := *txid
-	result, err := mongodb.FindSwapinResult(txidstr)
+	result, err := storage.FindSwapinResult(txidstr)
 	if err == nil {
 		return ConvertMgoSwapResultToSwapInfo(result), nil
 	}
-	register, err := mongodb.FindSwapin(txidstr)
+	register, err := storage.FindSwapin(txidstr)
 	if err == nil {
 		return ConvertMgoSwapToSwapInfo(register), nil
 	}
-	return nil, mongodb.ErrSwapNotFound
+	return nil, storage.ErrSwapNotFound
 }
 
 // GetRawSwapout api
 func GetRawSwapout(txid *string) (*Swap, error) {
-	return mongodb.FindSwapout(*txid)
+	return storage.FindSwapout(*txid)
 }
 
 // GetRawSwapoutResult api
 func GetRawSwapoutResult(txid *string) (*SwapResult, error) {
-	return mongodb.FindSwapoutResult(*txid)
+	return storage.FindSwapoutResult(*txid)
 }
 
 // GetSwapout api
 func GetSwapout(txid *string) (*SwapInfo, error) {
 	txidstr := *txid
-	result, err := mongodb.FindSwapoutResult(txidstr)
+	result, err := storage.FindSwapoutResult(txidstr)
 	if err == nil {
 		return ConvertMgoSwapResultToSwapInfo(result), nil
 	}
-	register, err := mongodb.FindSwapout(txidstr)
+	register, err := storage.FindSwapout(txidstr)
 	if err == nil {
 		return ConvertMgoSwapToSwapInfo(register), nil
 	}
-	return nil, mongodb.ErrSwapNotFound
+	return nil, storage.ErrSwapNotFound
 }
 
 func processHistoryLimit(limit int) int {
@@ -117,7 +153,7 @@ func processHistoryLimit(limit int) int {
 func GetSwapinHistory(address string, offset, limit int) ([]*SwapInfo, error) {
 	log.Debug("[api] receive GetSwapinHistory", "address", address, "offset", offset, "limit", limit)
 	limit = processHistoryLimit(limit)
-	result, err := mongodb.FindSwapinResults(address, offset, limit)
+	result, err := storage.FindSwapinResults(address, offset, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -128,7 +164,7 @@ func GetSwapinHistory(address string, offset, limit int) ([]*SwapInfo, error) {
 func GetSwapoutHistory(address string, offset, limit int) ([]*SwapInfo, error) {
 	log.Debug("[api] receive GetSwapoutHistory", "address", address, "offset", offset, "limit", limit)
 	limit = processHistoryLimit(limit)
-	result, err := mongodb.FindSwapoutResults(address, offset, limit)
+	result, err := storage.FindSwapoutResults(address, offset, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -136,13 +172,17 @@ func GetSwapoutHistory(address string, offset, limit int) ([]*SwapInfo, error) {
 }
 
 // Swapin api
-func Swapin(txid *string) (*PostResult, error) {
-	log.Debug("[api] receive Swapin", "txid", *txid)
+func Swapin(txid, pairID *string) (*PostResult, error) {
+	log.Debug("[api] receive Swapin", "txid", *txid, "pairID", *pairID)
 	txidstr := *txid
-	if swap, _ := mongodb.FindSwapin(txidstr); swap != nil {
+	bridge := tokens.GetCrossChainBridge(*pairID, true)
+	if bridge == nil {
+		return nil, errUnknownPairID
+	}
+	if swap, _ := storage.FindSwapin(txidstr); swap != nil {
 		return nil, errSwapExist
 	}
-	swapInfo, err := tokens.SrcBridge.VerifyTransaction(txidstr, true)
+	swapInfo, err := tokens.VerifyTransactionOfPair(bridge, txidstr, true)
 	if !tokens.ShouldRegisterSwapForError(err) {
 		return nil, newRPCError(-32099, "verify swapin failed! "+err.Error())
 	}
@@ -157,18 +197,22 @@ func Swapin(txid *string) (*PostResult, error) {
 func RetrySwapin(txid *string) (*PostResult, error) {
 	log.Debug("[api] retry Swapin", "txid", *txid)
 	txidstr := *txid
-	swap, _ := mongodb.FindSwapin(txidstr)
+	swap, _ := storage.FindSwapin(txidstr)
 	if swap == nil {
 		return nil, errSwapNotExist
 	}
 	if !swap.Status.CanRetry() {
 		return nil, errSwapCannotRetry
 	}
-	_, err := tokens.SrcBridge.VerifyTransaction(txidstr, true)
+	bridge := tokens.GetCrossChainBridge(swap.PairID, true)
+	if bridge == nil {
+		return nil, errUnknownPairID
+	}
+	_, err := bridge.VerifyTransaction(txidstr, true)
 	if err != nil {
 		return nil, newRPCError(-32099, "retry swapin failed! "+err.Error())
 	}
-	err = mongodb.UpdateSwapinStatus(txidstr, mongodb.TxNotStable, time.Now().Unix(), "")
+	err = storage.UpdateSwapStatusFrom(storage.ActorAPI, true, txidstr, swap.Status, storage.TxNotStable, time.Now().Unix(), "")
 	if err != nil {
 		return nil, err
 	}
@@ -176,13 +220,17 @@ func RetrySwapin(txid *string) (*PostResult, error) {
 }
 
 // Swapout api
-func Swapout(txid *string) (*PostResult, error) {
-	log.Debug("[api] receive Swapout", "txid", *txid)
+func Swapout(txid, pairID *string) (*PostResult, error) {
+	log.Debug("[api] receive Swapout", "txid", *txid, "pairID", *pairID)
 	txidstr := *txid
-	if swap, _ := mongodb.FindSwapout(txidstr); swap != nil {
+	bridge := tokens.GetCrossChainBridge(*pairID, false)
+	if bridge == nil {
+		return nil, errUnknownPairID
+	}
+	if swap, _ := storage.FindSwapout(txidstr); swap != nil {
 		return nil, errSwapExist
 	}
-	swapInfo, err := tokens.DstBridge.VerifyTransaction(txidstr, true)
+	swapInfo, err := tokens.VerifyTransactionOfPair(bridge, txidstr, true)
 	if !tokens.ShouldRegisterSwapForError(err) {
 		return nil, newRPCError(-32098, "verify swapout failed! "+err.Error())
 	}
@@ -198,21 +246,22 @@ func addSwapToDatabase(txid string, txType tokens.SwapTxType, swapInfo *tokens.T
 	if verifyError != nil {
 		memo = verifyError.Error()
 	}
-	swap := &mongodb.MgoSwap{
+	swap := &storage.Swap{
 		Key:       txid,
+		PairID:    swapInfo.PairID,
 		TxID:      txid,
 		TxType:    uint32(txType),
 		Bind:      swapInfo.Bind,
-		Status:    mongodb.GetStatusByTokenVerifyError(verifyError),
+		Status:    storage.GetStatusByTokenVerifyError(verifyError),
 		Timestamp: time.Now().Unix(),
 		Memo:      memo,
 	}
 	isSwapin := txType == tokens.SwapinTx
 	log.Info("[api] add swap", "isSwapin", isSwapin, "swap", swap)
 	if isSwapin {
-		return mongodb.AddSwapin(swap)
+		return storage.AddSwapin(storage.ActorAPI, swap)
 	}
-	return mongodb.AddSwapout(swap)
+	return storage.AddSwapout(storage.ActorAPI, swap)
 }
 
 // IsValidSwapinBindAddress api
@@ -227,23 +276,31 @@ func IsValidSwapoutBindAddress(address *string) bool {
 
 // RegisterP2shAddress api
 func RegisterP2shAddress(bindAddress string) (*tokens.P2shAddressInfo, error) {
-	return calcP2shAddress(bindAddress, true)
+	if btc.BridgeInstance == nil {
+		return nil, errNotBtcBridge
+	}
+	// keep the registered address type (p2sh or p2wsh) of the bind address
+	if result, _ := storage.FindP2shAddress(bindAddress); result != nil {
+		return calcP2shAddress(bindAddress, btc.BridgeInstance.IsP2wshAddress(result.P2shAddress), false)
+	}
+	isP2wsh := btc.BridgeInstance.IsP2wpkhAddress(btc.BridgeInstance.TokenConfig.DcrmAddress)
+	return calcP2shAddress(bindAddress, isP2wsh, true)
 }
 
 // GetP2shAddressInfo api
 func GetP2shAddressInfo(p2shAddress string) (*tokens.P2shAddressInfo, error) {
-	bindAddress, err := mongodb.FindP2shBindAddress(p2shAddress)
+	if btc.BridgeInstance == nil {
+		return nil, errNotBtcBridge
+	}
+	bindAddress, err := storage.FindP2shBindAddress(p2shAddress)
 	if err != nil {
 		return nil, err
 	}
-	return calcP2shAddress(bindAddress, false)
+	return calcP2shAddress(bindAddress, btc.BridgeInstance.IsP2wshAddress(p2shAddress), false)
 }
 
-func calcP2shAddress(bindAddress string, addToDatabase bool) (*tokens.P2shAddressInfo, error) {
-	if btc.BridgeInstance == nil {
-		return nil, errNotBtcBridge
-	}
-	p2shAddr, redeemScript, err := btc.BridgeInstance.GetP2shAddress(bindAddress)
+func calcP2shAddress(bindAddress string, isP2wsh, addToDatabase bool) (*tokens.P2shAddressInfo, error) {
+	p2shAddr, redeemScript, err := btc.BridgeInstance.GetP2shAddressByType(bindAddress, isP2wsh)
 	if err != nil {
 		return nil, newRPCInternalError(err)
 	}
@@ -252,13 +309,10 @@ func calcP2shAddress(bindAddress string, addToDatabase bool) (*tokens.P2shAddres
 		return nil, newRPCInternalError(err)
 	}
 	if addToDatabase {
-		result, _ := mongodb.FindP2shAddress(bindAddress)
-		if result == nil {
-			_ = mongodb.AddP2shAddress(&mongodb.MgoP2shAddress{
-				Key:         bindAddress,
-				P2shAddress: p2shAddr,
-			})
-		}
+		_ = storage.AddP2shAddress(&storage.P2shAddress{
+			Key:         bindAddress,
+			P2shAddress: p2shAddr,
+		})
 	}
 	return &tokens.P2shAddressInfo{
 		BindAddress:        bindAddress,
@@ -275,7 +329,7 @@ func P2shSwapin(txid, bindAddr *string) (*PostResult, error) {
 		return nil, errNotBtcBridge
 	}
 	txidstr := *txid
-	if swap, _ := mongodb.FindSwapin(txidstr); swap != nil {
+	if swap, _ := storage.FindSwapin(txidstr); swap != nil {
 		return nil, errSwapExist
 	}
 	_, err := btc.BridgeInstance.VerifyP2shTransaction(txidstr, *bindAddr, true)
@@ -286,16 +340,17 @@ func P2shSwapin(txid, bindAddr *string) (*PostResult, error) {
 	if err != nil {
 		memo = err.Error()
 	}
-	swap := &mongodb.MgoSwap{
+	swap := &storage.Swap{
 		Key:       txidstr,
+		PairID:    btc.BridgeInstance.PairID,
 		TxID:      txidstr,
 		TxType:    uint32(tokens.P2shSwapinTx),
 		Bind:      *bindAddr,
-		Status:    mongodb.GetStatusByTokenVerifyError(err),
+		Status:    storage.GetStatusByTokenVerifyError(err),
 		Timestamp: time.Now().Unix(),
 		Memo:      memo,
 	}
-	err = mongodb.AddSwapin(swap)
+	err = storage.AddSwapin(storage.ActorAPI, swap)
 	if err != nil {
 		return nil, err
 	}
@@ -304,14 +359,21 @@ func P2shSwapin(txid, bindAddr *string) (*PostResult, error) {
 }
 
 // GetLatestScanInfo api
-func GetLatestScanInfo(isSrc bool) (*LatestScanInfo, error) {
-	return mongodb.FindLatestScanInfo(isSrc)
+func GetLatestScanInfo(pairID string, isSrc bool) (*LatestScanInfo, error) {
+	if !tokens.IsTokenPairExist(pairID) {
+		return nil, errUnknownPairID
+	}
+	info, err := storage.FindLatestScanInfo(pairID, isSrc)
+	if err == storage.ErrItemNotFound {
+		return info, nil
+	}
+	return info, err
 }
 
 // RegisterAddress register address
 func RegisterAddress(address string) (*PostResult, error) {
 	address = strings.ToLower(address)
-	err := mongodb.AddRegisteredAddress(address)
+	err := storage.AddRegisteredAddress(address)
 	if err != nil {
 		return nil, err
 	}
@@ -319,8 +381,29 @@ func RegisterAddress(address string) (*PostResult, error) {
 	return &SuccessPostResult, nil
 }
 
+// GetFeeOverride get fee override of address, returns empty override if not exist
+func GetFeeOverride(address string) (*FeeOverride, error) {
+	override, err := storage.FindFeeOverride(address)
+	if err == storage.ErrItemNotFound {
+		return &FeeOverride{}, nil
+	}
+	if err != nil {
+		return nil, newRPCInternalError(err)
+	}
+	return override, nil
+}
+
+// GetSwapEvents get swap events (the status timeline) of swapin and swapout with txid
+func GetSwapEvents(txid string) ([]*SwapEventInfo, error) {
+	events, err := storage.FindSwapEvents(txid)
+	if err != nil {
+		return nil, newRPCInternalError(err)
+	}
+	return ConvertSwapEventsToSwapEventInfos(events), nil
+}
+
 // GetRegisteredAddress get registered address
 func GetRegisteredAddress(address string) (*RegisteredAddress, error) {
 	address = strings.ToLower(address)
-	return mongodb.FindRegisteredAddress(address)
+	return storage.FindRegisteredAddress(address)
 }
@@ -1,13 +1,14 @@
 package swapapi
 
 import (
-	"github.com/anyswap/CrossChain-Bridge/mongodb"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 )
 
 // ConvertMgoSwapToSwapInfo convert
-func ConvertMgoSwapToSwapInfo(ms *mongodb.MgoSwap) *SwapInfo {
+func ConvertMgoSwapToSwapInfo(ms *storage.Swap) *SwapInfo {
 	return &SwapInfo{
+		PairID:    ms.PairID,
 		TxID:      ms.TxID,
 		Bind:      ms.Bind,
 		Status:    ms.Status,
@@ -18,7 +19,7 @@ func ConvertMgoSwapToSwapInfo(ms *mongodb.MgoSwap) *SwapInfo {
 }
 
 // ConvertMgoSwapsToSwapInfos convert
-func ConvertMgoSwapsToSwapInfos(msSlice []*mongodb.MgoSwap) []*SwapInfo {
+func ConvertMgoSwapsToSwapInfos(msSlice []*storage.Swap) []*SwapInfo {
 	result := make([]*SwapInfo, len(msSlice))
 	for k, v := range msSlice {
 		result[k] = ConvertMgoSwapToSwapInfo(v)
@@ -27,21 +28,22 @@ func ConvertMgoSwapsToSwapInfos(msSlice []*mongodb.MgoSwap) []*SwapInfo {
 }
 
 // ConvertMgoSwapResultToSwapInfo convert
-func ConvertMgoSwapResultToSwapInfo(mr *mongodb.MgoSwapResult) *SwapInfo {
+func ConvertMgoSwapResultToSwapInfo(mr *storage.SwapResult) *SwapInfo {
 	var confirmations uint64
 	if mr.SwapHeight != 0 {
 		var latest uint64
 		switch mr.SwapType {
 		case uint32(tokens.SwapinType):
-			latest = tokens.DstLatestBlockHeight
+			latest = tokens.GetPairLatestBlockHeight(mr.PairID, false)
 		case uint32(tokens.SwapoutType):
-			latest = tokens.SrcLatestBlockHeight
+			latest = tokens.GetPairLatestBlockHeight(mr.PairID, true)
 		}
 		if latest > mr.SwapHeight {
 			confirmations = latest - mr.SwapHeight
 		}
 	}
 	return &SwapInfo{
+		PairID:        mr.PairID,
 		TxID:          mr.TxID,
 		TxHeight:      mr.TxHeight,
 		TxTime:        mr.TxTime,
@@ -53,21 +55,67 @@ func ConvertMgoSwapResultToSwapInfo(mr *mongodb.MgoSwapResult) *SwapInfo {
 		SwapHeight:    mr.SwapHeight,
 		SwapTime:      mr.SwapTime,
 		SwapValue:     mr.SwapValue,
+		SwapFee:       mr.SwapFee,
 		SwapType:      mr.SwapType,
 		SwapNonce:     mr.SwapNonce,
+		OldSwapTxs:    mr.OldSwapTxs,
 		Status:        mr.Status,
 		StatusMsg:     mr.Status.String(),
 		Timestamp:     mr.Timestamp,
 		Memo:          mr.Memo,
 		Confirmations: confirmations,
+		RefundTx:      mr.RefundTx,
+		RefundHeight:  mr.RefundHeight,
+		RefundTime:    mr.RefundTime,
+		RefundValue:   mr.RefundValue,
+		RefundFee:     mr.RefundFee,
+		RefundNonce:   mr.RefundNonce,
 	}
 }
 
 // ConvertMgoSwapResultsToSwapInfos convert
-func ConvertMgoSwapResultsToSwapInfos(mrSlice []*mongodb.MgoSwapResult) []*SwapInfo {
+func ConvertMgoSwapResultsToSwapInfos(mrSlice []*storage.SwapResult) []*SwapInfo {
 	result := make([]*SwapInfo, len(mrSlice))
 	for k, v := range mrSlice {
 		result[k] = ConvertMgoSwapResultToSwapInfo(v)
 	}
 	return result
 }
+
+// ConvertSwapEventToSwapEventInfo convert
+func ConvertSwapEventToSwapEventInfo(event *storage.SwapEvent) *SwapEventInfo {
+	info := &SwapEventInfo{
+		TxID:         event.TxID,
+		SwapType:     swapoutType,
+		Target:       "swap",
+		Action:       event.Action,
+		OldStatus:    event.OldStatus,
+		OldStatusMsg: event.OldStatus.String(),
+		Status:       event.NewStatus,
+		StatusMsg:    event.NewStatus.String(),
+		Actor:        event.Actor,
+		Memo:         event.Memo,
+		SwapTx:       event.SwapTx,
+		RefundTx:     event.RefundTx,
+		Timestamp:    event.Timestamp,
+	}
+	if event.IsSwapin {
+		info.SwapType = swapinType
+	}
+	if event.IsResult {
+		info.Target = "result"
+	}
+	if event.Action == storage.SwapEventAdd {
+		info.OldStatusMsg = ""
+	}
+	return info
+}
+
+// ConvertSwapEventsToSwapEventInfos convert
+func ConvertSwapEventsToSwapEventInfos(events []*storage.SwapEvent) []*SwapEventInfo {
+	result := make([]*SwapEventInfo, len(events))
+	for k, v := range events {
+		result[k] = ConvertSwapEventToSwapEventInfo(v)
+	}
+	return result
+}
@@ -0,0 +1,167 @@
+package swapapi
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+)
+
+const (
+	swapinType  = "swapin"
+	swapoutType = "swapout"
+
+	sortOrderAsc  = "asc"
+	sortOrderDesc = "desc"
+
+	defHistoryQueryLimit = 20
+	maxHistoryQueryLimit = 100
+)
+
+var errInvalidCursor = newRPCError(-32092, "invalid cursor")
+
+func newInvalidQueryError(format string, args ...interface{}) error {
+	return newRPCError(-32091, "invalid query: "+fmt.Sprintf(format, args...))
+}
+
+// historyCursor is bound to the query sorting
+type historyCursor struct {
+	SwapType string `json:"t,omitempty"`
+	SortBy   string `json:"s"`
+	Order    string `json:"o"`
+	Value    int64  `json:"v"`
+	Key      string `json:"k"`
+}
+
+func encodeHistoryCursor(cursor *historyCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeHistoryCursor(cursorStr string) (*historyCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursorStr)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var cursor historyCursor
+	if err = json.Unmarshal(data, &cursor); err != nil {
+		return nil, errInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// QuerySwapHistory query swap history with filters, sorting and cursor pagination
+func QuerySwapHistory(args *SwapHistoryQuery) (*SwapHistoryResult, error) {
+	log.Debug("[api] receive QuerySwapHistory", "args", args)
+	query, withSwapin, withSwapout, err := convertSwapHistoryQuery(args)
+	if err != nil {
+		return nil, err
+	}
+	result, next, err := storage.QuerySwapResults(query, withSwapin, withSwapout)
+	if err != nil {
+		return nil, err
+	}
+	res := &SwapHistoryResult{Swaps: ConvertMgoSwapResultsToSwapInfos(result)}
+	if next != nil {
+		res.NextCursor = encodeHistoryCursor(&historyCursor{
+			SwapType: args.SwapType,
+			SortBy:   query.SortBy,
+			Order:    args.Order,
+			Value:    next.Value,
+			Key:      next.Key,
+		})
+	}
+	return res, nil
+}
+
+func convertSwapHistoryQuery(args *SwapHistoryQuery) (query *storage.SwapResultQuery, withSwapin, withSwapout bool, err error) {
+	switch args.SwapType {
+	case "":
+		withSwapin, withSwapout = true, true
+	case swapinType:
+		withSwapin = true
+	case swapoutType:
+		withSwapout = true
+	default:
+		return nil, false, false, newInvalidQueryError("unknown swap type '%v'", args.SwapType)
+	}
+	switch args.Order {
+	case "":
+		args.Order = sortOrderDesc
+	case sortOrderAsc, sortOrderDesc:
+	default:
+		return nil, false, false, newInvalidQueryError("unknown sort order '%v'", args.Order)
+	}
+	if args.SortBy == "" {
+		args.SortBy = storage.SortByTimestamp
+	}
+	if !storage.IsValidSortField(args.SortBy) {
+		return nil, false, false, newInvalidQueryError("unknown sort field '%v'", args.SortBy)
+	}
+	switch {
+	case args.Limit < 0:
+		return nil, false, false, newInvalidQueryError("negative limit")
+	case args.Limit == 0:
+		args.Limit = defHistoryQueryLimit
+	case args.Limit > maxHistoryQueryLimit:
+		args.Limit = maxHistoryQueryLimit
+	}
+	query = &storage.SwapResultQuery{
+		PairID:    args.PairID,
+		From:      args.From,
+		To:        args.To,
+		Bind:      args.Bind,
+		SwapTx:    args.SwapTx,
+		StartTime: args.StartTime,
+		EndTime:   args.EndTime,
+		SortBy:    args.SortBy,
+		SortAsc:   args.Order == sortOrderAsc,
+		Limit:     args.Limit,
+	}
+	for _, s := range args.Statuses {
+		status, errp := parseSwapStatus(s)
+		if errp != nil {
+			return nil, false, false, newInvalidQueryError("%v", errp)
+		}
+		query.Statuses = append(query.Statuses, status)
+	}
+	if query.MinValue, err = parseHistoryValue(args.MinValue); err != nil {
+		return nil, false, false, err
+	}
+	if query.MaxValue, err = parseHistoryValue(args.MaxValue); err != nil {
+		return nil, false, false, err
+	}
+	if args.Cursor != "" {
+		cursor, errc := decodeHistoryCursor(args.Cursor)
+		if errc != nil {
+			return nil, false, false, errc
+		}
+		if cursor.SwapType != args.SwapType || cursor.SortBy != args.SortBy || cursor.Order != args.Order {
+			return nil, false, false, newInvalidQueryError("cursor does not match swap type or sorting")
+		}
+		query.After = &storage.SwapResultCursor{Value: cursor.Value, Key: cursor.Key}
+	}
+	return query, withSwapin, withSwapout, nil
+}
+
+func parseSwapStatus(s string) (storage.SwapStatus, error) {
+	if num, err := strconv.ParseUint(s, 10, 16); err == nil {
+		return storage.SwapStatus(num), nil
+	}
+	return storage.ParseSwapStatus(s)
+}
+
+func parseHistoryValue(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	value, ok := new(big.Int).SetString(s, 10)
+	if !ok || value.Sign() < 0 {
+		return nil, newInvalidQueryError("wrong value '%v'", s)
+	}
+	return value, nil
+}
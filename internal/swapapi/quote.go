@@ -0,0 +1,63 @@
+package swapapi
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
+)
+
+var errInvalidQuoteValue = newRPCError(-32090, "invalid quote value")
+
+// GetQuote api, preview fee and received value of swap
+func GetQuote(args *SwapQuoteArgs) (*SwapQuote, error) {
+	log.Debug("[api] receive GetQuote", "args", args)
+	var isSwapin bool
+	switch args.SwapType {
+	case swapinType:
+		isSwapin = true
+	case swapoutType:
+		isSwapin = false
+	default:
+		return nil, newInvalidQueryError("unknown swap type '%v'", args.SwapType)
+	}
+	pair := tokens.GetTokenPair(args.PairID)
+	if pair == nil {
+		return nil, errUnknownPairID
+	}
+	pairID := pair.PairID
+	value, ok := new(big.Int).SetString(args.Value, 10)
+	if !ok || value.Sign() < 0 {
+		return nil, errInvalidQuoteValue
+	}
+
+	// quote as if depositing now, promotions are matched by deposit tx time
+	feeArgs, err := tools.GetSwapFeeArgs(args.Account, uint64(time.Now().Unix()), isSwapin)
+	if err != nil {
+		return nil, newRPCInternalError(err)
+	}
+
+	// swapin is verified on source chain, swapout is verified on destination chain
+	fromToken := tokens.GetTokenConfig(pairID, isSwapin)
+	minSwap, maxSwap := tokens.GetSwapValueRange(pairID, isSwapin)
+	bigValueThreshold := tokens.GetBigValueThreshold(pairID, isSwapin)
+
+	return &SwapQuote{
+		PairID:            pairID,
+		SwapType:          args.SwapType,
+		Value:             value.String(),
+		SwapValue:         tokens.CalcSwapValue(pairID, value, isSwapin, feeArgs).String(),
+		SwapFee:           tokens.CalcSwapFee(pairID, value, isSwapin, feeArgs).String(),
+		FeeDiscount:       tokens.GetFeeDiscount(pairID, isSwapin, feeArgs).RatString(),
+		MinimumSwap:       minSwap.String(),
+		MaximumSwap:       maxSwap.String(),
+		IsValidValue:      tokens.CheckSwapValue(pairID, value, isSwapin),
+		BigValueThreshold: bigValueThreshold.String(),
+		IsBigValue:        value.Cmp(bigValueThreshold) > 0,
+		Confirmations:     *fromToken.Confirmations,
+		DepositDisabled:   tokens.IsSwapDisabled(pairID, true),
+		WithdrawDisabled:  tokens.IsSwapDisabled(pairID, false),
+	}, nil
+}
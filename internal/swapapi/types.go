@@ -1,34 +1,55 @@
 package swapapi
 
 import (
-	"github.com/anyswap/CrossChain-Bridge/mongodb"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 )
 
 // SwapStatus type alias
-type SwapStatus = mongodb.SwapStatus
+type SwapStatus = storage.SwapStatus
 
 // Swap type alias
-type Swap = mongodb.MgoSwap
+type Swap = storage.Swap
 
 // SwapResult type alias
-type SwapResult = mongodb.MgoSwapResult
+type SwapResult = storage.SwapResult
 
 // SwapStatistics type alias
-type SwapStatistics = mongodb.SwapStatistics
+type SwapStatistics = storage.SwapStatistics
 
 // LatestScanInfo type alias
-type LatestScanInfo = mongodb.MgoLatestScanInfo
+type LatestScanInfo = storage.LatestScanInfo
 
 // RegisteredAddress type alias
-type RegisteredAddress = mongodb.MgoRegisteredAddress
+type RegisteredAddress = storage.RegisteredAddress
+
+// FeeOverride type alias
+type FeeOverride = storage.FeeOverride
 
 // ServerInfo server info
 type ServerInfo struct {
 	Identifier string
 	SrcToken   *tokens.TokenConfig
 	DestToken  *tokens.TokenConfig
+	PairIDs    []string
 	Version    string
+	Leader     *LeaderInfo `json:",omitempty"`
+}
+
+// LeaderInfo leadership of swap servers (if high availability is enabled)
+type LeaderInfo struct {
+	Instance string // id of this instance
+	IsLeader bool   // is this instance the leader
+	Leader   string // id of the instance holding the leader lease
+	Token    uint64 // fencing token of the leader lease
+	Expires  int64  // expire time of the leader lease
+}
+
+// TokenPairInfo token pair info
+type TokenPairInfo struct {
+	PairID    string
+	SrcToken  *tokens.TokenConfig
+	DestToken *tokens.TokenConfig
 }
 
 // PostResult post result
@@ -39,6 +60,7 @@ var SuccessPostResult PostResult = "Success"
 
 // SwapInfo swap info
 type SwapInfo struct {
+	PairID        string     `json:"pairid"`
 	TxID          string     `json:"txid"`
 	TxHeight      uint64     `json:"txheight"`
 	TxTime        uint64     `json:"txtime"`
@@ -50,11 +72,88 @@ type SwapInfo struct {
 	SwapHeight    uint64     `json:"swapheight"`
 	SwapTime      uint64     `json:"swaptime"`
 	SwapValue     string     `json:"swapvalue"`
+	SwapFee       string     `json:"swapfee,omitempty"`
 	SwapType      uint32     `json:"swaptype"`
 	SwapNonce     uint64     `json:"swapnonce"`
+	OldSwapTxs    []string   `json:"oldswaptxs,omitempty"`
 	Status        SwapStatus `json:"status"`
 	StatusMsg     string     `json:"statusmsg"`
 	Timestamp     int64      `json:"timestamp"`
 	Memo          string     `json:"memo"`
 	Confirmations uint64     `json:"confirmations"`
+	RefundTx      string     `json:"refundtx,omitempty"`
+	RefundHeight  uint64     `json:"refundheight,omitempty"`
+	RefundTime    uint64     `json:"refundtime,omitempty"`
+	RefundValue   string     `json:"refundvalue,omitempty"`
+	RefundFee     string     `json:"refundfee,omitempty"`
+	RefundNonce   uint64     `json:"refundnonce,omitempty"`
+}
+
+// SwapEventInfo swap event (a status transition of swap or swap result)
+type SwapEventInfo struct {
+	TxID         string     `json:"txid"`
+	SwapType     string     `json:"swaptype"` // swapin or swapout
+	Target       string     `json:"target"`   // swap or result
+	Action       string     `json:"action"`   // add or update
+	OldStatus    SwapStatus `json:"oldstatus"`
+	OldStatusMsg string     `json:"oldstatusmsg"`
+	Status       SwapStatus `json:"status"`
+	StatusMsg    string     `json:"statusmsg"`
+	Actor        string     `json:"actor"`
+	Memo         string     `json:"memo"`
+	SwapTx       string     `json:"swaptx,omitempty"`
+	RefundTx     string     `json:"refundtx,omitempty"`
+	Timestamp    int64      `json:"timestamp"`
+}
+
+// SwapHistoryQuery swap history query args, empty filter matches any
+type SwapHistoryQuery struct {
+	SwapType  string   `json:"swaptype"` // swapin, swapout or empty (both)
+	Statuses  []string `json:"status"`   // status names or numbers
+	PairID    string   `json:"pairid"`
+	From      string   `json:"from"`
+	To        string   `json:"to"`
+	Bind      string   `json:"bind"`
+	SwapTx    string   `json:"swaptx"`
+	StartTime int64    `json:"starttime"` // timestamp >= starttime
+	EndTime   int64    `json:"endtime"`   // timestamp < endtime
+	MinValue  string   `json:"minvalue"`  // value >= minvalue
+	MaxValue  string   `json:"maxvalue"`  // value <= maxvalue
+	SortBy    string   `json:"sortby"`    // timestamp (default), txtime, txheight or swapheight
+	Order     string   `json:"order"`     // desc (default) or asc
+	Cursor    string   `json:"cursor"`    // nextcursor of the previous page
+	Limit     int      `json:"limit"`     // default 20, max 100
+}
+
+// SwapHistoryResult swap history query result
+type SwapHistoryResult struct {
+	Swaps      []*SwapInfo `json:"swaps"`
+	NextCursor string      `json:"nextcursor,omitempty"` // empty if no more results
+}
+
+// SwapQuoteArgs swap quote args
+type SwapQuoteArgs struct {
+	PairID   string `json:"pairid"`   // empty means the default token pair
+	SwapType string `json:"swaptype"` // swapin or swapout
+	Value    string `json:"value"`    // in decimals of the sending chain
+	Account  string `json:"account"`  // bind address, to apply its fee discount
+}
+
+// SwapQuote preview of swap before depositing,
+// values are in decimals of the sending chain except swapvalue.
+type SwapQuote struct {
+	PairID            string `json:"pairid"`
+	SwapType          string `json:"swaptype"`
+	Value             string `json:"value"`
+	SwapValue         string `json:"swapvalue"` // received value, in decimals of the receiving chain
+	SwapFee           string `json:"swapfee"`
+	FeeDiscount       string `json:"feediscount"` // account discount or promotion applied to swapfee
+	MinimumSwap       string `json:"minimumswap"`
+	MaximumSwap       string `json:"maximumswap"`
+	IsValidValue      bool   `json:"isvalidvalue"` // in swap range and swap value is positive
+	BigValueThreshold string `json:"bigvaluethreshold"`
+	IsBigValue        bool   `json:"isbigvalue"` // needs manual review
+	Confirmations     uint64 `json:"confirmations"`
+	DepositDisabled   bool   `json:"depositdisabled"`  // deposit (swapin) is under maintenance
+	WithdrawDisabled  bool   `json:"withdrawdisabled"` // withdraw (swapout) is under maintenance
 }
@@ -0,0 +1,223 @@
+// Package workqueue provides an in-memory delayed work queue
+// with per-queue retry schedule and visibility timeout.
+package workqueue
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Task queued task, tasks are deduplicated by key
+type Task struct {
+	Key      string
+	Value    interface{}
+	Attempts int // times the task is received, including this time
+}
+
+type item struct {
+	task     Task
+	due      time.Time // visible time, or visibility deadline if in flight
+	inflight bool
+	dirty    bool // added again while in flight
+	index    int
+}
+
+// Queue delayed work queue. a received task is invisible to other consumers
+// until it is done, retried, or its visibility timeout expires.
+type Queue struct {
+	name              string
+	visibilityTimeout time.Duration
+	retrySchedule     []time.Duration
+
+	lock   sync.Mutex
+	items  map[string]*item
+	heap   itemHeap
+	wakeup chan struct{}
+}
+
+// New new queue, the nth retry of a task is delayed by retrySchedule[n-1],
+// and by the last one if n is larger than the length of the schedule.
+func New(name string, visibilityTimeout time.Duration, retrySchedule ...time.Duration) *Queue {
+	return &Queue{
+		name:              name,
+		visibilityTimeout: visibilityTimeout,
+		retrySchedule:     retrySchedule,
+		items:             make(map[string]*item),
+		wakeup:            make(chan struct{}, 1),
+	}
+}
+
+// Name get queue name
+func (q *Queue) Name() string {
+	return q.name
+}
+
+// Len get count of tasks in queue (including in flight ones)
+func (q *Queue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.items)
+}
+
+// Add add task to be visible after delay, if the task exists,
+// it is made visible earlier (or processed again after done if it is in flight).
+func (q *Queue) Add(key string, value interface{}, delay time.Duration) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	due := time.Now().Add(delay)
+	if it, exist := q.items[key]; exist {
+		it.task.Value = value
+		if it.inflight {
+			it.dirty = true
+		} else if due.Before(it.due) {
+			it.due = due
+			heap.Fix(&q.heap, it.index)
+		}
+	} else {
+		it = &item{task: Task{Key: key, Value: value}, due: due}
+		q.items[key] = it
+		heap.Push(&q.heap, it)
+	}
+	q.notify()
+}
+
+// Get wait and receive the earliest visible task, returns nil if ctx is done
+func (q *Queue) Get(ctx context.Context) *Task {
+	for {
+		q.lock.Lock()
+		wait := time.Hour
+		if len(q.heap) > 0 {
+			it := q.heap[0]
+			now := time.Now()
+			if !it.due.After(now) {
+				it.inflight = true
+				it.task.Attempts++
+				it.due = now.Add(q.visibilityTimeout)
+				heap.Fix(&q.heap, it.index)
+				if !q.heap[0].due.After(now) {
+					q.notify() // wake up another consumer
+				}
+				task := it.task
+				q.lock.Unlock()
+				return &task
+			}
+			wait = it.due.Sub(now)
+		}
+		q.lock.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-q.wakeup:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Done remove the received task, or make it visible again if it is added while in flight
+func (q *Queue) Done(task *Task) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	it := q.getReceived(task)
+	if it == nil {
+		return
+	}
+	if it.dirty {
+		it.task.Attempts = 0
+		q.release(it, time.Now())
+		return
+	}
+	heap.Remove(&q.heap, it.index)
+	delete(q.items, task.Key)
+}
+
+// Retry make the received task visible again after the retry schedule delay
+func (q *Queue) Retry(task *Task) {
+	q.RetryAfter(task, q.getRetryDelay(task.Attempts))
+}
+
+// RetryAfter make the received task visible again after delay
+func (q *Queue) RetryAfter(task *Task, delay time.Duration) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	it := q.getReceived(task)
+	if it == nil {
+		return
+	}
+	due := time.Now()
+	if !it.dirty {
+		due = due.Add(delay)
+	}
+	q.release(it, due)
+}
+
+// getReceived get the in flight item of task, ignore the stale task
+// received before its visibility timeout expired and received by others.
+func (q *Queue) getReceived(task *Task) *item {
+	it, exist := q.items[task.Key]
+	if !exist || !it.inflight || it.task.Attempts != task.Attempts {
+		return nil
+	}
+	return it
+}
+
+func (q *Queue) release(it *item, due time.Time) {
+	it.inflight = false
+	it.dirty = false
+	it.due = due
+	heap.Fix(&q.heap, it.index)
+	q.notify()
+}
+
+func (q *Queue) getRetryDelay(attempts int) time.Duration {
+	if len(q.retrySchedule) == 0 {
+		return 0
+	}
+	if attempts > len(q.retrySchedule) {
+		attempts = len(q.retrySchedule)
+	}
+	if attempts < 1 {
+		attempts = 1
+	}
+	return q.retrySchedule[attempts-1]
+}
+
+func (q *Queue) notify() {
+	select {
+	case q.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// itemHeap min heap of items by due time
+type itemHeap []*item
+
+func (h itemHeap) Len() int { return len(h) }
+
+func (h itemHeap) Less(i, j int) bool { return h[i].due.Before(h[j].due) }
+
+func (h itemHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *itemHeap) Push(x interface{}) {
+	it := x.(*item)
+	it.index = len(*h)
+	*h = append(*h, it)
+}
+
+func (h *itemHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	it := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return it
+}
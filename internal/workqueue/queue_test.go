@@ -0,0 +1,113 @@
+package workqueue
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func getTask(t *testing.T, q *Queue, timeout time.Duration) *Task {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return q.Get(ctx)
+}
+
+func TestDedupe(t *testing.T) {
+	q := New("test", time.Minute)
+	q.Add("tx1", 1, time.Hour)
+	q.Add("tx1", 2, 0) // made visible earlier with the new value
+	q.Add("tx2", 3, time.Hour)
+	if q.Len() != 2 {
+		t.Fatalf("want 2 tasks, got %v", q.Len())
+	}
+	task := getTask(t, q, time.Second)
+	if task == nil || task.Key != "tx1" || task.Value != 2 || task.Attempts != 1 {
+		t.Fatalf("wrong task %+v", task)
+	}
+
+	// added while in flight, it is processed again after done
+	q.Add("tx1", 4, 0)
+	if getTask(t, q, 50*time.Millisecond) != nil {
+		t.Fatal("in flight task is received again")
+	}
+	q.Done(task)
+	task = getTask(t, q, time.Second)
+	if task == nil || task.Key != "tx1" || task.Value != 4 || task.Attempts != 1 {
+		t.Fatalf("wrong dirty task %+v", task)
+	}
+	q.Done(task)
+	if q.Len() != 1 {
+		t.Fatalf("want 1 task, got %v", q.Len())
+	}
+}
+
+func TestRetrySchedule(t *testing.T) {
+	schedule := []time.Duration{10 * time.Millisecond, 200 * time.Millisecond}
+	q := New("test", time.Minute, schedule...)
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, schedule[0]},
+		{1, schedule[0]},
+		{2, schedule[1]},
+		{5, schedule[1]}, // the last one is repeated
+	}
+	for _, test := range tests {
+		if got := q.getRetryDelay(test.attempts); got != test.want {
+			t.Errorf("retry delay of attempts %v, want %v, got %v", test.attempts, test.want, got)
+		}
+	}
+	if delay := New("test", time.Minute).getRetryDelay(3); delay != 0 {
+		t.Errorf("retry delay without schedule, want 0, got %v", delay)
+	}
+
+	q.Add("tx1", nil, 0)
+	task := getTask(t, q, time.Second)
+	q.Retry(task) // first retry after 10ms
+	task = getTask(t, q, time.Second)
+	if task == nil || task.Attempts != 2 {
+		t.Fatalf("wrong retried task %+v", task)
+	}
+	q.Retry(task) // second retry after 200ms
+	if getTask(t, q, 50*time.Millisecond) != nil {
+		t.Fatal("task is received before its retry delay")
+	}
+	task = getTask(t, q, time.Second)
+	if task == nil || task.Attempts != 3 {
+		t.Fatalf("wrong retried task %+v", task)
+	}
+
+	// added while in flight, retry immediately
+	q.Add("tx1", nil, time.Hour)
+	q.RetryAfter(task, time.Hour)
+	if task = getTask(t, q, time.Second); task == nil {
+		t.Fatal("dirty task is not retried immediately")
+	}
+}
+
+func TestVisibilityTimeout(t *testing.T) {
+	q := New("test", 50*time.Millisecond)
+	q.Add("tx1", nil, 0)
+	stale := getTask(t, q, time.Second)
+	if stale == nil {
+		t.Fatal("no task received")
+	}
+	if getTask(t, q, 20*time.Millisecond) != nil {
+		t.Fatal("task is received by others before its visibility timeout")
+	}
+	// the consumer is stuck, the task is received by another consumer
+	task := getTask(t, q, time.Second)
+	if task == nil || task.Attempts != 2 {
+		t.Fatalf("wrong redelivered task %+v", task)
+	}
+	// the stale consumer can not finish the task received by others
+	q.Done(stale)
+	if q.Len() != 1 {
+		t.Fatal("task is done by the stale consumer")
+	}
+	q.Done(task)
+	if q.Len() != 0 {
+		t.Fatal("task is not done")
+	}
+}
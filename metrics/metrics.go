@@ -0,0 +1,204 @@
+// Package metrics provides the prometheus metrics of swapserver and swaporacle.
+package metrics
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "bridge"
+
+var (
+	swapCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "swap_count",
+		Help:      "Count of swaps and swap results in each status.",
+	}, []string{"table", "status"})
+
+	jobLoopDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_loop_duration_seconds",
+		Help:      "Duration of one loop of processing found swaps in worker jobs.",
+		Buckets:   []float64{0.01, 0.1, 0.5, 1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"job"})
+
+	dcrmSignDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "dcrm_sign_duration_seconds",
+		Help:      "Round trip time of dcrm sign requests.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	})
+
+	dcrmSignFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dcrm_sign_failures_total",
+		Help:      "Count of failed dcrm sign requests.",
+	})
+
+	acceptSignCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "accept_sign_total",
+		Help:      "Count of dcrm accept sign by result.",
+	}, []string{"result"})
+
+	gatewayRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gateway_requests_total",
+		Help:      "Count of rpc requests to gateway api address.",
+	}, []string{"gateway"})
+
+	gatewayErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "gateway_errors_total",
+		Help:      "Count of failed rpc requests to gateway api address.",
+	}, []string{"gateway"})
+
+	latestBlockHeight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "latest_block_height",
+		Help:      "Latest block height of the chain of each token pair and endpoint.",
+	}, []string{"pairid", "endpoint"})
+
+	latestScannedHeight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "latest_scanned_height",
+		Help:      "Latest scanned block height of each token pair and endpoint.",
+	}, []string{"pairid", "endpoint"})
+
+	outflowBreakerTrips = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outflow_breaker_trips_total",
+		Help:      "Count of outflow breaker trips which pause swap of token pair and direction.",
+	}, []string{"pairid", "direction"})
+
+	droppedReorgedSwapTxs = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dropped_reorged_swap_txs_total",
+		Help:      "Count of swap txs in orphaned blocks which are never found again (need manual process).",
+	}, []string{"pairid", "direction"})
+)
+
+var (
+	gatewayAddresses     []string
+	gatewayAddressesLock sync.RWMutex
+)
+
+func init() {
+	prometheus.MustRegister(
+		swapCount,
+		jobLoopDuration,
+		dcrmSignDuration,
+		dcrmSignFailures,
+		acceptSignCount,
+		gatewayRequests,
+		gatewayErrors,
+		latestBlockHeight,
+		latestScannedHeight,
+		outflowBreakerTrips,
+		droppedReorgedSwapTxs,
+	)
+}
+
+func getEndpointLabel(isSrc bool) string {
+	if isSrc {
+		return "src"
+	}
+	return "dst"
+}
+
+// SetSwapCount set count of swaps (or swap results) in status
+func SetSwapCount(table, status string, count int) {
+	swapCount.WithLabelValues(table, status).Set(float64(count))
+}
+
+// ObserveJobLoop observe duration of one job loop
+func ObserveJobLoop(job string, start time.Time) {
+	jobLoopDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+}
+
+// ObserveDcrmSign observe dcrm sign round trip time and failure
+func ObserveDcrmSign(start time.Time, err error) {
+	dcrmSignDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		dcrmSignFailures.Inc()
+	}
+}
+
+// IncAcceptSign increase count of accept sign result
+func IncAcceptSign(result string) {
+	acceptSignCount.WithLabelValues(result).Inc()
+}
+
+// SetLatestBlockHeight set latest block height of token pair endpoint
+func SetLatestBlockHeight(pairID string, isSrc bool, height uint64) {
+	latestBlockHeight.WithLabelValues(pairID, getEndpointLabel(isSrc)).Set(float64(height))
+}
+
+// SetLatestScannedHeight set latest scanned height of token pair endpoint
+func SetLatestScannedHeight(pairID string, isSrc bool, height uint64) {
+	latestScannedHeight.WithLabelValues(pairID, getEndpointLabel(isSrc)).Set(float64(height))
+}
+
+// IncOutflowBreakerTrip increase count of outflow breaker trips of token pair and direction
+func IncOutflowBreakerTrip(pairID string, isSwapin bool) {
+	direction := "swapout"
+	if isSwapin {
+		direction = "swapin"
+	}
+	outflowBreakerTrips.WithLabelValues(pairID, direction).Inc()
+}
+
+// IncDroppedReorgedSwapTx increase count of dropped reorged swap txs
+func IncDroppedReorgedSwapTx(pairID string, isSwapin bool) {
+	direction := "swapout"
+	if isSwapin {
+		direction = "swapin"
+	}
+	droppedReorgedSwapTxs.WithLabelValues(pairID, direction).Inc()
+}
+
+// AddGatewayAddresses add gateway api addresses to observe requests to them
+func AddGatewayAddresses(addresses ...string) {
+	gatewayAddressesLock.Lock()
+	defer gatewayAddressesLock.Unlock()
+	for _, address := range addresses {
+		exist := false
+		for _, item := range gatewayAddresses {
+			if item == address {
+				exist = true
+				break
+			}
+		}
+		if !exist {
+			gatewayAddresses = append(gatewayAddresses, address)
+		}
+	}
+}
+
+// getGatewayOfURL get the longest gateway address which url starts with
+func getGatewayOfURL(url string) (gateway string) {
+	gatewayAddressesLock.RLock()
+	defer gatewayAddressesLock.RUnlock()
+	for _, address := range gatewayAddresses {
+		if strings.HasPrefix(url, address) && len(address) > len(gateway) {
+			gateway = address
+		}
+	}
+	return gateway
+}
+
+// ObserveGatewayRequest observe rpc request result to url,
+// requests to url not belonging to any gateway are ignored.
+func ObserveGatewayRequest(url string, err error) {
+	gateway := getGatewayOfURL(url)
+	if gateway == "" {
+		return
+	}
+	gatewayRequests.WithLabelValues(gateway).Inc()
+	if err != nil {
+		gatewayErrors.WithLabelValues(gateway).Inc()
+	}
+}
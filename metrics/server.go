@@ -0,0 +1,45 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var metricsServer *http.Server
+
+// Handler metrics http handler
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// StartMetricsServer start standalone metrics server
+func StartMetricsServer(port int) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+
+	log.Info("metrics service listen and serving", "port", port)
+	metricsServer = &http.Server{
+		Addr:         fmt.Sprintf(":%v", port),
+		ReadTimeout:  60 * time.Second,
+		WriteTimeout: 60 * time.Second,
+		Handler:      mux,
+	}
+	go func() {
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Error("metrics ListenAndServe error", "err", err)
+		}
+	}()
+}
+
+// StopMetricsServer stop standalone metrics server
+func StopMetricsServer(ctx context.Context) error {
+	if metricsServer == nil {
+		return nil
+	}
+	return metricsServer.Shutdown(ctx)
+}
@@ -1,271 +1,168 @@
 package mongodb
 
 import (
-	"math/big"
-	"sync"
-	"time"
-
 	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
 const (
-	maxCountOfResults = 5000
+	maxCountOfResults    = 5000
+	maxReserveNonceTries = 3
 )
 
-var (
-	retryLock sync.Mutex
-)
+// SwapStore mongodb storage backend
+type SwapStore struct{}
 
-// --------------- swapin and swapout uniform --------------------------------
+var _ storage.SwapStore = (*SwapStore)(nil)
 
-// UpdateSwapStatus update swap status
-func UpdateSwapStatus(isSwapin bool, txid string, status SwapStatus, timestamp int64, memo string) error {
-	if isSwapin {
-		return updateSwapStatus(collSwapin, txid, status, timestamp, memo)
-	}
-	return updateSwapStatus(collSwapout, txid, status, timestamp, memo)
+// NewSwapStore new mongodb storage backend, call MongoServerInit before using it
+func NewSwapStore() *SwapStore {
+	return &SwapStore{}
 }
 
-// UpdateSwapResultStatus update swap result status
-func UpdateSwapResultStatus(isSwapin bool, txid string, status SwapStatus, timestamp int64, memo string) error {
+func getSwapCollection(isSwapin bool) *mgo.Collection {
 	if isSwapin {
-		return updateSwapResultStatus(collSwapinResult, txid, status, timestamp, memo)
+		return collSwapin
 	}
-	return updateSwapResultStatus(collSwapoutResult, txid, status, timestamp, memo)
+	return collSwapout
 }
 
-// FindSwapResult find swap result
-func FindSwapResult(isSwapin bool, txid string) (*MgoSwapResult, error) {
+func getSwapResultCollection(isSwapin bool) *mgo.Collection {
 	if isSwapin {
-		return findSwapResult(collSwapinResult, txid)
+		return collSwapinResult
 	}
-	return findSwapResult(collSwapoutResult, txid)
+	return collSwapoutResult
 }
 
-// FindSwap find swap
-func FindSwap(isSwapin bool, txid string) (*MgoSwap, error) {
-	if isSwapin {
-		return findSwap(collSwapin, txid)
+// updateWithStatus update item only if its status is still oldStatus (compare and set),
+// and it is not written by a newer leader (fenced).
+func updateWithStatus(collection *mgo.Collection, txid string, oldStatus storage.SwapStatus, updates bson.M) error {
+	token := getFencingToken()
+	query := bson.M{"_id": txid, "status": oldStatus}
+	fence(token, query, updates)
+	err := collection.Update(query, bson.M{"$set": updates})
+	if err == mgo.ErrNotFound {
+		return fencedMissError(collection, txid, token, storage.ErrStatusMismatch)
 	}
-	return findSwap(collSwapout, txid)
-}
-
-// --------------- swapin --------------------------------
-
-// AddSwapin add swapin
-func AddSwapin(ms *MgoSwap) error {
-	return addSwap(collSwapin, ms)
-}
-
-// UpdateSwapinStatus update swapin status
-func UpdateSwapinStatus(txid string, status SwapStatus, timestamp int64, memo string) error {
-	return updateSwapStatus(collSwapin, txid, status, timestamp, memo)
-}
-
-// FindSwapin find swapin
-func FindSwapin(txid string) (*MgoSwap, error) {
-	return findSwap(collSwapin, txid)
-}
-
-// FindSwapinsWithStatus find swapin with status in the past septime
-func FindSwapinsWithStatus(status SwapStatus, septime int64) ([]*MgoSwap, error) {
-	return findSwapsWithStatus(collSwapin, status, septime)
-}
-
-// GetCountOfSwapinsWithStatus get count of swapins with status
-func GetCountOfSwapinsWithStatus(status SwapStatus) (int, error) {
-	return getCountWithStatus(collSwapin, status)
-}
-
-// --------------- swapout --------------------------------
-
-// AddSwapout add swapout
-func AddSwapout(ms *MgoSwap) error {
-	return addSwap(collSwapout, ms)
-}
-
-// UpdateSwapoutStatus update swapout status
-func UpdateSwapoutStatus(txid string, status SwapStatus, timestamp int64, memo string) error {
-	return updateSwapStatus(collSwapout, txid, status, timestamp, memo)
-}
-
-// FindSwapout find swapout
-func FindSwapout(txid string) (*MgoSwap, error) {
-	return findSwap(collSwapout, txid)
-}
-
-// FindSwapoutsWithStatus find swapout with status
-func FindSwapoutsWithStatus(status SwapStatus, septime int64) ([]*MgoSwap, error) {
-	return findSwapsWithStatus(collSwapout, status, septime)
+	return mgoError(err)
 }
 
-// GetCountOfSwapoutsWithStatus get count of swapout with status
-func GetCountOfSwapoutsWithStatus(status SwapStatus) (int, error) {
-	return getCountWithStatus(collSwapout, status)
+// Close close mongodb session
+func (s *SwapStore) Close() error {
+	MongoServerClose()
+	return nil
 }
 
 // ------------------ swapin / swapout common ------------------------
 
-func addSwap(collection *mgo.Collection, ms *MgoSwap) error {
-	err := collection.Insert(ms)
+// AddSwap add swap
+func (s *SwapStore) AddSwap(isSwapin bool, ms *storage.Swap) error {
+	err := fencedInsert(getSwapCollection(isSwapin), ms)
 	if err == nil {
-		log.Info("mongodb add swap", "txid", ms.TxID, "isSwapin", isSwapin(collection))
+		log.Info("mongodb add swap", "txid", ms.TxID, "isSwapin", isSwapin)
 	} else {
-		log.Debug("mongodb add swap", "txid", ms.TxID, "isSwapin", isSwapin(collection), "err", err)
+		log.Debug("mongodb add swap", "txid", ms.TxID, "isSwapin", isSwapin, "err", err)
 	}
-	return mgoError(err)
+	return err
 }
 
-func updateSwapStatus(collection *mgo.Collection, txid string, status SwapStatus, timestamp int64, memo string) error {
+// UpdateSwapStatus update swap status
+func (s *SwapStore) UpdateSwapStatus(isSwapin bool, txid string, oldStatus, status storage.SwapStatus, timestamp int64, memo string) error {
 	updates := bson.M{"status": status, "timestamp": timestamp}
 	if memo != "" {
 		updates["memo"] = memo
-	} else if status == TxNotSwapped || status == TxNotStable {
+	} else if status == storage.TxNotSwapped || status == storage.TxNotStable {
 		updates["memo"] = ""
 	}
-	if status == TxNotStable {
-		retryLock.Lock()
-		defer retryLock.Unlock()
-		swap, _ := findSwap(collection, txid)
-		if !(swap.Status.CanRetry() || swap.Status.CanReverify()) {
-			return nil
-		}
+	if status != oldStatus && (status == storage.TxNotSwapped || status == storage.TxNotStable) {
+		updates["retrycount"] = 0
+		updates["nextretrytime"] = 0
 	}
-	err := collection.UpdateId(txid, bson.M{"$set": updates})
+	err := updateWithStatus(getSwapCollection(isSwapin), txid, oldStatus, updates)
 	if err == nil {
 		printLog := log.Info
 		switch status {
-		case TxVerifyFailed, TxSwapFailed:
+		case storage.TxVerifyFailed, storage.TxSwapFailed, storage.TxDeadLetter:
 			printLog = log.Warn
 		}
-		printLog("mongodb update swap status", "txid", txid, "status", status, "isSwapin", isSwapin(collection))
+		printLog("mongodb update swap status", "txid", txid, "status", status, "isSwapin", isSwapin)
 	} else {
-		log.Debug("mongodb update swap status", "txid", txid, "status", status, "isSwapin", isSwapin(collection), "err", err)
+		log.Debug("mongodb update swap status", "txid", txid, "status", status, "isSwapin", isSwapin, "err", err)
 	}
-	return mgoError(err)
+	return err
+}
+
+// UpdateSwapRetry update swap retry count and next retry time
+func (s *SwapStore) UpdateSwapRetry(isSwapin bool, txid string, status storage.SwapStatus, retryCount uint64, nextRetryTime int64) error {
+	updates := bson.M{"retrycount": retryCount, "nextretrytime": nextRetryTime}
+	err := updateWithStatus(getSwapCollection(isSwapin), txid, status, updates)
+	if err == nil {
+		log.Info("mongodb update swap retry", "txid", txid, "status", status, "retrycount", retryCount, "nextretrytime", nextRetryTime, "isSwapin", isSwapin)
+	} else {
+		log.Debug("mongodb update swap retry", "txid", txid, "status", status, "isSwapin", isSwapin, "err", err)
+	}
+	return err
 }
 
-func findSwap(collection *mgo.Collection, txid string) (*MgoSwap, error) {
-	var result MgoSwap
-	err := collection.FindId(txid).One(&result)
+// FindSwap find swap
+func (s *SwapStore) FindSwap(isSwapin bool, txid string) (*storage.Swap, error) {
+	var result storage.Swap
+	err := getSwapCollection(isSwapin).FindId(txid).One(&result)
 	if err != nil {
 		return nil, mgoError(err)
 	}
 	return &result, nil
 }
 
-func findSwapsWithStatus(collection *mgo.Collection, status SwapStatus, septime int64) (result []*MgoSwap, err error) {
-	err = findSwapsOrSwapResultsWithStatus(&result, collection, status, septime)
+// FindSwapsWithStatus find swaps with status in the past septime
+func (s *SwapStore) FindSwapsWithStatus(isSwapin bool, status storage.SwapStatus, septime int64) (result []*storage.Swap, err error) {
+	err = findSwapsOrSwapResultsWithStatus(&result, getSwapCollection(isSwapin), status, septime)
 	return result, err
 }
 
-func findSwapsOrSwapResultsWithStatus(result interface{}, collection *mgo.Collection, status SwapStatus, septime int64) error {
-	qtime := bson.M{"timestamp": bson.M{"$gte": septime}}
+// FindExpiredSwapsWithStatus find swaps with status before septime
+func (s *SwapStore) FindExpiredSwapsWithStatus(isSwapin bool, status storage.SwapStatus, septime int64) ([]*storage.Swap, error) {
+	result := make([]*storage.Swap, 0, 20)
+	qtime := bson.M{"timestamp": bson.M{"$lt": septime}}
 	qstatus := bson.M{"status": status}
 	queries := []bson.M{qtime, qstatus}
-	q := collection.Find(bson.M{"$and": queries}).Sort("timestamp").Limit(maxCountOfResults)
-	return mgoError(q.All(result))
-}
-
-// --------------- swapin result --------------------------------
-
-// AddSwapinResult add swapin result
-func AddSwapinResult(mr *MgoSwapResult) error {
-	return addSwapResult(collSwapinResult, mr)
-}
-
-// UpdateSwapinResult update swapin result
-func UpdateSwapinResult(txid string, items *SwapResultUpdateItems) error {
-	return updateSwapResult(collSwapinResult, txid, items)
-}
-
-// UpdateSwapinResultStatus update swapin result status
-func UpdateSwapinResultStatus(txid string, status SwapStatus, timestamp int64, memo string) error {
-	return updateSwapResultStatus(collSwapinResult, txid, status, timestamp, memo)
-}
-
-// FindSwapinResult find swapin result
-func FindSwapinResult(txid string) (*MgoSwapResult, error) {
-	return findSwapResult(collSwapinResult, txid)
-}
-
-// FindSwapinResultsWithStatus find swapin result with status
-func FindSwapinResultsWithStatus(status SwapStatus, septime int64) ([]*MgoSwapResult, error) {
-	return findSwapResultsWithStatus(collSwapinResult, status, septime)
-}
-
-// FindSwapinResults find swapin history results
-func FindSwapinResults(address string, offset, limit int) ([]*MgoSwapResult, error) {
-	return findSwapResults(collSwapinResult, address, offset, limit)
-}
-
-// GetCountOfSwapinResults get count of swapin results
-func GetCountOfSwapinResults() (int, error) {
-	return getCount(collSwapinResult)
-}
-
-// GetCountOfSwapinResultsWithStatus get count of swapin results with status
-func GetCountOfSwapinResultsWithStatus(status SwapStatus) (int, error) {
-	return getCountWithStatus(collSwapinResult, status)
-}
-
-// --------------- swapout result --------------------------------
-
-// AddSwapoutResult add swapout result
-func AddSwapoutResult(mr *MgoSwapResult) error {
-	return addSwapResult(collSwapoutResult, mr)
-}
-
-// UpdateSwapoutResult update swapout result
-func UpdateSwapoutResult(txid string, items *SwapResultUpdateItems) error {
-	return updateSwapResult(collSwapoutResult, txid, items)
-}
-
-// UpdateSwapoutResultStatus update swapout result status
-func UpdateSwapoutResultStatus(txid string, status SwapStatus, timestamp int64, memo string) error {
-	return updateSwapResultStatus(collSwapoutResult, txid, status, timestamp, memo)
-}
-
-// FindSwapoutResult find swapout result
-func FindSwapoutResult(txid string) (*MgoSwapResult, error) {
-	return findSwapResult(collSwapoutResult, txid)
-}
-
-// FindSwapoutResultsWithStatus find swapout result with status
-func FindSwapoutResultsWithStatus(status SwapStatus, septime int64) ([]*MgoSwapResult, error) {
-	return findSwapResultsWithStatus(collSwapoutResult, status, septime)
-}
-
-// FindSwapoutResults find swapout history results
-func FindSwapoutResults(address string, offset, limit int) ([]*MgoSwapResult, error) {
-	return findSwapResults(collSwapoutResult, address, offset, limit)
+	err := getSwapCollection(isSwapin).Find(bson.M{"$and": queries}).Sort("timestamp").Limit(maxCountOfResults).All(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return result, nil
 }
 
-// GetCountOfSwapoutResults get count of swapout results
-func GetCountOfSwapoutResults() (int, error) {
-	return getCount(collSwapoutResult)
+// GetCountOfSwapsWithStatus get count of swaps with status
+func (s *SwapStore) GetCountOfSwapsWithStatus(isSwapin bool, status storage.SwapStatus) (int, error) {
+	return getCountWithStatus(getSwapCollection(isSwapin), status)
 }
 
-// GetCountOfSwapoutResultsWithStatus get count of swapout results with status
-func GetCountOfSwapoutResultsWithStatus(status SwapStatus) (int, error) {
-	return getCountWithStatus(collSwapoutResult, status)
+func findSwapsOrSwapResultsWithStatus(result interface{}, collection *mgo.Collection, status storage.SwapStatus, septime int64) error {
+	qtime := bson.M{"timestamp": bson.M{"$gte": septime}}
+	qstatus := bson.M{"status": status}
+	queries := []bson.M{qtime, qstatus}
+	q := collection.Find(bson.M{"$and": queries}).Sort("timestamp").Limit(maxCountOfResults)
+	return mgoError(q.All(result))
 }
 
 // ------------------ swapin / swapout result common ------------------------
 
-func addSwapResult(collection *mgo.Collection, ms *MgoSwapResult) error {
-	err := collection.Insert(ms)
+// AddSwapResult add swap result
+func (s *SwapStore) AddSwapResult(isSwapin bool, ms *storage.SwapResult) error {
+	err := fencedInsert(getSwapResultCollection(isSwapin), ms)
 	if err == nil {
-		log.Info("mongodb add swap result", "txid", ms.TxID, "swaptype", ms.SwapType, "isSwapin", isSwapin(collection))
+		log.Info("mongodb add swap result", "txid", ms.TxID, "swaptype", ms.SwapType, "isSwapin", isSwapin)
 	} else {
-		log.Debug("mongodb add swap result", "txid", ms.TxID, "swaptype", ms.SwapType, "isSwapin", isSwapin(collection), "err", err)
+		log.Debug("mongodb add swap result", "txid", ms.TxID, "swaptype", ms.SwapType, "isSwapin", isSwapin, "err", err)
 	}
-	return mgoError(err)
+	return err
 }
 
-func updateSwapResult(collection *mgo.Collection, txid string, items *SwapResultUpdateItems) error {
+// UpdateSwapResult update swap result
+func (s *SwapStore) UpdateSwapResult(isSwapin bool, txid string, oldStatus storage.SwapStatus, items *storage.SwapResultUpdateItems) error {
 	updates := bson.M{
 		"status":    items.Status,
 		"timestamp": items.Timestamp,
@@ -282,65 +179,88 @@ func updateSwapResult(collection *mgo.Collection, txid string, items *SwapResult
 	if items.SwapValue != "" {
 		updates["swapvalue"] = items.SwapValue
 	}
+	if items.SwapFee != "" {
+		updates["swapfee"] = items.SwapFee
+	}
 	if items.SwapType != 0 {
 		updates["swaptype"] = items.SwapType
 	}
 	if items.SwapNonce != 0 {
 		updates["swapnonce"] = items.SwapNonce
 	}
+	if len(items.OldSwapTxs) != 0 {
+		updates["oldswaptxs"] = items.OldSwapTxs
+	}
+	if items.RefundTx != "" {
+		updates["refundtx"] = items.RefundTx
+		updates["refundvalue"] = items.RefundValue
+		updates["refundfee"] = items.RefundFee
+		updates["refundnonce"] = items.RefundNonce
+	}
+	if items.RefundHeight != 0 {
+		updates["refundheight"] = items.RefundHeight
+	}
+	if items.RefundTime != 0 {
+		updates["refundtime"] = items.RefundTime
+	}
 	if items.Memo != "" {
 		updates["memo"] = items.Memo
-	} else if items.Status == MatchTxNotStable {
+	} else if items.Status == storage.MatchTxNotStable {
 		updates["memo"] = ""
 	}
-	err := collection.UpdateId(txid, bson.M{"$set": updates})
+	err := updateWithStatus(getSwapResultCollection(isSwapin), txid, oldStatus, updates)
 	if err == nil {
-		log.Info("mongodb update swap result", "txid", txid, "updates", updates, "isSwapin", isSwapin(collection))
+		log.Info("mongodb update swap result", "txid", txid, "updates", updates, "isSwapin", isSwapin)
 	} else {
-		log.Debug("mongodb update swap result", "txid", txid, "updates", updates, "isSwapin", isSwapin(collection), "err", err)
+		log.Debug("mongodb update swap result", "txid", txid, "updates", updates, "isSwapin", isSwapin, "err", err)
 	}
-	return mgoError(err)
+	return err
 }
 
-func updateSwapResultStatus(collection *mgo.Collection, txid string, status SwapStatus, timestamp int64, memo string) error {
+// UpdateSwapResultStatus update swap result status
+func (s *SwapStore) UpdateSwapResultStatus(isSwapin bool, txid string, oldStatus, status storage.SwapStatus, timestamp int64, memo string) error {
 	updates := bson.M{"status": status, "timestamp": timestamp}
 	if memo != "" {
 		updates["memo"] = memo
-	} else if status == MatchTxEmpty {
+	} else if status == storage.MatchTxEmpty {
+		// the cleared swap txs are kept in swap events and the journal
 		updates["memo"] = ""
 		updates["swaptx"] = ""
+		updates["oldswaptxs"] = []string{}
 	}
-	err := collection.UpdateId(txid, bson.M{"$set": updates})
-	isSwapin := isSwapin(collection)
+	if status == storage.MatchTxReorged {
+		updates["swapheight"] = 0
+		updates["swaptime"] = 0
+	}
+	err := updateWithStatus(getSwapResultCollection(isSwapin), txid, oldStatus, updates)
 	if err == nil {
 		log.Info("mongodb update swap result status", "txid", txid, "status", status, "isSwapin", isSwapin)
 	} else {
 		log.Debug("mongodb update swap result status", "txid", txid, "status", status, "isSwapin", isSwapin, "err", err)
 	}
-	if status == MatchTxStable {
-		if swapResult, errq := findSwapResult(collection, txid); errq == nil {
-			_ = UpdateSwapStatistics(swapResult.Value, swapResult.SwapValue, isSwapin)
-		}
-	}
-	return mgoError(err)
+	return err
 }
 
-func findSwapResult(collection *mgo.Collection, txid string) (*MgoSwapResult, error) {
-	var result MgoSwapResult
-	err := collection.FindId(txid).One(&result)
+// FindSwapResult find swap result
+func (s *SwapStore) FindSwapResult(isSwapin bool, txid string) (*storage.SwapResult, error) {
+	var result storage.SwapResult
+	err := getSwapResultCollection(isSwapin).FindId(txid).One(&result)
 	if err != nil {
 		return nil, mgoError(err)
 	}
 	return &result, nil
 }
 
-func findSwapResultsWithStatus(collection *mgo.Collection, status SwapStatus, septime int64) (result []*MgoSwapResult, err error) {
-	err = findSwapsOrSwapResultsWithStatus(&result, collection, status, septime)
+// FindSwapResultsWithStatus find swap results with status in the past septime
+func (s *SwapStore) FindSwapResultsWithStatus(isSwapin bool, status storage.SwapStatus, septime int64) (result []*storage.SwapResult, err error) {
+	err = findSwapsOrSwapResultsWithStatus(&result, getSwapResultCollection(isSwapin), status, septime)
 	return result, err
 }
 
-func findSwapResults(collection *mgo.Collection, address string, offset, limit int) ([]*MgoSwapResult, error) {
-	result := make([]*MgoSwapResult, 0, 20)
+// FindSwapResults find swap history results
+func (s *SwapStore) FindSwapResults(isSwapin bool, address string, offset, limit int) ([]*storage.SwapResult, error) {
+	collection := getSwapResultCollection(isSwapin)
+	result := make([]*storage.SwapResult, 0, 20)
 	var q *mgo.Query
 	if address == "all" {
 		q = collection.Find(nil)
@@ -359,105 +279,116 @@ func findSwapResults(collection *mgo.Collection, address string, offset, limit i
 	return result, nil
 }
 
-func getCount(collection *mgo.Collection) (int, error) {
-	return collection.Find(nil).Count()
-}
-
-func getCountWithStatus(collection *mgo.Collection, status SwapStatus) (int, error) {
-	return collection.Find(bson.M{"status": status}).Count()
+// QuerySwapResults query swap results
+func (s *SwapStore) QuerySwapResults(isSwapin bool, query *storage.SwapResultQuery) ([]*storage.SwapResult, error) {
+	conds := getSwapResultQueryConditions(query)
+	sortField, sortKey := query.SortBy, "_id"
+	if !query.SortAsc {
+		sortField, sortKey = "-"+sortField, "-"+sortKey
+	}
+	result := make([]*storage.SwapResult, 0, query.Limit)
+	q := getSwapResultCollection(isSwapin).Find(conds).Sort(sortField, sortKey)
+	if query.Limit > 0 {
+		q = q.Limit(query.Limit)
+	}
+	err := q.All(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return result, nil
 }
 
-// ------------------ statistics ------------------------
-
-// UpdateSwapStatistics update swap statistics
-func UpdateSwapStatistics(value, swapValue string, isSwapin bool) error {
-	curr, err := FindSwapStatistics()
-	if err != nil {
-		curr = &MgoSwapStatistics{
-			Key: keyOfSwapStatistics,
+func getSwapResultQueryConditions(query *storage.SwapResultQuery) bson.M {
+	conds := make([]bson.M, 0, 8)
+	if len(query.Statuses) != 0 {
+		conds = append(conds, bson.M{"status": bson.M{"$in": query.Statuses}})
+	}
+	addEqualCondition := func(key, value string) {
+		if value != "" {
+			conds = append(conds, bson.M{key: value})
 		}
 	}
-
-	addVal, _ := new(big.Int).SetString(value, 0)
-	addSwapVal, _ := new(big.Int).SetString(swapValue, 0)
-	addSwapFee := new(big.Int).Sub(addVal, addSwapVal)
-
-	curVal := big.NewInt(0)
-	curFee := big.NewInt(0)
-
-	updates := bson.M{}
-	if isSwapin {
-		curVal.SetString(curr.TotalSwapinValue, 0)
-		curFee.SetString(curr.TotalSwapinFee, 0)
-		curVal.Add(curVal, addSwapVal)
-		curFee.Add(curFee, addSwapFee)
-		updates["swapincount"] = curr.StableSwapinCount + 1
-		updates["totalswapinvalue"] = curVal.String()
-		updates["totalswapinfee"] = curFee.String()
-	} else {
-		curVal.SetString(curr.TotalSwapoutValue, 0)
-		curFee.SetString(curr.TotalSwapoutFee, 0)
-		curVal.Add(curVal, addSwapVal)
-		curFee.Add(curFee, addSwapFee)
-		updates["swapoutcount"] = curr.StableSwapoutCount + 1
-		updates["totalswapoutvalue"] = curVal.String()
-		updates["totalswapoutfee"] = curFee.String()
-	}
-	err = collSwapStatistics.UpdateId(keyOfSwapStatistics, bson.M{"$set": updates})
-	if err == nil {
-		log.Info("mongodb update swap statistics", "updates", updates)
-	} else {
-		log.Debug("mongodb update swap statistics", "updates", updates, "err", err)
+	addEqualCondition("pairid", query.PairID)
+	addEqualCondition("from", query.From)
+	addEqualCondition("to", query.To)
+	addEqualCondition("bind", query.Bind)
+	addEqualCondition("swaptx", query.SwapTx)
+	if query.StartTime != 0 {
+		conds = append(conds, bson.M{"timestamp": bson.M{"$gte": query.StartTime}})
+	}
+	if query.EndTime != 0 {
+		conds = append(conds, bson.M{"timestamp": bson.M{"$lt": query.EndTime}})
+	}
+	// compare the fixed width value key instead of the decimal string value
+	if query.MinValue != nil || query.MaxValue != nil {
+		minKey, maxKey := query.GetValueKeyRange()
+		valueCond := bson.M{"$gte": minKey}
+		if maxKey != "" {
+			valueCond["$lte"] = maxKey
+		}
+		conds = append(conds, bson.M{"valuekey": valueCond})
+	}
+	if query.After != nil {
+		cmpOp := "$lt"
+		if query.SortAsc {
+			cmpOp = "$gt"
+		}
+		conds = append(conds, bson.M{"$or": []bson.M{
+			{query.SortBy: bson.M{cmpOp: query.After.Value}},
+			{query.SortBy: query.After.Value, "_id": bson.M{cmpOp: query.After.Key}},
+		}})
+	}
+	switch len(conds) {
+	case 0:
+		return nil
+	case 1:
+		return conds[0]
+	default:
+		return bson.M{"$and": conds}
 	}
-	return mgoError(err)
 }
 
-// FindSwapStatistics find swap statistics
-func FindSwapStatistics() (*MgoSwapStatistics, error) {
-	var result MgoSwapStatistics
-	err := collSwapStatistics.FindId(keyOfSwapStatistics).One(&result)
-	return &result, mgoError(err)
+// GetCountOfSwapResults get count of swap results
+func (s *SwapStore) GetCountOfSwapResults(isSwapin bool) (int, error) {
+	return getSwapResultCollection(isSwapin).Find(nil).Count()
 }
 
-// SwapStatistics rpc return struct
-type SwapStatistics struct {
-	TotalSwapinCount    int
-	TotalSwapoutCount   int
-	PendingSwapinCount  int
-	PendingSwapoutCount int
-	StableSwapinCount   int
-	TotalSwapinValue    string
-	TotalSwapinFee      string
-	StableSwapoutCount  int
-	TotalSwapoutValue   string
-	TotalSwapoutFee     string
+// GetCountOfSwapResultsWithStatus get count of swap results with status
+func (s *SwapStore) GetCountOfSwapResultsWithStatus(isSwapin bool, status storage.SwapStatus) (int, error) {
+	return getCountWithStatus(getSwapResultCollection(isSwapin), status)
 }
 
-// GetSwapStatistics get swap statistics
-func GetSwapStatistics() (*SwapStatistics, error) {
-	stat := &SwapStatistics{}
+func getCountWithStatus(collection *mgo.Collection, status storage.SwapStatus) (int, error) {
+	return collection.Find(bson.M{"status": status}).Count()
+}
 
-	if curr, _ := FindSwapStatistics(); curr != nil {
-		stat.StableSwapinCount = curr.StableSwapinCount
-		stat.TotalSwapinValue = curr.TotalSwapinValue
-		stat.TotalSwapinFee = curr.TotalSwapinFee
-		stat.StableSwapoutCount = curr.StableSwapoutCount
-		stat.TotalSwapoutValue = curr.TotalSwapoutValue
-		stat.TotalSwapoutFee = curr.TotalSwapoutFee
-	}
+// ------------------ statistics ------------------------
 
-	stat.TotalSwapinCount, _ = GetCountOfSwapinResults()
-	stat.TotalSwapoutCount, _ = GetCountOfSwapoutResults()
-	stat.PendingSwapinCount, _ = GetCountOfSwapinResultsWithStatus(MatchTxEmpty)
-	stat.PendingSwapoutCount, _ = GetCountOfSwapoutResultsWithStatus(MatchTxEmpty)
+// UpdateSwapSummary update swap summary
+func (s *SwapStore) UpdateSwapSummary(summary *storage.SwapSummary) error {
+	err := fencedUpsertID(collSwapStatistics, summary.Key, summary)
+	if err == nil {
+		log.Info("mongodb update swap statistics", "summary", summary)
+	} else {
+		log.Debug("mongodb update swap statistics", "summary", summary, "err", err)
+	}
+	return err
+}
 
-	return stat, nil
+// FindSwapSummary find swap summary
+func (s *SwapStore) FindSwapSummary() (*storage.SwapSummary, error) {
+	var result storage.SwapSummary
+	err := collSwapStatistics.Find(nil).One(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return &result, nil
 }
 
 // ------------------ p2sh address ------------------------
 
 // AddP2shAddress add p2sh address
-func AddP2shAddress(ma *MgoP2shAddress) error {
+func (s *SwapStore) AddP2shAddress(ma *storage.P2shAddress) error {
 	err := collP2shAddress.Insert(ma)
 	if err == nil {
 		log.Info("mongodb add p2sh address", "key", ma.Key, "p2shaddress", ma.P2shAddress)
@@ -468,8 +399,8 @@ func AddP2shAddress(ma *MgoP2shAddress) error {
 }
 
 // FindP2shAddress find p2sh addrss through bind address
-func FindP2shAddress(key string) (*MgoP2shAddress, error) {
-	var result MgoP2shAddress
+func (s *SwapStore) FindP2shAddress(key string) (*storage.P2shAddress, error) {
+	var result storage.P2shAddress
 	err := collP2shAddress.FindId(key).One(&result)
 	if err != nil {
 		return nil, mgoError(err)
@@ -478,8 +409,8 @@ func FindP2shAddress(key string) (*MgoP2shAddress, error) {
 }
 
 // FindP2shBindAddress find bind address through p2sh address
-func FindP2shBindAddress(p2shAddress string) (string, error) {
-	var result MgoP2shAddress
+func (s *SwapStore) FindP2shBindAddress(p2shAddress string) (string, error) {
+	var result storage.P2shAddress
 	err := collP2shAddress.Find(bson.M{"p2shaddress": p2shAddress}).One(&result)
 	if err != nil {
 		return "", mgoError(err)
@@ -488,8 +419,8 @@ func FindP2shBindAddress(p2shAddress string) (string, error) {
 }
 
 // FindP2shAddresses find p2sh address
-func FindP2shAddresses(offset, limit int) ([]*MgoP2shAddress, error) {
-	result := make([]*MgoP2shAddress, 0, limit)
+func (s *SwapStore) FindP2shAddresses(offset, limit int) ([]*storage.P2shAddress, error) {
+	result := make([]*storage.P2shAddress, 0, limit)
 	q := collP2shAddress.Find(nil).Skip(offset).Limit(limit)
 	err := q.All(&result)
 	if err != nil {
@@ -501,69 +432,475 @@ func FindP2shAddresses(offset, limit int) ([]*MgoP2shAddress, error) {
 // ------------------ latest scan info ------------------------
 
 // UpdateLatestScanInfo update latest scan info
-func UpdateLatestScanInfo(isSrc bool, blockHeight uint64) error {
-	oldInfo, _ := FindLatestScanInfo(isSrc)
-	if oldInfo != nil {
-		oldHeight := oldInfo.BlockHeight
-		if blockHeight <= oldHeight {
-			return nil
+func (s *SwapStore) UpdateLatestScanInfo(info *storage.LatestScanInfo) error {
+	err := fencedUpsertID(collLatestScanInfo, info.Key, info)
+	if err == nil {
+		log.Info("mongodb update lastest scan info", "key", info.Key, "height", info.BlockHeight)
+	} else {
+		log.Debug("mongodb update latest scan info", "key", info.Key, "height", info.BlockHeight, "err", err)
+	}
+	return err
+}
+
+// FindLatestScanInfo find latest scan info
+func (s *SwapStore) FindLatestScanInfo(key string) (*storage.LatestScanInfo, error) {
+	var result storage.LatestScanInfo
+	err := collLatestScanInfo.FindId(key).One(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return &result, nil
+}
+
+// ------------------------ register address ------------------------------
+
+// AddRegisteredAddress add register address
+func (s *SwapStore) AddRegisteredAddress(ma *storage.RegisteredAddress) error {
+	err := collRegisteredAddress.Insert(ma)
+	return mgoError(err)
+}
+
+// FindRegisteredAddress find register address
+func (s *SwapStore) FindRegisteredAddress(key string) (*storage.RegisteredAddress, error) {
+	var result storage.RegisteredAddress
+	err := collRegisteredAddress.FindId(key).One(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return &result, nil
+}
+
+// --------------- blacklist --------------------------------
+
+// AddToBlacklist add to blacklist
+func (s *SwapStore) AddToBlacklist(mb *storage.BlackAccount) error {
+	err := collBlacklist.Insert(mb)
+	return mgoError(err)
+}
+
+// RemoveFromBlacklist remove from blacklist
+func (s *SwapStore) RemoveFromBlacklist(address string) error {
+	err := collBlacklist.RemoveId(address)
+	return mgoError(err)
+}
+
+// FindBlackAccount find black account
+func (s *SwapStore) FindBlackAccount(address string) (*storage.BlackAccount, error) {
+	var result storage.BlackAccount
+	err := collBlacklist.FindId(address).One(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return &result, nil
+}
+
+// --------------- webhook outbox --------------------------------
+
+// AddWebhookDelivery add webhook delivery to outbox
+func (s *SwapStore) AddWebhookDelivery(delivery *storage.WebhookDelivery) error {
+	return fencedInsert(collWebhookOutbox, delivery)
+}
+
+// UpdateWebhookDelivery replace webhook delivery in outbox
+func (s *SwapStore) UpdateWebhookDelivery(delivery *storage.WebhookDelivery) error {
+	return fencedUpsertID(collWebhookOutbox, delivery.Key, delivery)
+}
+
+// RemoveWebhookDelivery remove webhook delivery from outbox
+func (s *SwapStore) RemoveWebhookDelivery(key string) error {
+	return fencedRemoveID(collWebhookOutbox, key)
+}
+
+// FindWebhookDelivery find webhook delivery
+func (s *SwapStore) FindWebhookDelivery(key string) (*storage.WebhookDelivery, error) {
+	var result storage.WebhookDelivery
+	err := collWebhookOutbox.FindId(key).One(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return &result, nil
+}
+
+// FindPendingWebhookDeliveries find pending webhook deliveries
+func (s *SwapStore) FindPendingWebhookDeliveries(now int64, limit int) ([]*storage.WebhookDelivery, error) {
+	result := make([]*storage.WebhookDelivery, 0, 20)
+	q := collWebhookOutbox.Find(bson.M{"dead": false, "nexttime": bson.M{"$lte": now}}).Sort("nexttime")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	err := q.All(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return result, nil
+}
+
+// FindDeadWebhookDeliveries find dead webhook deliveries
+func (s *SwapStore) FindDeadWebhookDeliveries(offset, limit int) ([]*storage.WebhookDelivery, error) {
+	result := make([]*storage.WebhookDelivery, 0, 20)
+	q := collWebhookOutbox.Find(bson.M{"dead": true}).Sort("timestamp").Skip(offset)
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	err := q.All(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return result, nil
+}
+
+// --------------- fee override --------------------------------
+
+// SetFeeOverride insert or replace fee override
+func (s *SwapStore) SetFeeOverride(override *storage.FeeOverride) error {
+	_, err := collFeeOverride.UpsertId(override.Key, override)
+	return mgoError(err)
+}
+
+// RemoveFeeOverride remove fee override
+func (s *SwapStore) RemoveFeeOverride(address string) error {
+	err := collFeeOverride.RemoveId(address)
+	return mgoError(err)
+}
+
+// FindFeeOverride find fee override
+func (s *SwapStore) FindFeeOverride(address string) (*storage.FeeOverride, error) {
+	var result storage.FeeOverride
+	err := collFeeOverride.FindId(address).One(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return &result, nil
+}
+
+// FindFeeOverrides find all fee overrides
+func (s *SwapStore) FindFeeOverrides() ([]*storage.FeeOverride, error) {
+	result := make([]*storage.FeeOverride, 0, 20)
+	err := collFeeOverride.Find(nil).Sort("_id").All(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return result, nil
+}
+
+// --------------- swap event --------------------------------
+
+// AddSwapEvent add swap event
+func (s *SwapStore) AddSwapEvent(event *storage.SwapEvent) error {
+	return fencedInsert(collSwapEvent, event)
+}
+
+// FindSwapEvents find swap events of txid
+func (s *SwapStore) FindSwapEvents(txid string) ([]*storage.SwapEvent, error) {
+	result := make([]*storage.SwapEvent, 0, 20)
+	err := collSwapEvent.Find(bson.M{"txid": txid}).Sort("_id").All(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return result, nil
+}
+
+// FindSwapEventsAfter find swap events after key
+func (s *SwapStore) FindSwapEventsAfter(key string, limit int) ([]*storage.SwapEvent, error) {
+	result := make([]*storage.SwapEvent, 0, limit)
+	err := collSwapEvent.Find(bson.M{"_id": bson.M{"$gt": key}}).Sort("_id").Limit(limit).All(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return result, nil
+}
+
+// --------------- swap attempt --------------------------------
+
+// SetSwapAttempt insert or replace swap attempt
+func (s *SwapStore) SetSwapAttempt(attempt *storage.SwapAttempt) error {
+	return fencedUpsertID(collSwapAttempt, attempt.Key, attempt)
+}
+
+// FindSwapAttempts find swap attempts of txid
+func (s *SwapStore) FindSwapAttempts(txid string) ([]*storage.SwapAttempt, error) {
+	result := make([]*storage.SwapAttempt, 0, 2)
+	err := collSwapAttempt.Find(bson.M{"txid": txid}).Sort("signtime").All(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return result, nil
+}
+
+// FindSwapAttemptsWithStatus find swap attempts with status in the past septime
+func (s *SwapStore) FindSwapAttemptsWithStatus(status string, septime int64) ([]*storage.SwapAttempt, error) {
+	result := make([]*storage.SwapAttempt, 0, 20)
+	qstatus := bson.M{"status": status}
+	qtime := bson.M{"timestamp": bson.M{"$gte": septime}}
+	queries := []bson.M{qstatus, qtime}
+	err := collSwapAttempt.Find(bson.M{"$and": queries}).Sort("signtime").Limit(maxCountOfResults).All(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return result, nil
+}
+
+// ReserveNonce reserve the next nonce of account for txid,
+// the nonce is allocated by one findAndModify of the account counter,
+// so concurrent callers never get the same nonce.
+func (s *SwapStore) ReserveNonce(account, txid string, minNonce uint64, timestamp int64) (*storage.NonceReservation, error) {
+	token := getFencingToken()
+	var reservation storage.NonceReservation
+	qreuse := bson.M{
+		"account": account,
+		"txid":    txid,
+		"status":  storage.NonceReserved,
+		"nonce":   bson.M{"$gte": minNonce},
+	}
+	ureuse := bson.M{"timestamp": timestamp}
+	fence(token, qreuse, ureuse)
+	reuse := mgo.Change{
+		Update:    bson.M{"$set": ureuse},
+		ReturnNew: true,
+	}
+	_, err := collNonceReservation.Find(qreuse).Sort("nonce").Apply(reuse, &reservation)
+	if err == nil {
+		return &reservation, nil
+	}
+	if err != mgo.ErrNotFound {
+		return nil, mgoError(err)
+	}
+
+	for i := 0; i < maxReserveNonceTries; i++ {
+		nonce, err := allocateNonce(account, minNonce, timestamp, token)
+		if err != nil {
+			return nil, err
+		}
+		reservation = storage.NonceReservation{
+			Key:       storage.GetNonceReservationKey(account, nonce),
+			Account:   account,
+			Nonce:     nonce,
+			TxID:      txid,
+			Status:    storage.NonceReserved,
+			Timestamp: timestamp,
+		}
+		err = claimNonceReservation(&reservation, token)
+		if err == nil {
+			return &reservation, nil
+		}
+		if err != errNonceIsReserved {
+			return nil, err
+		}
+		log.Warn("mongodb nonce is reserved by others, reserve the next one", "account", account, "nonce", nonce)
+	}
+	return nil, errNonceIsReserved
+}
+
+// allocateNonce allocate nonce (not less than minNonce) from the counter of account,
+// every try is one findAndModify, either increasing the counter which is not less than minNonce,
+// or raising the counter which is less than minNonce (or missing) to minNonce+1.
+func allocateNonce(account string, minNonce uint64, timestamp int64, token uint64) (uint64, error) {
+	for i := 0; i < maxReserveNonceTries; i++ {
+		var old storage.NonceAccount
+		query := bson.M{"_id": account, "nextnonce": bson.M{"$gte": minNonce}}
+		updates := bson.M{"timestamp": timestamp}
+		fence(token, query, updates)
+		increase := mgo.Change{
+			Update: bson.M{
+				"$inc": bson.M{"nextnonce": 1},
+				"$set": updates,
+			},
+		}
+		_, err := collNonceAccount.Find(query).Apply(increase, &old)
+		if err == nil {
+			return old.NextNonce, nil
+		}
+		if err != mgo.ErrNotFound {
+			return 0, mgoError(err)
+		}
+
+		query = bson.M{"_id": account, "nextnonce": bson.M{"$not": bson.M{"$gte": minNonce}}}
+		updates = bson.M{"nextnonce": minNonce + 1, "timestamp": timestamp}
+		fence(token, query, updates)
+		raise := mgo.Change{
+			Update: bson.M{"$set": updates},
+			Upsert: true,
+		}
+		_, err = collNonceAccount.Find(query).Apply(raise, &old)
+		if err == nil {
+			return minNonce, nil
+		}
+		// the counter is raised by others (retry increasing), or fenced
+		if !mgo.IsDup(err) {
+			return 0, mgoError(err)
 		}
 	}
-	var key string
-	if isSrc {
-		key = keyOfSrcLatestScanInfo
-	} else {
-		key = keyOfDstLatestScanInfo
+	return 0, fencedMissError(collNonceAccount, account, token, storage.ErrStatusMismatch)
+}
+
+// claimNonceReservation insert reservation, or replace the existing one which is not reserved
+// (released, sent or cancelled before the counter is lowered by SetNextNonce).
+func claimNonceReservation(reservation *storage.NonceReservation, token uint64) error {
+	doc, err := fencedDoc(token, reservation)
+	if err != nil {
+		return err
+	}
+	err = collNonceReservation.Insert(doc)
+	if !mgo.IsDup(err) {
+		return mgoError(err)
+	}
+	query := bson.M{"_id": reservation.Key, "status": bson.M{"$ne": storage.NonceReserved}}
+	fence(token, query, nil)
+	err = collNonceReservation.Update(query, doc)
+	if err == mgo.ErrNotFound {
+		return fencedMissError(collNonceReservation, reservation.Key, token, errNonceIsReserved)
+	}
+	return mgoError(err)
+}
+
+// SetNextNonce set the next nonce of account, and release the unsent reservations not less than it
+func (s *SwapStore) SetNextNonce(account string, nonce uint64, timestamp int64) error {
+	nonceAccount := &storage.NonceAccount{
+		Key:       account,
+		NextNonce: nonce,
+		Timestamp: timestamp,
+	}
+	err := fencedUpsertID(collNonceAccount, account, nonceAccount)
+	if err != nil {
+		return err
+	}
+	qrelease := bson.M{
+		"account": account,
+		"status":  storage.NonceReserved,
+		"nonce":   bson.M{"$gte": nonce},
 	}
 	updates := bson.M{
-		"blockheight": blockHeight,
-		"timestamp":   time.Now().Unix(),
+		"status":    storage.NonceReleased,
+		"memo":      "released by setting nonce",
+		"timestamp": timestamp,
+	}
+	fence(getFencingToken(), qrelease, updates)
+	_, err = collNonceReservation.UpdateAll(qrelease, bson.M{"$set": updates})
+	return mgoError(err)
+}
+
+// FindNonceAccount find nonce account
+func (s *SwapStore) FindNonceAccount(account string) (*storage.NonceAccount, error) {
+	var result storage.NonceAccount
+	err := collNonceAccount.FindId(account).One(&result)
+	if err != nil {
+		return nil, mgoError(err)
+	}
+	return &result, nil
+}
+
+// FindNonceReservation find nonce reservation of account and nonce
+func (s *SwapStore) FindNonceReservation(account string, nonce uint64) (*storage.NonceReservation, error) {
+	var result storage.NonceReservation
+	err := collNonceReservation.FindId(storage.GetNonceReservationKey(account, nonce)).One(&result)
+	if err != nil {
+		return nil, mgoError(err)
 	}
-	err := collLatestScanInfo.UpdateId(key, bson.M{"$set": updates})
+	return &result, nil
+}
+
+// SetNonceReservation insert or replace nonce reservation
+func (s *SwapStore) SetNonceReservation(reservation *storage.NonceReservation) error {
+	return fencedUpsertID(collNonceReservation, reservation.Key, reservation)
+}
+
+// AcquireLease renew the unexpired lease of holder, or take over the expired lease,
+// the upsert of a lease held by others fails with duplicate key error.
+func (s *SwapStore) AcquireLease(name, holder string, ttl, now int64) (*storage.Lease, error) {
+	var lease storage.Lease
+	renew := mgo.Change{
+		Update:    bson.M{"$set": bson.M{"expires": now + ttl, "timestamp": now}},
+		ReturnNew: true,
+	}
+	qrenew := bson.M{"_id": name, "holder": holder, "expires": bson.M{"$gt": now}}
+	_, err := collLease.Find(qrenew).Apply(renew, &lease)
 	if err == nil {
-		log.Info("mongodb update lastest scan info", "isSrc", isSrc, "updates", updates)
-	} else {
-		log.Debug("mongodb update latest scan info", "isSrc", isSrc, "updates", updates, "err", err)
+		return &lease, nil
+	}
+	if err != mgo.ErrNotFound {
+		return nil, mgoError(err)
+	}
+
+	takeover := mgo.Change{
+		Update: bson.M{
+			"$set": bson.M{"holder": holder, "expires": now + ttl, "timestamp": now},
+			"$inc": bson.M{"token": 1},
+		},
+		Upsert:    true,
+		ReturnNew: true,
+	}
+	qtakeover := bson.M{"_id": name, "expires": bson.M{"$lte": now}}
+	_, err = collLease.Find(qtakeover).Apply(takeover, &lease)
+	if err != nil {
+		if mgo.IsDup(err) {
+			return nil, storage.ErrLeaseHeld
+		}
+		return nil, mgoError(err)
+	}
+	return &lease, nil
+}
+
+// ReleaseLease expire the lease if it is held by holder
+func (s *SwapStore) ReleaseLease(name, holder string) error {
+	err := collLease.Update(bson.M{"_id": name, "holder": holder}, bson.M{"$set": bson.M{"expires": 0}})
+	if err == mgo.ErrNotFound {
+		return nil
 	}
 	return mgoError(err)
 }
 
-// FindLatestScanInfo find latest scan info
-func FindLatestScanInfo(isSrc bool) (*MgoLatestScanInfo, error) {
-	var result MgoLatestScanInfo
-	var key string
-	if isSrc {
-		key = keyOfSrcLatestScanInfo
-	} else {
-		key = keyOfDstLatestScanInfo
+// FindLease find lease
+func (s *SwapStore) FindLease(name string) (*storage.Lease, error) {
+	var result storage.Lease
+	err := collLease.FindId(name).One(&result)
+	if err != nil {
+		return nil, mgoError(err)
 	}
-	err := collLatestScanInfo.FindId(key).One(&result)
-	return &result, mgoError(err)
+	return &result, nil
 }
 
-// ------------------------ register address ------------------------------
+// AddOutflow add outflow
+func (s *SwapStore) AddOutflow(outflow *storage.Outflow) error {
+	err := fencedInsert(collOutflow, outflow)
+	if err == nil {
+		log.Info("mongodb add outflow", "key", outflow.Key, "value", outflow.Value)
+	} else {
+		log.Debug("mongodb add outflow", "key", outflow.Key, "err", err)
+	}
+	return err
+}
 
-// AddRegisteredAddress add register address
-func AddRegisteredAddress(address string) error {
-	ma := &MgoRegisteredAddress{
-		Key:       address,
-		Timestamp: time.Now().Unix(),
+// FindOutflows find all outflows in the past septime
+func (s *SwapStore) FindOutflows(pairID string, isSwapin bool, septime int64) ([]*storage.Outflow, error) {
+	result := make([]*storage.Outflow, 0, 20)
+	qpair := bson.M{"pairid": pairID}
+	qdirection := bson.M{"isswapin": isSwapin}
+	qtime := bson.M{"timestamp": bson.M{"$gte": septime}}
+	queries := []bson.M{qpair, qdirection, qtime}
+	err := collOutflow.Find(bson.M{"$and": queries}).All(&result)
+	if err != nil {
+		return nil, mgoError(err)
 	}
-	err := collRegisteredAddress.Insert(ma)
+	return result, nil
+}
+
+// SetOutflowBreaker insert or replace outflow breaker
+func (s *SwapStore) SetOutflowBreaker(breaker *storage.OutflowBreaker) error {
+	err := fencedUpsertID(collOutflowBreaker, breaker.Key, breaker)
 	if err == nil {
-		log.Info("mongodb add register address", "key", ma.Key)
+		log.Info("mongodb set outflow breaker", "key", breaker.Key, "tripped", breaker.Tripped)
 	} else {
-		log.Debug("mongodb add register address", "key", ma.Key, "err", err)
+		log.Debug("mongodb set outflow breaker", "key", breaker.Key, "err", err)
 	}
-	return mgoError(err)
+	return err
 }
 
-// FindRegisteredAddress find register address
-func FindRegisteredAddress(key string) (*MgoRegisteredAddress, error) {
-	var result MgoRegisteredAddress
-	err := collRegisteredAddress.FindId(key).One(&result)
+// FindOutflowBreakers find all outflow breakers
+func (s *SwapStore) FindOutflowBreakers() ([]*storage.OutflowBreaker, error) {
+	result := make([]*storage.OutflowBreaker, 0, 10)
+	err := collOutflowBreaker.Find(nil).All(&result)
 	if err != nil {
 		return nil, mgoError(err)
 	}
-	return &result, nil
+	return result, nil
 }
@@ -13,6 +13,8 @@ var (
 	session  *mgo.Session
 
 	dialInfo *mgo.DialInfo
+
+	closeCh = make(chan struct{})
 )
 
 // HasSession has session connected
@@ -58,10 +60,24 @@ func mongoConnect() {
 	log.Info("[mongodb] connect database finished.", "dbName", dialInfo.Database)
 }
 
+// MongoServerClose close mongodb server session
+func MongoServerClose() {
+	if session == nil {
+		return
+	}
+	close(closeCh)
+	session.Close()
+	log.Info("[mongodb] close database session.", "dbName", dialInfo.Database)
+}
+
 // fix 'read tcp 127.0.0.1:43502->127.0.0.1:27917: i/o timeout'
 func checkMongoSession() {
 	for {
-		time.Sleep(60 * time.Second)
+		select {
+		case <-closeCh:
+			return
+		case <-time.After(60 * time.Second):
+		}
 		if err := ensureMongoConnected(); err != nil {
 			log.Info("[mongodb] check session error", "err", err)
 			log.Info("[mongodb] reconnect database", "dbName", dialInfo.Database)
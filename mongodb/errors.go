@@ -1,33 +1,23 @@
 package mongodb
 
 import (
-	rpcjson "github.com/gorilla/rpc/v2/json2"
+	"errors"
+
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"gopkg.in/mgo.v2"
 )
 
-func newError(ec rpcjson.ErrorCode, message string) error {
-	return &rpcjson.Error{
-		Code:    ec,
-		Message: message,
-	}
-}
+var errNonceIsReserved = errors.New("nonce is reserved by others")
 
 func mgoError(err error) error {
 	if err != nil {
 		if err == mgo.ErrNotFound {
-			return ErrItemNotFound
+			return storage.ErrItemNotFound
 		}
 		if mgo.IsDup(err) {
-			return ErrItemIsDup
+			return storage.ErrItemIsDup
 		}
-		return newError(-32001, "mgoError: "+err.Error())
+		return storage.NewError(-32001, "mgoError: "+err.Error())
 	}
 	return nil
 }
-
-// mongodb special errors
-var (
-	ErrItemNotFound = newError(-32002, "mgoError: Item not found")
-	ErrItemIsDup    = newError(-32003, "mgoError: Item is duplicate")
-	ErrSwapNotFound = newError(-32011, "mgoError: Swap is not found")
-)
@@ -0,0 +1,106 @@
+package mongodb
+
+import (
+	"sync/atomic"
+
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// fencingToken token of the leader lease held by this instance (0 if never elected),
+// every fenced document records the token of its last writer in 'fencetoken',
+// and a write with an older token than the recorded one is rejected by the write condition,
+// so a demoted leader can not overwrite anything written by the new leader.
+var fencingToken uint64
+
+// SetFencingToken fence the writes with token
+func (s *SwapStore) SetFencingToken(token uint64) {
+	atomic.StoreUint64(&fencingToken, token)
+}
+
+func getFencingToken() uint64 {
+	return atomic.LoadUint64(&fencingToken)
+}
+
+// fence add fencing condition to query and record token in updates (if not nil)
+func fence(token uint64, query, updates bson.M) {
+	if token == 0 {
+		return
+	}
+	// documents without 'fencetoken' match too
+	query["fencetoken"] = bson.M{"$not": bson.M{"$gt": token}}
+	if updates != nil {
+		updates["fencetoken"] = token
+	}
+}
+
+// fencedDoc convert item to document recording the fencing token
+func fencedDoc(token uint64, item interface{}) (bson.M, error) {
+	data, err := bson.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.M
+	if err = bson.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if token != 0 {
+		doc["fencetoken"] = token
+	}
+	return doc, nil
+}
+
+// fencedMissError get error of the fenced write which matched nothing,
+// ErrNotLeader if the document is written by a newer leader, otherwise missErr.
+func fencedMissError(collection *mgo.Collection, id string, token uint64, missErr error) error {
+	var doc struct {
+		FenceToken uint64 `bson:"fencetoken"`
+	}
+	err := collection.FindId(id).Select(bson.M{"fencetoken": 1}).One(&doc)
+	if err != nil {
+		return mgoError(err)
+	}
+	if token != 0 && doc.FenceToken > token {
+		return storage.ErrNotLeader
+	}
+	return missErr
+}
+
+// fencedInsert insert item with the fencing token
+func fencedInsert(collection *mgo.Collection, item interface{}) error {
+	doc, err := fencedDoc(getFencingToken(), item)
+	if err != nil {
+		return err
+	}
+	return mgoError(collection.Insert(doc))
+}
+
+// fencedUpsertID insert or replace item by id, the replacement of a document
+// written by a newer leader falls into inserting and fails with duplicate key.
+func fencedUpsertID(collection *mgo.Collection, id string, item interface{}) error {
+	token := getFencingToken()
+	doc, err := fencedDoc(token, item)
+	if err != nil {
+		return err
+	}
+	query := bson.M{"_id": id}
+	fence(token, query, nil)
+	_, err = collection.Upsert(query, doc)
+	if token != 0 && mgo.IsDup(err) {
+		return storage.ErrNotLeader
+	}
+	return mgoError(err)
+}
+
+// fencedRemoveID remove item by id
+func fencedRemoveID(collection *mgo.Collection, id string) error {
+	token := getFencingToken()
+	query := bson.M{"_id": id}
+	fence(token, query, nil)
+	err := collection.Remove(query)
+	if err == mgo.ErrNotFound {
+		return fencedMissError(collection, id, token, storage.ErrItemNotFound)
+	}
+	return mgoError(err)
+}
@@ -1,7 +1,10 @@
 package mongodb
 
 import (
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 )
 
 var (
@@ -14,12 +17,17 @@ var (
 	collLatestScanInfo    *mgo.Collection
 	collRegisteredAddress *mgo.Collection
 	collBlacklist         *mgo.Collection
+	collWebhookOutbox     *mgo.Collection
+	collFeeOverride       *mgo.Collection
+	collSwapEvent         *mgo.Collection
+	collSwapAttempt       *mgo.Collection
+	collNonceAccount      *mgo.Collection
+	collNonceReservation  *mgo.Collection
+	collLease             *mgo.Collection
+	collOutflow           *mgo.Collection
+	collOutflowBreaker    *mgo.Collection
 )
 
-func isSwapin(collection *mgo.Collection) bool {
-	return collection == collSwapin || collection == collSwapinResult
-}
-
 // do this when reconnect to the database
 func deinintCollections() {
 	collSwapin = database.C(tbSwapins)
@@ -31,6 +39,15 @@ func deinintCollections() {
 	collLatestScanInfo = database.C(tbLatestScanInfo)
 	collRegisteredAddress = database.C(tbRegisteredAddress)
 	collBlacklist = database.C(tbBlacklist)
+	collWebhookOutbox = database.C(tbWebhookOutbox)
+	collFeeOverride = database.C(tbFeeOverrides)
+	collSwapEvent = database.C(tbSwapEvents)
+	collSwapAttempt = database.C(tbSwapAttempts)
+	collNonceAccount = database.C(tbNonceAccounts)
+	collNonceReservation = database.C(tbNonceReservations)
+	collLease = database.C(tbLeases)
+	collOutflow = database.C(tbOutflows)
+	collOutflowBreaker = database.C(tbOutflowBreakers)
 }
 
 func initCollections() {
@@ -38,13 +55,69 @@ func initCollections() {
 	initCollection(tbSwapouts, &collSwapout, "timestamp", "status")
 	initCollection(tbSwapinResults, &collSwapinResult, "from", "timestamp")
 	initCollection(tbSwapoutResults, &collSwapoutResult, "from", "timestamp")
+	ensureSwapResultQueryIndexes(collSwapinResult)
+	ensureSwapResultQueryIndexes(collSwapoutResult)
+	fillSwapResultValueKeys(collSwapinResult)
+	fillSwapResultValueKeys(collSwapoutResult)
 	initCollection(tbP2shAddresses, &collP2shAddress, "p2shaddress")
 	initCollection(tbSwapStatistics, &collSwapStatistics)
 	initCollection(tbLatestScanInfo, &collLatestScanInfo)
 	initCollection(tbRegisteredAddress, &collRegisteredAddress)
 	initCollection(tbBlacklist, &collBlacklist)
+	initCollection(tbWebhookOutbox, &collWebhookOutbox, "dead", "nexttime")
+	initCollection(tbFeeOverrides, &collFeeOverride)
+	initCollection(tbSwapEvents, &collSwapEvent, "txid")
+	initCollection(tbSwapAttempts, &collSwapAttempt, "txid")
+	_ = collSwapAttempt.EnsureIndexKey("status", "timestamp")
+	initCollection(tbNonceAccounts, &collNonceAccount)
+	initCollection(tbNonceReservations, &collNonceReservation, "account", "txid")
+	_ = collNonceReservation.EnsureIndexKey("account", "status", "nonce")
+	initCollection(tbLeases, &collLease)
+	initCollection(tbOutflows, &collOutflow)
+	_ = collOutflow.EnsureIndexKey("pairid", "isswapin", "timestamp")
+	initCollection(tbOutflowBreakers, &collOutflowBreaker)
+}
+
+// compound indexes supporting QuerySwapResults (filters, sorting and cursor)
+func ensureSwapResultQueryIndexes(collection *mgo.Collection) {
+	indexes := [][]string{
+		{"timestamp", "_id"},
+		{"status", "timestamp", "_id"},
+		{"from", "timestamp", "_id"},
+		{"bind", "timestamp", "_id"},
+		{"to", "timestamp", "_id"},
+		{"pairid", "timestamp", "_id"},
+		{"swaptx"},
+		{"txtime", "_id"},
+		{"status", "txtime", "_id"},
+		{"txheight", "_id"},
+		{"swapheight", "_id"},
+		{"valuekey"},
+	}
+	for _, index := range indexes {
+		_ = collection.EnsureIndexKey(index...)
+	}
+}
 
-	initDefaultValue()
+// fill value key of swap results added before it is stored
+func fillSwapResultValueKeys(collection *mgo.Collection) {
+	var res storage.SwapResult
+	count := 0
+	iter := collection.Find(bson.M{"valuekey": bson.M{"$exists": false}}).Select(bson.M{"value": 1}).Iter()
+	for iter.Next(&res) {
+		err := collection.UpdateId(res.Key, bson.M{"$set": bson.M{"valuekey": storage.GetValueKey(res.Value)}})
+		if err != nil {
+			log.Warn("[mongodb] fill swap result value key failed", "collection", collection.Name, "key", res.Key, "err", err)
+			continue
+		}
+		count++
+	}
+	if err := iter.Close(); err != nil {
+		log.Warn("[mongodb] fill swap result value keys failed", "collection", collection.Name, "err", err)
+	}
+	if count > 0 {
+		log.Info("[mongodb] fill swap result value keys", "collection", collection.Name, "count", count)
+	}
 }
 
 func initCollection(table string, collection **mgo.Collection, indexKey ...string) {
@@ -53,19 +126,3 @@ func initCollection(table string, collection **mgo.Collection, indexKey ...strin
 		_ = (*collection).EnsureIndexKey(indexKey...)
 	}
 }
-
-func initDefaultValue() {
-	_ = collSwapStatistics.Insert(
-		&MgoSwapStatistics{
-			Key: keyOfSwapStatistics,
-		},
-	)
-	_ = collLatestScanInfo.Insert(
-		&MgoLatestScanInfo{
-			Key: keyOfSrcLatestScanInfo,
-		},
-		&MgoLatestScanInfo{
-			Key: keyOfDstLatestScanInfo,
-		},
-	)
-}
@@ -3,6 +3,8 @@ package params
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"net/url"
 	"os"
 	"strings"
 	"sync"
@@ -34,15 +36,21 @@ var (
 // ServerConfig config items (decode from toml file)
 type ServerConfig struct {
 	Identifier  string
-	MongoDB     *MongoDBConfig   `toml:",omitempty"`
-	APIServer   *APIServerConfig `toml:",omitempty"`
-	SrcToken    *tokens.TokenConfig
+	Storage     *StorageConfig      `toml:",omitempty"`
+	MongoDB     *MongoDBConfig      `toml:",omitempty"`
+	APIServer   *APIServerConfig    `toml:",omitempty"`
+	SrcToken    *tokens.TokenConfig `toml:",omitempty"`
 	SrcGateway  *tokens.GatewayConfig
-	DestToken   *tokens.TokenConfig
+	DestToken   *tokens.TokenConfig `toml:",omitempty"`
 	DestGateway *tokens.GatewayConfig
+	TokenPairs  []*tokens.TokenPairConfig `toml:",omitempty"`
 	Dcrm        *DcrmConfig
 	Oracle      *OracleConfig          `toml:",omitempty"`
+	Replace     *ReplaceConfig         `toml:",omitempty"`
+	Retry       *RetryConfig           `toml:",omitempty"`
+	HA          *HAConfig              `toml:",omitempty"`
 	BtcExtra    *tokens.BtcExtraConfig `toml:",omitempty"`
+	Webhooks    []*WebhookConfig       `toml:",omitempty"`
 	Admins      []string
 }
 
@@ -63,6 +71,217 @@ type DcrmConfig struct {
 // OracleConfig oracle config
 type OracleConfig struct {
 	ServerAPIAddress string
+	MetricsPort      int // standalone metrics service port (disabled if 0)
+}
+
+// ReplaceConfig replace stuck swap tx config
+type ReplaceConfig struct {
+	Enable            bool
+	WaitTimeToReplace int64  // seconds to wait before replacing unmined swap tx (default 900)
+	MaxReplaceCount   int    // maximum times to replace one swap (default 20)
+	PlusFeePercentage uint64 // plus this percentage of fee when replacing (default and minimum 10)
+}
+
+// default replace config values
+const (
+	defWaitTimeToReplace = 900
+	defMaxReplaceCount   = 20
+	minPlusFeePercentage = 10
+	maxPlusFeePercentage = 10000
+)
+
+// CheckConfig check replace config
+func (c *ReplaceConfig) CheckConfig() error {
+	if c.WaitTimeToReplace < 0 || c.MaxReplaceCount < 0 {
+		return errors.New("replace config 'WaitTimeToReplace' and 'MaxReplaceCount' must be non-negative")
+	}
+	if c.WaitTimeToReplace == 0 {
+		c.WaitTimeToReplace = defWaitTimeToReplace
+	}
+	if c.MaxReplaceCount == 0 {
+		c.MaxReplaceCount = defMaxReplaceCount
+	}
+	if c.PlusFeePercentage < minPlusFeePercentage {
+		c.PlusFeePercentage = minPlusFeePercentage
+	}
+	if c.PlusFeePercentage > maxPlusFeePercentage {
+		return errors.New("too large replace config 'PlusFeePercentage' value")
+	}
+	return nil
+}
+
+// GetReplaceConfig get replace config
+func GetReplaceConfig() *ReplaceConfig {
+	return GetConfig().Replace
+}
+
+// RetryConfig retry policies of failed swap processing in the verify and swap stages
+type RetryConfig struct {
+	Verify *RetryPolicyConfig `toml:",omitempty"`
+	Swap   *RetryPolicyConfig `toml:",omitempty"`
+}
+
+// RetryPolicyConfig retry policy of a swap stage, the nth retry is delayed by
+// InitialBackoff * 2^(n-1) seconds (at most MaxBackoff seconds).
+type RetryPolicyConfig struct {
+	MaxAttempts    uint64 // failed attempts before moving into dead-letter status (default 10)
+	InitialBackoff int64  // seconds to wait before the first retry (default 30)
+	MaxBackoff     int64  // maximum seconds to wait before a retry (default 3600)
+}
+
+// default retry policy values
+const (
+	defRetryMaxAttempts    = 10
+	defRetryInitialBackoff = 30
+	defRetryMaxBackoff     = 3600
+)
+
+// CheckConfig check retry config, the missing policies use default values
+func (c *RetryConfig) CheckConfig() error {
+	if c.Verify == nil {
+		c.Verify = &RetryPolicyConfig{}
+	}
+	if c.Swap == nil {
+		c.Swap = &RetryPolicyConfig{}
+	}
+	for _, policy := range []*RetryPolicyConfig{c.Verify, c.Swap} {
+		err := policy.CheckConfig()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckConfig check retry policy config
+func (c *RetryPolicyConfig) CheckConfig() error {
+	if c.InitialBackoff < 0 || c.MaxBackoff < 0 {
+		return errors.New("retry config 'InitialBackoff' and 'MaxBackoff' must be non-negative")
+	}
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = defRetryMaxAttempts
+	}
+	if c.InitialBackoff == 0 {
+		c.InitialBackoff = defRetryInitialBackoff
+	}
+	if c.MaxBackoff == 0 {
+		c.MaxBackoff = defRetryMaxBackoff
+	}
+	if c.InitialBackoff > c.MaxBackoff {
+		return errors.New("retry config 'InitialBackoff' must not be larger than 'MaxBackoff'")
+	}
+	return nil
+}
+
+// GetRetryConfig get retry config
+func GetRetryConfig() *RetryConfig {
+	return GetConfig().Retry
+}
+
+// HAConfig high availability config, swap servers sharing one database
+// elect a leader by a lease, only the leader runs the swap jobs.
+type HAConfig struct {
+	Enable        bool
+	InstanceID    string // unique id of this server instance (default hostname:apiport)
+	LeaseTTL      int64  // seconds the leadership lease lasts without renewal (default 30)
+	RenewInterval int64  // seconds between lease renewals (default 10)
+}
+
+// default ha config values
+const (
+	defLeaseTTL      = 30
+	defRenewInterval = 10
+)
+
+// CheckConfig check ha config
+func (c *HAConfig) CheckConfig(storageType string, apiPort int) error {
+	if !c.Enable {
+		return nil
+	}
+	if storageType != StorageTypeMongoDB {
+		return errors.New("ha config needs the shared 'mongodb' storage")
+	}
+	if c.LeaseTTL < 0 || c.RenewInterval < 0 {
+		return errors.New("ha config 'LeaseTTL' and 'RenewInterval' must be non-negative")
+	}
+	if c.LeaseTTL == 0 {
+		c.LeaseTTL = defLeaseTTL
+	}
+	if c.RenewInterval == 0 {
+		c.RenewInterval = defRenewInterval
+	}
+	if c.RenewInterval*2 > c.LeaseTTL {
+		return errors.New("ha config 'RenewInterval' must not be larger than half of 'LeaseTTL'")
+	}
+	if c.InstanceID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return fmt.Errorf("ha config 'InstanceID' is empty and get hostname failed: %v", err)
+		}
+		c.InstanceID = fmt.Sprintf("%v:%v", hostname, apiPort)
+	}
+	return nil
+}
+
+// GetHAConfig get ha config
+func GetHAConfig() *HAConfig {
+	return GetConfig().HA
+}
+
+// IsHAEnabled is high availability enabled
+func IsHAEnabled() bool {
+	ha := GetHAConfig()
+	return ha != nil && ha.Enable
+}
+
+// WebhookConfig webhook subscription config
+type WebhookConfig struct {
+	Name        string   // unique name of the webhook
+	URL         string   // http or https url to post payloads to
+	Secret      string   `json:"-"` // key of HMAC-SHA256 signature of payloads
+	Events      []string // swap status names or 'OutflowBreakerTripped' to notify (notify all if empty)
+	MaxAttempts int      // attempts before moving into dead-letter list (default 10)
+	Timeout     int      // seconds of http request timeout (default 10)
+}
+
+// default webhook config values
+const (
+	defWebhookMaxAttempts = 10
+	defWebhookTimeout     = 10
+)
+
+// CheckConfig check webhook config
+func (c *WebhookConfig) CheckConfig() error {
+	if c.Name == "" {
+		return errors.New("webhook must config non empty 'Name'")
+	}
+	u, err := url.Parse(c.URL)
+	if err != nil || !(u.Scheme == "http" || u.Scheme == "https") || u.Host == "" {
+		return fmt.Errorf("webhook '%v' has wrong 'URL' %v", c.Name, c.URL)
+	}
+	if c.Secret == "" {
+		return fmt.Errorf("webhook '%v' must config non empty 'Secret'", c.Name)
+	}
+	if c.MaxAttempts < 0 || c.Timeout < 0 {
+		return fmt.Errorf("webhook '%v' config 'MaxAttempts' and 'Timeout' must be non-negative", c.Name)
+	}
+	if c.MaxAttempts == 0 {
+		c.MaxAttempts = defWebhookMaxAttempts
+	}
+	if c.Timeout == 0 {
+		c.Timeout = defWebhookTimeout
+	}
+	return nil
+}
+
+// GetWebhookConfig get webhook config by name
+func GetWebhookConfig(name string) *WebhookConfig {
+	for _, webhook := range GetConfig().Webhooks {
+		if webhook.Name == name {
+			return webhook
+		}
+	}
+	return nil
 }
 
 // APIServerConfig api service config
@@ -71,6 +290,36 @@ type APIServerConfig struct {
 	AllowedOrigins []string
 }
 
+// storage types
+const (
+	StorageTypeMongoDB = "mongodb"
+	StorageTypeBoltDB  = "boltdb"
+)
+
+// StorageConfig storage backend config
+type StorageConfig struct {
+	Type       string // mongodb (default) or boltdb
+	BoltDBFile string // data file of boltdb (default datadir/swapserver.db)
+}
+
+// CheckConfig check storage config
+func (c *StorageConfig) CheckConfig() error {
+	c.Type = strings.ToLower(c.Type)
+	switch c.Type {
+	case "":
+		c.Type = StorageTypeMongoDB
+	case StorageTypeMongoDB, StorageTypeBoltDB:
+	default:
+		return fmt.Errorf("unknown storage type '%v'", c.Type)
+	}
+	return nil
+}
+
+// GetStorageConfig get storage config
+func GetStorageConfig() *StorageConfig {
+	return GetConfig().Storage
+}
+
 // MongoDBConfig mongodb config
 type MongoDBConfig struct {
 	DBURL    string
@@ -115,12 +364,42 @@ func CheckConfig(isServer bool) (err error) {
 		return errors.New("server must config non empty 'Identifier'")
 	}
 	if isServer {
-		if config.MongoDB == nil {
+		if config.Storage == nil {
+			config.Storage = &StorageConfig{}
+		}
+		err = config.Storage.CheckConfig()
+		if err != nil {
+			return err
+		}
+		if config.Storage.Type == StorageTypeMongoDB && config.MongoDB == nil {
 			return errors.New("server must config 'MongoDB'")
 		}
 		if config.APIServer == nil {
 			return errors.New("server must config 'APIServer'")
 		}
+		if config.Replace != nil {
+			err = config.Replace.CheckConfig()
+			if err != nil {
+				return err
+			}
+		}
+		if config.Retry == nil {
+			config.Retry = &RetryConfig{}
+		}
+		err = config.Retry.CheckConfig()
+		if err != nil {
+			return err
+		}
+		if config.HA != nil {
+			err = config.HA.CheckConfig(config.Storage.Type, GetAPIPort())
+			if err != nil {
+				return err
+			}
+		}
+		err = config.checkWebhooksConfig()
+		if err != nil {
+			return err
+		}
 	} else {
 		if config.Oracle == nil {
 			return errors.New("oracle must config 'Oracle'")
@@ -130,15 +409,9 @@ func CheckConfig(isServer bool) (err error) {
 			return err
 		}
 	}
-	if config.SrcToken == nil {
-		return errors.New("server must config 'SrcToken'")
-	}
 	if config.SrcGateway == nil {
 		return errors.New("server must config 'SrcGateway'")
 	}
-	if config.DestToken == nil {
-		return errors.New("server must config 'DestToken'")
-	}
 	if config.DestGateway == nil {
 		return errors.New("server must config 'DestGateway'")
 	}
@@ -149,17 +422,70 @@ func CheckConfig(isServer bool) (err error) {
 	if err != nil {
 		return err
 	}
-	err = config.SrcToken.CheckConfig(true)
-	if err != nil {
-		return err
+	return config.checkTokenPairsConfig()
+}
+
+func (config *ServerConfig) checkWebhooksConfig() error {
+	names := make(map[string]struct{}, len(config.Webhooks))
+	for _, webhook := range config.Webhooks {
+		if webhook == nil {
+			return errors.New("empty webhook config")
+		}
+		if err := webhook.CheckConfig(); err != nil {
+			return err
+		}
+		if _, exist := names[webhook.Name]; exist {
+			return fmt.Errorf("duplicate webhook name '%v'", webhook.Name)
+		}
+		names[webhook.Name] = struct{}{}
 	}
-	err = config.DestToken.CheckConfig(false)
-	if err != nil {
-		return err
+	return nil
+}
+
+func (config *ServerConfig) checkTokenPairsConfig() error {
+	// convert the legacy top level token config to the default token pair
+	if config.SrcToken != nil || config.DestToken != nil {
+		legacyPair := &tokens.TokenPairConfig{
+			PairID:    tokens.DefaultPairID,
+			SrcToken:  config.SrcToken,
+			DestToken: config.DestToken,
+		}
+		config.TokenPairs = append([]*tokens.TokenPairConfig{legacyPair}, config.TokenPairs...)
+		config.SrcToken = nil
+		config.DestToken = nil
+	}
+	if len(config.TokenPairs) == 0 {
+		return errors.New("server must config 'TokenPairs'")
+	}
+	pairIDs := make(map[string]struct{}, len(config.TokenPairs))
+	firstPair := config.TokenPairs[0]
+	for _, pair := range config.TokenPairs {
+		err := pair.CheckConfig()
+		if err != nil {
+			return err
+		}
+		if _, exist := pairIDs[pair.PairID]; exist {
+			return fmt.Errorf("duplicate token pair id '%v'", pair.PairID)
+		}
+		pairIDs[pair.PairID] = struct{}{}
+		// all pairs share the source and dest gateways
+		if !strings.EqualFold(pair.SrcToken.BlockChain, firstPair.SrcToken.BlockChain) ||
+			!strings.EqualFold(pair.SrcToken.NetID, firstPair.SrcToken.NetID) {
+			return fmt.Errorf("token pair '%v' source chain mismatch with token pair '%v'", pair.PairID, firstPair.PairID)
+		}
+		if !strings.EqualFold(pair.DestToken.BlockChain, firstPair.DestToken.BlockChain) ||
+			!strings.EqualFold(pair.DestToken.NetID, firstPair.DestToken.NetID) {
+			return fmt.Errorf("token pair '%v' dest chain mismatch with token pair '%v'", pair.PairID, firstPair.PairID)
+		}
 	}
 	return nil
 }
 
+// GetTokenPairsConfig get token pairs config
+func GetTokenPairsConfig() []*tokens.TokenPairConfig {
+	return GetConfig().TokenPairs
+}
+
 // CheckConfig check dcrm config
 func (c *DcrmConfig) CheckConfig(isServer bool) (err error) {
 	if c.RPCAddress == nil {
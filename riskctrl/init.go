@@ -25,8 +25,8 @@ func InitCrossChainBridge() {
 	srcNet := srcToken.NetID
 	dstNet := dstToken.NetID
 
-	srcBridge = bridge.NewCrossChainBridge(srcID, true)
-	dstBridge = bridge.NewCrossChainBridge(dstID, false)
+	srcBridge = bridge.NewCrossChainBridge(srcToken, true)
+	dstBridge = bridge.NewCrossChainBridge(dstToken, false)
 	log.Info("New bridge finished", "source", srcID, "sourceNet", srcNet, "dest", dstID, "destNet", dstNet)
 
 	srcBridge.SetTokenAndGateway(srcToken, srcGateway, false)
@@ -2,11 +2,18 @@ package client
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
+
+	"github.com/anyswap/CrossChain-Bridge/metrics"
 )
 
+// ErrResponseNotFound error of not found response status (404)
+var ErrResponseNotFound = errors.New("error response status: 404")
+
 // RPCGet rpc get
 func RPCGet(result interface{}, url string) error {
 	return RPCGetRequest(result, url, nil, nil, defaultTimeout)
@@ -18,12 +25,17 @@ func RPCGetWithTimeout(result interface{}, url string, timeout int) error {
 }
 
 // RPCGetRequest rpc get request
-func RPCGetRequest(result interface{}, url string, params, headers map[string]string, timeout int) error {
+func RPCGetRequest(result interface{}, url string, params, headers map[string]string, timeout int) (err error) {
+	defer func() { metrics.ObserveGatewayRequest(url, err) }()
 	resp, err := HTTPGet(url, params, headers, timeout)
 	if err != nil {
 		return fmt.Errorf("GET request error: %v (url: %v, params: %v)", err, url, params)
 	}
 
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return fmt.Errorf("%w (url: %v)", ErrResponseNotFound, url)
+	}
 	if resp.StatusCode != 200 {
 		return fmt.Errorf("error response status: %v (url: %v)", resp.StatusCode, url)
 	}
@@ -53,7 +65,8 @@ func RPCRawGetWithTimeout(url string, timeout int) (string, error) {
 }
 
 // RPCRawGetRequest rpc raw get request
-func RPCRawGetRequest(url string, params, headers map[string]string, timeout int) (string, error) {
+func RPCRawGetRequest(url string, params, headers map[string]string, timeout int) (result string, err error) {
+	defer func() { metrics.ObserveGatewayRequest(url, err) }()
 	resp, err := HTTPGet(url, params, headers, timeout)
 	if err != nil {
 		return "", fmt.Errorf("GET request error: %v (url: %v, params: %v)", err, url, params)
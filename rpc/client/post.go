@@ -6,6 +6,8 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+
+	"github.com/anyswap/CrossChain-Bridge/metrics"
 )
 
 const (
@@ -79,7 +81,8 @@ type jsonrpcResponse struct {
 }
 
 // RPCPostRequest rpc post request
-func RPCPostRequest(url string, req *Request, result interface{}) error {
+func RPCPostRequest(url string, req *Request, result interface{}) (err error) {
+	defer func() { metrics.ObserveGatewayRequest(url, err) }()
 	reqBody := &RequestBody{
 		Version: "2.0",
 		Method:  req.Method,
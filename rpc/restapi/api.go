@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/anyswap/CrossChain-Bridge/common"
 	"github.com/anyswap/CrossChain-Bridge/internal/swapapi"
@@ -36,6 +37,14 @@ func StatisticsHandler(w http.ResponseWriter, r *http.Request) {
 	writeResponse(w, res, err)
 }
 
+// TokenPairInfoHandler handler
+func TokenPairInfoHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	pairID := vars["pairid"]
+	res, err := swapapi.GetTokenPairInfo(pairID)
+	writeResponse(w, res, err)
+}
+
 // GetRawSwapinHandler handler
 func GetRawSwapinHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -60,6 +69,14 @@ func GetSwapinHandler(w http.ResponseWriter, r *http.Request) {
 	writeResponse(w, res, err)
 }
 
+// GetSwapEventsHandler handler
+func GetSwapEventsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	txid := vars["txid"]
+	res, err := swapapi.GetSwapEvents(txid)
+	writeResponse(w, res, err)
+}
+
 // GetRawSwapoutHandler handler
 func GetRawSwapoutHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -131,11 +148,86 @@ func SwapoutHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// QuerySwapHistoryHandler handler
+func QuerySwapHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	args, err := getHistoryQueryParams(r)
+	if err != nil {
+		writeResponse(w, nil, err)
+	} else {
+		res, err := swapapi.QuerySwapHistory(args)
+		writeResponse(w, res, err)
+	}
+}
+
+func getHistoryQueryParams(r *http.Request) (args *swapapi.SwapHistoryQuery, err error) {
+	vals := r.URL.Query()
+	args = &swapapi.SwapHistoryQuery{
+		SwapType: vals.Get("swaptype"),
+		PairID:   vals.Get("pairid"),
+		From:     vals.Get("from"),
+		To:       vals.Get("to"),
+		Bind:     vals.Get("bind"),
+		SwapTx:   vals.Get("swaptx"),
+		MinValue: vals.Get("minvalue"),
+		MaxValue: vals.Get("maxvalue"),
+		SortBy:   vals.Get("sortby"),
+		Order:    vals.Get("order"),
+		Cursor:   vals.Get("cursor"),
+	}
+	// status can be repeated or comma separated
+	for _, status := range vals["status"] {
+		for _, s := range strings.Split(status, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				args.Statuses = append(args.Statuses, s)
+			}
+		}
+	}
+	if args.StartTime, err = getTimeParam(vals.Get("starttime")); err != nil {
+		return nil, err
+	}
+	if args.EndTime, err = getTimeParam(vals.Get("endtime")); err != nil {
+		return nil, err
+	}
+	if limit := vals.Get("limit"); limit != "" {
+		if args.Limit, err = common.GetIntFromStr(limit); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+func getTimeParam(timeStr string) (int64, error) {
+	if timeStr == "" {
+		return 0, nil
+	}
+	timestamp, err := common.GetUint64FromStr(timeStr)
+	return int64(timestamp), err
+}
+
+// GetQuoteHandler handler
+func GetQuoteHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	args := &swapapi.SwapQuoteArgs{
+		PairID:   getPairIDParam(r),
+		SwapType: vars["swaptype"],
+		Value:    vars["value"],
+		Account:  r.URL.Query().Get("account"),
+	}
+	res, err := swapapi.GetQuote(args)
+	writeResponse(w, res, err)
+}
+
+// empty pair id means the default token pair
+func getPairIDParam(r *http.Request) string {
+	return r.URL.Query().Get("pairid")
+}
+
 // PostSwapinHandler handler
 func PostSwapinHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	txid := vars["txid"]
-	res, err := swapapi.Swapin(&txid)
+	pairID := getPairIDParam(r)
+	res, err := swapapi.Swapin(&txid, &pairID)
 	writeResponse(w, res, err)
 }
 
@@ -160,7 +252,8 @@ func PostP2shSwapinHandler(w http.ResponseWriter, r *http.Request) {
 func PostSwapoutHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	txid := vars["txid"]
-	res, err := swapapi.Swapout(&txid)
+	pairID := getPairIDParam(r)
+	res, err := swapapi.Swapout(&txid, &pairID)
 	writeResponse(w, res, err)
 }
 
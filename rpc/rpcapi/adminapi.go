@@ -1,13 +1,17 @@
 package rpcapi
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/anyswap/CrossChain-Bridge/admin"
 	"github.com/anyswap/CrossChain-Bridge/common"
-	"github.com/anyswap/CrossChain-Bridge/mongodb"
+	"github.com/anyswap/CrossChain-Bridge/internal/swapapi"
 	"github.com/anyswap/CrossChain-Bridge/params"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 )
 
@@ -20,6 +24,9 @@ const (
 	failSwapinOp  = "failswapin"
 	failSwapoutOp = "failswapout"
 	forceFlag     = "--force"
+
+	defWebhookDeadLettersLimit = 20
+	maxWebhookDeadLettersLimit = 100
 )
 
 // AdminCall admin call
@@ -38,25 +45,36 @@ func (s *RPCAPI) AdminCall(r *http.Request, rawTx, result *string) (err error) {
 	if !params.IsAdmin(sender.String()) {
 		return fmt.Errorf("sender %v is not admin", sender.String())
 	}
-	return doCall(args, result)
+	return doCall(storage.AdminActor(sender.String()), args, result)
 }
 
-func doCall(args *admin.CallArgs, result *string) error {
+// doCall do admin call, actor is recorded in the swap events of the swaps it changes
+func doCall(actor string, args *admin.CallArgs, result *string) error {
 	switch args.Method {
 	case "blacklist":
 		return blacklist(args, result)
 	case "bigvalue":
-		return bigvalue(args, result)
+		return bigvalue(actor, args, result)
 	case "maintain":
-		return maintain(args, result)
+		return maintain(actor, args, result)
 	case "reverify":
-		return reverify(args, result)
+		return reverify(actor, args, result)
 	case "reswap":
-		return reswap(args, result)
+		return reswap(actor, args, result)
 	case "manual":
-		return manual(args, result)
+		return manual(actor, args, result)
 	case "setnonce":
 		return setnonce(args, result)
+	case "webhook":
+		return webhook(args, result)
+	case "deadletter":
+		return deadletter(actor, args, result)
+	case "fee":
+		return feeOverride(args, result)
+	case "refund":
+		return refund(actor, args, result)
+	case "history":
+		return history(args, result)
 	default:
 		return fmt.Errorf("unknown admin method '%v'", args.Method)
 	}
@@ -72,12 +90,12 @@ func blacklist(args *admin.CallArgs, result *string) (err error) {
 	isQuery := false
 	switch operation {
 	case "add":
-		err = mongodb.AddToBlacklist(address)
+		err = storage.AddToBlacklist(address)
 	case "remove":
-		err = mongodb.RemoveFromBlacklist(address)
+		err = storage.RemoveFromBlacklist(address)
 	case "query":
 		isQuery = true
-		isBlacked, err = mongodb.QueryBlacklist(address)
+		isBlacked, err = storage.QueryBlacklist(address)
 	default:
 		return fmt.Errorf("unknown operation '%v'", operation)
 	}
@@ -96,7 +114,7 @@ func blacklist(args *admin.CallArgs, result *string) (err error) {
 	return nil
 }
 
-func bigvalue(args *admin.CallArgs, result *string) (err error) {
+func bigvalue(actor string, args *admin.CallArgs, result *string) (err error) {
 	if len(args.Params) != 2 {
 		return fmt.Errorf("wrong number of params, have %v want 2", len(args.Params))
 	}
@@ -104,9 +122,9 @@ func bigvalue(args *admin.CallArgs, result *string) (err error) {
 	txid := args.Params[1]
 	switch operation {
 	case passSwapinOp:
-		err = mongodb.PassSwapinBigValue(txid)
+		err = storage.PassSwapinBigValue(actor, txid)
 	case passSwapoutOp:
-		err = mongodb.PassSwapoutBigValue(txid)
+		err = storage.PassSwapoutBigValue(actor, txid)
 	default:
 		return fmt.Errorf("unknown operation '%v'", operation)
 	}
@@ -117,13 +135,23 @@ func bigvalue(args *admin.CallArgs, result *string) (err error) {
 	return nil
 }
 
-func maintain(args *admin.CallArgs, result *string) (err error) {
-	if len(args.Params) != 2 {
-		return fmt.Errorf("wrong number of params, have %v want 2", len(args.Params))
+func maintain(actor string, args *admin.CallArgs, result *string) (err error) {
+	if !(len(args.Params) == 2 || len(args.Params) == 3) {
+		return fmt.Errorf("wrong number of params, have %v want 2 or 3", len(args.Params))
 	}
 	operation := args.Params[0]
 	direction := args.Params[1]
 
+	// maintain all token pairs if pair id is not specified
+	pairIDs := tokens.GetAllPairIDs()
+	if len(args.Params) > 2 {
+		pairID := args.Params[2]
+		if !tokens.IsTokenPairExist(pairID) {
+			return fmt.Errorf("unknown token pair id '%v'", pairID)
+		}
+		pairIDs = []string{pairID}
+	}
+
 	var newDisableFlag bool
 	switch operation {
 	case "open":
@@ -148,19 +176,55 @@ func maintain(args *admin.CallArgs, result *string) (err error) {
 		return fmt.Errorf("unknown direction '%v'", direction)
 	}
 
-	if isDeposit {
-		tokens.GetTokenConfig(true).DisableSwap = newDisableFlag
-	}
+	for _, pairID := range pairIDs {
+		if isDeposit {
+			tokens.SetSwapDisabled(pairID, true, newDisableFlag)
+		}
 
-	if isWithdraw {
-		tokens.GetTokenConfig(false).DisableSwap = newDisableFlag
+		if isWithdraw {
+			tokens.SetSwapDisabled(pairID, false, newDisableFlag)
+		}
+
+		// reopening resets the tripped outflow breaker (persisted)
+		if !newDisableFlag {
+			if isDeposit {
+				err = storage.ResetOutflowBreaker(pairID, true, "reset by "+actor)
+			}
+			if err == nil && isWithdraw {
+				err = storage.ResetOutflowBreaker(pairID, false, "reset by "+actor)
+			}
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	*result = successReuslt
 	return nil
 }
 
-func reverify(args *admin.CallArgs, result *string) (err error) {
+func reverify(actor string, args *admin.CallArgs, result *string) (err error) {
+	if len(args.Params) != 2 {
+		return fmt.Errorf("wrong number of params, have %v want 2", len(args.Params))
+	}
+	operation := args.Params[0]
+	txid := args.Params[1]
+	switch operation {
+	case swapinOp:
+		err = storage.ReverifySwapin(actor, txid)
+	case swapoutOp:
+		err = storage.ReverifySwapout(actor, txid)
+	default:
+		return fmt.Errorf("unknown operation '%v'", operation)
+	}
+	if err != nil {
+		return err
+	}
+	*result = successReuslt
+	return nil
+}
+
+func refund(actor string, args *admin.CallArgs, result *string) (err error) {
 	if len(args.Params) != 2 {
 		return fmt.Errorf("wrong number of params, have %v want 2", len(args.Params))
 	}
@@ -168,9 +232,9 @@ func reverify(args *admin.CallArgs, result *string) (err error) {
 	txid := args.Params[1]
 	switch operation {
 	case swapinOp:
-		err = mongodb.ReverifySwapin(txid)
+		err = storage.ApproveRefund(actor, txid, true)
 	case swapoutOp:
-		err = mongodb.ReverifySwapout(txid)
+		err = storage.ApproveRefund(actor, txid, false)
 	default:
 		return fmt.Errorf("unknown operation '%v'", operation)
 	}
@@ -181,7 +245,7 @@ func reverify(args *admin.CallArgs, result *string) (err error) {
 	return nil
 }
 
-func reswap(args *admin.CallArgs, result *string) (err error) {
+func reswap(actor string, args *admin.CallArgs, result *string) (err error) {
 	if !(len(args.Params) == 2 || len(args.Params) == 3) {
 		return fmt.Errorf("wrong number of params, have %v want 2 or 3", len(args.Params))
 	}
@@ -198,9 +262,9 @@ func reswap(args *admin.CallArgs, result *string) (err error) {
 
 	switch operation {
 	case swapinOp:
-		err = mongodb.Reswapin(txid, forceOpt)
+		err = storage.Reswapin(actor, txid, forceOpt)
 	case swapoutOp:
-		err = mongodb.Reswapout(txid, forceOpt)
+		err = storage.Reswapout(actor, txid, forceOpt)
 	default:
 		return fmt.Errorf("unknown operation '%v'", operation)
 	}
@@ -211,7 +275,7 @@ func reswap(args *admin.CallArgs, result *string) (err error) {
 	return nil
 }
 
-func manual(args *admin.CallArgs, result *string) (err error) {
+func manual(actor string, args *admin.CallArgs, result *string) (err error) {
 	if !(len(args.Params) == 2 || len(args.Params) == 3) {
 		return fmt.Errorf("wrong number of params, have %v want 2 or 3", len(args.Params))
 	}
@@ -240,7 +304,7 @@ func manual(args *admin.CallArgs, result *string) (err error) {
 	default:
 		return fmt.Errorf("unknown operation '%v'", operation)
 	}
-	err = mongodb.ManualManageSwap(txid, memo, isSwapin, isPass)
+	err = storage.ManualManageSwap(actor, txid, memo, isSwapin, isPass)
 	if err != nil {
 		return err
 	}
@@ -249,22 +313,252 @@ func manual(args *admin.CallArgs, result *string) (err error) {
 }
 
 func setnonce(args *admin.CallArgs, result *string) (err error) {
-	if len(args.Params) != 2 {
-		return fmt.Errorf("wrong number of params, have %v want 2", len(args.Params))
+	if !(len(args.Params) == 2 || len(args.Params) == 3) {
+		return fmt.Errorf("wrong number of params, have %v want 2 or 3", len(args.Params))
 	}
 	operation := args.Params[0]
 	nonce, err := common.GetUint64FromStr(args.Params[1])
 	if err != nil {
 		return fmt.Errorf("wrong nonce value, %v", err)
 	}
+	var pairID string // empty means the default token pair
+	if len(args.Params) > 2 {
+		pairID = args.Params[2]
+	}
+	if !tokens.IsTokenPairExist(pairID) {
+		return fmt.Errorf("unknown token pair id '%v'", pairID)
+	}
 	switch operation {
 	case swapinOp:
-		tokens.DstBridge.SetNonce(nonce)
+		err = tokens.GetCrossChainBridge(pairID, false).SetNonce(nonce)
 	case swapoutOp:
-		tokens.SrcBridge.SetNonce(nonce)
+		err = tokens.GetCrossChainBridge(pairID, true).SetNonce(nonce)
+	default:
+		return fmt.Errorf("unknown operation '%v'", operation)
+	}
+	if err != nil {
+		return err
+	}
+	*result = successReuslt
+	return nil
+}
+
+func webhook(args *admin.CallArgs, result *string) (err error) {
+	if len(args.Params) == 0 {
+		return fmt.Errorf("wrong number of params, have 0 want at least 1")
+	}
+	operation := args.Params[0]
+	switch operation {
+	case "deadletters":
+		return webhookDeadLetters(args.Params[1:], result)
+	case "redeliver", "remove":
+		if len(args.Params) != 2 {
+			return fmt.Errorf("wrong number of params, have %v want 2", len(args.Params))
+		}
+		key := args.Params[1]
+		if operation == "redeliver" {
+			err = storage.RedeliverWebhook(key)
+		} else {
+			err = storage.RemoveWebhookDelivery(key)
+		}
+	default:
+		return fmt.Errorf("unknown operation '%v'", operation)
+	}
+	if err != nil {
+		return err
+	}
+	*result = successReuslt
+	return nil
+}
+
+// history print the status timeline of swap, one event per line
+func history(args *admin.CallArgs, result *string) (err error) {
+	if len(args.Params) != 1 {
+		return fmt.Errorf("wrong number of params, have %v want 1", len(args.Params))
+	}
+	txid := args.Params[0]
+	events, err := storage.FindSwapEvents(txid)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("no swap events of %v", txid)
+	}
+	lines := make([]string, 0, len(events))
+	for _, event := range swapapi.ConvertSwapEventsToSwapEventInfos(events) {
+		line := fmt.Sprintf("%v %v %v %v", time.Unix(event.Timestamp, 0).Format("2006-01-02 15:04:05"), event.SwapType, event.Target, event.Action)
+		if event.Action == storage.SwapEventAdd {
+			line += " " + event.StatusMsg
+		} else {
+			line += fmt.Sprintf(" %v -> %v", event.OldStatusMsg, event.StatusMsg)
+		}
+		line += " by " + event.Actor
+		if event.SwapTx != "" {
+			line += " swaptx=" + event.SwapTx
+		}
+		if event.RefundTx != "" {
+			line += " refundtx=" + event.RefundTx
+		}
+		if event.Memo != "" {
+			line += fmt.Sprintf(" memo=%q", event.Memo)
+		}
+		lines = append(lines, line)
+	}
+	*result = strings.Join(lines, "\n")
+	return nil
+}
+
+// deadletter list or requeue swaps in dead-letter status
+func deadletter(actor string, args *admin.CallArgs, result *string) (err error) {
+	if len(args.Params) == 0 {
+		return fmt.Errorf("wrong number of params, have 0 want at least 1")
+	}
+	operation := args.Params[0]
+	switch operation {
+	case "list":
+		if len(args.Params) > 2 {
+			return fmt.Errorf("wrong number of params, have %v want 1 or 2", len(args.Params))
+		}
+		var direction string // empty means both swapin and swapout
+		if len(args.Params) > 1 {
+			direction = args.Params[1]
+		}
+		return deadLetterSwaps(direction, result)
+	case "requeue":
+		if len(args.Params) != 3 {
+			return fmt.Errorf("wrong number of params, have %v want 3", len(args.Params))
+		}
+		txid := args.Params[2]
+		switch args.Params[1] {
+		case swapinOp:
+			err = storage.RequeueSwap(actor, txid, true)
+		case swapoutOp:
+			err = storage.RequeueSwap(actor, txid, false)
+		default:
+			return fmt.Errorf("unknown direction '%v'", args.Params[1])
+		}
 	default:
 		return fmt.Errorf("unknown operation '%v'", operation)
 	}
+	if err != nil {
+		return err
+	}
 	*result = successReuslt
 	return nil
 }
+
+// deadLetterSwaps print dead-letter swaps, one swap per line
+func deadLetterSwaps(direction string, result *string) error {
+	var directions []bool
+	switch direction {
+	case "":
+		directions = []bool{true, false}
+	case swapinOp:
+		directions = []bool{true}
+	case swapoutOp:
+		directions = []bool{false}
+	default:
+		return fmt.Errorf("unknown direction '%v'", direction)
+	}
+	var lines []string
+	for _, isSwapin := range directions {
+		swaps, err := storage.FindDeadLetterSwaps(isSwapin)
+		if err != nil {
+			return err
+		}
+		swapType := swapoutOp
+		if isSwapin {
+			swapType = swapinOp
+		}
+		for _, swap := range swaps {
+			line := fmt.Sprintf("%v %v %v pairid=%v retrycount=%v memo=%q", time.Unix(swap.Timestamp, 0).Format("2006-01-02 15:04:05"), swapType, swap.TxID, swap.PairID, swap.RetryCount, swap.Memo)
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		*result = "no dead-letter swaps"
+		return nil
+	}
+	*result = strings.Join(lines, "\n")
+	return nil
+}
+
+func webhookDeadLetters(params []string, result *string) error {
+	if len(params) > 2 {
+		return fmt.Errorf("wrong number of params, have %v want at most 3", len(params)+1)
+	}
+	offset, limit := uint64(0), uint64(defWebhookDeadLettersLimit)
+	var err error
+	if len(params) > 0 {
+		if offset, err = common.GetUint64FromStr(params[0]); err != nil {
+			return fmt.Errorf("wrong offset value, %v", err)
+		}
+	}
+	if len(params) > 1 {
+		if limit, err = common.GetUint64FromStr(params[1]); err != nil {
+			return fmt.Errorf("wrong limit value, %v", err)
+		}
+		if limit == 0 || limit > maxWebhookDeadLettersLimit {
+			limit = maxWebhookDeadLettersLimit
+		}
+	}
+	deliveries, err := storage.FindDeadWebhookDeliveries(int(offset), int(limit))
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(deliveries, "", "  ")
+	if err != nil {
+		return err
+	}
+	*result = string(data)
+	return nil
+}
+
+func feeOverride(args *admin.CallArgs, result *string) (err error) {
+	if len(args.Params) == 0 {
+		return fmt.Errorf("wrong number of params, have 0 want at least 1")
+	}
+	operation := args.Params[0]
+	var data interface{}
+	switch operation {
+	case "set":
+		if !(len(args.Params) == 4 || len(args.Params) == 5) {
+			return fmt.Errorf("wrong number of params, have %v want 4 or 5", len(args.Params))
+		}
+		var memo string
+		if len(args.Params) > 4 {
+			memo = args.Params[4]
+		}
+		err = storage.SetFeeOverride(args.Params[1], args.Params[2], args.Params[3], memo)
+	case "remove":
+		if len(args.Params) != 2 {
+			return fmt.Errorf("wrong number of params, have %v want 2", len(args.Params))
+		}
+		err = storage.RemoveFeeOverride(args.Params[1])
+	case "query":
+		if len(args.Params) != 2 {
+			return fmt.Errorf("wrong number of params, have %v want 2", len(args.Params))
+		}
+		data, err = storage.FindFeeOverride(args.Params[1])
+	case "list":
+		if len(args.Params) != 1 {
+			return fmt.Errorf("wrong number of params, have %v want 1", len(args.Params))
+		}
+		data, err = storage.FindFeeOverrides()
+	default:
+		return fmt.Errorf("unknown operation '%v'", operation)
+	}
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		*result = successReuslt
+		return nil
+	}
+	jsdata, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	*result = string(jsdata)
+	return nil
+}
@@ -1,6 +1,7 @@
 package rpcapi
 
 import (
+	"encoding/json"
 	"net/http"
 
 	"github.com/anyswap/CrossChain-Bridge/internal/swapapi"
@@ -30,6 +31,15 @@ func (s *RPCAPI) GetServerInfo(r *http.Request, args *RPCNullArgs, result *swapa
 	return err
 }
 
+// GetTokenPairInfo api
+func (s *RPCAPI) GetTokenPairInfo(r *http.Request, pairID *string, result *swapapi.TokenPairInfo) error {
+	res, err := swapapi.GetTokenPairInfo(*pairID)
+	if err == nil && res != nil {
+		*result = *res
+	}
+	return err
+}
+
 // GetSwapStatistics api
 func (s *RPCAPI) GetSwapStatistics(r *http.Request, args *RPCNullArgs, result *swapapi.SwapStatistics) error {
 	res, err := swapapi.GetSwapStatistics()
@@ -118,9 +128,46 @@ func (s *RPCAPI) GetSwapoutHistory(r *http.Request, args *RPCQueryHistoryArgs, r
 	return err
 }
 
+// QuerySwapHistory api
+func (s *RPCAPI) QuerySwapHistory(r *http.Request, args *swapapi.SwapHistoryQuery, result *swapapi.SwapHistoryResult) error {
+	res, err := swapapi.QuerySwapHistory(args)
+	if err == nil && res != nil {
+		*result = *res
+	}
+	return err
+}
+
+// GetQuote api
+func (s *RPCAPI) GetQuote(r *http.Request, args *swapapi.SwapQuoteArgs, result *swapapi.SwapQuote) error {
+	res, err := swapapi.GetQuote(args)
+	if err == nil && res != nil {
+		*result = *res
+	}
+	return err
+}
+
+// RPCTxAndPairIDArgs args of tx and token pair.
+// a plain txid string is also accepted and means the default token pair.
+type RPCTxAndPairIDArgs struct {
+	TxID   string `json:"txid"`
+	PairID string `json:"pairid"`
+}
+
+// UnmarshalJSON unmarshal from txid string or args object
+func (args *RPCTxAndPairIDArgs) UnmarshalJSON(input []byte) error {
+	var txid string
+	if err := json.Unmarshal(input, &txid); err == nil {
+		args.TxID = txid
+		args.PairID = ""
+		return nil
+	}
+	type plainArgs RPCTxAndPairIDArgs
+	return json.Unmarshal(input, (*plainArgs)(args))
+}
+
 // Swapin api
-func (s *RPCAPI) Swapin(r *http.Request, txid *string, result *swapapi.PostResult) error {
-	res, err := swapapi.Swapin(txid)
+func (s *RPCAPI) Swapin(r *http.Request, args *RPCTxAndPairIDArgs, result *swapapi.PostResult) error {
+	res, err := swapapi.Swapin(&args.TxID, &args.PairID)
 	if err == nil && res != nil {
 		*result = *res
 	}
@@ -152,8 +199,8 @@ func (s *RPCAPI) P2shSwapin(r *http.Request, args *RPCP2shSwapinArgs, result *sw
 }
 
 // Swapout api
-func (s *RPCAPI) Swapout(r *http.Request, txid *string, result *swapapi.PostResult) error {
-	res, err := swapapi.Swapout(txid)
+func (s *RPCAPI) Swapout(r *http.Request, args *RPCTxAndPairIDArgs, result *swapapi.PostResult) error {
+	res, err := swapapi.Swapout(&args.TxID, &args.PairID)
 	if err == nil && res != nil {
 		*result = *res
 	}
@@ -190,9 +237,28 @@ func (s *RPCAPI) GetP2shAddressInfo(r *http.Request, p2shAddress *string, result
 	return err
 }
 
+// RPCLatestScanInfoArgs args of latest scan info.
+// a plain bool is also accepted and means the default token pair.
+type RPCLatestScanInfoArgs struct {
+	IsSrc  bool   `json:"isSrc"`
+	PairID string `json:"pairid"`
+}
+
+// UnmarshalJSON unmarshal from isSrc bool or args object
+func (args *RPCLatestScanInfoArgs) UnmarshalJSON(input []byte) error {
+	var isSrc bool
+	if err := json.Unmarshal(input, &isSrc); err == nil {
+		args.IsSrc = isSrc
+		args.PairID = ""
+		return nil
+	}
+	type plainArgs RPCLatestScanInfoArgs
+	return json.Unmarshal(input, (*plainArgs)(args))
+}
+
 // GetLatestScanInfo api
-func (s *RPCAPI) GetLatestScanInfo(r *http.Request, isSrc *bool, result *swapapi.LatestScanInfo) error {
-	res, err := swapapi.GetLatestScanInfo(*isSrc)
+func (s *RPCAPI) GetLatestScanInfo(r *http.Request, args *RPCLatestScanInfoArgs, result *swapapi.LatestScanInfo) error {
+	res, err := swapapi.GetLatestScanInfo(args.PairID, args.IsSrc)
 	if err == nil && res != nil {
 		*result = *res
 	}
@@ -208,6 +274,24 @@ func (s *RPCAPI) RegisterAddress(r *http.Request, address *string, result *swapa
 	return err
 }
 
+// GetFeeOverride api
+func (s *RPCAPI) GetFeeOverride(r *http.Request, address *string, result *swapapi.FeeOverride) error {
+	res, err := swapapi.GetFeeOverride(*address)
+	if err == nil && res != nil {
+		*result = *res
+	}
+	return err
+}
+
+// GetSwapEvents api
+func (s *RPCAPI) GetSwapEvents(r *http.Request, txid *string, result *[]*swapapi.SwapEventInfo) error {
+	res, err := swapapi.GetSwapEvents(*txid)
+	if err == nil && res != nil {
+		*result = res
+	}
+	return err
+}
+
 // GetRegisteredAddress api
 func (s *RPCAPI) GetRegisteredAddress(r *http.Request, address *string, result *swapapi.RegisteredAddress) error {
 	res, err := swapapi.GetRegisteredAddress(*address)
@@ -12,30 +12,43 @@ import (
 
 // BuildSwapoutTxArgs build swapout tx args
 type BuildSwapoutTxArgs struct {
-	From     common.Address  `json:"from"`
-	Value    *hexutil.Big    `json:"value"`
-	Bind     string          `json:"bind"`
-	Gas      *hexutil.Uint64 `json:"gas"`
-	GasPrice *hexutil.Big    `json:"gasPrice"`
-	Nonce    *hexutil.Uint64 `json:"nonce"`
+	From      common.Address  `json:"from"`
+	Value     *hexutil.Big    `json:"value"`
+	Bind      string          `json:"bind"`
+	Gas       *hexutil.Uint64 `json:"gas"`
+	GasPrice  *hexutil.Big    `json:"gasPrice"`
+	GasTipCap *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	GasFeeCap *hexutil.Big    `json:"maxFeePerGas"`
+	Nonce     *hexutil.Uint64 `json:"nonce"`
+	PairID    string          `json:"pairid"`
 }
 
 // BuildSwapoutTx build swapout tx
 func (s *RPCAPI) BuildSwapoutTx(r *http.Request, args *BuildSwapoutTxArgs, result *types.Transaction) error {
 	from := args.From.String()
-	token, gateway := tokens.DstBridge.GetTokenAndGateway()
+	dstBridge := tokens.GetCrossChainBridge(args.PairID, false)
+	if dstBridge == nil {
+		return tokens.ErrUnknownPairID
+	}
+	token, gateway := dstBridge.GetTokenAndGateway()
 	contract := token.ContractAddress
 	extraArgs := &tokens.EthExtraArgs{
-		Gas:      (*uint64)(args.Gas),
-		GasPrice: args.GasPrice.ToInt(),
-		Nonce:    (*uint64)(args.Nonce),
+		Gas:       (*uint64)(args.Gas),
+		GasPrice:  args.GasPrice.ToInt(),
+		GasTipCap: args.GasTipCap.ToInt(),
+		GasFeeCap: args.GasFeeCap.ToInt(),
+		Nonce:     (*uint64)(args.Nonce),
 	}
 	swapoutVal := args.Value.ToInt()
 	bindAddr := args.Bind
 
 	ethBridge := eth.NewCrossChainBridge(false)
+	ethBridge.PairID = dstBridge.GetPairID()
 	ethBridge.TokenConfig = token
 	ethBridge.GatewayConfig = gateway
+	if b, ok := dstBridge.(*eth.Bridge); ok {
+		ethBridge.SignerChainID = b.SignerChainID
+	}
 	tx, err := ethBridge.BuildSwapoutTx(from, contract, extraArgs, swapoutVal, bindAddr)
 	if err != nil {
 		return err
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,18 +12,22 @@ import (
 	rpcjson "github.com/gorilla/rpc/v2/json2"
 
 	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/metrics"
 	"github.com/anyswap/CrossChain-Bridge/params"
 	"github.com/anyswap/CrossChain-Bridge/rpc/restapi"
 	"github.com/anyswap/CrossChain-Bridge/rpc/rpcapi"
+	"github.com/anyswap/CrossChain-Bridge/rpc/subscribe"
 )
 
+var apiServer *http.Server
+
 // StartAPIServer start api server
 func StartAPIServer() {
+	subscribe.Start()
 	router := initRouter()
 
 	apiPort := params.GetAPIPort()
-	apiServer := params.GetConfig().APIServer
-	allowedOrigins := apiServer.AllowedOrigins
+	allowedOrigins := params.GetConfig().APIServer.AllowedOrigins
 
 	corsOptions := []handlers.CORSOption{
 		handlers.AllowedMethods([]string{"GET", "POST"}),
@@ -35,19 +40,29 @@ func StartAPIServer() {
 	}
 
 	log.Info("JSON RPC service listen and serving", "port", apiPort, "allowedOrigins", allowedOrigins)
-	svr := http.Server{
+	apiServer = &http.Server{
 		Addr:         fmt.Sprintf(":%v", apiPort),
 		ReadTimeout:  60 * time.Second,
 		WriteTimeout: 60 * time.Second,
 		Handler:      handlers.CORS(corsOptions...)(router),
 	}
 	go func() {
-		if err := svr.ListenAndServe(); err != nil {
+		if err := apiServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Error("ListenAndServe error", "err", err)
 		}
 	}()
 }
 
+// StopAPIServer stop api server gracefully,
+// it waits active requests to finish until ctx is done.
+func StopAPIServer(ctx context.Context) error {
+	if apiServer == nil {
+		return nil
+	}
+	log.Info("JSON RPC service is stopping")
+	return apiServer.Shutdown(ctx)
+}
+
 func initRouter() *mux.Router {
 	r := mux.NewRouter()
 
@@ -56,8 +71,11 @@ func initRouter() *mux.Router {
 	_ = rpcserver.RegisterService(new(rpcapi.RPCAPI), "swap")
 
 	r.Handle("/rpc", rpcserver)
+	r.Handle("/metrics", metrics.Handler()).Methods("GET")
 	r.HandleFunc("/serverinfo", restapi.SeverInfoHandler).Methods("GET")
 	r.HandleFunc("/statistics", restapi.StatisticsHandler).Methods("GET")
+	r.HandleFunc("/pairinfo/{pairid}", restapi.TokenPairInfoHandler).Methods("GET")
+	r.HandleFunc("/quote/{swaptype}/{value}", restapi.GetQuoteHandler).Methods("GET")
 	r.HandleFunc("/swapin/post/{txid}", restapi.PostSwapinHandler).Methods("POST")
 	r.HandleFunc("/swapin/retry/{txid}", restapi.RetrySwapinHandler).Methods("POST")
 	r.HandleFunc("/swapin/post/{txid}/{bind}", restapi.PostP2shSwapinHandler).Methods("POST")
@@ -70,10 +88,14 @@ func initRouter() *mux.Router {
 	r.HandleFunc("/swapout/{txid}/rawresult", restapi.GetRawSwapoutResultHandler).Methods("GET")
 	r.HandleFunc("/swapin/history/{address}", restapi.SwapinHistoryHandler).Methods("GET")
 	r.HandleFunc("/swapout/history/{address}", restapi.SwapoutHistoryHandler).Methods("GET")
+	r.HandleFunc("/history", restapi.QuerySwapHistoryHandler).Methods("GET")
+	r.HandleFunc("/events/{txid}", restapi.GetSwapEventsHandler).Methods("GET")
 	r.HandleFunc("/p2sh/{address}", restapi.GetP2shAddressInfo).Methods("GET", "POST")
 	r.HandleFunc("/p2sh/bind/{address}", restapi.RegisterP2shAddress).Methods("GET", "POST")
 	r.HandleFunc("/registered/{address}", restapi.GetRegisteredAddress).Methods("GET", "POST")
 	r.HandleFunc("/register/{address}", restapi.RegisterAddress).Methods("GET", "POST")
+	r.HandleFunc("/subscribe/events", subscribe.SSEHandler).Methods("GET")
+	r.HandleFunc("/subscribe/ws", subscribe.WebsocketHandler).Methods("GET")
 
 	methodsExcluesGet := []string{"POST", "HEAD", "PUT", "DELETE", "CONNECT", "OPTIONS", "TRACE", "PATCH"}
 	methodsExcluesPost := []string{"GET", "HEAD", "PUT", "DELETE", "CONNECT", "OPTIONS", "TRACE", "PATCH"}
@@ -81,6 +103,9 @@ func initRouter() *mux.Router {
 
 	r.HandleFunc("/serverinfo", warnHandler).Methods(methodsExcluesGet...)
 	r.HandleFunc("/statistics", warnHandler).Methods(methodsExcluesGet...)
+	r.HandleFunc("/metrics", warnHandler).Methods(methodsExcluesGet...)
+	r.HandleFunc("/pairinfo/{pairid}", warnHandler).Methods(methodsExcluesGet...)
+	r.HandleFunc("/quote/{swaptype}/{value}", warnHandler).Methods(methodsExcluesGet...)
 	r.HandleFunc("/swapin/post/{txid}", warnHandler).Methods(methodsExcluesPost...)
 	r.HandleFunc("/swapin/post/{txid}/{bind}", warnHandler).Methods(methodsExcluesPost...)
 	r.HandleFunc("/swapout/post/{txid}", warnHandler).Methods(methodsExcluesPost...)
@@ -92,10 +117,14 @@ func initRouter() *mux.Router {
 	r.HandleFunc("/swapout/{txid}/rawresult", warnHandler).Methods(methodsExcluesGet...)
 	r.HandleFunc("/swapin/history/{address}", warnHandler).Methods(methodsExcluesGet...)
 	r.HandleFunc("/swapout/history/{address}", warnHandler).Methods(methodsExcluesGet...)
+	r.HandleFunc("/history", warnHandler).Methods(methodsExcluesGet...)
+	r.HandleFunc("/events/{txid}", warnHandler).Methods(methodsExcluesGet...)
 	r.HandleFunc("/p2sh/{address}", warnHandler).Methods(methodsExcluesGetAndPost...)
 	r.HandleFunc("/p2sh/bind/{address}", warnHandler).Methods(methodsExcluesGetAndPost...)
 	r.HandleFunc("/registered/{address}", warnHandler).Methods(methodsExcluesGetAndPost...)
 	r.HandleFunc("/register/{address}", warnHandler).Methods(methodsExcluesGetAndPost...)
+	r.HandleFunc("/subscribe/events", warnHandler).Methods(methodsExcluesGet...)
+	r.HandleFunc("/subscribe/ws", warnHandler).Methods(methodsExcluesGet...)
 
 	return r
 }
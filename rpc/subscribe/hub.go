@@ -0,0 +1,219 @@
+// Package subscribe push notifications of swap status changes to subscribers.
+package subscribe
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/internal/swapapi"
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+)
+
+const (
+	// events kept in memory for resuming from last event id
+	maxHistoryEvents = 10000
+	// max count of active subscriptions
+	maxSubscriptions = 10000
+	// buffered events of each subscription, slow subscriber is dropped if full
+	subscriptionBufferSize = 256
+
+	// swap types of event
+	swapinType  = "swapin"
+	swapoutType = "swapout"
+
+	// record types of event
+	swapRecord       = "swap"
+	swapResultRecord = "swapresult"
+)
+
+var (
+	errTooManySubscriptions = errors.New("too many subscriptions")
+	errWrongSwapType        = errors.New("wrong swap type, should be 'swapin' or 'swapout'")
+
+	hub *eventHub
+
+	startOnce sync.Once
+)
+
+// SwapEvent swap status change event
+type SwapEvent struct {
+	ID       uint64            `json:"id"`
+	SwapType string            `json:"swaptype"`
+	Record   string            `json:"record"`
+	Swap     *swapapi.SwapInfo `json:"swap"`
+}
+
+// Filter subscription filter, empty field matches any
+type Filter struct {
+	TxID        string `json:"txid"`
+	Bind        string `json:"bind"`
+	PairID      string `json:"pairid"`
+	SwapType    string `json:"swaptype"`
+	LastEventID uint64 `json:"lastEventId"`
+}
+
+// CheckFilter check filter
+func (f *Filter) CheckFilter() error {
+	switch f.SwapType {
+	case "", swapinType, swapoutType:
+		return nil
+	default:
+		return errWrongSwapType
+	}
+}
+
+func (f *Filter) match(ev *SwapEvent) bool {
+	info := ev.Swap
+	switch {
+	case f.SwapType != "" && f.SwapType != ev.SwapType:
+	case f.TxID != "" && !strings.EqualFold(f.TxID, info.TxID):
+	case f.Bind != "" && !strings.EqualFold(f.Bind, info.Bind):
+	case f.PairID != "" && !strings.EqualFold(f.PairID, info.PairID):
+	default:
+		return true
+	}
+	return false
+}
+
+// Subscription subscription of swap events
+type Subscription struct {
+	filter  *Filter
+	backlog []*SwapEvent // missed events since last event id
+	ch      chan *SwapEvent
+}
+
+// Backlog events happened after last event id
+func (s *Subscription) Backlog() []*SwapEvent {
+	return s.backlog
+}
+
+// Events channel of live events, it is closed if the subscriber is too slow
+func (s *Subscription) Events() <-chan *SwapEvent {
+	return s.ch
+}
+
+type eventHub struct {
+	mu      sync.Mutex
+	nextID  uint64
+	history []*SwapEvent // ring buffer
+	head    int          // index of the oldest event if history is full
+	subs    map[*Subscription]struct{}
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{
+		// event id increases across restart, so that resuming with an
+		// event id of the previous run replays all the kept events
+		nextID: uint64(time.Now().Unix()) << 20,
+		subs:   make(map[*Subscription]struct{}),
+	}
+}
+
+// Start start receiving swap updates from storage
+func Start() {
+	startOnce.Do(func() {
+		hub = newEventHub()
+		storage.AddSwapUpdateHandler(onSwapUpdated)
+		log.Info("start swap subscription service")
+	})
+}
+
+// Subscribe subscribe swap events with filter
+func Subscribe(filter *Filter) (*Subscription, error) {
+	if err := filter.CheckFilter(); err != nil {
+		return nil, err
+	}
+	if hub == nil {
+		Start()
+	}
+	return hub.subscribe(filter)
+}
+
+// Unsubscribe unsubscribe
+func Unsubscribe(sub *Subscription) {
+	if hub != nil {
+		hub.unsubscribe(sub)
+	}
+}
+
+func onSwapUpdated(isSwapin bool, swap *storage.Swap, result *storage.SwapResult) {
+	ev := &SwapEvent{SwapType: swapoutType}
+	if isSwapin {
+		ev.SwapType = swapinType
+	}
+	if swap != nil {
+		ev.Record = swapRecord
+		ev.Swap = swapapi.ConvertMgoSwapToSwapInfo(swap)
+	} else {
+		ev.Record = swapResultRecord
+		ev.Swap = swapapi.ConvertMgoSwapResultToSwapInfo(result)
+	}
+	hub.publish(ev)
+}
+
+func (h *eventHub) publish(ev *SwapEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.nextID++
+	ev.ID = h.nextID
+
+	if len(h.history) < maxHistoryEvents {
+		h.history = append(h.history, ev)
+	} else {
+		h.history[h.head] = ev
+		h.head = (h.head + 1) % maxHistoryEvents
+	}
+
+	for sub := range h.subs {
+		if !sub.filter.match(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			log.Warn("drop slow swap subscriber", "filter", sub.filter)
+			h.removeLocked(sub)
+		}
+	}
+}
+
+func (h *eventHub) subscribe(filter *Filter) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.subs) >= maxSubscriptions {
+		return nil, errTooManySubscriptions
+	}
+	sub := &Subscription{
+		filter: filter,
+		ch:     make(chan *SwapEvent, subscriptionBufferSize),
+	}
+	if filter.LastEventID != 0 {
+		count := len(h.history)
+		for i := 0; i < count; i++ {
+			ev := h.history[(h.head+i)%count]
+			if ev.ID > filter.LastEventID && filter.match(ev) {
+				sub.backlog = append(sub.backlog, ev)
+			}
+		}
+	}
+	h.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+func (h *eventHub) unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(sub)
+}
+
+func (h *eventHub) removeLocked(sub *Subscription) {
+	if _, exist := h.subs[sub]; exist {
+		delete(h.subs, sub)
+		close(sub.ch)
+	}
+}
@@ -0,0 +1,109 @@
+package subscribe
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// the api server closes connections after its write timeout (60s),
+	// so end the stream before it and let the client reconnect and resume.
+	sseMaxDuration      = 50 * time.Second
+	sseKeepAlive        = 15 * time.Second
+	sseRetryMillisecond = 1000
+)
+
+// SSEHandler server-sent events handler,
+// filter is specified by the query parameters 'txid', 'bind', 'pairid' and 'swaptype',
+// resume from the 'Last-Event-ID' header or the 'lastEventId' query parameter.
+func SSEHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	filter, err := parseSSEFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sub, err := Subscribe(filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer Unsubscribe(sub)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetryMillisecond)
+
+	for _, ev := range sub.Backlog() {
+		if err = writeSSEEvent(w, ev); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	timeout := time.NewTimer(sseMaxDuration)
+	defer timeout.Stop()
+
+	for {
+		select {
+		case ev, ok := <-sub.Events():
+			if !ok {
+				return
+			}
+			if err = writeSSEEvent(w, ev); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err = fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		case <-timeout.C:
+			return
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func parseSSEFilter(r *http.Request) (*Filter, error) {
+	query := r.URL.Query()
+	filter := &Filter{
+		TxID:     query.Get("txid"),
+		Bind:     query.Get("bind"),
+		PairID:   query.Get("pairid"),
+		SwapType: query.Get("swaptype"),
+	}
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = query.Get("lastEventId")
+	}
+	if lastEventID != "" {
+		id, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("wrong last event id %v", lastEventID)
+		}
+		filter.LastEventID = id
+	}
+	return filter, filter.CheckFilter()
+}
+
+func writeSSEEvent(w http.ResponseWriter, ev *SwapEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.SwapType, data)
+	return err
+}
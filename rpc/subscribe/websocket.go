@@ -0,0 +1,308 @@
+package subscribe
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/params"
+)
+
+const (
+	wsReadLimit    = 4096
+	wsWriteTimeout = 10 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	wsPingInterval = 30 * time.Second
+	wsOutQueueSize = 64
+
+	methodSubscribe    = "swap.Subscribe"
+	methodUnsubscribe  = "swap.Unsubscribe"
+	methodNotification = "swap.Subscription"
+
+	errCodeParseError     = -32700
+	errCodeInvalidRequest = -32600
+	errCodeMethodNotFound = -32601
+	errCodeInvalidParams  = -32602
+	errCodeServerError    = -32000
+)
+
+var (
+	errInvalidParams = errors.New("invalid params")
+	errNotSubscribed = errors.New("subscription not found")
+
+	upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin:     checkOrigin,
+	}
+)
+
+type jsonrpcRequest struct {
+	Version string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params"`
+}
+
+type jsonrpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type jsonrpcResponse struct {
+	Version string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result,omitempty"`
+	Error   *jsonrpcError    `json:"error,omitempty"`
+}
+
+type jsonrpcNotification struct {
+	Version string              `json:"jsonrpc"`
+	Method  string              `json:"method"`
+	Params  *notificationParams `json:"params"`
+}
+
+type notificationParams struct {
+	Subscription string     `json:"subscription"`
+	Result       *SwapEvent `json:"result"`
+}
+
+type wsConn struct {
+	conn *websocket.Conn
+	out  chan interface{}
+	done chan struct{}
+
+	mu     sync.Mutex
+	subs   map[string]*Subscription
+	nextID uint64
+}
+
+// WebsocketHandler JSON-RPC over websocket handler,
+// call 'swap.Subscribe' with a filter to receive 'swap.Subscription' notifications,
+// and call 'swap.Unsubscribe' with the returned subscription id to stop it.
+func WebsocketHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Debug("websocket upgrade failed", "err", err)
+		return
+	}
+	c := &wsConn{
+		conn: conn,
+		out:  make(chan interface{}, wsOutQueueSize),
+		done: make(chan struct{}),
+		subs: make(map[string]*Subscription),
+	}
+	go c.writeLoop()
+	c.readLoop()
+}
+
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	allowedOrigins := params.GetConfig().APIServer.AllowedOrigins
+	if len(allowedOrigins) == 0 {
+		return true
+	}
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *wsConn) readLoop() {
+	defer c.close()
+	c.conn.SetReadLimit(wsReadLimit)
+	_ = c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	})
+	for {
+		_, msg, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		_ = c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		var req jsonrpcRequest
+		if err = json.Unmarshal(msg, &req); err != nil {
+			c.sendError(nil, errCodeParseError, err)
+			continue
+		}
+		c.handleRequest(&req)
+	}
+}
+
+func (c *wsConn) writeLoop() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer func() {
+		ticker.Stop()
+		_ = c.conn.Close()
+	}()
+	for {
+		select {
+		case msg := <-c.out:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *wsConn) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case <-c.done:
+		return
+	default:
+		close(c.done)
+	}
+	for id, sub := range c.subs {
+		Unsubscribe(sub)
+		delete(c.subs, id)
+	}
+}
+
+func (c *wsConn) send(msg interface{}) bool {
+	select {
+	case c.out <- msg:
+		return true
+	case <-c.done:
+		return false
+	}
+}
+
+func (c *wsConn) sendError(id *json.RawMessage, code int, err error) {
+	c.send(&jsonrpcResponse{
+		Version: "2.0",
+		ID:      id,
+		Error:   &jsonrpcError{Code: code, Message: err.Error()},
+	})
+}
+
+func (c *wsConn) handleRequest(req *jsonrpcRequest) {
+	if req.Version != "2.0" || req.ID == nil {
+		c.sendError(req.ID, errCodeInvalidRequest, errors.New("invalid jsonrpc request"))
+		return
+	}
+	switch req.Method {
+	case methodSubscribe:
+		var filter Filter
+		if err := parseParams(req.Params, &filter); err != nil {
+			c.sendError(req.ID, errCodeInvalidParams, err)
+			return
+		}
+		c.subscribe(req.ID, &filter)
+	case methodUnsubscribe:
+		var subID string
+		if err := parseParams(req.Params, &subID); err != nil {
+			c.sendError(req.ID, errCodeInvalidParams, err)
+			return
+		}
+		if !c.unsubscribe(subID) {
+			c.sendError(req.ID, errCodeServerError, errNotSubscribed)
+			return
+		}
+		c.send(&jsonrpcResponse{Version: "2.0", ID: req.ID, Result: true})
+	default:
+		c.sendError(req.ID, errCodeMethodNotFound, errors.New("method not found"))
+	}
+}
+
+// parseParams accept both `[arg]` and `arg` style params
+func parseParams(rawParams json.RawMessage, arg interface{}) error {
+	if len(rawParams) == 0 {
+		return errInvalidParams
+	}
+	if rawParams[0] == '[' {
+		var args []json.RawMessage
+		if err := json.Unmarshal(rawParams, &args); err != nil || len(args) != 1 {
+			return errInvalidParams
+		}
+		rawParams = args[0]
+	}
+	if err := json.Unmarshal(rawParams, arg); err != nil {
+		return errInvalidParams
+	}
+	return nil
+}
+
+func (c *wsConn) subscribe(reqID *json.RawMessage, filter *Filter) {
+	sub, err := Subscribe(filter)
+	if err != nil {
+		c.sendError(reqID, errCodeServerError, err)
+		return
+	}
+	c.mu.Lock()
+	select {
+	case <-c.done:
+		c.mu.Unlock()
+		Unsubscribe(sub)
+		return
+	default:
+	}
+	c.nextID++
+	subID := "0x" + strconv.FormatUint(c.nextID, 16)
+	c.subs[subID] = sub
+	c.mu.Unlock()
+
+	// response must be sent before notifications
+	c.send(&jsonrpcResponse{Version: "2.0", ID: reqID, Result: subID})
+	go c.forward(subID, sub)
+}
+
+func (c *wsConn) unsubscribe(subID string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sub, exist := c.subs[subID]
+	if !exist {
+		return false
+	}
+	Unsubscribe(sub)
+	delete(c.subs, subID)
+	return true
+}
+
+func (c *wsConn) forward(subID string, sub *Subscription) {
+	notify := func(ev *SwapEvent) bool {
+		return c.send(&jsonrpcNotification{
+			Version: "2.0",
+			Method:  methodNotification,
+			Params:  &notificationParams{Subscription: subID, Result: ev},
+		})
+	}
+	for _, ev := range sub.Backlog() {
+		if !notify(ev) {
+			return
+		}
+	}
+	for ev := range sub.Events() {
+		if !notify(ev) {
+			return
+		}
+	}
+	// the subscription is dropped by hub if subscriber is too slow,
+	// close the connection to let the client resubscribe with last event id.
+	c.mu.Lock()
+	_, exist := c.subs[subID]
+	c.mu.Unlock()
+	if exist {
+		_ = c.conn.Close()
+	}
+}
@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+// --------------- blacklist --------------------------------
+
+// AddToBlacklist add to blacklist
+func AddToBlacklist(address string) error {
+	mb := &BlackAccount{
+		Key:       strings.ToLower(address),
+		Timestamp: time.Now().Unix(),
+	}
+	err := swapStore.AddToBlacklist(mb)
+	if err == nil {
+		log.Info("add to black list success", "address", address)
+	} else {
+		log.Info("add to black list failed", "address", address, "err", err)
+	}
+	return err
+}
+
+// RemoveFromBlacklist remove from blacklist
+func RemoveFromBlacklist(address string) error {
+	err := swapStore.RemoveFromBlacklist(strings.ToLower(address))
+	if err == nil {
+		log.Info("remove from black list success", "address", address)
+	} else {
+		log.Info("remove from black list failed", "address", address, "err", err)
+	}
+	return err
+}
+
+// QueryBlacklist query if is blacked
+func QueryBlacklist(address string) (isBlacked bool, err error) {
+	_, err = swapStore.FindBlackAccount(strings.ToLower(address))
+	if err == nil {
+		return true, nil
+	}
+	if err == ErrItemNotFound {
+		return false, nil
+	}
+	return false, err
+}
+
+// PassSwapinBigValue pass swapin big value
+func PassSwapinBigValue(actor, txid string) error {
+	return passBigValue(actor, txid, true)
+}
+
+// PassSwapoutBigValue pass swapout big value
+func PassSwapoutBigValue(actor, txid string) error {
+	return passBigValue(actor, txid, false)
+}
+
+func passBigValue(actor, txid string, isSwapin bool) error {
+	swap, err := FindSwap(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	if swap.Status != TxWithBigValue {
+		return fmt.Errorf("swap status is %v, not big value status %v", swap.Status.String(), TxWithBigValue.String())
+	}
+	err = UpdateSwapStatusFrom(actor, isSwapin, txid, swap.Status, TxNotSwapped, time.Now().Unix(), "")
+	if err != nil {
+		return err
+	}
+	return addSwapResultOutflow(isSwapin, txid)
+}
+
+// ApproveRefund approve refund which needs approval
+func ApproveRefund(actor, txid string, isSwapin bool) error {
+	swap, err := FindSwap(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	if swap.Status != TxRefundNeedApproval {
+		return fmt.Errorf("swap status is %v, not refund need approval status %v", swap.Status.String(), TxRefundNeedApproval.String())
+	}
+	return UpdateSwapStatusFrom(actor, isSwapin, txid, swap.Status, TxRefundApproved, time.Now().Unix(), "")
+}
+
+// ReverifySwapin reverify swapin
+func ReverifySwapin(actor, txid string) error {
+	return reverifySwap(actor, txid, true)
+}
+
+// ReverifySwapout reverify swapout
+func ReverifySwapout(actor, txid string) error {
+	return reverifySwap(actor, txid, false)
+}
+
+func reverifySwap(actor, txid string, isSwapin bool) error {
+	swap, err := FindSwap(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	if !swap.Status.CanReverify() {
+		return fmt.Errorf("swap status is %v, no need to reverify", swap.Status.String())
+	}
+	return UpdateSwapStatusFrom(actor, isSwapin, txid, swap.Status, TxNotStable, time.Now().Unix(), "")
+}
+
+// Reswapin reswapin
+func Reswapin(actor, txid, forceOpt string) error {
+	return reswap(actor, txid, forceOpt, true)
+}
+
+// Reswapout reswapout
+func Reswapout(actor, txid, forceOpt string) error {
+	return reswap(actor, txid, forceOpt, false)
+}
+
+func reswap(actor, txid, forceOpt string, isSwapin bool) error {
+	swap, err := FindSwap(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	if !swap.Status.CanReswap() {
+		return fmt.Errorf("swap status is %v, can not reswap", swap.Status.String())
+	}
+	swapResult, err := FindSwapResult(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	err = checkCanReswap(swapResult, forceOpt, isSwapin)
+	if err != nil {
+		return err
+	}
+
+	log.Info("[reswap] update status to TxNotSwapped to retry", "txid", txid, "swaptx", swapResult.SwapTx)
+	err = UpdateSwapResultStatusFrom(actor, isSwapin, txid, swapResult.Status, MatchTxEmpty, time.Now().Unix(), "")
+	if err != nil {
+		return err
+	}
+
+	return UpdateSwapStatusFrom(actor, isSwapin, txid, swap.Status, TxNotSwapped, time.Now().Unix(), "")
+}
+
+func checkCanReswap(res *SwapResult, forceOpt string, isSwapin bool) error {
+	swapType := tokens.SwapType(res.SwapType)
+	switch swapType {
+	case tokens.SwapinType:
+	case tokens.SwapoutType:
+	default:
+		return fmt.Errorf("swap type is %v, can not reswap", swapType.String())
+	}
+	switch res.Status {
+	case TxSwapFailed:
+	case MatchTxNotStable:
+	case MatchTxFailed:
+	default:
+		return fmt.Errorf("swap result status is %v, can not reswap", res.Status.String())
+	}
+	if res.SwapTx == "" {
+		return errors.New("swap without swaptx")
+	}
+	bridge := tokens.GetCrossChainBridge(res.PairID, !isSwapin)
+	if bridge == nil {
+		return tokens.ErrUnknownPairID
+	}
+	swapTxs, err := getAllSwapTxs(res, isSwapin)
+	if err != nil {
+		return err
+	}
+	// any of them may be mined (eg. the replaced tx), not only the current swap tx
+	for _, swapTx := range swapTxs {
+		_, err = bridge.GetTransaction(swapTx)
+		if err == nil {
+			return fmt.Errorf("swaptx %v exist in chain or pool", swapTx)
+		}
+		if err != tokens.ErrTxNotFound {
+			return fmt.Errorf("can not make sure swaptx %v is not in chain or pool: %v", swapTx, err)
+		}
+	}
+	return checkReswapNonce(bridge, res, forceOpt)
+}
+
+// getAllSwapTxs get all the swap txs of swap, including the replaced ones and the ones
+// of the previous swaps before reswapping (which are cleared from the swap result),
+// they are found in the swap attempt journal and the swap events.
+func getAllSwapTxs(res *SwapResult, isSwapin bool) ([]string, error) {
+	var swapTxs []string
+	exist := make(map[string]bool)
+	addSwapTx := func(swapTx string) {
+		if swapTx != "" && !exist[swapTx] {
+			exist[swapTx] = true
+			swapTxs = append(swapTxs, swapTx)
+		}
+	}
+	addSwapTx(res.SwapTx)
+	for _, swapTx := range res.OldSwapTxs {
+		addSwapTx(swapTx)
+	}
+	attempts, err := FindSwapAttempts(res.TxID)
+	if err != nil {
+		return nil, err
+	}
+	for _, attempt := range attempts {
+		swapType := tokens.SwapType(attempt.SwapType)
+		if attempt.IsSwapin == isSwapin && (swapType == tokens.SwapinType || swapType == tokens.SwapoutType) {
+			addSwapTx(attempt.Key)
+		}
+	}
+	events, err := FindSwapEvents(res.TxID)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if event.IsResult && event.IsSwapin == isSwapin {
+			addSwapTx(event.SwapTx)
+		}
+	}
+	return swapTxs, nil
+}
+
+func checkReswapNonce(bridge tokens.CrossChainBridge, res *SwapResult, forceOpt string) (err error) {
+	const forceFlag = "--force"
+	if forceOpt == forceFlag {
+		return nil
+	}
+	nonceGetter, ok := bridge.(tokens.NonceGetter)
+	if !ok {
+		return nil
+	}
+	tokenCfg, _ := bridge.GetTokenAndGateway()
+	// eth enhanced, if we fail at nonce a, we should retry after nonce a
+	// to ensure tx with nonce a is on blockchain to prevent double swapping
+	var nonce uint64
+	retryGetNonceCount := 3
+	for i := 0; i < retryGetNonceCount; i++ {
+		nonce, err = nonceGetter.GetPoolNonce(tokenCfg.DcrmAddress, "latest")
+		if err == nil {
+			break
+		}
+		log.Warn("get account nonce failed", "address", tokenCfg.DcrmAddress)
+		time.Sleep(time.Second)
+	}
+	if nonce < res.SwapNonce {
+		return errors.New("can not retry swap with lower nonce")
+	}
+	return nil
+}
+
+// FindDeadLetterSwaps find swaps in dead-letter status (at most maxCountOfResults items)
+func FindDeadLetterSwaps(isSwapin bool) ([]*Swap, error) {
+	return FindSwapsWithStatus(isSwapin, TxDeadLetter, 0)
+}
+
+// RequeueSwap move dead-letter swap back to the status it failed in (found in swap events),
+// or to TxNotStable to reverify if it is unknown. the retry count is reset.
+// a held swap is held again and admitted by the outflow limits when releasing.
+func RequeueSwap(actor, txid string, isSwapin bool) error {
+	swap, err := FindSwap(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	if swap.Status != TxDeadLetter {
+		return fmt.Errorf("swap status is %v, not dead-letter status %v", swap.Status.String(), TxDeadLetter.String())
+	}
+	status := TxNotStable
+	events, err := FindSwapEvents(txid)
+	if err != nil {
+		return err
+	}
+	for i := len(events) - 1; i >= 0; i-- {
+		event := events[i]
+		if event.IsSwapin == isSwapin && !event.IsResult && event.NewStatus == TxDeadLetter {
+			if event.OldStatus == TxNotSwapped || event.OldStatus == TxHeld {
+				status = event.OldStatus
+			}
+			break
+		}
+	}
+	log.Info("[requeue] update dead-letter swap status to retry", "txid", txid, "isSwapin", isSwapin, "status", status.String())
+	return UpdateSwapStatusFrom(actor, isSwapin, txid, swap.Status, status, time.Now().Unix(), "")
+}
+
+// ManualManageSwap manual manage swap
+func ManualManageSwap(actor, txid, memo string, isSwapin, isPass bool) error {
+	swap, err := FindSwap(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	if isPass {
+		if swap.Status.CanManualMakePass() {
+			err = UpdateSwapStatusFrom(actor, isSwapin, txid, swap.Status, TxNotSwapped, time.Now().Unix(), memo)
+			if err != nil {
+				return err
+			}
+			return addSwapResultOutflow(isSwapin, txid)
+		}
+		if swap.Status.CanReverify() {
+			return UpdateSwapStatusFrom(actor, isSwapin, txid, swap.Status, TxNotStable, time.Now().Unix(), memo)
+		}
+	} else if swap.Status.CanManualMakeFail() {
+		return UpdateSwapStatusFrom(actor, isSwapin, txid, swap.Status, ManualMakeFail, time.Now().Unix(), memo)
+	}
+	return fmt.Errorf("swap status is %v, can not operate. txid=%v isSwapin=%v isPass=%v", swap.Status.String(), txid, isSwapin, isPass)
+}
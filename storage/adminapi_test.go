@@ -0,0 +1,104 @@
+package storage_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+// bridge of test token pair, txs are in chain or pool, errs are rpc errors of txs
+type testBridge struct {
+	tokens.CrossChainBridge
+	txs  map[string]bool
+	errs map[string]error
+}
+
+func (b *testBridge) GetTransaction(txHash string) (interface{}, error) {
+	if err := b.errs[txHash]; err != nil {
+		return nil, err
+	}
+	if b.txs[txHash] {
+		return txHash, nil
+	}
+	return nil, tokens.ErrTxNotFound
+}
+
+func setTestSwapTx(t *testing.T, txid string, items *storage.SwapResultUpdateItems) {
+	items.Status = storage.MatchTxNotStable
+	if err := storage.UpdateSwapResult("test", false, txid, items); err != nil {
+		t.Fatalf("update swap result failed: %v", err)
+	}
+	if err := storage.UpdateSwapStatus("test", false, txid, storage.TxProcessed, 0, ""); err != nil {
+		t.Fatalf("update swap status failed: %v", err)
+	}
+}
+
+func checkTestReswap(t *testing.T, txid, wantErr string) {
+	err := storage.Reswapout("test", txid, "")
+	switch {
+	case wantErr == "" && err != nil:
+		t.Fatalf("reswap failed: %v", err)
+	case wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)):
+		t.Fatalf("reswap, want error '%v', got %v", wantErr, err)
+	}
+}
+
+var testReswapPairSeq int
+
+func TestReswapChecksAllSwapTxs(t *testing.T) {
+	setTestSwapStore(t)
+	bridge := &testBridge{txs: make(map[string]bool), errs: make(map[string]error)}
+	// the registry is global and the test may run repeatedly
+	testReswapPairSeq++
+	pairID := fmt.Sprintf("reswap-%v", testReswapPairSeq)
+	err := tokens.AddTokenPair(&tokens.TokenPair{PairID: pairID, SrcBridge: bridge, DstBridge: bridge})
+	if err != nil {
+		t.Fatalf("add token pair failed: %v", err)
+	}
+
+	swap := &storage.Swap{Key: "tx1", PairID: pairID, TxID: "tx1", Status: storage.TxNotSwapped}
+	if err = storage.AddSwap("test", false, swap); err != nil {
+		t.Fatalf("add swap failed: %v", err)
+	}
+	res := &storage.SwapResult{Key: "tx1", PairID: pairID, TxID: "tx1", Value: "1", SwapType: uint32(tokens.SwapoutType), Status: storage.MatchTxEmpty}
+	if err = storage.AddSwapResult("test", false, res); err != nil {
+		t.Fatalf("add swap result failed: %v", err)
+	}
+	// swap tx "a" is replaced by "b"
+	setTestSwapTx(t, "tx1", &storage.SwapResultUpdateItems{SwapTx: "a"})
+	setTestSwapTx(t, "tx1", &storage.SwapResultUpdateItems{SwapTx: "b", OldSwapTxs: []string{"a"}})
+
+	bridge.errs["a"] = errors.New("rpc error")
+	checkTestReswap(t, "tx1", "can not make sure swaptx a is not in chain or pool")
+	delete(bridge.errs, "a")
+
+	bridge.txs["a"] = true
+	checkTestReswap(t, "tx1", "swaptx a exist in chain or pool")
+	bridge.txs["a"] = false
+
+	checkTestReswap(t, "tx1", "")
+	if res, _ = storage.FindSwapResult(false, "tx1"); res.SwapTx != "" || len(res.OldSwapTxs) != 0 {
+		t.Fatalf("swap txs are not cleared by reswap, swaptx %v, old swaptxs %v", res.SwapTx, res.OldSwapTxs)
+	}
+
+	// swapped again with "c", the swap txs cleared by reswap are still checked
+	setTestSwapTx(t, "tx1", &storage.SwapResultUpdateItems{SwapTx: "c"})
+	bridge.txs["a"] = true
+	checkTestReswap(t, "tx1", "swaptx a exist in chain or pool")
+	bridge.txs["a"] = false
+
+	// the journaled swap tx which is not recorded
+	attempt := &storage.SwapAttempt{Key: "d", TxID: "tx1", PairID: pairID, SwapType: uint32(tokens.SwapoutType), Job: "swap", Status: storage.SwapAttemptSent}
+	if err = storage.SetSwapAttempt(attempt); err != nil {
+		t.Fatalf("set swap attempt failed: %v", err)
+	}
+	bridge.txs["d"] = true
+	checkTestReswap(t, "tx1", "swaptx d exist in chain or pool")
+	bridge.txs["d"] = false
+
+	checkTestReswap(t, "tx1", "")
+}
@@ -0,0 +1,531 @@
+package storage
+
+import (
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+const (
+	keyOfSwapSummary       = "latest"
+	keyOfSrcLatestScanInfo = "srclatest"
+	keyOfDstLatestScanInfo = "dstlatest"
+)
+
+var swapStore SwapStore
+
+// SetSwapStore set the storage backend
+func SetSwapStore(store SwapStore) {
+	swapStore = store
+}
+
+// HasSwapStore has storage backend set
+func HasSwapStore() bool {
+	return swapStore != nil
+}
+
+// CloseSwapStore close the storage backend
+func CloseSwapStore() error {
+	if swapStore == nil {
+		return nil
+	}
+	return swapStore.Close()
+}
+
+// --------------- swapin and swapout uniform --------------------------------
+
+// every status write is checked by the transition table (see transition.go)
+// and is compare and set on the old status, actor is who updates it (recorded in swap events).
+
+// UpdateSwapStatus update swap status from its current status
+func UpdateSwapStatus(actor string, isSwapin bool, txid string, status SwapStatus, timestamp int64, memo string) error {
+	old, err := FindSwap(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	return updateSwapStatus(actor, isSwapin, txid, old, status, timestamp, memo)
+}
+
+// UpdateSwapStatusFrom update swap status only if it is still oldStatus
+func UpdateSwapStatusFrom(actor string, isSwapin bool, txid string, oldStatus, status SwapStatus, timestamp int64, memo string) error {
+	old, err := FindSwap(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	if old.Status != oldStatus {
+		return ErrStatusMismatch
+	}
+	return updateSwapStatus(actor, isSwapin, txid, old, status, timestamp, memo)
+}
+
+func updateSwapStatus(actor string, isSwapin bool, txid string, old *Swap, status SwapStatus, timestamp int64, memo string) error {
+	err := checkSwapTransition(old.Status, status)
+	if err != nil {
+		return err
+	}
+	err = checkFencingToken()
+	if err != nil {
+		return err
+	}
+	err = swapStore.UpdateSwapStatus(isSwapin, txid, old.Status, status, timestamp, memo)
+	if err == nil {
+		addSwapUpdatedEvent(actor, isSwapin, txid, old, status, memo)
+		updated := *old
+		updated.ApplyStatusUpdate(status, timestamp, memo)
+		notifySwapUpdated(isSwapin, &updated)
+	}
+	return err
+}
+
+// UpdateSwapRetry update retry count and next retry time of swap only if its status is still status
+func UpdateSwapRetry(isSwapin bool, txid string, status SwapStatus, retryCount uint64, nextRetryTime int64) error {
+	err := checkFencingToken()
+	if err != nil {
+		return err
+	}
+	return swapStore.UpdateSwapRetry(isSwapin, txid, status, retryCount, nextRetryTime)
+}
+
+// UpdateSwapResultStatus update swap result status from its current status
+func UpdateSwapResultStatus(actor string, isSwapin bool, txid string, status SwapStatus, timestamp int64, memo string) error {
+	old, err := FindSwapResult(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	return updateSwapResultStatus(actor, isSwapin, txid, old, status, timestamp, memo)
+}
+
+// UpdateSwapResultStatusFrom update swap result status only if it is still oldStatus
+func UpdateSwapResultStatusFrom(actor string, isSwapin bool, txid string, oldStatus, status SwapStatus, timestamp int64, memo string) error {
+	old, err := FindSwapResult(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	if old.Status != oldStatus {
+		return ErrStatusMismatch
+	}
+	return updateSwapResultStatus(actor, isSwapin, txid, old, status, timestamp, memo)
+}
+
+func updateSwapResultStatus(actor string, isSwapin bool, txid string, old *SwapResult, status SwapStatus, timestamp int64, memo string) error {
+	err := checkResultTransition(old.Status, status)
+	if err != nil {
+		return err
+	}
+	err = checkFencingToken()
+	if err != nil {
+		return err
+	}
+	err = swapStore.UpdateSwapResultStatus(isSwapin, txid, old.Status, status, timestamp, memo)
+	if err == nil && status == MatchTxStable && old.Status != MatchTxStable {
+		_ = UpdateSwapStatistics(old.Value, old.SwapValue, old.SwapFee, isSwapin)
+	}
+	if err == nil {
+		addSwapResultUpdatedEvent(actor, isSwapin, txid, old, status, memo, "", "")
+		updated := *old
+		updated.ApplyStatusUpdate(status, timestamp, memo)
+		notifySwapResultUpdated(isSwapin, &updated)
+	}
+	return err
+}
+
+// UpdateSwapResult update swap result from its current status
+func UpdateSwapResult(actor string, isSwapin bool, txid string, items *SwapResultUpdateItems) error {
+	old, err := FindSwapResult(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	return updateSwapResult(actor, isSwapin, txid, old, items)
+}
+
+// UpdateSwapResultFrom update swap result only if its status is still oldStatus
+func UpdateSwapResultFrom(actor string, isSwapin bool, txid string, oldStatus SwapStatus, items *SwapResultUpdateItems) error {
+	old, err := FindSwapResult(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	if old.Status != oldStatus {
+		return ErrStatusMismatch
+	}
+	return updateSwapResult(actor, isSwapin, txid, old, items)
+}
+
+func updateSwapResult(actor string, isSwapin bool, txid string, old *SwapResult, items *SwapResultUpdateItems) error {
+	err := checkResultTransition(old.Status, items.Status)
+	if err != nil {
+		return err
+	}
+	err = checkFencingToken()
+	if err != nil {
+		return err
+	}
+	err = swapStore.UpdateSwapResult(isSwapin, txid, old.Status, items)
+	if err == nil {
+		addSwapResultUpdatedEvent(actor, isSwapin, txid, old, items.Status, items.Memo, items.SwapTx, items.RefundTx)
+		updated := *old
+		updated.ApplyUpdateItems(items)
+		notifySwapResultUpdated(isSwapin, &updated)
+	}
+	return err
+}
+
+// AddSwap add swap, actor is who registers it (recorded in swap events)
+func AddSwap(actor string, isSwapin bool, ms *Swap) error {
+	err := swapStore.AddSwap(isSwapin, ms)
+	if err == nil {
+		addSwapAddedEvent(actor, isSwapin, ms)
+		notifySwapUpdated(isSwapin, ms)
+	}
+	return err
+}
+
+// AddSwapResult add swap result, actor is who adds it (recorded in swap events)
+func AddSwapResult(actor string, isSwapin bool, mr *SwapResult) error {
+	err := checkFencingToken()
+	if err != nil {
+		return err
+	}
+	mr.ValueKey = GetValueKey(mr.Value)
+	err = swapStore.AddSwapResult(isSwapin, mr)
+	if err == nil {
+		addSwapResultAddedEvent(actor, isSwapin, mr)
+		notifySwapResultUpdated(isSwapin, mr)
+	}
+	return err
+}
+
+// FindSwapResult find swap result
+func FindSwapResult(isSwapin bool, txid string) (*SwapResult, error) {
+	return swapStore.FindSwapResult(isSwapin, txid)
+}
+
+// FindSwap find swap
+func FindSwap(isSwapin bool, txid string) (*Swap, error) {
+	return swapStore.FindSwap(isSwapin, txid)
+}
+
+// FindSwapsWithStatus find swaps with status in the past septime
+func FindSwapsWithStatus(isSwapin bool, status SwapStatus, septime int64) ([]*Swap, error) {
+	return swapStore.FindSwapsWithStatus(isSwapin, status, septime)
+}
+
+// FindExpiredSwapsWithStatus find swaps with status before septime
+func FindExpiredSwapsWithStatus(isSwapin bool, status SwapStatus, septime int64) ([]*Swap, error) {
+	return swapStore.FindExpiredSwapsWithStatus(isSwapin, status, septime)
+}
+
+// FindSwapResultsWithStatus find swap results with status in the past septime
+func FindSwapResultsWithStatus(isSwapin bool, status SwapStatus, septime int64) ([]*SwapResult, error) {
+	return swapStore.FindSwapResultsWithStatus(isSwapin, status, septime)
+}
+
+// --------------- swapin --------------------------------
+
+// AddSwapin add swapin
+func AddSwapin(actor string, ms *Swap) error {
+	return AddSwap(actor, true, ms)
+}
+
+// UpdateSwapinStatus update swapin status
+func UpdateSwapinStatus(actor, txid string, status SwapStatus, timestamp int64, memo string) error {
+	return UpdateSwapStatus(actor, true, txid, status, timestamp, memo)
+}
+
+// FindSwapin find swapin
+func FindSwapin(txid string) (*Swap, error) {
+	return FindSwap(true, txid)
+}
+
+// FindSwapinsWithStatus find swapin with status in the past septime
+func FindSwapinsWithStatus(status SwapStatus, septime int64) ([]*Swap, error) {
+	return swapStore.FindSwapsWithStatus(true, status, septime)
+}
+
+// GetCountOfSwapinsWithStatus get count of swapins with status
+func GetCountOfSwapinsWithStatus(status SwapStatus) (int, error) {
+	return swapStore.GetCountOfSwapsWithStatus(true, status)
+}
+
+// --------------- swapout --------------------------------
+
+// AddSwapout add swapout
+func AddSwapout(actor string, ms *Swap) error {
+	return AddSwap(actor, false, ms)
+}
+
+// UpdateSwapoutStatus update swapout status
+func UpdateSwapoutStatus(actor, txid string, status SwapStatus, timestamp int64, memo string) error {
+	return UpdateSwapStatus(actor, false, txid, status, timestamp, memo)
+}
+
+// FindSwapout find swapout
+func FindSwapout(txid string) (*Swap, error) {
+	return FindSwap(false, txid)
+}
+
+// FindSwapoutsWithStatus find swapout with status
+func FindSwapoutsWithStatus(status SwapStatus, septime int64) ([]*Swap, error) {
+	return swapStore.FindSwapsWithStatus(false, status, septime)
+}
+
+// GetCountOfSwapoutsWithStatus get count of swapout with status
+func GetCountOfSwapoutsWithStatus(status SwapStatus) (int, error) {
+	return swapStore.GetCountOfSwapsWithStatus(false, status)
+}
+
+// --------------- swapin result --------------------------------
+
+// AddSwapinResult add swapin result
+func AddSwapinResult(actor string, mr *SwapResult) error {
+	return AddSwapResult(actor, true, mr)
+}
+
+// UpdateSwapinResult update swapin result
+func UpdateSwapinResult(actor, txid string, items *SwapResultUpdateItems) error {
+	return UpdateSwapResult(actor, true, txid, items)
+}
+
+// UpdateSwapinResultStatus update swapin result status
+func UpdateSwapinResultStatus(actor, txid string, status SwapStatus, timestamp int64, memo string) error {
+	return UpdateSwapResultStatus(actor, true, txid, status, timestamp, memo)
+}
+
+// FindSwapinResult find swapin result
+func FindSwapinResult(txid string) (*SwapResult, error) {
+	return FindSwapResult(true, txid)
+}
+
+// FindSwapinResultsWithStatus find swapin result with status
+func FindSwapinResultsWithStatus(status SwapStatus, septime int64) ([]*SwapResult, error) {
+	return swapStore.FindSwapResultsWithStatus(true, status, septime)
+}
+
+// FindSwapinResults find swapin history results
+func FindSwapinResults(address string, offset, limit int) ([]*SwapResult, error) {
+	return swapStore.FindSwapResults(true, address, offset, limit)
+}
+
+// GetCountOfSwapinResults get count of swapin results
+func GetCountOfSwapinResults() (int, error) {
+	return swapStore.GetCountOfSwapResults(true)
+}
+
+// GetCountOfSwapinResultsWithStatus get count of swapin results with status
+func GetCountOfSwapinResultsWithStatus(status SwapStatus) (int, error) {
+	return swapStore.GetCountOfSwapResultsWithStatus(true, status)
+}
+
+// --------------- swapout result --------------------------------
+
+// AddSwapoutResult add swapout result
+func AddSwapoutResult(actor string, mr *SwapResult) error {
+	return AddSwapResult(actor, false, mr)
+}
+
+// UpdateSwapoutResult update swapout result
+func UpdateSwapoutResult(actor, txid string, items *SwapResultUpdateItems) error {
+	return UpdateSwapResult(actor, false, txid, items)
+}
+
+// UpdateSwapoutResultStatus update swapout result status
+func UpdateSwapoutResultStatus(actor, txid string, status SwapStatus, timestamp int64, memo string) error {
+	return UpdateSwapResultStatus(actor, false, txid, status, timestamp, memo)
+}
+
+// FindSwapoutResult find swapout result
+func FindSwapoutResult(txid string) (*SwapResult, error) {
+	return FindSwapResult(false, txid)
+}
+
+// FindSwapoutResultsWithStatus find swapout result with status
+func FindSwapoutResultsWithStatus(status SwapStatus, septime int64) ([]*SwapResult, error) {
+	return swapStore.FindSwapResultsWithStatus(false, status, septime)
+}
+
+// FindSwapoutResults find swapout history results
+func FindSwapoutResults(address string, offset, limit int) ([]*SwapResult, error) {
+	return swapStore.FindSwapResults(false, address, offset, limit)
+}
+
+// GetCountOfSwapoutResults get count of swapout results
+func GetCountOfSwapoutResults() (int, error) {
+	return swapStore.GetCountOfSwapResults(false)
+}
+
+// GetCountOfSwapoutResultsWithStatus get count of swapout results with status
+func GetCountOfSwapoutResultsWithStatus(status SwapStatus) (int, error) {
+	return swapStore.GetCountOfSwapResultsWithStatus(false, status)
+}
+
+// ------------------ statistics ------------------------
+
+// UpdateSwapStatistics update swap statistics,
+// swap fee is value minus swap value if not specified (same decimals).
+func UpdateSwapStatistics(value, swapValue, swapFee string, isSwapin bool) error {
+	curr, err := swapStore.FindSwapSummary()
+	if err != nil {
+		curr = &SwapSummary{}
+	}
+	curr.Key = keyOfSwapSummary
+
+	addVal, _ := new(big.Int).SetString(value, 0)
+	addSwapVal, _ := new(big.Int).SetString(swapValue, 0)
+	addSwapFee, ok := new(big.Int).SetString(swapFee, 0)
+	if !ok {
+		addSwapFee = new(big.Int).Sub(addVal, addSwapVal)
+	}
+
+	curVal := big.NewInt(0)
+	curFee := big.NewInt(0)
+
+	if isSwapin {
+		curVal.SetString(curr.TotalSwapinValue, 0)
+		curFee.SetString(curr.TotalSwapinFee, 0)
+		curVal.Add(curVal, addSwapVal)
+		curFee.Add(curFee, addSwapFee)
+		curr.StableSwapinCount++
+		curr.TotalSwapinValue = curVal.String()
+		curr.TotalSwapinFee = curFee.String()
+	} else {
+		curVal.SetString(curr.TotalSwapoutValue, 0)
+		curFee.SetString(curr.TotalSwapoutFee, 0)
+		curVal.Add(curVal, addSwapVal)
+		curFee.Add(curFee, addSwapFee)
+		curr.StableSwapoutCount++
+		curr.TotalSwapoutValue = curVal.String()
+		curr.TotalSwapoutFee = curFee.String()
+	}
+	return swapStore.UpdateSwapSummary(curr)
+}
+
+// GetSwapStatistics get swap statistics
+func GetSwapStatistics() (*SwapStatistics, error) {
+	stat := &SwapStatistics{}
+
+	if curr, _ := swapStore.FindSwapSummary(); curr != nil {
+		stat.StableSwapinCount = curr.StableSwapinCount
+		stat.TotalSwapinValue = curr.TotalSwapinValue
+		stat.TotalSwapinFee = curr.TotalSwapinFee
+		stat.StableSwapoutCount = curr.StableSwapoutCount
+		stat.TotalSwapoutValue = curr.TotalSwapoutValue
+		stat.TotalSwapoutFee = curr.TotalSwapoutFee
+	}
+
+	stat.TotalSwapinCount, _ = GetCountOfSwapinResults()
+	stat.TotalSwapoutCount, _ = GetCountOfSwapoutResults()
+	stat.PendingSwapinCount, _ = GetCountOfSwapinResultsWithStatus(MatchTxEmpty)
+	stat.PendingSwapoutCount, _ = GetCountOfSwapoutResultsWithStatus(MatchTxEmpty)
+
+	return stat, nil
+}
+
+// ------------------ p2sh address ------------------------
+
+// AddP2shAddress add p2sh address
+func AddP2shAddress(ma *P2shAddress) error {
+	return swapStore.AddP2shAddress(ma)
+}
+
+// FindP2shAddress find p2sh addrss through bind address
+func FindP2shAddress(key string) (*P2shAddress, error) {
+	return swapStore.FindP2shAddress(key)
+}
+
+// FindP2shBindAddress find bind address through p2sh address
+func FindP2shBindAddress(p2shAddress string) (string, error) {
+	return swapStore.FindP2shBindAddress(p2shAddress)
+}
+
+// FindP2shAddresses find p2sh address
+func FindP2shAddresses(offset, limit int) ([]*P2shAddress, error) {
+	return swapStore.FindP2shAddresses(offset, limit)
+}
+
+// ------------------ latest scan info ------------------------
+
+// the default pair keeps the keys used before multiple pairs
+func getLatestScanInfoKey(pairID string, isSrc bool) string {
+	key := keyOfDstLatestScanInfo
+	if isSrc {
+		key = keyOfSrcLatestScanInfo
+	}
+	if pairID == "" || pairID == tokens.DefaultPairID {
+		return key
+	}
+	return strings.ToLower(pairID) + ":" + key
+}
+
+// UpdateLatestScanInfo update latest scan info
+func UpdateLatestScanInfo(pairID string, isSrc bool, blockHeight uint64) error {
+	oldInfo, _ := FindLatestScanInfo(pairID, isSrc)
+	if oldInfo != nil {
+		oldHeight := oldInfo.BlockHeight
+		if blockHeight <= oldHeight {
+			return nil
+		}
+	}
+	return swapStore.UpdateLatestScanInfo(&LatestScanInfo{
+		Key:         getLatestScanInfoKey(pairID, isSrc),
+		BlockHeight: blockHeight,
+		Timestamp:   time.Now().Unix(),
+	})
+}
+
+// UpdateScannedBlocks save the recent scanned blocks tracked by the scanner (scan state),
+// they are tracked again on startup to detect the reorganisation when the scanner is stopped.
+func UpdateScannedBlocks(pairID string, isSrc bool, blocks []*ScannedBlock) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+	return swapStore.UpdateLatestScanInfo(&LatestScanInfo{
+		Key:         getLatestScanInfoKey(pairID, isSrc) + ":blocks",
+		BlockHeight: blocks[len(blocks)-1].Height,
+		Blocks:      blocks,
+		Timestamp:   time.Now().Unix(),
+	})
+}
+
+// FindScannedBlocks find the saved scanned blocks
+func FindScannedBlocks(pairID string, isSrc bool) ([]*ScannedBlock, error) {
+	result, err := swapStore.FindLatestScanInfo(getLatestScanInfoKey(pairID, isSrc) + ":blocks")
+	if err != nil {
+		return nil, err
+	}
+	return result.Blocks, nil
+}
+
+// FindLatestScanInfo find latest scan info,
+// returns an empty info and ErrItemNotFound if not found.
+func FindLatestScanInfo(pairID string, isSrc bool) (*LatestScanInfo, error) {
+	key := getLatestScanInfoKey(pairID, isSrc)
+	result, err := swapStore.FindLatestScanInfo(key)
+	if result == nil {
+		result = &LatestScanInfo{Key: key}
+	}
+	return result, err
+}
+
+// ------------------------ register address ------------------------------
+
+// AddRegisteredAddress add register address
+func AddRegisteredAddress(address string) error {
+	ma := &RegisteredAddress{
+		Key:       address,
+		Timestamp: time.Now().Unix(),
+	}
+	err := swapStore.AddRegisteredAddress(ma)
+	if err == nil {
+		log.Info("add register address", "key", ma.Key)
+	} else {
+		log.Debug("add register address", "key", ma.Key, "err", err)
+	}
+	return err
+}
+
+// FindRegisteredAddress find register address
+func FindRegisteredAddress(key string) (*RegisteredAddress, error) {
+	return swapStore.FindRegisteredAddress(key)
+}
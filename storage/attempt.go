@@ -0,0 +1,38 @@
+package storage
+
+import (
+	"github.com/anyswap/CrossChain-Bridge/log"
+)
+
+// swap attempt status
+const (
+	SwapAttemptSigned   = "signed"   // journaled before broadcasting
+	SwapAttemptSent     = "sent"     // broadcasted successfully
+	SwapAttemptFailed   = "failed"   // broadcasting failed
+	SwapAttemptResolved = "resolved" // found in chain or pool when reconciling
+	SwapAttemptDropped  = "dropped"  // never sent, or not found and can not be rebroadcasted when reconciling
+)
+
+// SetSwapAttempt write swap attempt to the journal
+func SetSwapAttempt(attempt *SwapAttempt) error {
+	err := checkFencingToken()
+	if err == nil {
+		err = swapStore.SetSwapAttempt(attempt)
+	}
+	if err == nil {
+		log.Info("set swap attempt success", "txid", attempt.TxID, "swaptx", attempt.Key, "job", attempt.Job, "status", attempt.Status)
+	} else {
+		log.Warn("set swap attempt failed", "txid", attempt.TxID, "swaptx", attempt.Key, "job", attempt.Job, "status", attempt.Status, "err", err)
+	}
+	return err
+}
+
+// FindSwapAttempts find swap attempts of txid
+func FindSwapAttempts(txid string) ([]*SwapAttempt, error) {
+	return swapStore.FindSwapAttempts(txid)
+}
+
+// FindSwapAttemptsWithStatus find swap attempts with status in the past septime
+func FindSwapAttemptsWithStatus(status string, septime int64) ([]*SwapAttempt, error) {
+	return swapStore.FindSwapAttemptsWithStatus(status, septime)
+}
@@ -0,0 +1,1089 @@
+package boltdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	maxCountOfResults = 5000
+)
+
+var (
+	bkSwapins           = []byte("Swapins")
+	bkSwapouts          = []byte("Swapouts")
+	bkSwapinResults     = []byte("SwapinResults")
+	bkSwapoutResults    = []byte("SwapoutResults")
+	bkP2shAddresses     = []byte("P2shAddresses")
+	bkSwapStatistics    = []byte("SwapStatistics")
+	bkLatestScanInfo    = []byte("LatestScanInfo")
+	bkRegisteredAddress = []byte("RegisteredAddress")
+	bkBlacklist         = []byte("Blacklist")
+	bkWebhookOutbox     = []byte("WebhookOutbox")
+	bkFeeOverrides      = []byte("FeeOverrides")
+	bkSwapEvents        = []byte("SwapEvents")
+	bkSwapAttempts      = []byte("SwapAttempts")
+	bkNonceAccounts     = []byte("NonceAccounts")
+	bkNonceReservations = []byte("NonceReservations")
+	bkLeases            = []byte("Leases")
+	bkOutflows          = []byte("Outflows")
+	bkOutflowBreakers   = []byte("OutflowBreakers")
+
+	allBuckets = [][]byte{
+		bkSwapins,
+		bkSwapouts,
+		bkSwapinResults,
+		bkSwapoutResults,
+		bkP2shAddresses,
+		bkSwapStatistics,
+		bkLatestScanInfo,
+		bkRegisteredAddress,
+		bkBlacklist,
+		bkWebhookOutbox,
+		bkFeeOverrides,
+		bkSwapEvents,
+		bkSwapAttempts,
+		bkNonceAccounts,
+		bkNonceReservations,
+		bkLeases,
+		bkOutflows,
+		bkOutflowBreakers,
+	}
+)
+
+// SwapStore embedded single file storage backend
+type SwapStore struct {
+	db *bolt.DB
+}
+
+var _ storage.SwapStore = (*SwapStore)(nil)
+
+// NewSwapStore open (create if not exist) the data file and new storage backend
+func NewSwapStore(dataFile string) (*SwapStore, error) {
+	db, err := bolt.Open(dataFile, 0600, &bolt.Options{Timeout: 3 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range allBuckets {
+			if _, errc := tx.CreateBucketIfNotExists(bucket); errc != nil {
+				return errc
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	log.Info("[boltdb] open data file success", "file", dataFile)
+	return &SwapStore{db: db}, nil
+}
+
+// Close close data file
+func (s *SwapStore) Close() error {
+	return s.db.Close()
+}
+
+func getSwapBucket(isSwapin bool) []byte {
+	if isSwapin {
+		return bkSwapins
+	}
+	return bkSwapouts
+}
+
+func getSwapResultBucket(isSwapin bool) []byte {
+	if isSwapin {
+		return bkSwapinResults
+	}
+	return bkSwapoutResults
+}
+
+// ------------------ common item operations ------------------------
+
+func (s *SwapStore) addItem(bucket []byte, key string, item interface{}) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b.Get([]byte(key)) != nil {
+			return storage.ErrItemIsDup
+		}
+		return b.Put([]byte(key), data)
+	})
+}
+
+func (s *SwapStore) putItem(bucket []byte, key string, item interface{}) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), data)
+	})
+}
+
+func (s *SwapStore) getItem(bucket []byte, key string, item interface{}) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get([]byte(key))
+		if data == nil {
+			return storage.ErrItemNotFound
+		}
+		return json.Unmarshal(data, item)
+	})
+}
+
+func (s *SwapStore) removeItem(bucket []byte, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		if b.Get([]byte(key)) == nil {
+			return storage.ErrItemNotFound
+		}
+		return b.Delete([]byte(key))
+	})
+}
+
+// modifyItem read, modify and write back item in one transaction,
+// nothing is written if modify returns error.
+func (s *SwapStore) modifyItem(bucket []byte, key string, item interface{}, modify func() error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bucket)
+		data := b.Get([]byte(key))
+		if data == nil {
+			return storage.ErrItemNotFound
+		}
+		if err := json.Unmarshal(data, item); err != nil {
+			return err
+		}
+		if err := modify(); err != nil {
+			return err
+		}
+		data, err := json.Marshal(item)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(key), data)
+	})
+}
+
+// forEach call fn with every raw value in bucket, stop if fn returns false
+func (s *SwapStore) forEach(bucket []byte, fn func(data []byte) (bool, error)) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucket).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			next, err := fn(v)
+			if err != nil {
+				return err
+			}
+			if !next {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// ------------------ swapin / swapout common ------------------------
+
+// AddSwap add swap
+func (s *SwapStore) AddSwap(isSwapin bool, ms *storage.Swap) error {
+	err := s.addItem(getSwapBucket(isSwapin), ms.Key, ms)
+	if err == nil {
+		log.Info("boltdb add swap", "txid", ms.TxID, "isSwapin", isSwapin)
+	} else {
+		log.Debug("boltdb add swap", "txid", ms.TxID, "isSwapin", isSwapin, "err", err)
+	}
+	return err
+}
+
+// UpdateSwapStatus update swap status
+func (s *SwapStore) UpdateSwapStatus(isSwapin bool, txid string, oldStatus, status storage.SwapStatus, timestamp int64, memo string) error {
+	var swap storage.Swap
+	err := s.modifyItem(getSwapBucket(isSwapin), txid, &swap, func() error {
+		if swap.Status != oldStatus {
+			return storage.ErrStatusMismatch
+		}
+		swap.ApplyStatusUpdate(status, timestamp, memo)
+		return nil
+	})
+	if err == nil {
+		printLog := log.Info
+		switch status {
+		case storage.TxVerifyFailed, storage.TxSwapFailed, storage.TxDeadLetter:
+			printLog = log.Warn
+		}
+		printLog("boltdb update swap status", "txid", txid, "status", status, "isSwapin", isSwapin)
+	} else {
+		log.Debug("boltdb update swap status", "txid", txid, "status", status, "isSwapin", isSwapin, "err", err)
+	}
+	return err
+}
+
+// UpdateSwapRetry update swap retry count and next retry time
+func (s *SwapStore) UpdateSwapRetry(isSwapin bool, txid string, status storage.SwapStatus, retryCount uint64, nextRetryTime int64) error {
+	var swap storage.Swap
+	err := s.modifyItem(getSwapBucket(isSwapin), txid, &swap, func() error {
+		if swap.Status != status {
+			return storage.ErrStatusMismatch
+		}
+		swap.RetryCount = retryCount
+		swap.NextRetryTime = nextRetryTime
+		return nil
+	})
+	if err == nil {
+		log.Info("boltdb update swap retry", "txid", txid, "status", status, "retrycount", retryCount, "nextretrytime", nextRetryTime, "isSwapin", isSwapin)
+	} else {
+		log.Debug("boltdb update swap retry", "txid", txid, "status", status, "isSwapin", isSwapin, "err", err)
+	}
+	return err
+}
+
+// FindSwap find swap
+func (s *SwapStore) FindSwap(isSwapin bool, txid string) (*storage.Swap, error) {
+	var result storage.Swap
+	err := s.getItem(getSwapBucket(isSwapin), txid, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// FindSwapsWithStatus find swaps with status in the past septime
+func (s *SwapStore) FindSwapsWithStatus(isSwapin bool, status storage.SwapStatus, septime int64) ([]*storage.Swap, error) {
+	return s.findSwaps(isSwapin, func(swap *storage.Swap) bool {
+		return swap.Status == status && swap.Timestamp >= septime
+	})
+}
+
+// FindExpiredSwapsWithStatus find swaps with status before septime
+func (s *SwapStore) FindExpiredSwapsWithStatus(isSwapin bool, status storage.SwapStatus, septime int64) ([]*storage.Swap, error) {
+	return s.findSwaps(isSwapin, func(swap *storage.Swap) bool {
+		return swap.Status == status && swap.Timestamp < septime
+	})
+}
+
+// findSwaps find swaps matching filter, sorted by timestamp and at most maxCountOfResults items
+func (s *SwapStore) findSwaps(isSwapin bool, filter func(swap *storage.Swap) bool) ([]*storage.Swap, error) {
+	result := make([]*storage.Swap, 0, 20)
+	err := s.forEach(getSwapBucket(isSwapin), func(data []byte) (bool, error) {
+		var swap storage.Swap
+		if err := json.Unmarshal(data, &swap); err != nil {
+			return false, err
+		}
+		if filter(&swap) {
+			result = append(result, &swap)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp < result[j].Timestamp
+	})
+	if len(result) > maxCountOfResults {
+		result = result[:maxCountOfResults]
+	}
+	return result, nil
+}
+
+// GetCountOfSwapsWithStatus get count of swaps with status
+func (s *SwapStore) GetCountOfSwapsWithStatus(isSwapin bool, status storage.SwapStatus) (int, error) {
+	count := 0
+	err := s.forEach(getSwapBucket(isSwapin), func(data []byte) (bool, error) {
+		var swap storage.Swap
+		if err := json.Unmarshal(data, &swap); err != nil {
+			return false, err
+		}
+		if swap.Status == status {
+			count++
+		}
+		return true, nil
+	})
+	return count, err
+}
+
+// ------------------ swapin / swapout result common ------------------------
+
+// AddSwapResult add swap result
+func (s *SwapStore) AddSwapResult(isSwapin bool, ms *storage.SwapResult) error {
+	err := s.addItem(getSwapResultBucket(isSwapin), ms.Key, ms)
+	if err == nil {
+		log.Info("boltdb add swap result", "txid", ms.TxID, "swaptype", ms.SwapType, "isSwapin", isSwapin)
+	} else {
+		log.Debug("boltdb add swap result", "txid", ms.TxID, "swaptype", ms.SwapType, "isSwapin", isSwapin, "err", err)
+	}
+	return err
+}
+
+// UpdateSwapResult update swap result
+func (s *SwapStore) UpdateSwapResult(isSwapin bool, txid string, oldStatus storage.SwapStatus, items *storage.SwapResultUpdateItems) error {
+	var swapResult storage.SwapResult
+	err := s.modifyItem(getSwapResultBucket(isSwapin), txid, &swapResult, func() error {
+		if swapResult.Status != oldStatus {
+			return storage.ErrStatusMismatch
+		}
+		swapResult.ApplyUpdateItems(items)
+		return nil
+	})
+	if err == nil {
+		log.Info("boltdb update swap result", "txid", txid, "updates", items, "isSwapin", isSwapin)
+	} else {
+		log.Debug("boltdb update swap result", "txid", txid, "updates", items, "isSwapin", isSwapin, "err", err)
+	}
+	return err
+}
+
+// UpdateSwapResultStatus update swap result status
+func (s *SwapStore) UpdateSwapResultStatus(isSwapin bool, txid string, oldStatus, status storage.SwapStatus, timestamp int64, memo string) error {
+	var swapResult storage.SwapResult
+	err := s.modifyItem(getSwapResultBucket(isSwapin), txid, &swapResult, func() error {
+		if swapResult.Status != oldStatus {
+			return storage.ErrStatusMismatch
+		}
+		swapResult.ApplyStatusUpdate(status, timestamp, memo)
+		return nil
+	})
+	if err == nil {
+		log.Info("boltdb update swap result status", "txid", txid, "status", status, "isSwapin", isSwapin)
+	} else {
+		log.Debug("boltdb update swap result status", "txid", txid, "status", status, "isSwapin", isSwapin, "err", err)
+	}
+	return err
+}
+
+// FindSwapResult find swap result
+func (s *SwapStore) FindSwapResult(isSwapin bool, txid string) (*storage.SwapResult, error) {
+	var result storage.SwapResult
+	err := s.getItem(getSwapResultBucket(isSwapin), txid, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *SwapStore) findSwapResults(isSwapin bool, filter func(*storage.SwapResult) bool) ([]*storage.SwapResult, error) {
+	result := make([]*storage.SwapResult, 0, 20)
+	err := s.forEach(getSwapResultBucket(isSwapin), func(data []byte) (bool, error) {
+		var swapResult storage.SwapResult
+		if err := json.Unmarshal(data, &swapResult); err != nil {
+			return false, err
+		}
+		if filter(&swapResult) {
+			result = append(result, &swapResult)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindSwapResultsWithStatus find swap results with status in the past septime
+func (s *SwapStore) FindSwapResultsWithStatus(isSwapin bool, status storage.SwapStatus, septime int64) ([]*storage.SwapResult, error) {
+	result, err := s.findSwapResults(isSwapin, func(res *storage.SwapResult) bool {
+		return res.Status == status && res.Timestamp >= septime
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp < result[j].Timestamp
+	})
+	if len(result) > maxCountOfResults {
+		result = result[:maxCountOfResults]
+	}
+	return result, nil
+}
+
+// FindSwapResults find swap history results
+func (s *SwapStore) FindSwapResults(isSwapin bool, address string, offset, limit int) ([]*storage.SwapResult, error) {
+	result, err := s.findSwapResults(isSwapin, func(res *storage.SwapResult) bool {
+		return address == "all" || res.From == address
+	})
+	if err != nil {
+		return nil, err
+	}
+	if limit >= 0 {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Timestamp < result[j].Timestamp
+		})
+	} else {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Timestamp > result[j].Timestamp
+		})
+		limit = -limit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(result) {
+		return []*storage.SwapResult{}, nil
+	}
+	result = result[offset:]
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// QuerySwapResults query swap results
+func (s *SwapStore) QuerySwapResults(isSwapin bool, query *storage.SwapResultQuery) ([]*storage.SwapResult, error) {
+	result, err := s.findSwapResults(isSwapin, func(res *storage.SwapResult) bool {
+		return query.Match(res) && query.IsAfterCursor(res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return query.Less(result[i], result[j])
+	})
+	if query.Limit > 0 && len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	return result, nil
+}
+
+// GetCountOfSwapResults get count of swap results
+func (s *SwapStore) GetCountOfSwapResults(isSwapin bool) (count int, err error) {
+	err = s.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(getSwapResultBucket(isSwapin)).Stats().KeyN
+		return nil
+	})
+	return count, err
+}
+
+// GetCountOfSwapResultsWithStatus get count of swap results with status
+func (s *SwapStore) GetCountOfSwapResultsWithStatus(isSwapin bool, status storage.SwapStatus) (int, error) {
+	result, err := s.findSwapResults(isSwapin, func(res *storage.SwapResult) bool {
+		return res.Status == status
+	})
+	return len(result), err
+}
+
+// ------------------ statistics ------------------------
+
+// UpdateSwapSummary update swap summary
+func (s *SwapStore) UpdateSwapSummary(summary *storage.SwapSummary) error {
+	err := s.putItem(bkSwapStatistics, summary.Key, summary)
+	if err == nil {
+		log.Info("boltdb update swap statistics", "summary", summary)
+	} else {
+		log.Debug("boltdb update swap statistics", "summary", summary, "err", err)
+	}
+	return err
+}
+
+// FindSwapSummary find swap summary
+func (s *SwapStore) FindSwapSummary() (*storage.SwapSummary, error) {
+	var result *storage.SwapSummary
+	err := s.forEach(bkSwapStatistics, func(data []byte) (bool, error) {
+		result = &storage.SwapSummary{}
+		return false, json.Unmarshal(data, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result == nil {
+		return nil, storage.ErrItemNotFound
+	}
+	return result, nil
+}
+
+// ------------------ p2sh address ------------------------
+
+// AddP2shAddress add p2sh address
+func (s *SwapStore) AddP2shAddress(ma *storage.P2shAddress) error {
+	err := s.addItem(bkP2shAddresses, ma.Key, ma)
+	if err == nil {
+		log.Info("boltdb add p2sh address", "key", ma.Key, "p2shaddress", ma.P2shAddress)
+	} else {
+		log.Debug("boltdb add p2sh address", "key", ma.Key, "p2shaddress", ma.P2shAddress, "err", err)
+	}
+	return err
+}
+
+// FindP2shAddress find p2sh addrss through bind address
+func (s *SwapStore) FindP2shAddress(key string) (*storage.P2shAddress, error) {
+	var result storage.P2shAddress
+	err := s.getItem(bkP2shAddresses, key, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// FindP2shBindAddress find bind address through p2sh address
+func (s *SwapStore) FindP2shBindAddress(p2shAddress string) (string, error) {
+	var bindAddress string
+	err := s.forEach(bkP2shAddresses, func(data []byte) (bool, error) {
+		var ma storage.P2shAddress
+		if err := json.Unmarshal(data, &ma); err != nil {
+			return false, err
+		}
+		if ma.P2shAddress == p2shAddress {
+			bindAddress = ma.Key
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if bindAddress == "" {
+		return "", storage.ErrItemNotFound
+	}
+	return bindAddress, nil
+}
+
+// FindP2shAddresses find p2sh address
+func (s *SwapStore) FindP2shAddresses(offset, limit int) ([]*storage.P2shAddress, error) {
+	result := make([]*storage.P2shAddress, 0, limit)
+	index := 0
+	err := s.forEach(bkP2shAddresses, func(data []byte) (bool, error) {
+		if index < offset {
+			index++
+			return true, nil
+		}
+		if limit > 0 && len(result) >= limit {
+			return false, nil
+		}
+		var ma storage.P2shAddress
+		if err := json.Unmarshal(data, &ma); err != nil {
+			return false, err
+		}
+		result = append(result, &ma)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ------------------ latest scan info ------------------------
+
+// UpdateLatestScanInfo update latest scan info
+func (s *SwapStore) UpdateLatestScanInfo(info *storage.LatestScanInfo) error {
+	err := s.putItem(bkLatestScanInfo, info.Key, info)
+	if err == nil {
+		log.Info("boltdb update lastest scan info", "key", info.Key, "height", info.BlockHeight)
+	} else {
+		log.Debug("boltdb update latest scan info", "key", info.Key, "height", info.BlockHeight, "err", err)
+	}
+	return err
+}
+
+// FindLatestScanInfo find latest scan info
+func (s *SwapStore) FindLatestScanInfo(key string) (*storage.LatestScanInfo, error) {
+	var result storage.LatestScanInfo
+	err := s.getItem(bkLatestScanInfo, key, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ------------------------ register address ------------------------------
+
+// AddRegisteredAddress add register address
+func (s *SwapStore) AddRegisteredAddress(ma *storage.RegisteredAddress) error {
+	return s.addItem(bkRegisteredAddress, ma.Key, ma)
+}
+
+// FindRegisteredAddress find register address
+func (s *SwapStore) FindRegisteredAddress(key string) (*storage.RegisteredAddress, error) {
+	var result storage.RegisteredAddress
+	err := s.getItem(bkRegisteredAddress, key, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// --------------- blacklist --------------------------------
+
+// AddToBlacklist add to blacklist
+func (s *SwapStore) AddToBlacklist(mb *storage.BlackAccount) error {
+	return s.addItem(bkBlacklist, mb.Key, mb)
+}
+
+// RemoveFromBlacklist remove from blacklist
+func (s *SwapStore) RemoveFromBlacklist(address string) error {
+	return s.removeItem(bkBlacklist, address)
+}
+
+// FindBlackAccount find black account
+func (s *SwapStore) FindBlackAccount(address string) (*storage.BlackAccount, error) {
+	var result storage.BlackAccount
+	err := s.getItem(bkBlacklist, address, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// --------------- webhook outbox --------------------------------
+
+// AddWebhookDelivery add webhook delivery to outbox
+func (s *SwapStore) AddWebhookDelivery(delivery *storage.WebhookDelivery) error {
+	return s.addItem(bkWebhookOutbox, delivery.Key, delivery)
+}
+
+// UpdateWebhookDelivery replace webhook delivery in outbox
+func (s *SwapStore) UpdateWebhookDelivery(delivery *storage.WebhookDelivery) error {
+	return s.putItem(bkWebhookOutbox, delivery.Key, delivery)
+}
+
+// RemoveWebhookDelivery remove webhook delivery from outbox
+func (s *SwapStore) RemoveWebhookDelivery(key string) error {
+	return s.removeItem(bkWebhookOutbox, key)
+}
+
+// FindWebhookDelivery find webhook delivery
+func (s *SwapStore) FindWebhookDelivery(key string) (*storage.WebhookDelivery, error) {
+	var result storage.WebhookDelivery
+	err := s.getItem(bkWebhookOutbox, key, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (s *SwapStore) findWebhookDeliveries(filter func(*storage.WebhookDelivery) bool) ([]*storage.WebhookDelivery, error) {
+	result := make([]*storage.WebhookDelivery, 0, 20)
+	err := s.forEach(bkWebhookOutbox, func(data []byte) (bool, error) {
+		var delivery storage.WebhookDelivery
+		if err := json.Unmarshal(data, &delivery); err != nil {
+			return false, err
+		}
+		if filter(&delivery) {
+			result = append(result, &delivery)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindPendingWebhookDeliveries find pending webhook deliveries
+func (s *SwapStore) FindPendingWebhookDeliveries(now int64, limit int) ([]*storage.WebhookDelivery, error) {
+	result, err := s.findWebhookDeliveries(func(delivery *storage.WebhookDelivery) bool {
+		return !delivery.Dead && delivery.NextTime <= now
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].NextTime < result[j].NextTime
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// FindDeadWebhookDeliveries find dead webhook deliveries
+func (s *SwapStore) FindDeadWebhookDeliveries(offset, limit int) ([]*storage.WebhookDelivery, error) {
+	result, err := s.findWebhookDeliveries(func(delivery *storage.WebhookDelivery) bool {
+		return delivery.Dead
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp < result[j].Timestamp
+	})
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(result) {
+		return []*storage.WebhookDelivery{}, nil
+	}
+	result = result[offset:]
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// SetFeeOverride insert or replace fee override
+func (s *SwapStore) SetFeeOverride(override *storage.FeeOverride) error {
+	return s.putItem(bkFeeOverrides, override.Key, override)
+}
+
+// RemoveFeeOverride remove fee override
+func (s *SwapStore) RemoveFeeOverride(address string) error {
+	return s.removeItem(bkFeeOverrides, address)
+}
+
+// FindFeeOverride find fee override
+func (s *SwapStore) FindFeeOverride(address string) (*storage.FeeOverride, error) {
+	var result storage.FeeOverride
+	err := s.getItem(bkFeeOverrides, address, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// FindFeeOverrides find all fee overrides
+func (s *SwapStore) FindFeeOverrides() ([]*storage.FeeOverride, error) {
+	result := make([]*storage.FeeOverride, 0, 20)
+	err := s.forEach(bkFeeOverrides, func(data []byte) (bool, error) {
+		var override storage.FeeOverride
+		if err := json.Unmarshal(data, &override); err != nil {
+			return false, err
+		}
+		result = append(result, &override)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// AddSwapEvent add swap event
+func (s *SwapStore) AddSwapEvent(event *storage.SwapEvent) error {
+	return s.addItem(bkSwapEvents, event.Key, event)
+}
+
+// FindSwapEvents find swap events of txid (bucket is iterated in key order)
+func (s *SwapStore) FindSwapEvents(txid string) ([]*storage.SwapEvent, error) {
+	result := make([]*storage.SwapEvent, 0, 20)
+	err := s.forEach(bkSwapEvents, func(data []byte) (bool, error) {
+		var event storage.SwapEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			return false, err
+		}
+		if event.TxID == txid {
+			result = append(result, &event)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// FindSwapEventsAfter find swap events after key (bucket is iterated in key order)
+func (s *SwapStore) FindSwapEventsAfter(key string, limit int) ([]*storage.SwapEvent, error) {
+	result := make([]*storage.SwapEvent, 0, limit)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bkSwapEvents).Cursor()
+		for k, v := c.Seek([]byte(key)); k != nil && len(result) < limit; k, v = c.Next() {
+			if string(k) == key {
+				continue
+			}
+			var event storage.SwapEvent
+			if err := json.Unmarshal(v, &event); err != nil {
+				return err
+			}
+			result = append(result, &event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetSwapAttempt insert or replace swap attempt
+func (s *SwapStore) SetSwapAttempt(attempt *storage.SwapAttempt) error {
+	return s.putItem(bkSwapAttempts, attempt.Key, attempt)
+}
+
+func (s *SwapStore) findSwapAttempts(filter func(*storage.SwapAttempt) bool) ([]*storage.SwapAttempt, error) {
+	result := make([]*storage.SwapAttempt, 0, 20)
+	err := s.forEach(bkSwapAttempts, func(data []byte) (bool, error) {
+		var attempt storage.SwapAttempt
+		if err := json.Unmarshal(data, &attempt); err != nil {
+			return false, err
+		}
+		if filter(&attempt) {
+			result = append(result, &attempt)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].SignTime < result[j].SignTime
+	})
+	return result, nil
+}
+
+// FindSwapAttempts find swap attempts of txid
+func (s *SwapStore) FindSwapAttempts(txid string) ([]*storage.SwapAttempt, error) {
+	return s.findSwapAttempts(func(attempt *storage.SwapAttempt) bool {
+		return attempt.TxID == txid
+	})
+}
+
+// FindSwapAttemptsWithStatus find swap attempts with status in the past septime
+func (s *SwapStore) FindSwapAttemptsWithStatus(status string, septime int64) ([]*storage.SwapAttempt, error) {
+	result, err := s.findSwapAttempts(func(attempt *storage.SwapAttempt) bool {
+		return attempt.Status == status && attempt.Timestamp >= septime
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result) > maxCountOfResults {
+		result = result[:maxCountOfResults]
+	}
+	return result, nil
+}
+
+// ReserveNonce reserve the next nonce of account for txid in one transaction
+func (s *SwapStore) ReserveNonce(account, txid string, minNonce uint64, timestamp int64) (*storage.NonceReservation, error) {
+	var reservation *storage.NonceReservation
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		bres := tx.Bucket(bkNonceReservations)
+		prefix := []byte(account + ":")
+		c := bres.Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var item storage.NonceReservation
+			if err := json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			if item.TxID == txid && item.Status == storage.NonceReserved && item.Nonce >= minNonce {
+				reservation = &item
+				return nil
+			}
+		}
+
+		bacc := tx.Bucket(bkNonceAccounts)
+		nonceAccount := storage.NonceAccount{Key: account}
+		if data := bacc.Get([]byte(account)); data != nil {
+			if err := json.Unmarshal(data, &nonceAccount); err != nil {
+				return err
+			}
+		}
+		if nonceAccount.NextNonce < minNonce {
+			nonceAccount.NextNonce = minNonce
+		}
+		reservation = &storage.NonceReservation{
+			Key:       storage.GetNonceReservationKey(account, nonceAccount.NextNonce),
+			Account:   account,
+			Nonce:     nonceAccount.NextNonce,
+			TxID:      txid,
+			Status:    storage.NonceReserved,
+			Timestamp: timestamp,
+		}
+		nonceAccount.NextNonce++
+		nonceAccount.Timestamp = timestamp
+
+		data, err := json.Marshal(&nonceAccount)
+		if err != nil {
+			return err
+		}
+		if err = bacc.Put([]byte(account), data); err != nil {
+			return err
+		}
+		data, err = json.Marshal(reservation)
+		if err != nil {
+			return err
+		}
+		return bres.Put([]byte(reservation.Key), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+// SetNextNonce set the next nonce of account, and release the unsent reservations not less than it
+func (s *SwapStore) SetNextNonce(account string, nonce uint64, timestamp int64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(&storage.NonceAccount{
+			Key:       account,
+			NextNonce: nonce,
+			Timestamp: timestamp,
+		})
+		if err != nil {
+			return err
+		}
+		if err = tx.Bucket(bkNonceAccounts).Put([]byte(account), data); err != nil {
+			return err
+		}
+		bres := tx.Bucket(bkNonceReservations)
+		c := bres.Cursor()
+		for k, v := c.Seek([]byte(storage.GetNonceReservationKey(account, nonce))); k != nil && bytes.HasPrefix(k, []byte(account+":")); k, v = c.Next() {
+			var item storage.NonceReservation
+			if err = json.Unmarshal(v, &item); err != nil {
+				return err
+			}
+			if item.Status != storage.NonceReserved {
+				continue
+			}
+			item.Status = storage.NonceReleased
+			item.Memo = "released by setting nonce"
+			item.Timestamp = timestamp
+			if data, err = json.Marshal(&item); err != nil {
+				return err
+			}
+			if err = bres.Put(k, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindNonceAccount find nonce account
+func (s *SwapStore) FindNonceAccount(account string) (*storage.NonceAccount, error) {
+	var result storage.NonceAccount
+	err := s.getItem(bkNonceAccounts, account, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// FindNonceReservation find nonce reservation of account and nonce
+func (s *SwapStore) FindNonceReservation(account string, nonce uint64) (*storage.NonceReservation, error) {
+	var result storage.NonceReservation
+	err := s.getItem(bkNonceReservations, storage.GetNonceReservationKey(account, nonce), &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// SetNonceReservation insert or replace nonce reservation
+func (s *SwapStore) SetNonceReservation(reservation *storage.NonceReservation) error {
+	return s.putItem(bkNonceReservations, reservation.Key, reservation)
+}
+
+// AcquireLease renew the unexpired lease of holder, or take over the expired lease
+func (s *SwapStore) AcquireLease(name, holder string, ttl, now int64) (*storage.Lease, error) {
+	lease := &storage.Lease{Key: name}
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(bkLeases)
+		if data := b.Get([]byte(name)); data != nil {
+			if err := json.Unmarshal(data, lease); err != nil {
+				return err
+			}
+		}
+		switch {
+		case lease.Expires <= now:
+			lease.Holder = holder
+			lease.Token++
+		case lease.Holder != holder:
+			return storage.ErrLeaseHeld
+		}
+		lease.Expires = now + ttl
+		lease.Timestamp = now
+		data, err := json.Marshal(lease)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(name), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// ReleaseLease expire the lease if it is held by holder
+func (s *SwapStore) ReleaseLease(name, holder string) error {
+	var lease storage.Lease
+	err := s.modifyItem(bkLeases, name, &lease, func() error {
+		if lease.Holder != holder {
+			return storage.ErrLeaseHeld
+		}
+		lease.Expires = 0
+		return nil
+	})
+	if err == storage.ErrItemNotFound || err == storage.ErrLeaseHeld {
+		return nil
+	}
+	return err
+}
+
+// SetFencingToken do nothing, the data file is opened by one instance exclusively
+func (s *SwapStore) SetFencingToken(token uint64) {}
+
+// FindLease find lease
+func (s *SwapStore) FindLease(name string) (*storage.Lease, error) {
+	var result storage.Lease
+	err := s.getItem(bkLeases, name, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// AddOutflow add outflow
+func (s *SwapStore) AddOutflow(outflow *storage.Outflow) error {
+	err := s.addItem(bkOutflows, outflow.Key, outflow)
+	if err == nil {
+		log.Info("boltdb add outflow", "key", outflow.Key, "value", outflow.Value)
+	} else {
+		log.Debug("boltdb add outflow", "key", outflow.Key, "err", err)
+	}
+	return err
+}
+
+// FindOutflows find all outflows in the past septime
+func (s *SwapStore) FindOutflows(pairID string, isSwapin bool, septime int64) ([]*storage.Outflow, error) {
+	result := make([]*storage.Outflow, 0, 20)
+	err := s.forEach(bkOutflows, func(data []byte) (bool, error) {
+		var outflow storage.Outflow
+		if err := json.Unmarshal(data, &outflow); err != nil {
+			return false, err
+		}
+		if outflow.PairID == pairID && outflow.IsSwapin == isSwapin && outflow.Timestamp >= septime {
+			result = append(result, &outflow)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetOutflowBreaker insert or replace outflow breaker
+func (s *SwapStore) SetOutflowBreaker(breaker *storage.OutflowBreaker) error {
+	err := s.putItem(bkOutflowBreakers, breaker.Key, breaker)
+	if err == nil {
+		log.Info("boltdb set outflow breaker", "key", breaker.Key, "tripped", breaker.Tripped)
+	} else {
+		log.Debug("boltdb set outflow breaker", "key", breaker.Key, "err", err)
+	}
+	return err
+}
+
+// FindOutflowBreakers find all outflow breakers
+func (s *SwapStore) FindOutflowBreakers() ([]*storage.OutflowBreaker, error) {
+	result := make([]*storage.OutflowBreaker, 0, 10)
+	err := s.forEach(bkOutflowBreakers, func(data []byte) (bool, error) {
+		var breaker storage.OutflowBreaker
+		if err := json.Unmarshal(data, &breaker); err != nil {
+			return false, err
+		}
+		result = append(result, &breaker)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
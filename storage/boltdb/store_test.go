@@ -0,0 +1,473 @@
+package boltdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/anyswap/CrossChain-Bridge/storage"
+)
+
+func newTestStore(t *testing.T) *SwapStore {
+	dir, err := ioutil.TempDir("", "boltdb-test")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	store, err := NewSwapStore(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("new swap store failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = store.Close()
+		_ = os.RemoveAll(dir)
+	})
+	return store
+}
+
+func TestSwap(t *testing.T) {
+	store := newTestStore(t)
+	swap := &storage.Swap{Key: "tx1", TxID: "tx1", Status: storage.TxNotStable, Timestamp: 10, Memo: "memo"}
+	if err := store.AddSwap(true, swap); err != nil {
+		t.Fatalf("add swap failed: %v", err)
+	}
+	if err := store.AddSwap(true, swap); err != storage.ErrItemIsDup {
+		t.Fatalf("add dup swap, want %v, got %v", storage.ErrItemIsDup, err)
+	}
+	if _, err := store.FindSwap(false, "tx1"); err != storage.ErrItemNotFound {
+		t.Fatalf("find swapout, want %v, got %v", storage.ErrItemNotFound, err)
+	}
+	if err := store.UpdateSwapStatus(true, "tx1", storage.TxNotStable, storage.TxNotSwapped, 20, ""); err != nil {
+		t.Fatalf("update swap status failed: %v", err)
+	}
+	if err := store.UpdateSwapStatus(true, "tx1", storage.TxNotStable, storage.ManualMakeFail, 30, "fail"); err != storage.ErrStatusMismatch {
+		t.Fatalf("update swap status from stale status, want %v, got %v", storage.ErrStatusMismatch, err)
+	}
+	if err := store.UpdateSwapStatus(true, "tx2", storage.TxNotStable, storage.TxNotSwapped, 20, ""); err != storage.ErrItemNotFound {
+		t.Fatalf("update missing swap status, want %v, got %v", storage.ErrItemNotFound, err)
+	}
+	found, err := store.FindSwap(true, "tx1")
+	if err != nil {
+		t.Fatalf("find swap failed: %v", err)
+	}
+	if found.Status != storage.TxNotSwapped || found.Timestamp != 20 || found.Memo != "" {
+		t.Fatalf("wrong updated swap %+v", found)
+	}
+	swaps, err := store.FindSwapsWithStatus(true, storage.TxNotSwapped, 15)
+	if err != nil || len(swaps) != 1 {
+		t.Fatalf("find swaps with status, got %v items, err %v", len(swaps), err)
+	}
+	if swaps, _ = store.FindSwapsWithStatus(true, storage.TxNotSwapped, 25); len(swaps) != 0 {
+		t.Fatalf("find swaps before septime, got %v items", len(swaps))
+	}
+	if swaps, _ = store.FindExpiredSwapsWithStatus(true, storage.TxNotSwapped, 20); len(swaps) != 0 {
+		t.Fatalf("find expired swaps after septime, got %v items", len(swaps))
+	}
+	if swaps, _ = store.FindExpiredSwapsWithStatus(true, storage.TxNotSwapped, 25); len(swaps) != 1 {
+		t.Fatalf("find expired swaps before septime, got %v items", len(swaps))
+	}
+}
+
+func TestSwapRetry(t *testing.T) {
+	store := newTestStore(t)
+	swap := &storage.Swap{Key: "tx1", TxID: "tx1", Status: storage.TxNotSwapped, Timestamp: 10}
+	if err := store.AddSwap(true, swap); err != nil {
+		t.Fatalf("add swap failed: %v", err)
+	}
+	if err := store.UpdateSwapRetry(true, "tx1", storage.TxNotStable, 1, 100); err != storage.ErrStatusMismatch {
+		t.Fatalf("update swap retry of stale status, want %v, got %v", storage.ErrStatusMismatch, err)
+	}
+	if err := store.UpdateSwapRetry(true, "tx1", storage.TxNotSwapped, 2, 100); err != nil {
+		t.Fatalf("update swap retry failed: %v", err)
+	}
+	if err := store.UpdateSwapStatus(true, "tx1", storage.TxNotSwapped, storage.TxDeadLetter, 20, "dead"); err != nil {
+		t.Fatalf("update swap status failed: %v", err)
+	}
+	found, _ := store.FindSwap(true, "tx1")
+	if found.RetryCount != 2 || found.NextRetryTime != 100 || found.Memo != "dead" {
+		t.Fatalf("retry state is not kept in dead-letter status %+v", found)
+	}
+	if err := store.UpdateSwapStatus(true, "tx1", storage.TxDeadLetter, storage.TxNotSwapped, 30, ""); err != nil {
+		t.Fatalf("requeue swap failed: %v", err)
+	}
+	found, _ = store.FindSwap(true, "tx1")
+	if found.RetryCount != 0 || found.NextRetryTime != 0 || found.Memo != "" {
+		t.Fatalf("retry state is not reset when requeued %+v", found)
+	}
+}
+
+func TestOutflows(t *testing.T) {
+	store := newTestStore(t)
+	outflows := []*storage.Outflow{
+		{Key: "swapin:tx1", PairID: "eth", IsSwapin: true, TxID: "tx1", Value: "1", Timestamp: 10},
+		{Key: "swapin:tx2", PairID: "eth", IsSwapin: true, TxID: "tx2", Value: "2", Timestamp: 20},
+		{Key: "swapout:tx3", PairID: "eth", IsSwapin: false, TxID: "tx3", Value: "3", Timestamp: 20},
+		{Key: "swapin:tx4", PairID: "btc", IsSwapin: true, TxID: "tx4", Value: "4", Timestamp: 20},
+	}
+	for _, outflow := range outflows {
+		if err := store.AddOutflow(outflow); err != nil {
+			t.Fatalf("add outflow failed: %v", err)
+		}
+	}
+	if err := store.AddOutflow(outflows[0]); err != storage.ErrItemIsDup {
+		t.Fatalf("add dup outflow, want %v, got %v", storage.ErrItemIsDup, err)
+	}
+	found, err := store.FindOutflows("eth", true, 15)
+	if err != nil || len(found) != 1 || found[0].TxID != "tx2" {
+		t.Fatalf("wrong outflows %+v, err %v", found, err)
+	}
+	found, _ = store.FindOutflows("eth", true, 0)
+	if len(found) != 2 {
+		t.Fatalf("want 2 outflows, got %v", len(found))
+	}
+}
+
+func TestOutflowBreakers(t *testing.T) {
+	store := newTestStore(t)
+	breaker := &storage.OutflowBreaker{Key: "swapin:eth", PairID: "eth", IsSwapin: true, Tripped: true, Reason: "exceed"}
+	if err := store.SetOutflowBreaker(breaker); err != nil {
+		t.Fatalf("set outflow breaker failed: %v", err)
+	}
+	reset := *breaker
+	reset.Tripped = false
+	if err := store.SetOutflowBreaker(&reset); err != nil {
+		t.Fatalf("reset outflow breaker failed: %v", err)
+	}
+	found, err := store.FindOutflowBreakers()
+	if err != nil || len(found) != 1 || found[0].Tripped {
+		t.Fatalf("wrong outflow breakers %+v, err %v", found, err)
+	}
+}
+
+func TestSwapResults(t *testing.T) {
+	store := newTestStore(t)
+	for i, txid := range []string{"tx1", "tx2", "tx3"} {
+		res := &storage.SwapResult{Key: txid, TxID: txid, From: "addr", Status: storage.MatchTxEmpty, Timestamp: int64(i)}
+		if err := store.AddSwapResult(false, res); err != nil {
+			t.Fatalf("add swap result failed: %v", err)
+		}
+	}
+	items := &storage.SwapResultUpdateItems{SwapTx: "swaptx", SwapNonce: 5, Status: storage.MatchTxNotStable, Timestamp: 10}
+	if err := store.UpdateSwapResult(false, "tx1", storage.MatchTxEmpty, items); err != nil {
+		t.Fatalf("update swap result failed: %v", err)
+	}
+	if err := store.UpdateSwapResult(false, "tx1", storage.MatchTxEmpty, items); err != storage.ErrStatusMismatch {
+		t.Fatalf("update swap result from stale status, want %v, got %v", storage.ErrStatusMismatch, err)
+	}
+	res, err := store.FindSwapResult(false, "tx1")
+	if err != nil || res.SwapTx != "swaptx" || res.SwapNonce != 5 || res.From != "addr" {
+		t.Fatalf("wrong updated swap result %+v, err %v", res, err)
+	}
+	items = &storage.SwapResultUpdateItems{SwapTx: "swaptx2", OldSwapTxs: []string{"swaptx"}, SwapHeight: 100, SwapTime: 1000, Status: storage.MatchTxNotStable, Timestamp: 10}
+	if err = store.UpdateSwapResult(false, "tx1", storage.MatchTxNotStable, items); err != nil {
+		t.Fatalf("update swap result failed: %v", err)
+	}
+	if res, _ = store.FindSwapResult(false, "tx1"); res.SwapTx != "swaptx2" || len(res.OldSwapTxs) != 1 || res.SwapNonce != 5 {
+		t.Fatalf("wrong replaced swap result %+v", res)
+	}
+	if err = store.UpdateSwapResultStatus(false, "tx1", storage.MatchTxNotStable, storage.MatchTxReorged, 11, "reorged"); err != nil {
+		t.Fatalf("update swap result status failed: %v", err)
+	}
+	if res, _ = store.FindSwapResult(false, "tx1"); res.SwapTx != "swaptx2" || res.SwapHeight != 0 || res.SwapTime != 0 {
+		t.Fatalf("swap height not reset on reorg %+v", res)
+	}
+	if err = store.UpdateSwapResultStatus(false, "tx1", storage.MatchTxReorged, storage.MatchTxEmpty, 11, ""); err != nil {
+		t.Fatalf("update swap result status failed: %v", err)
+	}
+	if res, _ = store.FindSwapResult(false, "tx1"); res.SwapTx != "" || len(res.OldSwapTxs) != 0 {
+		t.Fatalf("swaptx not cleared %+v", res)
+	}
+	latest, err := store.FindSwapResults(false, "all", 0, -2)
+	if err != nil || len(latest) != 2 || latest[0].TxID != "tx1" || latest[1].TxID != "tx3" {
+		t.Fatalf("find latest swap results, got %v, err %v", latest, err)
+	}
+	if count, _ := store.GetCountOfSwapResultsWithStatus(false, storage.MatchTxEmpty); count != 3 {
+		t.Fatalf("wrong count of swap results with status %v", count)
+	}
+}
+
+func TestRefundResult(t *testing.T) {
+	store := newTestStore(t)
+	res := &storage.SwapResult{Key: "tx1", TxID: "tx1", From: "addr", Value: "100", Status: storage.TxWithWrongMemo, Timestamp: 1}
+	if err := store.AddSwapResult(true, res); err != nil {
+		t.Fatalf("add swap result failed: %v", err)
+	}
+	items := &storage.SwapResultUpdateItems{RefundTx: "refundtx", RefundValue: "90", RefundFee: "10", RefundNonce: 3, Status: storage.RefundTxNotStable, Timestamp: 2}
+	if err := store.UpdateSwapResult(true, "tx1", storage.TxWithWrongMemo, items); err != nil {
+		t.Fatalf("update refund result failed: %v", err)
+	}
+	items = &storage.SwapResultUpdateItems{RefundHeight: 100, RefundTime: 1000, Status: storage.RefundTxNotStable, Timestamp: 3}
+	if err := store.UpdateSwapResult(true, "tx1", storage.RefundTxNotStable, items); err != nil {
+		t.Fatalf("update refund height failed: %v", err)
+	}
+	found, err := store.FindSwapResult(true, "tx1")
+	if err != nil {
+		t.Fatalf("find swap result failed: %v", err)
+	}
+	if found.RefundTx != "refundtx" || found.RefundValue != "90" || found.RefundFee != "10" || found.RefundNonce != 3 ||
+		found.RefundHeight != 100 || found.RefundTime != 1000 || found.SwapTx != "" {
+		t.Fatalf("wrong updated refund result %+v", found)
+	}
+}
+
+func TestSwapEvents(t *testing.T) {
+	store := newTestStore(t)
+	events := []*storage.SwapEvent{
+		{Key: "01", TxID: "tx1", IsSwapin: true, Action: storage.SwapEventAdd, NewStatus: storage.TxNotStable, Actor: storage.ActorScan},
+		{Key: "02", TxID: "tx2", IsSwapin: true, Action: storage.SwapEventAdd, NewStatus: storage.TxNotStable, Actor: storage.ActorAPI},
+		{Key: "03", TxID: "tx1", IsSwapin: true, Action: storage.SwapEventUpdate, OldStatus: storage.TxNotStable, NewStatus: storage.TxNotSwapped, Actor: "verify"},
+	}
+	for _, event := range events {
+		if err := store.AddSwapEvent(event); err != nil {
+			t.Fatalf("add swap event failed: %v", err)
+		}
+	}
+	if err := store.AddSwapEvent(events[0]); err != storage.ErrItemIsDup {
+		t.Fatalf("add dup swap event, want %v, got %v", storage.ErrItemIsDup, err)
+	}
+	found, err := store.FindSwapEvents("tx1")
+	if err != nil || len(found) != 2 {
+		t.Fatalf("find swap events, got %v items, err %v", len(found), err)
+	}
+	if found[0].Key != "01" || found[1].Key != "03" || found[1].OldStatus != storage.TxNotStable || found[1].Actor != "verify" {
+		t.Fatalf("wrong swap events %+v %+v", found[0], found[1])
+	}
+	found, err = store.FindSwapEventsAfter("01", 1)
+	if err != nil || len(found) != 1 || found[0].Key != "02" {
+		t.Fatalf("wrong swap events after key %+v, err %v", found, err)
+	}
+	found, _ = store.FindSwapEventsAfter("0", 10)
+	if len(found) != 3 {
+		t.Fatalf("want 3 swap events after key, got %v", len(found))
+	}
+}
+
+func TestSwapAttempts(t *testing.T) {
+	store := newTestStore(t)
+	attempts := []*storage.SwapAttempt{
+		{Key: "swaptx2", TxID: "tx1", Job: "replace", Nonce: 5, Status: storage.SwapAttemptSigned, SignTime: 20, Timestamp: 20},
+		{Key: "swaptx1", TxID: "tx1", Job: "swap", Nonce: 5, RawTx: "0x01", Status: storage.SwapAttemptSigned, SignTime: 10, Timestamp: 10},
+		{Key: "swaptx3", TxID: "tx2", Job: "swap", Status: storage.SwapAttemptSigned, SignTime: 30, Timestamp: 30},
+	}
+	for _, attempt := range attempts {
+		if err := store.SetSwapAttempt(attempt); err != nil {
+			t.Fatalf("set swap attempt failed: %v", err)
+		}
+	}
+	found, err := store.FindSwapAttempts("tx1")
+	if err != nil || len(found) != 2 || found[0].Key != "swaptx1" || found[0].RawTx != "0x01" || found[1].Key != "swaptx2" {
+		t.Fatalf("find swap attempts, got %v, err %v", found, err)
+	}
+	attempts[1].Status = storage.SwapAttemptSent
+	if err = store.SetSwapAttempt(attempts[1]); err != nil {
+		t.Fatalf("update swap attempt failed: %v", err)
+	}
+	signed, err := store.FindSwapAttemptsWithStatus(storage.SwapAttemptSigned, 15)
+	if err != nil || len(signed) != 2 || signed[0].Key != "swaptx2" || signed[1].Key != "swaptx3" {
+		t.Fatalf("find swap attempts with status, got %v, err %v", signed, err)
+	}
+}
+
+func TestNonceReservations(t *testing.T) {
+	store := newTestStore(t)
+	account := "ETHEREUM:rinkeby:0xdcrm"
+	r1, err := store.ReserveNonce(account, "tx1", 5, 10)
+	if err != nil || r1.Nonce != 5 || r1.Status != storage.NonceReserved {
+		t.Fatalf("reserve nonce, got %v, err %v", r1, err)
+	}
+	// pool nonce is behind the counter
+	r2, err := store.ReserveNonce(account, "tx2", 3, 10)
+	if err != nil || r2.Nonce != 6 {
+		t.Fatalf("reserve nonce behind counter, got %v, err %v", r2, err)
+	}
+	// retry of tx1 reuses its unsent reservation
+	if r, err := store.ReserveNonce(account, "tx1", 5, 20); err != nil || r.Nonce != 5 {
+		t.Fatalf("reserve nonce again, got %v, err %v", r, err)
+	}
+	// the reservation of tx1 is consumed by others
+	if r, err := store.ReserveNonce(account, "tx1", 7, 20); err != nil || r.Nonce != 7 {
+		t.Fatalf("reserve nonce after consumed, got %v, err %v", r, err)
+	}
+	if acc, err := store.FindNonceAccount(account); err != nil || acc.NextNonce != 8 {
+		t.Fatalf("find nonce account, got %v, err %v", acc, err)
+	}
+
+	r2.Status = storage.NonceSent
+	if err = store.SetNonceReservation(r2); err != nil {
+		t.Fatalf("set nonce reservation failed: %v", err)
+	}
+	if err = store.SetNextNonce(account, 6, 30); err != nil {
+		t.Fatalf("set next nonce failed: %v", err)
+	}
+	if r, err := store.FindNonceReservation(account, 6); err != nil || r.Status != storage.NonceSent || r.TxID != "tx2" {
+		t.Fatalf("sent reservation is changed, got %v, err %v", r, err)
+	}
+	if r, err := store.FindNonceReservation(account, 7); err != nil || r.Status != storage.NonceReleased {
+		t.Fatalf("unsent reservation is not released, got %v, err %v", r, err)
+	}
+	if r, err := store.FindNonceReservation(account, 5); err != nil || r.Status != storage.NonceReserved {
+		t.Fatalf("reservation below next nonce is changed, got %v, err %v", r, err)
+	}
+	if r, err := store.ReserveNonce(account, "tx3", 0, 40); err != nil || r.Nonce != 6 {
+		t.Fatalf("reserve nonce after set, got %v, err %v", r, err)
+	}
+	if _, err := store.FindNonceReservation("other", 5); err != storage.ErrItemNotFound {
+		t.Fatalf("find reservation of other account, want %v, got %v", storage.ErrItemNotFound, err)
+	}
+}
+
+func TestLease(t *testing.T) {
+	store := newTestStore(t)
+	lease, err := store.AcquireLease("leader", "a", 30, 100)
+	if err != nil || lease.Holder != "a" || lease.Token != 1 || lease.Expires != 130 {
+		t.Fatalf("acquire lease, got %v, err %v", lease, err)
+	}
+	if lease, err = store.AcquireLease("leader", "a", 30, 110); err != nil || lease.Token != 1 || lease.Expires != 140 {
+		t.Fatalf("renew lease, got %v, err %v", lease, err)
+	}
+	if _, err = store.AcquireLease("leader", "b", 30, 120); err != storage.ErrLeaseHeld {
+		t.Fatalf("acquire held lease, want %v, got %v", storage.ErrLeaseHeld, err)
+	}
+	if lease, err = store.AcquireLease("leader", "b", 30, 140); err != nil || lease.Holder != "b" || lease.Token != 2 {
+		t.Fatalf("take over expired lease, got %v, err %v", lease, err)
+	}
+	if err = store.ReleaseLease("leader", "a"); err != nil {
+		t.Fatalf("release lease of others failed: %v", err)
+	}
+	if lease, err = store.FindLease("leader"); err != nil || lease.Holder != "b" || lease.Expires != 170 {
+		t.Fatalf("lease is released by others, got %v, err %v", lease, err)
+	}
+	if err = store.ReleaseLease("leader", "b"); err != nil {
+		t.Fatalf("release lease failed: %v", err)
+	}
+	if lease, err = store.AcquireLease("leader", "a", 30, 150); err != nil || lease.Holder != "a" || lease.Token != 3 {
+		t.Fatalf("acquire released lease, got %v, err %v", lease, err)
+	}
+}
+
+func TestP2shAndBlacklist(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.AddP2shAddress(&storage.P2shAddress{Key: "bind", P2shAddress: "p2sh"}); err != nil {
+		t.Fatalf("add p2sh address failed: %v", err)
+	}
+	if bind, err := store.FindP2shBindAddress("p2sh"); err != nil || bind != "bind" {
+		t.Fatalf("find p2sh bind address, got %v, err %v", bind, err)
+	}
+	if err := store.AddToBlacklist(&storage.BlackAccount{Key: "addr"}); err != nil {
+		t.Fatalf("add to blacklist failed: %v", err)
+	}
+	if err := store.RemoveFromBlacklist("addr"); err != nil {
+		t.Fatalf("remove from blacklist failed: %v", err)
+	}
+	if _, err := store.FindBlackAccount("addr"); err != storage.ErrItemNotFound {
+		t.Fatalf("find removed black account, want %v, got %v", storage.ErrItemNotFound, err)
+	}
+}
+
+func TestWebhookOutbox(t *testing.T) {
+	store := newTestStore(t)
+	for i, key := range []string{"d1", "d2", "d3"} {
+		delivery := &storage.WebhookDelivery{Key: key, Webhook: "hook", NextTime: int64(30 - i*10), Timestamp: int64(i)}
+		if err := store.AddWebhookDelivery(delivery); err != nil {
+			t.Fatalf("add webhook delivery failed: %v", err)
+		}
+	}
+	pending, err := store.FindPendingWebhookDeliveries(20, 10)
+	if err != nil || len(pending) != 2 || pending[0].Key != "d3" || pending[1].Key != "d2" {
+		t.Fatalf("find pending webhook deliveries, got %v, err %v", pending, err)
+	}
+	pending[0].Dead = true
+	if err = store.UpdateWebhookDelivery(pending[0]); err != nil {
+		t.Fatalf("update webhook delivery failed: %v", err)
+	}
+	if pending, _ = store.FindPendingWebhookDeliveries(20, 10); len(pending) != 1 {
+		t.Fatalf("find pending webhook deliveries after dead, got %v items", len(pending))
+	}
+	dead, err := store.FindDeadWebhookDeliveries(0, 10)
+	if err != nil || len(dead) != 1 || dead[0].Key != "d3" {
+		t.Fatalf("find dead webhook deliveries, got %v, err %v", dead, err)
+	}
+	if err = store.RemoveWebhookDelivery("d3"); err != nil {
+		t.Fatalf("remove webhook delivery failed: %v", err)
+	}
+	if _, err = store.FindWebhookDelivery("d3"); err != storage.ErrItemNotFound {
+		t.Fatalf("find removed webhook delivery, want %v, got %v", storage.ErrItemNotFound, err)
+	}
+}
+
+func TestFeeOverride(t *testing.T) {
+	store := newTestStore(t)
+	override := &storage.FeeOverride{Key: "partner", SwapinDiscount: "0.3"}
+	if err := store.SetFeeOverride(override); err != nil {
+		t.Fatalf("set fee override failed: %v", err)
+	}
+	override.SwapoutDiscount = "0.5"
+	if err := store.SetFeeOverride(override); err != nil {
+		t.Fatalf("replace fee override failed: %v", err)
+	}
+	found, err := store.FindFeeOverride("partner")
+	if err != nil || found.SwapinDiscount != "0.3" || found.SwapoutDiscount != "0.5" {
+		t.Fatalf("find fee override, got %+v, err %v", found, err)
+	}
+	if overrides, _ := store.FindFeeOverrides(); len(overrides) != 1 {
+		t.Fatalf("find fee overrides, got %v items", len(overrides))
+	}
+	if err = store.RemoveFeeOverride("partner"); err != nil {
+		t.Fatalf("remove fee override failed: %v", err)
+	}
+	if _, err = store.FindFeeOverride("partner"); err != storage.ErrItemNotFound {
+		t.Fatalf("find removed fee override, want %v, got %v", storage.ErrItemNotFound, err)
+	}
+}
+
+func TestQuerySwapResults(t *testing.T) {
+	store := newTestStore(t)
+	for i, key := range []string{"r1", "r2", "r3", "r4", "r5"} {
+		status := storage.MatchTxStable
+		if i%2 == 1 {
+			status = storage.TxWithWrongMemo
+		}
+		res := &storage.SwapResult{Key: key, TxID: key, From: "from", Value: []string{"5", "50", "500", "5000", "50000"}[i], Status: status, Timestamp: int64(10 * (i / 2))}
+		if err := store.AddSwapResult(true, res); err != nil {
+			t.Fatalf("add swap result failed: %v", err)
+		}
+	}
+	// sorted by (timestamp, key) descending: r5 r4 r3 r2 r1
+	query := &storage.SwapResultQuery{SortBy: storage.SortByTimestamp, Limit: 2}
+	var keys []string
+	for page := 0; page < 3; page++ {
+		results, err := store.QuerySwapResults(true, query)
+		if err != nil {
+			t.Fatalf("query swap results failed: %v", err)
+		}
+		for _, res := range results {
+			keys = append(keys, res.Key)
+		}
+		if len(results) == 0 {
+			break
+		}
+		query.After = results[len(results)-1].Cursor(query.SortBy)
+	}
+	if fmt.Sprint(keys) != "[r5 r4 r3 r2 r1]" {
+		t.Fatalf("query swap results by pages, got %v", keys)
+	}
+
+	query = &storage.SwapResultQuery{
+		Statuses: []storage.SwapStatus{storage.MatchTxStable},
+		MinValue: big.NewInt(6),
+		SortBy:   storage.SortByTimestamp,
+		SortAsc:  true,
+		Limit:    10,
+	}
+	results, err := store.QuerySwapResults(true, query)
+	if err != nil || len(results) != 2 || results[0].Key != "r3" || results[1].Key != "r5" {
+		t.Fatalf("query swap results with filters, got %v, err %v", results, err)
+	}
+	query.MaxValue = big.NewInt(5000)
+	query.StartTime = 10
+	if results, _ = store.QuerySwapResults(true, query); len(results) != 1 || results[0].Key != "r3" {
+		t.Fatalf("query swap results with value and time range, got %v", results)
+	}
+}
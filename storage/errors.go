@@ -0,0 +1,23 @@
+package storage
+
+import (
+	rpcjson "github.com/gorilla/rpc/v2/json2"
+)
+
+// NewError new storage error
+func NewError(ec rpcjson.ErrorCode, message string) error {
+	return &rpcjson.Error{
+		Code:    ec,
+		Message: message,
+	}
+}
+
+// storage special errors
+var (
+	ErrItemNotFound   = NewError(-32002, "dbError: Item not found")
+	ErrItemIsDup      = NewError(-32003, "dbError: Item is duplicate")
+	ErrStatusMismatch = NewError(-32004, "dbError: Status is changed by others")
+	ErrLeaseHeld      = NewError(-32005, "dbError: Lease is held by others")
+	ErrNotLeader      = NewError(-32006, "dbError: Not the leader (stale fencing token)")
+	ErrSwapNotFound   = NewError(-32011, "dbError: Swap is not found")
+)
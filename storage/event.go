@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+)
+
+// actors of swap events, worker jobs use their job names as actor
+const (
+	ActorAPI  = "api"
+	ActorScan = "scan"
+)
+
+// actions of swap events
+const (
+	SwapEventAdd    = "add"
+	SwapEventUpdate = "update"
+)
+
+var swapEventSequence uint64
+
+// AdminActor actor of admin call by sender
+func AdminActor(sender string) string {
+	return "admin:" + sender
+}
+
+// IsAdminActor is actor of admin call
+func IsAdminActor(actor string) bool {
+	return strings.HasPrefix(actor, "admin:")
+}
+
+// FindSwapEvents find swap events (the status timeline) of txid
+func FindSwapEvents(txid string) ([]*SwapEvent, error) {
+	return swapStore.FindSwapEvents(txid)
+}
+
+// GetSwapEventKeyOfTime get the smallest swap event key at t (see addSwapEvent)
+func GetSwapEventKeyOfTime(t time.Time) string {
+	return fmt.Sprintf("%016x", t.UnixNano())
+}
+
+// FindSwapEventsAfter find swap events after key in key order (the event log is tailed
+// as the outbox of swap writes of all instances), at most limit items.
+func FindSwapEventsAfter(key string, limit int) ([]*SwapEvent, error) {
+	return swapStore.FindSwapEventsAfter(key, limit)
+}
+
+// addSwapEvent append swap event, failure is logged but not returned
+// as the swap itself is already written.
+func addSwapEvent(event *SwapEvent) {
+	now := time.Now()
+	event.Key = fmt.Sprintf("%016x-%08x", now.UnixNano(), atomic.AddUint64(&swapEventSequence, 1))
+	event.Timestamp = now.Unix()
+	err := swapStore.AddSwapEvent(event)
+	if err != nil {
+		log.Warn("add swap event failed", "txid", event.TxID, "isSwapin", event.IsSwapin, "status", event.NewStatus, "err", err)
+	}
+}
+
+func addSwapAddedEvent(actor string, isSwapin bool, swap *Swap) {
+	addSwapEvent(&SwapEvent{
+		TxID:      swap.TxID,
+		IsSwapin:  isSwapin,
+		Action:    SwapEventAdd,
+		NewStatus: swap.Status,
+		Actor:     actor,
+		Memo:      swap.Memo,
+	})
+}
+
+func addSwapUpdatedEvent(actor string, isSwapin bool, txid string, old *Swap, status SwapStatus, memo string) {
+	event := &SwapEvent{
+		TxID:      txid,
+		IsSwapin:  isSwapin,
+		Action:    SwapEventUpdate,
+		NewStatus: status,
+		Actor:     actor,
+		Memo:      memo,
+	}
+	if old != nil {
+		event.OldStatus = old.Status
+	}
+	addSwapEvent(event)
+}
+
+func addSwapResultAddedEvent(actor string, isSwapin bool, res *SwapResult) {
+	addSwapEvent(&SwapEvent{
+		TxID:      res.TxID,
+		IsSwapin:  isSwapin,
+		IsResult:  true,
+		Action:    SwapEventAdd,
+		NewStatus: res.Status,
+		Actor:     actor,
+		Memo:      res.Memo,
+		SwapTx:    res.SwapTx,
+	})
+}
+
+func addSwapResultUpdatedEvent(actor string, isSwapin bool, txid string, old *SwapResult, status SwapStatus, memo, swapTx, refundTx string) {
+	event := &SwapEvent{
+		TxID:      txid,
+		IsSwapin:  isSwapin,
+		IsResult:  true,
+		Action:    SwapEventUpdate,
+		NewStatus: status,
+		Actor:     actor,
+		Memo:      memo,
+		SwapTx:    swapTx,
+		RefundTx:  refundTx,
+	}
+	if old != nil {
+		event.OldStatus = old.Status
+		if event.SwapTx == "" {
+			event.SwapTx = old.SwapTx
+		}
+		if event.RefundTx == "" {
+			event.RefundTx = old.RefundTx
+		}
+	}
+	addSwapEvent(event)
+}
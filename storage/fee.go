@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+// SetFeeOverride set fee discounts of address (eg. partner discount)
+func SetFeeOverride(address, swapinDiscount, swapoutDiscount, memo string) error {
+	if _, err := tokens.ParseDiscountRate(swapinDiscount); err != nil {
+		return err
+	}
+	if _, err := tokens.ParseDiscountRate(swapoutDiscount); err != nil {
+		return err
+	}
+	override := &FeeOverride{
+		Key:             strings.ToLower(address),
+		SwapinDiscount:  swapinDiscount,
+		SwapoutDiscount: swapoutDiscount,
+		Memo:            memo,
+		Timestamp:       time.Now().Unix(),
+	}
+	err := swapStore.SetFeeOverride(override)
+	if err == nil {
+		log.Info("set fee override success", "address", address, "swapinDiscount", swapinDiscount, "swapoutDiscount", swapoutDiscount)
+	} else {
+		log.Info("set fee override failed", "address", address, "err", err)
+	}
+	return err
+}
+
+// RemoveFeeOverride remove fee override of address
+func RemoveFeeOverride(address string) error {
+	err := swapStore.RemoveFeeOverride(strings.ToLower(address))
+	if err == nil {
+		log.Info("remove fee override success", "address", address)
+	} else {
+		log.Info("remove fee override failed", "address", address, "err", err)
+	}
+	return err
+}
+
+// FindFeeOverride find fee override of address
+func FindFeeOverride(address string) (*FeeOverride, error) {
+	return swapStore.FindFeeOverride(strings.ToLower(address))
+}
+
+// FindFeeOverrides find all fee overrides
+func FindFeeOverrides() ([]*FeeOverride, error) {
+	return swapStore.FindFeeOverrides()
+}
@@ -0,0 +1,156 @@
+package storage
+
+// SwapStore storage backend of the swap server.
+// every item is keyed by its 'Key' field, adding an existing key returns ErrItemIsDup,
+// finding a missing key returns ErrItemNotFound.
+// status updates are compare and set, updating an item whose status
+// is not oldStatus returns ErrStatusMismatch and changes nothing.
+type SwapStore interface {
+	// AddSwap add registered swap
+	AddSwap(isSwapin bool, swap *Swap) error
+	// FindSwap find swap by txid
+	FindSwap(isSwapin bool, txid string) (*Swap, error)
+	// UpdateSwapStatus update status, timestamp and memo (if not empty) of swap,
+	// memo is cleared if it is empty and status is TxNotSwapped or TxNotStable,
+	// retry count and next retry time are cleared if status is changed to them.
+	UpdateSwapStatus(isSwapin bool, txid string, oldStatus, status SwapStatus, timestamp int64, memo string) error
+	// UpdateSwapRetry update retry count and next retry time of swap whose status is still status
+	UpdateSwapRetry(isSwapin bool, txid string, status SwapStatus, retryCount uint64, nextRetryTime int64) error
+	// FindSwapsWithStatus find swaps with status and timestamp >= septime,
+	// sorted by timestamp and at most maxCountOfResults items.
+	FindSwapsWithStatus(isSwapin bool, status SwapStatus, septime int64) ([]*Swap, error)
+	// FindExpiredSwapsWithStatus find swaps with status and timestamp < septime,
+	// sorted by timestamp and at most maxCountOfResults items.
+	FindExpiredSwapsWithStatus(isSwapin bool, status SwapStatus, septime int64) ([]*Swap, error)
+	// GetCountOfSwapsWithStatus get count of swaps with status
+	GetCountOfSwapsWithStatus(isSwapin bool, status SwapStatus) (int, error)
+
+	// AddSwapResult add swap result
+	AddSwapResult(isSwapin bool, result *SwapResult) error
+	// FindSwapResult find swap result by txid
+	FindSwapResult(isSwapin bool, txid string) (*SwapResult, error)
+	// UpdateSwapResult update status, timestamp and the non zero items of swap result,
+	// memo is cleared if it is empty and status is MatchTxNotStable.
+	UpdateSwapResult(isSwapin bool, txid string, oldStatus SwapStatus, items *SwapResultUpdateItems) error
+	// UpdateSwapResultStatus update status, timestamp and memo (if not empty) of swap result,
+	// memo and swaptx are cleared if memo is empty and status is MatchTxEmpty.
+	UpdateSwapResultStatus(isSwapin bool, txid string, oldStatus, status SwapStatus, timestamp int64, memo string) error
+	// FindSwapResultsWithStatus find swap results with status and timestamp >= septime,
+	// sorted by timestamp and at most maxCountOfResults items.
+	FindSwapResultsWithStatus(isSwapin bool, status SwapStatus, septime int64) ([]*SwapResult, error)
+	// FindSwapResults find swap results from address ('all' means any address),
+	// negative limit means find the latest results.
+	FindSwapResults(isSwapin bool, address string, offset, limit int) ([]*SwapResult, error)
+	// QuerySwapResults query swap results matching the filters and after the cursor,
+	// sorted by (sort field, key) and at most query.Limit items.
+	QuerySwapResults(isSwapin bool, query *SwapResultQuery) ([]*SwapResult, error)
+	// GetCountOfSwapResults get count of swap results
+	GetCountOfSwapResults(isSwapin bool) (int, error)
+	// GetCountOfSwapResultsWithStatus get count of swap results with status
+	GetCountOfSwapResultsWithStatus(isSwapin bool, status SwapStatus) (int, error)
+
+	// AddP2shAddress add p2sh address
+	AddP2shAddress(ma *P2shAddress) error
+	// FindP2shAddress find p2sh address through bind address
+	FindP2shAddress(bindAddress string) (*P2shAddress, error)
+	// FindP2shBindAddress find bind address through p2sh address
+	FindP2shBindAddress(p2shAddress string) (string, error)
+	// FindP2shAddresses find p2sh addresses by page
+	FindP2shAddresses(offset, limit int) ([]*P2shAddress, error)
+
+	// AddToBlacklist add to blacklist
+	AddToBlacklist(mb *BlackAccount) error
+	// RemoveFromBlacklist remove from blacklist
+	RemoveFromBlacklist(address string) error
+	// FindBlackAccount find black account
+	FindBlackAccount(address string) (*BlackAccount, error)
+
+	// AddRegisteredAddress add registered address
+	AddRegisteredAddress(ma *RegisteredAddress) error
+	// FindRegisteredAddress find registered address
+	FindRegisteredAddress(address string) (*RegisteredAddress, error)
+
+	// FindSwapSummary find swap summary
+	FindSwapSummary() (*SwapSummary, error)
+	// UpdateSwapSummary insert or replace swap summary
+	UpdateSwapSummary(summary *SwapSummary) error
+
+	// FindLatestScanInfo find latest scan info
+	FindLatestScanInfo(key string) (*LatestScanInfo, error)
+	// UpdateLatestScanInfo insert or replace latest scan info
+	UpdateLatestScanInfo(info *LatestScanInfo) error
+
+	// AddWebhookDelivery add webhook delivery to outbox
+	AddWebhookDelivery(delivery *WebhookDelivery) error
+	// UpdateWebhookDelivery replace webhook delivery in outbox
+	UpdateWebhookDelivery(delivery *WebhookDelivery) error
+	// RemoveWebhookDelivery remove webhook delivery from outbox
+	RemoveWebhookDelivery(key string) error
+	// FindWebhookDelivery find webhook delivery by key
+	FindWebhookDelivery(key string) (*WebhookDelivery, error)
+	// FindPendingWebhookDeliveries find not dead webhook deliveries with nexttime <= now,
+	// sorted by nexttime and at most limit items.
+	FindPendingWebhookDeliveries(now int64, limit int) ([]*WebhookDelivery, error)
+	// FindDeadWebhookDeliveries find dead webhook deliveries by page, sorted by timestamp
+	FindDeadWebhookDeliveries(offset, limit int) ([]*WebhookDelivery, error)
+
+	// SetFeeOverride insert or replace fee override
+	SetFeeOverride(override *FeeOverride) error
+	// RemoveFeeOverride remove fee override
+	RemoveFeeOverride(address string) error
+	// FindFeeOverride find fee override of address
+	FindFeeOverride(address string) (*FeeOverride, error)
+	// FindFeeOverrides find all fee overrides
+	FindFeeOverrides() ([]*FeeOverride, error)
+
+	// AddSwapEvent append swap event
+	AddSwapEvent(event *SwapEvent) error
+	// FindSwapEvents find swap events of txid, sorted by key
+	FindSwapEvents(txid string) ([]*SwapEvent, error)
+	// FindSwapEventsAfter find at most limit swap events with key larger than key, sorted by key
+	FindSwapEventsAfter(key string, limit int) ([]*SwapEvent, error)
+
+	// SetSwapAttempt insert or replace swap attempt
+	SetSwapAttempt(attempt *SwapAttempt) error
+	// FindSwapAttempts find swap attempts of txid, sorted by sign time
+	FindSwapAttempts(txid string) ([]*SwapAttempt, error)
+	// FindSwapAttemptsWithStatus find swap attempts with status and timestamp >= septime,
+	// sorted by sign time and at most maxCountOfResults items.
+	FindSwapAttemptsWithStatus(status string, septime int64) ([]*SwapAttempt, error)
+
+	// ReserveNonce reserve the next nonce (not less than minNonce) of account for txid atomically,
+	// the unsent reservation of txid which is not less than minNonce is reused.
+	ReserveNonce(account, txid string, minNonce uint64, timestamp int64) (*NonceReservation, error)
+	// SetNextNonce set the next nonce of account, and release the unsent reservations not less than it
+	SetNextNonce(account string, nonce uint64, timestamp int64) error
+	// FindNonceAccount find nonce account
+	FindNonceAccount(account string) (*NonceAccount, error)
+	// FindNonceReservation find nonce reservation of account and nonce
+	FindNonceReservation(account string, nonce uint64) (*NonceReservation, error)
+	// SetNonceReservation insert or replace nonce reservation
+	SetNonceReservation(reservation *NonceReservation) error
+
+	// AcquireLease renew the unexpired lease of holder, or take over the expired lease
+	// with increased token, returns ErrLeaseHeld if it is held by others.
+	AcquireLease(name, holder string, ttl, now int64) (*Lease, error)
+	// ReleaseLease expire the lease if it is held by holder
+	ReleaseLease(name, holder string) error
+	// FindLease find lease
+	FindLease(name string) (*Lease, error)
+	// SetFencingToken fence the swap writes with the lease token of this instance,
+	// a write is rejected with ErrNotLeader in its write condition
+	// if the item is already written with a newer token.
+	SetFencingToken(token uint64)
+
+	// AddOutflow add outflow
+	AddOutflow(outflow *Outflow) error
+	// FindOutflows find all outflows of token pair and direction with timestamp >= septime
+	FindOutflows(pairID string, isSwapin bool, septime int64) ([]*Outflow, error)
+	// SetOutflowBreaker insert or replace outflow breaker
+	SetOutflowBreaker(breaker *OutflowBreaker) error
+	// FindOutflowBreakers find all outflow breakers
+	FindOutflowBreakers() ([]*OutflowBreaker, error)
+
+	// Close close the storage backend
+	Close() error
+}
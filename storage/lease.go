@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+// LeaderLeaseName lease name of the swap server leadership
+const LeaderLeaseName = "leader"
+
+// the lease held by this instance which fences swap writes
+var (
+	fencingLease     *Lease
+	fencingLeaseLock sync.RWMutex
+)
+
+// AcquireLease acquire or renew lease for holder
+func AcquireLease(name, holder string, ttl int64) (*Lease, error) {
+	return swapStore.AcquireLease(name, holder, ttl, time.Now().Unix())
+}
+
+// ReleaseLease release lease held by holder
+func ReleaseLease(name, holder string) error {
+	return swapStore.ReleaseLease(name, holder)
+}
+
+// FindLease find lease
+func FindLease(name string) (*Lease, error) {
+	return swapStore.FindLease(name)
+}
+
+// SetFencingLease fence swap writes with the lease held by this instance,
+// once set, keep the stale lease instead of clearing it when losing leadership.
+// the store rejects the write of an item already written with a newer lease token
+// (by the new leader) in the write condition. nil lease stops fencing.
+func SetFencingLease(lease *Lease) {
+	fencingLeaseLock.Lock()
+	defer fencingLeaseLock.Unlock()
+	fencingLease = lease
+	var token uint64
+	if lease != nil {
+		token = lease.Token
+	}
+	swapStore.SetFencingToken(token)
+}
+
+// checkFencingToken reject swap writes locally once the lease held by this instance expires,
+// the writes racing with the takeover are fenced by the store (see SetFencingLease).
+func checkFencingToken() error {
+	fencingLeaseLock.RLock()
+	lease := fencingLease
+	fencingLeaseLock.RUnlock()
+	if lease != nil && lease.Expires <= time.Now().Unix() {
+		return ErrNotLeader
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+)
+
+// nonce reservation status
+const (
+	NonceReserved  = "reserved"  // reserved and not sent yet
+	NonceSent      = "sent"      // swap tx with the nonce is sent
+	NonceReleased  = "released"  // released by setting nonce or consumed by others
+	NonceCancelled = "cancelled" // gap filled by a zero value cancel tx
+)
+
+// GetNonceReservationKey get nonce reservation key (sortable by nonce)
+func GetNonceReservationKey(account string, nonce uint64) string {
+	return fmt.Sprintf("%s:%020d", account, nonce)
+}
+
+// ReserveNonce reserve nonce of account for txid
+func ReserveNonce(account, txid string, minNonce uint64) (*NonceReservation, error) {
+	err := checkFencingToken()
+	if err != nil {
+		return nil, err
+	}
+	reservation, err := swapStore.ReserveNonce(account, txid, minNonce, time.Now().Unix())
+	if err == nil {
+		log.Info("reserve nonce success", "account", account, "txid", txid, "minNonce", minNonce, "nonce", reservation.Nonce)
+	} else {
+		log.Warn("reserve nonce failed", "account", account, "txid", txid, "minNonce", minNonce, "err", err)
+	}
+	return reservation, err
+}
+
+// SetNextNonce set next nonce of account
+func SetNextNonce(account string, nonce uint64) error {
+	err := checkFencingToken()
+	if err == nil {
+		err = swapStore.SetNextNonce(account, nonce, time.Now().Unix())
+	}
+	if err == nil {
+		log.Info("set next nonce success", "account", account, "nonce", nonce)
+	} else {
+		log.Warn("set next nonce failed", "account", account, "nonce", nonce, "err", err)
+	}
+	return err
+}
+
+// FindNonceAccount find nonce account
+func FindNonceAccount(account string) (*NonceAccount, error) {
+	return swapStore.FindNonceAccount(account)
+}
+
+// FindNonceReservation find nonce reservation of account and nonce
+func FindNonceReservation(account string, nonce uint64) (*NonceReservation, error) {
+	return swapStore.FindNonceReservation(account, nonce)
+}
+
+// SetNonceReservation insert or replace nonce reservation
+func SetNonceReservation(reservation *NonceReservation) error {
+	err := checkFencingToken()
+	if err != nil {
+		return err
+	}
+	reservation.Timestamp = time.Now().Unix()
+	return swapStore.SetNonceReservation(reservation)
+}
+
+// NonceManager persistent nonce manager of dcrm addresses
+type NonceManager struct{}
+
+// ReserveNonce impl tokens.NonceManager
+func (m *NonceManager) ReserveNonce(account, txid string, minNonce uint64) (uint64, error) {
+	reservation, err := ReserveNonce(account, txid, minNonce)
+	if err != nil {
+		return 0, err
+	}
+	return reservation.Nonce, nil
+}
+
+// SetNextNonce impl tokens.NonceManager
+func (m *NonceManager) SetNextNonce(account string, nonce uint64) error {
+	return SetNextNonce(account, nonce)
+}
@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"sync"
+)
+
+// SwapUpdateHandler is called after a swap or swap result is added or updated,
+// `swap` is set if the swap is changed, `result` is set if the swap result is changed.
+type SwapUpdateHandler func(isSwapin bool, swap *Swap, result *SwapResult)
+
+var (
+	swapUpdateHandlers     []SwapUpdateHandler
+	swapUpdateHandlersLock sync.RWMutex
+)
+
+// AddSwapUpdateHandler add handler to be notified of swap updates
+func AddSwapUpdateHandler(handler SwapUpdateHandler) {
+	swapUpdateHandlersLock.Lock()
+	defer swapUpdateHandlersLock.Unlock()
+	swapUpdateHandlers = append(swapUpdateHandlers, handler)
+}
+
+func getSwapUpdateHandlers() []SwapUpdateHandler {
+	swapUpdateHandlersLock.RLock()
+	defer swapUpdateHandlersLock.RUnlock()
+	return swapUpdateHandlers
+}
+
+// notifySwapUpdated notify handlers of the written swap,
+// the updated swap is derived from the old one instead of reading it back.
+func notifySwapUpdated(isSwapin bool, swap *Swap) {
+	for _, handler := range getSwapUpdateHandlers() {
+		handler(isSwapin, swap, nil)
+	}
+}
+
+// notifySwapResultUpdated notify handlers of the written swap result
+func notifySwapResultUpdated(isSwapin bool, result *SwapResult) {
+	for _, handler := range getSwapUpdateHandlers() {
+		handler(isSwapin, nil, result)
+	}
+}
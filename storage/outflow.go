@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+// GetOutflowKey get outflow key of swap
+func GetOutflowKey(isSwapin bool, txid string) string {
+	if isSwapin {
+		return "swapin:" + txid
+	}
+	return "swapout:" + txid
+}
+
+// AddOutflow record the swapped value of admitted swap, an existing outflow of the swap is kept
+func AddOutflow(isSwapin bool, pairID, txid, bind, from, value string, released bool) error {
+	err := checkFencingToken()
+	if err != nil {
+		return err
+	}
+	outflow := &Outflow{
+		Key:       GetOutflowKey(isSwapin, txid),
+		PairID:    pairID,
+		IsSwapin:  isSwapin,
+		TxID:      txid,
+		Bind:      bind,
+		From:      from,
+		Value:     value,
+		Released:  released,
+		Timestamp: time.Now().Unix(),
+	}
+	err = swapStore.AddOutflow(outflow)
+	if err == ErrItemIsDup {
+		return nil
+	}
+	if err != nil {
+		log.Warn("add outflow failed", "pairID", pairID, "txid", txid, "isSwapin", isSwapin, "err", err)
+	}
+	return err
+}
+
+// FindOutflows find outflows of token pair and direction in the past septime
+func FindOutflows(pairID string, isSwapin bool, septime int64) ([]*Outflow, error) {
+	return swapStore.FindOutflows(pairID, isSwapin, septime)
+}
+
+// addSwapResultOutflow record outflow of swap passed by admin (bypassing the outflow limits),
+// so that it is counted by the limits of the later swaps. it is called after the status is
+// changed successfully, and is idempotent as the outflow is keyed by txid (see AddOutflow).
+func addSwapResultOutflow(isSwapin bool, txid string) error {
+	res, err := FindSwapResult(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	if tokens.GetOutflowLimits(res.PairID, isSwapin) == nil {
+		return nil
+	}
+	return AddOutflow(isSwapin, res.PairID, txid, res.Bind, res.From, res.Value, true)
+}
+
+// GetOutflowBreakerKey get outflow breaker key of token pair direction
+func GetOutflowBreakerKey(pairID string, isSwapin bool) string {
+	if isSwapin {
+		return "swapin:" + strings.ToLower(pairID)
+	}
+	return "swapout:" + strings.ToLower(pairID)
+}
+
+// TripOutflowBreaker persist the tripped breaker, so that the pause survives restarts and failovers
+func TripOutflowBreaker(pairID string, isSwapin bool, reason string) error {
+	return setOutflowBreaker(pairID, isSwapin, true, reason)
+}
+
+// ResetOutflowBreaker reset the tripped breaker (eg. by 'maintain open')
+func ResetOutflowBreaker(pairID string, isSwapin bool, reason string) error {
+	key := GetOutflowBreakerKey(pairID, isSwapin)
+	breakers, err := swapStore.FindOutflowBreakers()
+	if err != nil {
+		return err
+	}
+	for _, breaker := range breakers {
+		if breaker.Key == key && breaker.Tripped {
+			return setOutflowBreaker(pairID, isSwapin, false, reason)
+		}
+	}
+	return nil
+}
+
+func setOutflowBreaker(pairID string, isSwapin, tripped bool, reason string) error {
+	err := checkFencingToken()
+	if err != nil {
+		return err
+	}
+	breaker := &OutflowBreaker{
+		Key:       GetOutflowBreakerKey(pairID, isSwapin),
+		PairID:    strings.ToLower(pairID),
+		IsSwapin:  isSwapin,
+		Tripped:   tripped,
+		Reason:    reason,
+		Timestamp: time.Now().Unix(),
+	}
+	err = swapStore.SetOutflowBreaker(breaker)
+	if err != nil {
+		log.Warn("set outflow breaker failed", "pairID", pairID, "isSwapin", isSwapin, "tripped", tripped, "err", err)
+	}
+	return err
+}
+
+// FindOutflowBreakers find all outflow breakers
+func FindOutflowBreakers() ([]*OutflowBreaker, error) {
+	return swapStore.FindOutflowBreakers()
+}
@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// sort fields of swap results query
+const (
+	SortByTimestamp  = "timestamp"
+	SortByTxTime     = "txtime"
+	SortByTxHeight   = "txheight"
+	SortBySwapHeight = "swapheight"
+)
+
+// max decimal digits of uint256 value
+const valueKeyWidth = 78
+
+// SwapResultQuery filters, sorting and cursor of swap results query,
+// empty filter field matches any.
+type SwapResultQuery struct {
+	Statuses  []SwapStatus
+	PairID    string
+	From      string
+	To        string
+	Bind      string
+	SwapTx    string
+	StartTime int64    // timestamp >= StartTime if not zero
+	EndTime   int64    // timestamp < EndTime if not zero
+	MinValue  *big.Int // value >= MinValue if not nil
+	MaxValue  *big.Int // value <= MaxValue if not nil
+	SortBy    string   // sort field, default is timestamp
+	SortAsc   bool     // sort ascending, default is descending
+	After     *SwapResultCursor
+	Limit     int
+}
+
+// SwapResultCursor position of swap result in sort order (sort value and key)
+type SwapResultCursor struct {
+	Value int64
+	Key   string
+}
+
+// IsValidSortField is valid sort field
+func IsValidSortField(sortBy string) bool {
+	switch sortBy {
+	case SortByTimestamp, SortByTxTime, SortByTxHeight, SortBySwapHeight:
+		return true
+	default:
+		return false
+	}
+}
+
+// GetSortValue get value of sort field
+func (res *SwapResult) GetSortValue(sortBy string) int64 {
+	switch sortBy {
+	case SortByTxTime:
+		return int64(res.TxTime)
+	case SortByTxHeight:
+		return int64(res.TxHeight)
+	case SortBySwapHeight:
+		return int64(res.SwapHeight)
+	default:
+		return res.Timestamp
+	}
+}
+
+// Cursor get cursor of swap result in sort order
+func (res *SwapResult) Cursor(sortBy string) *SwapResultCursor {
+	return &SwapResultCursor{Value: res.GetSortValue(sortBy), Key: res.Key}
+}
+
+// Less compare swap results in sort order
+func (q *SwapResultQuery) Less(a, b *SwapResult) bool {
+	return q.isBefore(a.Cursor(q.SortBy), b.Cursor(q.SortBy))
+}
+
+// IsAfterCursor is swap result after the cursor in sort order
+func (q *SwapResultQuery) IsAfterCursor(res *SwapResult) bool {
+	return q.After == nil || q.isBefore(q.After, res.Cursor(q.SortBy))
+}
+
+func (q *SwapResultQuery) isBefore(a, b *SwapResultCursor) bool {
+	switch {
+	case a.Value != b.Value:
+		return (a.Value < b.Value) == q.SortAsc
+	case a.Key != b.Key:
+		return (a.Key < b.Key) == q.SortAsc
+	default:
+		return false
+	}
+}
+
+// Match is swap result matches the filters (not including cursor)
+func (q *SwapResultQuery) Match(res *SwapResult) bool {
+	switch {
+	case len(q.Statuses) != 0 && !q.matchStatus(res.Status):
+	case q.PairID != "" && res.PairID != q.PairID:
+	case q.From != "" && res.From != q.From:
+	case q.To != "" && res.To != q.To:
+	case q.Bind != "" && res.Bind != q.Bind:
+	case q.SwapTx != "" && res.SwapTx != q.SwapTx:
+	case q.StartTime != 0 && res.Timestamp < q.StartTime:
+	case q.EndTime != 0 && res.Timestamp >= q.EndTime:
+	case (q.MinValue != nil || q.MaxValue != nil) && !q.matchValue(res.Value):
+	default:
+		return true
+	}
+	return false
+}
+
+func (q *SwapResultQuery) matchStatus(status SwapStatus) bool {
+	for _, s := range q.Statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *SwapResultQuery) matchValue(valueStr string) bool {
+	value, ok := new(big.Int).SetString(valueStr, 10)
+	if !ok {
+		return false
+	}
+	if q.MinValue != nil && value.Cmp(q.MinValue) < 0 {
+		return false
+	}
+	if q.MaxValue != nil && value.Cmp(q.MaxValue) > 0 {
+		return false
+	}
+	return true
+}
+
+// GetValueKey get value key of decimal value string, which is the value
+// left padded with zeros to a fixed width, so that comparing value keys
+// as strings is comparing values. returns empty string if invalid.
+func GetValueKey(valueStr string) string {
+	value, ok := new(big.Int).SetString(valueStr, 10)
+	if !ok || value.Sign() < 0 {
+		return ""
+	}
+	return getValueKey(value)
+}
+
+func getValueKey(value *big.Int) string {
+	return fmt.Sprintf("%0*s", valueKeyWidth, value.String())
+}
+
+// GetValueKeyRange get value key range [minKey, maxKey] of value filters,
+// maxKey is empty if MaxValue is nil.
+func (q *SwapResultQuery) GetValueKeyRange() (minKey, maxKey string) {
+	minValue := q.MinValue
+	if minValue == nil || minValue.Sign() < 0 {
+		minValue = big.NewInt(0)
+	}
+	minKey = getValueKey(minValue)
+	if q.MaxValue != nil {
+		maxKey = getValueKey(q.MaxValue)
+	}
+	return minKey, maxKey
+}
+
+// QuerySwapResults query swap results of swapins and (or) swapouts,
+// returns the next cursor if there may be more results.
+func QuerySwapResults(query *SwapResultQuery, withSwapin, withSwapout bool) (result []*SwapResult, next *SwapResultCursor, err error) {
+	if query.SortBy == "" {
+		query.SortBy = SortByTimestamp
+	}
+	if !IsValidSortField(query.SortBy) {
+		return nil, nil, fmt.Errorf("unknown sort field '%v'", query.SortBy)
+	}
+	if query.Limit <= 0 {
+		return nil, nil, fmt.Errorf("query limit must be positive")
+	}
+	if withSwapin {
+		result, err = swapStore.QuerySwapResults(true, query)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	if withSwapout {
+		swapouts, err := swapStore.QuerySwapResults(false, query)
+		if err != nil {
+			return nil, nil, err
+		}
+		result = append(result, swapouts...)
+		sort.SliceStable(result, func(i, j int) bool {
+			return query.Less(result[i], result[j])
+		})
+	}
+	if len(result) > query.Limit {
+		result = result[:query.Limit]
+	}
+	if len(result) == query.Limit {
+		next = result[len(result)-1].Cursor(query.SortBy)
+	}
+	return result, next, nil
+}
@@ -0,0 +1,119 @@
+package storage_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/storage/boltdb"
+)
+
+func setTestSwapStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "storage-test")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	store, err := boltdb.NewSwapStore(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("new swap store failed: %v", err)
+	}
+	storage.SetSwapStore(store)
+	t.Cleanup(func() {
+		_ = storage.CloseSwapStore()
+		storage.SetSwapStore(nil)
+		_ = os.RemoveAll(dir)
+	})
+}
+
+func TestQuerySwapResultsCrossDirection(t *testing.T) {
+	setTestSwapStore(t)
+	swapResults := []struct {
+		key       string
+		isSwapin  bool
+		timestamp int64
+	}{
+		{"i1", true, 10},
+		{"i2", true, 20},
+		{"i3", true, 30},
+		{"i4", true, 30},
+		{"o1", false, 15},
+		{"o2", false, 20},
+		{"o3", false, 30},
+		{"o4", false, 40},
+	}
+	for _, r := range swapResults {
+		res := &storage.SwapResult{Key: r.key, TxID: r.key, Value: "1", Status: storage.MatchTxStable, Timestamp: r.timestamp}
+		if err := storage.AddSwapResult("test", r.isSwapin, res); err != nil {
+			t.Fatalf("add swap result failed: %v", err)
+		}
+	}
+	if res, err := storage.FindSwapResult(true, "i1"); err != nil || res.ValueKey != storage.GetValueKey("1") {
+		t.Fatalf("value key is not stored, got %+v, err %v", res, err)
+	}
+
+	tests := []struct {
+		sortAsc bool
+		limit   int
+		want    string
+	}{
+		{false, 3, "[o4 o3 i4 i3 o2 i2 o1 i1]"},
+		{true, 3, "[i1 o1 i2 o2 i3 i4 o3 o4]"},
+		{false, 1, "[o4 o3 i4 i3 o2 i2 o1 i1]"},
+		{true, 8, "[i1 o1 i2 o2 i3 i4 o3 o4]"},
+	}
+	for _, test := range tests {
+		query := &storage.SwapResultQuery{SortAsc: test.sortAsc, Limit: test.limit}
+		var keys []string
+		for page := 0; page <= len(swapResults); page++ {
+			results, next, err := storage.QuerySwapResults(query, true, true)
+			if err != nil {
+				t.Fatalf("query swap results failed: %v", err)
+			}
+			if len(results) > test.limit {
+				t.Fatalf("query swap results, want at most %v, got %v", test.limit, len(results))
+			}
+			for _, res := range results {
+				keys = append(keys, res.Key)
+			}
+			if next == nil {
+				break
+			}
+			query.After = next
+		}
+		if fmt.Sprint(keys) != test.want {
+			t.Errorf("query swap results by pages (asc %v, limit %v), want %v, got %v", test.sortAsc, test.limit, test.want, keys)
+		}
+	}
+}
+
+func TestGetValueKey(t *testing.T) {
+	values := []string{"0", "9", "10", "99", "100", "123456789012345678901234567890",
+		"115792089237316195423570985008687907853269984665640564039457584007913129639935", // max uint256
+	}
+	keys := make([]string, len(values))
+	for i, value := range values {
+		keys[i] = storage.GetValueKey(value)
+		if len(keys[i]) != len(values[len(values)-1]) {
+			t.Errorf("wrong value key length of %v, got %v", value, len(keys[i]))
+		}
+	}
+	if !sort.StringsAreSorted(keys) {
+		t.Errorf("value keys are not sorted as values: %v", keys)
+	}
+	for _, value := range []string{"", "-1", "1.5", "0x10"} {
+		if key := storage.GetValueKey(value); key != "" {
+			t.Errorf("value key of invalid value '%v', want empty, got %v", value, key)
+		}
+	}
+
+	query := &storage.SwapResultQuery{MaxValue: big.NewInt(99)}
+	minKey, maxKey := query.GetValueKeyRange()
+	if minKey != storage.GetValueKey("0") || maxKey != storage.GetValueKey("99") {
+		t.Errorf("wrong value key range [%v, %v]", minKey, maxKey)
+	}
+}
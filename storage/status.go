@@ -1,4 +1,4 @@
-package mongodb
+package storage
 
 import (
 	"fmt"
@@ -7,6 +7,8 @@ import (
 // -----------------------------------------------
 // swap status change graph
 // symbol '--->' mean transfer only under checked condition (eg. manual process)
+// the graph is enforced by swapTransitions and resultTransitions in transition.go,
+// update them together.
 //
 // -----------------------------------------------
 // 1. swap register status change graph
@@ -21,17 +23,43 @@ import (
 //                |- BindAddrIsContract-> manual
 //                |- RPCQueryError     -> manual
 //                |- TxWithBigValue        ---> TxNotSwapped
+//                |- TxHeld (exceed outflow limits) -> TxNotSwapped (released or manual)
 //                |- TxSenderNotRegistered ---> TxNotStable
 //                |- TxNotSwapped -> |- TxSwapFailed -> manual
 //                                   |- TxProcessed (->MatchTxNotStable)
+//
+// TxSwapFailed -> TxProcessed, TxRefundFailed -> TxRefunded (failed broadcasting found in chain or pool)
+//
+// TxNotStable, TxNotSwapped -> TxDeadLetter (retry exhausted or permanent error)
+// TxHeld -> TxDeadLetter (held too long)
+// TxDeadLetter ---> |- TxNotStable, TxNotSwapped, TxHeld (requeue)
+//                   |- ManualMakeFail
+//
+// TxNotSwapped, TxWithBigValue, TxHeld, TxProcessed -> TxReorged (source tx in orphaned block)
+// TxReorged -> |- TxNotSwapped, TxWithBigValue, TxHeld (reverify passed and not swapped)
+//              |- TxProcessed (reverify passed and swapped)
+//              |- TxVerifyFailed (reverify failed and not swapped)
+//              |- manual (reverify failed and swapped)
+//
+// refund (if enabled) of TxWithWrongMemo, TxWithWrongValue, BindAddrIsContract, SwapInBlacklist
+// -> |- TxRefundNeedApproval ---> TxRefundApproved -> TxRefunded
+//    |- TxRefunded
+//    |- TxRefundFailed -> manual
 // -----------------------------------------------
 // 2. swap result status change graph
 //
 // TxWithWrongMemo -> manual
 // TxWithBigValue        ---> MatchTxEmpty
+// TxHeld                ---> MatchTxEmpty
 // TxSenderNotRegistered ---> MatchTxEmpty
 // MatchTxEmpty          -> | MatchTxNotStable -> |- MatchTxStable
 //                                                |- MatchTxFailed -> manual
+// MatchTxNotStable, MatchTxStable -> MatchTxReorged (match tx in orphaned block)
+// MatchTxReorged -> |- MatchTxNotStable (match tx is found again in chain or pool)
+//                   |- MatchTxFailed -> manual (match tx is not found for a long time)
+// refunded -> RefundTxNotStable -> |- RefundTxStable
+//                                  |- RefundTxFailed -> manual
+// TxSwapFailed -> MatchTxNotStable, TxRefundFailed -> RefundTxNotStable (failed broadcasting found in chain or pool)
 // -----------------------------------------------
 
 // SwapStatus swap status
@@ -58,12 +86,23 @@ const (
 	ManualMakeFail                          // 16
 	BindAddrIsContract                      // 17
 	RPCQueryError                           // 18
+	TxReorged                               // 19
+	MatchTxReorged                          // 20
+	TxRefundNeedApproval                    // 21
+	TxRefundApproved                        // 22
+	TxRefundFailed                          // 23
+	TxRefunded                              // 24
+	RefundTxNotStable                       // 25
+	RefundTxStable                          // 26
+	RefundTxFailed                          // 27
+	TxDeadLetter                            // 28
+	TxHeld                                  // 29
 )
 
 // CanManualMakePass can manual make pass
 func (status SwapStatus) CanManualMakePass() bool {
 	switch status {
-	case TxWithBigValue:
+	case TxWithBigValue, TxHeld:
 		return true
 	default:
 		return false
@@ -73,7 +112,7 @@ func (status SwapStatus) CanManualMakePass() bool {
 // CanManualMakeFail can manual make fail
 func (status SwapStatus) CanManualMakeFail() bool {
 	switch status {
-	case TxNotStable, TxNotSwapped:
+	case TxNotStable, TxNotSwapped, TxReorged, TxDeadLetter, TxHeld:
 		return true
 	default:
 		return false
@@ -102,7 +141,19 @@ func (status SwapStatus) CanReverify() bool {
 		ManualMakeFail,
 		TxIncompatible,
 		BindAddrIsContract,
-		RPCQueryError:
+		RPCQueryError,
+		TxRefundNeedApproval,
+		TxHeld:
+		return true
+	default:
+		return false
+	}
+}
+
+// CanRefund can refund (deposit can never be swapped)
+func (status SwapStatus) CanRefund() bool {
+	switch status {
+	case TxWithWrongMemo, TxWithWrongValue, BindAddrIsContract, SwapInBlacklist:
 		return true
 	default:
 		return false
@@ -119,6 +170,16 @@ func (status SwapStatus) CanReswap() bool {
 	}
 }
 
+// ParseSwapStatus parse swap status from its name
+func ParseSwapStatus(name string) (SwapStatus, error) {
+	for status := TxNotStable; status <= TxHeld; status++ {
+		if status.String() == name {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown swap status '%v'", name)
+}
+
 // nolint:gocyclo // allow big simple switch
 func (status SwapStatus) String() string {
 	switch status {
@@ -160,6 +221,28 @@ func (status SwapStatus) String() string {
 		return "BindAddrIsContract"
 	case RPCQueryError:
 		return "RPCQueryError"
+	case TxReorged:
+		return "TxReorged"
+	case MatchTxReorged:
+		return "MatchTxReorged"
+	case TxRefundNeedApproval:
+		return "TxRefundNeedApproval"
+	case TxRefundApproved:
+		return "TxRefundApproved"
+	case TxRefundFailed:
+		return "TxRefundFailed"
+	case TxRefunded:
+		return "TxRefunded"
+	case RefundTxNotStable:
+		return "RefundTxNotStable"
+	case RefundTxStable:
+		return "RefundTxStable"
+	case RefundTxFailed:
+		return "RefundTxFailed"
+	case TxDeadLetter:
+		return "TxDeadLetter"
+	case TxHeld:
+		return "TxHeld"
 	default:
 		return fmt.Sprintf("unknown swap status %d", status)
 	}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// IllegalTransitionError is returned when a status update is not allowed by the transition table
+type IllegalTransitionError struct {
+	IsResult bool
+	From     SwapStatus
+	To       SwapStatus
+}
+
+func (e *IllegalTransitionError) Error() string {
+	target := "swap"
+	if e.IsResult {
+		target = "swap result"
+	}
+	return fmt.Sprintf("illegal %v status transition from %v to %v", target, e.From.String(), e.To.String())
+}
+
+// swap result statuses without swap tx and refund tx
+var pendingResultStatuses = []SwapStatus{
+	MatchTxEmpty,
+	TxWithBigValue,
+	TxHeld,
+	TxWithWrongMemo,
+	TxWithWrongValue,
+	BindAddrIsContract,
+	SwapInBlacklist,
+	TxSenderNotRegistered,
+	TxVerifyFailed,
+	TxReorged,
+}
+
+// swapTransitions allowed transitions of swap status (see the graph in status.go),
+// transition to the same status is always allowed.
+var swapTransitions = map[SwapStatus][]SwapStatus{
+	TxNotStable: {
+		TxVerifyFailed, TxWithWrongSender, TxWithWrongValue, TxIncompatible,
+		TxNotSwapped, TxWithWrongMemo, TxWithBigValue, TxSenderNotRegistered,
+		SwapInBlacklist, ManualMakeFail, BindAddrIsContract, RPCQueryError,
+		TxDeadLetter, TxHeld,
+	},
+	TxVerifyFailed:        {TxNotStable},
+	TxWithWrongValue:      {TxNotStable, TxRefundNeedApproval, TxRefunded, TxRefundFailed},
+	TxIncompatible:        {TxNotStable},
+	TxNotSwapped:          {TxProcessed, SwapInBlacklist, ManualMakeFail, TxReorged, TxDeadLetter},
+	TxSwapFailed:          {TxNotSwapped, TxProcessed},
+	TxProcessed:           {TxSwapFailed, TxNotSwapped, TxReorged},
+	TxWithWrongMemo:       {TxRefundNeedApproval, TxRefunded, TxRefundFailed},
+	TxWithBigValue:        {TxNotSwapped, TxNotStable, TxReorged},
+	TxSenderNotRegistered: {TxNotStable},
+	SwapInBlacklist:       {TxNotStable, TxRefundNeedApproval, TxRefunded, TxRefundFailed},
+	ManualMakeFail:        {TxNotStable},
+	BindAddrIsContract:    {TxNotStable, TxRefundNeedApproval, TxRefunded, TxRefundFailed},
+	RPCQueryError:         {TxNotStable},
+	TxReorged:             {TxNotSwapped, TxWithBigValue, TxHeld, TxProcessed, TxVerifyFailed, ManualMakeFail},
+	TxRefundNeedApproval:  {TxRefundApproved, TxNotStable},
+	TxRefundApproved:      {TxRefunded, TxRefundFailed},
+	TxRefunded:            {TxRefundFailed},
+	TxRefundFailed:        {TxRefunded},
+	TxDeadLetter:          {TxNotStable, TxNotSwapped, TxHeld, ManualMakeFail},
+	TxHeld:                {TxNotSwapped, TxNotStable, ManualMakeFail, TxReorged, TxDeadLetter},
+}
+
+// resultTransitions allowed transitions of swap result status (see the graph in status.go),
+// transition to the same status is always allowed.
+var resultTransitions = map[SwapStatus][]SwapStatus{
+	MatchTxNotStable:  {MatchTxStable, MatchTxFailed, TxSwapFailed, MatchTxReorged, MatchTxEmpty},
+	MatchTxStable:     {MatchTxReorged},
+	MatchTxFailed:     {MatchTxEmpty},
+	TxSwapFailed:      {MatchTxEmpty, MatchTxNotStable},
+	MatchTxReorged:    {MatchTxNotStable, MatchTxFailed},
+	RefundTxNotStable: {RefundTxStable, RefundTxFailed, TxRefundFailed},
+	TxRefundFailed:    {RefundTxNotStable},
+}
+
+func init() {
+	// a pending result can be reverified, swapped or refunded
+	for _, from := range pendingResultStatuses {
+		to := append([]SwapStatus{MatchTxNotStable, RefundTxNotStable}, pendingResultStatuses...)
+		resultTransitions[from] = append(resultTransitions[from], to...)
+	}
+}
+
+// CanTransitTo can swap status transit to status
+func (status SwapStatus) CanTransitTo(to SwapStatus) bool {
+	return canTransit(swapTransitions, status, to)
+}
+
+// CanResultTransitTo can swap result status transit to status
+func (status SwapStatus) CanResultTransitTo(to SwapStatus) bool {
+	return canTransit(resultTransitions, status, to)
+}
+
+func canTransit(transitions map[SwapStatus][]SwapStatus, from, to SwapStatus) bool {
+	if from == to {
+		return true
+	}
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+func checkSwapTransition(from, to SwapStatus) error {
+	if !from.CanTransitTo(to) {
+		return &IllegalTransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+func checkResultTransition(from, to SwapStatus) error {
+	if !from.CanResultTransitTo(to) {
+		return &IllegalTransitionError{IsResult: true, From: from, To: to}
+	}
+	return nil
+}
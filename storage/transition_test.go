@@ -0,0 +1,171 @@
+package storage_test
+
+import (
+	"testing"
+
+	"github.com/anyswap/CrossChain-Bridge/storage"
+)
+
+type testTransition struct {
+	from, to storage.SwapStatus
+}
+
+func TestSwapStatusTransitions(t *testing.T) {
+	legals := []testTransition{
+		{storage.TxNotStable, storage.TxNotSwapped},
+		{storage.TxNotStable, storage.TxWithBigValue},
+		{storage.TxNotStable, storage.TxHeld},
+		{storage.TxNotStable, storage.TxDeadLetter},
+		{storage.TxNotSwapped, storage.TxProcessed},
+		{storage.TxNotSwapped, storage.TxDeadLetter},
+		{storage.TxProcessed, storage.TxSwapFailed},
+		{storage.TxSwapFailed, storage.TxProcessed},
+		{storage.TxWithBigValue, storage.TxNotSwapped},
+		{storage.TxHeld, storage.TxNotSwapped},
+		{storage.TxHeld, storage.TxDeadLetter},
+		{storage.TxDeadLetter, storage.TxNotSwapped},
+		{storage.TxDeadLetter, storage.TxHeld},
+		{storage.TxProcessed, storage.TxReorged},
+		{storage.TxReorged, storage.TxProcessed},
+		{storage.TxReorged, storage.TxVerifyFailed},
+		{storage.TxWithWrongMemo, storage.TxRefundNeedApproval},
+		{storage.TxRefundNeedApproval, storage.TxRefundApproved},
+		{storage.TxRefundApproved, storage.TxRefunded},
+		{storage.TxRefundFailed, storage.TxRefunded},
+		{storage.TxRefunded, storage.TxRefundFailed},
+	}
+	illegals := []testTransition{
+		{storage.TxNotStable, storage.TxProcessed},
+		{storage.TxNotStable, storage.TxRefunded},
+		{storage.TxNotSwapped, storage.TxNotStable},
+		{storage.TxProcessed, storage.TxNotStable},
+		{storage.TxProcessed, storage.ManualMakeFail},
+		{storage.TxSwapFailed, storage.TxNotStable},
+		{storage.TxWithBigValue, storage.TxProcessed},
+		{storage.TxHeld, storage.TxProcessed},
+		{storage.TxDeadLetter, storage.TxProcessed},
+		{storage.TxReorged, storage.TxNotStable},
+		{storage.TxWithWrongSender, storage.TxNotStable},
+		{storage.TxWithWrongMemo, storage.TxNotStable},
+		{storage.TxWithWrongMemo, storage.TxNotSwapped},
+		{storage.TxRefundNeedApproval, storage.TxRefunded},
+		{storage.TxRefundApproved, storage.TxNotStable},
+		{storage.TxRefunded, storage.TxNotStable},
+		{storage.TxRefunded, storage.TxNotSwapped},
+		{storage.MatchTxStable, storage.TxNotStable},
+	}
+	for _, test := range legals {
+		if !test.from.CanTransitTo(test.to) {
+			t.Errorf("swap status should transit from %v to %v", test.from, test.to)
+		}
+	}
+	for _, test := range illegals {
+		if test.from.CanTransitTo(test.to) {
+			t.Errorf("swap status should not transit from %v to %v", test.from, test.to)
+		}
+	}
+	for status := storage.TxNotStable; status <= storage.TxHeld; status++ {
+		if !status.CanTransitTo(status) || !status.CanResultTransitTo(status) {
+			t.Errorf("status should transit to the same status %v", status)
+		}
+	}
+}
+
+// the manual operations allowed by status must be allowed by the transition table
+func TestSwapStatusTransitionsOfOperations(t *testing.T) {
+	for status := storage.TxNotStable; status <= storage.TxHeld; status++ {
+		if status.CanManualMakeFail() && !status.CanTransitTo(storage.ManualMakeFail) {
+			t.Errorf("can manual make fail %v, but can not transit to %v", status, storage.ManualMakeFail)
+		}
+		if status.CanManualMakePass() && !status.CanTransitTo(storage.TxNotSwapped) {
+			t.Errorf("can manual make pass %v, but can not transit to %v", status, storage.TxNotSwapped)
+		}
+		if (status.CanReverify() || status.CanRetry()) && !status.CanTransitTo(storage.TxNotStable) {
+			t.Errorf("can reverify or retry %v, but can not transit to %v", status, storage.TxNotStable)
+		}
+		if status.CanReswap() && !status.CanTransitTo(storage.TxNotSwapped) {
+			t.Errorf("can reswap %v, but can not transit to %v", status, storage.TxNotSwapped)
+		}
+		if status.CanRefund() && !status.CanTransitTo(storage.TxRefundNeedApproval) {
+			t.Errorf("can refund %v, but can not transit to %v", status, storage.TxRefundNeedApproval)
+		}
+	}
+}
+
+func TestSwapResultStatusTransitions(t *testing.T) {
+	legals := []testTransition{
+		{storage.MatchTxEmpty, storage.MatchTxNotStable},
+		{storage.MatchTxEmpty, storage.RefundTxNotStable},
+		{storage.TxWithBigValue, storage.MatchTxEmpty},
+		{storage.TxHeld, storage.MatchTxEmpty},
+		{storage.TxSenderNotRegistered, storage.MatchTxEmpty},
+		{storage.TxReorged, storage.TxHeld},
+		{storage.MatchTxNotStable, storage.MatchTxStable},
+		{storage.MatchTxNotStable, storage.MatchTxFailed},
+		{storage.MatchTxNotStable, storage.MatchTxReorged},
+		{storage.MatchTxStable, storage.MatchTxReorged},
+		{storage.MatchTxReorged, storage.MatchTxNotStable},
+		{storage.MatchTxReorged, storage.MatchTxFailed},
+		{storage.TxSwapFailed, storage.MatchTxNotStable},
+		{storage.RefundTxNotStable, storage.RefundTxStable},
+		{storage.RefundTxNotStable, storage.RefundTxFailed},
+		{storage.TxRefundFailed, storage.RefundTxNotStable},
+	}
+	illegals := []testTransition{
+		{storage.MatchTxEmpty, storage.MatchTxStable},
+		{storage.MatchTxStable, storage.MatchTxNotStable},
+		{storage.MatchTxStable, storage.MatchTxEmpty},
+		{storage.MatchTxStable, storage.MatchTxFailed},
+		{storage.MatchTxFailed, storage.MatchTxNotStable},
+		{storage.MatchTxReorged, storage.MatchTxStable},
+		{storage.RefundTxStable, storage.RefundTxNotStable},
+		{storage.RefundTxStable, storage.MatchTxEmpty},
+		{storage.RefundTxFailed, storage.RefundTxNotStable},
+		{storage.RefundTxNotStable, storage.MatchTxNotStable},
+		{storage.TxWithWrongSender, storage.MatchTxEmpty},
+	}
+	for _, test := range legals {
+		if !test.from.CanResultTransitTo(test.to) {
+			t.Errorf("swap result status should transit from %v to %v", test.from, test.to)
+		}
+	}
+	for _, test := range illegals {
+		if test.from.CanResultTransitTo(test.to) {
+			t.Errorf("swap result status should not transit from %v to %v", test.from, test.to)
+		}
+	}
+}
+
+func TestUpdateSwapStatusTransition(t *testing.T) {
+	setTestSwapStore(t)
+	swap := &storage.Swap{Key: "tx1", TxID: "tx1", Status: storage.TxNotStable}
+	if err := storage.AddSwap("test", true, swap); err != nil {
+		t.Fatalf("add swap failed: %v", err)
+	}
+
+	err := storage.UpdateSwapStatus("test", true, "tx1", storage.TxProcessed, 1, "")
+	if e, ok := err.(*storage.IllegalTransitionError); !ok || e.IsResult || e.From != storage.TxNotStable || e.To != storage.TxProcessed {
+		t.Fatalf("update swap status illegally, want illegal transition error, got %v", err)
+	}
+	if res, _ := storage.FindSwap(true, "tx1"); res.Status != storage.TxNotStable {
+		t.Fatalf("swap status is updated illegally to %v", res.Status)
+	}
+
+	for _, status := range []storage.SwapStatus{storage.TxNotSwapped, storage.TxProcessed} {
+		if err = storage.UpdateSwapStatus("test", true, "tx1", status, 1, ""); err != nil {
+			t.Fatalf("update swap status to %v failed: %v", status, err)
+		}
+	}
+	if res, _ := storage.FindSwap(true, "tx1"); res.Status != storage.TxProcessed {
+		t.Fatalf("wrong swap status, want %v, got %v", storage.TxProcessed, res.Status)
+	}
+
+	result := &storage.SwapResult{Key: "tx1", TxID: "tx1", Value: "1", Status: storage.MatchTxStable}
+	if err = storage.AddSwapResult("test", true, result); err != nil {
+		t.Fatalf("add swap result failed: %v", err)
+	}
+	err = storage.UpdateSwapResultStatus("test", true, "tx1", storage.MatchTxEmpty, 1, "")
+	if e, ok := err.(*storage.IllegalTransitionError); !ok || !e.IsResult {
+		t.Fatalf("update swap result status illegally, want illegal transition error, got %v", err)
+	}
+}
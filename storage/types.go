@@ -0,0 +1,249 @@
+package storage
+
+// Swap registered swap
+type Swap struct {
+	Key       string     `bson:"_id"`
+	PairID    string     `bson:"pairid"`
+	TxID      string     `bson:"txid"`
+	TxType    uint32     `bson:"txtype"`
+	Bind      string     `bson:"bind"`
+	Status    SwapStatus `bson:"status"`
+	Timestamp int64      `bson:"timestamp"`
+	Memo      string     `bson:"memo"`
+
+	RetryCount    uint64 `bson:"retrycount"`    // failed attempts in current status
+	NextRetryTime int64  `bson:"nextretrytime"` // not retried before this time
+}
+
+// SwapResult swap result (verified swap)
+type SwapResult struct {
+	Key        string     `bson:"_id"`
+	PairID     string     `bson:"pairid"`
+	TxID       string     `bson:"txid"`
+	TxHeight   uint64     `bson:"txheight"`
+	TxTime     uint64     `bson:"txtime"`
+	From       string     `bson:"from"`
+	To         string     `bson:"to"`
+	Bind       string     `bson:"bind"`
+	Value      string     `bson:"value"`
+	ValueKey   string     `bson:"valuekey"` // sortable value (see GetValueKey)
+	SwapTx     string     `bson:"swaptx"`
+	SwapHeight uint64     `bson:"swapheight"`
+	SwapTime   uint64     `bson:"swaptime"`
+	SwapValue  string     `bson:"swapvalue"`
+	SwapFee    string     `bson:"swapfee,omitempty"` // fee and rescaling dust (in decimals of value)
+	SwapType   uint32     `bson:"swaptype"`
+	SwapNonce  uint64     `bson:"swapnonce"`
+	OldSwapTxs []string   `bson:"oldswaptxs,omitempty"` // replaced swap txs
+	Status     SwapStatus `bson:"status"`
+	Timestamp  int64      `bson:"timestamp"`
+	Memo       string     `bson:"memo"`
+
+	// refund to the sender of the deposit on the same chain
+	RefundTx     string `bson:"refundtx,omitempty"`
+	RefundHeight uint64 `bson:"refundheight,omitempty"`
+	RefundTime   uint64 `bson:"refundtime,omitempty"`
+	RefundValue  string `bson:"refundvalue,omitempty"`
+	RefundFee    string `bson:"refundfee,omitempty"`
+	RefundNonce  uint64 `bson:"refundnonce,omitempty"`
+}
+
+// SwapResultUpdateItems swap update items
+type SwapResultUpdateItems struct {
+	SwapTx     string
+	SwapHeight uint64
+	SwapTime   uint64
+	SwapValue  string
+	SwapFee    string
+	SwapType   uint32
+	SwapNonce  uint64
+	OldSwapTxs []string
+	Status     SwapStatus
+	Timestamp  int64
+	Memo       string
+
+	RefundTx     string
+	RefundHeight uint64
+	RefundTime   uint64
+	RefundValue  string
+	RefundFee    string
+	RefundNonce  uint64
+}
+
+// P2shAddress key is the bind address
+type P2shAddress struct {
+	Key         string `bson:"_id"`
+	P2shAddress string `bson:"p2shaddress"`
+}
+
+// RegisteredAddress key is address (in whitelist)
+type RegisteredAddress struct {
+	Key       string `bson:"_id"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
+// SwapSummary accumulated values of stable swaps
+type SwapSummary struct {
+	Key                string `bson:"_id"`
+	StableSwapinCount  int    `bson:"swapincount"`
+	TotalSwapinValue   string `bson:"totalswapinvalue"`
+	TotalSwapinFee     string `bson:"totalswapinfee"`
+	StableSwapoutCount int    `bson:"swapoutcount"`
+	TotalSwapoutValue  string `bson:"totalswapoutvalue"`
+	TotalSwapoutFee    string `bson:"totalswapoutfee"`
+}
+
+// SwapStatistics rpc return struct
+type SwapStatistics struct {
+	TotalSwapinCount    int
+	TotalSwapoutCount   int
+	PendingSwapinCount  int
+	PendingSwapoutCount int
+	StableSwapinCount   int
+	TotalSwapinValue    string
+	TotalSwapinFee      string
+	StableSwapoutCount  int
+	TotalSwapoutValue   string
+	TotalSwapoutFee     string
+}
+
+// LatestScanInfo latest scan info
+type LatestScanInfo struct {
+	Key         string          `bson:"_id"`
+	BlockHeight uint64          `bson:"blockheight"`
+	Blocks      []*ScannedBlock `bson:"blocks,omitempty"` // scanned blocks (see UpdateScannedBlocks)
+	Timestamp   int64           `bson:"timestamp"`
+}
+
+// ScannedBlock block tracked by the scanner to detect chain reorganisation,
+// the txs are not persisted (got by block hash if the block is orphaned).
+type ScannedBlock struct {
+	Height     uint64   `bson:"height"`
+	Hash       string   `bson:"hash"`
+	ParentHash string   `bson:"parenthash"`
+	Txs        []string `bson:"-" json:"-"`
+}
+
+// BlackAccount key is address
+type BlackAccount struct {
+	Key       string `bson:"_id"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
+// WebhookDelivery webhook delivery in outbox, removed after delivered,
+// marked as dead (dead-letter) after too many failed attempts.
+type WebhookDelivery struct {
+	Key       string `bson:"_id"`
+	Webhook   string `bson:"webhook"`
+	Event     string `bson:"event"`
+	Payload   string `bson:"payload"`
+	Attempts  int    `bson:"attempts"`
+	NextTime  int64  `bson:"nexttime"`
+	LastError string `bson:"lasterror"`
+	Dead      bool   `bson:"dead"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
+// SwapEvent a status transition of swap or swap result, the event log is append only.
+// key is ordered by the event time.
+type SwapEvent struct {
+	Key       string     `bson:"_id"`
+	TxID      string     `bson:"txid"`
+	IsSwapin  bool       `bson:"isswapin"`
+	IsResult  bool       `bson:"isresult"` // transition of swap result, otherwise of swap
+	Action    string     `bson:"action"`   // add or update
+	OldStatus SwapStatus `bson:"oldstatus"`
+	NewStatus SwapStatus `bson:"newstatus"`
+	Actor     string     `bson:"actor"` // worker job name, admin address or api
+	Memo      string     `bson:"memo"`  // error text
+	SwapTx    string     `bson:"swaptx,omitempty"`
+	RefundTx  string     `bson:"refundtx,omitempty"`
+	Timestamp int64      `bson:"timestamp"`
+}
+
+// SwapAttempt journal entry of a signed swap, replace or refund tx, key is the signed tx hash.
+// it is written before broadcasting and reconciled against the chain on restart.
+type SwapAttempt struct {
+	Key       string   `bson:"_id"`
+	TxID      string   `bson:"txid"`
+	PairID    string   `bson:"pairid"`
+	IsSwapin  bool     `bson:"isswapin"`
+	SwapType  uint32   `bson:"swaptype"`
+	Job       string   `bson:"job"`   // swap, replace or refund
+	RawTx     string   `bson:"rawtx"` // hex encoded signed tx, empty if not supported by bridge
+	Nonce     uint64   `bson:"nonce"`
+	OutPoints []string `bson:"outpoints"` // spent outpoints (btc)
+	SwapValue string   `bson:"swapvalue"`
+	SwapFee   string   `bson:"swapfee"`
+	Status    string   `bson:"status"`
+	Memo      string   `bson:"memo"`
+	SignTime  int64    `bson:"signtime"`
+	SendTime  int64    `bson:"sendtime"`
+	Timestamp int64    `bson:"timestamp"`
+}
+
+// NonceAccount persistent swap nonce counter of dcrm address on a chain,
+// key is "<blockchain>:<netid>:<dcrm address in lower case>".
+type NonceAccount struct {
+	Key       string `bson:"_id"`
+	NextNonce uint64 `bson:"nextnonce"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
+// NonceReservation nonce reserved for a swap, key is "<account>:<nonce in 20 digits>".
+type NonceReservation struct {
+	Key       string `bson:"_id"`
+	Account   string `bson:"account"`
+	Nonce     uint64 `bson:"nonce"`
+	TxID      string `bson:"txid"`
+	Status    string `bson:"status"`
+	SwapTx    string `bson:"swaptx"` // sent swap tx or cancel tx
+	Memo      string `bson:"memo"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
+// Lease leadership lease of server instances, key is the lease name.
+// token is increased every time the lease is taken over (fencing token).
+type Lease struct {
+	Key       string `bson:"_id"`
+	Holder    string `bson:"holder"`
+	Token     uint64 `bson:"token"`
+	Expires   int64  `bson:"expires"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
+// Outflow swapped value admitted by the outflow limits, key is "<swapin|swapout>:<txid>".
+// the outflows in the rolling window are summed to check the limits,
+// the released outflows (held swaps released or passed by admin) are not counted by the breaker count.
+type Outflow struct {
+	Key       string `bson:"_id"`
+	PairID    string `bson:"pairid"`
+	IsSwapin  bool   `bson:"isswapin"`
+	TxID      string `bson:"txid"`
+	Bind      string `bson:"bind"`
+	From      string `bson:"from"`
+	Value     string `bson:"value"`
+	Released  bool   `bson:"released"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
+// OutflowBreaker breaker state of token pair direction, key is "<swapin|swapout>:<pairid>".
+// a tripped breaker pauses swap of the direction until it is reset by admin ('maintain open').
+type OutflowBreaker struct {
+	Key       string `bson:"_id"`
+	PairID    string `bson:"pairid"`
+	IsSwapin  bool   `bson:"isswapin"`
+	Tripped   bool   `bson:"tripped"`
+	Reason    string `bson:"reason"`
+	Timestamp int64  `bson:"timestamp"`
+}
+
+// FeeOverride fee override of account (eg. partner discount), key is the bind address.
+// discounts are decimals in range [0,1], empty means no discount.
+type FeeOverride struct {
+	Key             string `bson:"_id"`
+	SwapinDiscount  string `bson:"swapindiscount"`
+	SwapoutDiscount string `bson:"swapoutdiscount"`
+	Memo            string `bson:"memo"`
+	Timestamp       int64  `bson:"timestamp"`
+}
@@ -0,0 +1,85 @@
+package storage
+
+// the update semantics of swap and swap result writes, used by the boltdb backend
+// and to notify the updated items without reading them back (mongodb does the same by '$set').
+
+// ApplyStatusUpdate apply status update to swap
+func (swap *Swap) ApplyStatusUpdate(status SwapStatus, timestamp int64, memo string) {
+	oldStatus := swap.Status
+	swap.Status = status
+	swap.Timestamp = timestamp
+	if memo != "" {
+		swap.Memo = memo
+	} else if status == TxNotSwapped || status == TxNotStable {
+		swap.Memo = ""
+	}
+	if status != oldStatus && (status == TxNotSwapped || status == TxNotStable) {
+		swap.RetryCount = 0
+		swap.NextRetryTime = 0
+	}
+}
+
+// ApplyStatusUpdate apply status update to swap result
+func (res *SwapResult) ApplyStatusUpdate(status SwapStatus, timestamp int64, memo string) {
+	res.Status = status
+	res.Timestamp = timestamp
+	if memo != "" {
+		res.Memo = memo
+	} else if status == MatchTxEmpty {
+		// the cleared swap txs are kept in swap events and the journal (see getAllSwapTxs)
+		res.Memo = ""
+		res.SwapTx = ""
+		res.OldSwapTxs = nil
+	}
+	if status == MatchTxReorged {
+		res.SwapHeight = 0
+		res.SwapTime = 0
+	}
+}
+
+// ApplyUpdateItems apply update items to swap result, empty items are not updated
+func (res *SwapResult) ApplyUpdateItems(items *SwapResultUpdateItems) {
+	res.Status = items.Status
+	res.Timestamp = items.Timestamp
+	if items.SwapTx != "" {
+		res.SwapTx = items.SwapTx
+	}
+	if items.SwapHeight != 0 {
+		res.SwapHeight = items.SwapHeight
+	}
+	if items.SwapTime != 0 {
+		res.SwapTime = items.SwapTime
+	}
+	if items.SwapValue != "" {
+		res.SwapValue = items.SwapValue
+	}
+	if items.SwapFee != "" {
+		res.SwapFee = items.SwapFee
+	}
+	if items.SwapType != 0 {
+		res.SwapType = items.SwapType
+	}
+	if items.SwapNonce != 0 {
+		res.SwapNonce = items.SwapNonce
+	}
+	if len(items.OldSwapTxs) != 0 {
+		res.OldSwapTxs = items.OldSwapTxs
+	}
+	if items.RefundTx != "" {
+		res.RefundTx = items.RefundTx
+		res.RefundValue = items.RefundValue
+		res.RefundFee = items.RefundFee
+		res.RefundNonce = items.RefundNonce
+	}
+	if items.RefundHeight != 0 {
+		res.RefundHeight = items.RefundHeight
+	}
+	if items.RefundTime != 0 {
+		res.RefundTime = items.RefundTime
+	}
+	if items.Memo != "" {
+		res.Memo = items.Memo
+	} else if items.Status == MatchTxNotStable {
+		res.Memo = ""
+	}
+}
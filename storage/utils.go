@@ -1,4 +1,4 @@
-package mongodb
+package storage
 
 import (
 	"github.com/anyswap/CrossChain-Bridge/log"
@@ -28,7 +28,7 @@ func GetStatusByTokenVerifyError(err error) SwapStatus {
 	case tokens.ErrRPCQueryError:
 		return RPCQueryError
 	default:
-		log.Warn("[mongodb] maybe not considered tx verify error", "err", err)
+		log.Warn("[storage] maybe not considered tx verify error", "err", err)
 		return TxNotStable
 	}
 }
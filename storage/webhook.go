@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+)
+
+// AddWebhookDelivery add webhook delivery to outbox
+func AddWebhookDelivery(delivery *WebhookDelivery) error {
+	err := checkFencingToken()
+	if err != nil {
+		return err
+	}
+	return swapStore.AddWebhookDelivery(delivery)
+}
+
+// UpdateWebhookDelivery update webhook delivery
+func UpdateWebhookDelivery(delivery *WebhookDelivery) error {
+	err := checkFencingToken()
+	if err != nil {
+		return err
+	}
+	return swapStore.UpdateWebhookDelivery(delivery)
+}
+
+// RemoveWebhookDelivery remove webhook delivery
+func RemoveWebhookDelivery(key string) error {
+	err := checkFencingToken()
+	if err == nil {
+		err = swapStore.RemoveWebhookDelivery(key)
+	}
+	if err == nil {
+		log.Info("remove webhook delivery success", "key", key)
+	} else {
+		log.Info("remove webhook delivery failed", "key", key, "err", err)
+	}
+	return err
+}
+
+// FindPendingWebhookDeliveries find webhook deliveries to deliver now
+func FindPendingWebhookDeliveries(limit int) ([]*WebhookDelivery, error) {
+	return swapStore.FindPendingWebhookDeliveries(time.Now().Unix(), limit)
+}
+
+// FindDeadWebhookDeliveries find dead webhook deliveries (dead-letter list)
+func FindDeadWebhookDeliveries(offset, limit int) ([]*WebhookDelivery, error) {
+	return swapStore.FindDeadWebhookDeliveries(offset, limit)
+}
+
+// RedeliverWebhook move dead webhook delivery back to outbox
+func RedeliverWebhook(key string) error {
+	delivery, err := swapStore.FindWebhookDelivery(key)
+	if err != nil {
+		return err
+	}
+	if !delivery.Dead {
+		return errors.New("webhook delivery is not dead")
+	}
+	delivery.Dead = false
+	delivery.Attempts = 0
+	delivery.NextTime = time.Now().Unix()
+	err = swapStore.UpdateWebhookDelivery(delivery)
+	if err == nil {
+		log.Info("redeliver webhook success", "key", key)
+	} else {
+		log.Info("redeliver webhook failed", "key", key, "err", err)
+	}
+	return err
+}
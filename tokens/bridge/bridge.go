@@ -13,22 +13,20 @@ import (
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 	"github.com/anyswap/CrossChain-Bridge/tokens/btc"
 	"github.com/anyswap/CrossChain-Bridge/tokens/eth"
-	"github.com/anyswap/CrossChain-Bridge/tokens/fsn"
 	"github.com/btcsuite/btcutil"
 )
 
-// NewCrossChainBridge new bridge according to chain name
-func NewCrossChainBridge(id string, isSrc bool) tokens.CrossChainBridge {
-	blockChainIden := strings.ToUpper(id)
+// NewCrossChainBridge new bridge according to token config,
+// all utxo chains (BTC, LTC, DOGE, BCH) share the btc bridge,
+// all evm chains (known or configed with 'ChainID') share the eth bridge.
+func NewCrossChainBridge(tokenCfg *tokens.TokenConfig, isSrc bool) tokens.CrossChainBridge {
 	switch {
-	case strings.HasPrefix(blockChainIden, "BITCOIN"):
+	case btc.IsUtxoChain(tokenCfg.BlockChain):
 		return btc.NewCrossChainBridge(isSrc)
-	case strings.HasPrefix(blockChainIden, "ETHEREUM"):
+	case eth.IsEvmChain(tokenCfg):
 		return eth.NewCrossChainBridge(isSrc)
-	case strings.HasPrefix(blockChainIden, "FUSION"):
-		return fsn.NewCrossChainBridge(isSrc)
 	default:
-		log.Fatalf("Unsupported block chain %v", id)
+		log.Fatalf("Unsupported block chain %v", tokenCfg.BlockChain)
 		return nil
 	}
 }
@@ -36,39 +34,64 @@ func NewCrossChainBridge(id string, isSrc bool) tokens.CrossChainBridge {
 // InitCrossChainBridge init bridge
 func InitCrossChainBridge(isServer bool) {
 	cfg := params.GetConfig()
-	srcToken := cfg.SrcToken
-	dstToken := cfg.DestToken
 	srcGateway := cfg.SrcGateway
 	dstGateway := cfg.DestGateway
 
+	for _, pairCfg := range params.GetTokenPairsConfig() {
+		initTokenPair(pairCfg, srcGateway, dstGateway)
+	}
+
+	initBtcExtra(cfg.BtcExtra, *cfg.Dcrm.Pubkey)
+
+	initDcrm(cfg.Dcrm, isServer)
+}
+
+func initTokenPair(pairCfg *tokens.TokenPairConfig, srcGateway, dstGateway *tokens.GatewayConfig) {
+	pairID := pairCfg.PairID
+	srcToken := pairCfg.SrcToken
+	dstToken := pairCfg.DestToken
+
 	srcID := srcToken.BlockChain
 	dstID := dstToken.BlockChain
 	srcNet := srcToken.NetID
 	dstNet := dstToken.NetID
 
-	tokens.SrcBridge = NewCrossChainBridge(srcID, true)
-	tokens.DstBridge = NewCrossChainBridge(dstID, false)
-	log.Info("New bridge finished", "source", srcID, "sourceNet", srcNet, "dest", dstID, "destNet", dstNet)
+	srcBridge := NewCrossChainBridge(srcToken, true)
+	dstBridge := NewCrossChainBridge(dstToken, false)
+	srcBridge.SetPairID(pairID)
+	dstBridge.SetPairID(pairID)
+	log.Info("New bridge finished", "pairID", pairID, "source", srcID, "sourceNet", srcNet, "dest", dstID, "destNet", dstNet)
 
-	tokens.SrcBridge.SetTokenAndGateway(srcToken, srcGateway, true)
-	log.Info("Init bridge source", "token", srcToken.Symbol, "gateway", srcGateway)
+	srcBridge.SetTokenAndGateway(srcToken, srcGateway, true)
+	log.Info("Init bridge source", "pairID", pairID, "token", srcToken.Symbol, "gateway", srcGateway)
 
-	tokens.DstBridge.SetTokenAndGateway(dstToken, dstGateway, true)
-	log.Info("Init bridge destation", "token", dstToken.Symbol, "gateway", dstGateway)
+	dstBridge.SetTokenAndGateway(dstToken, dstGateway, true)
+	log.Info("Init bridge destation", "pairID", pairID, "token", dstToken.Symbol, "gateway", dstGateway)
 
-	initBtcExtra(cfg.BtcExtra, *cfg.Dcrm.Pubkey)
-
-	initDcrm(cfg.Dcrm, isServer)
+	err := tokens.AddTokenPair(&tokens.TokenPair{
+		PairID:    pairID,
+		SrcBridge: srcBridge,
+		DstBridge: dstBridge,
+	})
+	if err != nil {
+		log.Fatalf("add token pair error %v", err)
+	}
 }
 
 func initBtcExtra(btcExtra *tokens.BtcExtraConfig, dcrmPubkey string) {
-	if btc.BridgeInstance == nil || btcExtra == nil {
+	if btc.BridgeInstance == nil {
+		return
+	}
+
+	chain := btc.BridgeInstance.Chain
+	tokens.BtcMinRelayFee, tokens.BtcRelayFeePerKb = chain.DefaultFees()
+	if btcExtra == nil {
 		return
 	}
 
 	if btcExtra.MinRelayFee > 0 {
 		tokens.BtcMinRelayFee = btcExtra.MinRelayFee
-		maxMinRelayFee, _ := btcutil.NewAmount(0.001)
+		maxMinRelayFee := btcutil.Amount(chain.MaxRelayFee())
 		minRelayFee := btcutil.Amount(tokens.BtcMinRelayFee)
 		if minRelayFee > maxMinRelayFee {
 			log.Fatal("BtcMinRelayFee is too large", "value", minRelayFee, "max", maxMinRelayFee)
@@ -77,7 +100,7 @@ func initBtcExtra(btcExtra *tokens.BtcExtraConfig, dcrmPubkey string) {
 
 	if btcExtra.RelayFeePerKb > 0 {
 		tokens.BtcRelayFeePerKb = btcExtra.RelayFeePerKb
-		maxRelayFeePerKb, _ := btcutil.NewAmount(0.001)
+		maxRelayFeePerKb := btcutil.Amount(chain.MaxRelayFee())
 		relayFeePerKb := btcutil.Amount(tokens.BtcRelayFeePerKb)
 		if relayFeePerKb > maxRelayFeePerKb {
 			log.Fatal("BtcRelayFeePerKb is too large", "value", relayFeePerKb, "max", maxRelayFeePerKb)
@@ -1,8 +1,6 @@
 package btc
 
 import (
-	"strings"
-
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcutil"
 )
@@ -10,7 +8,7 @@ import (
 // IsValidAddress check address
 func (b *Bridge) IsValidAddress(addr string) bool {
 	chainConfig := b.GetChainConfig()
-	address, err := btcutil.DecodeAddress(addr, chainConfig)
+	address, err := b.DecodeAddress(addr)
 	if err != nil {
 		return false
 	}
@@ -20,7 +18,7 @@ func (b *Bridge) IsValidAddress(addr string) bool {
 // IsP2pkhAddress check p2pkh addrss
 func (b *Bridge) IsP2pkhAddress(addr string) bool {
 	chainConfig := b.GetChainConfig()
-	address, err := btcutil.DecodeAddress(addr, chainConfig)
+	address, err := b.DecodeAddress(addr)
 	if err != nil {
 		return false
 	}
@@ -34,7 +32,7 @@ func (b *Bridge) IsP2pkhAddress(addr string) bool {
 // IsP2shAddress check p2sh addrss
 func (b *Bridge) IsP2shAddress(addr string) bool {
 	chainConfig := b.GetChainConfig()
-	address, err := btcutil.DecodeAddress(addr, chainConfig)
+	address, err := b.DecodeAddress(addr)
 	if err != nil {
 		return false
 	}
@@ -45,15 +43,94 @@ func (b *Bridge) IsP2shAddress(addr string) bool {
 	return ok
 }
 
+// IsP2wpkhAddress check p2wpkh addrss
+func (b *Bridge) IsP2wpkhAddress(addr string) bool {
+	chainConfig := b.GetChainConfig()
+	address, err := b.DecodeAddress(addr)
+	if err != nil {
+		return false
+	}
+	if !address.IsForNet(chainConfig) {
+		return false
+	}
+	_, ok := address.(*btcutil.AddressWitnessPubKeyHash)
+	return ok
+}
+
+// IsP2wshAddress check p2wsh addrss
+func (b *Bridge) IsP2wshAddress(addr string) bool {
+	chainConfig := b.GetChainConfig()
+	address, err := b.DecodeAddress(addr)
+	if err != nil {
+		return false
+	}
+	if !address.IsForNet(chainConfig) {
+		return false
+	}
+	_, ok := address.(*btcutil.AddressWitnessScriptHash)
+	return ok
+}
+
+// getScriptPubkeyType get script pubkey type of address (in electrs format)
+func (b *Bridge) getScriptPubkeyType(addr string) string {
+	address, err := b.DecodeAddress(addr)
+	if err != nil {
+		return ""
+	}
+	switch address.(type) {
+	case *btcutil.AddressPubKeyHash:
+		return p2pkhType
+	case *btcutil.AddressScriptHash:
+		return p2shType
+	case *btcutil.AddressWitnessPubKeyHash:
+		return p2wpkhType
+	case *btcutil.AddressWitnessScriptHash:
+		return p2wshType
+	default:
+		return ""
+	}
+}
+
+// SupportSegWit is native segwit supported
+func (b *Bridge) SupportSegWit() bool {
+	return b.Chain.supportSegWit
+}
+
 // GetChainConfig get chain config (net params)
 func (b *Bridge) GetChainConfig() *chaincfg.Params {
-	token := b.TokenConfig
-	networkID := strings.ToLower(token.NetID)
-	switch networkID {
-	case netMainnet:
-		return &chaincfg.MainNetParams
-	case netTestnet3:
-		return &chaincfg.TestNet3Params
-	}
-	return &chaincfg.TestNet3Params
+	return b.Chain.getNet(b.TokenConfig.NetID).params
+}
+
+func (b *Bridge) getCashAddrPrefix() string {
+	return b.Chain.getNet(b.TokenConfig.NetID).cashAddrPrefix
+}
+
+// DecodeAddress decode address (accept CashAddr format if chain supports it)
+func (b *Bridge) DecodeAddress(addr string) (btcutil.Address, error) {
+	chainConfig := b.GetChainConfig()
+	if prefix := b.getCashAddrPrefix(); prefix != "" {
+		if address, err := DecodeCashAddr(addr, prefix, chainConfig); err == nil {
+			return address, nil
+		}
+	}
+	return btcutil.DecodeAddress(addr, chainConfig)
+}
+
+// EncodeAddress encode address (in CashAddr format if chain supports it)
+func (b *Bridge) EncodeAddress(address btcutil.Address) string {
+	if prefix := b.getCashAddrPrefix(); prefix != "" {
+		if cashAddr, err := EncodeCashAddr(prefix, address); err == nil {
+			return cashAddr
+		}
+	}
+	return address.EncodeAddress()
+}
+
+// NormalizeAddress convert address to the canonical format of the chain
+func (b *Bridge) NormalizeAddress(addr string) string {
+	address, err := b.DecodeAddress(addr)
+	if err != nil {
+		return addr
+	}
+	return b.EncodeAddress(address)
 }
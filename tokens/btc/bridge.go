@@ -10,16 +10,19 @@ import (
 
 const (
 	netMainnet  = "mainnet"
+	netTestnet  = "testnet"
 	netTestnet3 = "testnet3"
+	netTestnet4 = "testnet4"
 	netCustom   = "custom"
 )
 
 // BridgeInstance btc bridge instance
 var BridgeInstance *Bridge
 
-// Bridge btc bridge
+// Bridge btc bridge, which also bridges other utxo chains (eg. LTC, DOGE, BCH)
 type Bridge struct {
 	*tokens.CrossChainBridgeBase
+	Chain *UtxoChain
 }
 
 // NewCrossChainBridge new btc bridge
@@ -27,12 +30,19 @@ func NewCrossChainBridge(isSrc bool) *Bridge {
 	if !isSrc {
 		log.Fatalf("btc::NewCrossChainBridge error %v", tokens.ErrBridgeDestinationNotSupported)
 	}
-	BridgeInstance = &Bridge{tokens.NewCrossChainBridgeBase(isSrc)}
+	if BridgeInstance != nil {
+		log.Fatalf("btc::NewCrossChainBridge error: only one token pair is supported for utxo chain")
+	}
+	BridgeInstance = &Bridge{CrossChainBridgeBase: tokens.NewCrossChainBridgeBase(isSrc)}
 	return BridgeInstance
 }
 
 // SetTokenAndGateway set token and gateway config
 func (b *Bridge) SetTokenAndGateway(tokenCfg *tokens.TokenConfig, gatewayCfg *tokens.GatewayConfig, check bool) {
+	b.Chain = GetUtxoChain(tokenCfg.BlockChain)
+	if b.Chain == nil {
+		log.Fatal("unsupported utxo chain", "blockChain", tokenCfg.BlockChain)
+	}
 	b.CrossChainBridgeBase.SetTokenAndGateway(tokenCfg, gatewayCfg, check)
 	b.VerifyConfig()
 	b.InitLatestBlockNumber()
@@ -41,24 +51,26 @@ func (b *Bridge) SetTokenAndGateway(tokenCfg *tokens.TokenConfig, gatewayCfg *to
 // VerifyConfig verify config
 func (b *Bridge) VerifyConfig() {
 	tokenCfg := b.TokenConfig
-	networkID := strings.ToLower(tokenCfg.NetID)
-	switch networkID {
-	case netMainnet, netTestnet3:
-	case netCustom:
+	switch {
+	case b.Chain.IsSupportedNet(tokenCfg.NetID):
+	case strings.EqualFold(tokenCfg.NetID, netCustom):
 		return
 	default:
-		log.Fatal("unsupported bitcoin network", "netID", tokenCfg.NetID)
+		log.Fatal("unsupported network", "blockChain", tokenCfg.BlockChain, "netID", tokenCfg.NetID)
 	}
 
-	if !b.IsP2pkhAddress(tokenCfg.DcrmAddress) {
-		log.Fatal("invalid dcrm address (not p2pkh)", "address", tokenCfg.DcrmAddress)
+	if !b.IsP2pkhAddress(tokenCfg.DcrmAddress) && !(b.SupportSegWit() && b.IsP2wpkhAddress(tokenCfg.DcrmAddress)) {
+		log.Fatal("invalid dcrm address (not p2pkh or p2wpkh)", "address", tokenCfg.DcrmAddress)
 	}
 	if !b.IsValidAddress(tokenCfg.DepositAddress) {
 		log.Fatal("invalid deposit address", "address", tokenCfg.DepositAddress)
 	}
+	// use the same address format as the gateway (eg. CashAddr of BCH)
+	tokenCfg.DcrmAddress = b.NormalizeAddress(tokenCfg.DcrmAddress)
+	tokenCfg.DepositAddress = b.NormalizeAddress(tokenCfg.DepositAddress)
 
-	if strings.EqualFold(tokenCfg.Symbol, "BTC") && *tokenCfg.Decimals != 8 {
-		log.Fatal("invalid decimals for BTC", "configed", *tokenCfg.Decimals, "want", 8)
+	if *tokenCfg.Decimals != 8 {
+		log.Fatal("invalid decimals for "+tokenCfg.Symbol, "configed", *tokenCfg.Decimals, "want", 8)
 	}
 }
 
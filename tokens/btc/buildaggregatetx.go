@@ -35,7 +35,7 @@ func (b *Bridge) BuildAggregateTransaction(addrs []string, utxos []*electrs.Elec
 
 	relayFeePerKb := btcutil.Amount(tokens.BtcRelayFeePerKb + 2000)
 
-	return NewUnsignedTransaction(txOuts, relayFeePerKb, inputSource, changeSource)
+	return b.NewUnsignedTransaction(txOuts, relayFeePerKb, inputSource, changeSource)
 }
 
 func (b *Bridge) rebuildAggregateTransaction(prevOutPoints []*tokens.BtcOutPoint) (rawTx *txauthor.AuthoredTx, err error) {
@@ -62,12 +62,12 @@ func (b *Bridge) getUtxosFromElectUtxos(target btcutil.Amount, addrs []string, u
 		}
 
 		address := addrs[i]
-		if b.IsP2shAddress(address) {
+		if isP2wsh := b.IsP2wshAddress(address); isP2wsh || b.IsP2shAddress(address) {
 			bindAddr := tools.GetP2shBindAddress(address)
 			if bindAddr == "" {
 				continue
 			}
-			p2shAddr, _, _ = b.GetP2shAddress(bindAddr)
+			p2shAddr, _, _ = b.GetP2shAddressByType(bindAddr, isP2wsh)
 			if p2shAddr != address {
 				log.Warn("wrong registered p2sh address", "have", address, "bind", bindAddr, "want", p2shAddr)
 				continue
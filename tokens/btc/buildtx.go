@@ -22,8 +22,13 @@ import (
 const (
 	p2pkhType    = "p2pkh"
 	p2shType     = "p2sh"
+	p2wpkhType   = "v0_p2wpkh"
+	p2wshType    = "v0_p2wsh"
 	opReturnType = "op_return"
 
+	// max virtual size of the witness script of p2wsh memo input (memo up to 64 bytes)
+	p2wshMemoWitnessScriptVSize = 24
+
 	retryCount    = 3
 	retryInterval = 3 * time.Second
 )
@@ -44,9 +49,18 @@ func (b *Bridge) BuildRawTransaction(args *tokens.BuildTxArgs) (rawTx interface{
 	case tokens.SwapinType:
 		return nil, tokens.ErrSwapTypeNotSupported
 	case tokens.SwapoutType:
-		from = token.DcrmAddress                        // from
-		amount = tokens.CalcSwappedValue(amount, false) // amount
+		from = token.DcrmAddress                                             // from
+		amount = tokens.CalcSwapValue(b.PairID, amount, false, args.FeeArgs) // amount
 		memo = tokens.UnlockMemoPrefix + args.SwapID
+	case tokens.RefundType:
+		if !b.IsSrc {
+			return nil, tokens.ErrSwapTypeNotSupported
+		}
+		from = token.DcrmAddress                                // from
+		amount = tokens.CalcRefundValue(b.PairID, amount, true) // amount
+		memo = tokens.RefundMemoPrefix + args.SwapID
+	case tokens.CancelType:
+		return nil, tokens.ErrSwapTypeNotSupported
 	}
 
 	if from == "" {
@@ -80,7 +94,7 @@ func (b *Bridge) BuildRawTransaction(args *tokens.BuildTxArgs) (rawTx interface{
 
 	inputSource := func(target btcutil.Amount) (total btcutil.Amount, inputs []*wire.TxIn, inputValues []btcutil.Amount, scripts [][]byte, err error) {
 		if len(extra.PreviousOutPoints) != 0 {
-			return b.getUtxos(from, target, extra.PreviousOutPoints)
+			return b.getUtxos(from, target, extra.PreviousOutPoints, memo)
 		}
 		return b.selectUtxos(from, target)
 	}
@@ -89,7 +103,7 @@ func (b *Bridge) BuildRawTransaction(args *tokens.BuildTxArgs) (rawTx interface{
 		return b.getPayToAddrScript(changeAddress)
 	}
 
-	authoredTx, err := NewUnsignedTransaction(txOuts, relayFeePerKb, inputSource, changeSource)
+	authoredTx, err := b.NewUnsignedTransaction(txOuts, relayFeePerKb, inputSource, changeSource)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +121,12 @@ func (b *Bridge) BuildRawTransaction(args *tokens.BuildTxArgs) (rawTx interface{
 
 	if args.SwapType != tokens.NoSwapType {
 		args.Identifier = params.GetIdentifier()
+		// make swap tx replaceable if it's stuck in txpool
+		if b.Chain.supportRBF {
+			for _, txin := range authoredTx.Tx.TxIn {
+				txin.Sequence = rbfSequence
+			}
+		}
 	}
 
 	return authoredTx, nil
@@ -134,10 +154,9 @@ func (b *Bridge) getTxOutputs(to string, amount *big.Int, memo string) (txOuts [
 }
 
 func (b *Bridge) getPayToAddrScript(address string) ([]byte, error) {
-	chainConfig := b.GetChainConfig()
-	toAddr, err := btcutil.DecodeAddress(address, chainConfig)
+	toAddr, err := b.DecodeAddress(address)
 	if err != nil {
-		return nil, fmt.Errorf("decode btc address '%v' failed. %v", address, err)
+		return nil, fmt.Errorf("decode address '%v' failed. %v", address, err)
 	}
 	return txscript.PayToAddrScript(toAddr)
 }
@@ -175,80 +194,111 @@ func (b *Bridge) getOutspendWithRetry(point *tokens.BtcOutPoint) (outspend *elec
 	return outspend, err
 }
 
-func (b *Bridge) selectUtxos(from string, target btcutil.Amount) (total btcutil.Amount, inputs []*wire.TxIn, inputValues []btcutil.Amount, scripts [][]byte, err error) {
-	p2pkhScript, err := b.getPayToAddrScript(from)
+// getSameKeyAddresses get the p2pkh and p2wpkh addresses of the same public key hash,
+// so utxos of the legacy dcrm address are still spendable after switched to segwit.
+func (b *Bridge) getSameKeyAddresses(addr string) []string {
+	address, err := b.DecodeAddress(addr)
+	if err != nil || !b.SupportSegWit() {
+		return []string{addr}
+	}
+	var other btcutil.Address
+	switch address.(type) {
+	case *btcutil.AddressPubKeyHash:
+		other, err = btcutil.NewAddressWitnessPubKeyHash(address.ScriptAddress(), b.GetChainConfig())
+	case *btcutil.AddressWitnessPubKeyHash:
+		other, err = btcutil.NewAddressPubKeyHash(address.ScriptAddress(), b.GetChainConfig())
+	default:
+		return []string{addr}
+	}
 	if err != nil {
-		return 0, nil, nil, nil, err
+		return []string{addr}
 	}
+	return []string{addr, b.EncodeAddress(other)}
+}
 
-	utxos, err := b.findUxtosWithRetry(from)
-	if err != nil {
-		return 0, nil, nil, nil, err
+func isInStringSlice(slice []string, str string) bool {
+	for _, s := range slice {
+		if s == str {
+			return true
+		}
 	}
+	return false
+}
+
+func isPubkeyHashType(scriptType string) bool {
+	return scriptType == p2pkhType || scriptType == p2wpkhType
+}
 
+func (b *Bridge) selectUtxos(from string, target btcutil.Amount) (total btcutil.Amount, inputs []*wire.TxIn, inputValues []btcutil.Amount, scripts [][]byte, err error) {
 	var (
-		tx      *electrs.ElectTx
-		success bool
+		tx       *electrs.ElectTx
+		utxos    []*electrs.ElectUtxo
+		pkScript []byte
 	)
 
-	for _, utxo := range utxos {
-		value := btcutil.Amount(*utxo.Value)
-		if value <= 0 {
-			continue
-		}
-		if value > btcutil.MaxSatoshi {
-			continue
-		}
-		tx, err = b.getTransactionByHashWithRetry(*utxo.Txid)
+	for _, address := range b.getSameKeyAddresses(from) {
+		pkScript, err = b.getPayToAddrScript(address)
 		if err != nil {
-			continue
-		}
-		if *utxo.Vout >= uint32(len(tx.Vout)) {
-			continue
-		}
-		output := tx.Vout[*utxo.Vout]
-		if *output.ScriptpubkeyType != p2pkhType {
-			continue
-		}
-		if output.ScriptpubkeyAddress == nil || *output.ScriptpubkeyAddress != from {
-			continue
+			return 0, nil, nil, nil, err
 		}
-		txHash, err2 := chainhash.NewHashFromStr(*utxo.Txid)
-		if err2 != nil {
-			continue
+
+		utxos, err = b.findUxtosWithRetry(address)
+		if err != nil {
+			return 0, nil, nil, nil, err
 		}
-		preOut := wire.NewOutPoint(txHash, *utxo.Vout)
-		txIn := wire.NewTxIn(preOut, p2pkhScript, nil)
 
-		total += value
-		inputs = append(inputs, txIn)
-		inputValues = append(inputValues, value)
-		scripts = append(scripts, p2pkhScript)
+		for _, utxo := range utxos {
+			value := btcutil.Amount(*utxo.Value)
+			if value <= 0 {
+				continue
+			}
+			if value > btcutil.MaxSatoshi {
+				continue
+			}
+			tx, err = b.getTransactionByHashWithRetry(*utxo.Txid)
+			if err != nil {
+				continue
+			}
+			if *utxo.Vout >= uint32(len(tx.Vout)) {
+				continue
+			}
+			output := tx.Vout[*utxo.Vout]
+			if !isPubkeyHashType(*output.ScriptpubkeyType) {
+				continue
+			}
+			if output.ScriptpubkeyAddress == nil || *output.ScriptpubkeyAddress != address {
+				continue
+			}
+			txHash, err2 := chainhash.NewHashFromStr(*utxo.Txid)
+			if err2 != nil {
+				continue
+			}
+			preOut := wire.NewOutPoint(txHash, *utxo.Vout)
+			txIn := wire.NewTxIn(preOut, pkScript, nil)
 
-		if total >= target {
-			success = true
-			break
-		}
-	}
+			total += value
+			inputs = append(inputs, txIn)
+			inputValues = append(inputValues, value)
+			scripts = append(scripts, pkScript)
 
-	if !success {
-		err = fmt.Errorf("not enough balance, total %v < target %v", total, target)
-		return 0, nil, nil, nil, err
+			if total >= target {
+				return total, inputs, inputValues, scripts, nil
+			}
+		}
 	}
 
-	return total, inputs, inputValues, scripts, nil
+	err = fmt.Errorf("not enough balance, total %v < target %v", total, target)
+	return 0, nil, nil, nil, err
 }
 
-func (b *Bridge) getUtxos(from string, target btcutil.Amount, prevOutPoints []*tokens.BtcOutPoint) (total btcutil.Amount, inputs []*wire.TxIn, inputValues []btcutil.Amount, scripts [][]byte, err error) {
-	p2pkhScript, err := b.getPayToAddrScript(from)
-	if err != nil {
-		return 0, nil, nil, nil, err
-	}
+func (b *Bridge) getUtxos(from string, target btcutil.Amount, prevOutPoints []*tokens.BtcOutPoint, memo string) (total btcutil.Amount, inputs []*wire.TxIn, inputValues []btcutil.Amount, scripts [][]byte, err error) {
+	fromAddresses := b.getSameKeyAddresses(from)
 	var (
 		tx       *electrs.ElectTx
 		txHash   *chainhash.Hash
 		outspend *electrs.ElectOutspend
 		value    btcutil.Amount
+		pkScript []byte
 	)
 
 	for _, point := range prevOutPoints {
@@ -256,7 +306,7 @@ func (b *Bridge) getUtxos(from string, target btcutil.Amount, prevOutPoints []*t
 		if err != nil {
 			return 0, nil, nil, nil, err
 		}
-		if *outspend.Spent {
+		if *outspend.Spent && !b.isSpentByReplaceableTx(outspend, memo) {
 			if outspend.Status != nil && outspend.Status.BlockHeight != nil {
 				spentHeight := *outspend.Status.BlockHeight
 				err = fmt.Errorf("out point (%v, %v) is spent at %v", point.Hash, point.Index, spentHeight)
@@ -274,12 +324,12 @@ func (b *Bridge) getUtxos(from string, target btcutil.Amount, prevOutPoints []*t
 			return 0, nil, nil, nil, err
 		}
 		output := tx.Vout[point.Index]
-		if *output.ScriptpubkeyType != p2pkhType {
-			err = fmt.Errorf("out point (%v, %v) script pubkey type %v is not p2pkh", point.Hash, point.Index, *output.ScriptpubkeyType)
+		if !isPubkeyHashType(*output.ScriptpubkeyType) {
+			err = fmt.Errorf("out point (%v, %v) script pubkey type %v is not p2pkh or p2wpkh", point.Hash, point.Index, *output.ScriptpubkeyType)
 			return 0, nil, nil, nil, err
 		}
-		if output.ScriptpubkeyAddress == nil || *output.ScriptpubkeyAddress != from {
-			err = fmt.Errorf("out point (%v, %v) script pubkey address %v is not %v", point.Hash, point.Index, *output.ScriptpubkeyAddress, from)
+		if output.ScriptpubkeyAddress == nil || !isInStringSlice(fromAddresses, *output.ScriptpubkeyAddress) {
+			err = fmt.Errorf("out point (%v, %v) script pubkey address %v is not %v", point.Hash, point.Index, output.ScriptpubkeyAddress, from)
 			return 0, nil, nil, nil, err
 		}
 		value = btcutil.Amount(*output.Value)
@@ -287,15 +337,19 @@ func (b *Bridge) getUtxos(from string, target btcutil.Amount, prevOutPoints []*t
 			err = fmt.Errorf("out point (%v, %v) with zero value", point.Hash, point.Index)
 			return 0, nil, nil, nil, err
 		}
+		pkScript, err = b.getPayToAddrScript(*output.ScriptpubkeyAddress)
+		if err != nil {
+			return 0, nil, nil, nil, err
+		}
 
 		txHash, _ = chainhash.NewHashFromStr(point.Hash)
 		prevOutPoint := wire.NewOutPoint(txHash, point.Index)
-		txIn := wire.NewTxIn(prevOutPoint, p2pkhScript, nil)
+		txIn := wire.NewTxIn(prevOutPoint, pkScript, nil)
 
 		total += value
 		inputs = append(inputs, txIn)
 		inputValues = append(inputValues, value)
-		scripts = append(scripts, p2pkhScript)
+		scripts = append(scripts, pkScript)
 	}
 	if total < target {
 		err = fmt.Errorf("not enough balance, total %v < target %v", total, target)
@@ -314,7 +368,7 @@ func (insufficientFundsError) Error() string {
 // NewUnsignedTransaction ref btcwallet
 // ref. https://github.com/btcsuite/btcwallet/blob/b07494fc2d662fdda2b8a9db2a3eacde3e1ef347/wallet/txauthor/author.go
 // we only modify it to support P2PKH change script (the origin only support P2WPKH change script)
-func NewUnsignedTransaction(outputs []*wire.TxOut, relayFeePerKb btcutil.Amount, fetchInputs txauthor.InputSource, fetchChange txauthor.ChangeSource) (*txauthor.AuthoredTx, error) {
+func (b *Bridge) NewUnsignedTransaction(outputs []*wire.TxOut, relayFeePerKb btcutil.Amount, fetchInputs txauthor.InputSource, fetchChange txauthor.ChangeSource) (*txauthor.AuthoredTx, error) {
 	targetAmount := txauthor.SumOutputValues(outputs)
 	estimatedSize := txsizes.EstimateVirtualSize(0, 1, 0, outputs, true)
 	targetFee := txrules.FeeForSerializeSize(relayFeePerKb, estimatedSize)
@@ -330,7 +384,7 @@ func NewUnsignedTransaction(outputs []*wire.TxOut, relayFeePerKb btcutil.Amount,
 
 		// We count the types of inputs, which we'll use to estimate
 		// the vsize of the transaction.
-		var nested, p2wpkh, p2pkh int
+		var nested, p2wpkh, p2wsh, p2pkh int
 		for _, pkScript := range scripts {
 			switch {
 			// If this is a p2sh output, we assume this is a
@@ -339,12 +393,16 @@ func NewUnsignedTransaction(outputs []*wire.TxOut, relayFeePerKb btcutil.Amount,
 				nested++
 			case txscript.IsPayToWitnessPubKeyHash(pkScript):
 				p2wpkh++
+			case txscript.IsPayToWitnessScriptHash(pkScript):
+				p2wsh++
 			default:
 				p2pkh++
 			}
 		}
 
-		maxSignedSize := txsizes.EstimateVirtualSize(p2pkh, p2wpkh, nested, outputs, true)
+		// p2wsh memo input is a p2wpkh input with an extra witness script
+		maxSignedSize := txsizes.EstimateVirtualSize(p2pkh, p2wpkh+p2wsh, nested, outputs, true)
+		maxSignedSize += p2wsh * p2wshMemoWitnessScriptVSize
 		maxRequiredFee := txrules.FeeForSerializeSize(relayFeePerKb, maxSignedSize)
 		if maxRequiredFee < btcutil.Amount(tokens.BtcMinRelayFee) {
 			maxRequiredFee = btcutil.Amount(tokens.BtcMinRelayFee)
@@ -373,7 +431,7 @@ func NewUnsignedTransaction(outputs []*wire.TxOut, relayFeePerKb btcutil.Amount,
 			//	return nil, errors.New("fee estimation requires change " +
 			//		"scripts no larger than P2WPKH output scripts")
 			//}
-			threshold := txrules.GetDustThreshold(len(changeScript), txrules.DefaultRelayFeePerKb)
+			threshold := b.Chain.getDustThreshold(len(changeScript))
 			if changeAmount < threshold {
 				log.Debug("get rid of dust change", "amount", changeAmount, "threshold", threshold, "scriptsize", len(changeScript))
 			} else {
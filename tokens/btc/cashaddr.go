@@ -0,0 +1,135 @@
+package btc
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcutil/bech32"
+)
+
+// CashAddr address format of BCH
+// ref. https://github.com/bitcoincashorg/bitcoincash.org/blob/master/spec/cashaddr.md
+
+const (
+	cashAddrCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	cashAddrTypeP2PKH = 0
+	cashAddrTypeP2SH  = 1
+
+	cashAddrChecksumLen = 8
+)
+
+var errInvalidCashAddr = errors.New("invalid cashaddr")
+
+func cashAddrPolyMod(values []byte) uint64 {
+	c := uint64(1)
+	for _, d := range values {
+		c0 := byte(c >> 35)
+		c = ((c & 0x07ffffffff) << 5) ^ uint64(d)
+		if c0&0x01 != 0 {
+			c ^= 0x98f2bc8e61
+		}
+		if c0&0x02 != 0 {
+			c ^= 0x79b76d99e2
+		}
+		if c0&0x04 != 0 {
+			c ^= 0xf33e5fb3c4
+		}
+		if c0&0x08 != 0 {
+			c ^= 0xae2eabe2a8
+		}
+		if c0&0x10 != 0 {
+			c ^= 0x1e4f43e470
+		}
+	}
+	return c ^ 1
+}
+
+func cashAddrPrefixValues(prefix string) []byte {
+	values := make([]byte, 0, len(prefix)+1)
+	for i := 0; i < len(prefix); i++ {
+		values = append(values, prefix[i]&0x1f)
+	}
+	return append(values, 0)
+}
+
+// EncodeCashAddr encode p2pkh or p2sh address to CashAddr format with prefix
+func EncodeCashAddr(prefix string, address btcutil.Address) (string, error) {
+	var versionByte byte
+	switch address.(type) {
+	case *btcutil.AddressPubKeyHash:
+		versionByte = cashAddrTypeP2PKH << 3
+	case *btcutil.AddressScriptHash:
+		versionByte = cashAddrTypeP2SH << 3
+	default:
+		return "", errors.New("cashaddr only supports p2pkh and p2sh address")
+	}
+	payload := append([]byte{versionByte}, address.ScriptAddress()...)
+	data, err := bech32.ConvertBits(payload, 8, 5, true)
+	if err != nil {
+		return "", err
+	}
+
+	values := append(cashAddrPrefixValues(prefix), data...)
+	values = append(values, make([]byte, cashAddrChecksumLen)...)
+	polyMod := cashAddrPolyMod(values)
+	for i := 0; i < cashAddrChecksumLen; i++ {
+		data = append(data, byte((polyMod>>uint(5*(cashAddrChecksumLen-1-i)))&0x1f))
+	}
+
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	sb.WriteByte(':')
+	for _, d := range data {
+		sb.WriteByte(cashAddrCharset[d])
+	}
+	return sb.String(), nil
+}
+
+// DecodeCashAddr decode CashAddr format address (prefix is optional)
+func DecodeCashAddr(addr, prefix string, net *chaincfg.Params) (btcutil.Address, error) {
+	if strings.ToLower(addr) != addr && strings.ToUpper(addr) != addr {
+		return nil, errInvalidCashAddr // mixed case
+	}
+	addr = strings.ToLower(addr)
+	if sep := strings.LastIndexByte(addr, ':'); sep >= 0 {
+		if addr[:sep] != prefix {
+			return nil, errInvalidCashAddr
+		}
+		addr = addr[sep+1:]
+	}
+	if len(addr) <= cashAddrChecksumLen {
+		return nil, errInvalidCashAddr
+	}
+
+	data := make([]byte, len(addr))
+	for i := 0; i < len(addr); i++ {
+		index := strings.IndexByte(cashAddrCharset, addr[i])
+		if index < 0 {
+			return nil, errInvalidCashAddr
+		}
+		data[i] = byte(index)
+	}
+	if cashAddrPolyMod(append(cashAddrPrefixValues(prefix), data...)) != 0 {
+		return nil, errors.New("invalid cashaddr checksum")
+	}
+
+	payload, err := bech32.ConvertBits(data[:len(data)-cashAddrChecksumLen], 5, 8, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) != 1+20 || payload[0]&0x07 != 0 {
+		return nil, errors.New("unsupported cashaddr hash size")
+	}
+	hash := payload[1:]
+	switch payload[0] >> 3 {
+	case cashAddrTypeP2PKH:
+		return btcutil.NewAddressPubKeyHash(hash, net)
+	case cashAddrTypeP2SH:
+		return btcutil.NewAddressScriptHashFromHash(hash, net)
+	default:
+		return nil, errors.New("unsupported cashaddr type")
+	}
+}
@@ -0,0 +1,86 @@
+package btc
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcutil"
+)
+
+// examples of CashAddr spec (legacy address and its CashAddr)
+var cashAddrTests = []struct {
+	legacy   string
+	cashAddr string
+}{
+	{"1BpEi6DfDAUFd7GtittLSdBeYJvcoaVggu", "bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a"},
+	{"1KXrWXciRDZUpQwQmuM1DbwsKDLYAYsVLR", "bitcoincash:qr95sy3j9xwd2ap32xkykttr4cvcu7as4y0qverfuy"},
+	{"16w1D5WRVKJuZUsSRzdLp9w3YGcgoxDXb", "bitcoincash:qqq3728yw0y47sqn6l2na30mcw6zm78dzqre909m2r"},
+	{"3CWFddi6m4ndiGyKqzYvsFYagqDLPVMTzC", "bitcoincash:ppm2qsznhks23z7629mms6s4cwef74vcwvn0h829pq"},
+	{"3LDsS579y7sruadqu11beEJoTjdFiFCdX4", "bitcoincash:pr95sy3j9xwd2ap32xkykttr4cvcu7as4yc93ky28e"},
+	{"31nwvkZwyPdgzjBJZXfDmSWsC4ZLKpYyUw", "bitcoincash:pqq3728yw0y47sqn6l2na30mcw6zm78dzq5ucqzc37"},
+}
+
+func TestCashAddrEncodeDecode(t *testing.T) {
+	net := BitcoinCashChain.nets[netMainnet]
+	for _, test := range cashAddrTests {
+		legacy, err := btcutil.DecodeAddress(test.legacy, net.params)
+		if err != nil {
+			t.Fatalf("decode legacy address %v failed: %v", test.legacy, err)
+		}
+		cashAddr, err := EncodeCashAddr(net.cashAddrPrefix, legacy)
+		if err != nil || cashAddr != test.cashAddr {
+			t.Errorf("encode %v, want %v, got %v, err %v", test.legacy, test.cashAddr, cashAddr, err)
+		}
+		// with or without prefix, lower or upper case
+		withoutPrefix := strings.TrimPrefix(test.cashAddr, net.cashAddrPrefix+":")
+		for _, addr := range []string{test.cashAddr, withoutPrefix, strings.ToUpper(test.cashAddr)} {
+			decoded, err := DecodeCashAddr(addr, net.cashAddrPrefix, net.params)
+			if err != nil || decoded.EncodeAddress() != test.legacy {
+				t.Errorf("decode %v, want %v, got %v, err %v", addr, test.legacy, decoded, err)
+			}
+		}
+	}
+}
+
+func TestCashAddrTestnet(t *testing.T) {
+	net := BitcoinCashChain.nets[netTestnet3]
+	hash, _ := hex.DecodeString("76a04053bda0a88bda5177b86a15c3b29f559873")
+	address, _ := btcutil.NewAddressPubKeyHash(hash, net.params)
+	cashAddr, err := EncodeCashAddr(net.cashAddrPrefix, address)
+	if err != nil || !strings.HasPrefix(cashAddr, "bchtest:qp") {
+		t.Fatalf("encode testnet address, got %v, err %v", cashAddr, err)
+	}
+	decoded, err := DecodeCashAddr(cashAddr, net.cashAddrPrefix, net.params)
+	if err != nil || decoded.EncodeAddress() != address.EncodeAddress() {
+		t.Fatalf("decode testnet address %v, got %v, err %v", cashAddr, decoded, err)
+	}
+	// checksum commits to the prefix
+	if _, err = DecodeCashAddr(strings.TrimPrefix(cashAddr, "bchtest:"), "bitcoincash", net.params); err == nil {
+		t.Fatal("decode testnet address with mainnet prefix")
+	}
+}
+
+func TestCashAddrInvalid(t *testing.T) {
+	net := BitcoinCashChain.nets[netMainnet]
+	valid := cashAddrTests[0].cashAddr
+	invalids := []string{
+		"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6b", // wrong checksum
+		"bitcoincash:Qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a", // mixed case
+		"bchtest:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a",     // wrong prefix
+		"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6o", // invalid char
+		"bitcoincash:qpm2qszn", // too short
+		"bitcoincash:qpm2qsznhks23z7629mms6s4cwef74vcwvy22gdx6a:", // empty payload
+		valid[:len(valid)-1],
+	}
+	for _, addr := range invalids {
+		if decoded, err := DecodeCashAddr(addr, net.cashAddrPrefix, net.params); err == nil {
+			t.Errorf("decode invalid cashaddr %v, got %v", addr, decoded)
+		}
+	}
+	pubKey, _ := hex.DecodeString("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+	address, _ := btcutil.NewAddressPubKey(pubKey, net.params)
+	if _, err := EncodeCashAddr(net.cashAddrPrefix, address); err == nil {
+		t.Error("encode pubkey address to cashaddr")
+	}
+}
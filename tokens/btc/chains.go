@@ -0,0 +1,196 @@
+package btc
+
+import (
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/txrules"
+)
+
+// UtxoChain utxo chain specific params and rules
+type UtxoChain struct {
+	Name string
+
+	// net params keyed by lower case net id,
+	// the test net params are used for net ids not listed (eg. custom)
+	nets    map[string]*utxoNet
+	testNet string
+
+	// sign with BIP143 sighash and SIGHASH_FORKID (BCH)
+	sigHashForkID bool
+	// support replace by fee (BIP125)
+	supportRBF bool
+	// support native segwit (BIP141, BIP143, BIP173)
+	supportSegWit bool
+
+	// dust threshold is calced by this relay fee per kb,
+	// or is fixed to dustLimit if it's positive (DOGE)
+	dustRelayFeePerKb btcutil.Amount
+	dustLimit         btcutil.Amount
+
+	// default and max values of BtcExtra fee config
+	defMinRelayFee   int64
+	defRelayFeePerKb int64
+	maxRelayFee      int64
+
+	// replacement should pay at least this more relay fee per kb
+	incrementalRelayFeePerKb int64
+}
+
+type utxoNet struct {
+	params         *chaincfg.Params
+	cashAddrPrefix string // CashAddr prefix (BCH), empty means use legacy address
+}
+
+// utxo chain names, which are prefixes of upper case 'BlockChain' config
+const (
+	chainBitcoin     = "BITCOIN"
+	chainBitcoinCash = "BITCOINCASH"
+	chainLitecoin    = "LITECOIN"
+	chainDogecoin    = "DOGECOIN"
+)
+
+var (
+	// BitcoinChain bitcoin
+	BitcoinChain = &UtxoChain{
+		Name: chainBitcoin,
+		nets: map[string]*utxoNet{
+			netMainnet:  {params: &chaincfg.MainNetParams},
+			netTestnet3: {params: &chaincfg.TestNet3Params},
+		},
+		testNet:                  netTestnet3,
+		supportRBF:               true,
+		supportSegWit:            true,
+		dustRelayFeePerKb:        txrules.DefaultRelayFeePerKb,
+		defMinRelayFee:           400,
+		defRelayFeePerKb:         2000,
+		maxRelayFee:              100000, // 0.001 BTC
+		incrementalRelayFeePerKb: 1000,
+	}
+
+	// BitcoinCashChain bitcoin cash
+	BitcoinCashChain = &UtxoChain{
+		Name: chainBitcoinCash,
+		nets: map[string]*utxoNet{
+			netMainnet: {
+				params:         newUtxoNetParams(&chaincfg.MainNetParams, "bch-mainnet", 0xe8f3e1e3, 0x00, 0x05, 0x80, ""),
+				cashAddrPrefix: "bitcoincash",
+			},
+			netTestnet3: {
+				params:         newUtxoNetParams(&chaincfg.TestNet3Params, "bch-testnet3", 0xf4f3e5f4, 0x6f, 0xc4, 0xef, ""),
+				cashAddrPrefix: "bchtest",
+			},
+		},
+		testNet:                  netTestnet3,
+		sigHashForkID:            true,
+		dustRelayFeePerKb:        txrules.DefaultRelayFeePerKb,
+		defMinRelayFee:           400,
+		defRelayFeePerKb:         2000,
+		maxRelayFee:              100000, // 0.001 BCH
+		incrementalRelayFeePerKb: 1000,
+	}
+
+	// LitecoinChain litecoin
+	LitecoinChain = &UtxoChain{
+		Name: chainLitecoin,
+		nets: map[string]*utxoNet{
+			netMainnet:  {params: newUtxoNetParams(&chaincfg.MainNetParams, "ltc-mainnet", 0xdbb6c0fb, 0x30, 0x32, 0xb0, "ltc")},
+			netTestnet4: {params: newUtxoNetParams(&chaincfg.TestNet3Params, "ltc-testnet4", 0xf1c8d2fd, 0x6f, 0x3a, 0xef, "tltc")},
+		},
+		testNet:                  netTestnet4,
+		supportRBF:               true,
+		supportSegWit:            true,
+		dustRelayFeePerKb:        3000,
+		defMinRelayFee:           1000,
+		defRelayFeePerKb:         10000,
+		maxRelayFee:              1000000, // 0.01 LTC
+		incrementalRelayFeePerKb: 1000,
+	}
+
+	// DogecoinChain dogecoin
+	DogecoinChain = &UtxoChain{
+		Name: chainDogecoin,
+		nets: map[string]*utxoNet{
+			netMainnet: {params: newUtxoNetParams(&chaincfg.MainNetParams, "doge-mainnet", 0xc0c0c0c0, 0x1e, 0x16, 0x9e, "")},
+			netTestnet: {params: newUtxoNetParams(&chaincfg.TestNet3Params, "doge-testnet", 0xdcb7c1fc, 0x71, 0xc4, 0xf1, "")},
+		},
+		testNet:                  netTestnet,
+		supportRBF:               true,
+		dustLimit:                1000000, // 0.01 DOGE
+		defMinRelayFee:           100000,  // 0.001 DOGE
+		defRelayFeePerKb:         1000000, // 0.01 DOGE
+		maxRelayFee:              1e8,     // 1 DOGE
+		incrementalRelayFeePerKb: 100000,
+	}
+
+	// longer names must be matched first (eg. BITCOINCASH before BITCOIN)
+	utxoChains = []*UtxoChain{BitcoinCashChain, LitecoinChain, DogecoinChain, BitcoinChain}
+)
+
+// register net params to decode bech32 address of the net
+func init() {
+	for _, net := range LitecoinChain.nets {
+		if err := chaincfg.Register(net.params); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func newUtxoNetParams(base *chaincfg.Params, name string, net wire.BitcoinNet, pubKeyHashAddrID, scriptHashAddrID, privateKeyID byte, bech32HRP string) *chaincfg.Params {
+	params := *base
+	params.Name = name
+	params.Net = net
+	params.PubKeyHashAddrID = pubKeyHashAddrID
+	params.ScriptHashAddrID = scriptHashAddrID
+	params.PrivateKeyID = privateKeyID
+	params.Bech32HRPSegwit = bech32HRP
+	return &params
+}
+
+// GetUtxoChain get utxo chain by 'BlockChain' config, returns nil if not utxo chain
+func GetUtxoChain(blockChain string) *UtxoChain {
+	blockChain = strings.ToUpper(blockChain)
+	for _, chain := range utxoChains {
+		if strings.HasPrefix(blockChain, chain.Name) {
+			return chain
+		}
+	}
+	return nil
+}
+
+// IsUtxoChain is utxo chain which is bridged by btc bridge
+func IsUtxoChain(blockChain string) bool {
+	return GetUtxoChain(blockChain) != nil
+}
+
+// IsSupportedNet is supported net id
+func (c *UtxoChain) IsSupportedNet(netID string) bool {
+	_, exist := c.nets[strings.ToLower(netID)]
+	return exist
+}
+
+// DefaultFees default min relay fee and relay fee per kb
+func (c *UtxoChain) DefaultFees() (minRelayFee, relayFeePerKb int64) {
+	return c.defMinRelayFee, c.defRelayFeePerKb
+}
+
+// MaxRelayFee max value of configed min relay fee and relay fee per kb
+func (c *UtxoChain) MaxRelayFee() int64 {
+	return c.maxRelayFee
+}
+
+func (c *UtxoChain) getNet(netID string) *utxoNet {
+	if net, exist := c.nets[strings.ToLower(netID)]; exist {
+		return net
+	}
+	return c.nets[c.testNet]
+}
+
+func (c *UtxoChain) getDustThreshold(scriptSize int) btcutil.Amount {
+	if c.dustLimit > 0 {
+		return c.dustLimit
+	}
+	return txrules.GetDustThreshold(scriptSize, c.dustRelayFeePerKb)
+}
@@ -1,6 +1,7 @@
 package electrs
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 
@@ -32,19 +33,26 @@ func GetLatestBlockNumber(b tokens.CrossChainBridge) (result uint64, err error)
 	return 0, err
 }
 
-// GetTransactionByHash call /tx/{txHash}
+// GetTransactionByHash call /tx/{txHash},
+// returns tokens.ErrTxNotFound only if all gateways answer it is not found.
 func GetTransactionByHash(b tokens.CrossChainBridge, txHash string) (*ElectTx, error) {
 	_, gateway := b.GetTokenAndGateway()
 	var result ElectTx
-	var err error
+	var rpcErr error
 	for _, apiAddress := range gateway.APIAddress {
 		url := apiAddress + "/tx/" + txHash
-		err = client.RPCGet(&result, url)
+		err := client.RPCGet(&result, url)
 		if err == nil {
 			return &result, nil
 		}
+		if !errors.Is(err, client.ErrResponseNotFound) {
+			rpcErr = err
+		}
 	}
-	return nil, err
+	if rpcErr != nil {
+		return nil, rpcErr
+	}
+	return nil, tokens.ErrTxNotFound
 }
 
 // GetElectTransactionStatus call /tx/{txHash}/status
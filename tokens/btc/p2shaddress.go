@@ -1,6 +1,7 @@
 package btc
 
 import (
+	"crypto/sha256"
 	"fmt"
 
 	"github.com/anyswap/CrossChain-Bridge/common"
@@ -11,13 +12,17 @@ import (
 	"github.com/btcsuite/btcutil"
 )
 
-// GetP2shAddressWithMemo common
-func GetP2shAddressWithMemo(memo, pubKeyHash []byte, net *chaincfg.Params) (p2shAddress string, redeemScript []byte, err error) {
-	redeemScript, err = txscript.NewScriptBuilder().
+func getMemoRedeemScript(memo, pubKeyHash []byte) ([]byte, error) {
+	return txscript.NewScriptBuilder().
 		AddData(memo).AddOp(txscript.OP_DROP).
 		AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).AddData(pubKeyHash).
 		AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).
 		Script()
+}
+
+// GetP2shAddressWithMemo common
+func GetP2shAddressWithMemo(memo, pubKeyHash []byte, net *chaincfg.Params) (p2shAddress string, redeemScript []byte, err error) {
+	redeemScript, err = getMemoRedeemScript(memo, pubKeyHash)
 	if err != nil {
 		return
 	}
@@ -30,20 +35,60 @@ func GetP2shAddressWithMemo(memo, pubKeyHash []byte, net *chaincfg.Params) (p2sh
 	return
 }
 
-// GetP2shAddress get p2sh address from bind address
+// GetP2wshAddressWithMemo common (native segwit version of GetP2shAddressWithMemo)
+func GetP2wshAddressWithMemo(memo, pubKeyHash []byte, net *chaincfg.Params) (p2wshAddress string, witnessScript []byte, err error) {
+	witnessScript, err = getMemoRedeemScript(memo, pubKeyHash)
+	if err != nil {
+		return
+	}
+	scriptHash := sha256.Sum256(witnessScript)
+	var addressScriptHash *btcutil.AddressWitnessScriptHash
+	addressScriptHash, err = btcutil.NewAddressWitnessScriptHash(scriptHash[:], net)
+	if err != nil {
+		return
+	}
+	p2wshAddress = addressScriptHash.EncodeAddress()
+	return
+}
+
+// GetP2shAddress get p2sh address from bind address,
+// it's a p2wsh address if dcrm address is a p2wpkh address.
 func (b *Bridge) GetP2shAddress(bindAddr string) (p2shAddress string, redeemScript []byte, err error) {
-	if !tokens.GetCrossChainBridge(!b.IsSrc).IsValidAddress(bindAddr) {
+	return b.GetP2shAddressByType(bindAddr, b.IsP2wpkhAddress(b.TokenConfig.DcrmAddress))
+}
+
+// GetP2shAddressByType get p2sh or p2wsh address from bind address
+func (b *Bridge) GetP2shAddressByType(bindAddr string, isP2wsh bool) (p2shAddress string, redeemScript []byte, err error) {
+	if !tokens.GetCrossChainBridge(b.PairID, !b.IsSrc).IsValidAddress(bindAddr) {
 		return "", nil, fmt.Errorf("invalid bind address %v", bindAddr)
 	}
 	memo := common.FromHex(bindAddr)
 	net := b.GetChainConfig()
 	dcrmAddress := b.TokenConfig.DcrmAddress
-	address, err := btcutil.DecodeAddress(dcrmAddress, net)
+	address, err := b.DecodeAddress(dcrmAddress)
 	if err != nil {
 		return "", nil, fmt.Errorf("invalid dcrm address %v, %v", dcrmAddress, err)
 	}
 	pubKeyHash := address.ScriptAddress()
-	return GetP2shAddressWithMemo(memo, pubKeyHash, net)
+	if isP2wsh {
+		if !b.SupportSegWit() {
+			return "", nil, fmt.Errorf("segwit is not supported on %v", b.TokenConfig.BlockChain)
+		}
+		return GetP2wshAddressWithMemo(memo, pubKeyHash, net)
+	}
+	p2shAddress, redeemScript, err = GetP2shAddressWithMemo(memo, pubKeyHash, net)
+	if err != nil {
+		return "", nil, err
+	}
+	return b.NormalizeAddress(p2shAddress), redeemScript, nil
+}
+
+// getP2shAddressTypes get supported bind address types (is p2wsh or not)
+func (b *Bridge) getP2shAddressTypes() []bool {
+	if b.SupportSegWit() {
+		return []bool{false, true}
+	}
+	return []bool{false}
 }
 
 func (b *Bridge) getRedeemScriptByOutputScrpit(preScript []byte) ([]byte, error) {
@@ -55,13 +100,13 @@ func (b *Bridge) getRedeemScriptByOutputScrpit(preScript []byte) ([]byte, error)
 	if err != nil {
 		return nil, err
 	}
-	p2shAddr := p2shAddress.String()
+	p2shAddr := b.EncodeAddress(p2shAddress)
 	bindAddr := tools.GetP2shBindAddress(p2shAddr)
 	if bindAddr == "" {
 		return nil, fmt.Errorf("ps2h address %v is registered", p2shAddr)
 	}
-	var address string
-	address, redeemScript, _ := b.GetP2shAddress(bindAddr)
+	isP2wsh := pkScript.Class() == txscript.WitnessV0ScriptHashTy
+	address, redeemScript, _ := b.GetP2shAddressByType(bindAddr, isP2wsh)
 	if address != p2shAddr {
 		return nil, fmt.Errorf("ps2h address mismatch for bind address %v, have %v want %v", bindAddr, p2shAddr, address)
 	}
@@ -75,7 +120,7 @@ func (b *Bridge) GetP2shAddressByRedeemScript(redeemScript []byte) (string, erro
 	if err != nil {
 		return "", err
 	}
-	return addressScriptHash.EncodeAddress(), nil
+	return b.EncodeAddress(addressScriptHash), nil
 }
 
 // GetP2shSigScript get p2sh signature script
@@ -86,3 +131,9 @@ func (b *Bridge) GetP2shSigScript(redeemScript []byte) ([]byte, error) {
 	}
 	return b.getPayToAddrScript(p2shAddr)
 }
+
+// GetP2wshPkScript get p2wsh output script by witness script
+func (b *Bridge) GetP2wshPkScript(witnessScript []byte) ([]byte, error) {
+	scriptHash := sha256.Sum256(witnessScript)
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(scriptHash[:]).Script()
+}
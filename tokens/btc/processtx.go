@@ -41,12 +41,12 @@ func (b *Bridge) processTransactionImpl(tx *electrs.ElectTx) {
 
 func (b *Bridge) processSwapin(txid string) error {
 	swapInfo, err := b.VerifyTransaction(txid, true)
-	return tools.RegisterSwapin(txid, swapInfo.Bind, err)
+	return tools.RegisterSwapin(b.PairID, txid, swapInfo.Bind, err)
 }
 
 func (b *Bridge) processP2shSwapin(txid, bindAddress string) error {
 	swapInfo, err := b.VerifyP2shTransaction(txid, bindAddress, true)
-	return tools.RegisterP2shSwapin(txid, swapInfo.Bind, err)
+	return tools.RegisterP2shSwapin(b.PairID, txid, swapInfo.Bind, err)
 }
 
 // CheckSwapinTxType check swapin type
@@ -58,14 +58,14 @@ func (b *Bridge) CheckSwapinTxType(tx *electrs.ElectTx) (p2shBindAddr string, er
 			continue
 		}
 		switch *output.ScriptpubkeyType {
-		case p2shType:
-			// use the first registered p2sh address
+		case p2shType, p2wshType:
+			// use the first registered p2sh (or p2wsh) address
 			p2shAddress := *output.ScriptpubkeyAddress
 			p2shBindAddr = tools.GetP2shBindAddress(p2shAddress)
 			if p2shBindAddr != "" {
 				return p2shBindAddr, nil
 			}
-		case p2pkhType:
+		case p2pkhType, p2wpkhType:
 			if *output.ScriptpubkeyAddress == depositAddress {
 				if txFrom == "" {
 					txFrom = getTxFrom(tx.Vin, depositAddress)
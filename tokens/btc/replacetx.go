@@ -0,0 +1,87 @@
+package btc
+
+import (
+	"strings"
+
+	"github.com/anyswap/CrossChain-Bridge/common"
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+	"github.com/anyswap/CrossChain-Bridge/tokens/btc/electrs"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// rbfSequence signals the tx is replaceable (BIP125)
+	rbfSequence = wire.MaxTxInSequenceNum - 2
+)
+
+// GetReplaceExtraArgs impl tokens.ReplaceableBridge,
+// spend the same previous outpoints of the stuck swap tx with a higher relay fee.
+func (b *Bridge) GetReplaceExtraArgs(swapTx string, swapNonce, plusFeePercentage uint64) (*tokens.AllExtras, error) {
+	if !b.Chain.supportRBF {
+		return nil, tokens.ErrReplaceTxNotSupported
+	}
+	tx, err := b.getTransactionByHashWithRetry(swapTx)
+	if err != nil {
+		return nil, err
+	}
+	if tx.Status != nil && tx.Status.BlockHeight != nil {
+		return nil, tokens.ErrSwapTxAlreadyMined
+	}
+
+	extra := &tokens.BtcExtraArgs{}
+	extra.PreviousOutPoints = make([]*tokens.BtcOutPoint, len(tx.Vin))
+	for i, input := range tx.Vin {
+		extra.PreviousOutPoints[i] = &tokens.BtcOutPoint{
+			Hash:  *input.Txid,
+			Index: *input.Vout,
+		}
+	}
+
+	var oldRelayFeePerKb int64
+	if tx.Fee != nil && tx.Weight != nil && *tx.Weight > 0 {
+		vsize := (int64(*tx.Weight) + 3) / 4
+		oldRelayFeePerKb = int64(*tx.Fee) * 1000 / vsize
+	}
+	relayFeePerKb := oldRelayFeePerKb * int64(100+plusFeePercentage) / 100
+	if relayFeePerKb < oldRelayFeePerKb+b.Chain.incrementalRelayFeePerKb {
+		relayFeePerKb = oldRelayFeePerKb + b.Chain.incrementalRelayFeePerKb
+	}
+	if relayFeePerKb < tokens.BtcRelayFeePerKb {
+		relayFeePerKb = tokens.BtcRelayFeePerKb
+	}
+	extra.RelayFeePerKb = &relayFeePerKb
+
+	log.Info("get replace extra args", "swaptx", swapTx, "oldRelayFeePerKb", oldRelayFeePerKb, "relayFeePerKb", relayFeePerKb)
+	return &tokens.AllExtras{BtcExtra: extra}, nil
+}
+
+// isSpentByReplaceableTx return true if the outpoint is spent in txpool
+// by a tx with the same memo, which is the tx we want to replace.
+func (b *Bridge) isSpentByReplaceableTx(outspend *electrs.ElectOutspend, memo string) bool {
+	if memo == "" || outspend.Txid == nil {
+		return false
+	}
+	if outspend.Status != nil && outspend.Status.BlockHeight != nil {
+		return false
+	}
+	tx, err := b.getTransactionByHashWithRetry(*outspend.Txid)
+	if err != nil {
+		return false
+	}
+	return hasMemo(tx.Vout, memo)
+}
+
+func hasMemo(vout []*electrs.ElectTxOut, memo string) bool {
+	for _, output := range vout {
+		if output.ScriptpubkeyType == nil || *output.ScriptpubkeyType != opReturnType ||
+			output.ScriptpubkeyAsm == nil {
+			continue
+		}
+		parts := regexMemo.Split(*output.ScriptpubkeyAsm, -1)
+		if len(parts) == 2 && string(common.FromHex(strings.TrimSpace(parts[1]))) == memo {
+			return true
+		}
+	}
+	return false
+}
@@ -1,27 +1,34 @@
 package btc
 
 import (
+	"context"
 	"fmt"
-	"time"
 
+	"github.com/anyswap/CrossChain-Bridge/common"
 	"github.com/anyswap/CrossChain-Bridge/log"
 	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
 )
 
 var (
 	scannedBlocks = tools.NewCachedScannedBlocks(13)
+	reorgTracker  = tools.NewReorgTracker(maxReorgDepth)
+	maxReorgDepth = uint64(20)
 )
 
 // StartChainTransactionScanJob scan job
-func (b *Bridge) StartChainTransactionScanJob() {
+func (b *Bridge) StartChainTransactionScanJob(ctx context.Context) {
 	chainName := b.TokenConfig.BlockChain
 	log.Infof("[scanchain] start %v scan chain job", chainName)
+	defer log.Infof("[scanchain] stop %v scan chain job", chainName)
 
-	startHeight := tools.GetLatestScanHeight(b.IsSrc)
+	startHeight := tools.GetLatestScanHeight(b.PairID, b.IsSrc)
 	confirmations := *b.TokenConfig.Confirmations
 	initialHeight := b.TokenConfig.InitialHeight
 
-	latest := tools.LoopGetLatestBlockNumber(b)
+	latest, err := tools.LoopGetLatestBlockNumber(ctx, b)
+	if err != nil {
+		return
+	}
 
 	var height uint64
 	switch {
@@ -40,31 +47,64 @@ func (b *Bridge) StartChainTransactionScanJob() {
 	if height+maxScanHeight < latest {
 		height = latest - maxScanHeight
 	}
-	_ = tools.UpdateLatestScanInfo(b.IsSrc, height)
+	_ = tools.UpdateLatestScanInfo(b.PairID, b.IsSrc, height)
+	tools.SeedReorgTracker(reorgTracker, b.PairID, b.IsSrc, b.GetBlockHash, b.GetBlockTxids)
 	log.Infof("[scanchain] start %v scan chain loop from %v latest=%v", chainName, height, latest)
 
 	stable := height
 	errorSubject := fmt.Sprintf("[scanchain] get %v block failed", chainName)
 	scanSubject := fmt.Sprintf("[scanchain] scanned %v block", chainName)
 	for {
-		latest := tools.LoopGetLatestBlockNumber(b)
+		latest, err := tools.LoopGetLatestBlockNumber(ctx, b)
+		if err != nil {
+			return
+		}
 		for h := stable + 1; h <= latest; {
+			if ctx.Err() != nil {
+				return
+			}
 			blockHash, err := b.GetBlockHash(h)
 			if err != nil {
 				log.Error(errorSubject, "height", h, "err", err)
-				time.Sleep(retryIntervalInScanJob)
+				common.SleepWithContext(ctx, retryIntervalInScanJob)
 				continue
 			}
 			if scannedBlocks.IsBlockScanned(blockHash) {
 				h++
 				continue
 			}
+			block, err := b.GetBlock(blockHash)
+			if err != nil || block.PreviousHash == nil {
+				log.Error(errorSubject, "height", h, "blockHash", blockHash, "err", err)
+				common.SleepWithContext(ctx, retryIntervalInScanJob)
+				continue
+			}
 			txids, err := b.GetBlockTxids(blockHash)
 			if err != nil {
 				log.Error(errorSubject, "height", h, "blockHash", blockHash, "err", err)
-				time.Sleep(retryIntervalInScanJob)
+				common.SleepWithContext(ctx, retryIntervalInScanJob)
 				continue
 			}
+			orphaned, err := reorgTracker.AddBlock(&tools.ScannedBlock{
+				Height:     h,
+				Hash:       blockHash,
+				ParentHash: *block.PreviousHash,
+				Txs:        txids,
+			}, b.GetBlockHash)
+			if err != nil {
+				log.Error(errorSubject, "height", h, "blockHash", blockHash, "err", err)
+				common.SleepWithContext(ctx, retryIntervalInScanJob)
+				continue
+			}
+			if len(orphaned) > 0 {
+				tools.FillOrphanedBlockTxs(orphaned, b.GetBlockTxids)
+				tools.HandleOrphanedBlocks(b.IsSrc, orphaned)
+				// rescan the canonical blocks from the fork point
+				if forkHeight := orphaned[0].Height; forkHeight < h {
+					h = forkHeight
+					continue
+				}
+			}
 			for _, txid := range txids {
 				b.processTransaction(txid)
 			}
@@ -74,8 +114,11 @@ func (b *Bridge) StartChainTransactionScanJob() {
 		}
 		if stable+confirmations < latest {
 			stable = latest - confirmations
-			_ = tools.UpdateLatestScanInfo(b.IsSrc, stable)
+			_ = tools.UpdateLatestScanInfo(b.PairID, b.IsSrc, stable)
+		}
+		tools.SaveReorgTracker(reorgTracker, b.PairID, b.IsSrc)
+		if !common.SleepWithContext(ctx, restIntervalInScanJob) {
+			return
 		}
-		time.Sleep(restIntervalInScanJob)
 	}
 }
@@ -1,9 +1,10 @@
 package btc
 
 import (
+	"context"
 	"fmt"
-	"time"
 
+	"github.com/anyswap/CrossChain-Bridge/common"
 	"github.com/anyswap/CrossChain-Bridge/log"
 	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
 )
@@ -13,19 +14,25 @@ var (
 )
 
 // StartPoolTransactionScanJob scan job
-func (b *Bridge) StartPoolTransactionScanJob() {
+func (b *Bridge) StartPoolTransactionScanJob(ctx context.Context) {
 	chainName := b.TokenConfig.BlockChain
 	log.Infof("[scanpool] start scan %v tx pool job", chainName)
+	defer log.Infof("[scanpool] stop scan %v tx pool job", chainName)
 	errorSubject := fmt.Sprintf("[scanpool] get %v pool txs error", chainName)
 	scanSubject := fmt.Sprintf("[scanpool] scanned %v tx", chainName)
 	for {
 		txids, err := b.GetPoolTxidList()
 		if err != nil {
 			log.Error(errorSubject, "err", err)
-			time.Sleep(retryIntervalInScanJob)
+			if !common.SleepWithContext(ctx, retryIntervalInScanJob) {
+				return
+			}
 			continue
 		}
 		for _, txid := range txids {
+			if ctx.Err() != nil {
+				return
+			}
 			if scannedTxs.IsTxScanned(txid) {
 				continue
 			}
@@ -33,6 +40,8 @@ func (b *Bridge) StartPoolTransactionScanJob() {
 			b.processTransaction(txid)
 			scannedTxs.CacheScannedTx(txid)
 		}
-		time.Sleep(restIntervalInScanJob)
+		if !common.SleepWithContext(ctx, restIntervalInScanJob) {
+			return
+		}
 	}
 }
@@ -1,9 +1,11 @@
 package btc
 
 import (
+	"context"
 	"fmt"
 	"time"
 
+	"github.com/anyswap/CrossChain-Bridge/common"
 	"github.com/anyswap/CrossChain-Bridge/log"
 	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
 )
@@ -16,8 +18,9 @@ var (
 )
 
 // StartSwapHistoryScanJob scan job
-func (b *Bridge) StartSwapHistoryScanJob() {
+func (b *Bridge) StartSwapHistoryScanJob(ctx context.Context) {
 	log.Infof("[swaphistory] start scan %v swap history job", b.TokenConfig.BlockChain)
+	defer log.Infof("[swaphistory] stop scan %v swap history job", b.TokenConfig.BlockChain)
 
 	isProcessed := func(txid string) bool {
 		if b.IsSrc {
@@ -26,14 +29,17 @@ func (b *Bridge) StartSwapHistoryScanJob() {
 		return tools.IsSwapoutExist(txid)
 	}
 
-	go b.scanFirstLoop(isProcessed)
+	go b.scanFirstLoop(ctx, isProcessed)
 
-	b.scanTransactionHistory(isProcessed)
+	b.scanTransactionHistory(ctx, isProcessed)
 }
 
-func (b *Bridge) scanFirstLoop(isProcessed func(string) bool) {
+func (b *Bridge) scanFirstLoop(ctx context.Context, isProcessed func(string) bool) {
 	// first loop process all tx history no matter whether processed before
-	latest := tools.LoopGetLatestBlockNumber(b)
+	latest, err := tools.LoopGetLatestBlockNumber(ctx, b)
+	if err != nil {
+		return
+	}
 	minHeight := b.TokenConfig.InitialHeight
 	if minHeight+maxFirstScanHeight < latest {
 		minHeight = latest - maxFirstScanHeight
@@ -45,9 +51,12 @@ func (b *Bridge) scanFirstLoop(isProcessed func(string) bool) {
 
 FIRST_LOOP:
 	for {
+		if ctx.Err() != nil {
+			return
+		}
 		txHistory, err := b.GetTransactionHistory(b.TokenConfig.DepositAddress, lastSeenTxid)
 		if err != nil {
-			time.Sleep(retryIntervalInScanJob)
+			common.SleepWithContext(ctx, retryIntervalInScanJob)
 			continue
 		}
 		if len(txHistory) == 0 {
@@ -73,13 +82,16 @@ FIRST_LOOP:
 	log.Infof("[scanFirstLoop] finish %v first scan loop to min height %v", chainName, minHeight)
 }
 
-func (b *Bridge) scanTransactionHistory(isProcessed func(string) bool) {
+func (b *Bridge) scanTransactionHistory(ctx context.Context, isProcessed func(string) bool) {
 	var (
 		lastSeenTxid = ""
 		rescan       = true
 	)
 
-	latest := tools.LoopGetLatestBlockNumber(b)
+	latest, err := tools.LoopGetLatestBlockNumber(ctx, b)
+	if err != nil {
+		return
+	}
 	minHeight := b.TokenConfig.InitialHeight
 	if minHeight+maxScanHeight < latest {
 		minHeight = latest - maxScanHeight
@@ -91,10 +103,13 @@ func (b *Bridge) scanTransactionHistory(isProcessed func(string) bool) {
 	log.Infof("[scanhistory] start %v scan swap history loop from height %v", chainName, minHeight)
 
 	for {
+		if ctx.Err() != nil {
+			return
+		}
 		txHistory, err := b.GetTransactionHistory(b.TokenConfig.DepositAddress, lastSeenTxid)
 		if err != nil {
 			log.Error(errorSubject, "err", err)
-			time.Sleep(retryIntervalInScanJob)
+			common.SleepWithContext(ctx, retryIntervalInScanJob)
 			continue
 		}
 		if len(txHistory) == 0 {
@@ -121,7 +136,7 @@ func (b *Bridge) scanTransactionHistory(isProcessed func(string) bool) {
 		}
 		if rescan {
 			lastSeenTxid = ""
-			time.Sleep(restIntervalInScanJob)
+			common.SleepWithContext(ctx, restIntervalInScanJob)
 		} else {
 			lastSeenTxid = *txHistory[len(txHistory)-1].Txid
 		}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/anyswap/CrossChain-Bridge/log"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcwallet/wallet/txauthor"
 )
 
@@ -31,3 +32,33 @@ func (b *Bridge) SendTransaction(signedTx interface{}) (txHash string, err error
 
 	return b.PostTransaction(txHex)
 }
+
+// EncodeSignedTx impl tokens.SignedTxEncoder
+func (b *Bridge) EncodeSignedTx(signedTx interface{}) (rawTx string, err error) {
+	authoredTx, ok := signedTx.(*txauthor.AuthoredTx)
+	if !ok || authoredTx.Tx == nil {
+		return "", tokens.ErrWrongRawTx
+	}
+	tx := authoredTx.Tx
+	buf := bytes.NewBuffer(make([]byte, 0, tx.SerializeSize()))
+	err = tx.Serialize(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// DecodeSignedTx impl tokens.SignedTxEncoder,
+// only the tx of the authored tx is restored, which is enough to send it.
+func (b *Bridge) DecodeSignedTx(rawTx string) (signedTx interface{}, err error) {
+	data, err := hex.DecodeString(rawTx)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(wire.MsgTx)
+	err = tx.Deserialize(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return &txauthor.AuthoredTx{Tx: tx}, nil
+}
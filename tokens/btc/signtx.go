@@ -16,6 +16,7 @@ import (
 	"github.com/anyswap/CrossChain-Bridge/tools/crypto"
 	"github.com/btcsuite/btcd/btcec"
 	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/btcsuite/btcutil"
 	"github.com/btcsuite/btcwallet/wallet/txauthor"
 )
@@ -24,7 +25,8 @@ const (
 	retryGetSignStatusCount    = 70
 	retryGetSignStatusInterval = 10 * time.Second
 
-	hashType = txscript.SigHashAll
+	// sigHashForkID replay protected sighash flag of BCH
+	sigHashForkID txscript.SigHashType = 0x40
 )
 
 // DcrmSignTransaction dcrm sign raw tx
@@ -44,7 +46,7 @@ func (b *Bridge) DcrmSignTransaction(rawTx interface{}, args *tokens.BuildTxArgs
 
 	for i, preScript := range authoredTx.PrevScripts {
 		sigScript := preScript
-		if txscript.IsPayToScriptHash(preScript) {
+		if txscript.IsPayToScriptHash(preScript) || txscript.IsPayToWitnessScriptHash(preScript) {
 			sigScript, err = b.getRedeemScriptByOutputScrpit(preScript)
 			if err != nil {
 				return nil, "", err
@@ -52,7 +54,7 @@ func (b *Bridge) DcrmSignTransaction(rawTx interface{}, args *tokens.BuildTxArgs
 			hasP2shInput = true
 		}
 
-		sigHash, err = txscript.CalcSignatureHash(sigScript, hashType, authoredTx.Tx, i)
+		sigHash, err = b.calcSignatureHash(sigScript, authoredTx, i)
 		if err != nil {
 			return nil, "", err
 		}
@@ -72,6 +74,31 @@ func (b *Bridge) DcrmSignTransaction(rawTx interface{}, args *tokens.BuildTxArgs
 	return b.MakeSignedTransaction(authoredTx, msgHashes, rsvs, sigScripts, args)
 }
 
+func (b *Bridge) sigHashType() txscript.SigHashType {
+	if b.Chain.sigHashForkID {
+		return txscript.SigHashAll | sigHashForkID
+	}
+	return txscript.SigHashAll
+}
+
+// calcSignatureHash calc sighash of the input,
+// segwit inputs and BCH (with fork id) use BIP143 sighash
+func (b *Bridge) calcSignatureHash(sigScript []byte, authoredTx *txauthor.AuthoredTx, i int) ([]byte, error) {
+	hashType := b.sigHashType()
+	if b.Chain.sigHashForkID || isWitnessScript(authoredTx.PrevScripts[i]) {
+		if i >= len(authoredTx.PrevInputValues) {
+			return nil, errors.New("missing previous input value")
+		}
+		sigHashes := txscript.NewTxSigHashes(authoredTx.Tx)
+		return txscript.CalcWitnessSigHash(sigScript, sigHashes, hashType, authoredTx.Tx, i, int64(authoredTx.PrevInputValues[i]))
+	}
+	return txscript.CalcSignatureHash(sigScript, hashType, authoredTx.Tx, i)
+}
+
+func isWitnessScript(pkScript []byte) bool {
+	return txscript.IsPayToWitnessPubKeyHash(pkScript) || txscript.IsPayToWitnessScriptHash(pkScript)
+}
+
 func checkEqualLength(authoredTx *txauthor.AuthoredTx, msgHash, rsv []string, sigScripts [][]byte) error {
 	txIn := authoredTx.Tx.TxIn
 	if len(txIn) != len(msgHash) {
@@ -107,7 +134,7 @@ func (b *Bridge) MakeSignedTransaction(authoredTx *txauthor.AuthoredTx, msgHash,
 
 	var sigScript []byte
 	for i, txin := range authoredTx.Tx.TxIn {
-		signData, ok := getSigDataFromRSV(rsv[i])
+		signData, ok := b.getSigDataFromRSV(rsv[i])
 		if !ok {
 			return nil, "", errors.New("wrong RSV data")
 		}
@@ -134,6 +161,20 @@ func (b *Bridge) MakeSignedTransaction(authoredTx *txauthor.AuthoredTx, msgHash,
 					sigScript, err = txscript.NewScriptBuilder().AddData(signData).AddData(cPkData).AddData(redeemScript).Script()
 				}
 			}
+		case txscript.WitnessV0PubKeyHashTy:
+			sigScript = nil
+			txin.Witness = wire.TxWitness{signData, cPkData}
+		case txscript.WitnessV0ScriptHashTy:
+			if sigScripts == nil {
+				err = fmt.Errorf("call MakeSignedTransaction spend p2wsh without witness scripts")
+			} else {
+				witnessScript := sigScripts[i]
+				err = b.verifyRedeemScript(prevScript, witnessScript)
+				if err == nil {
+					sigScript = nil
+					txin.Witness = wire.TxWitness{signData, cPkData, witnessScript}
+				}
+			}
 		default:
 			err = fmt.Errorf("unsupport to spend '%v' output", scriptClass.String())
 		}
@@ -148,7 +189,15 @@ func (b *Bridge) MakeSignedTransaction(authoredTx *txauthor.AuthoredTx, msgHash,
 }
 
 func (b *Bridge) verifyRedeemScript(prevScript, redeemScript []byte) error {
-	p2shScript, err := b.GetP2shSigScript(redeemScript)
+	var (
+		p2shScript []byte
+		err        error
+	)
+	if txscript.IsPayToWitnessScriptHash(prevScript) {
+		p2shScript, err = b.GetP2wshPkScript(redeemScript)
+	} else {
+		p2shScript, err = b.GetP2shSigScript(redeemScript)
+	}
 	if err != nil {
 		return err
 	}
@@ -158,7 +207,7 @@ func (b *Bridge) verifyRedeemScript(prevScript, redeemScript []byte) error {
 	return nil
 }
 
-func getSigDataFromRSV(rsv string) ([]byte, bool) {
+func (b *Bridge) getSigDataFromRSV(rsv string) ([]byte, bool) {
 	rs := rsv[0 : len(rsv)-2]
 
 	r := rs[:64]
@@ -179,7 +228,7 @@ func getSigDataFromRSV(rsv string) ([]byte, bool) {
 		S: ss,
 	}
 
-	signData := append(sign.Serialize(), byte(hashType))
+	signData := append(sign.Serialize(), byte(b.sigHashType()))
 	return signData, true
 }
 
@@ -189,12 +238,13 @@ func (b *Bridge) verifyPublickeyData(pkData []byte) error {
 	if dcrmAddress == "" {
 		return nil
 	}
-	address, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pkData), b.GetChainConfig())
+	address, err := b.DecodeAddress(dcrmAddress)
 	if err != nil {
 		return err
 	}
-	if address.EncodeAddress() != dcrmAddress {
-		return fmt.Errorf("public key address %v is not the configed dcrm address %v", address, dcrmAddress)
+	pubKeyHash := btcutil.Hash160(pkData)
+	if !bytes.Equal(address.ScriptAddress(), pubKeyHash) {
+		return fmt.Errorf("public key hash %x is not of the configed dcrm address %v", pubKeyHash, dcrmAddress)
 	}
 	return nil
 }
@@ -340,7 +390,7 @@ func (b *Bridge) SignTransaction(rawTx interface{}, wif string) (signedTx interf
 
 	for i, preScript := range authoredTx.PrevScripts {
 		sigScript := preScript
-		if txscript.IsPayToScriptHash(preScript) {
+		if txscript.IsPayToScriptHash(preScript) || txscript.IsPayToWitnessScriptHash(preScript) {
 			sigScript, err = b.getRedeemScriptByOutputScrpit(preScript)
 			if err != nil {
 				return nil, "", err
@@ -348,7 +398,7 @@ func (b *Bridge) SignTransaction(rawTx interface{}, wif string) (signedTx interf
 			hasP2shInput = true
 		}
 
-		sigHash, err := txscript.CalcSignatureHash(sigScript, hashType, authoredTx.Tx, i)
+		sigHash, err := b.calcSignatureHash(sigScript, authoredTx, i)
 		if err != nil {
 			return nil, "", err
 		}
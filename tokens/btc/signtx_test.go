@@ -0,0 +1,176 @@
+package btc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/btcsuite/btcutil"
+	"github.com/btcsuite/btcwallet/wallet/txauthor"
+)
+
+func decodeTestHex(t *testing.T, s string) []byte {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("decode hex %v failed: %v", s, err)
+	}
+	return data
+}
+
+func newTestAuthoredTx(t *testing.T, rawTx string, prevScripts [][]byte, prevValues []btcutil.Amount) *txauthor.AuthoredTx {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(decodeTestHex(t, rawTx))); err != nil {
+		t.Fatalf("deserialize tx failed: %v", err)
+	}
+	return &txauthor.AuthoredTx{Tx: tx, PrevScripts: prevScripts, PrevInputValues: prevValues}
+}
+
+// digest of BIP143 preimage, it's calculated here step by step as the spec
+func calcTestBip143SigHash(tx *wire.MsgTx, idx int, scriptCode []byte, amount int64, hashType uint32) []byte {
+	var prevouts, sequences, outputs, preimage bytes.Buffer
+	for _, txIn := range tx.TxIn {
+		prevouts.Write(txIn.PreviousOutPoint.Hash[:])
+		_ = binary.Write(&prevouts, binary.LittleEndian, txIn.PreviousOutPoint.Index)
+		_ = binary.Write(&sequences, binary.LittleEndian, txIn.Sequence)
+	}
+	for _, txOut := range tx.TxOut {
+		_ = wire.WriteTxOut(&outputs, 0, 0, txOut)
+	}
+	txIn := tx.TxIn[idx]
+	_ = binary.Write(&preimage, binary.LittleEndian, tx.Version)
+	preimage.Write(chainhash.DoubleHashB(prevouts.Bytes()))
+	preimage.Write(chainhash.DoubleHashB(sequences.Bytes()))
+	preimage.Write(txIn.PreviousOutPoint.Hash[:])
+	_ = binary.Write(&preimage, binary.LittleEndian, txIn.PreviousOutPoint.Index)
+	_ = wire.WriteVarBytes(&preimage, 0, scriptCode)
+	_ = binary.Write(&preimage, binary.LittleEndian, amount)
+	_ = binary.Write(&preimage, binary.LittleEndian, txIn.Sequence)
+	preimage.Write(chainhash.DoubleHashB(outputs.Bytes()))
+	_ = binary.Write(&preimage, binary.LittleEndian, tx.LockTime)
+	_ = binary.Write(&preimage, binary.LittleEndian, hashType)
+	return chainhash.DoubleHashB(preimage.Bytes())
+}
+
+// BCH signs all inputs (including legacy p2pkh) with BIP143 sighash and SIGHASH_FORKID
+func TestForkIDSignatureHash(t *testing.T) {
+	bchBridge := &Bridge{Chain: BitcoinCashChain}
+	btcBridge := &Bridge{Chain: BitcoinChain}
+	if hashType := bchBridge.sigHashType(); hashType != 0x41 {
+		t.Fatalf("wrong BCH sighash type %x", hashType)
+	}
+	if hashType := btcBridge.sigHashType(); hashType != txscript.SigHashAll {
+		t.Fatalf("wrong BTC sighash type %x", hashType)
+	}
+
+	p2pkhScript := decodeTestHex(t, "76a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac")
+	authoredTx := newTestAuthoredTx(t, bip143TxP2WPKH,
+		[][]byte{p2pkhScript, p2pkhScript},
+		[]btcutil.Amount{625000000, 600000000})
+
+	for i := range authoredTx.Tx.TxIn {
+		amount := int64(authoredTx.PrevInputValues[i])
+		sigHash, err := bchBridge.calcSignatureHash(p2pkhScript, authoredTx, i)
+		if err != nil {
+			t.Fatalf("calc BCH sighash of input %v failed: %v", i, err)
+		}
+		if want := calcTestBip143SigHash(authoredTx.Tx, i, p2pkhScript, amount, 0x41); !bytes.Equal(sigHash, want) {
+			t.Errorf("wrong BCH sighash of input %v, want %x, got %x", i, want, sigHash)
+		}
+		if noForkID := calcTestBip143SigHash(authoredTx.Tx, i, p2pkhScript, amount, 0x01); bytes.Equal(sigHash, noForkID) {
+			t.Errorf("BCH sighash of input %v does not commit to the fork id", i)
+		}
+
+		legacySigHash, err := btcBridge.calcSignatureHash(p2pkhScript, authoredTx, i)
+		if err != nil {
+			t.Fatalf("calc BTC sighash of input %v failed: %v", i, err)
+		}
+		want, _ := txscript.CalcSignatureHash(p2pkhScript, txscript.SigHashAll, authoredTx.Tx, i)
+		if !bytes.Equal(legacySigHash, want) || bytes.Equal(legacySigHash, sigHash) {
+			t.Errorf("wrong BTC legacy sighash of input %v, got %x", i, legacySigHash)
+		}
+	}
+
+	// BIP143 sighash needs the previous input value
+	authoredTx.PrevInputValues = authoredTx.PrevInputValues[:1]
+	if _, err := bchBridge.calcSignatureHash(p2pkhScript, authoredTx, 1); err == nil {
+		t.Error("calc BCH sighash without previous input value")
+	}
+}
+
+// unsigned txs of examples of BIP143
+const (
+	bip143TxP2WPKH = "0100000002fff7f7881a8099afa6940d42d1e7f6362bec38171ea3edf433541db4e4ad969f0000000000eeffffffef51e1b804cc89d182d279655c3aa89e815b1b309fe287d9b2b55d57b90ec68a0100000000ffffffff02202cb206000000001976a9148280b37df378db99f66f85c95a783a76ac7a6d5988ac9093510d000000001976a9143bde42dbee7e4dbe6a21b2d50ce2f0167faa815988ac11000000"
+	bip143TxP2WSH  = "010000000136641869ca081e70f394c6948e8af409e18b619df2ed74aa106c1ca29787b96e0100000000ffffffff0200e9a435000000001976a914389ffce9cd9ae88dcc0631e88a821ffdbe9bfe2688acc0832f05000000001976a9147480a33f950689af511e6e84c138dbbd3c3ee41588ac00000000"
+
+	// 6-of-6 multisig witness script of the P2SH-P2WSH example
+	bip143WitnessScript = "56210307b8ae49ac90a048e9b53357a2354b3334e9c8bee813ecb98e99a7e07e8c3ba32103b28f0c28bfab54554ae8c658ac5c3e0ce6e79ad336331f78c428dd43eea8449b21034b8113d703413d57761b8b9781957b8c0ac1dfe69f492580ca4195f50376ba4a21033400f6afecb833092a9a21cfdf1ed1376e58c5d1f47de74683123987e967a8f42103a6d48b1131e94ba04d9737d61acdaa1322008af9602b3b14862c07a1789aac162102d8b661b0b3302ee2f162b09e07a55ad5dfbe673a9f01d9f0c19617681024306b56ae"
+)
+
+// native P2WPKH example of BIP143, the second input is P2WPKH
+func TestBip143SignatureHashP2WPKH(t *testing.T) {
+	bridge := &Bridge{Chain: BitcoinChain}
+	p2pkScript := decodeTestHex(t, "2103c9f4836b9a4f77fc0d81f7bcb01b7f1b35916864b9476c241ce9fc198bd25432ac")
+	p2wpkhScript := decodeTestHex(t, "00141d0f172a0ecb48aee1be1f2687d2963ae33f71a1")
+	authoredTx := newTestAuthoredTx(t, bip143TxP2WPKH,
+		[][]byte{p2pkScript, p2wpkhScript},
+		[]btcutil.Amount{625000000, 600000000})
+
+	sigHash, err := bridge.calcSignatureHash(p2wpkhScript, authoredTx, 1)
+	if err != nil {
+		t.Fatalf("calc P2WPKH sighash failed: %v", err)
+	}
+	want := decodeTestHex(t, "c37af31116d1b27caf68aae9e3ac82f1477929014d5b917657d0eb49478cb670")
+	if !bytes.Equal(sigHash, want) {
+		t.Fatalf("wrong P2WPKH sighash, want %x, got %x", want, sigHash)
+	}
+	// script code of P2WPKH is the P2PKH script of the key hash
+	scriptCode := decodeTestHex(t, "76a9141d0f172a0ecb48aee1be1f2687d2963ae33f71a188ac")
+	if manual := calcTestBip143SigHash(authoredTx.Tx, 1, scriptCode, 600000000, 0x01); !bytes.Equal(sigHash, manual) {
+		t.Fatalf("P2WPKH sighash mismatch the spec steps, want %x, got %x", manual, sigHash)
+	}
+
+	// the first input is legacy P2PK
+	legacySigHash, err := bridge.calcSignatureHash(p2pkScript, authoredTx, 0)
+	if err != nil {
+		t.Fatalf("calc legacy sighash failed: %v", err)
+	}
+	wantLegacy, _ := txscript.CalcSignatureHash(p2pkScript, txscript.SigHashAll, authoredTx.Tx, 0)
+	if !bytes.Equal(legacySigHash, wantLegacy) {
+		t.Fatalf("wrong legacy sighash, want %x, got %x", wantLegacy, legacySigHash)
+	}
+}
+
+// P2SH-P2WSH example of BIP143 (SIGHASH_ALL), the sighash is the same when
+// the witness script is spent by native P2WSH, as BIP143 commits to the witness script
+func TestBip143SignatureHashP2WSH(t *testing.T) {
+	bridge := &Bridge{Chain: BitcoinChain}
+	witnessScript := decodeTestHex(t, bip143WitnessScript)
+	scriptHash := sha256.Sum256(witnessScript)
+	p2wshScript := append([]byte{txscript.OP_0, txscript.OP_DATA_32}, scriptHash[:]...)
+	authoredTx := newTestAuthoredTx(t, bip143TxP2WSH,
+		[][]byte{p2wshScript},
+		[]btcutil.Amount{987654321})
+
+	sigHash, err := bridge.calcSignatureHash(witnessScript, authoredTx, 0)
+	if err != nil {
+		t.Fatalf("calc P2WSH sighash failed: %v", err)
+	}
+	want := decodeTestHex(t, "185c0be5263dce5b4bb50a047973c1b6272bfbd0103a89444597dc40b248ee7c")
+	if !bytes.Equal(sigHash, want) {
+		t.Fatalf("wrong P2WSH sighash, want %x, got %x", want, sigHash)
+	}
+	if manual := calcTestBip143SigHash(authoredTx.Tx, 0, witnessScript, 987654321, 0x01); !bytes.Equal(sigHash, manual) {
+		t.Fatalf("P2WSH sighash mismatch the spec steps, want %x, got %x", manual, sigHash)
+	}
+
+	// BIP143 commits to the input value
+	authoredTx.PrevInputValues[0]++
+	if other, _ := bridge.calcSignatureHash(witnessScript, authoredTx, 0); bytes.Equal(other, sigHash) {
+		t.Fatal("P2WSH sighash does not commit to the input value")
+	}
+}
@@ -9,12 +9,13 @@ import (
 // VerifyP2shTransaction verify p2sh tx
 func (b *Bridge) VerifyP2shTransaction(txHash, bindAddress string, allowUnstable bool) (*tokens.TxSwapInfo, error) {
 	swapInfo := &tokens.TxSwapInfo{}
+	swapInfo.PairID = b.PairID  // PairID
 	swapInfo.Hash = txHash      // Hash
 	swapInfo.Bind = bindAddress // Bind
 	if !b.IsSrc {
 		return swapInfo, tokens.ErrBridgeDestinationNotSupported
 	}
-	p2shAddress, _, err := b.GetP2shAddress(bindAddress)
+	_, _, err := b.GetP2shAddress(bindAddress)
 	if err != nil {
 		return swapInfo, tokens.ErrWrongP2shBindAddress
 	}
@@ -36,7 +37,22 @@ func (b *Bridge) VerifyP2shTransaction(txHash, bindAddress string, allowUnstable
 	if txStatus.BlockTime != nil {
 		swapInfo.Timestamp = *txStatus.BlockTime // Timestamp
 	}
-	value, _, rightReceiver := b.GetReceivedValue(tx.Vout, p2shAddress, p2shType)
+	// the bind address may be registered as p2sh or p2wsh address
+	var (
+		p2shAddress   string
+		value         uint64
+		rightReceiver bool
+	)
+	for _, isP2wsh := range b.getP2shAddressTypes() {
+		p2shAddress, _, err = b.GetP2shAddressByType(bindAddress, isP2wsh)
+		if err != nil {
+			return swapInfo, tokens.ErrWrongP2shBindAddress
+		}
+		value, _, rightReceiver = b.GetReceivedValue(tx.Vout, p2shAddress, b.getScriptPubkeyType(p2shAddress))
+		if rightReceiver {
+			break
+		}
+	}
 	if !rightReceiver {
 		return swapInfo, tokens.ErrTxWithWrongReceiver
 	}
@@ -50,7 +66,7 @@ func (b *Bridge) VerifyP2shTransaction(txHash, bindAddress string, allowUnstable
 		return swapInfo, tokens.ErrTxWithWrongSender
 	}
 
-	if !tokens.CheckSwapValue(swapInfo.Value, b.IsSrc) {
+	if !tokens.CheckSwapValue(b.PairID, swapInfo.Value, b.IsSrc) {
 		return swapInfo, tokens.ErrTxWithWrongValue
 	}
 
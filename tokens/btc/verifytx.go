@@ -61,13 +61,13 @@ func (b *Bridge) VerifyMsgHash(rawTx interface{}, msgHash []string, extra interf
 	}
 	for i, preScript := range authoredTx.PrevScripts {
 		sigScript := preScript
-		if txscript.IsPayToScriptHash(sigScript) {
+		if txscript.IsPayToScriptHash(sigScript) || txscript.IsPayToWitnessScriptHash(sigScript) {
 			sigScript, err = b.getRedeemScriptByOutputScrpit(preScript)
 			if err != nil {
 				return err
 			}
 		}
-		sigHash, err := txscript.CalcSignatureHash(sigScript, hashType, authoredTx.Tx, i)
+		sigHash, err := b.calcSignatureHash(sigScript, authoredTx, i)
 		if err != nil {
 			return err
 		}
@@ -88,7 +88,8 @@ func (b *Bridge) VerifyTransaction(txHash string, allowUnstable bool) (*tokens.T
 
 func (b *Bridge) verifySwapinTx(txHash string, allowUnstable bool) (*tokens.TxSwapInfo, error) {
 	swapInfo := &tokens.TxSwapInfo{}
-	swapInfo.Hash = txHash // Hash
+	swapInfo.PairID = b.PairID // PairID
+	swapInfo.Hash = txHash     // Hash
 	if !allowUnstable && !b.checkStable(txHash) {
 		return swapInfo, tokens.ErrTxNotStable
 	}
@@ -108,7 +109,7 @@ func (b *Bridge) verifySwapinTx(txHash string, allowUnstable bool) (*tokens.TxSw
 		swapInfo.Timestamp = *txStatus.BlockTime // Timestamp
 	}
 	depositAddress := b.TokenConfig.DepositAddress
-	value, memoScript, rightReceiver := b.GetReceivedValue(tx.Vout, depositAddress, p2pkhType)
+	value, memoScript, rightReceiver := b.GetReceivedValue(tx.Vout, depositAddress, b.getScriptPubkeyType(depositAddress))
 	if !rightReceiver {
 		return swapInfo, tokens.ErrTxWithWrongReceiver
 	}
@@ -129,7 +130,7 @@ func (b *Bridge) verifySwapinTx(txHash string, allowUnstable bool) (*tokens.TxSw
 		return swapInfo, tokens.ErrTxWithWrongSender
 	}
 
-	if !tokens.CheckSwapValue(swapInfo.Value, b.IsSrc) {
+	if !tokens.CheckSwapValue(b.PairID, swapInfo.Value, b.IsSrc) {
 		return swapInfo, tokens.ErrTxWithWrongValue
 	}
 
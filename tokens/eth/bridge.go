@@ -7,24 +7,71 @@ import (
 
 	"github.com/anyswap/CrossChain-Bridge/log"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
+	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
 	"github.com/anyswap/CrossChain-Bridge/types"
 )
 
 const (
-	netMainnet = "mainnet"
-	netRinkeby = "rinkeby"
-	netCustom  = "custom"
+	netCustom = "custom"
+
+	defSignerType = "London"
 )
 
+// block chains supported before 'ChainID' is configurable,
+// mapping to their default signer types.
+var knownEvmChains = map[string]string{
+	"ETHEREUM": "London",
+	"FUSION":   "EIP155",
+}
+
+// chain ids of the known networks, keyed by known block chain and upper case net id.
+var knownChainIDs = map[string]uint64{
+	"ETHEREUM:MAINNET": 1,
+	"ETHEREUM:RINKEBY": 4,
+	"FUSION:MAINNET":   32659,
+	"FUSION:TESTNET":   46688,
+	"FUSION:DEVNET":    55555,
+}
+
+func getKnownEvmChain(blockChain string) string {
+	blockChain = strings.ToUpper(blockChain)
+	for chain := range knownEvmChains {
+		if strings.HasPrefix(blockChain, chain) {
+			return chain
+		}
+	}
+	return ""
+}
+
+// IsEvmChain is evm chain which is bridged by eth bridge,
+// either a known block chain or configed with 'ChainID'.
+func IsEvmChain(tokenCfg *tokens.TokenConfig) bool {
+	return tokenCfg.ChainID != "" || getKnownEvmChain(tokenCfg.BlockChain) != ""
+}
+
 // Bridge eth bridge
 type Bridge struct {
 	*tokens.CrossChainBridgeBase
-	Signer types.Signer
+	Signer        types.Signer
+	SignerChainID *big.Int
+
+	// scan state, the chain and pool scan states are only used by the
+	// shared scanner of token pairs on the same chain (see getScanBridges)
+	scannedBlocks     *tools.CachedScannedBlocks
+	reorgTracker      *tools.ReorgTracker
+	scannedTxs        *tools.CachedScannedTxs
+	scannedHistoryTxs *tools.CachedScannedTxs
+	quickSyncFinish   bool
 }
 
 // NewCrossChainBridge new bridge
 func NewCrossChainBridge(isSrc bool) *Bridge {
-	return &Bridge{CrossChainBridgeBase: tokens.NewCrossChainBridgeBase(isSrc)}
+	return &Bridge{
+		CrossChainBridgeBase: tokens.NewCrossChainBridgeBase(isSrc),
+		scannedBlocks:        tools.NewCachedScannedBlocks(67),
+		reorgTracker:         tools.NewReorgTracker(maxReorgDepth),
+		scannedTxs:           tools.NewCachedScannedTxs(300),
+	}
 }
 
 // SetTokenAndGateway set token and gateway config
@@ -46,18 +93,14 @@ func (b *Bridge) Init() {
 	b.InitLatestBlockNumber()
 }
 
-// VerifyChainID verify chain id
+// VerifyChainID verify gateway chain id with the configed chain id
 func (b *Bridge) VerifyChainID() {
 	tokenCfg := b.TokenConfig
 	gatewayCfg := b.GatewayConfig
 
-	networkID := strings.ToLower(tokenCfg.NetID)
-
-	switch networkID {
-	case netMainnet, netRinkeby:
-	case netCustom:
-	default:
-		log.Fatalf("unsupported ethereum network: %v", tokenCfg.NetID)
+	wantChainID := b.getConfigedChainID()
+	if wantChainID == nil && !strings.EqualFold(tokenCfg.NetID, netCustom) {
+		log.Fatalf("unsupported network %v of %v, please config 'ChainID'", tokenCfg.NetID, tokenCfg.BlockChain)
 	}
 
 	var (
@@ -66,8 +109,7 @@ func (b *Bridge) VerifyChainID() {
 	)
 
 	for {
-		// call NetworkID instead of ChainID as ChainID may return 0x0 wrongly
-		chainID, err = b.NetworkID()
+		chainID, err = b.getGatewayChainID()
 		if err == nil {
 			break
 		}
@@ -76,27 +118,46 @@ func (b *Bridge) VerifyChainID() {
 		time.Sleep(3 * time.Second)
 	}
 
-	panicMismatchChainID := func() {
-		log.Fatalf("gateway chainID %v is not %v", chainID, tokenCfg.NetID)
+	if wantChainID != nil && chainID.Cmp(wantChainID) != 0 {
+		log.Fatalf("gateway chainID %v is not %v", chainID, wantChainID)
 	}
 
-	switch networkID {
-	case netMainnet:
-		if chainID.Uint64() != 1 {
-			panicMismatchChainID()
-		}
-	case netRinkeby:
-		if chainID.Uint64() != 4 {
-			panicMismatchChainID()
-		}
-	case netCustom:
-	default:
-		log.Fatalf("unsupported ethereum network %v", networkID)
+	signerType := tokenCfg.SignerType
+	if signerType == "" {
+		signerType = knownEvmChains[getKnownEvmChain(tokenCfg.BlockChain)]
 	}
+	if signerType == "" {
+		signerType = defSignerType
+	}
+
+	b.SignerChainID = chainID
+	b.Signer = types.MakeSigner(signerType, chainID)
+
+	log.Info("VerifyChainID succeed", "blockChain", tokenCfg.BlockChain, "networkID", tokenCfg.NetID, "chainID", chainID, "signer", signerType)
+}
 
-	b.Signer = types.MakeSigner("EIP155", chainID)
+// getConfigedChainID get chain id from config, or from the known networks
+func (b *Bridge) getConfigedChainID() *big.Int {
+	tokenCfg := b.TokenConfig
+	if tokenCfg.ChainID != "" {
+		chainID, _ := new(big.Int).SetString(tokenCfg.ChainID, 0)
+		return chainID
+	}
+	key := getKnownEvmChain(tokenCfg.BlockChain) + ":" + strings.ToUpper(tokenCfg.NetID)
+	if chainID, exist := knownChainIDs[key]; exist {
+		return new(big.Int).SetUint64(chainID)
+	}
+	return nil
+}
 
-	log.Info("VerifyChainID succeed", "networkID", networkID, "chainID", chainID)
+// getGatewayChainID call eth_chainId, and fallback to net_version
+// as some nodes return 0x0 wrongly for eth_chainId
+func (b *Bridge) getGatewayChainID() (*big.Int, error) {
+	chainID, err := b.ChainID()
+	if err == nil && chainID.Sign() > 0 {
+		return chainID, nil
+	}
+	return b.NetworkID()
 }
 
 // VerifyTokenCofig verify token config
@@ -117,8 +178,8 @@ func (b *Bridge) VerifyTokenCofig() {
 func (b *Bridge) verifyDecimals() {
 	tokenCfg := b.TokenConfig
 	configedDecimals := *tokenCfg.Decimals
-	switch strings.ToUpper(tokenCfg.Symbol) {
-	case "ETH", "FSN":
+	// native coins of evm chains all have 18 decimals
+	if b.IsSrc && tokenCfg.ContractAddress == "" {
 		if configedDecimals != 18 {
 			log.Fatal("invalid decimals", "configed", configedDecimals, "want", 18)
 		}
@@ -23,7 +23,7 @@ func (b *Bridge) BuildSwapoutTx(from, contract string, extraArgs *tokens.EthExtr
 		return nil, fmt.Errorf("not enough balance, %v < %v", balance, swapoutVal)
 	}
 	token := b.TokenConfig
-	if token != nil && !tokens.CheckSwapValue(swapoutVal, b.IsSrc) {
+	if token != nil && !tokens.CheckSwapValue(b.PairID, swapoutVal, b.IsSrc) {
 		decimals := *token.Decimals
 		minValue := tokens.ToBits(*token.MinimumSwap, decimals)
 		maxValue := tokens.ToBits(*token.MaximumSwap, decimals)
@@ -18,6 +18,9 @@ var (
 	retryRPCInterval = 1 * time.Second
 
 	defReserveGasFee = big.NewInt(1e16) // 0.01 ETH
+
+	defBlockCountFeeHistory    = 20
+	feeHistoryRewardPercentile = 50.0
 )
 
 // BuildRawTransaction build raw tx
@@ -50,6 +53,19 @@ func (b *Bridge) BuildRawTransaction(args *tokens.BuildTxArgs) (rawTx interface{
 			} else {
 				input = []byte(tokens.UnlockMemoPrefix + args.SwapID)
 			}
+		case tokens.RefundType:
+			err = b.buildRefundTxInput(args)
+			if err != nil {
+				return nil, err
+			}
+			input = *args.Input
+		case tokens.CancelType:
+			// cancel the specified nonce by a zero value tx to self
+			if args.Extra == nil || args.Extra.EthExtra == nil || args.Extra.EthExtra.Nonce == nil {
+				return nil, tokens.ErrWrongExtraArgs
+			}
+			args.To = b.TokenConfig.DcrmAddress
+			args.Value = big.NewInt(0)
 		}
 	} else {
 		input = *args.Input
@@ -70,11 +86,20 @@ func (b *Bridge) buildTx(args *tokens.BuildTxArgs, extra *tokens.EthExtraArgs, i
 		nonce    = *extra.Nonce
 		gasLimit = *extra.Gas
 		gasPrice = extra.GasPrice
+
+		isDynamicFeeTx = extra.GasFeeCap != nil
 	)
+	if isDynamicFeeTx {
+		gasPrice = extra.GasFeeCap
+	}
 
 	if args.SwapType == tokens.SwapoutType {
 		if !b.TokenConfig.IsErc20() {
-			value = tokens.CalcSwappedValue(value, false)
+			value = tokens.CalcSwapValue(b.PairID, value, false, args.FeeArgs)
+		}
+	} else if args.SwapType == tokens.RefundType {
+		if b.IsSrc && !b.TokenConfig.IsErc20() {
+			value = tokens.CalcRefundValue(b.PairID, value, true)
 		}
 	}
 
@@ -108,6 +133,9 @@ func (b *Bridge) buildTx(args *tokens.BuildTxArgs, extra *tokens.EthExtraArgs, i
 		return nil, errors.New("not enough coin balance")
 	}
 
+	if isDynamicFeeTx {
+		return types.NewDynamicFeeTransaction(b.SignerChainID, nonce, &to, value, gasLimit, extra.GasTipCap, extra.GasFeeCap, input), nil
+	}
 	return types.NewTransaction(nonce, to, value, gasLimit, gasPrice, input), nil
 }
 
@@ -121,7 +149,14 @@ func (b *Bridge) setDefaults(args *tokens.BuildTxArgs) (extra *tokens.EthExtraAr
 	} else {
 		extra = args.Extra.EthExtra
 	}
-	if extra.GasPrice == nil {
+	useDynamicFeeTx := extra.GasTipCap != nil || extra.GasFeeCap != nil ||
+		(extra.GasPrice == nil && b.TokenConfig.EnableDynamicFeeTx)
+	if useDynamicFeeTx {
+		err = b.setDynamicFeeDefaults(args, extra)
+		if err != nil {
+			return nil, err
+		}
+	} else if extra.GasPrice == nil {
 		extra.GasPrice, err = b.getGasPrice()
 		if err != nil {
 			return nil, err
@@ -135,7 +170,7 @@ func (b *Bridge) setDefaults(args *tokens.BuildTxArgs) (extra *tokens.EthExtraAr
 		}
 	}
 	if extra.Nonce == nil {
-		extra.Nonce, err = b.getAccountNonce(args.From, args.SwapType)
+		extra.Nonce, err = b.getAccountNonce(args.From, &args.SwapInfo)
 		if err != nil {
 			return nil, err
 		}
@@ -147,6 +182,76 @@ func (b *Bridge) setDefaults(args *tokens.BuildTxArgs) (extra *tokens.EthExtraAr
 	return extra, nil
 }
 
+// setDynamicFeeDefaults set gas tip cap and gas fee cap of EIP-1559 tx,
+// gas tip cap is the average reward in fee history (plus percentage),
+// and gas fee cap is the double of next base fee plus gas tip cap.
+func (b *Bridge) setDynamicFeeDefaults(args *tokens.BuildTxArgs, extra *tokens.EthExtraArgs) error {
+	if extra.GasPrice != nil {
+		return tokens.ErrWrongExtraArgs
+	}
+	if extra.GasTipCap == nil || extra.GasFeeCap == nil {
+		gasTipCap, baseFee, err := b.estimateGasTipCapAndBaseFee()
+		if err != nil {
+			return err
+		}
+		if extra.GasTipCap == nil {
+			if args.SwapType != tokens.NoSwapType {
+				addPercent := b.TokenConfig.PlusGasTipCapPercentage
+				if addPercent > 0 {
+					gasTipCap.Mul(gasTipCap, big.NewInt(int64(100+addPercent)))
+					gasTipCap.Div(gasTipCap, big.NewInt(100))
+				}
+			}
+			extra.GasTipCap = gasTipCap
+		}
+		if extra.GasFeeCap == nil {
+			gasFeeCap := new(big.Int).Mul(baseFee, big.NewInt(2))
+			extra.GasFeeCap = gasFeeCap.Add(gasFeeCap, extra.GasTipCap)
+		}
+	}
+	if extra.GasTipCap.Cmp(extra.GasFeeCap) > 0 {
+		return fmt.Errorf("gasTipCap %v is larger than gasFeeCap %v", extra.GasTipCap, extra.GasFeeCap)
+	}
+	return nil
+}
+
+func (b *Bridge) estimateGasTipCapAndBaseFee() (gasTipCap, baseFee *big.Int, err error) {
+	blockCount := b.TokenConfig.BlockCountFeeHistory
+	if blockCount == 0 {
+		blockCount = defBlockCountFeeHistory
+	}
+	var history *types.RPCFeeHistory
+	for i := 0; i < retryRPCCount; i++ {
+		history, err = b.FeeHistory(blockCount, []float64{feeHistoryRewardPercentile})
+		if err == nil {
+			break
+		}
+		time.Sleep(retryRPCInterval)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(history.BaseFee) == 0 {
+		return nil, nil, errors.New("fee history has no base fee")
+	}
+	// the last one is the base fee of the next block
+	baseFee = history.BaseFee[len(history.BaseFee)-1].ToInt()
+
+	gasTipCap = new(big.Int)
+	count := 0
+	for _, rewards := range history.Reward {
+		if len(rewards) == 0 || rewards[0] == nil {
+			continue
+		}
+		gasTipCap.Add(gasTipCap, rewards[0].ToInt())
+		count++
+	}
+	if count > 0 {
+		gasTipCap.Div(gasTipCap, big.NewInt(int64(count)))
+	}
+	return gasTipCap, baseFee, nil
+}
+
 func (b *Bridge) getGasPrice() (price *big.Int, err error) {
 	for i := 0; i < retryRPCCount; i++ {
 		price, err = b.SuggestPrice()
@@ -158,7 +263,7 @@ func (b *Bridge) getGasPrice() (price *big.Int, err error) {
 	return nil, err
 }
 
-func (b *Bridge) getAccountNonce(from string, swapType tokens.SwapType) (nonceptr *uint64, err error) {
+func (b *Bridge) getAccountNonce(from string, swapInfo *tokens.SwapInfo) (nonceptr *uint64, err error) {
 	var nonce uint64
 	for i := 0; i < retryRPCCount; i++ {
 		nonce, err = b.GetPoolNonce(from, "pending")
@@ -171,23 +276,29 @@ func (b *Bridge) getAccountNonce(from string, swapType tokens.SwapType) (noncept
 		return nil, err
 	}
 	if from == b.TokenConfig.DcrmAddress {
-		if swapType != tokens.NoSwapType {
-			nonce = b.AdjustNonce(nonce)
+		if swapInfo.SwapType != tokens.NoSwapType {
+			nonce, err = b.ReserveNonce(swapInfo.SwapID, nonce)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
 	return &nonce, nil
 }
 
-// build input for calling `Swapin(bytes32 txhash, address account, uint256 amount)`
 func (b *Bridge) buildSwapinTxInput(args *tokens.BuildTxArgs) error {
+	return b.buildMintTxInput(args, tokens.CalcSwapValue(b.PairID, args.Value, true, args.FeeArgs))
+}
+
+// build input for calling `Swapin(bytes32 txhash, address account, uint256 amount)`
+func (b *Bridge) buildMintTxInput(args *tokens.BuildTxArgs, amount *big.Int) error {
 	funcHash := getSwapinFuncHash()
 	txHash := common.HexToHash(args.SwapID)
 	address := common.HexToAddress(args.To)
 	if address == (common.Address{}) || !common.IsHexAddress(args.To) {
-		log.Warn("swapin to wrong address", "address", args.To)
-		return errors.New("can not swapin to empty or invalid address")
+		log.Warn(args.SwapType.String()+" to wrong address", "address", args.To)
+		return fmt.Errorf("can not %v to empty or invalid address", args.SwapType.String())
 	}
-	amount := tokens.CalcSwappedValue(args.Value, true)
 
 	input := PackDataWithFuncHash(funcHash, txHash, address, amount)
 	args.Input = &input // input
@@ -199,13 +310,30 @@ func (b *Bridge) buildSwapinTxInput(args *tokens.BuildTxArgs) error {
 }
 
 func (b *Bridge) buildErc20SwapoutTxInput(args *tokens.BuildTxArgs) (err error) {
+	return b.buildErc20TransferTxInput(args, tokens.CalcSwapValue(b.PairID, args.Value, false, args.FeeArgs))
+}
+
+// build refund tx input, the deposit is refunded to its sender on the same chain,
+// swapout is refunded by minting the burned token back.
+func (b *Bridge) buildRefundTxInput(args *tokens.BuildTxArgs) error {
+	if !b.IsSrc {
+		return b.buildMintTxInput(args, tokens.CalcRefundValue(b.PairID, args.Value, false))
+	}
+	if b.TokenConfig.IsErc20() {
+		return b.buildErc20TransferTxInput(args, tokens.CalcRefundValue(b.PairID, args.Value, true))
+	}
+	input := []byte(tokens.RefundMemoPrefix + args.SwapID)
+	args.Input = &input // input
+	return nil
+}
+
+func (b *Bridge) buildErc20TransferTxInput(args *tokens.BuildTxArgs, amount *big.Int) (err error) {
 	funcHash := erc20CodeParts["transfer"]
 	address := common.HexToAddress(args.To)
 	if address == (common.Address{}) || !common.IsHexAddress(args.To) {
-		log.Warn("swapout to wrong address", "address", args.To)
-		return errors.New("can not swapout to empty or invalid address")
+		log.Warn(args.SwapType.String()+" to wrong address", "address", args.To)
+		return fmt.Errorf("can not %v to empty or invalid address", args.SwapType.String())
 	}
-	amount := tokens.CalcSwappedValue(args.Value, false)
 
 	input := PackDataWithFuncHash(funcHash, address, amount)
 	args.Input = &input // input
@@ -223,7 +351,7 @@ func (b *Bridge) buildErc20SwapoutTxInput(args *tokens.BuildTxArgs) (err error)
 		time.Sleep(retryRPCInterval)
 	}
 	if err == nil && balance.Cmp(amount) < 0 {
-		return errors.New("not enough token balance to swapout")
+		return fmt.Errorf("not enough token balance to %v", args.SwapType.String())
 	}
 	return err
 }
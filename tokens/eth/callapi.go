@@ -8,7 +8,7 @@ import (
 	"github.com/anyswap/CrossChain-Bridge/common"
 	"github.com/anyswap/CrossChain-Bridge/common/hexutil"
 	"github.com/anyswap/CrossChain-Bridge/rpc/client"
-	"github.com/anyswap/CrossChain-Bridge/tools/rlp"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
 	"github.com/anyswap/CrossChain-Bridge/types"
 )
 
@@ -74,22 +74,45 @@ func (b *Bridge) GetBlockByNumber(number *big.Int) (*types.RPCBlock, error) {
 	return nil, err
 }
 
-// GetTransactionByHash call eth_getTransactionByHash
+// GetFinalizedBlockNumber call eth_getBlockByNumber with 'finalized' tag
+func (b *Bridge) GetFinalizedBlockNumber() (uint64, error) {
+	gateway := b.GatewayConfig
+	var result *types.RPCBlock
+	var err error
+	for _, apiAddress := range gateway.APIAddress {
+		url := apiAddress
+		err = client.RPCPost(&result, url, "eth_getBlockByNumber", "finalized", false)
+		if err == nil && result != nil && result.Number != nil {
+			return result.Number.ToInt().Uint64(), nil
+		}
+	}
+	if err == nil {
+		err = errors.New("finalized block not found")
+	}
+	return 0, err
+}
+
+// GetTransactionByHash call eth_getTransactionByHash,
+// returns tokens.ErrTxNotFound only if all gateways answer it is not found.
 func (b *Bridge) GetTransactionByHash(txHash string) (*types.RPCTransaction, error) {
 	gateway := b.GatewayConfig
 	var result *types.RPCTransaction
-	var err error
+	var rpcErr error
 	for _, apiAddress := range gateway.APIAddress {
 		url := apiAddress
-		err = client.RPCPost(&result, url, "eth_getTransactionByHash", txHash)
-		if err == nil && result != nil {
+		err := client.RPCPost(&result, url, "eth_getTransactionByHash", txHash)
+		if err != nil {
+			rpcErr = err
+			continue
+		}
+		if result != nil {
 			return result, nil
 		}
 	}
-	if result == nil {
-		return nil, errors.New("tx not found")
+	if rpcErr != nil {
+		return nil, rpcErr
 	}
-	return nil, err
+	return nil, tokens.ErrTxNotFound
 }
 
 // GetPendingTransactions call eth_pendingTransactions
@@ -106,7 +129,18 @@ func (b *Bridge) GetPendingTransactions() (result []*types.RPCTransaction, err e
 }
 
 // GetTransactionReceipt call eth_getTransactionReceipt
+// (or fsn_getTransactionAndReceipt if configed 'ReceiptAPI' is fsn)
 func (b *Bridge) GetTransactionReceipt(txHash string) (*types.RPCTxReceipt, error) {
+	if b.TokenConfig.ReceiptAPI == tokens.ReceiptAPIFsn {
+		result, err := b.GetTransactionAndReceipt(txHash)
+		if err != nil {
+			return nil, err
+		}
+		if result.Receipt == nil {
+			return nil, errors.New("tx receipt not found")
+		}
+		return result.Receipt, nil
+	}
 	gateway := b.GatewayConfig
 	var result *types.RPCTxReceipt
 	var err error
@@ -123,6 +157,24 @@ func (b *Bridge) GetTransactionReceipt(txHash string) (*types.RPCTxReceipt, erro
 	return nil, err
 }
 
+// GetTransactionAndReceipt call fsn_getTransactionAndReceipt (fsn special)
+func (b *Bridge) GetTransactionAndReceipt(txHash string) (*types.RPCTxAndReceipt, error) {
+	gateway := b.GatewayConfig
+	var result *types.RPCTxAndReceipt
+	var err error
+	for _, apiAddress := range gateway.APIAddress {
+		url := apiAddress
+		err = client.RPCPost(&result, url, "fsn_getTransactionAndReceipt", txHash)
+		if err == nil && result != nil {
+			return result, nil
+		}
+	}
+	if result == nil {
+		return nil, errors.New("tx and receipt not found")
+	}
+	return nil, err
+}
+
 // GetContractLogs get contract logs
 func (b *Bridge) GetContractLogs(contractAddresses []common.Address, logTopics [][]common.Hash, blockHeight uint64) ([]*types.RPCLog, error) {
 	height := new(big.Int).SetUint64(blockHeight)
@@ -184,9 +236,24 @@ func (b *Bridge) SuggestPrice() (*big.Int, error) {
 	return nil, err
 }
 
+// FeeHistory call eth_feeHistory
+func (b *Bridge) FeeHistory(blockCount int, rewardPercentiles []float64) (*types.RPCFeeHistory, error) {
+	gateway := b.GatewayConfig
+	var result types.RPCFeeHistory
+	var err error
+	for _, apiAddress := range gateway.APIAddress {
+		url := apiAddress
+		err = client.RPCPost(&result, url, "eth_feeHistory", hexutil.Uint(blockCount), "latest", rewardPercentiles)
+		if err == nil {
+			return &result, nil
+		}
+	}
+	return nil, err
+}
+
 // SendSignedTransaction call eth_sendRawTransaction
 func (b *Bridge) SendSignedTransaction(tx *types.Transaction) error {
-	data, err := rlp.EncodeToBytes(tx)
+	data, err := tx.MarshalBinary()
 	if err != nil {
 		return err
 	}
@@ -1,9 +1,43 @@
 package eth
 
 import (
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
 	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
 )
 
+// token pairs on the same chain and endpoint share one chain scanner and
+// one pool scanner, which are run by the first bridge with scan enabled,
+// and the scanned txs are dispatched to all of them.
+func (b *Bridge) getScanBridges() []*Bridge {
+	var bridges []*Bridge
+	for _, bridge := range tokens.GetBridgesOfChain(b) {
+		if ethBridge, ok := bridge.(*Bridge); ok && ethBridge.TokenConfig.EnableScan {
+			bridges = append(bridges, ethBridge)
+		}
+	}
+	if len(bridges) == 0 {
+		bridges = append(bridges, b)
+	}
+	return bridges
+}
+
+// isChainScanner is the bridge running the shared scanners of its chain
+func (b *Bridge) isChainScanner(scanName string) bool {
+	scanner := b.getScanBridges()[0]
+	if scanner != b {
+		log.Info("["+scanName+"] share scanner of token pair", "pairID", b.PairID, "scannerPairID", scanner.PairID, "isSrc", b.IsSrc)
+		return false
+	}
+	return true
+}
+
+func (b *Bridge) dispatchTransaction(txid string) {
+	for _, bridge := range b.getScanBridges() {
+		bridge.processTransaction(txid)
+	}
+}
+
 func (b *Bridge) processTransaction(txid string) {
 	if b.IsSrc {
 		_ = b.processSwapin(txid)
@@ -16,14 +50,14 @@ func (b *Bridge) processSwapin(txid string) error {
 	if tools.IsSwapinExist(txid) {
 		return nil
 	}
-	swapInfo, err := b.VerifyTransaction(txid, true)
-	return tools.RegisterSwapin(txid, swapInfo.Bind, err)
+	swapInfo, err := tokens.VerifyTransactionOfPair(b, txid, true)
+	return tools.RegisterSwapin(b.PairID, txid, swapInfo.Bind, err)
 }
 
 func (b *Bridge) processSwapout(txid string) error {
 	if tools.IsSwapoutExist(txid) {
 		return nil
 	}
-	swapInfo, err := b.VerifyTransaction(txid, true)
-	return tools.RegisterSwapout(txid, swapInfo.Bind, err)
+	swapInfo, err := tokens.VerifyTransactionOfPair(b, txid, true)
+	return tools.RegisterSwapout(b.PairID, txid, swapInfo.Bind, err)
 }
@@ -0,0 +1,85 @@
+package eth
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+// GetReplaceExtraArgs impl tokens.ReplaceableBridge,
+// keep the nonce of the stuck swap tx and raise its gas price
+// (or gas tip cap and gas fee cap of dynamic fee tx).
+func (b *Bridge) GetReplaceExtraArgs(swapTx string, swapNonce, plusFeePercentage uint64) (*tokens.AllExtras, error) {
+	var (
+		oldGasPrice  *big.Int
+		oldGasTipCap *big.Int
+		oldGasFeeCap *big.Int
+
+		isDynamicFeeTx = b.TokenConfig.EnableDynamicFeeTx
+		extra          = &tokens.EthExtraArgs{Nonce: &swapNonce}
+	)
+
+	tx, err := b.GetTransactionByHash(swapTx)
+	if err == nil {
+		if tx.BlockNumber != nil {
+			return nil, tokens.ErrSwapTxAlreadyMined
+		}
+		if tx.AccountNonce != nil && uint64(*tx.AccountNonce) != swapNonce {
+			return nil, fmt.Errorf("swap tx %v nonce %v mismatch with %v", swapTx, uint64(*tx.AccountNonce), swapNonce)
+		}
+		if tx.GasLimit != nil {
+			gas := uint64(*tx.GasLimit)
+			extra.Gas = &gas
+		}
+		isDynamicFeeTx = tx.GasFeeCap != nil && tx.GasTipCap != nil
+		if isDynamicFeeTx {
+			oldGasTipCap = tx.GasTipCap.ToInt()
+			oldGasFeeCap = tx.GasFeeCap.ToInt()
+		} else if tx.Price != nil {
+			oldGasPrice = tx.Price.ToInt()
+		}
+	} else {
+		log.Warn("get stuck swap tx failed, replace it with estimated fee", "swaptx", swapTx, "err", err)
+	}
+
+	if isDynamicFeeTx {
+		gasTipCap, baseFee, errf := b.estimateGasTipCapAndBaseFee()
+		if errf != nil {
+			return nil, errf
+		}
+		if oldGasTipCap == nil {
+			oldGasTipCap = gasTipCap
+		}
+		extra.GasTipCap = maxBigInt(addPercentage(oldGasTipCap, plusFeePercentage), gasTipCap)
+		gasFeeCap := new(big.Int).Mul(baseFee, big.NewInt(2))
+		gasFeeCap.Add(gasFeeCap, extra.GasTipCap)
+		if oldGasFeeCap == nil {
+			oldGasFeeCap = gasFeeCap
+		}
+		extra.GasFeeCap = maxBigInt(addPercentage(oldGasFeeCap, plusFeePercentage), gasFeeCap)
+	} else {
+		gasPrice, errf := b.getGasPrice()
+		if errf != nil {
+			return nil, errf
+		}
+		if oldGasPrice == nil {
+			oldGasPrice = gasPrice
+		}
+		extra.GasPrice = maxBigInt(addPercentage(oldGasPrice, plusFeePercentage), gasPrice)
+	}
+	return &tokens.AllExtras{EthExtra: extra}, nil
+}
+
+func addPercentage(value *big.Int, percentage uint64) *big.Int {
+	result := new(big.Int).Mul(value, new(big.Int).SetUint64(100+percentage))
+	return result.Div(result, big.NewInt(100))
+}
+
+func maxBigInt(x, y *big.Int) *big.Int {
+	if x.Cmp(y) >= 0 {
+		return x
+	}
+	return y
+}
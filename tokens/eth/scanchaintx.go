@@ -5,25 +5,44 @@ import (
 	"fmt"
 	"math/big"
 	"sync"
-	"time"
 
+	"github.com/anyswap/CrossChain-Bridge/common"
 	"github.com/anyswap/CrossChain-Bridge/log"
 	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
 )
 
 var (
-	scannedBlocks = tools.NewCachedScannedBlocks(67)
-
-	quickSyncFinish  bool
 	quickSyncWorkers = uint64(4)
+	maxReorgDepth    = uint64(128)
 )
 
-func (b *Bridge) getStartAndLatestHeight() (start, latest uint64) {
-	startHeight := tools.GetLatestScanHeight(b.IsSrc)
+// the scan state is updated for all token pairs sharing the chain scanner,
+// and the scanner starts from the lowest scanned height of them.
+func (b *Bridge) getLatestScanHeight() (height uint64) {
+	for _, bridge := range b.getScanBridges() {
+		scanned := tools.GetLatestScanHeight(bridge.PairID, bridge.IsSrc)
+		if scanned != 0 && (height == 0 || scanned < height) {
+			height = scanned
+		}
+	}
+	return height
+}
+
+func (b *Bridge) updateLatestScanInfo(height uint64) {
+	for _, bridge := range b.getScanBridges() {
+		_ = tools.UpdateLatestScanInfo(bridge.PairID, bridge.IsSrc, height)
+	}
+}
+
+func (b *Bridge) getStartAndLatestHeight(ctx context.Context) (start, latest uint64, err error) {
+	startHeight := b.getLatestScanHeight()
 	confirmations := *b.TokenConfig.Confirmations
 	initialHeight := b.TokenConfig.InitialHeight
 
-	latest = tools.LoopGetLatestBlockNumber(b)
+	latest, err = tools.LoopGetLatestBlockNumber(ctx, b)
+	if err != nil {
+		return 0, 0, err
+	}
 
 	switch {
 	case startHeight != 0:
@@ -41,20 +60,28 @@ func (b *Bridge) getStartAndLatestHeight() (start, latest uint64) {
 	if start+maxScanHeight < latest {
 		start = latest - maxScanHeight
 	}
-	return start, latest
+	return start, latest, nil
 }
 
 // StartChainTransactionScanJob scan job
-func (b *Bridge) StartChainTransactionScanJob() {
+func (b *Bridge) StartChainTransactionScanJob(ctx context.Context) {
+	if !b.isChainScanner("scanchain") {
+		return
+	}
 	chainName := b.TokenConfig.BlockChain
 	log.Infof("[scanchain] start %v scan chain job", chainName)
+	defer log.Infof("[scanchain] stop %v scan chain job", chainName)
 
-	start, latest := b.getStartAndLatestHeight()
-	_ = tools.UpdateLatestScanInfo(b.IsSrc, start)
+	start, latest, err := b.getStartAndLatestHeight(ctx)
+	if err != nil {
+		return
+	}
+	b.updateLatestScanInfo(start)
+	tools.SeedReorgTracker(b.reorgTracker, b.PairID, b.IsSrc, b.getBlockHashOf, b.getBlockTxsOf)
 	log.Infof("[scanchain] start %v scan chain loop from %v latest=%v", chainName, start, latest)
 
 	if latest > start {
-		go b.quickSync(context.Background(), nil, start, latest+1)
+		go b.quickSync(ctx, nil, start, latest+1)
 	}
 
 	stable := latest
@@ -64,7 +91,10 @@ func (b *Bridge) StartChainTransactionScanJob() {
 	var quickSyncCtx context.Context
 	var quickSyncCancel context.CancelFunc
 	for {
-		latest = tools.LoopGetLatestBlockNumber(b)
+		latest, err = tools.LoopGetLatestBlockNumber(ctx, b)
+		if err != nil {
+			return
+		}
 		if stable+maxScanHeight < latest {
 			if quickSyncCancel != nil {
 				select {
@@ -74,37 +104,87 @@ func (b *Bridge) StartChainTransactionScanJob() {
 					quickSyncCancel()
 				}
 			}
-			quickSyncCtx, quickSyncCancel = context.WithCancel(context.Background())
+			quickSyncCtx, quickSyncCancel = context.WithCancel(ctx)
 			go b.quickSync(quickSyncCtx, quickSyncCancel, stable+1, latest)
 			stable = latest
 		}
 		for h := stable; h <= latest; {
+			if ctx.Err() != nil {
+				return
+			}
 			block, err := b.GetBlockByNumber(new(big.Int).SetUint64(h))
 			if err != nil {
 				log.Error(errorSubject, "height", h, "err", err)
-				time.Sleep(retryIntervalInScanJob)
+				common.SleepWithContext(ctx, retryIntervalInScanJob)
 				continue
 			}
 			blockHash := block.Hash.String()
-			if scannedBlocks.IsBlockScanned(blockHash) {
+			if b.scannedBlocks.IsBlockScanned(blockHash) {
 				h++
 				continue
 			}
-			for _, tx := range block.Transactions {
-				b.processTransaction(tx.String())
+			txs := make([]string, len(block.Transactions))
+			for i, tx := range block.Transactions {
+				txs[i] = tx.String()
 			}
-			scannedBlocks.CacheScannedBlock(blockHash, h)
+			orphaned, err := b.reorgTracker.AddBlock(&tools.ScannedBlock{
+				Height:     h,
+				Hash:       blockHash,
+				ParentHash: block.ParentHash.String(),
+				Txs:        txs,
+			}, b.getBlockHashOf)
+			if err != nil {
+				log.Error(errorSubject, "height", h, "err", err)
+				common.SleepWithContext(ctx, retryIntervalInScanJob)
+				continue
+			}
+			if len(orphaned) > 0 {
+				tools.FillOrphanedBlockTxs(orphaned, b.getBlockTxsOf)
+				tools.HandleOrphanedBlocks(b.IsSrc, orphaned)
+				// rescan the canonical blocks from the fork point
+				if forkHeight := orphaned[0].Height; forkHeight < h {
+					h = forkHeight
+					continue
+				}
+			}
+			for _, txid := range txs {
+				b.dispatchTransaction(txid)
+			}
+			b.scannedBlocks.CacheScannedBlock(blockHash, h)
 			log.Info(scanSubject, "blockHash", blockHash, "height", h, "txs", len(block.Transactions))
 			h++
 		}
 		stable = latest
-		if quickSyncFinish {
-			_ = tools.UpdateLatestScanInfo(b.IsSrc, stable)
+		if b.quickSyncFinish {
+			b.updateLatestScanInfo(stable)
+		}
+		tools.SaveReorgTracker(b.reorgTracker, b.PairID, b.IsSrc)
+		if !common.SleepWithContext(ctx, restIntervalInScanJob) {
+			return
 		}
-		time.Sleep(restIntervalInScanJob)
 	}
 }
 
+func (b *Bridge) getBlockHashOf(height uint64) (string, error) {
+	block, err := b.GetBlockByNumber(new(big.Int).SetUint64(height))
+	if err != nil {
+		return "", err
+	}
+	return block.Hash.String(), nil
+}
+
+func (b *Bridge) getBlockTxsOf(blockHash string) ([]string, error) {
+	block, err := b.GetBlockByHash(blockHash)
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]string, len(block.Transactions))
+	for i, tx := range block.Transactions {
+		txs[i] = tx.String()
+	}
+	return txs, nil
+}
+
 func (b *Bridge) quickSync(ctx context.Context, cancel context.CancelFunc, start, end uint64) {
 	chainName := b.TokenConfig.BlockChain
 	log.Printf("[scanchain] begin %v syncRange job. start=%v end=%v", chainName, start, end)
@@ -127,8 +207,8 @@ func (b *Bridge) quickSync(ctx context.Context, cancel context.CancelFunc, start
 	wg.Wait()
 	if cancel != nil {
 		cancel()
-	} else {
-		quickSyncFinish = true
+	} else if ctx.Err() == nil {
+		b.quickSyncFinish = true
 	}
 	log.Printf("[scanchain] finish %v syncRange job. start=%v end=%v", chainName, start, end)
 }
@@ -139,19 +219,17 @@ func (b *Bridge) quickSyncRange(ctx context.Context, idx, start, end uint64, wg
 	log.Printf("[scanchain] id=%v begin %v syncRange start=%v end=%v", idx, chainName, start, end)
 
 	for h := start; h < end; {
-		select {
-		case <-ctx.Done():
+		if ctx.Err() != nil {
 			break
-		default:
 		}
 		block, err := b.GetBlockByNumber(new(big.Int).SetUint64(h))
 		if err != nil {
 			log.Errorf("[scanchain] id=%v get %v block failed at height %v. err=%v", idx, chainName, h, err)
-			time.Sleep(retryIntervalInScanJob)
+			common.SleepWithContext(ctx, retryIntervalInScanJob)
 			continue
 		}
 		for _, tx := range block.Transactions {
-			b.processTransaction(tx.String())
+			b.dispatchTransaction(tx.String())
 		}
 		log.Debugf("[scanchain] id=%v scanned %v block, height=%v hash=%v txs=%v", idx, chainName, h, block.Hash.String(), len(block.Transactions))
 		h++
@@ -1,39 +1,46 @@
 package eth
 
 import (
+	"context"
 	"fmt"
-	"time"
 
+	"github.com/anyswap/CrossChain-Bridge/common"
 	"github.com/anyswap/CrossChain-Bridge/log"
-	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
-)
-
-var (
-	scannedTxs = tools.NewCachedScannedTxs(300)
 )
 
 // StartPoolTransactionScanJob scan job
-func (b *Bridge) StartPoolTransactionScanJob() {
+func (b *Bridge) StartPoolTransactionScanJob(ctx context.Context) {
+	if !b.isChainScanner("scanpool") {
+		return
+	}
 	chainName := b.TokenConfig.BlockChain
 	log.Infof("[scanpool] start scan %v tx pool job", chainName)
+	defer log.Infof("[scanpool] stop scan %v tx pool job", chainName)
 	errorSubject := fmt.Sprintf("[scanpool] get %v pool txs error", chainName)
 	scanSubject := fmt.Sprintf("[scanpool] scanned %v tx", chainName)
 	for {
 		txs, err := b.GetPendingTransactions()
 		if err != nil {
 			log.Error(errorSubject, "err", err)
-			time.Sleep(retryIntervalInScanJob)
+			if !common.SleepWithContext(ctx, retryIntervalInScanJob) {
+				return
+			}
 			continue
 		}
 		for _, tx := range txs {
+			if ctx.Err() != nil {
+				return
+			}
 			txid := tx.Hash.String()
-			if scannedTxs.IsTxScanned(txid) {
+			if b.scannedTxs.IsTxScanned(txid) {
 				continue
 			}
 			log.Info(scanSubject, "txid", txid)
-			b.processTransaction(txid)
-			scannedTxs.CacheScannedTx(txid)
+			b.dispatchTransaction(txid)
+			b.scannedTxs.CacheScannedTx(txid)
+		}
+		if !common.SleepWithContext(ctx, restIntervalInScanJob) {
+			return
 		}
-		time.Sleep(restIntervalInScanJob)
 	}
 }
@@ -1,6 +1,7 @@
 package eth
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
@@ -18,16 +19,15 @@ var (
 	restIntervalInScanJob  = 3 * time.Second
 
 	quickSyncHistoryWorkers = uint64(4)
-
-	scannedHistoryTxs *tools.CachedScannedTxs
 )
 
 // StartSwapHistoryScanJob scan job
-func (b *Bridge) StartSwapHistoryScanJob() {
+func (b *Bridge) StartSwapHistoryScanJob(ctx context.Context) {
 	if b.TokenConfig.ContractAddress == "" {
 		return
 	}
 	log.Infof("[swaphistory] start scan %v swap history job", b.TokenConfig.BlockChain)
+	defer log.Infof("[swaphistory] stop scan %v swap history job", b.TokenConfig.BlockChain)
 
 	isProcessed := func(txid string) bool {
 		if b.IsSrc {
@@ -36,9 +36,9 @@ func (b *Bridge) StartSwapHistoryScanJob() {
 		return tools.IsSwapoutExist(txid)
 	}
 
-	go b.scanFirstLoop(isProcessed)
+	go b.scanFirstLoop(ctx, isProcessed)
 
-	b.scanTransactionHistory(isProcessed)
+	b.scanTransactionHistory(ctx, isProcessed)
 }
 
 func (b *Bridge) getSwapLogs(blockHeight uint64) ([]*types.RPCLog, error) {
@@ -52,9 +52,12 @@ func (b *Bridge) getSwapLogs(blockHeight uint64) ([]*types.RPCLog, error) {
 	return b.GetContractLogs(contractAddresses, logTopics, blockHeight)
 }
 
-func (b *Bridge) scanFirstLoop(isProcessed func(string) bool) {
+func (b *Bridge) scanFirstLoop(ctx context.Context, isProcessed func(string) bool) {
 	// first loop process all tx history no matter whether processed before
-	latest := tools.LoopGetLatestBlockNumber(b)
+	latest, err := tools.LoopGetLatestBlockNumber(ctx, b)
+	if err != nil {
+		return
+	}
 	minHeight := b.TokenConfig.InitialHeight
 	if minHeight+maxFirstScanHeight < latest {
 		minHeight = latest - maxFirstScanHeight
@@ -62,10 +65,13 @@ func (b *Bridge) scanFirstLoop(isProcessed func(string) bool) {
 	chainName := b.TokenConfig.BlockChain
 	log.Infof("[scanFirstLoop] start %v first scan loop to min height %v", chainName, minHeight)
 	for height := latest; height >= minHeight; {
+		if ctx.Err() != nil {
+			return
+		}
 		logs, err := b.getSwapLogs(height)
 		if err != nil {
 			log.Errorf("[scanFirstLoop] get %v swap logs error. height=%v err=%v", chainName, height, err)
-			time.Sleep(retryIntervalInScanJob)
+			common.SleepWithContext(ctx, retryIntervalInScanJob)
 			continue
 		}
 		for _, swaplog := range logs {
@@ -85,38 +91,47 @@ func (b *Bridge) scanFirstLoop(isProcessed func(string) bool) {
 	log.Infof("[scanFirstLoop] finish %v first scan loop to min height %v", chainName, minHeight)
 }
 
-func (b *Bridge) scanTransactionHistory(isProcessed func(string) bool) {
+func (b *Bridge) scanTransactionHistory(ctx context.Context, isProcessed func(string) bool) {
 	chainName := b.TokenConfig.BlockChain
-	latest := tools.LoopGetLatestBlockNumber(b)
+	latest, err := tools.LoopGetLatestBlockNumber(ctx, b)
+	if err != nil {
+		return
+	}
 	minHeight := b.TokenConfig.InitialHeight
 	if minHeight+maxScanHeight < latest {
 		minHeight = latest - maxScanHeight
 	}
 	log.Infof("[scanhistory] start %v scan swap history loop from height %v", chainName, minHeight)
 	if latest > minHeight {
-		go b.quickSyncHistory(minHeight, latest+1)
+		go b.quickSyncHistory(ctx, minHeight, latest+1)
 	}
 
-	scannedHistoryTxs = tools.NewCachedScannedTxs(500)
+	b.scannedHistoryTxs = tools.NewCachedScannedTxs(500)
 	stable := latest
 	errorSubject := fmt.Sprintf("[scanhistory] get %v swap logs failed", chainName)
 	scanSubject := fmt.Sprintf("[scanhistory] scanned %v block", chainName)
 	for {
-		latest := tools.LoopGetLatestBlockNumber(b)
+		latest, err := tools.LoopGetLatestBlockNumber(ctx, b)
+		if err != nil {
+			return
+		}
 		for h := stable; h <= latest; {
+			if ctx.Err() != nil {
+				return
+			}
 			logs, err := b.getSwapLogs(h)
 			if err != nil {
 				log.Error(errorSubject, "height", h, "err", err)
-				time.Sleep(retryIntervalInScanJob)
+				common.SleepWithContext(ctx, retryIntervalInScanJob)
 				continue
 			}
 			for _, swaplog := range logs {
 				txid := swaplog.TxHash.String()
-				if scannedHistoryTxs.IsTxScanned(txid) || isProcessed(txid) {
+				if b.scannedHistoryTxs.IsTxScanned(txid) || isProcessed(txid) {
 					continue
 				}
 				b.processTransaction(txid)
-				scannedHistoryTxs.CacheScannedTx(txid)
+				b.scannedHistoryTxs.CacheScannedTx(txid)
 			}
 			if h != stable {
 				log.Info(scanSubject, "height", h)
@@ -124,11 +139,13 @@ func (b *Bridge) scanTransactionHistory(isProcessed func(string) bool) {
 			h++
 		}
 		stable = latest
-		time.Sleep(restIntervalInScanJob)
+		if !common.SleepWithContext(ctx, restIntervalInScanJob) {
+			return
+		}
 	}
 }
 
-func (b *Bridge) quickSyncHistory(start, end uint64) {
+func (b *Bridge) quickSyncHistory(ctx context.Context, start, end uint64) {
 	chainName := b.TokenConfig.BlockChain
 	log.Printf("[scanhistory] begin %v syncRange job. start=%v end=%v", chainName, start, end)
 	count := end - start
@@ -145,22 +162,25 @@ func (b *Bridge) quickSyncHistory(start, end uint64) {
 		if i+1 == workers {
 			wend = end + 1
 		}
-		go b.quickSyncHistoryRange(i+1, wstt, wend, wg)
+		go b.quickSyncHistoryRange(ctx, i+1, wstt, wend, wg)
 	}
 	wg.Wait()
 	log.Printf("[scanhistory] finish %v syncRange job. start=%v end=%v", chainName, start, end)
 }
 
-func (b *Bridge) quickSyncHistoryRange(idx, start, end uint64, wg *sync.WaitGroup) {
+func (b *Bridge) quickSyncHistoryRange(ctx context.Context, idx, start, end uint64, wg *sync.WaitGroup) {
 	defer wg.Done()
 	chainName := b.TokenConfig.BlockChain
 	log.Printf("[scanhistory] id=%v begin %v syncRange start=%v end=%v", idx, chainName, start, end)
 
 	for h := start; h < end; {
+		if ctx.Err() != nil {
+			break
+		}
 		logs, err := b.getSwapLogs(h)
 		if err != nil {
 			log.Errorf("[scanhistory] id=%v get %v swap logs at height %v failed. err=%v", idx, chainName, h, err)
-			time.Sleep(retryIntervalInScanJob)
+			common.SleepWithContext(ctx, retryIntervalInScanJob)
 			continue
 		}
 		for _, swaplog := range logs {
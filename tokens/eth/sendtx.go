@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/anyswap/CrossChain-Bridge/common/hexutil"
 	"github.com/anyswap/CrossChain-Bridge/log"
 	"github.com/anyswap/CrossChain-Bridge/types"
 )
@@ -25,3 +26,30 @@ func (b *Bridge) SendTransaction(signedTx interface{}) (txHash string, err error
 	log.Trace("SendTransaction success", "raw", tx.RawStr())
 	return txHash, nil
 }
+
+// EncodeSignedTx impl tokens.SignedTxEncoder
+func (b *Bridge) EncodeSignedTx(signedTx interface{}) (rawTx string, err error) {
+	tx, ok := signedTx.(*types.Transaction)
+	if !ok {
+		return "", errors.New("wrong signed transaction type")
+	}
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(data), nil
+}
+
+// DecodeSignedTx impl tokens.SignedTxEncoder
+func (b *Bridge) DecodeSignedTx(rawTx string) (signedTx interface{}, err error) {
+	data, err := hexutil.Decode(rawTx)
+	if err != nil {
+		return nil, err
+	}
+	tx := new(types.Transaction)
+	err = tx.UnmarshalBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -20,7 +20,8 @@ func (b *Bridge) verifySwapoutTx(txHash string, allowUnstable bool) (*tokens.TxS
 
 func (b *Bridge) verifySwapoutTxStable(txHash string) (*tokens.TxSwapInfo, error) {
 	swapInfo := &tokens.TxSwapInfo{}
-	swapInfo.Hash = txHash // Hash
+	swapInfo.PairID = b.PairID // PairID
+	swapInfo.Hash = txHash     // Hash
 	token := b.TokenConfig
 
 	txStatus := b.GetTransactionStatus(txHash)
@@ -59,7 +60,7 @@ func (b *Bridge) verifySwapoutTxStable(txHash string) (*tokens.TxSwapInfo, error
 	}
 	swapInfo.Value = value // Value
 
-	if !tokens.CheckSwapValue(swapInfo.Value, b.IsSrc) {
+	if !tokens.CheckSwapValue(b.PairID, swapInfo.Value, b.IsSrc) {
 		return swapInfo, tokens.ErrTxWithWrongValue
 	}
 
@@ -74,7 +75,8 @@ func (b *Bridge) verifySwapoutTxStable(txHash string) (*tokens.TxSwapInfo, error
 
 func (b *Bridge) verifySwapoutTxUnstable(txHash string) (*tokens.TxSwapInfo, error) {
 	swapInfo := &tokens.TxSwapInfo{}
-	swapInfo.Hash = txHash // Hash
+	swapInfo.PairID = b.PairID // PairID
+	swapInfo.Hash = txHash     // Hash
 	tx, err := b.GetTransactionByHash(txHash)
 	if err != nil {
 		log.Debug("[verifySwapout] "+b.TokenConfig.BlockChain+" Bridge::GetTransaction fail", "tx", txHash, "err", err)
@@ -107,7 +109,7 @@ func (b *Bridge) verifySwapoutTxUnstable(txHash string) (*tokens.TxSwapInfo, err
 	}
 	swapInfo.Value = value // Value
 
-	if !tokens.CheckSwapValue(swapInfo.Value, b.IsSrc) {
+	if !tokens.CheckSwapValue(b.PairID, swapInfo.Value, b.IsSrc) {
 		return swapInfo, tokens.ErrTxWithWrongValue
 	}
 
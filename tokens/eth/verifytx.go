@@ -43,11 +43,23 @@ func (b *Bridge) GetTransactionStatus(txHash string) *tokens.TxStatus {
 		} else {
 			log.Debug("GetLatestBlockNumber fail", "err", err)
 		}
+		if b.TokenConfig.ConfirmationPolicy == tokens.ConfirmationPolicyFinalized && !b.isBlockFinalized(txStatus.BlockHeight) {
+			txStatus.Confirmations = 0
+		}
 	}
 	txStatus.Receipt = txr
 	return &txStatus
 }
 
+func (b *Bridge) isBlockFinalized(height uint64) bool {
+	finalized, err := b.GetFinalizedBlockNumber()
+	if err != nil {
+		log.Debug("GetFinalizedBlockNumber fail", "err", err)
+		return false
+	}
+	return height <= finalized
+}
+
 // VerifyMsgHash verify msg hash
 func (b *Bridge) VerifyMsgHash(rawTx interface{}, msgHashes []string, extra interface{}) error {
 	tx, ok := rawTx.(*types.Transaction)
@@ -57,6 +69,10 @@ func (b *Bridge) VerifyMsgHash(rawTx interface{}, msgHashes []string, extra inte
 	if len(msgHashes) != 1 {
 		return tokens.ErrWrongCountOfMsgHashes
 	}
+	err := b.verifyTxFeeArgs(tx, extra)
+	if err != nil {
+		return err
+	}
 	msgHash := msgHashes[0]
 	signer := b.Signer
 	sigHash := signer.Hash(tx)
@@ -66,6 +82,37 @@ func (b *Bridge) VerifyMsgHash(rawTx interface{}, msgHashes []string, extra inte
 	return nil
 }
 
+// verifyTxFeeArgs verify the fee fields of tx are the same as in extra args,
+// so that legacy and EIP-1559 dynamic fee tx can not be mixed up.
+func (b *Bridge) verifyTxFeeArgs(tx *types.Transaction, extra interface{}) error {
+	allExtras, ok := extra.(*tokens.AllExtras)
+	if !ok || allExtras == nil || allExtras.EthExtra == nil {
+		return nil
+	}
+	ethExtra := allExtras.EthExtra
+	if tx.Type() != types.DynamicFeeTxType {
+		if ethExtra.GasTipCap != nil || ethExtra.GasFeeCap != nil {
+			return tokens.ErrWrongExtraArgs
+		}
+		if ethExtra.GasPrice != nil && ethExtra.GasPrice.Cmp(tx.GasPrice()) != 0 {
+			return tokens.ErrWrongExtraArgs
+		}
+		return nil
+	}
+	if ethExtra.GasPrice != nil || ethExtra.GasTipCap == nil || ethExtra.GasFeeCap == nil {
+		return tokens.ErrWrongExtraArgs
+	}
+	if ethExtra.GasTipCap.Cmp(tx.GasTipCap()) != 0 ||
+		ethExtra.GasFeeCap.Cmp(tx.GasFeeCap()) != 0 ||
+		tx.GasTipCap().Cmp(tx.GasFeeCap()) > 0 {
+		return tokens.ErrWrongExtraArgs
+	}
+	if b.SignerChainID == nil || tx.ChainID().Cmp(b.SignerChainID) != 0 {
+		return types.ErrInvalidChainID
+	}
+	return nil
+}
+
 // VerifyTransaction impl
 func (b *Bridge) VerifyTransaction(txHash string, allowUnstable bool) (*tokens.TxSwapInfo, error) {
 	if !b.IsSrc {
@@ -80,7 +127,8 @@ func (b *Bridge) verifySwapinTx(txHash string, allowUnstable bool) (*tokens.TxSw
 	}
 
 	swapInfo := &tokens.TxSwapInfo{}
-	swapInfo.Hash = txHash // Hash
+	swapInfo.PairID = b.PairID // PairID
+	swapInfo.Hash = txHash     // Hash
 	token := b.TokenConfig
 
 	tx, err := b.GetTransactionByHash(txHash)
@@ -124,7 +172,7 @@ func (b *Bridge) verifySwapinTx(txHash string, allowUnstable bool) (*tokens.TxSw
 		return swapInfo, tokens.ErrTxWithWrongSender
 	}
 
-	if !tokens.CheckSwapValue(swapInfo.Value, b.IsSrc) {
+	if !tokens.CheckSwapValue(b.PairID, swapInfo.Value, b.IsSrc) {
 		return swapInfo, tokens.ErrTxWithWrongValue
 	}
 
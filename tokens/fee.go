@@ -0,0 +1,188 @@
+package tokens
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+// FeeTier swap fee of the swaps whose value is not less than 'MinimumValue',
+// the fee is 'FixedSwapFee' plus 'SwapFeeRate' of the swap value.
+type FeeTier struct {
+	MinimumValue float64 // whole unit
+	SwapFeeRate  float64
+	FixedSwapFee float64 // whole unit
+
+	// calced value
+	minValue     *big.Int
+	swapFeeRate  *big.Rat
+	fixedSwapFee *big.Int
+}
+
+// FeePromotion fee discount of the swaps whose deposit tx time is in [StartTime, EndTime)
+type FeePromotion struct {
+	Name         string
+	StartTime    int64   // unix timestamp
+	EndTime      int64   // unix timestamp
+	DiscountRate float64 // in range (0,1], 1 means free of fee
+
+	// calced value
+	discountRate *big.Rat
+}
+
+// SwapFeeArgs swap specific args of fee calculation
+type SwapFeeArgs struct {
+	TxTime   uint64   // deposit tx time, to match fee promotions
+	Discount *big.Rat // fee discount of the account (eg. partner discount)
+}
+
+var (
+	bigRatOne = big.NewRat(1, 1)
+
+	errWrongDiscountRate = errors.New("wrong discount rate (decimal in range [0,1])")
+)
+
+// ParseDiscountRate parse discount rate, empty string means zero
+func ParseDiscountRate(rate string) (*big.Rat, error) {
+	if rate == "" {
+		return new(big.Rat), nil
+	}
+	discount, ok := new(big.Rat).SetString(rate)
+	if !ok || discount.Sign() < 0 || discount.Cmp(bigRatOne) > 0 {
+		return nil, errWrongDiscountRate
+	}
+	return discount, nil
+}
+
+// floatToRat convert config float to the exact value of its decimal literal
+func floatToRat(value float64) *big.Rat {
+	result, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+	return result
+}
+
+func ratToInt(value *big.Rat) *big.Int {
+	return new(big.Int).Quo(value.Num(), value.Denom())
+}
+
+// toBitsExact convert whole unit value to bits with exact decimal math
+func toBitsExact(value float64, decimals uint8) *big.Int {
+	oneToken := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	bits := new(big.Rat).Mul(floatToRat(value), new(big.Rat).SetInt(oneToken))
+	return ratToInt(bits)
+}
+
+func checkFeeRate(rate float64) bool {
+	return rate >= 0 && rate <= 1
+}
+
+func (c *TokenConfig) checkFeeSchedule() error {
+	if c.FixedSwapFee != nil && *c.FixedSwapFee < 0 {
+		return errors.New("wrong token config, 'FixedSwapFee' must be non-negative")
+	}
+	for i, tier := range c.FeeTiers {
+		if tier.MinimumValue <= 0 {
+			return fmt.Errorf("wrong fee tier %v, 'MinimumValue' must be positive", i)
+		}
+		if i > 0 && tier.MinimumValue <= c.FeeTiers[i-1].MinimumValue {
+			return fmt.Errorf("wrong fee tier %v, 'MinimumValue' must be in ascending order", i)
+		}
+		if !checkFeeRate(tier.SwapFeeRate) {
+			return fmt.Errorf("wrong fee tier %v, 'SwapFeeRate' must be in range [0,1]", i)
+		}
+		if tier.FixedSwapFee < 0 {
+			return fmt.Errorf("wrong fee tier %v, 'FixedSwapFee' must be non-negative", i)
+		}
+	}
+	for i, promotion := range c.FeePromotions {
+		if promotion.StartTime >= promotion.EndTime {
+			return fmt.Errorf("wrong fee promotion %v '%v', 'StartTime' must be before 'EndTime'", i, promotion.Name)
+		}
+		if promotion.DiscountRate <= 0 || promotion.DiscountRate > 1 {
+			return fmt.Errorf("wrong fee promotion %v '%v', 'DiscountRate' must be in range (0,1]", i, promotion.Name)
+		}
+	}
+	return nil
+}
+
+func (c *TokenConfig) calcAndStoreFeeSchedule() {
+	decimals := *c.Decimals
+	c.swapFeeRate = floatToRat(*c.SwapFeeRate)
+	c.fixedSwapFee = big.NewInt(0)
+	if c.FixedSwapFee != nil {
+		c.fixedSwapFee = toBitsExact(*c.FixedSwapFee, decimals)
+	}
+	c.maxSwapFee = toBitsExact(*c.MaximumSwapFee, decimals)
+	c.minSwapFee = toBitsExact(*c.MinimumSwapFee, decimals)
+	for _, tier := range c.FeeTiers {
+		tier.minValue = toBitsExact(tier.MinimumValue, decimals)
+		tier.swapFeeRate = floatToRat(tier.SwapFeeRate)
+		tier.fixedSwapFee = toBitsExact(tier.FixedSwapFee, decimals)
+	}
+	for _, promotion := range c.FeePromotions {
+		promotion.discountRate = floatToRat(promotion.DiscountRate)
+	}
+}
+
+// getFeeDiscount get the largest discount of the account discount and the active promotions
+func (c *TokenConfig) getFeeDiscount(args *SwapFeeArgs) *big.Rat {
+	discount := new(big.Rat)
+	if args == nil {
+		return discount
+	}
+	if args.Discount != nil && args.Discount.Cmp(discount) > 0 {
+		discount.Set(args.Discount)
+	}
+	txTime := int64(args.TxTime)
+	for _, promotion := range c.FeePromotions {
+		if txTime < promotion.StartTime || txTime >= promotion.EndTime {
+			continue
+		}
+		if promotion.discountRate.Cmp(discount) > 0 {
+			discount.Set(promotion.discountRate)
+		}
+	}
+	return discount
+}
+
+// calcSwapFee calc fee of the schedule, not exceed value
+func (c *TokenConfig) calcSwapFee(value *big.Int, args *SwapFeeArgs) *big.Int {
+	rate, fixed := c.swapFeeRate, c.fixedSwapFee
+	for _, tier := range c.FeeTiers {
+		if value.Cmp(tier.minValue) < 0 {
+			break
+		}
+		rate, fixed = tier.swapFeeRate, tier.fixedSwapFee
+	}
+	if rate.Sign() == 0 && fixed.Sign() == 0 {
+		return big.NewInt(0)
+	}
+
+	feeRat := new(big.Rat).Mul(new(big.Rat).SetInt(value), rate)
+	swapFee := ratToInt(feeRat.Add(feeRat, new(big.Rat).SetInt(fixed)))
+
+	if swapFee.Cmp(c.minSwapFee) < 0 {
+		swapFee = new(big.Int).Set(c.minSwapFee)
+	} else if swapFee.Cmp(c.maxSwapFee) > 0 {
+		swapFee = new(big.Int).Set(c.maxSwapFee)
+	}
+
+	if discount := c.getFeeDiscount(args); discount.Sign() > 0 {
+		remain := new(big.Rat).Sub(bigRatOne, discount)
+		swapFee = ratToInt(remain.Mul(remain, new(big.Rat).SetInt(swapFee)))
+	}
+
+	if swapFee.Cmp(value) > 0 {
+		return new(big.Int).Set(value)
+	}
+	return swapFee
+}
+
+// GetFeeDiscount get fee discount applied to swap
+func GetFeeDiscount(pairID string, isSrc bool, args *SwapFeeArgs) *big.Rat {
+	token := GetTokenConfig(pairID, isSrc)
+	if token == nil {
+		return new(big.Rat)
+	}
+	return token.getFeeDiscount(args)
+}
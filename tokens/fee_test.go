@@ -0,0 +1,124 @@
+package tokens
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestFloatToRat(t *testing.T) {
+	tests := []struct {
+		value float64
+		want  *big.Rat
+	}{
+		{0, new(big.Rat)},
+		{0.1, big.NewRat(1, 10)},
+		{0.0003, big.NewRat(3, 10000)},
+		{1e-7, big.NewRat(1, 10000000)},
+		{123.456, big.NewRat(123456, 1000)},
+		{1, bigRatOne},
+	}
+	for _, test := range tests {
+		if got := floatToRat(test.value); got.Cmp(test.want) != 0 {
+			t.Errorf("float to rat %v, want %v, got %v", test.value, test.want, got)
+		}
+	}
+}
+
+func TestToBitsExact(t *testing.T) {
+	tests := []struct {
+		value    float64
+		decimals uint8
+		want     string
+	}{
+		{0.1, 18, "100000000000000000"},
+		{0.29, 2, "29"},
+		{1.005, 2, "100"}, // truncated
+		{123456789.123456, 6, "123456789123456"},
+		{1e-7, 6, "0"},
+		{100, 0, "100"},
+	}
+	for _, test := range tests {
+		if got := toBitsExact(test.value, test.decimals); got.String() != test.want {
+			t.Errorf("to bits %v with %v decimals, want %v, got %v", test.value, test.decimals, test.want, got)
+		}
+	}
+}
+
+func newTestFeeScheduleToken() *TokenConfig {
+	decimals := uint8(6)
+	swapFeeRate, fixedSwapFee, minSwapFee, maxSwapFee := 0.001, 0.5, 1.0, 50.0
+	token := &TokenConfig{
+		Decimals:       &decimals,
+		SwapFeeRate:    &swapFeeRate,
+		FixedSwapFee:   &fixedSwapFee,
+		MinimumSwapFee: &minSwapFee,
+		MaximumSwapFee: &maxSwapFee,
+		FeeTiers: []*FeeTier{
+			{MinimumValue: 1000, SwapFeeRate: 0.0005},
+			{MinimumValue: 10000, SwapFeeRate: 0.0002},
+		},
+		FeePromotions: []*FeePromotion{
+			{Name: "test", StartTime: 100, EndTime: 200, DiscountRate: 0.5},
+		},
+	}
+	token.calcAndStoreFeeSchedule()
+	return token
+}
+
+func TestCalcSwapFeeTiers(t *testing.T) {
+	token := newTestFeeScheduleToken()
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"100000000", "1000000"},      // base rate, clamped to minimum fee
+		{"999999999", "1499999"},      // base rate plus fixed fee, truncated
+		{"1000000000", "1000000"},     // first tier, clamped to minimum fee
+		{"5000000000", "2500000"},     // first tier
+		{"9999999999", "4999999"},     // first tier, truncated
+		{"10000000000", "2000000"},    // second tier
+		{"1000000000000", "50000000"}, // second tier, clamped to maximum fee
+		{"500000", "500000"},          // minimum fee is capped by value
+		{"0", "0"},
+	}
+	for _, test := range tests {
+		value := newTestBig(t, test.value)
+		if fee := token.calcSwapFee(value, nil); fee.String() != test.want {
+			t.Errorf("swap fee of %v, want %v, got %v", test.value, test.want, fee)
+		}
+	}
+
+	// zero rate and zero fixed fee is free of fee, not clamped to minimum fee
+	token.FeeTiers[0].swapFeeRate = new(big.Rat)
+	if fee := token.calcSwapFee(big.NewInt(5000000000), nil); fee.Sign() != 0 {
+		t.Errorf("swap fee of free tier, want 0, got %v", fee)
+	}
+}
+
+func TestCalcSwapFeeDiscount(t *testing.T) {
+	token := newTestFeeScheduleToken()
+	tests := []struct {
+		value    int64
+		txTime   uint64
+		discount *big.Rat
+		want     int64
+	}{
+		{5000000000, 0, nil, 2500000},
+		{5000000000, 0, big.NewRat(1, 5), 2000000},
+		{5000000000, 0, big.NewRat(1, 3), 1666666},   // truncated
+		{5000000000, 100, nil, 1250000},              // promotion starts
+		{5000000000, 199, big.NewRat(1, 5), 1250000}, // promotion is larger
+		{5000000000, 150, big.NewRat(4, 5), 500000},  // account discount is larger
+		{5000000000, 200, big.NewRat(1, 5), 2000000}, // promotion ends
+		{5000000000, 99, nil, 2500000},
+		{5000000000, 0, big.NewRat(1, 1), 0},
+		{100000000, 150, nil, 500000}, // discount after clamping to minimum fee
+	}
+	for _, test := range tests {
+		args := &SwapFeeArgs{TxTime: test.txTime, Discount: test.discount}
+		if fee := token.calcSwapFee(big.NewInt(test.value), args); fee.Cmp(big.NewInt(test.want)) != 0 {
+			t.Errorf("swap fee of %v (time %v, discount %v), want %v, got %v",
+				test.value, test.txTime, test.discount, test.want, fee)
+		}
+	}
+}
@@ -1,21 +1,28 @@
 package tokens
 
 import (
+	"context"
 	"errors"
 	"math"
 	"math/big"
+	"strings"
+	"sync"
 
 	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/metrics"
 )
 
 // transaction memo prefix
 const (
 	LockMemoPrefix   = "SWAPTO:"
 	UnlockMemoPrefix = "SWAPTX:"
+	RefundMemoPrefix = "REFUNDTX:"
 )
 
 // common variables
 var (
+	// SrcBridge and DstBridge are the bridges of the default token pair,
+	// use them only for chain level queries (eg. address validation).
 	SrcBridge CrossChainBridge
 	DstBridge CrossChainBridge
 
@@ -38,6 +45,8 @@ var (
 	ErrBuildSwapTxInWrongEndpoint    = errors.New("build swap in/out tx in wrong endpoint")
 	ErrTxBeforeInitialHeight         = errors.New("transaction before initial block height")
 	ErrAddressIsInBlacklist          = errors.New("address is in black list")
+	ErrSwapTxAlreadyMined            = errors.New("swap tx is already mined")
+	ErrReplaceTxNotSupported         = errors.New("replace tx not supported")
 
 	ErrTodo = errors.New("developing: TODO")
 
@@ -52,6 +61,7 @@ var (
 	ErrTxFuncHashMismatch   = errors.New("tx func hash mismatch")
 	ErrDepositLogNotFound   = errors.New("deposit log not found or removed")
 	ErrSwapoutLogNotFound   = errors.New("swapout log not found or removed")
+	ErrTxWithMultiplePairs  = errors.New("tx touches multiple token pairs")
 
 	// errors should register
 	ErrTxWithWrongMemo       = errors.New("tx with wrong memo")
@@ -86,9 +96,31 @@ type NonceGetter interface {
 	GetPoolNonce(address, height string) (uint64, error)
 }
 
+// NonceReserver interface (eth like chain)
+type NonceReserver interface {
+	GetNonceAccount() string
+	ReserveNonce(txid string, poolNonce uint64) (nonce uint64, err error)
+}
+
+// ReplaceableBridge interface of bridge which supports replacing stuck swap tx
+type ReplaceableBridge interface {
+	// GetReplaceExtraArgs get extra args to rebuild the stuck swap tx with the same
+	// nonce (eth like chain) or previous outpoints (btc) and a higher fee.
+	GetReplaceExtraArgs(swapTx string, swapNonce, plusFeePercentage uint64) (*AllExtras, error)
+}
+
+// SignedTxEncoder interface of bridge which can encode signed tx to hex string and decode it back,
+// the swap attempt journal persists signed tx with it and rebroadcasts it after restart.
+type SignedTxEncoder interface {
+	EncodeSignedTx(signedTx interface{}) (rawTx string, err error)
+	DecodeSignedTx(rawTx string) (signedTx interface{}, err error)
+}
+
 // CrossChainBridge interface
 type CrossChainBridge interface {
 	IsSrcEndpoint() bool
+	GetPairID() string
+	SetPairID(pairID string)
 	GetTokenAndGateway() (*TokenConfig, *GatewayConfig)
 	SetTokenAndGateway(tokenCfg *TokenConfig, gatewayCfg *GatewayConfig, check bool)
 
@@ -106,11 +138,11 @@ type CrossChainBridge interface {
 	GetLatestBlockNumber() (uint64, error)
 	GetLatestBlockNumberOf(apiAddress string) (uint64, error)
 
-	StartPoolTransactionScanJob()
-	StartChainTransactionScanJob()
-	StartSwapHistoryScanJob()
+	StartPoolTransactionScanJob(ctx context.Context)
+	StartChainTransactionScanJob(ctx context.Context)
+	StartSwapHistoryScanJob(ctx context.Context)
 
-	SetNonce(value uint64)
+	SetNonce(value uint64) error
 	AdjustNonce(value uint64) (nonce uint64)
 	IncreaseNonce(value uint64)
 
@@ -130,13 +162,39 @@ func SetLatestBlockHeight(latest uint64, isSrc bool) {
 	}
 }
 
+// swap nonces are tracked per endpoint and dcrm address,
+// token pairs sharing one dcrm address on the same chain share the nonce.
+// they are only used if no persistent nonce manager is set.
+var (
+	swapNonces    = make(map[string]uint64)
+	swapNonceLock sync.Mutex
+
+	nonceManager NonceManager
+)
+
+// NonceManager persistent nonce manager (eth like chain),
+// account is the key of dcrm address on a chain (see GetNonceAccount).
+type NonceManager interface {
+	ReserveNonce(account, txid string, minNonce uint64) (nonce uint64, err error)
+	SetNextNonce(account string, nonce uint64) error
+}
+
+// SetNonceManager set persistent nonce manager
+func SetNonceManager(manager NonceManager) {
+	nonceManager = manager
+}
+
+// HasNonceManager has persistent nonce manager
+func HasNonceManager() bool {
+	return nonceManager != nil
+}
+
 // CrossChainBridgeBase base bridge
 type CrossChainBridgeBase struct {
 	TokenConfig   *TokenConfig
 	GatewayConfig *GatewayConfig
 	IsSrc         bool
-	SwapinNonce   uint64
-	SwapoutNonce  uint64
+	PairID        string
 }
 
 // NewCrossChainBridgeBase new base bridge
@@ -144,41 +202,71 @@ func NewCrossChainBridgeBase(isSrc bool) *CrossChainBridgeBase {
 	return &CrossChainBridgeBase{IsSrc: isSrc}
 }
 
-// SetNonce set nonce directly
-func (b *CrossChainBridgeBase) SetNonce(value uint64) {
+func (b *CrossChainBridgeBase) getNonceKey() string {
+	var dcrmAddress string
+	if b.TokenConfig != nil {
+		dcrmAddress = strings.ToLower(b.TokenConfig.DcrmAddress)
+	}
 	if b.IsSrcEndpoint() {
-		b.SwapoutNonce = value
-	} else {
-		b.SwapinNonce = value
+		return "swapout:" + dcrmAddress
+	}
+	return "swapin:" + dcrmAddress
+}
+
+// GetNonceAccount get the persistent nonce account key of dcrm address
+func (b *CrossChainBridgeBase) GetNonceAccount() string {
+	if b.TokenConfig == nil {
+		return ""
 	}
+	return strings.Join([]string{
+		b.TokenConfig.BlockChain,
+		b.TokenConfig.NetID,
+		strings.ToLower(b.TokenConfig.DcrmAddress),
+	}, ":")
+}
+
+// SetNonce set nonce directly
+func (b *CrossChainBridgeBase) SetNonce(value uint64) error {
+	if nonceManager != nil {
+		err := nonceManager.SetNextNonce(b.GetNonceAccount(), value)
+		if err != nil {
+			return err
+		}
+	}
+	swapNonceLock.Lock()
+	defer swapNonceLock.Unlock()
+	swapNonces[b.getNonceKey()] = value
+	return nil
+}
+
+// ReserveNonce reserve swap nonce of txid not less than pool nonce (eth like chain),
+// use the persistent nonce manager if it is set, otherwise adjust the local nonce.
+func (b *CrossChainBridgeBase) ReserveNonce(txid string, poolNonce uint64) (nonce uint64, err error) {
+	if nonceManager == nil {
+		return b.AdjustNonce(poolNonce), nil
+	}
+	return nonceManager.ReserveNonce(b.GetNonceAccount(), txid, poolNonce)
 }
 
 // AdjustNonce adjust account nonce (eth like chain)
 func (b *CrossChainBridgeBase) AdjustNonce(value uint64) (nonce uint64) {
+	swapNonceLock.Lock()
+	defer swapNonceLock.Unlock()
+	key := b.getNonceKey()
 	nonce = value
-	if b.IsSrcEndpoint() {
-		if b.SwapoutNonce > value {
-			nonce = b.SwapoutNonce
-		} else {
-			b.SwapoutNonce = value
-		}
+	if swapNonces[key] > value {
+		nonce = swapNonces[key]
 	} else {
-		if b.SwapinNonce > value {
-			nonce = b.SwapinNonce
-		} else {
-			b.SwapinNonce = value
-		}
+		swapNonces[key] = value
 	}
 	return nonce
 }
 
 // IncreaseNonce decrease account nonce (eth like chain)
 func (b *CrossChainBridgeBase) IncreaseNonce(value uint64) {
-	if b.IsSrcEndpoint() {
-		b.SwapoutNonce += value
-	} else {
-		b.SwapinNonce += value
-	}
+	swapNonceLock.Lock()
+	defer swapNonceLock.Unlock()
+	swapNonces[b.getNonceKey()] += value
 }
 
 // IsSrcEndpoint returns if bridge is at the source endpoint
@@ -186,6 +274,16 @@ func (b *CrossChainBridgeBase) IsSrcEndpoint() bool {
 	return b.IsSrc
 }
 
+// GetPairID get token pair id of this bridge
+func (b *CrossChainBridgeBase) GetPairID() string {
+	return b.PairID
+}
+
+// SetPairID set token pair id of this bridge
+func (b *CrossChainBridgeBase) SetPairID(pairID string) {
+	b.PairID = pairID
+}
+
 // GetTokenAndGateway get token and gateway config
 func (b *CrossChainBridgeBase) GetTokenAndGateway() (*TokenConfig, *GatewayConfig) {
 	return b.TokenConfig, b.GatewayConfig
@@ -195,6 +293,9 @@ func (b *CrossChainBridgeBase) GetTokenAndGateway() (*TokenConfig, *GatewayConfi
 func (b *CrossChainBridgeBase) SetTokenAndGateway(tokenCfg *TokenConfig, gatewayCfg *GatewayConfig, check bool) {
 	b.TokenConfig = tokenCfg
 	b.GatewayConfig = gatewayCfg
+	if gatewayCfg != nil {
+		metrics.AddGatewayAddresses(gatewayCfg.APIAddress...)
+	}
 	if !check {
 		return
 	}
@@ -204,25 +305,54 @@ func (b *CrossChainBridgeBase) SetTokenAndGateway(tokenCfg *TokenConfig, gateway
 	}
 }
 
-// GetCrossChainBridge get bridge of specified endpoint
-func GetCrossChainBridge(isSrc bool) CrossChainBridge {
+// GetCrossChainBridge get bridge of specified token pair and endpoint
+func GetCrossChainBridge(pairID string, isSrc bool) CrossChainBridge {
+	pair := GetTokenPair(pairID)
+	if pair == nil {
+		return nil
+	}
 	if isSrc {
-		return SrcBridge
+		return pair.SrcBridge
 	}
-	return DstBridge
+	return pair.DstBridge
 }
 
-// GetTokenConfig get token config of specified endpoint
-func GetTokenConfig(isSrc bool) *TokenConfig {
-	var token *TokenConfig
-	if isSrc {
-		token, _ = SrcBridge.GetTokenAndGateway()
-	} else {
-		token, _ = DstBridge.GetTokenAndGateway()
+// GetTokenConfig get token config of specified token pair and endpoint
+func GetTokenConfig(pairID string, isSrc bool) *TokenConfig {
+	bridge := GetCrossChainBridge(pairID, isSrc)
+	if bridge == nil {
+		return nil
 	}
+	token, _ := bridge.GetTokenAndGateway()
 	return token
 }
 
+// disableSwapLock synchronize 'DisableSwap' of token configs,
+// which is changed at runtime by 'maintain' and the outflow breakers
+var disableSwapLock sync.RWMutex
+
+// IsSwapDisabled is swap of specified token pair and endpoint disabled
+func IsSwapDisabled(pairID string, isSrc bool) bool {
+	token := GetTokenConfig(pairID, isSrc)
+	if token == nil {
+		return false
+	}
+	disableSwapLock.RLock()
+	defer disableSwapLock.RUnlock()
+	return token.DisableSwap
+}
+
+// SetSwapDisabled disable or enable swap of specified token pair and endpoint
+func SetSwapDisabled(pairID string, isSrc, disabled bool) {
+	token := GetTokenConfig(pairID, isSrc)
+	if token == nil {
+		return
+	}
+	disableSwapLock.Lock()
+	defer disableSwapLock.Unlock()
+	token.DisableSwap = disabled
+}
+
 // FromBits convert from bits
 func FromBits(value *big.Int, decimals uint8) float64 {
 	oneToken := math.Pow(10, float64(decimals))
@@ -246,47 +376,90 @@ func ToBits(value float64, decimals uint8) *big.Int {
 }
 
 // GetBigValueThreshold get big value threshold
-func GetBigValueThreshold(isSrc bool) *big.Int {
-	token := GetTokenConfig(isSrc)
+func GetBigValueThreshold(pairID string, isSrc bool) *big.Int {
+	token := GetTokenConfig(pairID, isSrc)
+	if token == nil {
+		return big.NewInt(0)
+	}
 	return token.bigValThreshhold
 }
 
+// GetSwapValueRange get minimum and maximum swap value
+func GetSwapValueRange(pairID string, isSrc bool) (minSwap, maxSwap *big.Int) {
+	token := GetTokenConfig(pairID, isSrc)
+	if token == nil {
+		return big.NewInt(0), big.NewInt(0)
+	}
+	return token.minSwap, token.maxSwap
+}
+
 // CheckSwapValue check swap value is in right range
-func CheckSwapValue(value *big.Int, isSrc bool) bool {
-	token := GetTokenConfig(isSrc)
+func CheckSwapValue(pairID string, value *big.Int, isSrc bool) bool {
+	token := GetTokenConfig(pairID, isSrc)
+	if token == nil {
+		return false
+	}
 	if value.Cmp(token.minSwap) < 0 {
 		return false
 	}
 	if value.Cmp(token.maxSwap) > 0 {
 		return false
 	}
-	swappedValue := CalcSwappedValue(value, isSrc)
-	return swappedValue.Sign() > 0
+	swapValue := CalcSwapValue(pairID, value, isSrc, nil)
+	return swapValue.Sign() > 0
 }
 
-// CalcSwappedValue calc swapped value (get rid of fee)
-func CalcSwappedValue(value *big.Int, isSrc bool) *big.Int {
-	token := GetTokenConfig(isSrc)
-
-	if *token.SwapFeeRate == 0.0 {
-		return value
+// CalcSwappedValue calc swapped value (get rid of fee),
+// fee args of nil means no account discount nor promotion.
+func CalcSwappedValue(pairID string, value *big.Int, isSrc bool, feeArgs *SwapFeeArgs) *big.Int {
+	token := GetTokenConfig(pairID, isSrc)
+	if token == nil {
+		return big.NewInt(0)
 	}
+	swapFee := token.calcSwapFee(value, feeArgs)
+	return new(big.Int).Sub(value, swapFee)
+}
 
-	swapValue := new(big.Float).SetInt(value)
-	swapFeeRate := new(big.Float).SetFloat64(*token.SwapFeeRate)
-	swapFeeFloat := new(big.Float).Mul(swapValue, swapFeeRate)
-
-	swapFee := big.NewInt(0)
-	swapFeeFloat.Int(swapFee)
-
-	if swapFee.Cmp(token.minSwapFee) < 0 {
-		swapFee = token.minSwapFee
-	} else if swapFee.Cmp(token.maxSwapFee) > 0 {
-		swapFee = token.maxSwapFee
+// ConvertTokenValue rescale value from the decimals of the token on the sending chain
+// to the decimals of the token on the receiving chain (sending chain is source chain if isSwapin).
+// up scaling is exact, down scaling truncates and returns the truncated dust
+// (in the decimals of the sending chain), which is kept by the bridge.
+func ConvertTokenValue(pairID string, value *big.Int, isSwapin bool) (converted, dust *big.Int) {
+	fromToken := GetTokenConfig(pairID, isSwapin)
+	toToken := GetTokenConfig(pairID, !isSwapin)
+	if fromToken == nil || toToken == nil {
+		return big.NewInt(0), big.NewInt(0)
 	}
+	return RescaleValue(value, *fromToken.Decimals, *toToken.Decimals)
+}
 
-	if value.Cmp(swapFee) > 0 {
-		return new(big.Int).Sub(value, swapFee)
+// RescaleValue rescale value from decimals to decimals, returns truncated dust
+func RescaleValue(value *big.Int, fromDecimals, toDecimals uint8) (converted, dust *big.Int) {
+	switch {
+	case fromDecimals == toDecimals:
+		return new(big.Int).Set(value), big.NewInt(0)
+	case fromDecimals < toDecimals:
+		multiplier := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(toDecimals-fromDecimals)), nil)
+		return new(big.Int).Mul(value, multiplier), big.NewInt(0)
+	default:
+		divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(fromDecimals-toDecimals)), nil)
+		converted, dust = new(big.Int).QuoRem(value, divisor, new(big.Int))
+		return converted, dust
 	}
-	return big.NewInt(0)
+}
+
+// CalcSwapValue calc value to send on the receiving chain,
+// get rid of fee and rescale to the decimals of the receiving chain.
+func CalcSwapValue(pairID string, value *big.Int, isSwapin bool, feeArgs *SwapFeeArgs) *big.Int {
+	swapValue, _ := ConvertTokenValue(pairID, CalcSwappedValue(pairID, value, isSwapin, feeArgs), isSwapin)
+	return swapValue
+}
+
+// CalcSwapFee calc fee kept by the bridge (in the decimals of the sending chain),
+// including the dust truncated by rescaling.
+func CalcSwapFee(pairID string, value *big.Int, isSwapin bool, feeArgs *SwapFeeArgs) *big.Int {
+	swappedValue := CalcSwappedValue(pairID, value, isSwapin, feeArgs)
+	_, dust := ConvertTokenValue(pairID, swappedValue, isSwapin)
+	fee := new(big.Int).Sub(value, swappedValue)
+	return fee.Add(fee, dust)
 }
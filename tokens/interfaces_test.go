@@ -0,0 +1,112 @@
+package tokens
+
+import (
+	"math/big"
+	"testing"
+)
+
+func newTestBig(t *testing.T, value string) *big.Int {
+	result, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		t.Fatalf("wrong big int %v", value)
+	}
+	return result
+}
+
+func newTestFeeToken(decimals uint8, swapFeeRate, minSwapFee, maxSwapFee float64) *TokenConfig {
+	token := &TokenConfig{
+		BlockChain:     "Ethereum",
+		NetID:          "Mainnet",
+		Decimals:       &decimals,
+		SwapFeeRate:    &swapFeeRate,
+		MinimumSwapFee: &minSwapFee,
+		MaximumSwapFee: &maxSwapFee,
+	}
+	token.calcAndStoreFeeSchedule()
+	return token
+}
+
+func TestRescaleValue(t *testing.T) {
+	tests := []struct {
+		value     string
+		from, to  uint8
+		converted string
+		dust      string
+	}{
+		{"123456789", 18, 18, "123456789", "0"},
+		{"123456789", 6, 18, "123456789000000000000", "0"},
+		{"123456789", 0, 2, "12345678900", "0"},
+		{"123456789000000000000", 18, 6, "123456789", "0"},
+		{"123456789123456789012", 18, 6, "123456789", "123456789012"},
+		{"999999999999", 18, 6, "0", "999999999999"},
+		{"0", 18, 6, "0", "0"},
+	}
+	for _, test := range tests {
+		value := newTestBig(t, test.value)
+		converted, dust := RescaleValue(value, test.from, test.to)
+		if converted.String() != test.converted || dust.String() != test.dust {
+			t.Errorf("rescale %v from %v to %v decimals, want (%v, %v), got (%v, %v)",
+				test.value, test.from, test.to, test.converted, test.dust, converted, dust)
+		}
+		if value.String() != test.value {
+			t.Errorf("rescale %v modified the value to %v", test.value, value)
+		}
+		// no value is lost, the dust is in the from decimals
+		back, _ := RescaleValue(converted, test.to, test.from)
+		if back.Add(back, dust).String() != test.value {
+			t.Errorf("rescale %v from %v to %v decimals, value is lost", test.value, test.from, test.to)
+		}
+	}
+}
+
+func TestCalcSwapFeeWithDust(t *testing.T) {
+	srcToken := newTestFeeToken(18, 0.001, 0, 100)
+	dstToken := newTestFeeToken(6, 0.001, 0, 100)
+	addTestTokenPair(t, &TokenPair{
+		PairID:    "usdt",
+		SrcBridge: &testBridge{pairID: "usdt", isSrc: true, token: srcToken},
+		DstBridge: &testBridge{pairID: "usdt", token: dstToken},
+	})
+	freeSrcToken := newTestFeeToken(18, 0, 0, 0)
+	freeDstToken := newTestFeeToken(6, 0, 0, 0)
+	addTestTokenPair(t, &TokenPair{
+		PairID:    "free",
+		SrcBridge: &testBridge{pairID: "free", isSrc: true, token: freeSrcToken},
+		DstBridge: &testBridge{pairID: "free", token: freeDstToken},
+	})
+
+	tests := []struct {
+		pairID    string
+		isSwapin  bool
+		value     string
+		swapValue string
+		swapFee   string
+	}{
+		// scaling down, fee includes the truncated dust
+		{"usdt", true, "1000000123456789012345", "999000123", "1000000456789012345"},
+		{"usdt", true, "1000000000000000000000", "999000000", "1000000000000000000"},
+		{"free", true, "5000000000007", "5", "7"},
+		{"free", true, "999999999999", "0", "999999999999"},
+		// scaling up, no dust
+		{"usdt", false, "1500000000", "1498500000000000000000", "1500000"},
+		{"free", false, "1234567", "1234567000000000000", "0"},
+	}
+	for _, test := range tests {
+		value := newTestBig(t, test.value)
+		swapValue := CalcSwapValue(test.pairID, value, test.isSwapin, nil)
+		swapFee := CalcSwapFee(test.pairID, value, test.isSwapin, nil)
+		if swapValue.String() != test.swapValue || swapFee.String() != test.swapFee {
+			t.Errorf("swap %v of pair %v (swapin %v), want value %v fee %v, got value %v fee %v",
+				test.value, test.pairID, test.isSwapin, test.swapValue, test.swapFee, swapValue, swapFee)
+		}
+
+		// swap value and fee add up to the value in the decimals of the sending chain
+		fromToken := GetTokenConfig(test.pairID, test.isSwapin)
+		toToken := GetTokenConfig(test.pairID, !test.isSwapin)
+		back, dust := RescaleValue(swapValue, *toToken.Decimals, *fromToken.Decimals)
+		if dust.Sign() != 0 || back.Add(back, swapFee).Cmp(value) != 0 {
+			t.Errorf("swap %v of pair %v (swapin %v), swap value and fee do not add up to value",
+				test.value, test.pairID, test.isSwapin)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package tokens
+
+import (
+	"errors"
+	"math/big"
+)
+
+const defOutflowLimitWindow = 86400
+
+// OutflowLimits limits of the swapped value in the rolling window of one direction,
+// nil value means no limit.
+type OutflowLimits struct {
+	Window       int64 // seconds
+	Total        *big.Int
+	PerBind      *big.Int
+	PerSender    *big.Int
+	BreakerCount int // trip the breaker if so many swaps are in window (disabled if 0)
+}
+
+func (c *TokenConfig) hasOutflowLimits() bool {
+	return c.MaximumOutflow != nil || c.MaximumOutflowPerBind != nil ||
+		c.MaximumOutflowPerSender != nil || c.OutflowBreakerCount > 0
+}
+
+func (c *TokenConfig) checkOutflowConfig() error {
+	if c.OutflowLimitWindow < 0 || c.OutflowBreakerCount < 0 {
+		return errors.New("wrong token config, 'OutflowLimitWindow' and 'OutflowBreakerCount' must be non-negative")
+	}
+	for _, limit := range []*float64{c.MaximumOutflow, c.MaximumOutflowPerBind, c.MaximumOutflowPerSender} {
+		if limit != nil && *limit < 0 {
+			return errors.New("wrong token config, 'MaximumOutflow' limits must be non-negative")
+		}
+	}
+	return nil
+}
+
+func (c *TokenConfig) calcAndStoreOutflowLimits() {
+	c.outflowLimits = nil
+	if !c.hasOutflowLimits() {
+		return
+	}
+	limits := &OutflowLimits{
+		Window:       c.OutflowLimitWindow,
+		BreakerCount: c.OutflowBreakerCount,
+	}
+	if limits.Window == 0 {
+		limits.Window = defOutflowLimitWindow
+	}
+	if c.MaximumOutflow != nil {
+		limits.Total = ToBits(*c.MaximumOutflow, *c.Decimals)
+	}
+	if c.MaximumOutflowPerBind != nil {
+		limits.PerBind = ToBits(*c.MaximumOutflowPerBind, *c.Decimals)
+	}
+	if c.MaximumOutflowPerSender != nil {
+		limits.PerSender = ToBits(*c.MaximumOutflowPerSender, *c.Decimals)
+	}
+	c.outflowLimits = limits
+}
+
+// GetOutflowLimits get outflow limits of swapin or swapout, nil means no limit
+func GetOutflowLimits(pairID string, isSwapin bool) *OutflowLimits {
+	token := GetTokenConfig(pairID, isSwapin)
+	if token == nil {
+		return nil
+	}
+	return token.outflowLimits
+}
@@ -0,0 +1,67 @@
+package tokens
+
+import (
+	"errors"
+	"math/big"
+)
+
+// refundable deposits, they can never be swapped
+var refundableVerifyErrors = map[error]bool{
+	ErrTxWithWrongMemo:    true,
+	ErrTxWithWrongValue:   true,
+	ErrBindAddrIsContract: true,
+}
+
+// IsRefundableVerifyError is deposit verified with this error refundable,
+// nil error is also refundable as the sender may be blacklisted.
+func IsRefundableVerifyError(err error) bool {
+	return err == nil || refundableVerifyErrors[err]
+}
+
+func (c *TokenConfig) checkRefundConfig() error {
+	if c.RefundFee != nil && *c.RefundFee < 0 {
+		return errors.New("wrong token config, 'RefundFee' must be non-negative")
+	}
+	if c.RefundApprovalThreshold != nil && *c.RefundApprovalThreshold < 0 {
+		return errors.New("wrong token config, 'RefundApprovalThreshold' must be non-negative")
+	}
+	return nil
+}
+
+func (c *TokenConfig) calcAndStoreRefundValue() {
+	c.refundFee = big.NewInt(0)
+	if c.RefundFee != nil {
+		c.refundFee = toBitsExact(*c.RefundFee, *c.Decimals)
+	}
+	// defaults to big value threshold
+	c.refundThreshold = c.bigValThreshhold
+	if c.RefundApprovalThreshold != nil {
+		c.refundThreshold = toBitsExact(*c.RefundApprovalThreshold, *c.Decimals)
+	}
+}
+
+// IsRefundEnabled is refund enabled for the deposits of swapin or swapout
+func IsRefundEnabled(pairID string, isSwapin bool) bool {
+	token := GetTokenConfig(pairID, isSwapin)
+	return token != nil && token.EnableRefund
+}
+
+// CalcRefundValue calc value refunded to the sender of the deposit (get rid of refund fee)
+func CalcRefundValue(pairID string, value *big.Int, isSwapin bool) *big.Int {
+	token := GetTokenConfig(pairID, isSwapin)
+	if token == nil || value.Cmp(token.refundFee) <= 0 {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Sub(value, token.refundFee)
+}
+
+// CalcRefundFee calc fee kept by the bridge when refunding
+func CalcRefundFee(pairID string, value *big.Int, isSwapin bool) *big.Int {
+	return new(big.Int).Sub(value, CalcRefundValue(pairID, value, isSwapin))
+}
+
+// IsRefundNeedApproval is refund of value need admin approval
+func IsRefundNeedApproval(pairID string, value *big.Int, isSwapin bool) bool {
+	token := GetTokenConfig(pairID, isSwapin)
+	return token == nil || value.Cmp(token.refundThreshold) > 0
+}
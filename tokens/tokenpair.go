@@ -0,0 +1,254 @@
+package tokens
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/anyswap/CrossChain-Bridge/log"
+)
+
+// DefaultPairID pair id of the legacy top level 'SrcToken' and 'DestToken' config
+const DefaultPairID = "default"
+
+// default max rate of value lost by rescaling decimals
+const defMaxRescaleLossRate = 0.001
+
+// ErrUnknownPairID unknown pair id error
+var ErrUnknownPairID = errors.New("unknown token pair id")
+
+var (
+	tokenPairs     = make(map[string]*TokenPair)
+	tokenPairIDs   []string
+	tokenPairsLock sync.RWMutex
+)
+
+// TokenPairConfig pair config
+type TokenPairConfig struct {
+	PairID    string
+	SrcToken  *TokenConfig
+	DestToken *TokenConfig
+
+	// max rate of value lost by rescaling decimals (truncated dust / minimum swap value)
+	MaxRescaleLossRate *float64 `toml:",omitempty"`
+}
+
+// CheckConfig check token pair config
+func (c *TokenPairConfig) CheckConfig() error {
+	if c.PairID == "" {
+		return errors.New("token pair must config 'PairID'")
+	}
+	c.PairID = strings.ToLower(c.PairID)
+	if c.SrcToken == nil {
+		return fmt.Errorf("token pair '%v' must config 'SrcToken'", c.PairID)
+	}
+	if c.DestToken == nil {
+		return fmt.Errorf("token pair '%v' must config 'DestToken'", c.PairID)
+	}
+	err := c.SrcToken.CheckConfig(true)
+	if err != nil {
+		return fmt.Errorf("token pair '%v' source token: %v", c.PairID, err)
+	}
+	err = c.DestToken.CheckConfig(false)
+	if err != nil {
+		return fmt.Errorf("token pair '%v' dest token: %v", c.PairID, err)
+	}
+	err = c.checkRescaleLoss()
+	if err != nil {
+		return fmt.Errorf("token pair '%v': %v", c.PairID, err)
+	}
+	return nil
+}
+
+// checkRescaleLoss refuse pair if rescaling from the larger decimals
+// may lose more than the tolerance of the minimum swap value
+func (c *TokenPairConfig) checkRescaleLoss() error {
+	maxLossRate := defMaxRescaleLossRate
+	if c.MaxRescaleLossRate != nil {
+		maxLossRate = *c.MaxRescaleLossRate
+	}
+	if maxLossRate < 0 || maxLossRate >= 1 {
+		return errors.New("'MaxRescaleLossRate' must be in range [0, 1)")
+	}
+	srcDecimals := *c.SrcToken.Decimals
+	dstDecimals := *c.DestToken.Decimals
+	if srcDecimals == dstDecimals {
+		return nil
+	}
+	fromToken := c.SrcToken // swapin truncates dust
+	diff := int64(srcDecimals) - int64(dstDecimals)
+	if diff < 0 {
+		fromToken = c.DestToken // swapout truncates dust
+		diff = -diff
+	}
+	maxLoss := new(big.Int).Exp(big.NewInt(10), big.NewInt(diff), nil)
+	maxLoss.Sub(maxLoss, big.NewInt(1))
+	minSwap := fromToken.minSwap
+	if minSwap.Sign() <= 0 {
+		return fmt.Errorf("rescaling decimals (%v to %v) requires positive 'MinimumSwap' of %v", srcDecimals, dstDecimals, fromToken.Symbol)
+	}
+	lossRate, _ := new(big.Rat).SetFrac(maxLoss, minSwap).Float64()
+	if lossRate > maxLossRate {
+		return fmt.Errorf("rescaling decimals (%v to %v) may lose %v of minimum swap value, larger than 'MaxRescaleLossRate' %v", srcDecimals, dstDecimals, lossRate, maxLossRate)
+	}
+	return nil
+}
+
+// TokenPair token pair with the bridges of both endpoints
+type TokenPair struct {
+	PairID    string
+	SrcBridge CrossChainBridge
+	DstBridge CrossChainBridge
+}
+
+// AddTokenPair add token pair to the registry.
+// the first added pair is used as the default pair.
+func AddTokenPair(pair *TokenPair) error {
+	pairID := strings.ToLower(pair.PairID)
+	tokenPairsLock.Lock()
+	defer tokenPairsLock.Unlock()
+	if _, exist := tokenPairs[pairID]; exist {
+		return fmt.Errorf("duplicate token pair id '%v'", pair.PairID)
+	}
+	pair.PairID = pairID
+	tokenPairs[pairID] = pair
+	tokenPairIDs = append(tokenPairIDs, pairID)
+	if len(tokenPairIDs) == 1 {
+		SrcBridge = pair.SrcBridge
+		DstBridge = pair.DstBridge
+	}
+	return nil
+}
+
+// GetTokenPair get token pair by pair id.
+// empty pair id means the default (first added) pair,
+// which is compatible with records and requests before multiple pairs.
+func GetTokenPair(pairID string) *TokenPair {
+	tokenPairsLock.RLock()
+	defer tokenPairsLock.RUnlock()
+	if pairID == "" {
+		if len(tokenPairIDs) == 0 {
+			return nil
+		}
+		pairID = tokenPairIDs[0]
+	}
+	return tokenPairs[strings.ToLower(pairID)]
+}
+
+// IsTokenPairExist is token pair exist
+func IsTokenPairExist(pairID string) bool {
+	return GetTokenPair(pairID) != nil
+}
+
+// GetAllPairIDs get all pair ids in adding order
+func GetAllPairIDs() []string {
+	tokenPairsLock.RLock()
+	defer tokenPairsLock.RUnlock()
+	pairIDs := make([]string, len(tokenPairIDs))
+	copy(pairIDs, tokenPairIDs)
+	return pairIDs
+}
+
+// GetDefaultPairID get default pair id
+func GetDefaultPairID() string {
+	tokenPairsLock.RLock()
+	defer tokenPairsLock.RUnlock()
+	if len(tokenPairIDs) == 0 {
+		return ""
+	}
+	return tokenPairIDs[0]
+}
+
+// latest block heights of token pair endpoints, key is pair id and endpoint
+var (
+	latestBlockHeights     = make(map[string]uint64)
+	latestBlockHeightsLock sync.RWMutex
+)
+
+func getEndpointKey(pairID string, isSrc bool) string {
+	if isSrc {
+		return strings.ToLower(pairID) + ":src"
+	}
+	return strings.ToLower(pairID) + ":dst"
+}
+
+// GetChainKey get key of the chain of bridge (block chain and net id),
+// the bridges of token pairs on the same chain have the same key.
+func GetChainKey(bridge CrossChainBridge) string {
+	token, _ := bridge.GetTokenAndGateway()
+	return strings.ToLower(token.BlockChain + ":" + token.NetID)
+}
+
+// GetBridgesOfChain get bridges of all token pairs on the same chain and
+// endpoint (source or dest) as the bridge (including itself), in adding order.
+func GetBridgesOfChain(bridge CrossChainBridge) []CrossChainBridge {
+	isSrc := bridge.IsSrcEndpoint()
+	chainKey := GetChainKey(bridge)
+	tokenPairsLock.RLock()
+	defer tokenPairsLock.RUnlock()
+	bridges := make([]CrossChainBridge, 0, len(tokenPairIDs))
+	for _, pairID := range tokenPairIDs {
+		pairBridge := tokenPairs[pairID].DstBridge
+		if isSrc {
+			pairBridge = tokenPairs[pairID].SrcBridge
+		}
+		if GetChainKey(pairBridge) == chainKey {
+			bridges = append(bridges, pairBridge)
+		}
+	}
+	return bridges
+}
+
+// VerifyTransactionOfPair verify tx of the token pair of bridge.
+// swaps are keyed by txid, so a tx which also touches other token pairs
+// on the same chain and endpoint is rejected with ErrTxWithMultiplePairs.
+// if it can not be told (rpc query error of this or other pairs),
+// ErrRPCQueryError is returned to verify it again later.
+func VerifyTransactionOfPair(bridge CrossChainBridge, txid string, allowUnstable bool) (*TxSwapInfo, error) {
+	swapInfo, err := bridge.VerifyTransaction(txid, allowUnstable)
+	if err == ErrRPCQueryError || !ShouldRegisterSwapForError(err) {
+		return swapInfo, err
+	}
+	otherUnknown := false
+	for _, other := range GetBridgesOfChain(bridge) {
+		if other == bridge {
+			continue
+		}
+		_, otherErr := other.VerifyTransaction(txid, allowUnstable)
+		switch {
+		case otherErr == ErrRPCQueryError:
+			log.Warn("verify tx of other token pair failed", "txid", txid, "pairID", bridge.GetPairID(), "otherPairID", other.GetPairID(), "err", otherErr)
+			otherUnknown = true
+		case ShouldRegisterSwapForError(otherErr):
+			log.Warn("tx touches multiple token pairs", "txid", txid, "pairID", bridge.GetPairID(), "otherPairID", other.GetPairID())
+			return swapInfo, ErrTxWithMultiplePairs
+		}
+	}
+	if otherUnknown {
+		return swapInfo, ErrRPCQueryError
+	}
+	return swapInfo, err
+}
+
+// SetPairLatestBlockHeight set latest block height of token pair endpoint,
+// the height of the default pair is also set to SrcLatestBlockHeight or DstLatestBlockHeight.
+func SetPairLatestBlockHeight(pairID string, isSrc bool, latest uint64) {
+	latestBlockHeightsLock.Lock()
+	latestBlockHeights[getEndpointKey(pairID, isSrc)] = latest
+	latestBlockHeightsLock.Unlock()
+	if strings.EqualFold(pairID, GetDefaultPairID()) {
+		SetLatestBlockHeight(latest, isSrc)
+	}
+}
+
+// GetPairLatestBlockHeight get latest block height of token pair endpoint
+func GetPairLatestBlockHeight(pairID string, isSrc bool) uint64 {
+	if pairID == "" {
+		pairID = GetDefaultPairID()
+	}
+	latestBlockHeightsLock.RLock()
+	defer latestBlockHeightsLock.RUnlock()
+	return latestBlockHeights[getEndpointKey(pairID, isSrc)]
+}
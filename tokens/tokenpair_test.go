@@ -0,0 +1,83 @@
+package tokens
+
+import (
+	"testing"
+)
+
+// bridge of a token pair which only verifies its own txs
+type testBridge struct {
+	CrossChainBridge
+	pairID string
+	isSrc  bool
+	token  *TokenConfig
+	txs    map[string]error // verify error of txs touching the pair
+}
+
+func (b *testBridge) IsSrcEndpoint() bool { return b.isSrc }
+
+func (b *testBridge) GetPairID() string { return b.pairID }
+
+func (b *testBridge) GetTokenAndGateway() (*TokenConfig, *GatewayConfig) { return b.token, nil }
+
+func (b *testBridge) VerifyTransaction(txHash string, allowUnstable bool) (*TxSwapInfo, error) {
+	if err, exist := b.txs[txHash]; exist {
+		return &TxSwapInfo{PairID: b.pairID, Hash: txHash}, err
+	}
+	return &TxSwapInfo{}, ErrTxWithWrongReceiver
+}
+
+func setTestTokenPairs(t *testing.T, bridges ...*testBridge) {
+	for _, bridge := range bridges {
+		addTestTokenPair(t, &TokenPair{PairID: bridge.pairID, SrcBridge: bridge, DstBridge: bridge})
+	}
+}
+
+func addTestTokenPair(t *testing.T, pair *TokenPair) {
+	if err := AddTokenPair(pair); err != nil {
+		t.Fatalf("add token pair failed: %v", err)
+	}
+	t.Cleanup(func() {
+		tokenPairs = make(map[string]*TokenPair)
+		tokenPairIDs = nil
+		SrcBridge, DstBridge = nil, nil
+	})
+}
+
+func TestVerifyTransactionOfPair(t *testing.T) {
+	ethToken := &TokenConfig{BlockChain: "Ethereum", NetID: "Mainnet"}
+	bscToken := &TokenConfig{BlockChain: "Binance", NetID: "Mainnet"}
+	usdt := &testBridge{pairID: "usdt", token: ethToken, txs: map[string]error{"tx1": nil, "tx3": nil, "tx4": ErrRPCQueryError, "tx6": nil}}
+	usdc := &testBridge{pairID: "usdc", token: ethToken, txs: map[string]error{"tx2": ErrTxWithWrongValue, "tx3": ErrTxWithWrongMemo, "tx4": nil, "tx5": ErrTxNotFound, "tx6": ErrRPCQueryError}}
+	busd := &testBridge{pairID: "busd", token: bscToken, txs: map[string]error{"tx1": nil}}
+	dai := &testBridge{pairID: "dai", token: ethToken, txs: map[string]error{"tx6": nil}}
+	setTestTokenPairs(t, usdt, usdc, busd, dai)
+
+	if bridges := GetBridgesOfChain(usdc); len(bridges) != 3 || bridges[0] != usdt || bridges[1] != usdc || bridges[2] != dai {
+		t.Fatalf("wrong bridges of chain %v", bridges)
+	}
+
+	tests := []struct {
+		bridge *testBridge
+		txid   string
+		want   error
+	}{
+		{usdt, "tx1", nil},                    // also touches busd on another chain
+		{usdc, "tx1", ErrTxWithWrongReceiver}, // not of the pair
+		{usdc, "tx2", ErrTxWithWrongValue},    // should register error of the pair
+		{usdt, "tx3", ErrTxWithMultiplePairs},
+		{usdc, "tx3", ErrTxWithMultiplePairs},
+		{usdc, "tx4", ErrRPCQueryError}, // rpc error of the other pair is retryable
+		{usdt, "tx4", ErrRPCQueryError}, // rpc error of the pair is retryable
+		{usdc, "tx5", ErrTxNotFound},
+		{dai, "tx6", ErrTxWithMultiplePairs}, // matches usdt, though rpc error of usdc
+	}
+	for _, test := range tests {
+		swapInfo, err := VerifyTransactionOfPair(test.bridge, test.txid, true)
+		if err != test.want {
+			t.Errorf("verify %v of pair %v, want error %v, got %v", test.txid, test.bridge.pairID, test.want, err)
+		}
+		if swapInfo == nil {
+			t.Errorf("verify %v of pair %v, swap info is nil", test.txid, test.bridge.pairID)
+		}
+	}
+}
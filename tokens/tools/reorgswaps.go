@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/dcrm"
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+)
+
+var (
+	maxReorgResultLifetime = int64(3 * 24 * 3600)
+
+	memoOfSourceTxReorged = "source tx is in orphaned block"
+	memoOfMatchTxReorged  = "match tx is in orphaned block"
+)
+
+// SeedReorgTracker track the scanned blocks saved in the scan state, the blocks
+// orphaned while the scanner is stopped are handled (their txs are got by block hash).
+func SeedReorgTracker(tracker *ReorgTracker, pairID string, isSrc bool, getBlockHash func(height uint64) (string, error), getBlockTxs func(blockHash string) ([]string, error)) {
+	if !dcrm.IsSwapServer() || !storage.HasSwapStore() {
+		return
+	}
+	blocks, err := storage.FindScannedBlocks(pairID, isSrc)
+	if err != nil {
+		if err != storage.ErrItemNotFound {
+			log.Warn("[reorg] find scanned blocks failed", "pairID", pairID, "isSrc", isSrc, "err", err)
+		}
+		return
+	}
+	orphaned, err := tracker.Seed(blocks, getBlockHash)
+	if err != nil {
+		log.Warn("[reorg] seed reorg tracker failed", "pairID", pairID, "isSrc", isSrc, "err", err)
+		return
+	}
+	log.Info("[reorg] seed reorg tracker", "pairID", pairID, "isSrc", isSrc, "blocks", len(blocks), "orphaned", len(orphaned))
+	if len(orphaned) > 0 {
+		FillOrphanedBlockTxs(orphaned, getBlockTxs)
+		HandleOrphanedBlocks(isSrc, orphaned)
+	}
+}
+
+// SaveReorgTracker save the tracked blocks to the scan state
+func SaveReorgTracker(tracker *ReorgTracker, pairID string, isSrc bool) {
+	if !dcrm.IsSwapServer() || !storage.HasSwapStore() {
+		return
+	}
+	err := storage.UpdateScannedBlocks(pairID, isSrc, tracker.Blocks())
+	if err != nil {
+		log.Warn("[reorg] save scanned blocks failed", "pairID", pairID, "isSrc", isSrc, "err", err)
+	}
+}
+
+// FillOrphanedBlockTxs get txs of the orphaned blocks which are seeded from the scan state
+func FillOrphanedBlockTxs(blocks []*ScannedBlock, getBlockTxs func(blockHash string) ([]string, error)) {
+	for _, block := range blocks {
+		if block.Txs != nil {
+			continue
+		}
+		txs, err := getBlockTxs(block.Hash)
+		if err != nil {
+			log.Error("[reorg] get txs of orphaned block failed", "height", block.Height, "hash", block.Hash, "err", err)
+			continue
+		}
+		block.Txs = txs
+	}
+}
+
+// HandleOrphanedBlocks move swaps whose source tx or match tx
+// is in the orphaned blocks to reorged status to reverify them.
+// blocks of src chain contain swapin source txs and swapout match txs,
+// blocks of dest chain contain swapout source txs and swapin match txs.
+func HandleOrphanedBlocks(isSrc bool, blocks []*ScannedBlock) {
+	if len(blocks) == 0 {
+		return
+	}
+	log.Warn("[reorg] found orphaned blocks", "isSrc", isSrc, "from", blocks[0].Height, "to", blocks[len(blocks)-1].Height, "count", len(blocks))
+	if !dcrm.IsSwapServer() || !storage.HasSwapStore() {
+		return
+	}
+	orphanedTxs := make(map[string]struct{})
+	for _, block := range blocks {
+		for _, txid := range block.Txs {
+			orphanedTxs[txid] = struct{}{}
+			markSwapReorged(isSrc, txid)
+		}
+	}
+	markSwapResultsReorged(!isSrc, orphanedTxs)
+}
+
+func markSwapReorged(isSwapin bool, txid string) {
+	swap, err := storage.FindSwap(isSwapin, txid)
+	if err != nil {
+		return
+	}
+	switch swap.Status {
+	case storage.TxNotSwapped, storage.TxWithBigValue, storage.TxHeld, storage.TxProcessed:
+	default:
+		return
+	}
+	now := time.Now().Unix()
+	err = storage.UpdateSwapStatusFrom(storage.ActorScan, isSwapin, txid, swap.Status, storage.TxReorged, now, memoOfSourceTxReorged)
+	if err != nil {
+		log.Error("[reorg] mark swap reorged failed", "txid", txid, "isSwapin", isSwapin, "err", err)
+		return
+	}
+	log.Warn("[reorg] mark swap reorged", "txid", txid, "isSwapin", isSwapin, "oldStatus", swap.Status)
+	res, err := storage.FindSwapResult(isSwapin, txid)
+	if err != nil || res.SwapTx != "" {
+		return
+	}
+	switch res.Status {
+	case storage.MatchTxEmpty, storage.TxWithBigValue, storage.TxHeld:
+		err = storage.UpdateSwapResultStatusFrom(storage.ActorScan, isSwapin, txid, res.Status, storage.TxReorged, now, memoOfSourceTxReorged)
+		if err != nil {
+			log.Error("[reorg] mark swap result reorged failed", "txid", txid, "isSwapin", isSwapin, "err", err)
+		}
+	}
+}
+
+func markSwapResultsReorged(isSwapin bool, orphanedTxs map[string]struct{}) {
+	septime := time.Now().Unix() - maxReorgResultLifetime
+	for _, status := range []storage.SwapStatus{storage.MatchTxNotStable, storage.MatchTxStable} {
+		var (
+			results []*storage.SwapResult
+			err     error
+		)
+		if isSwapin {
+			results, err = storage.FindSwapinResultsWithStatus(status, septime)
+		} else {
+			results, err = storage.FindSwapoutResultsWithStatus(status, septime)
+		}
+		if err != nil {
+			log.Error("[reorg] find swap results failed", "status", status, "isSwapin", isSwapin, "err", err)
+			continue
+		}
+		for _, res := range results {
+			if !isMatchTxOrphaned(res, orphanedTxs) {
+				continue
+			}
+			err = storage.UpdateSwapResultStatusFrom(storage.ActorScan, isSwapin, res.Key, status, storage.MatchTxReorged, time.Now().Unix(), memoOfMatchTxReorged)
+			if err != nil {
+				log.Error("[reorg] mark swap result reorged failed", "txid", res.Key, "swaptx", res.SwapTx, "isSwapin", isSwapin, "err", err)
+				continue
+			}
+			log.Warn("[reorg] mark swap result reorged", "txid", res.Key, "swaptx", res.SwapTx, "isSwapin", isSwapin, "oldStatus", status)
+		}
+	}
+}
+
+func isMatchTxOrphaned(res *storage.SwapResult, orphanedTxs map[string]struct{}) bool {
+	if _, exist := orphanedTxs[res.SwapTx]; exist {
+		return true
+	}
+	for _, oldSwapTx := range res.OldSwapTxs {
+		if _, exist := orphanedTxs[oldSwapTx]; exist {
+			return true
+		}
+	}
+	return false
+}
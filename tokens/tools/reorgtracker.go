@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"sort"
+
+	"github.com/anyswap/CrossChain-Bridge/storage"
+)
+
+// ScannedBlock scanned block info tracked to detect chain reorganisation
+type ScannedBlock = storage.ScannedBlock
+
+// ReorgTracker track scanned block hash per height,
+// and detect chain reorganisation by parent hash mismatch.
+type ReorgTracker struct {
+	capacity uint64
+	blocks   map[uint64]*ScannedBlock
+}
+
+// NewReorgTracker new reorg tracker which tracks at most capacity recent heights
+func NewReorgTracker(capacity uint64) *ReorgTracker {
+	return &ReorgTracker{
+		capacity: capacity,
+		blocks:   make(map[uint64]*ScannedBlock, capacity),
+	}
+}
+
+// AddBlock track a new scanned block, returns the orphaned blocks (sorted by height)
+// if the block does not link to the tracked chain.
+// getBlockHash is used to get the canonical block hash of a height
+// when walking back to the fork point.
+func (t *ReorgTracker) AddBlock(block *ScannedBlock, getBlockHash func(height uint64) (string, error)) (orphaned []*ScannedBlock, err error) {
+	height := block.Height
+	old, exist := t.blocks[height]
+	if exist {
+		if old.Hash == block.Hash {
+			return nil, nil
+		}
+		orphaned = append(orphaned, old)
+	}
+
+	if height > 0 {
+		expectHash := block.ParentHash
+		for h := height - 1; ; h-- {
+			tracked := t.blocks[h]
+			if tracked == nil || tracked.Hash == expectHash {
+				break
+			}
+			orphaned = append(orphaned, tracked)
+			if h == 0 {
+				break
+			}
+			if expectHash, err = getBlockHash(h - 1); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(orphaned) > 0 {
+		// descendants of the orphaned blocks are orphaned too
+		for h, tracked := range t.blocks {
+			if h > height {
+				orphaned = append(orphaned, tracked)
+			}
+		}
+		for _, orphan := range orphaned {
+			delete(t.blocks, orphan.Height)
+		}
+		sort.Slice(orphaned, func(i, j int) bool {
+			return orphaned[i].Height < orphaned[j].Height
+		})
+	}
+
+	t.blocks[height] = block
+	for h := range t.blocks {
+		if h+t.capacity <= height {
+			delete(t.blocks, h)
+		}
+	}
+	return orphaned, nil
+}
+
+// Seed track the blocks saved in the scan state (eg. on startup), returns the orphaned ones
+// (sorted by height) which are reorganised while the scanner is stopped.
+// the blocks are checked against the canonical chain from the highest one to the fork point.
+func (t *ReorgTracker) Seed(blocks []*ScannedBlock, getBlockHash func(height uint64) (string, error)) (orphaned []*ScannedBlock, err error) {
+	blocks = append([]*ScannedBlock{}, blocks...)
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Height > blocks[j].Height
+	})
+	linked := 0
+	for ; linked < len(blocks); linked++ {
+		block := blocks[linked]
+		canonicalHash, errh := getBlockHash(block.Height)
+		if errh != nil {
+			return nil, errh
+		}
+		if canonicalHash == block.Hash {
+			break
+		}
+	}
+	orphaned = blocks[:linked]
+	for _, block := range blocks[linked:] {
+		if _, exist := t.blocks[block.Height]; !exist {
+			t.blocks[block.Height] = block
+		}
+	}
+	sort.Slice(orphaned, func(i, j int) bool {
+		return orphaned[i].Height < orphaned[j].Height
+	})
+	return orphaned, nil
+}
+
+// Blocks get the tracked blocks sorted by height
+func (t *ReorgTracker) Blocks() []*ScannedBlock {
+	blocks := make([]*ScannedBlock, 0, len(t.blocks))
+	for _, block := range t.blocks {
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Height < blocks[j].Height
+	})
+	return blocks
+}
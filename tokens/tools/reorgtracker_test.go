@@ -0,0 +1,160 @@
+package tools
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// chain of block hashes by height, fork blocks are named with the fork prefix
+type testChain map[uint64]string
+
+func (c testChain) getBlockHash(height uint64) (string, error) {
+	hash, exist := c[height]
+	if !exist {
+		return "", fmt.Errorf("block %v not found", height)
+	}
+	return hash, nil
+}
+
+func newTestChain(prefix string, from, to uint64) testChain {
+	chain := make(testChain)
+	for h := from; h <= to; h++ {
+		chain[h] = fmt.Sprintf("%v%v", prefix, h)
+	}
+	return chain
+}
+
+func (c testChain) block(height uint64) *ScannedBlock {
+	parentHash := ""
+	if height > 0 {
+		parentHash = c[height-1]
+	}
+	return &ScannedBlock{Height: height, Hash: c[height], ParentHash: parentHash}
+}
+
+func getHeights(blocks []*ScannedBlock) []uint64 {
+	heights := make([]uint64, 0, len(blocks))
+	for _, block := range blocks {
+		heights = append(heights, block.Height)
+	}
+	return heights
+}
+
+func TestReorgTrackerAddBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		capacity uint64
+		scanned  uint64   // blocks 0..scanned of chain 'a' are tracked
+		forkAt   uint64   // chain 'b' forks from chain 'a' at this height
+		add      uint64   // height of chain 'b' block to add
+		orphaned []uint64 // want orphaned heights
+		tracked  []uint64 // want tracked heights after adding
+	}{
+		{
+			name:     "linked block",
+			capacity: 10, scanned: 5, forkAt: 6, add: 6,
+			orphaned: []uint64{},
+			tracked:  []uint64{0, 1, 2, 3, 4, 5, 6},
+		},
+		{
+			name:     "same height replaced",
+			capacity: 10, scanned: 5, forkAt: 5, add: 5,
+			orphaned: []uint64{5},
+			tracked:  []uint64{0, 1, 2, 3, 4, 5},
+		},
+		{
+			name:     "fork walk-back",
+			capacity: 10, scanned: 5, forkAt: 3, add: 6,
+			orphaned: []uint64{3, 4, 5},
+			tracked:  []uint64{0, 1, 2, 6},
+		},
+		{
+			name:     "descendants of orphans",
+			capacity: 10, scanned: 8, forkAt: 4, add: 5,
+			orphaned: []uint64{4, 5, 6, 7, 8},
+			tracked:  []uint64{0, 1, 2, 3, 5},
+		},
+		{
+			name:     "capacity eviction",
+			capacity: 3, scanned: 5, forkAt: 6, add: 6,
+			orphaned: []uint64{},
+			tracked:  []uint64{4, 5, 6},
+		},
+		{
+			name:     "fork below the evicted blocks",
+			capacity: 3, scanned: 5, forkAt: 1, add: 6,
+			orphaned: []uint64{3, 4, 5},
+			tracked:  []uint64{6},
+		},
+	}
+	for _, test := range tests {
+		chainA := newTestChain("a", 0, test.scanned)
+		chainB := newTestChain("b", test.forkAt, test.add)
+		for h := uint64(0); h < test.forkAt; h++ {
+			chainB[h] = chainA[h]
+		}
+		tracker := NewReorgTracker(test.capacity)
+		for h := uint64(0); h <= test.scanned; h++ {
+			if orphaned, err := tracker.AddBlock(chainA.block(h), chainA.getBlockHash); err != nil || len(orphaned) != 0 {
+				t.Fatalf("%v: scan block %v, orphaned %v, err %v", test.name, h, len(orphaned), err)
+			}
+		}
+		orphaned, err := tracker.AddBlock(chainB.block(test.add), chainB.getBlockHash)
+		if err != nil {
+			t.Errorf("%v: add block error: %v", test.name, err)
+			continue
+		}
+		if got := getHeights(orphaned); !reflect.DeepEqual(got, test.orphaned) {
+			t.Errorf("%v: want orphaned %v, got %v", test.name, test.orphaned, got)
+		}
+		for _, block := range orphaned {
+			if block.Hash != chainA[block.Height] {
+				t.Errorf("%v: orphaned block %v is not of the old chain", test.name, block.Hash)
+			}
+		}
+		if got := getHeights(tracker.Blocks()); !reflect.DeepEqual(got, test.tracked) {
+			t.Errorf("%v: want tracked %v, got %v", test.name, test.tracked, got)
+		}
+	}
+}
+
+func TestReorgTrackerSeed(t *testing.T) {
+	saved := newTestChain("a", 0, 5)
+	blocks := make([]*ScannedBlock, 0, len(saved))
+	for h := range saved {
+		blocks = append(blocks, saved.block(h))
+	}
+
+	tests := []struct {
+		name     string
+		forkAt   uint64
+		orphaned []uint64
+	}{
+		{"not reorganised", 6, []uint64{}},
+		{"reorganised while stopped", 3, []uint64{3, 4, 5}},
+	}
+	for _, test := range tests {
+		canonical := newTestChain("b", test.forkAt, 10)
+		for h := uint64(0); h < test.forkAt; h++ {
+			canonical[h] = saved[h]
+		}
+		tracker := NewReorgTracker(10)
+		orphaned, err := tracker.Seed(blocks, canonical.getBlockHash)
+		if err != nil {
+			t.Errorf("%v: seed error: %v", test.name, err)
+			continue
+		}
+		if got := getHeights(orphaned); !reflect.DeepEqual(got, test.orphaned) {
+			t.Errorf("%v: want orphaned %v, got %v", test.name, test.orphaned, got)
+		}
+		// the seeded blocks link the rescanned canonical blocks
+		next := test.forkAt
+		if next > 5 {
+			next = 6
+		}
+		if orphaned, err = tracker.AddBlock(canonical.block(next), canonical.getBlockHash); err != nil || len(orphaned) != 0 {
+			t.Errorf("%v: add block after seeding, orphaned %v, err %v", test.name, len(orphaned), err)
+		}
+	}
+}
@@ -1,13 +1,16 @@
 package tools
 
 import (
+	"context"
 	"time"
 
+	"github.com/anyswap/CrossChain-Bridge/common"
 	"github.com/anyswap/CrossChain-Bridge/dcrm"
 	"github.com/anyswap/CrossChain-Bridge/log"
-	"github.com/anyswap/CrossChain-Bridge/mongodb"
+	"github.com/anyswap/CrossChain-Bridge/metrics"
 	"github.com/anyswap/CrossChain-Bridge/params"
 	"github.com/anyswap/CrossChain-Bridge/rpc/client"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 )
 
@@ -18,8 +21,8 @@ var (
 
 // IsSwapinExist is swapin exist
 func IsSwapinExist(txid string) bool {
-	if mongodb.HasSession() {
-		swap, _ := mongodb.FindSwapin(txid)
+	if storage.HasSwapStore() {
+		swap, _ := storage.FindSwapin(txid)
 		return swap != nil
 	}
 	var result interface{}
@@ -35,8 +38,8 @@ func IsSwapinExist(txid string) bool {
 
 // IsSwapoutExist is swapout exist
 func IsSwapoutExist(txid string) bool {
-	if mongodb.HasSession() {
-		swap, _ := mongodb.FindSwapout(txid)
+	if storage.HasSwapStore() {
+		swap, _ := storage.FindSwapout(txid)
 		return swap != nil
 	}
 	var result interface{}
@@ -51,70 +54,76 @@ func IsSwapoutExist(txid string) bool {
 }
 
 // RegisterSwapin register swapin
-func RegisterSwapin(txid, bind string, verifyError error) error {
-	return registerSwap(true, txid, bind, verifyError)
+func RegisterSwapin(pairID, txid, bind string, verifyError error) error {
+	return registerSwap(true, pairID, txid, bind, verifyError)
 }
 
 // RegisterSwapout register swapout
-func RegisterSwapout(txid, bind string, verifyError error) error {
-	return registerSwap(false, txid, bind, verifyError)
+func RegisterSwapout(pairID, txid, bind string, verifyError error) error {
+	return registerSwap(false, pairID, txid, bind, verifyError)
 }
 
-func registerSwap(isSwapin bool, txid, bind string, verifyError error) error {
+func registerSwap(isSwapin bool, pairID, txid, bind string, verifyError error) error {
 	if !tokens.ShouldRegisterSwapForError(verifyError) {
 		return verifyError
 	}
 	isServer := dcrm.IsSwapServer()
-	log.Info("[scan] register swap", "isSwapin", isSwapin, "isServer", isServer, "tx", txid, "bind", bind)
+	log.Info("[scan] register swap", "pairID", pairID, "isSwapin", isSwapin, "isServer", isServer, "tx", txid, "bind", bind)
 	if isServer {
 		var memo string
 		if verifyError != nil {
 			memo = verifyError.Error()
 		}
-		swap := &mongodb.MgoSwap{
+		swap := &storage.Swap{
 			Key:       txid,
+			PairID:    pairID,
 			TxID:      txid,
 			Bind:      bind,
-			Status:    mongodb.GetStatusByTokenVerifyError(verifyError),
+			Status:    storage.GetStatusByTokenVerifyError(verifyError),
 			Timestamp: time.Now().Unix(),
 			Memo:      memo,
 		}
 		if isSwapin {
 			swap.TxType = uint32(tokens.SwapinTx)
-			return mongodb.AddSwapin(swap)
+			return storage.AddSwapin(storage.ActorScan, swap)
 		}
 		swap.TxType = uint32(tokens.SwapoutTx)
-		return mongodb.AddSwapout(swap)
+		return storage.AddSwapout(storage.ActorScan, swap)
+	}
+	args := map[string]interface{}{
+		"txid":   txid,
+		"pairid": pairID,
 	}
 	var result interface{}
 	if isSwapin {
-		return client.RPCPost(&result, params.ServerAPIAddress, "swap.Swapin", txid)
+		return client.RPCPost(&result, params.ServerAPIAddress, "swap.Swapin", args)
 	}
-	return client.RPCPost(&result, params.ServerAPIAddress, "swap.Swapout", txid)
+	return client.RPCPost(&result, params.ServerAPIAddress, "swap.Swapout", args)
 }
 
 // RegisterP2shSwapin register p2sh swapin
-func RegisterP2shSwapin(txid, bind string, verifyError error) error {
+func RegisterP2shSwapin(pairID, txid, bind string, verifyError error) error {
 	if !tokens.ShouldRegisterSwapForError(verifyError) {
 		return verifyError
 	}
 	isServer := dcrm.IsSwapServer()
-	log.Info("[scan] register p2sh swapin", "isServer", isServer, "tx", txid, "bind", bind)
+	log.Info("[scan] register p2sh swapin", "pairID", pairID, "isServer", isServer, "tx", txid, "bind", bind)
 	if isServer {
 		var memo string
 		if verifyError != nil {
 			memo = verifyError.Error()
 		}
-		swap := &mongodb.MgoSwap{
+		swap := &storage.Swap{
 			Key:       txid,
+			PairID:    pairID,
 			TxID:      txid,
 			TxType:    uint32(tokens.P2shSwapinTx),
 			Bind:      bind,
-			Status:    mongodb.GetStatusByTokenVerifyError(verifyError),
+			Status:    storage.GetStatusByTokenVerifyError(verifyError),
 			Timestamp: time.Now().Unix(),
 			Memo:      memo,
 		}
-		return mongodb.AddSwapin(swap)
+		return storage.AddSwapin(storage.ActorScan, swap)
 	}
 	args := map[string]interface{}{
 		"txid": txid,
@@ -126,8 +135,8 @@ func RegisterP2shSwapin(txid, bind string, verifyError error) error {
 
 // GetP2shBindAddress get p2sh bind address
 func GetP2shBindAddress(p2shAddress string) (bindAddress string) {
-	if mongodb.HasSession() {
-		bindAddress, _ = mongodb.FindP2shBindAddress(p2shAddress)
+	if storage.HasSwapStore() {
+		bindAddress, _ = storage.FindP2shBindAddress(p2shAddress)
 		return bindAddress
 	}
 	var result tokens.P2shAddressInfo
@@ -142,55 +151,61 @@ func GetP2shBindAddress(p2shAddress string) (bindAddress string) {
 }
 
 // GetLatestScanHeight get latest scanned block height
-func GetLatestScanHeight(isSrc bool) uint64 {
-	if mongodb.HasSession() {
+func GetLatestScanHeight(pairID string, isSrc bool) uint64 {
+	if storage.HasSwapStore() {
 		for {
-			latestInfo, err := mongodb.FindLatestScanInfo(isSrc)
-			if err == nil {
+			latestInfo, err := storage.FindLatestScanInfo(pairID, isSrc)
+			if err == nil || err == storage.ErrItemNotFound {
 				height := latestInfo.BlockHeight
-				log.Info("GetLatestScanHeight", "isSrc", isSrc, "height", height)
+				log.Info("GetLatestScanHeight", "pairID", pairID, "isSrc", isSrc, "height", height)
 				return height
 			}
 			time.Sleep(1 * time.Second)
 		}
 	}
-	var result mongodb.MgoLatestScanInfo
+	args := map[string]interface{}{
+		"isSrc":  isSrc,
+		"pairid": pairID,
+	}
+	var result storage.LatestScanInfo
 	for {
-		err := client.RPCPost(&result, params.ServerAPIAddress, "swap.GetLatestScanInfo", isSrc)
+		err := client.RPCPost(&result, params.ServerAPIAddress, "swap.GetLatestScanInfo", args)
 		if err == nil {
 			height := result.BlockHeight
-			log.Info("GetLatestScanHeight", "isSrc", isSrc, "height", height)
+			log.Info("GetLatestScanHeight", "pairID", pairID, "isSrc", isSrc, "height", height)
 			return height
 		}
 		time.Sleep(1 * time.Second)
 	}
 }
 
-// LoopGetLatestBlockNumber loop and get latest block number
-func LoopGetLatestBlockNumber(b tokens.CrossChainBridge) uint64 {
+// LoopGetLatestBlockNumber loop and get latest block number until success or context is done
+func LoopGetLatestBlockNumber(ctx context.Context, b tokens.CrossChainBridge) (uint64, error) {
 	for {
 		latest, err := b.GetLatestBlockNumber()
-		if err != nil {
-			log.Error("get latest block failed", "isSrc", b.IsSrcEndpoint(), "err", err)
-			time.Sleep(3 * time.Second)
-			continue
+		if err == nil {
+			return latest, nil
+		}
+		log.Error("get latest block failed", "isSrc", b.IsSrcEndpoint(), "err", err)
+		if !common.SleepWithContext(ctx, 3*time.Second) {
+			return 0, ctx.Err()
 		}
-		return latest
 	}
 }
 
 // UpdateLatestScanInfo update latest scan info
-func UpdateLatestScanInfo(isSrc bool, height uint64) error {
+func UpdateLatestScanInfo(pairID string, isSrc bool, height uint64) error {
+	metrics.SetLatestScannedHeight(pairID, isSrc, height)
 	if dcrm.IsSwapServer() {
-		return mongodb.UpdateLatestScanInfo(isSrc, height)
+		return storage.UpdateLatestScanInfo(pairID, isSrc, height)
 	}
 	return nil
 }
 
 // IsAddressRegistered is address registered
 func IsAddressRegistered(address string) bool {
-	if mongodb.HasSession() {
-		result, _ := mongodb.FindRegisteredAddress(address)
+	if storage.HasSwapStore() {
+		result, _ := storage.FindRegisteredAddress(address)
 		return result != nil
 	}
 	var result interface{}
@@ -203,3 +218,48 @@ func IsAddressRegistered(address string) bool {
 	}
 	return false
 }
+
+// GetSwapFeeArgs get fee args of swap, account is the bind address,
+// txTime is the deposit tx time.
+func GetSwapFeeArgs(account string, txTime uint64, isSwapin bool) (*tokens.SwapFeeArgs, error) {
+	override, err := getFeeOverride(account)
+	if err != nil {
+		return nil, err
+	}
+	discountRate := override.SwapoutDiscount
+	if isSwapin {
+		discountRate = override.SwapinDiscount
+	}
+	discount, err := tokens.ParseDiscountRate(discountRate)
+	if err != nil {
+		return nil, err
+	}
+	return &tokens.SwapFeeArgs{
+		TxTime:   txTime,
+		Discount: discount,
+	}, nil
+}
+
+// getFeeOverride get fee override of account, returns empty override if not exist
+func getFeeOverride(account string) (override *storage.FeeOverride, err error) {
+	if account == "" {
+		return &storage.FeeOverride{}, nil
+	}
+	if storage.HasSwapStore() {
+		override, err = storage.FindFeeOverride(account)
+		if err == storage.ErrItemNotFound {
+			return &storage.FeeOverride{}, nil
+		}
+		return override, err
+	}
+	for i := 0; i < retryRPCCount; i++ {
+		var result storage.FeeOverride
+		err = client.RPCPost(&result, params.ServerAPIAddress, "swap.GetFeeOverride", account)
+		if err == nil {
+			return &result, nil
+		}
+		time.Sleep(retryRPCInterval)
+	}
+	log.Warn("get fee override failed", "account", account, "err", err)
+	return nil, err
+}
@@ -18,31 +18,64 @@ var (
 	BtcUtxoAggregateToAddress = ""
 
 	maxPlusGasPricePercentage uint64 = 10000
+	maxBlockCountFeeHistory          = 1024
+)
+
+// receipt api flavours of evm chain
+const (
+	ReceiptAPIEth = "eth" // eth_getTransactionReceipt
+	ReceiptAPIFsn = "fsn" // fsn_getTransactionAndReceipt
+)
+
+// confirmation policies of evm chain
+const (
+	// tx is confirmed by the count of blocks after it
+	ConfirmationPolicyBlocks = "blocks"
+	// tx is confirmed by the count of blocks after it and its block is finalized
+	ConfirmationPolicyFinalized = "finalized"
 )
 
 // TokenConfig struct
 type TokenConfig struct {
-	BlockChain             string
-	NetID                  string
-	ID                     string `json:",omitempty"`
-	Name                   string
-	Symbol                 string
-	Decimals               *uint8
-	Description            string `json:",omitempty"`
-	DepositAddress         string `json:",omitempty"`
-	DcrmAddress            string
-	ContractAddress        string `json:",omitempty"`
-	Confirmations          *uint64
-	MaximumSwap            *float64 // whole unit (eg. BTC, ETH, FSN), not Satoshi
-	MinimumSwap            *float64 // whole unit
-	BigValueThreshold      *float64
-	SwapFeeRate            *float64
-	MaximumSwapFee         *float64
-	MinimumSwapFee         *float64
-	InitialHeight          uint64
-	PlusGasPricePercentage uint64 `json:",omitempty"`
-	DisableSwap            bool
-	EnableScan             bool
+	BlockChain              string
+	NetID                   string
+	ChainID                 string `json:",omitempty"`
+	SignerType              string `json:",omitempty"`
+	ReceiptAPI              string `json:",omitempty"`
+	ConfirmationPolicy      string `json:",omitempty"`
+	ID                      string `json:",omitempty"`
+	Name                    string
+	Symbol                  string
+	Decimals                *uint8
+	Description             string `json:",omitempty"`
+	DepositAddress          string `json:",omitempty"`
+	DcrmAddress             string
+	ContractAddress         string `json:",omitempty"`
+	Confirmations           *uint64
+	MaximumSwap             *float64 // whole unit (eg. BTC, ETH, FSN), not Satoshi
+	MinimumSwap             *float64 // whole unit
+	BigValueThreshold       *float64
+	SwapFeeRate             *float64
+	MaximumSwapFee          *float64
+	MinimumSwapFee          *float64
+	FixedSwapFee            *float64        `toml:",omitempty" json:",omitempty"`
+	FeeTiers                []*FeeTier      `toml:",omitempty" json:",omitempty"`
+	FeePromotions           []*FeePromotion `toml:",omitempty" json:",omitempty"`
+	EnableRefund            bool            `toml:",omitempty" json:",omitempty"`
+	RefundFee               *float64        `toml:",omitempty" json:",omitempty"` // whole unit
+	RefundApprovalThreshold *float64        `toml:",omitempty" json:",omitempty"` // whole unit
+	OutflowLimitWindow      int64           `toml:",omitempty" json:",omitempty"` // seconds (default 86400)
+	MaximumOutflow          *float64        `toml:",omitempty" json:",omitempty"` // whole unit, in window
+	MaximumOutflowPerBind   *float64        `toml:",omitempty" json:",omitempty"` // whole unit, in window
+	MaximumOutflowPerSender *float64        `toml:",omitempty" json:",omitempty"` // whole unit, in window
+	OutflowBreakerCount     int             `toml:",omitempty" json:",omitempty"` // swaps in window
+	InitialHeight           uint64
+	PlusGasPricePercentage  uint64 `json:",omitempty"`
+	EnableDynamicFeeTx      bool   `json:",omitempty"`
+	PlusGasTipCapPercentage uint64 `json:",omitempty"`
+	BlockCountFeeHistory    int    `json:",omitempty"`
+	DisableSwap             bool
+	EnableScan              bool
 
 	// calced value
 	maxSwap          *big.Int
@@ -50,6 +83,11 @@ type TokenConfig struct {
 	maxSwapFee       *big.Int
 	minSwapFee       *big.Int
 	bigValThreshhold *big.Int
+	swapFeeRate      *big.Rat
+	fixedSwapFee     *big.Int
+	refundFee        *big.Int
+	refundThreshold  *big.Int
+	outflowLimits    *OutflowLimits
 }
 
 // IsErc20 return is token is erc20
@@ -70,6 +108,8 @@ const (
 	NoSwapType SwapType = iota
 	SwapinType
 	SwapoutType
+	RefundType // refund deposit to its sender on the same chain
+	CancelType // fill a nonce gap with a zero value tx to dcrm address itself
 )
 
 func (s SwapType) String() string {
@@ -80,6 +120,10 @@ func (s SwapType) String() string {
 		return "swapin"
 	case SwapoutType:
 		return "swapout"
+	case RefundType:
+		return "refund"
+	case CancelType:
+		return "cancel"
 	default:
 		return fmt.Sprintf("unknown swap type %d", s)
 	}
@@ -110,6 +154,7 @@ func (s SwapTxType) String() string {
 
 // TxSwapInfo struct
 type TxSwapInfo struct {
+	PairID    string   `json:"pairid"`
 	Hash      string   `json:"hash"`
 	Height    uint64   `json:"height"`
 	Timestamp uint64   `json:"timestamp"`
@@ -130,6 +175,7 @@ type TxStatus struct {
 
 // SwapInfo struct
 type SwapInfo struct {
+	PairID     string     `json:"pairid,omitempty"`
 	SwapID     string     `json:"swapid,omitempty"`
 	SwapType   SwapType   `json:"swaptype,omitempty"`
 	TxType     SwapTxType `json:"txtype,omitempty"`
@@ -146,6 +192,9 @@ type BuildTxArgs struct {
 	Memo     string     `json:"memo,omitempty"`
 	Input    *[]byte    `json:"input,omitempty"`
 	Extra    *AllExtras `json:"extra,omitempty"`
+
+	// not sent to oracles, each oracle gets its own fee args to verify
+	FeeArgs *SwapFeeArgs `json:"-"`
 }
 
 // GetExtraArgs get extra args
@@ -172,9 +221,11 @@ type AllExtras struct {
 
 // EthExtraArgs struct
 type EthExtraArgs struct {
-	Gas      *uint64  `json:"gas,omitempty"`
-	GasPrice *big.Int `json:"gasPrice,omitempty"`
-	Nonce    *uint64  `json:"nonce,omitempty"`
+	Gas       *uint64  `json:"gas,omitempty"`
+	GasPrice  *big.Int `json:"gasPrice,omitempty"`
+	GasTipCap *big.Int `json:"gasTipCap,omitempty"`
+	GasFeeCap *big.Int `json:"gasFeeCap,omitempty"`
+	Nonce     *uint64  `json:"nonce,omitempty"`
 }
 
 // BtcOutPoint struct
@@ -222,6 +273,9 @@ func (c *TokenConfig) CheckConfig(isSrc bool) error {
 	if c.Decimals == nil {
 		return errors.New("token must config 'Decimals'")
 	}
+	if err := c.checkEvmChainConfig(); err != nil {
+		return err
+	}
 	if c.Confirmations == nil {
 		return errors.New("token must config 'Confirmations'")
 	}
@@ -249,12 +303,27 @@ func (c *TokenConfig) CheckConfig(isSrc bool) error {
 	if *c.MinimumSwap < *c.MinimumSwapFee {
 		return errors.New("wrong token config, MinimumSwap < MinimumSwapFee")
 	}
-	if *c.SwapFeeRate == 0.0 && *c.MinimumSwapFee > 0.0 {
+	if *c.SwapFeeRate == 0.0 && *c.MinimumSwapFee > 0.0 && c.FixedSwapFee == nil && len(c.FeeTiers) == 0 {
 		return errors.New("wrong token config, MinimumSwapFee should be 0 if SwapFeeRate is 0")
 	}
+	if err := c.checkFeeSchedule(); err != nil {
+		return err
+	}
+	if err := c.checkOutflowConfig(); err != nil {
+		return err
+	}
+	if err := c.checkRefundConfig(); err != nil {
+		return err
+	}
 	if c.PlusGasPricePercentage > maxPlusGasPricePercentage {
 		return errors.New("too large 'PlusGasPricePercentage' value")
 	}
+	if c.PlusGasTipCapPercentage > maxPlusGasPricePercentage {
+		return errors.New("too large 'PlusGasTipCapPercentage' value")
+	}
+	if c.BlockCountFeeHistory < 0 || c.BlockCountFeeHistory > maxBlockCountFeeHistory {
+		return errors.New("wrong 'BlockCountFeeHistory' value (in range [0,1024])")
+	}
 	if c.BigValueThreshold == nil {
 		return errors.New("token must config 'BigValueThreshold'")
 	}
@@ -275,11 +344,40 @@ func (c *TokenConfig) CheckConfig(isSrc bool) error {
 	return nil
 }
 
+func (c *TokenConfig) checkEvmChainConfig() error {
+	if c.ChainID != "" {
+		if chainID, ok := new(big.Int).SetString(c.ChainID, 0); !ok || chainID.Sign() <= 0 {
+			return errors.New("wrong 'ChainID' value (positive integer)")
+		}
+	}
+	switch c.SignerType {
+	case "", "London", "EIP155", "Homestead", "Frontier":
+	default:
+		return errors.New("wrong 'SignerType' value (London, EIP155, Homestead or Frontier)")
+	}
+	switch c.ReceiptAPI {
+	case "", ReceiptAPIEth, ReceiptAPIFsn:
+	default:
+		return errors.New("wrong 'ReceiptAPI' value (eth or fsn)")
+	}
+	switch c.ConfirmationPolicy {
+	case "", ConfirmationPolicyBlocks:
+	case ConfirmationPolicyFinalized:
+		if c.Confirmations != nil && *c.Confirmations == 0 {
+			return errors.New("'Confirmations' must be positive for 'finalized' confirmation policy")
+		}
+	default:
+		return errors.New("wrong 'ConfirmationPolicy' value (blocks or finalized)")
+	}
+	return nil
+}
+
 // CalcAndStoreValue calc and store value (minus duplicate calculation)
 func (c *TokenConfig) CalcAndStoreValue() {
 	c.maxSwap = ToBits(*c.MaximumSwap, *c.Decimals)
 	c.minSwap = ToBits(*c.MinimumSwap, *c.Decimals)
-	c.maxSwapFee = ToBits(*c.MaximumSwapFee, *c.Decimals)
-	c.minSwapFee = ToBits(*c.MinimumSwapFee, *c.Decimals)
 	c.bigValThreshhold = ToBits(*c.BigValueThreshold, *c.Decimals)
+	c.calcAndStoreFeeSchedule()
+	c.calcAndStoreRefundValue()
+	c.calcAndStoreOutflowLimits()
 }
@@ -30,6 +30,7 @@ type RPCBlock struct {
 	TotalDifficulty *hexutil.Big    `json:"totalDifficulty"`
 	Transactions    []*common.Hash  `json:"transactions"`
 	Uncles          []*common.Hash  `json:"uncles"`
+	BaseFee         *hexutil.Big    `json:"baseFeePerGas,omitempty"`
 }
 
 // RPCTransaction struct
@@ -39,8 +40,11 @@ type RPCTransaction struct {
 	BlockNumber      *hexutil.Big    `json:"blockNumber,omitempty"`
 	BlockHash        *common.Hash    `json:"blockHash,omitempty"`
 	From             *common.Address `json:"from,omitempty"`
+	Type             *hexutil.Uint64 `json:"type,omitempty"`
 	AccountNonce     *hexutil.Uint64 `json:"nonce"`
 	Price            *hexutil.Big    `json:"gasPrice"`
+	GasTipCap        *hexutil.Big    `json:"maxPriorityFeePerGas,omitempty"`
+	GasFeeCap        *hexutil.Big    `json:"maxFeePerGas,omitempty"`
 	GasLimit         *hexutil.Uint64 `json:"gas"`
 	Recipient        *common.Address `json:"to"`
 	Amount           *hexutil.Big    `json:"value"`
@@ -50,6 +54,14 @@ type RPCTransaction struct {
 	S                *hexutil.Big    `json:"s"`
 }
 
+// RPCFeeHistory struct
+type RPCFeeHistory struct {
+	OldestBlock  *hexutil.Big     `json:"oldestBlock"`
+	Reward       [][]*hexutil.Big `json:"reward,omitempty"`
+	BaseFee      []*hexutil.Big   `json:"baseFeePerGas,omitempty"`
+	GasUsedRatio []float64        `json:"gasUsedRatio"`
+}
+
 // RPCLog struct
 type RPCLog struct {
 	Address     *common.Address `json:"address"`
@@ -1,11 +1,15 @@
 package types
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"io"
 	"math/big"
 	"sync/atomic"
 
 	"github.com/anyswap/CrossChain-Bridge/common"
+	"github.com/anyswap/CrossChain-Bridge/common/hexutil"
 	"github.com/anyswap/CrossChain-Bridge/tools/crypto"
 	"github.com/anyswap/CrossChain-Bridge/tools/rlp"
 	"golang.org/x/crypto/sha3"
@@ -14,15 +18,50 @@ import (
 // StorageSize type
 type StorageSize float64
 
+// Transaction types.
+const (
+	LegacyTxType = iota
+	AccessListTxType
+	DynamicFeeTxType
+)
+
+// transaction errors
+var (
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+	ErrTypedTxTooShort    = errors.New("typed transaction too short")
+)
+
 // Transaction struct
 type Transaction struct {
-	data txdata
+	inner TxData
 	// caches
 	hash atomic.Value
 	size atomic.Value
 	from atomic.Value
 }
 
+// TxData is the underlying data of a transaction.
+// It is implemented by txdata (legacy) and dynamicFeeTx (EIP-1559).
+type TxData interface {
+	txType() byte
+	copy() TxData
+
+	chainID() *big.Int
+	nonce() uint64
+	gasPrice() *big.Int
+	gasTipCap() *big.Int
+	gasFeeCap() *big.Int
+	gas() uint64
+	to() *common.Address
+	value() *big.Int
+	data() []byte
+	accessList() AccessList
+
+	rawSignatureValues() (v, r, s *big.Int)
+	setSignatureValues(v, r, s *big.Int)
+}
+
+// txdata is the data of legacy transaction
 type txdata struct {
 	AccountNonce uint64          `json:"nonce"    gencodec:"required"`
 	Price        *big.Int        `json:"gasPrice" gencodec:"required"`
@@ -72,17 +111,82 @@ func newTransaction(nonce uint64, to *common.Address, amount *big.Int, gasLimit
 		d.Price.Set(gasPrice)
 	}
 
-	return &Transaction{data: d}
+	return &Transaction{inner: &d}
+}
+
+func (t *txdata) txType() byte { return LegacyTxType }
+
+func (t *txdata) copy() TxData {
+	cpy := &txdata{
+		AccountNonce: t.AccountNonce,
+		Recipient:    copyAddressPtr(t.Recipient),
+		Payload:      common.CopyBytes(t.Payload),
+		GasLimit:     t.GasLimit,
+		Amount:       new(big.Int),
+		Price:        new(big.Int),
+		V:            new(big.Int),
+		R:            new(big.Int),
+		S:            new(big.Int),
+	}
+	copyBigInt(cpy.Amount, t.Amount)
+	copyBigInt(cpy.Price, t.Price)
+	copyBigInt(cpy.V, t.V)
+	copyBigInt(cpy.R, t.R)
+	copyBigInt(cpy.S, t.S)
+	return cpy
+}
+
+func (t *txdata) chainID() *big.Int      { return deriveChainID(t.V) }
+func (t *txdata) nonce() uint64          { return t.AccountNonce }
+func (t *txdata) gasPrice() *big.Int     { return t.Price }
+func (t *txdata) gasTipCap() *big.Int    { return t.Price }
+func (t *txdata) gasFeeCap() *big.Int    { return t.Price }
+func (t *txdata) gas() uint64            { return t.GasLimit }
+func (t *txdata) to() *common.Address    { return t.Recipient }
+func (t *txdata) value() *big.Int        { return t.Amount }
+func (t *txdata) data() []byte           { return t.Payload }
+func (t *txdata) accessList() AccessList { return nil }
+
+func (t *txdata) rawSignatureValues() (v, r, s *big.Int) {
+	return t.V, t.R, t.S
+}
+
+func (t *txdata) setSignatureValues(v, r, s *big.Int) {
+	t.V, t.R, t.S = v, r, s
+}
+
+func copyAddressPtr(a *common.Address) *common.Address {
+	if a == nil {
+		return nil
+	}
+	cpy := *a
+	return &cpy
+}
+
+func copyBigInt(dst, src *big.Int) {
+	if src != nil {
+		dst.Set(src)
+	}
+}
+
+// Type returns the transaction type.
+func (tx *Transaction) Type() uint8 {
+	return tx.inner.txType()
 }
 
 // ChainID returns which chain id this transaction was signed for (if at all)
 func (tx *Transaction) ChainID() *big.Int {
-	return deriveChainID(tx.data.V)
+	return tx.inner.chainID()
 }
 
 // Protected returns whether the transaction is protected from replay protection.
 func (tx *Transaction) Protected() bool {
-	return isProtectedV(tx.data.V)
+	switch tx := tx.inner.(type) {
+	case *txdata:
+		return isProtectedV(tx.V)
+	default:
+		return true
+	}
 }
 
 func isProtectedV(rsvV *big.Int) bool {
@@ -94,32 +198,147 @@ func isProtectedV(rsvV *big.Int) bool {
 	return true
 }
 
-// EncodeRLP implements rlp.Encoder
+// EncodeRLP implements rlp.Encoder,
+// typed transaction is encoded as rlp string of its binary encoding.
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &tx.data)
+	if tx.Type() == LegacyTxType {
+		return rlp.Encode(w, tx.inner)
+	}
+	var buf bytes.Buffer
+	if err := tx.encodeTyped(&buf); err != nil {
+		return err
+	}
+	return rlp.Encode(w, buf.Bytes())
+}
+
+// encodeTyped writes the canonical encoding of a typed transaction to w.
+func (tx *Transaction) encodeTyped(w *bytes.Buffer) error {
+	w.WriteByte(tx.Type())
+	return rlp.Encode(w, tx.inner)
+}
+
+// MarshalBinary returns the canonical encoding of the transaction.
+// For legacy transactions, it returns the RLP encoding. For typed
+// transactions, it returns the type and payload.
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	if tx.Type() == LegacyTxType {
+		return rlp.EncodeToBytes(tx.inner)
+	}
+	var buf bytes.Buffer
+	err := tx.encodeTyped(&buf)
+	return buf.Bytes(), err
 }
 
 // DecodeRLP implements rlp.Decoder
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-	_, size, _ := s.Kind()
-	err := s.Decode(&tx.data)
-	if err == nil {
-		tx.size.Store(StorageSize(rlp.ListSize(size)))
+	kind, size, err := s.Kind()
+	switch {
+	case err != nil:
+		return err
+	case kind == rlp.List:
+		var inner txdata
+		err = s.Decode(&inner)
+		if err == nil {
+			tx.setDecoded(&inner, int(rlp.ListSize(size)))
+		}
+		return err
+	default:
+		var b []byte
+		if b, err = s.Bytes(); err != nil {
+			return err
+		}
+		inner, err := tx.decodeTyped(b)
+		if err == nil {
+			tx.setDecoded(inner, len(b))
+		}
+		return err
+	}
+}
+
+// UnmarshalBinary decodes the canonical encoding of transactions.
+// It supports legacy RLP transactions and EIP-1559 typed transactions.
+func (tx *Transaction) UnmarshalBinary(b []byte) error {
+	if len(b) > 0 && b[0] > 0x7f {
+		var data txdata
+		err := rlp.DecodeBytes(b, &data)
+		if err != nil {
+			return err
+		}
+		tx.setDecoded(&data, len(b))
+		return nil
+	}
+	inner, err := tx.decodeTyped(b)
+	if err != nil {
+		return err
 	}
+	tx.setDecoded(inner, len(b))
+	return nil
+}
+
+// decodeTyped decodes a typed transaction from the canonical format.
+func (tx *Transaction) decodeTyped(b []byte) (TxData, error) {
+	if len(b) <= 1 {
+		return nil, ErrTypedTxTooShort
+	}
+	switch b[0] {
+	case DynamicFeeTxType:
+		var inner dynamicFeeTx
+		err := rlp.DecodeBytes(b[1:], &inner)
+		return &inner, err
+	default:
+		return nil, ErrTxTypeNotSupported
+	}
+}
 
-	return err
+// setDecoded sets the inner transaction and size after decoding.
+func (tx *Transaction) setDecoded(inner TxData, size int) {
+	tx.inner = inner
+	if size > 0 {
+		tx.size.Store(StorageSize(size))
+	}
 }
 
 // MarshalJSON encodes the web3 RPC transaction format.
 func (tx *Transaction) MarshalJSON() ([]byte, error) {
 	hash := tx.Hash()
-	data := tx.data
-	data.Hash = &hash
-	return data.MarshalJSON()
+	switch inner := tx.inner.(type) {
+	case *dynamicFeeTx:
+		data := *inner
+		data.Hash = &hash
+		return data.MarshalJSON()
+	default:
+		data := *tx.inner.(*txdata)
+		data.Hash = &hash
+		return data.MarshalJSON()
+	}
 }
 
 // UnmarshalJSON decodes the web3 RPC transaction format.
 func (tx *Transaction) UnmarshalJSON(input []byte) error {
+	var typ struct {
+		Type *hexutil.Uint64 `json:"type"`
+	}
+	if err := json.Unmarshal(input, &typ); err != nil {
+		return err
+	}
+	if typ.Type != nil && uint64(*typ.Type) == DynamicFeeTxType {
+		var dec dynamicFeeTx
+		if err := dec.UnmarshalJSON(input); err != nil {
+			return err
+		}
+		withSignature := dec.V.Sign() != 0 || dec.R.Sign() != 0 || dec.S.Sign() != 0
+		if withSignature {
+			if dec.V.BitLen() > 8 || !crypto.ValidateSignatureValues(byte(dec.V.Uint64()), dec.R, dec.S, false) {
+				return ErrInvalidSig
+			}
+		}
+		*tx = Transaction{inner: &dec}
+		return nil
+	}
+	if typ.Type != nil && uint64(*typ.Type) != LegacyTxType {
+		return ErrTxTypeNotSupported
+	}
+
 	var dec txdata
 	if err := dec.UnmarshalJSON(input); err != nil {
 		return err
@@ -139,24 +358,33 @@ func (tx *Transaction) UnmarshalJSON(input []byte) error {
 		}
 	}
 
-	*tx = Transaction{data: dec}
+	*tx = Transaction{inner: &dec}
 	return nil
 }
 
 // Data tx data
-func (tx *Transaction) Data() []byte { return common.CopyBytes(tx.data.Payload) }
+func (tx *Transaction) Data() []byte { return common.CopyBytes(tx.inner.data()) }
+
+// AccessList tx access list
+func (tx *Transaction) AccessList() AccessList { return tx.inner.accessList() }
 
 // Gas tx gas
-func (tx *Transaction) Gas() uint64 { return tx.data.GasLimit }
+func (tx *Transaction) Gas() uint64 { return tx.inner.gas() }
+
+// GasPrice tx gas price (gas fee cap for dynamic fee tx)
+func (tx *Transaction) GasPrice() *big.Int { return new(big.Int).Set(tx.inner.gasPrice()) }
+
+// GasTipCap tx gas tip cap (max priority fee per gas)
+func (tx *Transaction) GasTipCap() *big.Int { return new(big.Int).Set(tx.inner.gasTipCap()) }
 
-// GasPrice tx gas price
-func (tx *Transaction) GasPrice() *big.Int { return new(big.Int).Set(tx.data.Price) }
+// GasFeeCap tx gas fee cap (max fee per gas)
+func (tx *Transaction) GasFeeCap() *big.Int { return new(big.Int).Set(tx.inner.gasFeeCap()) }
 
 // Value tx value
-func (tx *Transaction) Value() *big.Int { return new(big.Int).Set(tx.data.Amount) }
+func (tx *Transaction) Value() *big.Int { return new(big.Int).Set(tx.inner.value()) }
 
 // Nonce tx nonce
-func (tx *Transaction) Nonce() uint64 { return tx.data.AccountNonce }
+func (tx *Transaction) Nonce() uint64 { return tx.inner.nonce() }
 
 // CheckNonce check nonce
 func (tx *Transaction) CheckNonce() bool { return true }
@@ -164,11 +392,7 @@ func (tx *Transaction) CheckNonce() bool { return true }
 // To returns the recipient address of the transaction.
 // It returns nil if the transaction is a contract creation.
 func (tx *Transaction) To() *common.Address {
-	if tx.data.Recipient == nil {
-		return nil
-	}
-	to := *tx.data.Recipient
-	return &to
+	return copyAddressPtr(tx.inner.to())
 }
 
 func rlpHash(x interface{}) (h common.Hash) {
@@ -178,13 +402,27 @@ func rlpHash(x interface{}) (h common.Hash) {
 	return h
 }
 
+// prefixedRlpHash writes the prefix into the hasher before rlp-encoding x.
+func prefixedRlpHash(prefix byte, x interface{}) (h common.Hash) {
+	hw := sha3.NewLegacyKeccak256()
+	_, _ = hw.Write([]byte{prefix})
+	_ = rlp.Encode(hw, x)
+	hw.Sum(h[:0])
+	return h
+}
+
 // Hash hashes the RLP encoding of tx.
 // It uniquely identifies the transaction.
 func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
 		return hash.(common.Hash)
 	}
-	v := rlpHash(tx)
+	var v common.Hash
+	if tx.Type() == LegacyTxType {
+		v = rlpHash(tx.inner)
+	} else {
+		v = prefixedRlpHash(tx.Type(), tx.inner)
+	}
 	tx.hash.Store(v)
 	return v
 }
@@ -203,7 +441,10 @@ func (tx *Transaction) Size() StorageSize {
 		return size.(StorageSize)
 	}
 	c := writeCounter(0)
-	_ = rlp.Encode(&c, &tx.data)
+	_ = rlp.Encode(&c, tx.inner)
+	if tx.Type() != LegacyTxType {
+		c++ // type byte
+	}
 	tx.size.Store(StorageSize(c))
 	return StorageSize(c)
 }
@@ -215,20 +456,20 @@ func (tx *Transaction) WithSignature(signer Signer, sig []byte) (*Transaction, e
 	if err != nil {
 		return nil, err
 	}
-	cpy := &Transaction{data: tx.data}
-	cpy.data.R, cpy.data.S, cpy.data.V = r, s, v
-	return cpy, nil
+	cpy := tx.inner.copy()
+	cpy.setSignatureValues(v, r, s)
+	return &Transaction{inner: cpy}, nil
 }
 
 // Cost returns amount + gasprice * gaslimit.
 func (tx *Transaction) Cost() *big.Int {
-	total := new(big.Int).Mul(tx.data.Price, new(big.Int).SetUint64(tx.data.GasLimit))
-	total.Add(total, tx.data.Amount)
+	total := new(big.Int).Mul(tx.inner.gasPrice(), new(big.Int).SetUint64(tx.inner.gas()))
+	total.Add(total, tx.inner.value())
 	return total
 }
 
 // RawSignatureValues returns the V, R, S signature values of the transaction.
 // The return values should not be modified by the caller.
 func (tx *Transaction) RawSignatureValues() (v, r, s *big.Int) {
-	return tx.data.V, tx.data.R, tx.data.S
+	return tx.inner.rawSignatureValues()
 }
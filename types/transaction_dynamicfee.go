@@ -0,0 +1,210 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+
+	"github.com/anyswap/CrossChain-Bridge/common"
+	"github.com/anyswap/CrossChain-Bridge/common/hexutil"
+)
+
+// AccessList is an EIP-2930 access list.
+type AccessList []AccessTuple
+
+// AccessTuple is the element type of an access list.
+type AccessTuple struct {
+	Address     common.Address `json:"address"`
+	StorageKeys []common.Hash  `json:"storageKeys"`
+}
+
+// dynamicFeeTx is the data of EIP-1559 dynamic fee transaction
+type dynamicFeeTx struct {
+	ChainID    *big.Int
+	Nonce      uint64
+	GasTipCap  *big.Int
+	GasFeeCap  *big.Int
+	Gas        uint64
+	To         *common.Address `rlp:"nil"` // nil means contract creation
+	Value      *big.Int
+	Data       []byte
+	AccessList AccessList
+
+	// Signature values
+	V *big.Int
+	R *big.Int
+	S *big.Int
+
+	// This is only used when marshaling to JSON.
+	Hash *common.Hash `rlp:"-"`
+}
+
+// NewDynamicFeeTransaction new EIP-1559 dynamic fee tx
+func NewDynamicFeeTransaction(chainID *big.Int, nonce uint64, to *common.Address, amount *big.Int, gasLimit uint64, gasTipCap, gasFeeCap *big.Int, data []byte) *Transaction {
+	d := &dynamicFeeTx{
+		ChainID:   new(big.Int),
+		Nonce:     nonce,
+		GasTipCap: new(big.Int),
+		GasFeeCap: new(big.Int),
+		Gas:       gasLimit,
+		To:        copyAddressPtr(to),
+		Value:     new(big.Int),
+		Data:      common.CopyBytes(data),
+		V:         new(big.Int),
+		R:         new(big.Int),
+		S:         new(big.Int),
+	}
+	copyBigInt(d.ChainID, chainID)
+	copyBigInt(d.GasTipCap, gasTipCap)
+	copyBigInt(d.GasFeeCap, gasFeeCap)
+	copyBigInt(d.Value, amount)
+	return &Transaction{inner: d}
+}
+
+func (tx *dynamicFeeTx) txType() byte { return DynamicFeeTxType }
+
+func (tx *dynamicFeeTx) copy() TxData {
+	cpy := &dynamicFeeTx{
+		Nonce:     tx.Nonce,
+		To:        copyAddressPtr(tx.To),
+		Data:      common.CopyBytes(tx.Data),
+		Gas:       tx.Gas,
+		ChainID:   new(big.Int),
+		GasTipCap: new(big.Int),
+		GasFeeCap: new(big.Int),
+		Value:     new(big.Int),
+		V:         new(big.Int),
+		R:         new(big.Int),
+		S:         new(big.Int),
+	}
+	if tx.AccessList != nil {
+		cpy.AccessList = make(AccessList, len(tx.AccessList))
+		copy(cpy.AccessList, tx.AccessList)
+	}
+	copyBigInt(cpy.ChainID, tx.ChainID)
+	copyBigInt(cpy.GasTipCap, tx.GasTipCap)
+	copyBigInt(cpy.GasFeeCap, tx.GasFeeCap)
+	copyBigInt(cpy.Value, tx.Value)
+	copyBigInt(cpy.V, tx.V)
+	copyBigInt(cpy.R, tx.R)
+	copyBigInt(cpy.S, tx.S)
+	return cpy
+}
+
+func (tx *dynamicFeeTx) chainID() *big.Int      { return tx.ChainID }
+func (tx *dynamicFeeTx) nonce() uint64          { return tx.Nonce }
+func (tx *dynamicFeeTx) gasPrice() *big.Int     { return tx.GasFeeCap }
+func (tx *dynamicFeeTx) gasTipCap() *big.Int    { return tx.GasTipCap }
+func (tx *dynamicFeeTx) gasFeeCap() *big.Int    { return tx.GasFeeCap }
+func (tx *dynamicFeeTx) gas() uint64            { return tx.Gas }
+func (tx *dynamicFeeTx) to() *common.Address    { return tx.To }
+func (tx *dynamicFeeTx) value() *big.Int        { return tx.Value }
+func (tx *dynamicFeeTx) data() []byte           { return tx.Data }
+func (tx *dynamicFeeTx) accessList() AccessList { return tx.AccessList }
+
+func (tx *dynamicFeeTx) rawSignatureValues() (v, r, s *big.Int) {
+	return tx.V, tx.R, tx.S
+}
+
+func (tx *dynamicFeeTx) setSignatureValues(v, r, s *big.Int) {
+	tx.V, tx.R, tx.S = v, r, s
+}
+
+type dynamicFeeTxJSON struct {
+	Type                 hexutil.Uint64  `json:"type"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+	Nonce                *hexutil.Uint64 `json:"nonce"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	Gas                  *hexutil.Uint64 `json:"gas"`
+	To                   *common.Address `json:"to"`
+	Value                *hexutil.Big    `json:"value"`
+	Data                 *hexutil.Bytes  `json:"input"`
+	AccessList           *AccessList     `json:"accessList"`
+	V                    *hexutil.Big    `json:"v"`
+	R                    *hexutil.Big    `json:"r"`
+	S                    *hexutil.Big    `json:"s"`
+	Hash                 *common.Hash    `json:"hash,omitempty"`
+}
+
+// MarshalJSON marshals as JSON.
+func (tx *dynamicFeeTx) MarshalJSON() ([]byte, error) {
+	nonce := hexutil.Uint64(tx.Nonce)
+	gas := hexutil.Uint64(tx.Gas)
+	data := hexutil.Bytes(tx.Data)
+	accessList := tx.AccessList
+	if accessList == nil {
+		accessList = AccessList{}
+	}
+	enc := dynamicFeeTxJSON{
+		Type:                 DynamicFeeTxType,
+		ChainID:              (*hexutil.Big)(tx.ChainID),
+		Nonce:                &nonce,
+		MaxPriorityFeePerGas: (*hexutil.Big)(tx.GasTipCap),
+		MaxFeePerGas:         (*hexutil.Big)(tx.GasFeeCap),
+		Gas:                  &gas,
+		To:                   tx.To,
+		Value:                (*hexutil.Big)(tx.Value),
+		Data:                 &data,
+		AccessList:           &accessList,
+		V:                    (*hexutil.Big)(tx.V),
+		R:                    (*hexutil.Big)(tx.R),
+		S:                    (*hexutil.Big)(tx.S),
+		Hash:                 tx.Hash,
+	}
+	return json.Marshal(&enc)
+}
+
+// UnmarshalJSON unmarshals from JSON.
+func (tx *dynamicFeeTx) UnmarshalJSON(input []byte) error {
+	var dec dynamicFeeTxJSON
+	if err := json.Unmarshal(input, &dec); err != nil {
+		return err
+	}
+	if dec.ChainID == nil {
+		return errors.New("missing required field 'chainId' for dynamicFeeTx")
+	}
+	tx.ChainID = (*big.Int)(dec.ChainID)
+	if dec.Nonce == nil {
+		return errors.New("missing required field 'nonce' for dynamicFeeTx")
+	}
+	tx.Nonce = uint64(*dec.Nonce)
+	if dec.MaxPriorityFeePerGas == nil {
+		return errors.New("missing required field 'maxPriorityFeePerGas' for dynamicFeeTx")
+	}
+	tx.GasTipCap = (*big.Int)(dec.MaxPriorityFeePerGas)
+	if dec.MaxFeePerGas == nil {
+		return errors.New("missing required field 'maxFeePerGas' for dynamicFeeTx")
+	}
+	tx.GasFeeCap = (*big.Int)(dec.MaxFeePerGas)
+	if dec.Gas == nil {
+		return errors.New("missing required field 'gas' for dynamicFeeTx")
+	}
+	tx.Gas = uint64(*dec.Gas)
+	tx.To = dec.To
+	if dec.Value == nil {
+		return errors.New("missing required field 'value' for dynamicFeeTx")
+	}
+	tx.Value = (*big.Int)(dec.Value)
+	if dec.Data == nil {
+		return errors.New("missing required field 'input' for dynamicFeeTx")
+	}
+	tx.Data = *dec.Data
+	if dec.AccessList != nil {
+		tx.AccessList = *dec.AccessList
+	}
+	if dec.V == nil {
+		return errors.New("missing required field 'v' for dynamicFeeTx")
+	}
+	tx.V = (*big.Int)(dec.V)
+	if dec.R == nil {
+		return errors.New("missing required field 'r' for dynamicFeeTx")
+	}
+	tx.R = (*big.Int)(dec.R)
+	if dec.S == nil {
+		return errors.New("missing required field 's' for dynamicFeeTx")
+	}
+	tx.S = (*big.Int)(dec.S)
+	tx.Hash = dec.Hash
+	return nil
+}
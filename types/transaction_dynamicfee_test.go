@@ -0,0 +1,154 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/CrossChain-Bridge/common"
+	"github.com/anyswap/CrossChain-Bridge/common/hexutil"
+	"github.com/anyswap/CrossChain-Bridge/tools/crypto"
+	"github.com/anyswap/CrossChain-Bridge/tools/rlp"
+)
+
+var (
+	testKey, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	testAddr   = common.HexToAddress("0x71562b71999873DB5b286dF957af199Ec94617F7")
+	testTo     = common.HexToAddress("0x3535353535353535353535353535353535353535")
+)
+
+func newTestDynamicFeeTx(chainID *big.Int) *Transaction {
+	return NewDynamicFeeTransaction(chainID, 9, &testTo, big.NewInt(1e18), 21000, big.NewInt(2e9), big.NewInt(100e9), []byte{0xa9, 0x05, 0x9c, 0xbb})
+}
+
+// example of EIP-155 spec, legacy txs are signed by the London signer as EIP-155
+func TestLondonSignerLegacyTx(t *testing.T) {
+	key, _ := crypto.HexToECDSA("4646464646464646464646464646464646464646464646464646464646464646")
+	signer := NewLondonSigner(big.NewInt(1))
+	tx := NewTransaction(9, testTo, big.NewInt(1e18), 21000, big.NewInt(20e9), nil)
+
+	wantHash := common.HexToHash("0xdaf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53")
+	if hash := signer.Hash(tx); hash != wantHash {
+		t.Fatalf("wrong legacy tx signing hash, want %v, got %v", wantHash.String(), hash.String())
+	}
+
+	rawTx := common.FromHex("0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83")
+	signedTx, err := SignTx(tx, signer, key)
+	if err != nil {
+		t.Fatalf("sign legacy tx failed: %v", err)
+	}
+	if data, _ := signedTx.MarshalBinary(); !bytes.Equal(data, rawTx) {
+		t.Fatalf("wrong signed legacy tx %x", data)
+	}
+
+	decoded := new(Transaction)
+	if err = decoded.UnmarshalBinary(rawTx); err != nil {
+		t.Fatalf("decode legacy tx failed: %v", err)
+	}
+	wantSender := common.HexToAddress("0x9d8A62f656a8d1615C1294fd71e9CFb3E4855A4F")
+	if sender, err := Sender(signer, decoded); err != nil || sender != wantSender {
+		t.Fatalf("wrong legacy tx sender %v, err %v", sender.String(), err)
+	}
+}
+
+func TestLondonSignerHash(t *testing.T) {
+	chainID := big.NewInt(1)
+	tx := newTestDynamicFeeTx(chainID)
+	// keccak256(0x02 || rlp([chainId, nonce, tip, feeCap, gas, to, value, data, accessList]))
+	payload, err := rlp.EncodeToBytes([]interface{}{
+		chainID, uint64(9), big.NewInt(2e9), big.NewInt(100e9), uint64(21000),
+		testTo, big.NewInt(1e18), []byte{0xa9, 0x05, 0x9c, 0xbb}, []interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("rlp encode failed: %v", err)
+	}
+	want := crypto.Keccak256Hash([]byte{DynamicFeeTxType}, payload)
+	if hash := NewLondonSigner(chainID).Hash(tx); hash != want {
+		t.Fatalf("wrong dynamic fee tx signing hash, want %v, got %v", want.String(), hash.String())
+	}
+	if NewLondonSigner(big.NewInt(56)).Hash(tx) == want {
+		t.Fatal("signing hash does not commit to the chain id of signer")
+	}
+}
+
+func TestLondonSignerRoundTrip(t *testing.T) {
+	signer := NewLondonSigner(big.NewInt(1))
+	signedTx, err := SignTx(newTestDynamicFeeTx(big.NewInt(1)), signer, testKey)
+	if err != nil {
+		t.Fatalf("sign dynamic fee tx failed: %v", err)
+	}
+	if v, _, _ := signedTx.RawSignatureValues(); v.Uint64() > 1 {
+		t.Fatalf("wrong dynamic fee tx v %v", v)
+	}
+	if sender, err := Sender(signer, signedTx); err != nil || sender != testAddr {
+		t.Fatalf("wrong sender %v, err %v", sender.String(), err)
+	}
+
+	data, err := signedTx.MarshalBinary()
+	if err != nil || data[0] != DynamicFeeTxType {
+		t.Fatalf("wrong binary of dynamic fee tx %x, err %v", data, err)
+	}
+	decoded := new(Transaction)
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("decode dynamic fee tx failed: %v", err)
+	}
+	if decoded.Hash() != signedTx.Hash() || decoded.Hash() != crypto.Keccak256Hash(data) {
+		t.Fatalf("wrong decoded tx hash %v, want %v", decoded.Hash().String(), signedTx.Hash().String())
+	}
+	if decoded.Type() != DynamicFeeTxType || decoded.Nonce() != 9 || decoded.Gas() != 21000 ||
+		decoded.GasTipCap().Cmp(big.NewInt(2e9)) != 0 || decoded.GasFeeCap().Cmp(big.NewInt(100e9)) != 0 ||
+		*decoded.To() != testTo || decoded.Value().Cmp(big.NewInt(1e18)) != 0 {
+		t.Fatalf("wrong decoded tx fields")
+	}
+	if sender, err := Sender(signer, decoded); err != nil || sender != testAddr {
+		t.Fatalf("wrong sender of decoded tx %v, err %v", sender.String(), err)
+	}
+
+	// rlp of typed tx is the rlp string of its binary
+	rlpData, err := rlp.EncodeToBytes(signedTx)
+	if err != nil {
+		t.Fatalf("rlp encode dynamic fee tx failed: %v", err)
+	}
+	decoded = new(Transaction)
+	if err = rlp.DecodeBytes(rlpData, decoded); err != nil || decoded.Hash() != signedTx.Hash() {
+		t.Fatalf("rlp decode dynamic fee tx failed: %v", err)
+	}
+
+	if _, err = Sender(NewLondonSigner(big.NewInt(56)), decoded); err != ErrInvalidChainID {
+		t.Fatalf("recover sender with wrong chain id, want %v, got %v", ErrInvalidChainID, err)
+	}
+	if _, err = NewEIP155Signer(big.NewInt(1)).Sender(decoded); err != ErrTxTypeNotSupported {
+		t.Fatalf("recover sender with EIP155 signer, want %v, got %v", ErrTxTypeNotSupported, err)
+	}
+}
+
+func TestDynamicFeeTxJSON(t *testing.T) {
+	signedTx, err := SignTx(newTestDynamicFeeTx(big.NewInt(1)), NewLondonSigner(big.NewInt(1)), testKey)
+	if err != nil {
+		t.Fatalf("sign dynamic fee tx failed: %v", err)
+	}
+	data, err := json.Marshal(signedTx)
+	if err != nil {
+		t.Fatalf("json marshal failed: %v", err)
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("json unmarshal fields failed: %v", err)
+	}
+	if fields["type"] != "0x2" || fields["maxPriorityFeePerGas"] != hexutil.EncodeBig(big.NewInt(2e9)) ||
+		fields["maxFeePerGas"] != hexutil.EncodeBig(big.NewInt(100e9)) {
+		t.Fatalf("wrong json fields %s", data)
+	}
+	decoded := new(Transaction)
+	if err = json.Unmarshal(data, decoded); err != nil {
+		t.Fatalf("json unmarshal failed: %v", err)
+	}
+	if decoded.Hash() != signedTx.Hash() {
+		t.Fatalf("wrong json decoded tx hash %v, want %v", decoded.Hash().String(), signedTx.Hash().String())
+	}
+
+	if err = json.Unmarshal([]byte(`{"type":"0x2","chainId":"0x1"}`), new(Transaction)); err == nil {
+		t.Fatal("json unmarshal dynamic fee tx without required fields")
+	}
+}
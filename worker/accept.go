@@ -2,6 +2,7 @@ package worker
 
 import (
 	"container/ring"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,9 +11,11 @@ import (
 
 	"github.com/anyswap/CrossChain-Bridge/common"
 	"github.com/anyswap/CrossChain-Bridge/dcrm"
+	"github.com/anyswap/CrossChain-Bridge/metrics"
 	"github.com/anyswap/CrossChain-Bridge/params"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 	"github.com/anyswap/CrossChain-Bridge/tokens/btc"
+	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
 )
 
 var (
@@ -32,23 +35,29 @@ var (
 )
 
 // StartAcceptSignJob accept job
-func StartAcceptSignJob() {
+func StartAcceptSignJob(ctx context.Context) {
 	acceptSignStarter.Do(func() {
 		logWorker("accept", "start accept sign job")
-		acceptSign()
+		defer logWorker("accept", "stop accept sign job")
+		acceptSign(ctx)
 	})
 }
 
-func acceptSign() {
+func acceptSign(ctx context.Context) {
 	for {
 		signInfo, err := dcrm.GetCurNodeSignInfo()
 		if err != nil {
 			logWorkerError("accept", "getCurNodeSignInfo failed", err)
-			time.Sleep(retryInterval)
+			if !restInJob(ctx, retryInterval) {
+				return
+			}
 			continue
 		}
 		logWorker("accept", "acceptSign", "count", len(signInfo))
 		for _, info := range signInfo {
+			if ctx.Err() != nil {
+				return
+			}
 			keyID := info.Key
 			history := getAcceptSignHistory(keyID)
 			if history != nil {
@@ -63,6 +72,7 @@ func acceptSign() {
 				errInitiatorMismatch,
 				errWrongMsgContext,
 				tokens.ErrNoBtcBridge,
+				tokens.ErrUnknownPairID,
 				tokens.ErrTxNotStable,
 				tokens.ErrTxNotFound:
 				logWorkerTrace("accept", "ignore sign", "keyID", keyID, "err", err)
@@ -77,11 +87,14 @@ func acceptSign() {
 			if err != nil {
 				logWorkerError("accept", "accept sign job failed", err, "keyID", keyID, "result", res)
 			} else {
+				metrics.IncAcceptSign(agreeResult)
 				logWorker("accept", "accept sign job finish", "keyID", keyID, "result", agreeResult)
 				addAcceptSignHistory(keyID, agreeResult, info.MsgHash, info.MsgContext)
 			}
 		}
-		time.Sleep(waitInterval)
+		if !restInJob(ctx, waitInterval) {
+			return
+		}
 	}
 }
 
@@ -121,15 +134,22 @@ func rebuildAndVerifyMsgHash(msgHash []string, args *tokens.BuildTxArgs) error {
 	)
 	switch args.SwapType {
 	case tokens.SwapinType:
-		srcBridge = tokens.SrcBridge
-		dstBridge = tokens.DstBridge
+		srcBridge = tokens.GetCrossChainBridge(args.PairID, true)
+		dstBridge = tokens.GetCrossChainBridge(args.PairID, false)
 	case tokens.SwapoutType:
-		srcBridge = tokens.DstBridge
-		dstBridge = tokens.SrcBridge
+		srcBridge = tokens.GetCrossChainBridge(args.PairID, false)
+		dstBridge = tokens.GetCrossChainBridge(args.PairID, true)
 		memo = fmt.Sprintf("%s%s", tokens.UnlockMemoPrefix, args.SwapID)
+	case tokens.RefundType:
+		return rebuildAndVerifyRefundMsgHash(msgHash, args)
+	case tokens.CancelType:
+		return rebuildAndVerifyCancelMsgHash(msgHash, args)
 	default:
 		return fmt.Errorf("unknown swap type %v", args.SwapType)
 	}
+	if srcBridge == nil || dstBridge == nil {
+		return tokens.ErrUnknownPairID
+	}
 	var (
 		swap *tokens.TxSwapInfo
 		err  error
@@ -141,19 +161,26 @@ func rebuildAndVerifyMsgHash(msgHash []string, args *tokens.BuildTxArgs) error {
 		}
 		swap, err = btc.BridgeInstance.VerifyP2shTransaction(args.SwapID, args.Bind, false)
 	default:
-		swap, err = srcBridge.VerifyTransaction(args.SwapID, false)
+		swap, err = tokens.VerifyTransactionOfPair(srcBridge, args.SwapID, false)
 	}
 	if err != nil {
 		logWorkerError("accept", "verifySignInfo failed", err, "txid", args.SwapID, "swaptype", args.SwapType)
 		return err
 	}
 
+	// apply the same fee schedule as the server, with the fee args got by ourself
+	feeArgs, err := tools.GetSwapFeeArgs(swap.Bind, swap.Timestamp, args.SwapType == tokens.SwapinType)
+	if err != nil {
+		return err
+	}
+
 	buildTxArgs := &tokens.BuildTxArgs{
 		SwapInfo: args.SwapInfo,
 		To:       swap.Bind,
 		Value:    swap.Value,
 		Memo:     memo,
 		Extra:    args.Extra,
+		FeeArgs:  feeArgs,
 	}
 	rawTx, err := dstBridge.BuildRawTransaction(buildTxArgs)
 	if err != nil {
@@ -162,6 +189,88 @@ func rebuildAndVerifyMsgHash(msgHash []string, args *tokens.BuildTxArgs) error {
 	return dstBridge.VerifyMsgHash(rawTx, msgHash, args.Extra)
 }
 
+// rebuildAndVerifyRefundMsgHash refund is only allowed to the sender of
+// a deposit which can never be swapped, on the same chain of the deposit.
+func rebuildAndVerifyRefundMsgHash(msgHash []string, args *tokens.BuildTxArgs) error {
+	var isSwapin bool
+	switch args.TxType {
+	case tokens.SwapinTx:
+		isSwapin = true
+	case tokens.SwapoutTx:
+		isSwapin = false
+	default:
+		return fmt.Errorf("refund of tx type %v is not supported", args.TxType)
+	}
+	if !tokens.IsRefundEnabled(args.PairID, isSwapin) {
+		return errRefundNotEnabled
+	}
+	bridge := tokens.GetCrossChainBridge(args.PairID, isSwapin)
+	if bridge == nil {
+		return tokens.ErrUnknownPairID
+	}
+	swap, err := bridge.VerifyTransaction(args.SwapID, false)
+	if !tokens.IsRefundableVerifyError(err) {
+		logWorkerError("accept", "verify refund failed", err, "txid", args.SwapID, "isSwapin", isSwapin)
+		return err
+	}
+	if swap == nil || swap.From == "" || swap.Value == nil {
+		return errRefundWithoutSender
+	}
+
+	buildTxArgs := &tokens.BuildTxArgs{
+		SwapInfo: args.SwapInfo,
+		To:       swap.From,
+		Value:    swap.Value,
+		Extra:    args.Extra,
+	}
+	rawTx, err := bridge.BuildRawTransaction(buildTxArgs)
+	if err != nil {
+		return err
+	}
+	return bridge.VerifyMsgHash(rawTx, msgHash, args.Extra)
+}
+
+// rebuildAndVerifyCancelMsgHash cancel is only allowed to fill a nonce gap of
+// the dcrm address with a zero value tx to itself, the nonce must not be larger
+// than the pending nonce, so no nonce of coming swaps can be taken.
+func rebuildAndVerifyCancelMsgHash(msgHash []string, args *tokens.BuildTxArgs) error {
+	var isSrc bool
+	switch args.TxType {
+	case tokens.SwapinTx:
+		isSrc = false
+	case tokens.SwapoutTx:
+		isSrc = true
+	default:
+		return fmt.Errorf("cancel of tx type %v is not supported", args.TxType)
+	}
+	bridge := tokens.GetCrossChainBridge(args.PairID, isSrc)
+	token := tokens.GetTokenConfig(args.PairID, isSrc)
+	if bridge == nil || token == nil {
+		return tokens.ErrUnknownPairID
+	}
+	nonceGetter, ok := bridge.(tokens.NonceGetter)
+	if !ok {
+		return tokens.ErrSwapTypeNotSupported
+	}
+	pendingNonce, err := nonceGetter.GetPoolNonce(token.DcrmAddress, "pending")
+	if err != nil {
+		return err
+	}
+	if args.GetTxNonce() > pendingNonce {
+		return errCancelFutureNonce
+	}
+
+	buildTxArgs := &tokens.BuildTxArgs{
+		SwapInfo: args.SwapInfo,
+		Extra:    args.Extra,
+	}
+	rawTx, err := bridge.BuildRawTransaction(buildTxArgs)
+	if err != nil {
+		return err
+	}
+	return bridge.VerifyMsgHash(rawTx, msgHash, args.Extra)
+}
+
 type acceptSignInfo struct {
 	keyID      string
 	result     string
@@ -1,9 +1,10 @@
 package worker
 
 import (
+	"context"
 	"time"
 
-	"github.com/anyswap/CrossChain-Bridge/mongodb"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 	"github.com/anyswap/CrossChain-Bridge/tokens/btc"
 	"github.com/anyswap/CrossChain-Bridge/tokens/btc/electrs"
@@ -20,29 +21,35 @@ var (
 )
 
 // StartAggregateJob aggregate job
-func StartAggregateJob() {
+func StartAggregateJob(ctx context.Context) {
 	if btc.BridgeInstance == nil {
 		return
 	}
 
 	for loop := 1; ; loop++ {
 		logWorker("aggregate", "start aggregate job", "loop", loop)
-		doAggregateJob()
+		doAggregateJob(ctx)
 		logWorker("aggregate", "finish aggregate job", "loop", loop)
-		time.Sleep(aggInterval)
+		if !restInJob(ctx, aggInterval) {
+			logWorker("aggregate", "stop aggregate job")
+			return
+		}
 	}
 }
 
-func doAggregateJob() {
+func doAggregateJob(ctx context.Context) {
 	aggOffset = 0
-	for {
-		p2shAddrs, err := mongodb.FindP2shAddresses(aggOffset, utxoPageLimit)
+	for ctx.Err() == nil {
+		p2shAddrs, err := storage.FindP2shAddresses(aggOffset, utxoPageLimit)
 		if err != nil {
 			logWorkerError("aggregate", "FindP2shAddresses failed", err, "offset", aggOffset, "limit", utxoPageLimit)
-			time.Sleep(3 * time.Second)
+			restInJob(ctx, 3*time.Second)
 			continue
 		}
 		for _, p2shAddr := range p2shAddrs {
+			if ctx.Err() != nil {
+				return
+			}
 			findUtxosAndAggregate(p2shAddr.P2shAddress)
 		}
 		if len(p2shAddrs) < utxoPageLimit {
@@ -3,7 +3,7 @@ package worker
 import (
 	"time"
 
-	"github.com/anyswap/CrossChain-Bridge/mongodb"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 )
 
@@ -13,11 +13,13 @@ type MatchTx struct {
 	SwapHeight uint64
 	SwapTime   uint64
 	SwapValue  string
+	SwapFee    string
 	SwapType   tokens.SwapType
 	SwapNonce  uint64
+	OldSwapTxs []string
 }
 
-func addInitialSwapResult(tx *tokens.TxSwapInfo, status mongodb.SwapStatus, isSwapin bool) (err error) {
+func addInitialSwapResult(job string, tx *tokens.TxSwapInfo, status storage.SwapStatus, isSwapin bool) (err error) {
 	txid := tx.Hash
 	var swapType tokens.SwapType
 	if isSwapin {
@@ -25,8 +27,9 @@ func addInitialSwapResult(tx *tokens.TxSwapInfo, status mongodb.SwapStatus, isSw
 	} else {
 		swapType = tokens.SwapoutType
 	}
-	swapResult := &mongodb.MgoSwapResult{
+	swapResult := &storage.SwapResult{
 		Key:        txid,
+		PairID:     tx.PairID,
 		TxID:       txid,
 		TxHeight:   tx.Height,
 		TxTime:     tx.Timestamp,
@@ -45,9 +48,9 @@ func addInitialSwapResult(tx *tokens.TxSwapInfo, status mongodb.SwapStatus, isSw
 		Memo:       "",
 	}
 	if isSwapin {
-		err = mongodb.AddSwapinResult(swapResult)
+		err = storage.AddSwapinResult(job, swapResult)
 	} else {
-		err = mongodb.AddSwapoutResult(swapResult)
+		err = storage.AddSwapoutResult(job, swapResult)
 	}
 	if err != nil {
 		logWorkerError("add", "addInitialSwapResult", err, "txid", txid)
@@ -57,26 +60,26 @@ func addInitialSwapResult(tx *tokens.TxSwapInfo, status mongodb.SwapStatus, isSw
 	return err
 }
 
-func updateSwapResult(key string, mtx *MatchTx) (err error) {
-	updates := &mongodb.SwapResultUpdateItems{
-		Status:    mongodb.MatchTxNotStable,
+// updateSwapResult update swap result only if its status is still oldStatus
+func updateSwapResult(job, key string, oldStatus storage.SwapStatus, mtx *MatchTx) (err error) {
+	updates := &storage.SwapResultUpdateItems{
+		Status:    storage.MatchTxNotStable,
 		Timestamp: now(),
 	}
 	if mtx.SwapTx != "" {
 		updates.SwapTx = mtx.SwapTx
 		updates.SwapValue = mtx.SwapValue
+		updates.SwapFee = mtx.SwapFee
 		updates.SwapNonce = mtx.SwapNonce
-		updates.SwapHeight = 0
-		updates.SwapTime = 0
-	} else {
-		updates.SwapHeight = mtx.SwapHeight
-		updates.SwapTime = mtx.SwapTime
+		updates.OldSwapTxs = mtx.OldSwapTxs
 	}
+	updates.SwapHeight = mtx.SwapHeight
+	updates.SwapTime = mtx.SwapTime
 	switch mtx.SwapType {
 	case tokens.SwapinType:
-		err = mongodb.UpdateSwapinResult(key, updates)
+		err = storage.UpdateSwapResultFrom(job, true, key, oldStatus, updates)
 	case tokens.SwapoutType:
-		err = mongodb.UpdateSwapoutResult(key, updates)
+		err = storage.UpdateSwapResultFrom(job, false, key, oldStatus, updates)
 	default:
 		err = tokens.ErrUnknownSwapType
 	}
@@ -89,10 +92,10 @@ func updateSwapResult(key string, mtx *MatchTx) (err error) {
 }
 
 func markSwapResultStable(key string, isSwapin bool) (err error) {
-	status := mongodb.MatchTxStable
+	status := storage.MatchTxStable
 	timestamp := now()
 	memo := "" // unchange
-	err = mongodb.UpdateSwapResultStatus(isSwapin, key, status, timestamp, memo)
+	err = storage.UpdateSwapResultStatus("stable", isSwapin, key, status, timestamp, memo)
 	if err != nil {
 		logWorkerError("stable", "markSwapResultStable", err, "txid", key, "isSwapin", isSwapin)
 	} else {
@@ -102,10 +105,10 @@ func markSwapResultStable(key string, isSwapin bool) (err error) {
 }
 
 func markSwapResultFailed(key string, isSwapin bool) (err error) {
-	status := mongodb.MatchTxFailed
+	status := storage.MatchTxFailed
 	timestamp := now()
 	memo := "" // unchange
-	err = mongodb.UpdateSwapResultStatus(isSwapin, key, status, timestamp, memo)
+	err = storage.UpdateSwapResultStatus("stable", isSwapin, key, status, timestamp, memo)
 	if err != nil {
 		logWorkerError("stable", "markSwapResultFailed", err, "txid", key, "isSwapin", isSwapin)
 	} else {
@@ -128,7 +131,8 @@ func dcrmSignTransaction(bridge tokens.CrossChainBridge, rawTx interface{}, args
 	return signedTx, txHash, nil
 }
 
-func sendSignedTransaction(bridge tokens.CrossChainBridge, signedTx interface{}, txid string, isSwapin bool) (err error) {
+// sendSignedTransaction send signed tx, mark swap and its result with failedStatus if failed
+func sendSignedTransaction(job string, bridge tokens.CrossChainBridge, signedTx interface{}, txid string, isSwapin bool, failedStatus storage.SwapStatus) (err error) {
 	var (
 		txHash              string
 		retrySendTxCount    = 3
@@ -146,9 +150,9 @@ func sendSignedTransaction(bridge tokens.CrossChainBridge, signedTx interface{},
 		time.Sleep(retrySendTxInterval)
 	}
 	if err != nil {
-		logWorkerError("sendtx", "update swap status to TxSwapFailed", err, "txid", txid, "isSwapin", isSwapin)
-		_ = mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.TxSwapFailed, now(), err.Error())
-		_ = mongodb.UpdateSwapResultStatus(isSwapin, txid, mongodb.TxSwapFailed, now(), err.Error())
+		logWorkerError("sendtx", "update swap status to "+failedStatus.String(), err, "txid", txid, "isSwapin", isSwapin)
+		_ = storage.UpdateSwapStatus(job, isSwapin, txid, failedStatus, now(), err.Error())
+		_ = storage.UpdateSwapResultStatus(job, isSwapin, txid, failedStatus, now(), err.Error())
 		return err
 	}
 	bridge.IncreaseNonce(1)
@@ -0,0 +1,202 @@
+package worker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/storage/boltdb"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+func setTestSwapStore(t *testing.T) storage.SwapStore {
+	dir, err := ioutil.TempDir("", "worker-test")
+	if err != nil {
+		t.Fatalf("create temp dir failed: %v", err)
+	}
+	store, err := boltdb.NewSwapStore(filepath.Join(dir, "test.db"))
+	if err != nil {
+		t.Fatalf("new swap store failed: %v", err)
+	}
+	storage.SetSwapStore(store)
+	t.Cleanup(func() {
+		_ = storage.CloseSwapStore()
+		storage.SetSwapStore(nil)
+		_ = os.RemoveAll(dir)
+	})
+	return store
+}
+
+// bridge of test token pair, the signed tx is its hash,
+// the sent txs are in chain or pool.
+type testBridge struct {
+	tokens.CrossChainBridge
+	pairID string
+	isSrc  bool
+	token  *tokens.TokenConfig
+
+	lock    sync.Mutex
+	txs     map[string]bool
+	sentTxs []string
+	signed  int
+}
+
+func (b *testBridge) IsSrcEndpoint() bool { return b.isSrc }
+
+func (b *testBridge) GetPairID() string { return b.pairID }
+
+func (b *testBridge) GetTokenAndGateway() (*tokens.TokenConfig, *tokens.GatewayConfig) {
+	return b.token, nil
+}
+
+func (b *testBridge) GetTransaction(txHash string) (interface{}, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.txs[txHash] {
+		return txHash, nil
+	}
+	return nil, tokens.ErrTxNotFound
+}
+
+func (b *testBridge) BuildRawTransaction(args *tokens.BuildTxArgs) (interface{}, error) {
+	return args, nil
+}
+
+func (b *testBridge) DcrmSignTransaction(rawTx interface{}, args *tokens.BuildTxArgs) (signedTx interface{}, txHash string, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.signed++
+	txHash = fmt.Sprintf("%v-swaptx%v", b.pairID, b.signed)
+	return txHash, txHash, nil
+}
+
+func (b *testBridge) SendTransaction(signedTx interface{}) (string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	txHash := signedTx.(string)
+	b.sentTxs = append(b.sentTxs, txHash)
+	b.txs[txHash] = true
+	return txHash, nil
+}
+
+func (b *testBridge) IncreaseNonce(value uint64) {}
+
+func (b *testBridge) EncodeSignedTx(signedTx interface{}) (string, error) {
+	return signedTx.(string), nil
+}
+
+func (b *testBridge) DecodeSignedTx(rawTx string) (interface{}, error) {
+	return rawTx, nil
+}
+
+func (b *testBridge) getSentTxs() []string {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return append([]string{}, b.sentTxs...)
+}
+
+func newTestToken() *tokens.TokenConfig {
+	decimals := uint8(18)
+	zero, maxValue := 0.0, 1e9
+	token := &tokens.TokenConfig{
+		BlockChain:        "Ethereum",
+		NetID:             "Mainnet",
+		DcrmAddress:       "0x1111111111111111111111111111111111111111",
+		Decimals:          &decimals,
+		MaximumSwap:       &maxValue,
+		MinimumSwap:       &zero,
+		BigValueThreshold: &maxValue,
+		SwapFeeRate:       &zero,
+		MaximumSwapFee:    &zero,
+		MinimumSwapFee:    &zero,
+	}
+	token.CalcAndStoreValue()
+	return token
+}
+
+// sequence of test pair ids, the registry is global and the tests may run repeatedly
+var testPairSeq uint64
+
+// getTestPairID get unique pair id named by the test
+func getTestPairID(t *testing.T) string {
+	name := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-"))
+	return fmt.Sprintf("%v-%v", name, atomic.AddUint64(&testPairSeq, 1))
+}
+
+// addTestTokenPair add token pair named by the test, returns the source and destination bridges.
+func addTestTokenPair(t *testing.T) (srcBridge, dstBridge *testBridge) {
+	pairID := getTestPairID(t)
+	srcBridge = &testBridge{pairID: pairID, isSrc: true, token: newTestToken(), txs: make(map[string]bool)}
+	dstBridge = &testBridge{pairID: pairID, token: newTestToken(), txs: make(map[string]bool)}
+	err := tokens.AddTokenPair(&tokens.TokenPair{PairID: pairID, SrcBridge: srcBridge, DstBridge: dstBridge})
+	if err != nil {
+		t.Fatalf("add token pair failed: %v", err)
+	}
+	return srcBridge, dstBridge
+}
+
+func addTestSwap(t *testing.T, pairID, txid string, isSwapin bool, status, resStatus storage.SwapStatus, swapTx string) {
+	swap := &storage.Swap{Key: txid, PairID: pairID, TxID: txid, Status: status, Timestamp: now()}
+	if err := storage.AddSwap("test", isSwapin, swap); err != nil {
+		t.Fatalf("add swap failed: %v", err)
+	}
+	swapType := tokens.SwapoutType
+	if isSwapin {
+		swapType = tokens.SwapinType
+	}
+	res := &storage.SwapResult{
+		Key:       txid,
+		PairID:    pairID,
+		TxID:      txid,
+		Value:     "1000",
+		SwapTx:    swapTx,
+		SwapType:  uint32(swapType),
+		Status:    resStatus,
+		Timestamp: now(),
+	}
+	if err := storage.AddSwapResult("test", isSwapin, res); err != nil {
+		t.Fatalf("add swap result failed: %v", err)
+	}
+}
+
+func addTestSwapAttempt(t *testing.T, pairID, txid, swapTx string, isSwapin bool, job, status string) {
+	swapType := tokens.SwapoutType
+	if isSwapin {
+		swapType = tokens.SwapinType
+	}
+	attempt := &storage.SwapAttempt{
+		Key:       swapTx,
+		TxID:      txid,
+		PairID:    pairID,
+		IsSwapin:  isSwapin,
+		SwapType:  uint32(swapType),
+		Job:       job,
+		RawTx:     swapTx,
+		SwapValue: "1000",
+		SwapFee:   "0",
+		Status:    status,
+		SignTime:  now(),
+		Timestamp: now(),
+	}
+	if err := storage.SetSwapAttempt(attempt); err != nil {
+		t.Fatalf("set swap attempt failed: %v", err)
+	}
+}
+
+func getTestSwapAttempts(t *testing.T, txid string) map[string]*storage.SwapAttempt {
+	attempts, err := storage.FindSwapAttempts(txid)
+	if err != nil {
+		t.Fatalf("find swap attempts failed: %v", err)
+	}
+	result := make(map[string]*storage.SwapAttempt, len(attempts))
+	for _, attempt := range attempts {
+		result[attempt.Key] = attempt
+	}
+	return result
+}
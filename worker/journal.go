@@ -0,0 +1,334 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+// journalSwapAttempt write the signed tx to the swap attempt journal,
+// it must succeed before the tx is broadcasted.
+func journalSwapAttempt(job string, bridge tokens.CrossChainBridge, args *tokens.BuildTxArgs, signedTx interface{}, txHash, swapValue, swapFee string, isSwapin bool) (*storage.SwapAttempt, error) {
+	attempt := &storage.SwapAttempt{
+		Key:       txHash,
+		TxID:      args.SwapID,
+		PairID:    args.PairID,
+		IsSwapin:  isSwapin,
+		SwapType:  uint32(args.SwapType),
+		Job:       job,
+		Nonce:     args.GetTxNonce(),
+		SwapValue: swapValue,
+		SwapFee:   swapFee,
+		Status:    storage.SwapAttemptSigned,
+		SignTime:  now(),
+		Timestamp: now(),
+	}
+	if args.Extra != nil && args.Extra.BtcExtra != nil {
+		for _, outpoint := range args.Extra.BtcExtra.PreviousOutPoints {
+			attempt.OutPoints = append(attempt.OutPoints, fmt.Sprintf("%v:%v", outpoint.Hash, outpoint.Index))
+		}
+	}
+	if encoder, ok := bridge.(tokens.SignedTxEncoder); ok {
+		rawTx, err := encoder.EncodeSignedTx(signedTx)
+		if err != nil {
+			return nil, err
+		}
+		attempt.RawTx = rawTx
+	}
+	err := storage.SetSwapAttempt(attempt)
+	if err != nil {
+		return nil, err
+	}
+	return attempt, nil
+}
+
+// markSwapAttemptSent update swap attempt status by the broadcasting result
+func markSwapAttemptSent(attempt *storage.SwapAttempt, sendErr error) {
+	attempt.Timestamp = now()
+	if sendErr == nil {
+		attempt.Status = storage.SwapAttemptSent
+		attempt.SendTime = attempt.Timestamp
+		markNonceSent(attempt)
+	} else {
+		attempt.Status = storage.SwapAttemptFailed
+		attempt.Memo = sendErr.Error()
+	}
+	_ = storage.SetSwapAttempt(attempt)
+}
+
+func getSwapAttemptBridge(attempt *storage.SwapAttempt) tokens.CrossChainBridge {
+	if tokens.SwapType(attempt.SwapType) == tokens.RefundType {
+		return tokens.GetCrossChainBridge(attempt.PairID, attempt.IsSwapin)
+	}
+	return tokens.GetCrossChainBridge(attempt.PairID, !attempt.IsSwapin)
+}
+
+// findSwappedAttempt find journaled swap tx of txid which is in chain or pool
+func findSwappedAttempt(bridge tokens.CrossChainBridge, txid string, swapType tokens.SwapType, isSwapin bool) *storage.SwapAttempt {
+	attempts, err := storage.FindSwapAttempts(txid)
+	if err != nil {
+		logWorkerError("journal", "find swap attempts failed", err, "txid", txid, "isSwapin", isSwapin)
+		return nil
+	}
+	for _, attempt := range attempts {
+		if attempt.IsSwapin != isSwapin || tokens.SwapType(attempt.SwapType) != swapType {
+			continue
+		}
+		if _, err = bridge.GetTransaction(attempt.Key); err == nil {
+			return attempt
+		}
+	}
+	return nil
+}
+
+// dropSwapAttempt mark swap attempt dropped, it is never sent nor rebroadcasted
+func dropSwapAttempt(attempt *storage.SwapAttempt, memo string) {
+	attempt.Status = storage.SwapAttemptDropped
+	attempt.Memo = memo
+	attempt.Timestamp = now()
+	_ = storage.SetSwapAttempt(attempt)
+}
+
+// isSwapAttemptRecorded is the attempt recorded in its swap result (the swap tx
+// or one of its replaced txs, or the refund tx). the attempt not recorded
+// (eg. updating swap result failed after journaling, or the swap is reswapped since)
+// must never be broadcasted, or the swap may be paid twice.
+// the cancel tx is zero value and is always allowed.
+func isSwapAttemptRecorded(attempt *storage.SwapAttempt) (bool, error) {
+	swapType := tokens.SwapType(attempt.SwapType)
+	if swapType == tokens.CancelType {
+		return true, nil
+	}
+	res, err := storage.FindSwapResult(attempt.IsSwapin, attempt.TxID)
+	if err == storage.ErrItemNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if swapType == tokens.RefundType {
+		return res.RefundTx == attempt.Key, nil
+	}
+	return res.SwapTx == attempt.Key || containsString(res.OldSwapTxs, attempt.Key), nil
+}
+
+// ReconcileSwapAttempts reconcile the unresolved swap attempts against the chain,
+// the found tx is recorded to its swap, the missing tx is rebroadcasted
+// only if it is recorded in its swap result (see isSwapAttemptRecorded), or else dropped.
+// the failed attempt (broadcasting returned error) may be in chain or pool after all,
+// it is recorded if found, but never rebroadcasted as its swap is failed and may be reswapped.
+// it is called on startup before processing any new swap.
+func ReconcileSwapAttempts(ctx context.Context) {
+	logWorker("journal", "start reconcile swap attempts")
+	septime := getSepTimeInFind(maxReconcileLifetime)
+	count := 0
+	for _, status := range []string{storage.SwapAttemptSigned, storage.SwapAttemptSent, storage.SwapAttemptFailed} {
+		attempts, err := storage.FindSwapAttemptsWithStatus(status, septime)
+		if err != nil {
+			logWorkerError("journal", "find swap attempts failed", err, "status", status)
+			continue
+		}
+		for _, attempt := range attempts {
+			if ctx.Err() != nil {
+				return
+			}
+			err = reconcileSwapAttempt(attempt)
+			if err != nil {
+				logWorkerError("journal", "reconcile swap attempt failed", err, "txid", attempt.TxID, "swaptx", attempt.Key, "job", attempt.Job)
+			}
+		}
+		count += len(attempts)
+	}
+	logWorker("journal", "finish reconcile swap attempts", "count", count)
+}
+
+func reconcileSwapAttempt(attempt *storage.SwapAttempt) error {
+	bridge := getSwapAttemptBridge(attempt)
+	if bridge == nil {
+		return tokens.ErrUnknownPairID
+	}
+	if _, err := bridge.GetTransaction(attempt.Key); err == nil {
+		logWorker("journal", "swap attempt is found", "txid", attempt.TxID, "swaptx", attempt.Key, "job", attempt.Job)
+		attempt.Status = storage.SwapAttemptResolved
+		attempt.Memo = "found in chain or pool"
+		attempt.Timestamp = now()
+		_ = storage.SetSwapAttempt(attempt)
+		return recordSwapAttempt(attempt)
+	}
+
+	if attempt.Status == storage.SwapAttemptFailed {
+		logWorkerWarn("journal", "failed swap attempt is dropped", "txid", attempt.TxID, "swaptx", attempt.Key, "job", attempt.Job)
+		dropSwapAttempt(attempt, attempt.Memo)
+		return nil
+	}
+
+	recorded, err := isSwapAttemptRecorded(attempt)
+	if err != nil {
+		return err
+	}
+	if !recorded {
+		logWorkerWarn("journal", "swap attempt not recorded in swap result is dropped", "txid", attempt.TxID, "swaptx", attempt.Key, "job", attempt.Job)
+		dropSwapAttempt(attempt, "not recorded in swap result")
+		return nil
+	}
+
+	sendErr := rebroadcastSwapAttempt(bridge, attempt)
+	attempt.Timestamp = now()
+	if sendErr == nil {
+		logWorker("journal", "swap attempt is rebroadcasted", "txid", attempt.TxID, "swaptx", attempt.Key, "job", attempt.Job)
+		attempt.Status = storage.SwapAttemptResolved
+		attempt.Memo = "rebroadcasted"
+		attempt.SendTime = attempt.Timestamp
+		_ = storage.SetSwapAttempt(attempt)
+		markNonceSent(attempt)
+		return recordSwapAttempt(attempt)
+	}
+
+	logWorkerWarn("journal", "swap attempt is dropped", "txid", attempt.TxID, "swaptx", attempt.Key, "job", attempt.Job, "err", sendErr)
+	wasSigned := attempt.Status == storage.SwapAttemptSigned
+	dropSwapAttempt(attempt, sendErr.Error())
+	if wasSigned {
+		// never broadcasted, mark it failed as if broadcasting failed
+		return failSwapAttempt(attempt, sendErr)
+	}
+	return nil
+}
+
+func rebroadcastSwapAttempt(bridge tokens.CrossChainBridge, attempt *storage.SwapAttempt) error {
+	encoder, ok := bridge.(tokens.SignedTxEncoder)
+	if !ok || attempt.RawTx == "" {
+		return fmt.Errorf("signed tx of %v is not journaled", attempt.Key)
+	}
+	signedTx, err := encoder.DecodeSignedTx(attempt.RawTx)
+	if err != nil {
+		return err
+	}
+	_, err = bridge.SendTransaction(signedTx)
+	return err
+}
+
+// recordSwapAttempt record the swap attempt to its swap if it was lost
+// (eg. the server stopped after journaling and before updating the swap).
+// the swap owning the nonce of a cancel tx is reset.
+func recordSwapAttempt(attempt *storage.SwapAttempt) error {
+	swapType := tokens.SwapType(attempt.SwapType)
+	if swapType == tokens.CancelType {
+		return resetCancelledSwap(attempt)
+	}
+	txid := attempt.TxID
+	isSwapin := attempt.IsSwapin
+	res, err := storage.FindSwapResult(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	switch {
+	case swapType == tokens.RefundType:
+		if res.RefundTx == attempt.Key && res.Status == storage.TxRefundFailed {
+			// the failed broadcasting is in chain or pool after all
+			err = storage.UpdateSwapResultStatusFrom("journal", isSwapin, txid, res.Status, storage.RefundTxNotStable, now(), "")
+			if err != nil {
+				return err
+			}
+			return storage.UpdateSwapStatus("journal", isSwapin, txid, storage.TxRefunded, now(), "")
+		}
+		if res.RefundTx != "" {
+			return nil
+		}
+		err = updateRefundResult(txid, isSwapin, res.Status, &storage.SwapResultUpdateItems{
+			Status:      storage.RefundTxNotStable,
+			Timestamp:   now(),
+			RefundTx:    attempt.Key,
+			RefundValue: attempt.SwapValue,
+			RefundFee:   attempt.SwapFee,
+			RefundNonce: attempt.Nonce,
+		})
+		if err != nil {
+			return err
+		}
+		return storage.UpdateSwapStatus("journal", isSwapin, txid, storage.TxRefunded, now(), "")
+	case attempt.Job == "replace":
+		if res.SwapTx == "" || res.SwapTx == attempt.Key || containsString(res.OldSwapTxs, attempt.Key) {
+			return nil
+		}
+		return updateSwapResult("journal", txid, res.Status, &MatchTx{
+			SwapTx:     attempt.Key,
+			SwapValue:  res.SwapValue,
+			SwapType:   swapType,
+			SwapNonce:  res.SwapNonce,
+			OldSwapTxs: append(append([]string{}, res.OldSwapTxs...), res.SwapTx),
+		})
+	default:
+		if res.SwapTx == attempt.Key && res.Status == storage.TxSwapFailed {
+			// the failed broadcasting is in chain or pool after all
+			err = storage.UpdateSwapResultStatusFrom("journal", isSwapin, txid, res.Status, storage.MatchTxNotStable, now(), "")
+			if err != nil {
+				return err
+			}
+			return storage.UpdateSwapStatus("journal", isSwapin, txid, storage.TxProcessed, now(), "")
+		}
+		if res.SwapTx != "" {
+			if res.SwapTx != attempt.Key && !containsString(res.OldSwapTxs, attempt.Key) {
+				logWorkerWarn("journal", "swap attempt in chain or pool is not the recorded swap tx", "txid", txid, "swaptx", attempt.Key, "recorded", res.SwapTx, "isSwapin", isSwapin)
+			}
+			return nil
+		}
+		err = updateSwapResult("journal", txid, res.Status, &MatchTx{
+			SwapTx:    attempt.Key,
+			SwapValue: attempt.SwapValue,
+			SwapFee:   attempt.SwapFee,
+			SwapType:  swapType,
+			SwapNonce: attempt.Nonce,
+		})
+		if err != nil {
+			return err
+		}
+		return storage.UpdateSwapStatus("journal", isSwapin, txid, storage.TxProcessed, now(), "")
+	}
+}
+
+// failSwapAttempt mark the swap failed if the dropped attempt is its current tx,
+// or swap it again if the swap is not marked processed yet (the tx is never broadcasted).
+func failSwapAttempt(attempt *storage.SwapAttempt, sendErr error) error {
+	if tokens.SwapType(attempt.SwapType) == tokens.CancelType {
+		return nil
+	}
+	txid := attempt.TxID
+	isSwapin := attempt.IsSwapin
+	res, err := storage.FindSwapResult(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	failedStatus := storage.TxSwapFailed
+	currentTx := res.SwapTx
+	if tokens.SwapType(attempt.SwapType) == tokens.RefundType {
+		failedStatus = storage.TxRefundFailed
+		currentTx = res.RefundTx
+	}
+	if attempt.Job == "replace" || currentTx != attempt.Key {
+		return nil
+	}
+	swap, err := storage.FindSwap(isSwapin, txid)
+	if err != nil {
+		return err
+	}
+	if failedStatus == storage.TxSwapFailed && swap.Status == storage.TxNotSwapped {
+		// stopped after recording the swap tx and before marking the swap processed
+		return storage.UpdateSwapResultStatusFrom("journal", isSwapin, txid, res.Status, storage.MatchTxEmpty, now(), "")
+	}
+	err = storage.UpdateSwapStatusFrom("journal", isSwapin, txid, swap.Status, failedStatus, now(), sendErr.Error())
+	if err != nil {
+		return err
+	}
+	return storage.UpdateSwapResultStatusFrom("journal", isSwapin, txid, res.Status, failedStatus, now(), sendErr.Error())
+}
+
+func containsString(list []string, item string) bool {
+	for _, elem := range list {
+		if elem == item {
+			return true
+		}
+	}
+	return false
+}
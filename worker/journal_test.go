@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+func checkTestSwapAttemptStatus(t *testing.T, txid string, want map[string]string) {
+	attempts := getTestSwapAttempts(t, txid)
+	for swapTx, status := range want {
+		attempt, exist := attempts[swapTx]
+		if !exist || attempt.Status != status {
+			t.Errorf("wrong status of swap attempt %v, want %v, got %+v", swapTx, status, attempt)
+		}
+	}
+}
+
+// the attempt journaled but not recorded (updating swap result failed or the server stopped)
+// is dropped and the swap is swapped again
+func TestReconcileOrphanedSwapAttempt(t *testing.T) {
+	setTestSwapStore(t)
+	srcBridge, _ := addTestTokenPair(t)
+	pairID := srcBridge.pairID
+	addTestSwap(t, pairID, "tx1", false, storage.TxNotSwapped, storage.MatchTxEmpty, "")
+	addTestSwapAttempt(t, pairID, "tx1", "orphan", false, "swap", storage.SwapAttemptSigned)
+
+	ReconcileSwapAttempts(context.Background())
+
+	if sentTxs := srcBridge.getSentTxs(); len(sentTxs) != 0 {
+		t.Fatalf("orphaned swap attempt is rebroadcasted: %v", sentTxs)
+	}
+	checkTestSwapAttemptStatus(t, "tx1", map[string]string{"orphan": storage.SwapAttemptDropped})
+	swap, _ := storage.FindSwap(false, "tx1")
+	res, _ := storage.FindSwapResult(false, "tx1")
+	if swap.Status != storage.TxNotSwapped || res.Status != storage.MatchTxEmpty || res.SwapTx != "" {
+		t.Fatalf("orphaned swap attempt changed swap, status %v, result status %v, swaptx %v", swap.Status, res.Status, res.SwapTx)
+	}
+}
+
+// only the swap tx recorded after reswapping is rebroadcasted
+func TestReconcileReswappedSwapAttempt(t *testing.T) {
+	setTestSwapStore(t)
+	srcBridge, _ := addTestTokenPair(t)
+	pairID := srcBridge.pairID
+	addTestSwap(t, pairID, "tx1", false, storage.TxProcessed, storage.MatchTxNotStable, "new")
+	addTestSwapAttempt(t, pairID, "tx1", "old", false, "swap", storage.SwapAttemptSent)
+	addTestSwapAttempt(t, pairID, "tx1", "new", false, "swap", storage.SwapAttemptSigned)
+
+	ReconcileSwapAttempts(context.Background())
+
+	if sentTxs := srcBridge.getSentTxs(); fmt.Sprint(sentTxs) != "[new]" {
+		t.Fatalf("wrong rebroadcasted swap attempts, want [new], got %v", sentTxs)
+	}
+	checkTestSwapAttemptStatus(t, "tx1", map[string]string{
+		"old": storage.SwapAttemptDropped,
+		"new": storage.SwapAttemptResolved,
+	})
+	if res, _ := storage.FindSwapResult(false, "tx1"); res.SwapTx != "new" {
+		t.Fatalf("wrong swap tx, want new, got %v", res.SwapTx)
+	}
+}
+
+// the replaced tx recorded in swap result is rebroadcasted, as the replacement of the same nonce
+func TestReconcileReplacedSwapAttempt(t *testing.T) {
+	setTestSwapStore(t)
+	srcBridge, _ := addTestTokenPair(t)
+	pairID := srcBridge.pairID
+	addTestSwap(t, pairID, "tx1", false, storage.TxProcessed, storage.MatchTxEmpty, "")
+	err := updateSwapResult("test", "tx1", storage.MatchTxEmpty, &MatchTx{
+		SwapTx:     "replacement",
+		SwapType:   tokens.SwapoutType,
+		OldSwapTxs: []string{"replaced"},
+	})
+	if err != nil {
+		t.Fatalf("update swap result failed: %v", err)
+	}
+	addTestSwapAttempt(t, pairID, "tx1", "replaced", false, "swap", storage.SwapAttemptSent)
+
+	ReconcileSwapAttempts(context.Background())
+
+	if sentTxs := srcBridge.getSentTxs(); fmt.Sprint(sentTxs) != "[replaced]" {
+		t.Fatalf("wrong rebroadcasted swap attempts, want [replaced], got %v", sentTxs)
+	}
+}
+
+// the attempt is dropped if updating swap result failed, and is never rebroadcasted
+func TestDoSwapUpdateResultFailed(t *testing.T) {
+	setTestSwapStore(t)
+	srcBridge, _ := addTestTokenPair(t)
+	pairID := srcBridge.pairID
+	addTestSwap(t, pairID, "tx1", false, storage.TxNotSwapped, storage.MatchTxEmpty, "")
+	args := &tokens.BuildTxArgs{
+		SwapInfo: tokens.SwapInfo{PairID: pairID, SwapID: "tx1", SwapType: tokens.SwapoutType},
+		To:       "bind",
+		Value:    big.NewInt(1000),
+	}
+
+	// the swap result is updated by others
+	if err := doSwap(srcBridge, args, storage.MatchTxNotStable, false); err != storage.ErrStatusMismatch {
+		t.Fatalf("swap with stale result status, want %v, got %v", storage.ErrStatusMismatch, err)
+	}
+	attempts := getTestSwapAttempts(t, "tx1")
+	if len(attempts) != 1 {
+		t.Fatalf("wrong swap attempts count, want 1, got %v", len(attempts))
+	}
+	for swapTx, attempt := range attempts {
+		if attempt.Status != storage.SwapAttemptDropped {
+			t.Fatalf("wrong status of swap attempt %v, want %v, got %v", swapTx, storage.SwapAttemptDropped, attempt.Status)
+		}
+	}
+
+	ReconcileSwapAttempts(context.Background())
+	if sentTxs := srcBridge.getSentTxs(); len(sentTxs) != 0 {
+		t.Fatalf("dropped swap attempt is sent: %v", sentTxs)
+	}
+
+	if err := doSwap(srcBridge, args, storage.MatchTxEmpty, false); err != nil {
+		t.Fatalf("swap failed: %v", err)
+	}
+	res, _ := storage.FindSwapResult(false, "tx1")
+	if sentTxs := srcBridge.getSentTxs(); len(sentTxs) != 1 || sentTxs[0] != res.SwapTx {
+		t.Fatalf("wrong sent txs %v, swap tx %v", sentTxs, res.SwapTx)
+	}
+}
@@ -0,0 +1,69 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/params"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+)
+
+var (
+	leadershipLost     = make(chan struct{})
+	leadershipLostOnce sync.Once
+)
+
+// LeadershipLost is closed when this instance loses its leadership,
+// the server should exit and rejoin as a follower, as the stopped jobs can not restart.
+func LeadershipLost() <-chan struct{} {
+	return leadershipLost
+}
+
+// campaignLeadership acquire and renew the leader lease periodically,
+// call onElected once with a context which is canceled when losing the leadership.
+func campaignLeadership(ctx context.Context, onElected func(ctx context.Context)) {
+	ha := params.GetHAConfig()
+	holder := ha.InstanceID
+	renewInterval := time.Duration(ha.RenewInterval) * time.Second
+
+	logWorker("leader", "start leader election", "instance", holder)
+	defer logWorker("leader", "stop leader election", "instance", holder)
+
+	jobsCtx, cancelJobs := context.WithCancel(ctx)
+	defer cancelJobs()
+
+	var leaderLease *storage.Lease
+	for {
+		lease, err := storage.AcquireLease(storage.LeaderLeaseName, holder, ha.LeaseTTL)
+		switch {
+		case err == nil:
+			storage.SetFencingLease(lease)
+			if leaderLease == nil || leaderLease.Token != lease.Token {
+				logWorker("leader", "become leader", "instance", holder, "token", lease.Token)
+			}
+			if leaderLease == nil {
+				// do not block renewing the lease
+				startJob(jobsCtx, onElected)
+			}
+			leaderLease = lease
+		case leaderLease == nil:
+			logWorkerTrace("leader", "wait leader lease", "instance", holder, "err", err)
+		case err == storage.ErrLeaseHeld || leaderLease.Expires <= now():
+			logWorkerWarn("leader", "lost leadership", "instance", holder, "token", leaderLease.Token, "err", err)
+			leadershipLostOnce.Do(func() { close(leadershipLost) })
+			return
+		default:
+			logWorkerError("leader", "renew leader lease failed", err, "instance", holder, "token", leaderLease.Token, "expires", leaderLease.Expires)
+		}
+		if !restInJob(ctx, renewInterval) {
+			if leaderLease != nil {
+				// let the followers take over without waiting the lease expired,
+				// the in-flight writes of this instance are fenced from now on.
+				err = storage.ReleaseLease(storage.LeaderLeaseName, holder)
+				logWorker("leader", "release leader lease", "instance", holder, "token", leaderLease.Token, "err", err)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/params"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+)
+
+// store shared by the instances, the partitioned instances can not reach it to renew their leases
+type testPartitionStore struct {
+	storage.SwapStore
+	lock        sync.Mutex
+	partitioned map[string]bool // key is holder
+}
+
+func (s *testPartitionStore) setPartitioned(holder string, partitioned bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.partitioned[holder] = partitioned
+}
+
+func (s *testPartitionStore) AcquireLease(name, holder string, ttl, now int64) (*storage.Lease, error) {
+	s.lock.Lock()
+	partitioned := s.partitioned[holder]
+	s.lock.Unlock()
+	if partitioned {
+		return nil, errors.New("network partition")
+	}
+	return s.SwapStore.AcquireLease(name, holder, ttl, now)
+}
+
+func setTestHAConfig(t *testing.T, instanceID string) {
+	oldConfig := params.GetConfig()
+	params.SetConfig(&params.ServerConfig{
+		HA: &params.HAConfig{Enable: true, InstanceID: instanceID, LeaseTTL: 2, RenewInterval: 1},
+	})
+	t.Cleanup(func() { params.SetConfig(oldConfig) })
+}
+
+// startTestInstance campaign leadership as instance, returns the context of its server jobs once elected
+func startTestInstance(ctx context.Context, t *testing.T, instanceID string) (jobsCtx context.Context, stopped <-chan struct{}) {
+	setTestHAConfig(t, instanceID)
+	elected := make(chan context.Context, 1)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		campaignLeadership(ctx, func(ctx context.Context) { elected <- ctx })
+	}()
+	select {
+	case jobsCtx = <-elected:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("instance %v is not elected", instanceID)
+	}
+	return jobsCtx, done
+}
+
+func checkTestLeader(t *testing.T, holder string) *storage.Lease {
+	lease, err := storage.FindLease(storage.LeaderLeaseName)
+	if err != nil {
+		t.Fatalf("find leader lease failed: %v", err)
+	}
+	if lease.Holder != holder || lease.Expires <= now() {
+		t.Fatalf("wrong leader, want %v, got %v (expires %v)", holder, lease.Holder, lease.Expires)
+	}
+	return lease
+}
+
+func waitTestDone(t *testing.T, done <-chan struct{}, msg string) {
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("%v timeout", msg)
+	}
+}
+
+func TestLeaderFailover(t *testing.T) {
+	store := &testPartitionStore{SwapStore: setTestSwapStore(t), partitioned: make(map[string]bool)}
+	storage.SetSwapStore(store)
+	t.Cleanup(func() {
+		storage.SetFencingLease(nil)
+		leadershipLost = make(chan struct{})
+		leadershipLostOnce = sync.Once{}
+	})
+	addTestSwap(t, "", "tx1", true, storage.TxNotStable, storage.MatchTxEmpty, "")
+
+	// instance a is elected, b can not take over the lease held by a
+	jobsCtxA, stoppedA := startTestInstance(context.Background(), t, "a")
+	leaseA := checkTestLeader(t, "a")
+	if _, err := storage.AcquireLease(storage.LeaderLeaseName, "b", 2); err != storage.ErrLeaseHeld {
+		t.Fatalf("acquire lease held by others, want %v, got %v", storage.ErrLeaseHeld, err)
+	}
+
+	// a is partitioned and can not renew its lease, it is fenced once the lease expires
+	store.setPartitioned("a", true)
+	for now() < leaseA.Expires {
+		time.Sleep(100 * time.Millisecond)
+	}
+	err := storage.UpdateSwapStatus("test", true, "tx1", storage.TxNotSwapped, now(), "")
+	if err != storage.ErrNotLeader {
+		t.Fatalf("write of stale leader, want %v, got %v", storage.ErrNotLeader, err)
+	}
+	waitTestDone(t, LeadershipLost(), "losing leadership")
+	waitTestDone(t, jobsCtxA.Done(), "stopping server jobs of stale leader")
+	waitTestDone(t, stoppedA, "stopping leader election of stale leader")
+
+	// b takes over the expired lease with a newer fencing token
+	ctxB, cancelB := context.WithCancel(context.Background())
+	defer cancelB()
+	_, stoppedB := startTestInstance(ctxB, t, "b")
+	leaseB := checkTestLeader(t, "b")
+	if leaseB.Token != leaseA.Token+1 {
+		t.Fatalf("wrong fencing token of new leader, want %v, got %v", leaseA.Token+1, leaseB.Token)
+	}
+	if err = storage.UpdateSwapStatus("test", true, "tx1", storage.TxNotSwapped, now(), ""); err != nil {
+		t.Fatalf("write of new leader failed: %v", err)
+	}
+
+	// the stale leader can not renew its lease after the partition heals
+	store.setPartitioned("a", false)
+	if _, err = storage.AcquireLease(storage.LeaderLeaseName, "a", 2); err != storage.ErrLeaseHeld {
+		t.Fatalf("stale leader renews lease, want %v, got %v", storage.ErrLeaseHeld, err)
+	}
+
+	// b releases the lease when stopped, so that others can take over without waiting
+	cancelB()
+	waitTestDone(t, stoppedB, "stopping leader election")
+	lease, err := storage.FindLease(storage.LeaderLeaseName)
+	if err != nil || lease.Expires != 0 {
+		t.Fatalf("lease is not released when stopped, lease %+v, err %v", lease, err)
+	}
+}
@@ -0,0 +1,46 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/metrics"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+)
+
+var (
+	updateSwapCountMetricsStarter  sync.Once
+	updateSwapCountMetricsInterval = time.Minute
+)
+
+// StartUpdateSwapCountMetricsJob update swap count metrics job
+func StartUpdateSwapCountMetricsJob(ctx context.Context) {
+	updateSwapCountMetricsStarter.Do(func() {
+		logWorker("metrics", "start update swap count metrics job")
+		defer logWorker("metrics", "stop update swap count metrics job")
+		for {
+			updateSwapCountMetrics()
+			if !restInJob(ctx, updateSwapCountMetricsInterval) {
+				return
+			}
+		}
+	})
+}
+
+func updateSwapCountMetrics() {
+	for status := storage.TxNotStable; status <= storage.TxHeld; status++ {
+		if count, err := storage.GetCountOfSwapinsWithStatus(status); err == nil {
+			metrics.SetSwapCount("swapins", status.String(), count)
+		}
+		if count, err := storage.GetCountOfSwapoutsWithStatus(status); err == nil {
+			metrics.SetSwapCount("swapouts", status.String(), count)
+		}
+		if count, err := storage.GetCountOfSwapinResultsWithStatus(status); err == nil {
+			metrics.SetSwapCount("swapinresults", status.String(), count)
+		}
+		if count, err := storage.GetCountOfSwapoutResultsWithStatus(status); err == nil {
+			metrics.SetSwapCount("swapoutresults", status.String(), count)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/metrics"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+var (
+	nonceStarter sync.Once
+
+	errCancelFutureNonce = errors.New("cancel nonce is larger than pending nonce")
+
+	// swaps building, signing or sending their txs,
+	// the nonces reserved by them are not cancelled by the gap filler.
+	inflightSwaps     = make(map[string]int)
+	inflightSwapsLock sync.Mutex
+)
+
+// markSwapInflight mark swap in flight until the returned func is called
+func markSwapInflight(txid string) (done func()) {
+	inflightSwapsLock.Lock()
+	inflightSwaps[txid]++
+	inflightSwapsLock.Unlock()
+	return func() { unmarkSwapInflight(txid) }
+}
+
+// tryMarkSwapInflight mark swap in flight if it is not in flight yet
+func tryMarkSwapInflight(txid string) (done func(), ok bool) {
+	inflightSwapsLock.Lock()
+	defer inflightSwapsLock.Unlock()
+	if inflightSwaps[txid] > 0 {
+		return nil, false
+	}
+	inflightSwaps[txid]++
+	return func() { unmarkSwapInflight(txid) }, true
+}
+
+func unmarkSwapInflight(txid string) {
+	inflightSwapsLock.Lock()
+	defer inflightSwapsLock.Unlock()
+	if inflightSwaps[txid]--; inflightSwaps[txid] <= 0 {
+		delete(inflightSwaps, txid)
+	}
+}
+
+func isSwapInflight(txid string) bool {
+	inflightSwapsLock.Lock()
+	defer inflightSwapsLock.Unlock()
+	return inflightSwaps[txid] > 0
+}
+
+// nonceAccount dcrm address on an eth like chain
+type nonceAccount struct {
+	key     string
+	address string
+	pairID  string
+	isSrc   bool
+	bridge  tokens.CrossChainBridge
+}
+
+// StartNonceJob nonce job, detect nonce gaps of dcrm addresses against the chain,
+// and fill them with the journaled swap tx owning the nonce, or a zero value cancel tx.
+func StartNonceJob(ctx context.Context) {
+	if !tokens.HasNonceManager() {
+		logWorker("nonce", "nonce job is disabled")
+		return
+	}
+	startJob(ctx, startNonceJob)
+}
+
+func startNonceJob(ctx context.Context) {
+	nonceStarter.Do(func() {
+		logWorker("nonce", "start nonce job")
+		defer logWorker("nonce", "stop nonce job")
+		for {
+			start := time.Now()
+			accounts := getNonceAccounts()
+			for _, account := range accounts {
+				if ctx.Err() != nil {
+					return
+				}
+				err := checkNonceGaps(account)
+				if err != nil {
+					logWorkerError("nonce", "check nonce gaps error", err, "account", account.key)
+				}
+			}
+			if len(accounts) > 0 {
+				metrics.ObserveJobLoop("nonce", start)
+			}
+			if !restInJob(ctx, restIntervalInNonceJob) {
+				return
+			}
+		}
+	})
+}
+
+// getNonceAccounts get the distinct dcrm addresses of eth like bridges
+func getNonceAccounts() []*nonceAccount {
+	var accounts []*nonceAccount
+	exist := make(map[string]bool)
+	for _, pairID := range tokens.GetAllPairIDs() {
+		for _, isSrc := range []bool{true, false} {
+			bridge := tokens.GetCrossChainBridge(pairID, isSrc)
+			token := tokens.GetTokenConfig(pairID, isSrc)
+			if bridge == nil || token == nil || token.DcrmAddress == "" {
+				continue
+			}
+			if _, ok := bridge.(tokens.NonceGetter); !ok {
+				continue
+			}
+			reserver, ok := bridge.(tokens.NonceReserver)
+			if !ok {
+				continue
+			}
+			key := reserver.GetNonceAccount()
+			if exist[key] {
+				continue
+			}
+			exist[key] = true
+			accounts = append(accounts, &nonceAccount{
+				key:     key,
+				address: token.DcrmAddress,
+				pairID:  pairID,
+				isSrc:   isSrc,
+				bridge:  bridge,
+			})
+		}
+	}
+	return accounts
+}
+
+// checkNonceGaps the nonces in range [pending nonce, next reserved nonce)
+// are reserved by us but missing in chain and pool.
+func checkNonceGaps(account *nonceAccount) error {
+	nonceGetter := account.bridge.(tokens.NonceGetter)
+	latestNonce, err := nonceGetter.GetPoolNonce(account.address, "latest")
+	if err != nil {
+		return err
+	}
+	pendingNonce, err := nonceGetter.GetPoolNonce(account.address, "pending")
+	if err != nil {
+		return err
+	}
+	stored, err := storage.FindNonceAccount(account.key)
+	if err == storage.ErrItemNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if pendingNonce >= stored.NextNonce {
+		logWorkerTrace("nonce", "no nonce gap", "account", account.key, "latest", latestNonce, "pending", pendingNonce, "next", stored.NextNonce)
+		return nil
+	}
+	logWorkerWarn("nonce", "nonce gap detected", "account", account.key, "latest", latestNonce, "pending", pendingNonce, "next", stored.NextNonce)
+	// fill in order, a nonce can only be mined after the lower ones
+	for nonce := pendingNonce; nonce < stored.NextNonce; nonce++ {
+		filled, err := fillNonceGap(account, stored, nonce)
+		if err != nil || !filled {
+			return err
+		}
+	}
+	return nil
+}
+
+func fillNonceGap(account *nonceAccount, stored *storage.NonceAccount, nonce uint64) (filled bool, err error) {
+	reservation, err := storage.FindNonceReservation(account.key, nonce)
+	if err != nil && err != storage.ErrItemNotFound {
+		return false, err
+	}
+	lastTime := stored.Timestamp
+	if reservation != nil {
+		lastTime = reservation.Timestamp
+	}
+	if now()-lastTime < waitTimeToFillNonceGap {
+		// leave time for the owning swap to send its tx
+		return false, nil
+	}
+	if reservation != nil && reservation.TxID != "" && isSwapInflight(reservation.TxID) {
+		// eg. the owning swap is waiting dcrm signing
+		logWorkerTrace("nonce", "nonce owner is in flight", "account", account.key, "nonce", nonce, "txid", reservation.TxID)
+		return false, nil
+	}
+
+	if reservation == nil {
+		reservation = &storage.NonceReservation{
+			Key:     storage.GetNonceReservationKey(account.key, nonce),
+			Account: account.key,
+			Nonce:   nonce,
+		}
+	} else if reservation.TxID != "" {
+		attempt := rebroadcastNonceOwner(account, reservation)
+		if attempt != nil {
+			logWorker("nonce", "fill nonce gap with swap tx", "account", account.key, "nonce", nonce, "txid", reservation.TxID, "swaptx", attempt.Key)
+			reservation.Status = storage.NonceSent
+			reservation.SwapTx = attempt.Key
+			reservation.Memo = "rebroadcasted"
+			_ = storage.SetNonceReservation(reservation)
+			return true, nil
+		}
+	}
+
+	attempt, err := sendCancelTx(account, reservation)
+	if err != nil {
+		logWorkerError("nonce", "send cancel tx failed", err, "account", account.key, "nonce", nonce, "txid", reservation.TxID)
+		return false, err
+	}
+	logWorker("nonce", "fill nonce gap with cancel tx", "account", account.key, "nonce", nonce, "txid", reservation.TxID, "canceltx", attempt.Key)
+	reservation.Status = storage.NonceCancelled
+	reservation.SwapTx = attempt.Key
+	reservation.Memo = "cancelled"
+	_ = storage.SetNonceReservation(reservation)
+	err = resetCancelledSwap(attempt)
+	if err != nil {
+		logWorkerError("nonce", "reset cancelled swap failed", err, "account", account.key, "nonce", nonce, "txid", reservation.TxID)
+	}
+	return true, nil
+}
+
+// rebroadcastNonceOwner rebroadcast the latest journaled tx of the owning swap with the nonce,
+// which is not dropped and is recorded in its swap result (see isSwapAttemptRecorded).
+func rebroadcastNonceOwner(account *nonceAccount, reservation *storage.NonceReservation) *storage.SwapAttempt {
+	attempts, err := storage.FindSwapAttempts(reservation.TxID)
+	if err != nil {
+		return nil
+	}
+	for i := len(attempts) - 1; i >= 0; i-- {
+		attempt := attempts[i]
+		if attempt.Nonce != reservation.Nonce || getSwapAttemptNonceAccount(attempt) != account.key ||
+			attempt.Status == storage.SwapAttemptDropped {
+			continue
+		}
+		if recorded, _ := isSwapAttemptRecorded(attempt); !recorded {
+			continue
+		}
+		err = rebroadcastSwapAttempt(account.bridge, attempt)
+		if err == nil {
+			return attempt
+		}
+		logWorkerWarn("nonce", "rebroadcast swap attempt failed", "txid", attempt.TxID, "swaptx", attempt.Key, "nonce", attempt.Nonce, "err", err)
+	}
+	return nil
+}
+
+// sendCancelTx sign and send zero value cancel tx with the nonce of reservation,
+// the signed tx is journaled (fenced) before sending, a demoted leader can not send it.
+func sendCancelTx(account *nonceAccount, reservation *storage.NonceReservation) (*storage.SwapAttempt, error) {
+	nonce := reservation.Nonce
+	txid := reservation.TxID
+	txType := tokens.SwapinTx
+	if account.isSrc {
+		txType = tokens.SwapoutTx
+	}
+	args := &tokens.BuildTxArgs{
+		SwapInfo: tokens.SwapInfo{
+			PairID:   account.pairID,
+			SwapID:   txid,
+			SwapType: tokens.CancelType,
+			TxType:   txType,
+		},
+		Extra: &tokens.AllExtras{
+			EthExtra: &tokens.EthExtraArgs{Nonce: &nonce},
+		},
+	}
+	rawTx, err := account.bridge.BuildRawTransaction(args)
+	if err != nil {
+		return nil, err
+	}
+	signedTx, txHash, err := dcrmSignTransaction(account.bridge, rawTx, args.GetExtraArgs())
+	if err != nil {
+		return nil, err
+	}
+	// the bridge of attempt is the opposite endpoint of the swap direction
+	attempt, err := journalSwapAttempt("cancel", account.bridge, args, signedTx, txHash, "0", "0", !account.isSrc)
+	if err != nil {
+		return nil, err
+	}
+	_, err = account.bridge.SendTransaction(signedTx)
+	markSwapAttemptSent(attempt, err)
+	if err != nil {
+		return nil, err
+	}
+	return attempt, nil
+}
+
+// resetCancelledSwap reset the swap owning the nonce of the cancel tx,
+// as its swap txs with the nonce can never be mined. the swap is swapped again
+// with a new nonce, the refund is marked failed for admin to check.
+func resetCancelledSwap(cancel *storage.SwapAttempt) error {
+	txid := cancel.TxID
+	if txid == "" {
+		return nil
+	}
+	attempts, err := storage.FindSwapAttempts(txid)
+	if err != nil {
+		return err
+	}
+	account := getSwapAttemptNonceAccount(cancel)
+	memo := fmt.Sprintf("nonce %v is cancelled by %v", cancel.Nonce, cancel.Key)
+	cancelledTxs := make(map[string]*storage.SwapAttempt)
+	for _, attempt := range attempts {
+		if tokens.SwapType(attempt.SwapType) == tokens.CancelType ||
+			attempt.Nonce != cancel.Nonce || getSwapAttemptNonceAccount(attempt) != account {
+			continue
+		}
+		cancelledTxs[attempt.Key] = attempt
+		if attempt.Status != storage.SwapAttemptDropped {
+			dropSwapAttempt(attempt, memo)
+		}
+	}
+	for _, attempt := range cancelledTxs {
+		isSwapin := attempt.IsSwapin
+		res, err := storage.FindSwapResult(isSwapin, txid)
+		if err != nil {
+			return err
+		}
+		switch {
+		case res.RefundTx != "" && cancelledTxs[res.RefundTx] != nil:
+			logWorkerWarn("nonce", "refund tx is cancelled", "txid", txid, "refundtx", res.RefundTx, "isSwapin", isSwapin)
+			err = storage.UpdateSwapResultStatus("nonce", isSwapin, txid, storage.TxRefundFailed, now(), memo)
+			if err == nil {
+				err = storage.UpdateSwapStatus("nonce", isSwapin, txid, storage.TxRefundFailed, now(), memo)
+			}
+		case res.SwapTx != "" && cancelledTxs[res.SwapTx] != nil:
+			logWorkerWarn("nonce", "swap tx is cancelled, swap it again", "txid", txid, "swaptx", res.SwapTx, "isSwapin", isSwapin)
+			err = storage.UpdateSwapResultStatus("nonce", isSwapin, txid, storage.MatchTxEmpty, now(), "")
+			if err == nil {
+				err = storage.UpdateSwapStatus("nonce", isSwapin, txid, storage.TxNotSwapped, now(), memo)
+			}
+		default:
+			continue
+		}
+		return err
+	}
+	return nil
+}
+
+func getSwapAttemptNonceAccount(attempt *storage.SwapAttempt) string {
+	bridge := getSwapAttemptBridge(attempt)
+	if bridge == nil {
+		return ""
+	}
+	if _, ok := bridge.(tokens.NonceGetter); !ok {
+		return ""
+	}
+	if reserver, ok := bridge.(tokens.NonceReserver); ok {
+		return reserver.GetNonceAccount()
+	}
+	return ""
+}
+
+// markNonceSent mark the nonce reservation of the sent swap attempt
+func markNonceSent(attempt *storage.SwapAttempt) {
+	if !tokens.HasNonceManager() || tokens.SwapType(attempt.SwapType) == tokens.CancelType {
+		return
+	}
+	account := getSwapAttemptNonceAccount(attempt)
+	if account == "" {
+		return
+	}
+	reservation, err := storage.FindNonceReservation(account, attempt.Nonce)
+	if err != nil || reservation.TxID != attempt.TxID {
+		return
+	}
+	reservation.Status = storage.NonceSent
+	reservation.SwapTx = attempt.Key
+	reservation.Memo = attempt.Job
+	_ = storage.SetNonceReservation(reservation)
+}
@@ -0,0 +1,234 @@
+package worker
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+// bridge of eth like chain, its nonces are reserved by the persistent nonce manager
+type testNonceBridge struct {
+	*testBridge
+	base       *tokens.CrossChainBridgeBase
+	poolNonces map[string]uint64 // key is 'latest' or 'pending'
+}
+
+func (b *testNonceBridge) GetPoolNonce(address, height string) (uint64, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.poolNonces[height], nil
+}
+
+func (b *testNonceBridge) setPoolNonce(latest, pending uint64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.poolNonces["latest"] = latest
+	b.poolNonces["pending"] = pending
+}
+
+func (b *testNonceBridge) GetNonceAccount() string {
+	return b.base.GetNonceAccount()
+}
+
+func (b *testNonceBridge) ReserveNonce(txid string, poolNonce uint64) (uint64, error) {
+	return b.base.ReserveNonce(txid, poolNonce)
+}
+
+// addTestNoncePair add token pair named by the test, swapout txs are sent by its source bridge
+func addTestNoncePair(t *testing.T) (*testNonceBridge, *nonceAccount) {
+	tokens.SetNonceManager(&storage.NonceManager{})
+	t.Cleanup(func() { tokens.SetNonceManager(nil) })
+
+	pairID := getTestPairID(t)
+	token := newTestToken()
+	srcBridge := &testNonceBridge{
+		testBridge: &testBridge{pairID: pairID, isSrc: true, token: token, txs: make(map[string]bool)},
+		base:       &tokens.CrossChainBridgeBase{TokenConfig: token, IsSrc: true, PairID: pairID},
+		poolNonces: make(map[string]uint64),
+	}
+	dstBridge := &testBridge{pairID: pairID, token: newTestToken(), txs: make(map[string]bool)}
+	err := tokens.AddTokenPair(&tokens.TokenPair{PairID: pairID, SrcBridge: srcBridge, DstBridge: dstBridge})
+	if err != nil {
+		t.Fatalf("add token pair failed: %v", err)
+	}
+	account := &nonceAccount{
+		key:     srcBridge.GetNonceAccount(),
+		address: token.DcrmAddress,
+		pairID:  pairID,
+		isSrc:   true,
+		bridge:  srcBridge,
+	}
+	return srcBridge, account
+}
+
+func addTestNonceSwapAttempt(t *testing.T, pairID, txid, swapTx string, nonce uint64) {
+	addTestSwapAttempt(t, pairID, txid, swapTx, false, "swap", storage.SwapAttemptSent)
+	attempt := getTestSwapAttempts(t, txid)[swapTx]
+	attempt.Nonce = nonce
+	if err := storage.SetSwapAttempt(attempt); err != nil {
+		t.Fatalf("set swap attempt failed: %v", err)
+	}
+}
+
+func checkTestNonceReservation(t *testing.T, account string, nonce uint64, status, swapTx string) {
+	reservation, err := storage.FindNonceReservation(account, nonce)
+	if err != nil {
+		t.Fatalf("find nonce reservation %v failed: %v", nonce, err)
+	}
+	if reservation.Status != status || reservation.SwapTx != swapTx {
+		t.Errorf("wrong reservation of nonce %v, want %v %v, got %v %v", nonce, status, swapTx, reservation.Status, reservation.SwapTx)
+	}
+}
+
+func TestFillNonceGaps(t *testing.T) {
+	setTestSwapStore(t)
+	bridge, account := addTestNoncePair(t)
+	pairID := bridge.pairID
+	for i, txid := range []string{"tx1", "tx2", "tx3", "tx4"} {
+		nonce, err := bridge.ReserveNonce(txid, 5)
+		if err != nil {
+			t.Fatalf("reserve nonce failed: %v", err)
+		}
+		if nonce != uint64(5+i) {
+			t.Fatalf("wrong reserved nonce of %v, want %v, got %v", txid, 5+i, nonce)
+		}
+	}
+	// tx1 is mined, tx2 is sent and recorded, tx3 is orphaned, tx4 is in flight
+	addTestSwap(t, pairID, "tx2", false, storage.TxProcessed, storage.MatchTxNotStable, "tx2-swaptx")
+	addTestNonceSwapAttempt(t, pairID, "tx2", "tx2-swaptx", 6)
+	addTestSwap(t, pairID, "tx3", false, storage.TxNotSwapped, storage.MatchTxEmpty, "")
+	addTestNonceSwapAttempt(t, pairID, "tx3", "tx3-orphan", 7)
+	done := markSwapInflight("tx4")
+	defer done()
+
+	// no gap if pending nonce reaches the next nonce
+	bridge.setPoolNonce(9, 9)
+	if err := checkNonceGaps(account); err != nil {
+		t.Fatalf("check nonce gaps failed: %v", err)
+	}
+	bridge.setPoolNonce(6, 6)
+
+	// the owning swaps are given time to send their txs
+	if err := checkNonceGaps(account); err != nil {
+		t.Fatalf("check nonce gaps failed: %v", err)
+	}
+	if sentTxs := bridge.getSentTxs(); len(sentTxs) != 0 {
+		t.Fatalf("nonce gap is filled before wait time, sent %v", sentTxs)
+	}
+
+	oldWaitTime := waitTimeToFillNonceGap
+	waitTimeToFillNonceGap = 0
+	defer func() { waitTimeToFillNonceGap = oldWaitTime }()
+	if err := checkNonceGaps(account); err != nil {
+		t.Fatalf("check nonce gaps failed: %v", err)
+	}
+
+	cancelTx := pairID + "-swaptx1"
+	want := fmt.Sprint([]string{"tx2-swaptx", cancelTx})
+	if sentTxs := bridge.getSentTxs(); fmt.Sprint(sentTxs) != want {
+		t.Fatalf("wrong txs filling nonce gaps, want %v, got %v", want, sentTxs)
+	}
+	checkTestNonceReservation(t, account.key, 6, storage.NonceSent, "tx2-swaptx")
+	checkTestNonceReservation(t, account.key, 7, storage.NonceCancelled, cancelTx)
+	checkTestNonceReservation(t, account.key, 8, storage.NonceReserved, "") // owner in flight
+	checkTestSwapAttemptStatus(t, "tx3", map[string]string{
+		"tx3-orphan": storage.SwapAttemptDropped,
+		cancelTx:     storage.SwapAttemptSent,
+	})
+	if swap, _ := storage.FindSwap(false, "tx3"); swap.Status != storage.TxNotSwapped {
+		t.Fatalf("wrong status of swap owning the cancelled nonce, want %v, got %v", storage.TxNotSwapped, swap.Status)
+	}
+}
+
+// the cancelled swap tx recorded in swap result is swapped again
+func TestFillNonceGapResetCancelledSwap(t *testing.T) {
+	setTestSwapStore(t)
+	bridge, account := addTestNoncePair(t)
+	pairID := bridge.pairID
+	if _, err := bridge.ReserveNonce("tx1", 5); err != nil {
+		t.Fatalf("reserve nonce failed: %v", err)
+	}
+	// the swap tx is recorded but its signed tx is not journaled, it can not be rebroadcasted
+	addTestSwap(t, pairID, "tx1", false, storage.TxProcessed, storage.MatchTxNotStable, "tx1-swaptx")
+	addTestNonceSwapAttempt(t, pairID, "tx1", "tx1-swaptx", 5)
+	attempt := getTestSwapAttempts(t, "tx1")["tx1-swaptx"]
+	attempt.RawTx = ""
+	if err := storage.SetSwapAttempt(attempt); err != nil {
+		t.Fatalf("set swap attempt failed: %v", err)
+	}
+	bridge.setPoolNonce(5, 5)
+
+	oldWaitTime := waitTimeToFillNonceGap
+	waitTimeToFillNonceGap = 0
+	defer func() { waitTimeToFillNonceGap = oldWaitTime }()
+	if err := checkNonceGaps(account); err != nil {
+		t.Fatalf("check nonce gaps failed: %v", err)
+	}
+
+	cancelTx := pairID + "-swaptx1"
+	checkTestNonceReservation(t, account.key, 5, storage.NonceCancelled, cancelTx)
+	swap, _ := storage.FindSwap(false, "tx1")
+	res, _ := storage.FindSwapResult(false, "tx1")
+	if swap.Status != storage.TxNotSwapped || res.Status != storage.MatchTxEmpty {
+		t.Fatalf("cancelled swap is not reset, status %v, result status %v", swap.Status, res.Status)
+	}
+}
+
+func TestReserveNonceConcurrently(t *testing.T) {
+	setTestSwapStore(t)
+	bridge, account := addTestNoncePair(t)
+
+	const count = 20
+	var wg sync.WaitGroup
+	nonces := make([]uint64, count)
+	sameNonces := make([]uint64, count)
+	errs := make(chan error, 2*count)
+	for i := 0; i < count; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			nonce, err := bridge.ReserveNonce(fmt.Sprintf("tx%v", i), 5)
+			nonces[i] = nonce
+			errs <- err
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			nonce, err := bridge.ReserveNonce("same", 5)
+			sameNonces[i] = nonce
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("reserve nonce failed: %v", err)
+		}
+	}
+
+	// the reservation of the same txid is reused
+	for _, nonce := range sameNonces {
+		if nonce != sameNonces[0] {
+			t.Fatalf("same txid reserves different nonces: %v", sameNonces)
+		}
+	}
+	// the reserved nonces are distinct and contiguous
+	all := append(append([]uint64{}, nonces...), sameNonces[0])
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	for i, nonce := range all {
+		if nonce != uint64(5+i) {
+			t.Fatalf("reserved nonces are not distinct and contiguous: %v", all)
+		}
+	}
+	stored, err := storage.FindNonceAccount(account.key)
+	if err != nil {
+		t.Fatalf("find nonce account failed: %v", err)
+	}
+	if stored.NextNonce != uint64(5+count+1) {
+		t.Fatalf("wrong next nonce, want %v, got %v", 5+count+1, stored.NextNonce)
+	}
+}
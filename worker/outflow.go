@@ -0,0 +1,265 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/common"
+	"github.com/anyswap/CrossChain-Bridge/metrics"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+var (
+	outflowStarter sync.Once
+
+	errOutflowBreakerCount         = errors.New("too many swaps in outflow limit window")
+	errOutflowLimitExceeded        = errors.New("exceed outflow limit")
+	errOutflowPerBindLimitExceeded = errors.New("exceed outflow limit per bind address")
+	errOutflowPerSenderExceeded    = errors.New("exceed outflow limit per sender")
+
+	// admission of token pair direction is serialized (read outflows then add outflow)
+	outflowAdmitLocks     = make(map[string]*sync.Mutex)
+	outflowAdmitLocksLock sync.Mutex
+
+	// token pair directions paused by the tripped breakers (not by 'maintain close')
+	breakerPausedPairs     = make(map[string]bool)
+	breakerPausedPairsLock sync.Mutex
+)
+
+// StartOutflowJob outflow job, release the held swaps when the outflow limits allow
+func StartOutflowJob(ctx context.Context) {
+	startJob(ctx, startOutflowJob)
+}
+
+func startOutflowJob(ctx context.Context) {
+	outflowStarter.Do(func() {
+		logWorker("outflow", "start outflow job")
+		defer logWorker("outflow", "stop outflow job")
+		for {
+			start := time.Now()
+			syncOutflowBreakers()
+			count := 0
+			for _, isSwapin := range []bool{true, false} {
+				deadLetterExpiredSwaps("outflow", isSwapin, storage.TxHeld, maxHeldLifetime)
+				count += releaseHeldSwaps(ctx, isSwapin)
+			}
+			if count > 0 {
+				metrics.ObserveJobLoop("outflow", start)
+			}
+			if !restInJob(ctx, restIntervalInOutflowJob) {
+				return
+			}
+		}
+	})
+}
+
+// releaseHeldSwaps release the held swaps first in first out (in order of the time they are held),
+// a swap is kept held if an earlier held swap sharing the exceeded limit is kept held
+// (the total limit of the token pair direction, or the limit of the same bind address or sender),
+// so that the later smaller swaps do not overtake it. the fresh swaps are admitted when verified
+// regardless of the held swaps. the swaps of paused direction are kept held,
+// the swaps held longer than maxHeldLifetime are moved into dead-letter status.
+func releaseHeldSwaps(ctx context.Context, isSwapin bool) int {
+	septime := getSepTimeInFind(maxHeldLifetime)
+	swaps, err := storage.FindSwapsWithStatus(isSwapin, storage.TxHeld, septime)
+	if err != nil {
+		logWorkerError("outflow", "find held swaps error", err, "isSwapin", isSwapin)
+	}
+	blocked := make(map[string]bool) // keys of the limits exceeded by the swaps kept held
+	for _, swap := range swaps {
+		if ctx.Err() != nil {
+			break
+		}
+		if tokens.GetTokenConfig(swap.PairID, isSwapin) == nil || tokens.IsSwapDisabled(swap.PairID, isSwapin) {
+			continue
+		}
+		res, err := storage.FindSwapResult(isSwapin, swap.TxID)
+		if err != nil {
+			logWorkerError("outflow", "find held swap result error", err, "txid", swap.TxID, "isSwapin", isSwapin)
+			blocked[getOutflowLimitKey(swap.PairID, errOutflowLimitExceeded, "")] = true
+			continue
+		}
+		if blocked[getOutflowLimitKey(res.PairID, errOutflowLimitExceeded, "")] ||
+			blocked[getOutflowLimitKey(res.PairID, errOutflowPerBindLimitExceeded, res.Bind)] ||
+			blocked[getOutflowLimitKey(res.PairID, errOutflowPerSenderExceeded, res.From)] {
+			continue
+		}
+		held, err := releaseHeldSwap(res, isSwapin)
+		switch {
+		case err != nil:
+			logWorkerError("outflow", "release held swap error", err, "txid", swap.TxID, "isSwapin", isSwapin)
+			blocked[getOutflowLimitKey(res.PairID, errOutflowLimitExceeded, "")] = true
+		case held == errOutflowPerBindLimitExceeded:
+			blocked[getOutflowLimitKey(res.PairID, held, res.Bind)] = true
+		case held == errOutflowPerSenderExceeded:
+			blocked[getOutflowLimitKey(res.PairID, held, res.From)] = true
+		case held != nil:
+			blocked[getOutflowLimitKey(res.PairID, held, "")] = true
+		}
+	}
+	return len(swaps)
+}
+
+// getOutflowLimitKey get key of the exceeded limit, address is the bind address or sender of the per address limits
+func getOutflowLimitKey(pairID string, held error, address string) string {
+	return strings.ToLower(pairID + ":" + held.Error() + ":" + address)
+}
+
+func releaseHeldSwap(res *storage.SwapResult, isSwapin bool) (held, err error) {
+	value, err := common.GetBigIntFromStr(res.Value)
+	if err != nil {
+		return nil, err
+	}
+	held, err = admitOutflow(res.PairID, res.TxID, res.Bind, res.From, value, isSwapin, true)
+	if err != nil || held != nil {
+		return held, err
+	}
+	logWorker("outflow", "release held swap", "txid", res.TxID, "isSwapin", isSwapin)
+	return nil, storage.UpdateSwapStatusFrom("outflow", isSwapin, res.TxID, storage.TxHeld, storage.TxNotSwapped, now(), "")
+}
+
+// getVerifiedSwapStatus get status of the verified swap, big value swaps need admin approval,
+// swaps exceeding the outflow limits are held until released or passed by admin.
+func getVerifiedSwapStatus(swapInfo *tokens.TxSwapInfo, isSwapin bool) (status, resultStatus storage.SwapStatus, memo string, err error) {
+	if swapInfo.Value.Cmp(tokens.GetBigValueThreshold(swapInfo.PairID, isSwapin)) > 0 {
+		return storage.TxWithBigValue, storage.TxWithBigValue, "", nil
+	}
+	held, err := admitOutflow(swapInfo.PairID, swapInfo.Hash, swapInfo.Bind, swapInfo.From, swapInfo.Value, isSwapin, false)
+	if err != nil {
+		return status, resultStatus, "", err
+	}
+	if held != nil {
+		logWorkerWarn("outflow", "hold swap exceeding outflow limits", "txid", swapInfo.Hash, "isSwapin", isSwapin, "bind", swapInfo.Bind, "from", swapInfo.From, "value", swapInfo.Value, "reason", held)
+		return storage.TxHeld, storage.TxHeld, held.Error(), nil
+	}
+	return storage.TxNotSwapped, storage.MatchTxEmpty, "", nil
+}
+
+// admitOutflow check the outflow limits in the rolling window and record the outflow of the admitted swap,
+// returns non nil held error if the swap exceeds the limits. the fresh swap (not released)
+// trips the breaker when exceeding the total limit or the breaker count. the breaker count
+// only counts the fresh swaps, so that releasing the held swaps does not trip the breaker.
+func admitOutflow(pairID, txid, bind, from string, value *big.Int, isSwapin, isRelease bool) (held, err error) {
+	limits := tokens.GetOutflowLimits(pairID, isSwapin)
+	if limits == nil {
+		return nil, nil
+	}
+	lock := getOutflowAdmitLock(pairID, isSwapin)
+	lock.Lock()
+	defer lock.Unlock()
+	outflows, err := storage.FindOutflows(pairID, isSwapin, getSepTimeInFind(limits.Window))
+	if err != nil {
+		return nil, err
+	}
+	count := 1 // fresh swaps in window
+	total := new(big.Int).Set(value)
+	bindTotal := new(big.Int).Set(value)
+	senderTotal := new(big.Int).Set(value)
+	for _, outflow := range outflows {
+		if outflow.TxID == txid {
+			return nil, nil // admitted already (eg. reverified)
+		}
+		outValue, errv := common.GetBigIntFromStr(outflow.Value)
+		if errv != nil {
+			continue
+		}
+		if !outflow.Released {
+			count++
+		}
+		total.Add(total, outValue)
+		if strings.EqualFold(outflow.Bind, bind) {
+			bindTotal.Add(bindTotal, outValue)
+		}
+		if strings.EqualFold(outflow.From, from) {
+			senderTotal.Add(senderTotal, outValue)
+		}
+	}
+	switch {
+	case !isRelease && limits.BreakerCount > 0 && count > limits.BreakerCount:
+		held = errOutflowBreakerCount
+	case limits.Total != nil && total.Cmp(limits.Total) > 0:
+		held = errOutflowLimitExceeded
+	case limits.PerBind != nil && bindTotal.Cmp(limits.PerBind) > 0:
+		held = errOutflowPerBindLimitExceeded
+	case limits.PerSender != nil && senderTotal.Cmp(limits.PerSender) > 0:
+		held = errOutflowPerSenderExceeded
+	}
+	if held != nil {
+		if !isRelease && (held == errOutflowBreakerCount || held == errOutflowLimitExceeded) {
+			tripOutflowBreaker(pairID, isSwapin, held)
+		}
+		return held, nil
+	}
+	return nil, storage.AddOutflow(isSwapin, pairID, txid, bind, from, value.String(), isRelease)
+}
+
+func getOutflowAdmitLock(pairID string, isSwapin bool) *sync.Mutex {
+	key := storage.GetOutflowBreakerKey(pairID, isSwapin)
+	outflowAdmitLocksLock.Lock()
+	defer outflowAdmitLocksLock.Unlock()
+	lock, exist := outflowAdmitLocks[key]
+	if !exist {
+		lock = new(sync.Mutex)
+		outflowAdmitLocks[key] = lock
+	}
+	return lock
+}
+
+// tripOutflowBreaker pause swap of the token pair direction like 'maintain close',
+// admin should check the outflow anomaly and reopen it by 'maintain open'.
+// the breaker is persisted (restored by syncOutflowBreakers after restart or failover)
+// and alerted through the webhook outbox.
+func tripOutflowBreaker(pairID string, isSwapin bool, reason error) {
+	if tokens.GetTokenConfig(pairID, isSwapin) == nil || tokens.IsSwapDisabled(pairID, isSwapin) {
+		return
+	}
+	err := storage.TripOutflowBreaker(pairID, isSwapin, reason.Error())
+	if err != nil {
+		logWorkerError("outflow", "persist outflow breaker failed", err, "pairID", pairID, "isSwapin", isSwapin)
+	}
+	pauseByOutflowBreaker(pairID, isSwapin)
+	metrics.IncOutflowBreakerTrip(pairID, isSwapin)
+	addOutflowBreakerDeliveries(pairID, isSwapin, reason)
+	logWorkerError("outflow", "outflow breaker tripped, swap is paused until 'maintain open'", reason, "pairID", pairID, "isSwapin", isSwapin)
+}
+
+func pauseByOutflowBreaker(pairID string, isSwapin bool) {
+	if tokens.GetTokenConfig(pairID, isSwapin) == nil {
+		return
+	}
+	breakerPausedPairsLock.Lock()
+	defer breakerPausedPairsLock.Unlock()
+	tokens.SetSwapDisabled(pairID, isSwapin, true)
+	breakerPausedPairs[storage.GetOutflowBreakerKey(pairID, isSwapin)] = true
+}
+
+// syncOutflowBreakers pause the token pair directions of the persisted tripped breakers,
+// and reopen the directions paused by the breakers which are reset by admin (on any instance).
+func syncOutflowBreakers() {
+	breakers, err := storage.FindOutflowBreakers()
+	if err != nil {
+		logWorkerError("outflow", "find outflow breakers error", err)
+		return
+	}
+	for _, breaker := range breakers {
+		if breaker.Tripped {
+			if tokens.GetTokenConfig(breaker.PairID, breaker.IsSwapin) != nil && !tokens.IsSwapDisabled(breaker.PairID, breaker.IsSwapin) {
+				logWorkerWarn("outflow", "restore tripped outflow breaker", "pairID", breaker.PairID, "isSwapin", breaker.IsSwapin, "reason", breaker.Reason)
+			}
+			pauseByOutflowBreaker(breaker.PairID, breaker.IsSwapin)
+			continue
+		}
+		breakerPausedPairsLock.Lock()
+		if breakerPausedPairs[breaker.Key] {
+			delete(breakerPausedPairs, breaker.Key)
+			tokens.SetSwapDisabled(breaker.PairID, breaker.IsSwapin, false)
+			logWorker("outflow", "outflow breaker is reset", "pairID", breaker.PairID, "isSwapin", breaker.IsSwapin, "reason", breaker.Reason)
+		}
+		breakerPausedPairsLock.Unlock()
+	}
+}
@@ -0,0 +1,182 @@
+package worker
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/anyswap/CrossChain-Bridge/params"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+// addTestOutflowPair add token pair with the swapin outflow limits (0 decimals, nil value means no limit)
+func addTestOutflowPair(t *testing.T, total, perBind, perSender *float64, breakerCount int) string {
+	oldConfig := params.GetConfig()
+	params.SetConfig(&params.ServerConfig{}) // no webhooks to alert the tripped breaker
+	t.Cleanup(func() { params.SetConfig(oldConfig) })
+
+	srcBridge, _ := addTestTokenPair(t)
+	token := srcBridge.token
+	decimals := uint8(0)
+	token.Decimals = &decimals
+	token.MaximumOutflow = total
+	token.MaximumOutflowPerBind = perBind
+	token.MaximumOutflowPerSender = perSender
+	token.OutflowBreakerCount = breakerCount
+	token.CalcAndStoreValue()
+	return srcBridge.pairID
+}
+
+func newTestFloat(value float64) *float64 {
+	return &value
+}
+
+func addTestHeldSwap(t *testing.T, pairID, txid, bind, from string, value, timestamp int64) {
+	swap := &storage.Swap{Key: txid, PairID: pairID, TxID: txid, Status: storage.TxHeld, Timestamp: timestamp}
+	if err := storage.AddSwap("test", true, swap); err != nil {
+		t.Fatalf("add swap failed: %v", err)
+	}
+	res := &storage.SwapResult{
+		Key:       txid,
+		PairID:    pairID,
+		TxID:      txid,
+		Bind:      bind,
+		From:      from,
+		Value:     big.NewInt(value).String(),
+		SwapType:  uint32(tokens.SwapinType),
+		Status:    storage.TxHeld,
+		Timestamp: timestamp,
+	}
+	if err := storage.AddSwapResult("test", true, res); err != nil {
+		t.Fatalf("add swap result failed: %v", err)
+	}
+}
+
+func checkTestAdmitOutflow(t *testing.T, pairID, txid, bind, from string, value int64, isRelease bool, want error) {
+	held, err := admitOutflow(pairID, txid, bind, from, big.NewInt(value), true, isRelease)
+	if err != nil {
+		t.Fatalf("admit outflow of %v failed: %v", txid, err)
+	}
+	if held != want {
+		t.Fatalf("wrong admission of %v, want %v, got %v", txid, want, held)
+	}
+}
+
+func TestAdmitOutflow(t *testing.T) {
+	setTestSwapStore(t)
+	pairID := addTestOutflowPair(t, newTestFloat(10), newTestFloat(5), newTestFloat(4), 0)
+
+	checkTestAdmitOutflow(t, pairID, "tx1", "a", "s1", 3, false, nil)
+	checkTestAdmitOutflow(t, pairID, "tx1", "a", "s1", 3, false, nil) // admitted already
+	checkTestAdmitOutflow(t, pairID, "tx2", "A", "s2", 3, false, errOutflowPerBindLimitExceeded)
+	checkTestAdmitOutflow(t, pairID, "tx3", "b", "S1", 2, false, errOutflowPerSenderExceeded)
+	checkTestAdmitOutflow(t, pairID, "tx4", "b", "s2", 4, false, nil)
+	checkTestAdmitOutflow(t, pairID, "tx5", "c", "s3", 4, true, errOutflowLimitExceeded)
+	if tokens.IsSwapDisabled(pairID, true) {
+		t.Fatalf("released swap trips the outflow breaker")
+	}
+
+	outflows, err := storage.FindOutflows(pairID, true, 0)
+	if err != nil {
+		t.Fatalf("find outflows failed: %v", err)
+	}
+	if len(outflows) != 2 {
+		t.Fatalf("wrong outflows count, want 2, got %v", len(outflows))
+	}
+
+	// fresh swap exceeding total limit trips the breaker
+	checkTestAdmitOutflow(t, pairID, "tx6", "d", "s4", 4, false, errOutflowLimitExceeded)
+	if !tokens.IsSwapDisabled(pairID, true) {
+		t.Fatalf("outflow breaker is not tripped by total limit")
+	}
+
+	// the pair without limits admits without recording outflow
+	if held, err := admitOutflow("unknown", "tx7", "a", "s1", big.NewInt(100), true, false); held != nil || err != nil {
+		t.Fatalf("admit swap without limits, want nil, got held %v, err %v", held, err)
+	}
+}
+
+func TestOutflowBreakerTripAndReset(t *testing.T) {
+	setTestSwapStore(t)
+	pairID := addTestOutflowPair(t, newTestFloat(10), nil, nil, 2)
+
+	checkTestAdmitOutflow(t, pairID, "tx1", "a", "s1", 1, false, nil)
+	checkTestAdmitOutflow(t, pairID, "tx2", "a", "s1", 1, false, nil)
+	checkTestAdmitOutflow(t, pairID, "tx3", "a", "s1", 1, false, errOutflowBreakerCount)
+	if !tokens.IsSwapDisabled(pairID, true) {
+		t.Fatalf("outflow breaker is not tripped by breaker count")
+	}
+	if tokens.IsSwapDisabled(pairID, false) {
+		t.Fatalf("outflow breaker pauses the other direction")
+	}
+
+	// the persisted breaker is restored (eg. after restart)
+	tokens.SetSwapDisabled(pairID, true, false)
+	syncOutflowBreakers()
+	if !tokens.IsSwapDisabled(pairID, true) {
+		t.Fatalf("tripped outflow breaker is not restored")
+	}
+
+	// reset by 'maintain open' on any instance
+	if err := storage.ResetOutflowBreaker(pairID, true, "reset by test"); err != nil {
+		t.Fatalf("reset outflow breaker failed: %v", err)
+	}
+	syncOutflowBreakers()
+	if tokens.IsSwapDisabled(pairID, true) {
+		t.Fatalf("reset outflow breaker still pauses swap")
+	}
+}
+
+func TestReleaseHeldSwaps(t *testing.T) {
+	setTestSwapStore(t)
+	pairID := addTestOutflowPair(t, nil, newTestFloat(5), nil, 3)
+
+	checkTestAdmitOutflow(t, pairID, "tx1", "a", "s1", 1, false, nil)
+	checkTestAdmitOutflow(t, pairID, "tx2", "b", "s2", 1, false, nil)
+	timestamp := now() - 10
+	addTestHeldSwap(t, pairID, "held1", "a", "s3", 5, timestamp)   // exceeds limit of bind a
+	addTestHeldSwap(t, pairID, "held2", "a", "s4", 1, timestamp+1) // fits, but not overtakes held1
+	addTestHeldSwap(t, pairID, "held3", "c", "s5", 3, timestamp+2)
+	addTestHeldSwap(t, pairID, "held4", "d", "s6", 2, timestamp+3)
+
+	if count := releaseHeldSwaps(context.Background(), true); count != 4 {
+		t.Fatalf("wrong held swaps count, want 4, got %v", count)
+	}
+	wants := map[string]storage.SwapStatus{
+		"held1": storage.TxHeld,
+		"held2": storage.TxHeld,
+		"held3": storage.TxNotSwapped,
+		"held4": storage.TxNotSwapped,
+	}
+	for txid, want := range wants {
+		if swap, _ := storage.FindSwap(true, txid); swap.Status != want {
+			t.Errorf("wrong status of %v, want %v, got %v", txid, want, swap.Status)
+		}
+	}
+	if tokens.IsSwapDisabled(pairID, true) {
+		t.Fatalf("releasing held swaps trips the outflow breaker")
+	}
+
+	// the released swaps are not counted by the breaker count
+	checkTestAdmitOutflow(t, pairID, "tx3", "e", "s7", 1, false, nil)
+	if tokens.IsSwapDisabled(pairID, true) {
+		t.Fatalf("fresh swap after releasing trips the outflow breaker")
+	}
+
+	// the swaps of paused direction are kept held
+	tokens.SetSwapDisabled(pairID, true, true)
+	checkTestAdmitOutflow(t, pairID, "tx1", "a", "s1", 1, false, nil)
+	if err := storage.UpdateSwapStatus("test", true, "held1", storage.TxDeadLetter, now(), ""); err != nil {
+		t.Fatalf("update swap status failed: %v", err)
+	}
+	releaseHeldSwaps(context.Background(), true)
+	if swap, _ := storage.FindSwap(true, "held2"); swap.Status != storage.TxHeld {
+		t.Fatalf("swap of paused direction is released")
+	}
+	tokens.SetSwapDisabled(pairID, true, false)
+	releaseHeldSwaps(context.Background(), true)
+	if swap, _ := storage.FindSwap(true, "held2"); swap.Status != storage.TxNotSwapped {
+		t.Fatalf("wrong status of held2, want %v, got %v", storage.TxNotSwapped, swap.Status)
+	}
+}
@@ -0,0 +1,261 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/internal/workqueue"
+	"github.com/anyswap/CrossChain-Bridge/metrics"
+	"github.com/anyswap/CrossChain-Bridge/params"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+var pipelineStarter sync.Once
+
+// swapTask task of swap pipeline stage, keyed by txid
+type swapTask struct {
+	isSwapin bool
+	txid     string
+}
+
+// pipelineStage stage of swap pipeline, a task is retried until
+// process returns pending false (the swap has left the stage),
+// after retryAfter if it is positive, otherwise by the retry schedule.
+// each queue has several consumers, a task not finished in the visibility timeout
+// (eg. its consumer is stuck) is received by another consumer.
+// the task of exclusive stage is not processed while its swap is in flight
+// (eg. the swap of the task received before is still waiting dcrm signing),
+// it is retried by the retry schedule instead.
+type pipelineStage struct {
+	name      string
+	queues    map[bool]*workqueue.Queue // key is isSwapin
+	consumers int
+	exclusive bool
+	process   func(isSwapin bool, txid string) (pending bool, retryAfter time.Duration, err error)
+}
+
+func newPipelineStage(name string, consumers int, visibilityTimeout time.Duration, retrySchedule []time.Duration, exclusive bool, process func(isSwapin bool, txid string) (bool, time.Duration, error)) *pipelineStage {
+	return &pipelineStage{
+		name: name,
+		queues: map[bool]*workqueue.Queue{
+			true:  workqueue.New("swapin_"+name, visibilityTimeout, retrySchedule...),
+			false: workqueue.New("swapout_"+name, visibilityTimeout, retrySchedule...),
+		},
+		consumers: consumers,
+		exclusive: exclusive,
+		process:   process,
+	}
+}
+
+var (
+	verifyStage = newPipelineStage("verify", consumersOfVerifyTask, visibilityTimeoutOfVerifyTask, retryScheduleOfVerifyTask, false, processVerifyTask)
+	swapStage   = newPipelineStage("swap", consumersOfSwapTask, visibilityTimeoutOfSwapTask, retryScheduleOfSwapTask, true, processSwapTask)
+	stableStage = newPipelineStage("stable", consumersOfStableTask, visibilityTimeoutOfStableTask, retryScheduleOfStableTask, false, processStableTask)
+)
+
+// enqueue add swap task to stage, the existing task is made visible earlier
+func (stage *pipelineStage) enqueue(isSwapin bool, txid string, delay time.Duration) {
+	stage.queues[isSwapin].Add(txid, &swapTask{isSwapin: isSwapin, txid: txid}, delay)
+}
+
+// startPipeline start the consumers of swap pipeline stages,
+// and enqueue tasks by swap updates (eg. registered by api or scanner, verified, swapped).
+// in HA mode, the swaps written by followers are enqueued by tailing the swap event log.
+func startPipeline(ctx context.Context) {
+	pipelineStarter.Do(func() {
+		storage.AddSwapUpdateHandler(enqueueSwapTask)
+		for _, stage := range []*pipelineStage{verifyStage, swapStage, stableStage} {
+			for _, queue := range stage.queues {
+				for i := 0; i < stage.consumers; i++ {
+					stage, queue := stage, queue
+					startJob(ctx, func(ctx context.Context) {
+						consumeSwapTasks(ctx, stage, queue)
+					})
+				}
+			}
+		}
+		if params.IsHAEnabled() {
+			startJob(ctx, feedPipelineFromSwapEvents)
+		}
+	})
+}
+
+// feedPipelineFromSwapEvents enqueue the swaps registered (by api) or operated (by admin)
+// on the followers, which only write them to the store. the swaps written by the jobs of
+// this instance are enqueued by enqueueSwapTask already.
+func feedPipelineFromSwapEvents(ctx context.Context) {
+	logWorker("pipeline", "start feeding pipeline from swap events")
+	defer logWorker("pipeline", "stop feeding pipeline from swap events")
+	seen := make(map[string]int64) // event key -> timestamp
+	for {
+		key := storage.GetSwapEventKeyOfTime(time.Now().Add(-swapEventFeedLookback))
+		for {
+			events, err := storage.FindSwapEventsAfter(key, swapEventFeedBatchSize)
+			if err != nil {
+				logWorkerError("pipeline", "find swap events error", err, "key", key)
+				break
+			}
+			for _, event := range events {
+				if _, exist := seen[event.Key]; !exist {
+					seen[event.Key] = event.Timestamp
+					enqueueSwapEventTask(event)
+				}
+			}
+			if len(events) < swapEventFeedBatchSize {
+				break
+			}
+			key = events[len(events)-1].Key
+		}
+		septime := getSepTimeInFind(int64(2 * swapEventFeedLookback / time.Second))
+		for key, timestamp := range seen {
+			if timestamp < septime {
+				delete(seen, key)
+			}
+		}
+		if !restInJob(ctx, restIntervalInSwapEventFeed) {
+			return
+		}
+	}
+}
+
+func enqueueSwapEventTask(event *storage.SwapEvent) {
+	if !(event.Actor == storage.ActorAPI || storage.IsAdminActor(event.Actor)) {
+		return
+	}
+	switch {
+	case !event.IsResult && event.NewStatus == storage.TxNotStable:
+		verifyStage.enqueue(event.IsSwapin, event.TxID, 0)
+	case !event.IsResult && event.NewStatus == storage.TxNotSwapped:
+		swapStage.enqueue(event.IsSwapin, event.TxID, 0)
+	case event.IsResult && event.NewStatus == storage.MatchTxNotStable && event.SwapTx != "":
+		stableStage.enqueue(event.IsSwapin, event.TxID, retryScheduleOfStableTask[0])
+	}
+}
+
+func enqueueSwapTask(isSwapin bool, swap *storage.Swap, result *storage.SwapResult) {
+	switch {
+	case swap != nil && swap.Status == storage.TxNotStable:
+		verifyStage.enqueue(isSwapin, swap.TxID, getRetryWaitTime(swap))
+	case swap != nil && swap.Status == storage.TxNotSwapped:
+		swapStage.enqueue(isSwapin, swap.TxID, getRetryWaitTime(swap))
+	case result != nil && result.Status == storage.MatchTxNotStable && result.SwapTx != "":
+		// wait the swap tx to be mined
+		stableStage.enqueue(isSwapin, result.TxID, retryScheduleOfStableTask[0])
+	}
+}
+
+func consumeSwapTasks(ctx context.Context, stage *pipelineStage, queue *workqueue.Queue) {
+	logWorker(stage.name, "start consume swap tasks", "queue", queue.Name())
+	defer logWorker(stage.name, "stop consume swap tasks", "queue", queue.Name())
+	for {
+		task := queue.Get(ctx)
+		if task == nil {
+			return
+		}
+		st := task.Value.(*swapTask)
+		done := func() {}
+		if stage.exclusive {
+			var ok bool
+			if done, ok = tryMarkSwapInflight(st.txid); !ok {
+				logWorkerTrace(stage.name, "swap task is in flight", "txid", st.txid, "isSwapin", st.isSwapin, "attempts", task.Attempts)
+				queue.Retry(task)
+				continue
+			}
+		}
+		start := time.Now()
+		pending, retryAfter, err := stage.process(st.isSwapin, st.txid)
+		done()
+		switch err {
+		case nil, tokens.ErrTxNotStable, tokens.ErrTxNotFound:
+		default:
+			logWorkerError(stage.name, "process swap task error", err, "txid", st.txid, "isSwapin", st.isSwapin, "attempts", task.Attempts)
+		}
+		metrics.ObserveJobLoop(queue.Name(), start)
+		switch {
+		case pending && retryAfter > 0:
+			queue.RetryAfter(task, retryAfter)
+		case pending:
+			queue.Retry(task)
+		default:
+			queue.Done(task)
+		}
+	}
+}
+
+func processVerifyTask(isSwapin bool, txid string) (pending bool, retryAfter time.Duration, err error) {
+	swap, err := findSwapInStatus("verify", isSwapin, txid, storage.TxNotStable, maxVerifyLifetime)
+	if swap == nil {
+		return err != nil, 0, err
+	}
+	if retryAfter = getRetryWaitTime(swap); retryAfter > 0 {
+		return true, retryAfter, nil
+	}
+	err = processSwapVerify(swap, isSwapin)
+	return checkSwapTaskResult("verify", isSwapin, txid, storage.TxNotStable, err)
+}
+
+func processSwapTask(isSwapin bool, txid string) (pending bool, retryAfter time.Duration, err error) {
+	swap, err := findSwapInStatus("swap", isSwapin, txid, storage.TxNotSwapped, maxDoSwapLifetime)
+	if swap == nil {
+		return err != nil, 0, err
+	}
+	if retryAfter = getRetryWaitTime(swap); retryAfter > 0 {
+		return true, retryAfter, nil
+	}
+	err = processSwap(swap, isSwapin)
+	return checkSwapTaskResult("swap", isSwapin, txid, storage.TxNotSwapped, err)
+}
+
+func processStableTask(isSwapin bool, txid string) (pending bool, retryAfter time.Duration, err error) {
+	res, err := storage.FindSwapResult(isSwapin, txid)
+	if err != nil {
+		return err != storage.ErrItemNotFound, 0, err
+	}
+	if res.Status != storage.MatchTxNotStable || res.SwapTx == "" || res.Timestamp < getSepTimeInFind(maxStableLifetime) {
+		return false, 0, nil
+	}
+	err = processSwapStable(res, isSwapin)
+	res, errf := storage.FindSwapResult(isSwapin, txid)
+	return errf != nil || res.Status == storage.MatchTxNotStable, 0, err
+}
+
+// checkSwapTaskResult the task is pending if the swap is still in status,
+// the failed attempt is retried by the retry policy of the stage.
+func checkSwapTaskResult(job string, isSwapin bool, txid string, status storage.SwapStatus, err error) (pending bool, retryAfter time.Duration, _ error) {
+	swap, errf := storage.FindSwap(isSwapin, txid)
+	if errf != nil {
+		return true, 0, err
+	}
+	if swap.Status != status {
+		return false, 0, err
+	}
+	switch err {
+	case nil, tokens.ErrTxNotStable, tokens.ErrTxNotFound:
+		return true, 0, err
+	}
+	retryAfter, isDead := onSwapProcessFailed(job, swap, isSwapin, err)
+	return !isDead, retryAfter, err
+}
+
+// findSwapInStatus find swap if it is in status and not older than lifetime,
+// returns nil swap and nil error if the task is obsolete.
+// the expired swap is moved into dead-letter status.
+func findSwapInStatus(job string, isSwapin bool, txid string, status storage.SwapStatus, lifetime int64) (*storage.Swap, error) {
+	swap, err := storage.FindSwap(isSwapin, txid)
+	if err == storage.ErrItemNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if swap.Status != status {
+		return nil, nil
+	}
+	if swap.Timestamp < getSepTimeInFind(lifetime) {
+		expireSwap(job, swap, isSwapin, lifetime)
+		return nil, nil
+	}
+	return swap, nil
+}
@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// runTestRedelivery process a task longer than its visibility timeout with two consumers,
+// returns the max count of the task processed concurrently.
+func runTestRedelivery(t *testing.T, txid string, exclusive bool) (maxRunning int) {
+	var lock sync.Mutex
+	running, calls := 0, 0
+	release := make(chan struct{})
+	stage := newPipelineStage(t.Name(), 2, 20*time.Millisecond, []time.Duration{5 * time.Millisecond}, exclusive,
+		func(isSwapin bool, txid string) (bool, time.Duration, error) {
+			lock.Lock()
+			running++
+			calls++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			first := calls == 1
+			lock.Unlock()
+			if first {
+				<-release // eg. waiting dcrm signing
+			}
+			lock.Lock()
+			running--
+			lock.Unlock()
+			return false, 0, nil
+		})
+	queue := stage.queues[true]
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for i := 0; i < stage.consumers; i++ {
+		go consumeSwapTasks(ctx, stage, queue)
+	}
+	stage.enqueue(true, txid, 0)
+
+	time.Sleep(100 * time.Millisecond) // several visibility timeouts
+	close(release)
+	for deadline := time.Now().Add(time.Second); queue.Len() > 0; time.Sleep(5 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("swap task is not finished, queue length %v", queue.Len())
+		}
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	return maxRunning
+}
+
+func TestPipelineRedeliveryWhileProcessing(t *testing.T) {
+	if maxRunning := runTestRedelivery(t, "tx1", true); maxRunning != 1 {
+		t.Fatalf("swap task processed concurrently, want 1, got %v", maxRunning)
+	}
+	if isSwapInflight("tx1") {
+		t.Fatalf("swap is still in flight after processed")
+	}
+	// without exclusion the redelivered task is processed by another consumer
+	if maxRunning := runTestRedelivery(t, "tx2", false); maxRunning != 2 {
+		t.Fatalf("wrong concurrency of non exclusive stage, want 2, got %v", maxRunning)
+	}
+}
@@ -0,0 +1,304 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/common"
+	"github.com/anyswap/CrossChain-Bridge/metrics"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+	"github.com/anyswap/CrossChain-Bridge/types"
+)
+
+var (
+	refundStarter       sync.Once
+	refundStableStarter sync.Once
+
+	errRefundNotEnabled    = errors.New("refund is not enabled")
+	errRefundWithoutSender = errors.New("refund deposit without sender")
+
+	// deposits of these status are refunded if refund is enabled
+	refundSwapStatuses = []storage.SwapStatus{
+		storage.TxWithWrongMemo,
+		storage.TxWithWrongValue,
+		storage.BindAddrIsContract,
+		storage.SwapInBlacklist,
+		storage.TxRefundApproved,
+	}
+)
+
+// StartRefundJob refund job, only started if refund is enabled by any token
+func StartRefundJob(ctx context.Context) {
+	if !isRefundEnabled(true) && !isRefundEnabled(false) {
+		logWorker("refund", "refund is not enabled by any token")
+		return
+	}
+	startJob(ctx, startRefundJob)
+	startJob(ctx, startRefundStableJob)
+}
+
+func isRefundEnabled(isSwapin bool) bool {
+	for _, pairID := range tokens.GetAllPairIDs() {
+		if tokens.IsRefundEnabled(pairID, isSwapin) {
+			return true
+		}
+	}
+	return false
+}
+
+func startRefundJob(ctx context.Context) {
+	refundStarter.Do(func() {
+		logWorker("refund", "start refund job")
+		defer logWorker("refund", "stop refund job")
+		for {
+			start := time.Now()
+			count := 0
+			for _, isSwapin := range []bool{true, false} {
+				if !isRefundEnabled(isSwapin) {
+					continue
+				}
+				res := findSwapsToRefund(isSwapin)
+				count += len(res)
+				for _, swap := range res {
+					if ctx.Err() != nil {
+						return
+					}
+					err := processRefund(swap, isSwapin)
+					if err != nil {
+						logWorkerError("refund", "process refund error", err, "txid", swap.TxID, "isSwapin", isSwapin)
+					}
+				}
+			}
+			if count > 0 {
+				metrics.ObserveJobLoop("refund", start)
+			}
+			if !restInJob(ctx, restIntervalInRefundJob) {
+				return
+			}
+		}
+	})
+}
+
+func startRefundStableJob(ctx context.Context) {
+	refundStableStarter.Do(func() {
+		logWorker("refund", "start refund stable job")
+		defer logWorker("refund", "stop refund stable job")
+		for {
+			start := time.Now()
+			count := 0
+			for _, isSwapin := range []bool{true, false} {
+				res, err := findRefundResultsToStable(isSwapin)
+				if err != nil {
+					logWorkerError("refund", "find refund results error", err, "isSwapin", isSwapin)
+				}
+				count += len(res)
+				for _, swap := range res {
+					if ctx.Err() != nil {
+						return
+					}
+					err = processRefundStable(swap, isSwapin)
+					if err != nil {
+						logWorkerError("refund", "process refund stable error", err, "txid", swap.TxID, "isSwapin", isSwapin)
+					}
+				}
+			}
+			if count > 0 {
+				metrics.ObserveJobLoop("refund_stable", start)
+			}
+			if !restInJob(ctx, restIntervalInRefundJob) {
+				return
+			}
+		}
+	})
+}
+
+func findSwapsToRefund(isSwapin bool) (result []*storage.Swap) {
+	septime := getSepTimeInFind(maxRefundLifetime)
+	for _, status := range refundSwapStatuses {
+		swaps, err := storage.FindSwapsWithStatus(isSwapin, status, septime)
+		if err != nil {
+			logWorkerError("refund", "find swaps to refund error", err, "status", status, "isSwapin", isSwapin)
+			continue
+		}
+		result = append(result, swaps...)
+	}
+	return result
+}
+
+func findRefundResultsToStable(isSwapin bool) ([]*storage.SwapResult, error) {
+	status := storage.RefundTxNotStable
+	septime := getSepTimeInFind(maxRefundLifetime)
+	return storage.FindSwapResultsWithStatus(isSwapin, status, septime)
+}
+
+func processRefund(swap *storage.Swap, isSwapin bool) (err error) {
+	txid := swap.TxID
+	pairID := swap.PairID
+	if !tokens.IsRefundEnabled(pairID, isSwapin) {
+		return nil
+	}
+	if swap.Status != storage.TxRefundApproved && now()-swap.Timestamp < waitTimeToRefund {
+		return nil
+	}
+	txType := tokens.SwapTxType(swap.TxType)
+	if txType != tokens.SwapinTx && txType != tokens.SwapoutTx {
+		logWorkerTrace("refund", "refund of tx type is not supported", "txid", txid, "txtype", txType)
+		return nil
+	}
+	logWorker("refund", "start process refund", "txid", txid, "status", swap.Status, "isSwapin", isSwapin)
+
+	refundBridge := tokens.GetCrossChainBridge(pairID, isSwapin)
+	if refundBridge == nil {
+		return tokens.ErrUnknownPairID
+	}
+
+	res, err := storage.FindSwapResult(isSwapin, txid)
+	if err == storage.ErrItemNotFound {
+		// blacklisted when verifying, there is no swap result yet
+		res, err = addRefundSwapResult(refundBridge, swap, isSwapin)
+	}
+	if err != nil {
+		return err
+	}
+	if res.SwapTx != "" || res.RefundTx != "" {
+		return fmt.Errorf("%v already has swaptx '%v' refundtx '%v'", txid, res.SwapTx, res.RefundTx)
+	}
+	if res.From == "" || !refundBridge.IsValidAddress(res.From) {
+		return storage.UpdateSwapStatusFrom("refund", isSwapin, txid, swap.Status, storage.TxRefundFailed, now(), errRefundWithoutSender.Error())
+	}
+
+	value, err := common.GetBigIntFromStr(res.Value)
+	if err != nil {
+		return fmt.Errorf("wrong value %v", res.Value)
+	}
+	refundValue := tokens.CalcRefundValue(pairID, value, isSwapin)
+	if refundValue.Sign() <= 0 {
+		return storage.UpdateSwapStatusFrom("refund", isSwapin, txid, swap.Status, storage.TxRefundFailed, now(), "value is not enough for refund fee")
+	}
+	if swap.Status != storage.TxRefundApproved && tokens.IsRefundNeedApproval(pairID, value, isSwapin) {
+		logWorker("refund", "refund need approval", "txid", txid, "value", value, "isSwapin", isSwapin)
+		return storage.UpdateSwapStatusFrom("refund", isSwapin, txid, swap.Status, storage.TxRefundNeedApproval, now(), "")
+	}
+
+	args := &tokens.BuildTxArgs{
+		SwapInfo: tokens.SwapInfo{
+			PairID:   pairID,
+			SwapID:   txid,
+			SwapType: tokens.RefundType,
+			TxType:   txType,
+			Bind:     swap.Bind,
+		},
+		To:    res.From,
+		Value: value,
+	}
+	defer markSwapInflight(txid)()
+	rawTx, err := refundBridge.BuildRawTransaction(args)
+	if err != nil {
+		logWorkerError("refund", "BuildRawTransaction failed", err, "txid", txid, "isSwapin", isSwapin)
+		return err
+	}
+
+	signedTx, txHash, err := dcrmSignTransaction(refundBridge, rawTx, args.GetExtraArgs())
+	if err != nil {
+		logWorkerError("refund", "DcrmSignTransaction failed", err, "txid", txid, "isSwapin", isSwapin)
+		return err
+	}
+
+	// update database before sending transaction
+	refundFee := tokens.CalcRefundFee(pairID, value, isSwapin).String()
+	attempt, err := journalSwapAttempt("refund", refundBridge, args, signedTx, txHash, refundValue.String(), refundFee, isSwapin)
+	if err != nil {
+		logWorkerError("refund", "journal swap attempt failed", err, "txid", txid, "isSwapin", isSwapin)
+		return err
+	}
+	err = updateRefundResult(txid, isSwapin, res.Status, &storage.SwapResultUpdateItems{
+		Status:      storage.RefundTxNotStable,
+		Timestamp:   now(),
+		RefundTx:    txHash,
+		RefundValue: refundValue.String(),
+		RefundFee:   refundFee,
+		RefundNonce: args.GetTxNonce(),
+	})
+	if err != nil {
+		dropSwapAttempt(attempt, err.Error())
+		return err
+	}
+
+	err = storage.UpdateSwapStatusFrom("refund", isSwapin, txid, swap.Status, storage.TxRefunded, now(), "")
+	if err != nil {
+		logWorkerError("refund", "update swap status failed", err, "txid", txid, "isSwapin", isSwapin)
+		return err
+	}
+
+	err = sendSignedTransaction("refund", refundBridge, signedTx, txid, isSwapin, storage.TxRefundFailed)
+	markSwapAttemptSent(attempt, err)
+	return err
+}
+
+func addRefundSwapResult(bridge tokens.CrossChainBridge, swap *storage.Swap, isSwapin bool) (*storage.SwapResult, error) {
+	swapInfo, err := verifySwapTransaction(bridge, swap)
+	if !tokens.IsRefundableVerifyError(err) {
+		return nil, err
+	}
+	if swapInfo == nil || swapInfo.Value == nil {
+		return nil, errRefundWithoutSender
+	}
+	err = addInitialSwapResult("refund", swapInfo, swap.Status, isSwapin)
+	if err != nil {
+		return nil, err
+	}
+	return storage.FindSwapResult(isSwapin, swap.TxID)
+}
+
+func updateRefundResult(key string, isSwapin bool, oldStatus storage.SwapStatus, updates *storage.SwapResultUpdateItems) (err error) {
+	err = storage.UpdateSwapResultFrom("refund", isSwapin, key, oldStatus, updates)
+	if err != nil {
+		logWorkerError("refund", "updateRefundResult", err, "txid", key, "refundtx", updates.RefundTx, "status", updates.Status, "isSwapin", isSwapin)
+	} else {
+		logWorker("refund", "updateRefundResult", "txid", key, "refundtx", updates.RefundTx, "status", updates.Status, "isSwapin", isSwapin)
+	}
+	return err
+}
+
+func processRefundStable(swap *storage.SwapResult, isSwapin bool) error {
+	refundBridge := tokens.GetCrossChainBridge(swap.PairID, isSwapin)
+	if refundBridge == nil {
+		return tokens.ErrUnknownPairID
+	}
+
+	txStatus := refundBridge.GetTransactionStatus(swap.RefundTx)
+	if txStatus == nil || txStatus.BlockHeight == 0 {
+		return nil
+	}
+
+	if swap.RefundHeight == 0 {
+		return updateRefundResult(swap.Key, isSwapin, swap.Status, &storage.SwapResultUpdateItems{
+			Status:       storage.RefundTxNotStable,
+			Timestamp:    now(),
+			RefundHeight: txStatus.BlockHeight,
+			RefundTime:   txStatus.BlockTime,
+		})
+	}
+
+	token, _ := refundBridge.GetTokenAndGateway()
+	if txStatus.Confirmations < *token.Confirmations {
+		return nil
+	}
+	status := storage.RefundTxStable
+	if txStatus.Receipt != nil {
+		receipt, ok := txStatus.Receipt.(*types.RPCTxReceipt)
+		txFailed := !ok || receipt == nil || *receipt.Status != 1
+		if !txFailed && token.ContractAddress != "" && len(receipt.Logs) == 0 {
+			txFailed = true
+		}
+		if txFailed {
+			status = storage.RefundTxFailed
+		}
+	}
+	logWorker("refund", "update refund result status", "txid", swap.Key, "refundtx", swap.RefundTx, "status", status, "isSwapin", isSwapin)
+	return storage.UpdateSwapResultStatusFrom("refund", isSwapin, swap.Key, swap.Status, status, now(), "")
+}
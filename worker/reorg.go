@@ -0,0 +1,186 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/metrics"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+var (
+	swapinReorgStarter  sync.Once
+	swapoutReorgStarter sync.Once
+)
+
+// StartReorgJob reverify swaps affected by chain reorganisation
+func StartReorgJob(ctx context.Context) {
+	startJob(ctx, startSwapinReorgJob)
+	startJob(ctx, startSwapoutReorgJob)
+}
+
+func startSwapinReorgJob(ctx context.Context) {
+	swapinReorgStarter.Do(func() {
+		logWorker("reorg", "start swapin reorg job")
+		defer logWorker("reorg", "stop swapin reorg job")
+		for {
+			start := time.Now()
+			count := processReorgedSwaps(ctx, true)
+			if count > 0 {
+				metrics.ObserveJobLoop("swapin_reorg", start)
+			}
+			if !restInJob(ctx, restIntervalInReorgJob) {
+				return
+			}
+		}
+	})
+}
+
+func startSwapoutReorgJob(ctx context.Context) {
+	swapoutReorgStarter.Do(func() {
+		logWorker("reorg", "start swapout reorg job")
+		defer logWorker("reorg", "stop swapout reorg job")
+		for {
+			start := time.Now()
+			count := processReorgedSwaps(ctx, false)
+			if count > 0 {
+				metrics.ObserveJobLoop("swapout_reorg", start)
+			}
+			if !restInJob(ctx, restIntervalInReorgJob) {
+				return
+			}
+		}
+	})
+}
+
+func processReorgedSwaps(ctx context.Context, isSwapin bool) (count int) {
+	septime := getSepTimeInFind(maxReorgLifetime)
+	var (
+		swaps []*storage.Swap
+		err   error
+	)
+	if isSwapin {
+		swaps, err = storage.FindSwapinsWithStatus(storage.TxReorged, septime)
+	} else {
+		swaps, err = storage.FindSwapoutsWithStatus(storage.TxReorged, septime)
+	}
+	if err != nil {
+		logWorkerError("reorg", "find reorged swaps error", err, "isSwapin", isSwapin)
+	}
+	for _, swap := range swaps {
+		if ctx.Err() != nil {
+			return count
+		}
+		err = processSwapReorg(swap, isSwapin)
+		if err != nil {
+			logWorkerError("reorg", "process swap reorg error", err, "txid", swap.TxID, "isSwapin", isSwapin)
+		}
+	}
+	count += len(swaps)
+
+	var results []*storage.SwapResult
+	if isSwapin {
+		results, err = storage.FindSwapinResultsWithStatus(storage.MatchTxReorged, septime)
+	} else {
+		results, err = storage.FindSwapoutResultsWithStatus(storage.MatchTxReorged, septime)
+	}
+	if err != nil {
+		logWorkerError("reorg", "find reorged swap results error", err, "isSwapin", isSwapin)
+	}
+	for _, res := range results {
+		if ctx.Err() != nil {
+			return count
+		}
+		err = processSwapResultReorg(res, isSwapin)
+		if err != nil {
+			logWorkerError("reorg", "process swap result reorg error", err, "txid", res.TxID, "isSwapin", isSwapin)
+		}
+	}
+	count += len(results)
+	return count
+}
+
+// processSwapReorg reverify the source tx which was in an orphaned block
+func processSwapReorg(swap *storage.Swap, isSwapin bool) error {
+	txid := swap.TxID
+	bridge := tokens.GetCrossChainBridge(swap.PairID, isSwapin)
+	if bridge == nil {
+		return tokens.ErrUnknownPairID
+	}
+	swapInfo, err := verifySwapTransaction(bridge, swap)
+	switch err {
+	case tokens.ErrTxNotStable, tokens.ErrTxNotFound:
+		// wait the source tx to be stable on the canonical chain
+		return nil
+	}
+
+	res, errf := storage.FindSwapResult(isSwapin, txid)
+	if errf != nil {
+		return errf
+	}
+
+	if res.SwapTx != "" {
+		if err != nil {
+			logWorkerError("reorg", "swapped source tx is invalid after reorg, need manual process", err, "txid", txid, "swaptx", res.SwapTx, "isSwapin", isSwapin)
+			return nil
+		}
+		logWorker("reorg", "swapped source tx is reverified", "txid", txid, "swaptx", res.SwapTx, "isSwapin", isSwapin)
+		return storage.UpdateSwapStatus("reorg", isSwapin, txid, storage.TxProcessed, now(), "")
+	}
+
+	if err != nil {
+		logWorkerWarn("reorg", "source tx is invalid after reorg", "txid", txid, "isSwapin", isSwapin, "err", err)
+		memo := err.Error()
+		err = storage.UpdateSwapResultStatus("reorg", isSwapin, txid, storage.TxVerifyFailed, now(), memo)
+		if err != nil {
+			return err
+		}
+		return storage.UpdateSwapStatus("reorg", isSwapin, txid, storage.TxVerifyFailed, now(), memo)
+	}
+
+	status, resultStatus, memo, err := getVerifiedSwapStatus(swapInfo, isSwapin)
+	if err != nil {
+		return err
+	}
+	logWorker("reorg", "source tx is reverified", "txid", txid, "isSwapin", isSwapin, "status", status)
+	err = storage.UpdateSwapResultStatus("reorg", isSwapin, txid, resultStatus, now(), memo)
+	if err != nil {
+		return err
+	}
+	return storage.UpdateSwapStatus("reorg", isSwapin, txid, status, now(), memo)
+}
+
+// processSwapResultReorg move the match tx which was in an orphaned block
+// back to not stable status after it is found again in chain or pool.
+// never reswap here, the match tx may be mined again after reorg.
+// the match tx not found for a long time is marked failed for admin to check and reswap.
+func processSwapResultReorg(res *storage.SwapResult, isSwapin bool) error {
+	resBridge := tokens.GetCrossChainBridge(res.PairID, !isSwapin)
+	if resBridge == nil {
+		return tokens.ErrUnknownPairID
+	}
+	swapTxs := append(append([]string{}, res.OldSwapTxs...), res.SwapTx)
+	for _, swapTx := range swapTxs {
+		if _, err := resBridge.GetTransaction(swapTx); err == nil {
+			logWorker("reorg", "reorged swap tx is found", "txid", res.TxID, "swaptx", swapTx, "isSwapin", isSwapin)
+			var swapType tokens.SwapType
+			if isSwapin {
+				swapType = tokens.SwapinType
+			} else {
+				swapType = tokens.SwapoutType
+			}
+			return updateSwapResult("reorg", res.TxID, res.Status, &MatchTx{SwapType: swapType})
+		}
+	}
+	if res.Timestamp > getSepTimeInFind(waitTimeToFailReorgedSwapTx) {
+		logWorkerWarn("reorg", "reorged swap tx is not found", "txid", res.TxID, "swaptx", res.SwapTx, "isSwapin", isSwapin)
+		return nil
+	}
+	logWorkerError("reorg", "reorged swap tx is dropped, need manual process", tokens.ErrTxNotFound, "txid", res.TxID, "swaptx", res.SwapTx, "isSwapin", isSwapin)
+	metrics.IncDroppedReorgedSwapTx(res.PairID, isSwapin)
+	memo := fmt.Sprintf("reorged swap tx is not found in %v seconds", waitTimeToFailReorgedSwapTx)
+	return storage.UpdateSwapResultStatusFrom("reorg", isSwapin, res.TxID, res.Status, storage.MatchTxFailed, now(), memo)
+}
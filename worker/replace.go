@@ -0,0 +1,262 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/common"
+	"github.com/anyswap/CrossChain-Bridge/metrics"
+	"github.com/anyswap/CrossChain-Bridge/params"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
+)
+
+var (
+	swapinReplaceStarter  sync.Once
+	swapoutReplaceStarter sync.Once
+)
+
+// StartReplaceJob replace job
+func StartReplaceJob(ctx context.Context) {
+	replaceConfig := params.GetReplaceConfig()
+	if replaceConfig == nil || !replaceConfig.Enable {
+		logWorker("replace", "replace job is disabled")
+		return
+	}
+	startJob(ctx, startSwapinReplaceJob)
+	startJob(ctx, startSwapoutReplaceJob)
+}
+
+func startSwapinReplaceJob(ctx context.Context) {
+	swapinReplaceStarter.Do(func() {
+		logWorker("replace", "start swapin replace job")
+		defer logWorker("replace", "stop swapin replace job")
+		for {
+			start := time.Now()
+			res, err := findSwapinResultsToReplace()
+			if err != nil {
+				logWorkerError("replace", "find swapin results error", err)
+			}
+			for _, swap := range res {
+				if ctx.Err() != nil {
+					return
+				}
+				err = processSwapinReplace(swap)
+				if err != nil {
+					logWorkerError("replace", "process swapin replace error", err, "txid", swap.TxID)
+				}
+			}
+			if len(res) > 0 {
+				metrics.ObserveJobLoop("swapin_replace", start)
+			}
+			if !restInJob(ctx, restIntervalInReplaceJob) {
+				return
+			}
+		}
+	})
+}
+
+func startSwapoutReplaceJob(ctx context.Context) {
+	swapoutReplaceStarter.Do(func() {
+		logWorker("replace", "start swapout replace job")
+		defer logWorker("replace", "stop swapout replace job")
+		for {
+			start := time.Now()
+			res, err := findSwapoutResultsToReplace()
+			if err != nil {
+				logWorkerError("replace", "find swapout results error", err)
+			}
+			for _, swap := range res {
+				if ctx.Err() != nil {
+					return
+				}
+				err = processSwapoutReplace(swap)
+				if err != nil {
+					logWorkerError("replace", "process swapout replace error", err, "txid", swap.TxID)
+				}
+			}
+			if len(res) > 0 {
+				metrics.ObserveJobLoop("swapout_replace", start)
+			}
+			if !restInJob(ctx, restIntervalInReplaceJob) {
+				return
+			}
+		}
+	})
+}
+
+func findSwapinResultsToReplace() ([]*storage.SwapResult, error) {
+	status := storage.MatchTxNotStable
+	septime := getSepTimeInFind(maxReplaceLifetime)
+	return storage.FindSwapinResultsWithStatus(status, septime)
+}
+
+func findSwapoutResultsToReplace() ([]*storage.SwapResult, error) {
+	status := storage.MatchTxNotStable
+	septime := getSepTimeInFind(maxReplaceLifetime)
+	return storage.FindSwapoutResultsWithStatus(status, septime)
+}
+
+func processSwapinReplace(swap *storage.SwapResult) error {
+	return processSwapReplace(swap, true)
+}
+
+func processSwapoutReplace(swap *storage.SwapResult) error {
+	return processSwapReplace(swap, false)
+}
+
+func processSwapReplace(res *storage.SwapResult, isSwapin bool) error {
+	if res.SwapTx == "" || res.SwapHeight != 0 {
+		return nil
+	}
+	replaceConfig := params.GetReplaceConfig()
+	if now()-res.Timestamp < replaceConfig.WaitTimeToReplace {
+		return nil
+	}
+	if len(res.OldSwapTxs) >= replaceConfig.MaxReplaceCount {
+		logWorkerTrace("replace", "swap reached max replace count", "txid", res.TxID, "isSwapin", isSwapin, "count", len(res.OldSwapTxs))
+		return nil
+	}
+
+	pairID := res.PairID
+	resBridge := tokens.GetCrossChainBridge(pairID, !isSwapin)
+	if resBridge == nil {
+		return tokens.ErrUnknownPairID
+	}
+	replaceBridge, ok := resBridge.(tokens.ReplaceableBridge)
+	if !ok {
+		return nil
+	}
+	if tokens.IsSwapDisabled(pairID, isSwapin) {
+		logWorkerTrace("replace", "swap is disabled", "pairID", pairID, "isSwapin", isSwapin)
+		return nil
+	}
+
+	// leave it to the stable job if any of the swap txs is mined
+	swapTxs := append(append([]string{}, res.OldSwapTxs...), res.SwapTx)
+	for _, swapTx := range swapTxs {
+		txStatus := resBridge.GetTransactionStatus(swapTx)
+		if txStatus != nil && txStatus.BlockHeight > 0 {
+			return nil
+		}
+	}
+
+	logWorker("replace", "start replace swap tx", "txid", res.TxID, "swaptx", res.SwapTx, "isSwapin", isSwapin)
+
+	// the latest swap tx may be not broadcasted, then try the previous ones
+	var (
+		extra *tokens.AllExtras
+		err   error
+	)
+	for i := len(swapTxs) - 1; i >= 0; i-- {
+		extra, err = replaceBridge.GetReplaceExtraArgs(swapTxs[i], res.SwapNonce, replaceConfig.PlusFeePercentage)
+		if err == nil {
+			break
+		}
+		if err == tokens.ErrSwapTxAlreadyMined || err == tokens.ErrReplaceTxNotSupported {
+			return nil
+		}
+		logWorkerWarn("replace", "get replace extra args failed", "txid", res.TxID, "swaptx", swapTxs[i], "err", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	value, err := common.GetBigIntFromStr(res.Value)
+	if err != nil {
+		return fmt.Errorf("wrong value %v", res.Value)
+	}
+
+	var swapType tokens.SwapType
+	if isSwapin {
+		swapType = tokens.SwapinType
+	} else {
+		swapType = tokens.SwapoutType
+	}
+
+	feeArgs, err := tools.GetSwapFeeArgs(res.Bind, res.TxTime, isSwapin)
+	if err != nil {
+		return err
+	}
+
+	args := &tokens.BuildTxArgs{
+		SwapInfo: tokens.SwapInfo{
+			PairID:   pairID,
+			SwapID:   res.TxID,
+			SwapType: swapType,
+		},
+		To:      res.Bind,
+		Value:   value,
+		Extra:   extra,
+		FeeArgs: feeArgs,
+	}
+
+	if isSwapin {
+		swap, errf := storage.FindSwap(isSwapin, res.TxID)
+		if errf != nil {
+			return errf
+		}
+		args.TxType = tokens.SwapTxType(swap.TxType)
+		args.Bind = swap.Bind
+	}
+
+	defer markSwapInflight(res.TxID)()
+	rawTx, err := resBridge.BuildRawTransaction(args)
+	if err != nil {
+		logWorkerError("replace", "BuildRawTransaction failed", err, "txid", res.TxID, "isSwapin", isSwapin)
+		return err
+	}
+
+	signedTx, txHash, err := dcrmSignTransaction(resBridge, rawTx, args.GetExtraArgs())
+	if err != nil {
+		logWorkerError("replace", "DcrmSignTransaction failed", err, "txid", res.TxID, "isSwapin", isSwapin)
+		return err
+	}
+
+	// update database before sending transaction
+	matchTx := &MatchTx{
+		SwapTx:     txHash,
+		SwapValue:  res.SwapValue,
+		SwapType:   swapType,
+		SwapNonce:  res.SwapNonce,
+		OldSwapTxs: swapTxs,
+	}
+	attempt, err := journalSwapAttempt("replace", resBridge, args, signedTx, txHash, res.SwapValue, res.SwapFee, isSwapin)
+	if err != nil {
+		logWorkerError("replace", "journal swap attempt failed", err, "txid", res.TxID, "isSwapin", isSwapin)
+		return err
+	}
+	err = updateSwapResult("replace", res.TxID, res.Status, matchTx)
+	if err != nil {
+		logWorkerError("replace", "update swap result failed", err, "txid", res.TxID, "isSwapin", isSwapin)
+		dropSwapAttempt(attempt, err.Error())
+		return err
+	}
+
+	err = sendReplaceTransaction(resBridge, signedTx, res.TxID, isSwapin)
+	markSwapAttemptSent(attempt, err)
+	return err
+}
+
+// sendReplaceTransaction send replacement tx,
+// it does not increase nonce nor mark swap failed as sendSignedTransaction does.
+func sendReplaceTransaction(bridge tokens.CrossChainBridge, signedTx interface{}, txid string, isSwapin bool) (err error) {
+	var (
+		txHash              string
+		retrySendTxCount    = 3
+		retrySendTxInterval = 1 * time.Second
+	)
+	for i := 0; i < retrySendTxCount; i++ {
+		txHash, err = bridge.SendTransaction(signedTx)
+		if err == nil {
+			logWorker("replace", "send replace tx success", "txid", txid, "txHash", txHash, "isSwapin", isSwapin)
+			return nil
+		}
+		time.Sleep(retrySendTxInterval)
+	}
+	logWorkerError("replace", "send replace tx failed", err, "txid", txid, "txHash", txHash, "isSwapin", isSwapin)
+	return err
+}
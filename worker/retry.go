@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/params"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+// permanentSwapErrors can not be fixed by retrying (eg. config or tx type errors),
+// the swap is moved into dead-letter status at once.
+// other errors (eg. rpc, dcrm sign and database errors) are transient.
+var permanentSwapErrors = []error{
+	tokens.ErrUnknownPairID,
+	tokens.ErrNoBtcBridge,
+	tokens.ErrWrongSwapinTxType,
+	tokens.ErrUnknownSwapType,
+	tokens.ErrSwapTypeNotSupported,
+	tokens.ErrBuildSwapTxInWrongEndpoint,
+	tokens.ErrWrongExtraArgs,
+}
+
+// isPermanentSwapError is err (or the error it wraps) permanent
+func isPermanentSwapError(err error) bool {
+	for _, permanentErr := range permanentSwapErrors {
+		if errors.Is(err, permanentErr) {
+			return true
+		}
+	}
+	return false
+}
+
+// getRetryPolicy get retry policy of the stage retrying swaps in status
+func getRetryPolicy(status storage.SwapStatus) *params.RetryPolicyConfig {
+	if status == storage.TxNotStable {
+		return params.GetRetryConfig().Verify
+	}
+	return params.GetRetryConfig().Swap
+}
+
+// getRetryBackoff exponential backoff, InitialBackoff * 2^(n-1) seconds, at most MaxBackoff
+func getRetryBackoff(policy *params.RetryPolicyConfig, retryCount uint64) time.Duration {
+	backoff := policy.InitialBackoff
+	for i := uint64(1); i < retryCount && backoff < policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > policy.MaxBackoff {
+		backoff = policy.MaxBackoff
+	}
+	return time.Duration(backoff) * time.Second
+}
+
+// getRetryWaitTime get the time to wait before retrying the failed swap
+func getRetryWaitTime(swap *storage.Swap) time.Duration {
+	if wait := swap.NextRetryTime - now(); wait > 0 {
+		return time.Duration(wait) * time.Second
+	}
+	return 0
+}
+
+// onSwapProcessFailed record the failed attempt of swap which is still in its status,
+// and returns the time to wait before retrying. the swap is moved into dead-letter status
+// if the error is permanent or the attempts are exhausted.
+func onSwapProcessFailed(job string, swap *storage.Swap, isSwapin bool, err error) (retryAfter time.Duration, isDead bool) {
+	policy := getRetryPolicy(swap.Status)
+	retryCount := swap.RetryCount + 1
+	if isPermanentSwapError(err) || retryCount >= policy.MaxAttempts {
+		moveSwapToDeadLetter(job, swap, isSwapin, retryCount, err)
+		return 0, true
+	}
+	retryAfter = getRetryBackoff(policy, retryCount)
+	nextRetryTime := now() + int64(retryAfter/time.Second)
+	errr := storage.UpdateSwapRetry(isSwapin, swap.TxID, swap.Status, retryCount, nextRetryTime)
+	if errr != nil {
+		logWorkerError(job, "update swap retry failed", errr, "txid", swap.TxID, "isSwapin", isSwapin)
+	}
+	logWorkerWarn(job, "process swap failed, retry later", "txid", swap.TxID, "isSwapin", isSwapin, "status", swap.Status, "retrycount", retryCount, "nextretrytime", nextRetryTime, "err", err)
+	return retryAfter, false
+}
+
+func moveSwapToDeadLetter(job string, swap *storage.Swap, isSwapin bool, retryCount uint64, err error) {
+	errr := storage.UpdateSwapRetry(isSwapin, swap.TxID, swap.Status, retryCount, 0)
+	if errr != nil {
+		logWorkerError(job, "update swap retry failed", errr, "txid", swap.TxID, "isSwapin", isSwapin)
+	}
+	memo := fmt.Sprintf("%v failed %v times: %v", job, retryCount, err)
+	errr = storage.UpdateSwapStatusFrom(job, isSwapin, swap.TxID, swap.Status, storage.TxDeadLetter, now(), memo)
+	if errr != nil {
+		logWorkerError(job, "move swap into dead-letter status failed", errr, "txid", swap.TxID, "isSwapin", isSwapin)
+		return
+	}
+	logWorkerWarn(job, "move swap into dead-letter status", "txid", swap.TxID, "isSwapin", isSwapin, "status", swap.Status, "retrycount", retryCount, "err", err)
+}
+
+// deadLetterExpiredSwaps move the swaps which stay in status longer than lifetime
+// (eg. never stable, or the pair is paused) into dead-letter status, instead of
+// leaving them out of the pipeline and sweepers silently. the lifetime is counted
+// regardless of pausing, so pausing a pair (by 'maintain close' or the outflow breaker)
+// longer than the lifetime dead-letters its pending swaps, they are marked in memo
+// and should be requeued by admin after reopening the pair.
+func deadLetterExpiredSwaps(job string, isSwapin bool, status storage.SwapStatus, lifetime int64) {
+	swaps, err := storage.FindExpiredSwapsWithStatus(isSwapin, status, getSepTimeInFind(lifetime))
+	if err != nil {
+		logWorkerError(job, "find expired swaps error", err, "status", status, "isSwapin", isSwapin)
+		return
+	}
+	for _, swap := range swaps {
+		expireSwap(job, swap, isSwapin, lifetime)
+	}
+}
+
+func expireSwap(job string, swap *storage.Swap, isSwapin bool, lifetime int64) {
+	memo := fmt.Sprintf("%v expired after %v seconds in status %v", job, lifetime, swap.Status)
+	if tokens.IsSwapDisabled(swap.PairID, isSwapin) {
+		memo += " (swap is paused)"
+	}
+	err := storage.UpdateSwapStatusFrom(job, isSwapin, swap.TxID, swap.Status, storage.TxDeadLetter, now(), memo)
+	if err != nil {
+		logWorkerError(job, "move expired swap into dead-letter status failed", err, "txid", swap.TxID, "isSwapin", isSwapin)
+		return
+	}
+	logWorkerWarn(job, "move expired swap into dead-letter status", "txid", swap.TxID, "isSwapin", isSwapin, "status", swap.Status, "timestamp", swap.Timestamp)
+}
@@ -0,0 +1,170 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/params"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
+)
+
+func setTestRetryConfig(t *testing.T) {
+	oldConfig := params.GetConfig()
+	params.SetConfig(&params.ServerConfig{
+		Retry: &params.RetryConfig{
+			Verify: &params.RetryPolicyConfig{MaxAttempts: 2, InitialBackoff: 10, MaxBackoff: 20},
+			Swap:   &params.RetryPolicyConfig{MaxAttempts: 3, InitialBackoff: 30, MaxBackoff: 200},
+		},
+	})
+	t.Cleanup(func() { params.SetConfig(oldConfig) })
+}
+
+func TestIsPermanentSwapError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{errors.New("some error"), false},
+		{tokens.ErrRPCQueryError, false},
+		{tokens.ErrTxNotStable, false},
+		{fmt.Errorf("build tx failed: %w", tokens.ErrRPCQueryError), false},
+		{fmt.Errorf("build tx failed: %w", tokens.ErrUnknownSwapType), true},
+		{fmt.Errorf("wrapped: %w", fmt.Errorf("build tx failed: %w", tokens.ErrWrongExtraArgs)), true},
+		{errors.New(tokens.ErrUnknownPairID.Error()), false}, // same message but not the error
+	}
+	for _, permanentErr := range permanentSwapErrors {
+		tests = append(tests, struct {
+			err  error
+			want bool
+		}{permanentErr, true})
+	}
+	for _, test := range tests {
+		if got := isPermanentSwapError(test.err); got != test.want {
+			t.Errorf("is permanent swap error %v, want %v, got %v", test.err, test.want, got)
+		}
+	}
+}
+
+func TestGetRetryBackoff(t *testing.T) {
+	policy := &params.RetryPolicyConfig{MaxAttempts: 10, InitialBackoff: 30, MaxBackoff: 200}
+	tests := []struct {
+		retryCount uint64
+		want       time.Duration
+	}{
+		{0, 30 * time.Second},
+		{1, 30 * time.Second},
+		{2, 60 * time.Second},
+		{3, 120 * time.Second},
+		{4, 200 * time.Second},
+		{5, 200 * time.Second},
+		{1000, 200 * time.Second},
+	}
+	for _, test := range tests {
+		if got := getRetryBackoff(policy, test.retryCount); got != test.want {
+			t.Errorf("retry backoff of %v retries, want %v, got %v", test.retryCount, test.want, got)
+		}
+	}
+}
+
+func checkTestDeadLetter(t *testing.T, txid string, isSwapin bool, memo string) *storage.Swap {
+	swap, err := storage.FindSwap(isSwapin, txid)
+	if err != nil {
+		t.Fatalf("find swap failed: %v", err)
+	}
+	if swap.Status != storage.TxDeadLetter || !strings.Contains(swap.Memo, memo) {
+		t.Fatalf("swap %v is not in dead-letter status, status %v, memo %v", txid, swap.Status, swap.Memo)
+	}
+	return swap
+}
+
+func TestOnSwapProcessFailed(t *testing.T) {
+	setTestSwapStore(t)
+	setTestRetryConfig(t)
+	transientErr := fmt.Errorf("dcrm sign failed: %w", tokens.ErrRPCQueryError)
+
+	// transient errors are retried with backoff until the attempts are exhausted
+	addTestSwap(t, "", "tx1", false, storage.TxNotSwapped, storage.MatchTxEmpty, "")
+	for i, want := range []time.Duration{30 * time.Second, 60 * time.Second} {
+		swap, _ := storage.FindSwap(false, "tx1")
+		retryAfter, isDead := onSwapProcessFailed("swap", swap, false, transientErr)
+		if isDead || retryAfter != want {
+			t.Fatalf("wrong retry of failed attempt %v, want %v, got %v (dead %v)", i+1, want, retryAfter, isDead)
+		}
+		swap, _ = storage.FindSwap(false, "tx1")
+		if swap.Status != storage.TxNotSwapped || swap.RetryCount != uint64(i+1) || getRetryWaitTime(swap) <= 0 {
+			t.Fatalf("wrong retry of swap, status %v, retry count %v, next retry time %v", swap.Status, swap.RetryCount, swap.NextRetryTime)
+		}
+	}
+	swap, _ := storage.FindSwap(false, "tx1")
+	if _, isDead := onSwapProcessFailed("swap", swap, false, transientErr); !isDead {
+		t.Fatalf("swap is not dead after max attempts")
+	}
+	swap = checkTestDeadLetter(t, "tx1", false, "swap failed 3 times")
+	if swap.RetryCount != 3 || swap.NextRetryTime != 0 {
+		t.Fatalf("wrong retry of dead swap, retry count %v, next retry time %v", swap.RetryCount, swap.NextRetryTime)
+	}
+
+	// permanent errors are not retried
+	addTestSwap(t, "", "tx2", false, storage.TxNotSwapped, storage.MatchTxEmpty, "")
+	swap, _ = storage.FindSwap(false, "tx2")
+	permanentErr := fmt.Errorf("build tx failed: %w", tokens.ErrUnknownSwapType)
+	if _, isDead := onSwapProcessFailed("swap", swap, false, permanentErr); !isDead {
+		t.Fatalf("swap with permanent error is not dead")
+	}
+	checkTestDeadLetter(t, "tx2", false, tokens.ErrUnknownSwapType.Error())
+
+	// the verify stage has its own policy
+	addTestSwap(t, "", "tx3", true, storage.TxNotStable, storage.MatchTxEmpty, "")
+	swap, _ = storage.FindSwap(true, "tx3")
+	if retryAfter, isDead := onSwapProcessFailed("verify", swap, true, transientErr); isDead || retryAfter != 10*time.Second {
+		t.Fatalf("wrong retry of verify, want %v, got %v (dead %v)", 10*time.Second, retryAfter, isDead)
+	}
+	swap, _ = storage.FindSwap(true, "tx3")
+	if _, isDead := onSwapProcessFailed("verify", swap, true, transientErr); !isDead {
+		t.Fatalf("verify is not dead after max attempts")
+	}
+	checkTestDeadLetter(t, "tx3", true, "verify failed 2 times")
+
+	// the dead swap is requeued by admin
+	if err := storage.UpdateSwapStatus("admin", true, "tx3", storage.TxNotStable, now(), ""); err != nil {
+		t.Fatalf("requeue dead swap failed: %v", err)
+	}
+}
+
+func TestDeadLetterExpiredSwaps(t *testing.T) {
+	setTestSwapStore(t)
+	srcBridge, _ := addTestTokenPair(t)
+	pairID := srcBridge.pairID
+	lifetime := int64(100)
+	addTestSwap(t, pairID, "expired", false, storage.TxNotSwapped, storage.MatchTxEmpty, "")
+	addTestSwap(t, pairID, "fresh", false, storage.TxNotSwapped, storage.MatchTxEmpty, "")
+	addTestSwap(t, pairID, "stable", false, storage.TxNotStable, storage.MatchTxEmpty, "")
+	setTestSwapTimestamp(t, "expired", storage.TxNotSwapped, now()-lifetime-10)
+	setTestSwapTimestamp(t, "stable", storage.TxNotStable, now()-lifetime-10)
+
+	deadLetterExpiredSwaps("swap", false, storage.TxNotSwapped, lifetime)
+	checkTestDeadLetter(t, "expired", false, "swap expired after 100 seconds")
+	for txid, want := range map[string]storage.SwapStatus{"fresh": storage.TxNotSwapped, "stable": storage.TxNotStable} {
+		if swap, _ := storage.FindSwap(false, txid); swap.Status != want {
+			t.Errorf("wrong status of %v, want %v, got %v", txid, want, swap.Status)
+		}
+	}
+
+	// the swaps expired while the pair is paused are marked
+	tokens.SetSwapDisabled(pairID, false, true)
+	setTestSwapTimestamp(t, "fresh", storage.TxNotSwapped, now()-lifetime-10)
+	deadLetterExpiredSwaps("swap", false, storage.TxNotSwapped, lifetime)
+	checkTestDeadLetter(t, "fresh", false, "(swap is paused)")
+}
+
+// setTestSwapTimestamp set the time when swap is updated into status
+func setTestSwapTimestamp(t *testing.T, txid string, status storage.SwapStatus, timestamp int64) {
+	if err := storage.UpdateSwapStatus("test", false, txid, status, timestamp, ""); err != nil {
+		t.Fatalf("update swap timestamp failed: %v", err)
+	}
+}
@@ -1,22 +1,34 @@
 package worker
 
 import (
+	"context"
+
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 )
 
 // StartScanJob scan job
-func StartScanJob(isServer bool) {
-	srcTokenCfg, _ := tokens.SrcBridge.GetTokenAndGateway()
+func StartScanJob(ctx context.Context, isServer bool) {
+	for _, pairID := range tokens.GetAllPairIDs() {
+		startScanJobOfPair(ctx, pairID)
+	}
+}
+
+func startScanJobOfPair(ctx context.Context, pairID string) {
+	pair := tokens.GetTokenPair(pairID)
+
+	srcTokenCfg, _ := pair.SrcBridge.GetTokenAndGateway()
 	if srcTokenCfg.EnableScan {
-		go tokens.SrcBridge.StartPoolTransactionScanJob()
-		go tokens.SrcBridge.StartChainTransactionScanJob()
-		go tokens.SrcBridge.StartSwapHistoryScanJob()
+		logWorker("scan", "start scan source chain", "pairID", pairID)
+		startJob(ctx, pair.SrcBridge.StartPoolTransactionScanJob)
+		startJob(ctx, pair.SrcBridge.StartChainTransactionScanJob)
+		startJob(ctx, pair.SrcBridge.StartSwapHistoryScanJob)
 	}
 
-	dstTokenCfg, _ := tokens.DstBridge.GetTokenAndGateway()
+	dstTokenCfg, _ := pair.DstBridge.GetTokenAndGateway()
 	if dstTokenCfg.EnableScan {
-		go tokens.DstBridge.StartPoolTransactionScanJob()
-		go tokens.DstBridge.StartChainTransactionScanJob()
-		go tokens.DstBridge.StartSwapHistoryScanJob()
+		logWorker("scan", "start scan dest chain", "pairID", pairID)
+		startJob(ctx, pair.DstBridge.StartPoolTransactionScanJob)
+		startJob(ctx, pair.DstBridge.StartChainTransactionScanJob)
+		startJob(ctx, pair.DstBridge.StartSwapHistoryScanJob)
 	}
 }
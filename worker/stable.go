@@ -1,9 +1,10 @@
 package worker
 
 import (
+	"context"
 	"sync"
 
-	"github.com/anyswap/CrossChain-Bridge/mongodb"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 	"github.com/anyswap/CrossChain-Bridge/types"
 )
@@ -14,14 +15,15 @@ var (
 )
 
 // StartStableJob stable job
-func StartStableJob() {
-	go startSwapinStableJob()
-	go startSwapoutStableJob()
+func StartStableJob(ctx context.Context) {
+	startJob(ctx, startSwapinStableJob)
+	startJob(ctx, startSwapoutStableJob)
 }
 
-func startSwapinStableJob() {
+func startSwapinStableJob(ctx context.Context) {
 	swapinStableStarter.Do(func() {
 		logWorker("stable", "start update swapin stable job")
+		defer logWorker("stable", "stop update swapin stable job")
 		for {
 			res, err := findSwapinResultsToStable()
 			if err != nil {
@@ -31,19 +33,19 @@ func startSwapinStableJob() {
 				logWorker("stable", "find swapin results to stable", "count", len(res))
 			}
 			for _, swap := range res {
-				err = processSwapinStable(swap)
-				if err != nil {
-					logWorkerError("stable", "process swapin stable error", err)
-				}
+				stableStage.enqueue(true, swap.TxID, 0)
+			}
+			if !restInJob(ctx, sweepIntervalInStableJob) {
+				return
 			}
-			restInJob(restIntervalInStableJob)
 		}
 	})
 }
 
-func startSwapoutStableJob() {
+func startSwapoutStableJob(ctx context.Context) {
 	swapoutStableStarter.Do(func() {
 		logWorker("stable", "start update swapout stable job")
+		defer logWorker("stable", "stop update swapout stable job")
 		for {
 			res, err := findSwapoutResultsToStable()
 			if err != nil {
@@ -53,52 +55,63 @@ func startSwapoutStableJob() {
 				logWorker("stable", "find swapout results to stable", "count", len(res))
 			}
 			for _, swap := range res {
-				err = processSwapoutStable(swap)
-				if err != nil {
-					logWorkerError("stable", "process swapout stable error", err)
-				}
+				stableStage.enqueue(false, swap.TxID, 0)
+			}
+			if !restInJob(ctx, sweepIntervalInStableJob) {
+				return
 			}
-			restInJob(restIntervalInStableJob)
 		}
 	})
 }
 
-func findSwapinResultsToStable() ([]*mongodb.MgoSwapResult, error) {
-	status := mongodb.MatchTxNotStable
+func findSwapinResultsToStable() ([]*storage.SwapResult, error) {
+	status := storage.MatchTxNotStable
 	septime := getSepTimeInFind(maxStableLifetime)
-	return mongodb.FindSwapinResultsWithStatus(status, septime)
+	return storage.FindSwapinResultsWithStatus(status, septime)
 }
 
-func findSwapoutResultsToStable() ([]*mongodb.MgoSwapResult, error) {
-	status := mongodb.MatchTxNotStable
+func findSwapoutResultsToStable() ([]*storage.SwapResult, error) {
+	status := storage.MatchTxNotStable
 	septime := getSepTimeInFind(maxStableLifetime)
-	return mongodb.FindSwapoutResultsWithStatus(status, septime)
+	return storage.FindSwapoutResultsWithStatus(status, septime)
 }
 
-func processSwapinStable(swap *mongodb.MgoSwapResult) error {
+func processSwapinStable(swap *storage.SwapResult) error {
 	logWorker("stable", "start processSwapinStable", "swaptxid", swap.SwapTx, "status", swap.Status)
 	return processSwapStable(swap, true)
 }
 
-func processSwapoutStable(swap *mongodb.MgoSwapResult) (err error) {
+func processSwapoutStable(swap *storage.SwapResult) (err error) {
 	logWorker("stable", "start processSwapoutStable", "swaptxid", swap.SwapTx, "status", swap.Status)
 	return processSwapStable(swap, false)
 }
 
-func processSwapStable(swap *mongodb.MgoSwapResult, isSwapin bool) (err error) {
+func processSwapStable(swap *storage.SwapResult, isSwapin bool) (err error) {
 	swapTxID := swap.SwapTx
 
-	var resBridge tokens.CrossChainBridge
+	resBridge := tokens.GetCrossChainBridge(swap.PairID, !isSwapin)
+	if resBridge == nil {
+		return tokens.ErrUnknownPairID
+	}
 	var swapType tokens.SwapType
 	if isSwapin {
-		resBridge = tokens.DstBridge
 		swapType = tokens.SwapinType
 	} else {
-		resBridge = tokens.SrcBridge
 		swapType = tokens.SwapoutType
 	}
 
 	txStatus := resBridge.GetTransactionStatus(swapTxID)
+	if (txStatus == nil || txStatus.BlockHeight == 0) && swap.SwapHeight == 0 {
+		// one of the replaced swap txs may be mined instead
+		for _, oldSwapTx := range swap.OldSwapTxs {
+			oldTxStatus := resBridge.GetTransactionStatus(oldSwapTx)
+			if oldTxStatus != nil && oldTxStatus.BlockHeight > 0 {
+				swapTxID = oldSwapTx
+				txStatus = oldTxStatus
+				break
+			}
+		}
+	}
 	if txStatus == nil || txStatus.BlockHeight == 0 {
 		return nil
 	}
@@ -128,5 +141,9 @@ func processSwapStable(swap *mongodb.MgoSwapResult, isSwapin bool) (err error) {
 		SwapTime:   txStatus.BlockTime,
 		SwapType:   swapType,
 	}
-	return updateSwapResult(swap.Key, matchTx)
+	if swapTxID != swap.SwapTx {
+		logWorker("stable", "replaced swap tx is mined", "txid", swap.Key, "swaptx", swap.SwapTx, "minedtx", swapTxID)
+		matchTx.SwapTx = swapTxID
+	}
+	return updateSwapResult("stable", swap.Key, swap.Status, matchTx)
 }
@@ -1,34 +1,31 @@
 package worker
 
 import (
-	"container/ring"
+	"context"
 	"fmt"
-	"math/big"
 	"sync"
 
 	"github.com/anyswap/CrossChain-Bridge/common"
-	"github.com/anyswap/CrossChain-Bridge/mongodb"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
+	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
 )
 
 var (
 	swapinSwapStarter  sync.Once
 	swapoutSwapStarter sync.Once
-
-	swapRing        *ring.Ring
-	swapRingLock    sync.RWMutex
-	swapRingMaxSize = 1000
 )
 
 // StartSwapJob swap job
-func StartSwapJob() {
-	go startSwapinSwapJob()
-	go startSwapoutSwapJob()
+func StartSwapJob(ctx context.Context) {
+	startJob(ctx, startSwapinSwapJob)
+	startJob(ctx, startSwapoutSwapJob)
 }
 
-func startSwapinSwapJob() {
+func startSwapinSwapJob(ctx context.Context) {
 	swapinSwapStarter.Do(func() {
 		logWorker("swap", "start swapin swap job")
+		defer logWorker("swap", "stop swapin swap job")
 		for {
 			res, err := findSwapinsToSwap()
 			if err != nil {
@@ -38,19 +35,20 @@ func startSwapinSwapJob() {
 				logWorker("swapin", "find swapins to swap", "count", len(res))
 			}
 			for _, swap := range res {
-				err = processSwapinSwap(swap)
-				if err != nil {
-					logWorkerError("swapin", "process swapin swap error", err, "txid", swap.TxID)
-				}
+				swapStage.enqueue(true, swap.TxID, getRetryWaitTime(swap))
+			}
+			deadLetterExpiredSwaps("swap", true, storage.TxNotSwapped, maxDoSwapLifetime)
+			if !restInJob(ctx, sweepIntervalInDoSwapJob) {
+				return
 			}
-			restInJob(restIntervalInDoSwapJob)
 		}
 	})
 }
 
-func startSwapoutSwapJob() {
+func startSwapoutSwapJob(ctx context.Context) {
 	swapoutSwapStarter.Do(func() {
 		logWorker("swapout", "start swapout swap job")
+		defer logWorker("swapout", "stop swapout swap job")
 		for {
 			res, err := findSwapoutsToSwap()
 			if err != nil {
@@ -60,35 +58,35 @@ func startSwapoutSwapJob() {
 				logWorker("swapout", "find swapouts to swap", "count", len(res))
 			}
 			for _, swap := range res {
-				err = processSwapoutSwap(swap)
-				if err != nil {
-					logWorkerError("swapout", "process swapout swap error", err)
-				}
+				swapStage.enqueue(false, swap.TxID, getRetryWaitTime(swap))
+			}
+			deadLetterExpiredSwaps("swap", false, storage.TxNotSwapped, maxDoSwapLifetime)
+			if !restInJob(ctx, sweepIntervalInDoSwapJob) {
+				return
 			}
-			restInJob(restIntervalInDoSwapJob)
 		}
 	})
 }
 
-func findSwapinsToSwap() ([]*mongodb.MgoSwap, error) {
-	status := mongodb.TxNotSwapped
+func findSwapinsToSwap() ([]*storage.Swap, error) {
+	status := storage.TxNotSwapped
 	septime := getSepTimeInFind(maxDoSwapLifetime)
-	return mongodb.FindSwapinsWithStatus(status, septime)
+	return storage.FindSwapinsWithStatus(status, septime)
 }
 
-func findSwapoutsToSwap() ([]*mongodb.MgoSwap, error) {
-	status := mongodb.TxNotSwapped
+func findSwapoutsToSwap() ([]*storage.Swap, error) {
+	status := storage.TxNotSwapped
 	septime := getSepTimeInFind(maxDoSwapLifetime)
-	return mongodb.FindSwapoutsWithStatus(status, septime)
+	return storage.FindSwapoutsWithStatus(status, septime)
 }
 
-func isSwapInBlacklist(swap *mongodb.MgoSwapResult) (isBlacked bool, err error) {
-	isBlacked, err = mongodb.QueryBlacklist(swap.From)
+func isSwapInBlacklist(swap *storage.SwapResult) (isBlacked bool, err error) {
+	isBlacked, err = storage.QueryBlacklist(swap.From)
 	if err != nil {
 		return isBlacked, err
 	}
 	if !isBlacked && swap.Bind != swap.From {
-		isBlacked, err = mongodb.QueryBlacklist(swap.Bind)
+		isBlacked, err = storage.QueryBlacklist(swap.Bind)
 		if err != nil {
 			return isBlacked, err
 		}
@@ -96,34 +94,36 @@ func isSwapInBlacklist(swap *mongodb.MgoSwapResult) (isBlacked bool, err error)
 	return isBlacked, nil
 }
 
-func processSwapinSwap(swap *mongodb.MgoSwap) (err error) {
+func processSwapinSwap(swap *storage.Swap) (err error) {
 	return processSwap(swap, true)
 }
 
-func processSwapoutSwap(swap *mongodb.MgoSwap) (err error) {
+func processSwapoutSwap(swap *storage.Swap) (err error) {
 	return processSwap(swap, false)
 }
 
-func processSwap(swap *mongodb.MgoSwap, isSwapin bool) (err error) {
+func processSwap(swap *storage.Swap, isSwapin bool) (err error) {
 	txid := swap.TxID
 	logWorker("swap", "start process swap", "txid", txid, "status", swap.Status, "isSwapin", isSwapin)
 
-	var resBridge tokens.CrossChainBridge
+	pairID := swap.PairID
+	resBridge := tokens.GetCrossChainBridge(pairID, !isSwapin)
+	if resBridge == nil {
+		return tokens.ErrUnknownPairID
+	}
 	var swapType tokens.SwapType
 	if isSwapin {
-		resBridge = tokens.DstBridge
 		swapType = tokens.SwapinType
 	} else {
-		resBridge = tokens.SrcBridge
 		swapType = tokens.SwapoutType
 	}
 
-	res, err := mongodb.FindSwapResult(isSwapin, txid)
+	res, err := storage.FindSwapResult(isSwapin, txid)
 	if err != nil {
 		return err
 	}
-	if tokens.GetTokenConfig(isSwapin).DisableSwap {
-		logWorkerTrace("swap", "swap is disabled", "isSwapin", isSwapin)
+	if tokens.IsSwapDisabled(pairID, isSwapin) {
+		logWorkerTrace("swap", "swap is disabled", "pairID", pairID, "isSwapin", isSwapin)
 		return nil
 	}
 	isBlacked, err := isSwapInBlacklist(res)
@@ -133,12 +133,12 @@ func processSwap(swap *mongodb.MgoSwap, isSwapin bool) (err error) {
 	if isBlacked {
 		logWorkerTrace("swap", "address is in blacklist", "txid", txid, "isSwapin", isSwapin)
 		err = tokens.ErrAddressIsInBlacklist
-		_ = mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.SwapInBlacklist, now(), err.Error())
+		_ = storage.UpdateSwapStatusFrom("swap", isSwapin, txid, swap.Status, storage.SwapInBlacklist, now(), err.Error())
 		return nil
 	}
 	if res.SwapTx != "" {
-		_ = mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.TxProcessed, now(), "")
-		if res.Status != mongodb.MatchTxEmpty {
+		_ = storage.UpdateSwapStatusFrom("swap", isSwapin, txid, swap.Status, storage.TxProcessed, now(), "")
+		if res.Status != storage.MatchTxEmpty {
 			return fmt.Errorf("%v already swapped to %v with status %v", txid, res.SwapTx, res.Status)
 		}
 		if _, err = resBridge.GetTransaction(res.SwapTx); err == nil {
@@ -146,19 +146,22 @@ func processSwap(swap *mongodb.MgoSwap, isSwapin bool) (err error) {
 		}
 	}
 
-	history := getSwapHistory(txid, isSwapin)
-	if history != nil {
-		if _, err = resBridge.GetTransaction(history.matchTx); err == nil {
-			matchTx := &MatchTx{
-				SwapTx:    history.matchTx,
-				SwapValue: tokens.CalcSwappedValue(history.value, isSwapin).String(),
-				SwapType:  swapType,
-				SwapNonce: history.nonce,
-			}
-			_ = updateSwapResult(txid, matchTx)
-			logWorker("swap", "ignore swapped swap", "txid", txid, "matchTx", history.matchTx, "isSwapin", isSwapin)
-			return fmt.Errorf("found swapped in history, txid=%v, matchTx=%v", txid, history.matchTx)
+	feeArgs, err := tools.GetSwapFeeArgs(res.Bind, res.TxTime, isSwapin)
+	if err != nil {
+		return err
+	}
+
+	if attempt := findSwappedAttempt(resBridge, txid, swapType, isSwapin); attempt != nil {
+		matchTx := &MatchTx{
+			SwapTx:    attempt.Key,
+			SwapValue: attempt.SwapValue,
+			SwapFee:   attempt.SwapFee,
+			SwapType:  swapType,
+			SwapNonce: attempt.Nonce,
 		}
+		_ = updateSwapResult("swap", txid, res.Status, matchTx)
+		logWorker("swap", "ignore swapped swap", "txid", txid, "matchTx", attempt.Key, "isSwapin", isSwapin)
+		return fmt.Errorf("found swapped in journal, txid=%v, matchTx=%v", txid, attempt.Key)
 	}
 
 	value, err := common.GetBigIntFromStr(res.Value)
@@ -168,11 +171,13 @@ func processSwap(swap *mongodb.MgoSwap, isSwapin bool) (err error) {
 
 	args := &tokens.BuildTxArgs{
 		SwapInfo: tokens.SwapInfo{
+			PairID:   pairID,
 			SwapID:   txid,
 			SwapType: swapType,
 		},
-		To:    res.Bind,
-		Value: value,
+		To:      res.Bind,
+		Value:   value,
+		FeeArgs: feeArgs,
 	}
 
 	if isSwapin {
@@ -180,16 +185,23 @@ func processSwap(swap *mongodb.MgoSwap, isSwapin bool) (err error) {
 		args.Bind = swap.Bind
 	}
 
-	return doSwap(resBridge, args, isSwapin)
+	return doSwap(resBridge, args, res.Status, isSwapin)
 }
 
-func doSwap(resBridge tokens.CrossChainBridge, args *tokens.BuildTxArgs, isSwapin bool) (err error) {
+// doSwap build, sign and send swap tx, the swap result is updated from resStatus
+// before sending, so only one of the concurrent swappers can send the swap tx.
+func doSwap(resBridge tokens.CrossChainBridge, args *tokens.BuildTxArgs, resStatus storage.SwapStatus, isSwapin bool) (err error) {
 	txid := args.SwapID
 	swapType := args.SwapType
 	originValue := args.Value
+	defer markSwapInflight(txid)()
 	if isSwapin != (swapType == tokens.SwapinType) {
 		return fmt.Errorf("mismatch isSwapin=%v but swapType=%v", isSwapin, swapType.String())
 	}
+	swappedValue := tokens.CalcSwappedValue(args.PairID, originValue, isSwapin, args.FeeArgs)
+	if _, dust := tokens.ConvertTokenValue(args.PairID, swappedValue, isSwapin); dust.Sign() > 0 {
+		logWorker("doSwap", "rescaling truncated dust is kept as fee", "txid", txid, "isSwapin", isSwapin, "value", originValue, "dust", dust)
+	}
 	rawTx, err := resBridge.BuildRawTransaction(args)
 	if err != nil {
 		logWorkerError("doSwap", "BuildRawTransaction failed", err, "txid", txid, "isSwapin", isSwapin)
@@ -205,78 +217,32 @@ func doSwap(resBridge tokens.CrossChainBridge, args *tokens.BuildTxArgs, isSwapi
 	swapTxNonce := args.GetTxNonce()
 
 	// update database before sending transaction
-	addSwapHistory(txid, originValue, txHash, swapTxNonce, isSwapin)
 	matchTx := &MatchTx{
 		SwapTx:    txHash,
-		SwapValue: tokens.CalcSwappedValue(originValue, isSwapin).String(),
+		SwapValue: tokens.CalcSwapValue(args.PairID, originValue, isSwapin, args.FeeArgs).String(),
+		SwapFee:   tokens.CalcSwapFee(args.PairID, originValue, isSwapin, args.FeeArgs).String(),
 		SwapType:  swapType,
 		SwapNonce: swapTxNonce,
 	}
-	err = updateSwapResult(txid, matchTx)
+	attempt, err := journalSwapAttempt("swap", resBridge, args, signedTx, txHash, matchTx.SwapValue, matchTx.SwapFee, isSwapin)
+	if err != nil {
+		logWorkerError("doSwap", "journal swap attempt failed", err, "txid", txid, "isSwapin", isSwapin)
+		return err
+	}
+	err = updateSwapResult("swap", txid, resStatus, matchTx)
 	if err != nil {
 		logWorkerError("doSwap", "update swap result failed", err, "txid", txid, "isSwapin", isSwapin)
+		dropSwapAttempt(attempt, err.Error())
 		return err
 	}
 
-	err = mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.TxProcessed, now(), "")
+	err = storage.UpdateSwapStatus("swap", isSwapin, txid, storage.TxProcessed, now(), "")
 	if err != nil {
 		logWorkerError("doSwap", "update swap status failed", err, "txid", txid, "isSwapin", isSwapin)
 		return err
 	}
 
-	return sendSignedTransaction(resBridge, signedTx, txid, isSwapin)
-}
-
-type swapInfo struct {
-	txid     string
-	value    *big.Int
-	matchTx  string
-	nonce    uint64
-	isSwapin bool
-}
-
-func addSwapHistory(txid string, value *big.Int, matchTx string, nonce uint64, isSwapin bool) {
-	// Create the new item as its own ring
-	item := ring.New(1)
-	item.Value = &swapInfo{
-		txid:     txid,
-		value:    value,
-		matchTx:  matchTx,
-		nonce:    nonce,
-		isSwapin: isSwapin,
-	}
-
-	swapRingLock.Lock()
-	defer swapRingLock.Unlock()
-
-	if swapRing == nil {
-		swapRing = item
-	} else {
-		if swapRing.Len() == swapRingMaxSize {
-			swapRing = swapRing.Move(-1)
-			swapRing.Unlink(1)
-			swapRing = swapRing.Move(1)
-		}
-		swapRing.Move(-1).Link(item)
-	}
-}
-
-func getSwapHistory(txid string, isSwapin bool) *swapInfo {
-	swapRingLock.RLock()
-	defer swapRingLock.RUnlock()
-
-	if swapRing == nil {
-		return nil
-	}
-
-	r := swapRing
-	for i := 0; i < r.Len(); i++ {
-		item := r.Value.(*swapInfo)
-		if item.txid == txid && item.isSwapin == isSwapin {
-			return item
-		}
-		r = r.Prev()
-	}
-
-	return nil
+	err = sendSignedTransaction("swap", resBridge, signedTx, txid, isSwapin, storage.TxSwapFailed)
+	markSwapAttemptSent(attempt, err)
+	return err
 }
@@ -1,9 +1,11 @@
 package worker
 
 import (
+	"context"
 	"sync"
 	"time"
 
+	"github.com/anyswap/CrossChain-Bridge/metrics"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 	"github.com/anyswap/CrossChain-Bridge/tokens/tools"
 )
@@ -14,73 +16,83 @@ var (
 	adjustGatewayOrderInterval      = 10 * time.Minute
 )
 
+// pairEndpoint endpoint of token pair
+type pairEndpoint struct {
+	pairID string
+	isSrc  bool
+	bridge tokens.CrossChainBridge
+}
+
+// getPairEndpointsByChain get endpoints of all token pairs grouped by chain,
+// so the latest block height of every chain is queried once.
+func getPairEndpointsByChain() map[string][]*pairEndpoint {
+	chains := make(map[string][]*pairEndpoint)
+	for _, pairID := range tokens.GetAllPairIDs() {
+		for _, isSrc := range []bool{true, false} {
+			bridge := tokens.GetCrossChainBridge(pairID, isSrc)
+			if bridge == nil {
+				continue
+			}
+			chainKey := tokens.GetChainKey(bridge)
+			chains[chainKey] = append(chains[chainKey], &pairEndpoint{pairID: pairID, isSrc: isSrc, bridge: bridge})
+		}
+	}
+	return chains
+}
+
 // StartUpdateLatestBlockHeightJob update latest block height job
-func StartUpdateLatestBlockHeightJob() {
+func StartUpdateLatestBlockHeightJob(ctx context.Context) {
 	updateLatestBlockHeightStarter.Do(func() {
 		logWorker("updatelatest", "start update latest block height job")
-		go adjustGatewayOrder()
+		defer logWorker("updatelatest", "stop update latest block height job")
+		go adjustGatewayOrder(ctx)
 		for {
-			updateSrcLatestBlockHeight()
-			updateDstLatestBlockHeight()
-			time.Sleep(updateLatestBlockHeightInterval)
+			for chainKey, endpoints := range getPairEndpointsByChain() {
+				updateLatestBlockHeight(chainKey, endpoints)
+			}
+			if !restInJob(ctx, updateLatestBlockHeightInterval) {
+				return
+			}
 		}
 	})
 }
 
-func updateSrcLatestBlockHeight() {
-	srcLatest, err := tokens.SrcBridge.GetLatestBlockNumber()
+func updateLatestBlockHeight(chainKey string, endpoints []*pairEndpoint) {
+	latest, err := endpoints[0].bridge.GetLatestBlockNumber()
 	if err != nil {
-		logWorkerError("updatelatest", "get src latest block number error", err)
+		logWorkerError("updatelatest", "get latest block number error", err, "chain", chainKey)
 		return
 	}
-	if tokens.SrcLatestBlockHeight != srcLatest {
-		tokens.SrcLatestBlockHeight = srcLatest
-		logWorker("updatelatest", "update src latest block number", "latest", srcLatest)
-	}
-}
-
-func updateDstLatestBlockHeight() {
-	dstLatest, err := tokens.DstBridge.GetLatestBlockNumber()
-	if err != nil {
-		logWorkerError("updatelatest", "get dest latest block number error", err)
-		return
-	}
-	if tokens.DstLatestBlockHeight != dstLatest {
-		tokens.DstLatestBlockHeight = dstLatest
-		logWorker("updatelatest", "update dest latest block number", "latest", dstLatest)
+	for _, endpoint := range endpoints {
+		metrics.SetLatestBlockHeight(endpoint.pairID, endpoint.isSrc, latest)
+		if tokens.GetPairLatestBlockHeight(endpoint.pairID, endpoint.isSrc) != latest {
+			tokens.SetPairLatestBlockHeight(endpoint.pairID, endpoint.isSrc, latest)
+			logWorkerTrace("updatelatest", "update latest block number", "pairID", endpoint.pairID, "isSrc", endpoint.isSrc, "latest", latest)
+		}
 	}
 }
 
-func adjustGatewayOrder() {
+func adjustGatewayOrder(ctx context.Context) {
 	for {
-		time.Sleep(adjustGatewayOrderInterval)
+		if !restInJob(ctx, adjustGatewayOrderInterval) {
+			return
+		}
 		logWorker("adjustGatewayOrder", "adjust gateway api adddress order")
-		adjustSrcGatewayOrder()
-		adjustDstGatewayOrder()
-	}
-}
-
-func adjustSrcGatewayOrder() {
-	// use block number as weight
-	var weightedAPIs tools.WeightedStringSlice
-
-	_, gateway := tokens.SrcBridge.GetTokenAndGateway()
-	for _, apiAddress := range gateway.APIAddress {
-		height, _ := tokens.SrcBridge.GetLatestBlockNumberOf(apiAddress)
-		weightedAPIs = weightedAPIs.Add(apiAddress, height)
+		for _, endpoints := range getPairEndpointsByChain() {
+			for _, endpoint := range endpoints {
+				adjustBridgeGatewayOrder(endpoint.bridge)
+			}
+		}
 	}
-
-	weightedAPIs = weightedAPIs.Sort()
-	gateway.APIAddress = weightedAPIs.GetStrings()
 }
 
-func adjustDstGatewayOrder() {
+func adjustBridgeGatewayOrder(bridge tokens.CrossChainBridge) {
 	// use block number as weight
 	var weightedAPIs tools.WeightedStringSlice
 
-	_, gateway := tokens.DstBridge.GetTokenAndGateway()
+	_, gateway := bridge.GetTokenAndGateway()
 	for _, apiAddress := range gateway.APIAddress {
-		height, _ := tokens.DstBridge.GetLatestBlockNumberOf(apiAddress)
+		height, _ := bridge.GetLatestBlockNumberOf(apiAddress)
 		weightedAPIs = weightedAPIs.Add(apiAddress, height)
 	}
 
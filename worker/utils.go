@@ -1,20 +1,58 @@
 package worker
 
 import (
+	"context"
 	"time"
 
+	"github.com/anyswap/CrossChain-Bridge/common"
 	"github.com/anyswap/CrossChain-Bridge/log"
 )
 
 var (
-	maxVerifyLifetime       = int64(7 * 24 * 3600)
-	restIntervalInVerifyJob = 3 * time.Second
+	// swaps are processed by the pipeline (see pipeline.go) as they are updated,
+	// the sweep jobs enqueue the missed ones periodically as a safety net.
+	maxVerifyLifetime             = int64(7 * 24 * 3600)
+	sweepIntervalInVerifyJob      = 60 * time.Second
+	visibilityTimeoutOfVerifyTask = 10 * time.Minute
+	consumersOfVerifyTask         = 4
+	retryScheduleOfVerifyTask     = []time.Duration{3 * time.Second, 10 * time.Second, 30 * time.Second, 60 * time.Second, 3 * time.Minute}
 
-	maxDoSwapLifetime       = int64(7 * 24 * 3600)
-	restIntervalInDoSwapJob = 3 * time.Second
+	maxDoSwapLifetime           = int64(7 * 24 * 3600)
+	sweepIntervalInDoSwapJob    = 60 * time.Second
+	visibilityTimeoutOfSwapTask = 30 * time.Minute
+	consumersOfSwapTask         = 2
+	retryScheduleOfSwapTask     = []time.Duration{10 * time.Second, 30 * time.Second, 60 * time.Second, 5 * time.Minute}
 
-	maxStableLifetime       = int64(7 * 24 * 3600)
-	restIntervalInStableJob = 3 * time.Second
+	maxStableLifetime             = int64(7 * 24 * 3600)
+	sweepIntervalInStableJob      = 60 * time.Second
+	visibilityTimeoutOfStableTask = 10 * time.Minute
+	consumersOfStableTask         = 4
+	retryScheduleOfStableTask     = []time.Duration{15 * time.Second, 30 * time.Second, 60 * time.Second, 2 * time.Minute}
+
+	// the swap event log is tailed to enqueue the swaps written by followers (HA mode),
+	// the events in the lookback window are read again to tolerate the clock skew of instances.
+	restIntervalInSwapEventFeed = 3 * time.Second
+	swapEventFeedLookback       = 30 * time.Second
+	swapEventFeedBatchSize      = 500
+
+	maxReplaceLifetime       = int64(7 * 24 * 3600)
+	restIntervalInReplaceJob = 60 * time.Second
+
+	maxReorgLifetime            = int64(7 * 24 * 3600)
+	restIntervalInReorgJob      = 10 * time.Second
+	waitTimeToFailReorgedSwapTx = int64(3600) // leave time for the reorged swap tx to be mined again
+
+	maxReconcileLifetime = int64(7 * 24 * 3600)
+
+	waitTimeToFillNonceGap = int64(600) // leave time for the owning swap to send its tx
+	restIntervalInNonceJob = 60 * time.Second
+
+	maxHeldLifetime          = int64(7 * 24 * 3600)
+	restIntervalInOutflowJob = 60 * time.Second
+
+	maxRefundLifetime       = int64(7 * 24 * 3600)
+	waitTimeToRefund        = int64(3600) // leave time to handle manually before refunding
+	restIntervalInRefundJob = 10 * time.Second
 )
 
 func now() int64 {
@@ -47,6 +85,7 @@ func getSepTimeInFind(dist int64) int64 {
 	return 0
 }
 
-func restInJob(duration time.Duration) {
-	time.Sleep(duration)
+// restInJob rest duration in job, returns false if context is done
+func restInJob(ctx context.Context, duration time.Duration) bool {
+	return common.SleepWithContext(ctx, duration)
 }
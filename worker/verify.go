@@ -1,9 +1,10 @@
 package worker
 
 import (
+	"context"
 	"sync"
 
-	"github.com/anyswap/CrossChain-Bridge/mongodb"
+	"github.com/anyswap/CrossChain-Bridge/storage"
 	"github.com/anyswap/CrossChain-Bridge/tokens"
 	"github.com/anyswap/CrossChain-Bridge/tokens/btc"
 )
@@ -14,14 +15,15 @@ var (
 )
 
 // StartVerifyJob verify job
-func StartVerifyJob() {
-	go startSwapinVerifyJob()
-	go startSwapoutVerifyJob()
+func StartVerifyJob(ctx context.Context) {
+	startJob(ctx, startSwapinVerifyJob)
+	startJob(ctx, startSwapoutVerifyJob)
 }
 
-func startSwapinVerifyJob() {
+func startSwapinVerifyJob(ctx context.Context) {
 	swapinVerifyStarter.Do(func() {
 		logWorker("verify", "start swapin verify job")
+		defer logWorker("verify", "stop swapin verify job")
 		for {
 			res, err := findSwapinsToVerify()
 			if err != nil {
@@ -31,21 +33,20 @@ func startSwapinVerifyJob() {
 				logWorker("verify", "find swapins to verify", "count", len(res))
 			}
 			for _, swap := range res {
-				err = processSwapinVerify(swap)
-				switch err {
-				case nil, tokens.ErrTxNotStable, tokens.ErrTxNotFound:
-				default:
-					logWorkerError("verify", "process swapin verify error", err, "txid", swap.TxID)
-				}
+				verifyStage.enqueue(true, swap.TxID, getRetryWaitTime(swap))
+			}
+			deadLetterExpiredSwaps("verify", true, storage.TxNotStable, maxVerifyLifetime)
+			if !restInJob(ctx, sweepIntervalInVerifyJob) {
+				return
 			}
-			restInJob(restIntervalInVerifyJob)
 		}
 	})
 }
 
-func startSwapoutVerifyJob() {
+func startSwapoutVerifyJob(ctx context.Context) {
 	swapoutVerifyStarter.Do(func() {
 		logWorker("verify", "start swapout verify job")
+		defer logWorker("verify", "stop swapout verify job")
 		for {
 			res, err := findSwapoutsToVerify()
 			if err != nil {
@@ -55,37 +56,35 @@ func startSwapoutVerifyJob() {
 				logWorker("verify", "find swapouts to verify", "count", len(res))
 			}
 			for _, swap := range res {
-				err = processSwapoutVerify(swap)
-				switch err {
-				case nil, tokens.ErrTxNotStable, tokens.ErrTxNotFound:
-				default:
-					logWorkerError("verify", "process swapout verify error", err, "txid", swap.TxID)
-				}
+				verifyStage.enqueue(false, swap.TxID, getRetryWaitTime(swap))
+			}
+			deadLetterExpiredSwaps("verify", false, storage.TxNotStable, maxVerifyLifetime)
+			if !restInJob(ctx, sweepIntervalInVerifyJob) {
+				return
 			}
-			restInJob(restIntervalInVerifyJob)
 		}
 	})
 }
 
-func findSwapinsToVerify() ([]*mongodb.MgoSwap, error) {
-	status := mongodb.TxNotStable
+func findSwapinsToVerify() ([]*storage.Swap, error) {
+	status := storage.TxNotStable
 	septime := getSepTimeInFind(maxVerifyLifetime)
-	return mongodb.FindSwapinsWithStatus(status, septime)
+	return storage.FindSwapinsWithStatus(status, septime)
 }
 
-func findSwapoutsToVerify() ([]*mongodb.MgoSwap, error) {
-	status := mongodb.TxNotStable
+func findSwapoutsToVerify() ([]*storage.Swap, error) {
+	status := storage.TxNotStable
 	septime := getSepTimeInFind(maxVerifyLifetime)
-	return mongodb.FindSwapoutsWithStatus(status, septime)
+	return storage.FindSwapoutsWithStatus(status, septime)
 }
 
 func isInBlacklist(swapInfo *tokens.TxSwapInfo) (isBlacked bool, err error) {
-	isBlacked, err = mongodb.QueryBlacklist(swapInfo.From)
+	isBlacked, err = storage.QueryBlacklist(swapInfo.From)
 	if err != nil {
 		return isBlacked, err
 	}
 	if !isBlacked && swapInfo.Bind != swapInfo.From {
-		isBlacked, err = mongodb.QueryBlacklist(swapInfo.Bind)
+		isBlacked, err = storage.QueryBlacklist(swapInfo.Bind)
 		if err != nil {
 			return isBlacked, err
 		}
@@ -93,39 +92,31 @@ func isInBlacklist(swapInfo *tokens.TxSwapInfo) (isBlacked bool, err error) {
 	return isBlacked, nil
 }
 
-func processSwapinVerify(swap *mongodb.MgoSwap) (err error) {
+func processSwapinVerify(swap *storage.Swap) (err error) {
 	return processSwapVerify(swap, true)
 }
 
-func processSwapoutVerify(swap *mongodb.MgoSwap) error {
+func processSwapoutVerify(swap *storage.Swap) error {
 	return processSwapVerify(swap, false)
 }
 
-func processSwapVerify(swap *mongodb.MgoSwap, isSwapin bool) (err error) {
+func processSwapVerify(swap *storage.Swap, isSwapin bool) (err error) {
 	txid := swap.TxID
-	var bridge tokens.CrossChainBridge
-	if isSwapin {
-		bridge = tokens.SrcBridge
-	} else {
-		bridge = tokens.DstBridge
+	bridge := tokens.GetCrossChainBridge(swap.PairID, isSwapin)
+	if bridge == nil {
+		return tokens.ErrUnknownPairID
 	}
+	tokenCfg, _ := bridge.GetTokenAndGateway()
 
-	var swapInfo *tokens.TxSwapInfo
-	switch tokens.SwapTxType(swap.TxType) {
-	case tokens.SwapinTx, tokens.SwapoutTx:
-		swapInfo, err = bridge.VerifyTransaction(txid, false)
-	case tokens.P2shSwapinTx:
-		if btc.BridgeInstance == nil {
-			return tokens.ErrNoBtcBridge
-		}
-		swapInfo, err = btc.BridgeInstance.VerifyP2shTransaction(txid, swap.Bind, false)
-	default:
-		return tokens.ErrWrongSwapinTxType
+	swapInfo, err := verifySwapTransaction(bridge, swap)
+	switch err {
+	case tokens.ErrNoBtcBridge, tokens.ErrWrongSwapinTxType:
+		return err
 	}
 	if swapInfo.Height != 0 &&
-		swapInfo.Height < tokens.GetTokenConfig(isSwapin).InitialHeight {
+		swapInfo.Height < tokenCfg.InitialHeight {
 		err = tokens.ErrTxBeforeInitialHeight
-		return mongodb.UpdateSwapinStatus(txid, mongodb.TxVerifyFailed, now(), err.Error())
+		return storage.UpdateSwapinStatus("verify", txid, storage.TxVerifyFailed, now(), err.Error())
 	}
 	isBlacked, errf := isInBlacklist(swapInfo)
 	if errf != nil {
@@ -133,51 +124,68 @@ func processSwapVerify(swap *mongodb.MgoSwap, isSwapin bool) (err error) {
 	}
 	if isBlacked {
 		err = tokens.ErrAddressIsInBlacklist
-		return mongodb.UpdateSwapinStatus(txid, mongodb.SwapInBlacklist, now(), err.Error())
+		return storage.UpdateSwapinStatus("verify", txid, storage.SwapInBlacklist, now(), err.Error())
 	}
 	return updateSwapStatus(txid, swapInfo, isSwapin, err)
 }
 
+func verifySwapTransaction(bridge tokens.CrossChainBridge, swap *storage.Swap) (*tokens.TxSwapInfo, error) {
+	switch tokens.SwapTxType(swap.TxType) {
+	case tokens.SwapinTx, tokens.SwapoutTx:
+		return tokens.VerifyTransactionOfPair(bridge, swap.TxID, false)
+	case tokens.P2shSwapinTx:
+		if btc.BridgeInstance == nil {
+			return nil, tokens.ErrNoBtcBridge
+		}
+		return btc.BridgeInstance.VerifyP2shTransaction(swap.TxID, swap.Bind, false)
+	default:
+		return nil, tokens.ErrWrongSwapinTxType
+	}
+}
+
 func updateSwapStatus(txid string, swapInfo *tokens.TxSwapInfo, isSwapin bool, err error) error {
-	resultStatus := mongodb.MatchTxEmpty
+	resultStatus := storage.MatchTxEmpty
 
 	switch err {
 	case tokens.ErrTxNotStable, tokens.ErrTxNotFound:
 		return err
 	case nil:
-		status := mongodb.TxNotSwapped
-		if swapInfo.Value.Cmp(tokens.GetBigValueThreshold(isSwapin)) > 0 {
-			status = mongodb.TxWithBigValue
-			resultStatus = mongodb.TxWithBigValue
+		var (
+			status storage.SwapStatus
+			memo   string
+		)
+		status, resultStatus, memo, err = getVerifiedSwapStatus(swapInfo, isSwapin)
+		if err != nil {
+			return err
 		}
-		err = mongodb.UpdateSwapStatus(isSwapin, txid, status, now(), "")
+		err = storage.UpdateSwapStatus("verify", isSwapin, txid, status, now(), memo)
 	case tokens.ErrTxWithWrongMemo:
-		resultStatus = mongodb.TxWithWrongMemo
-		err = mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.TxWithWrongMemo, now(), err.Error())
+		resultStatus = storage.TxWithWrongMemo
+		err = storage.UpdateSwapStatus("verify", isSwapin, txid, storage.TxWithWrongMemo, now(), err.Error())
 	case tokens.ErrBindAddrIsContract:
-		resultStatus = mongodb.BindAddrIsContract
-		err = mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.BindAddrIsContract, now(), err.Error())
+		resultStatus = storage.BindAddrIsContract
+		err = storage.UpdateSwapStatus("verify", isSwapin, txid, storage.BindAddrIsContract, now(), err.Error())
 	case tokens.ErrTxWithWrongValue:
-		resultStatus = mongodb.TxWithWrongValue
-		err = mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.TxWithWrongValue, now(), err.Error())
+		resultStatus = storage.TxWithWrongValue
+		err = storage.UpdateSwapStatus("verify", isSwapin, txid, storage.TxWithWrongValue, now(), err.Error())
 	case tokens.ErrTxSenderNotRegistered:
-		return mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.TxSenderNotRegistered, now(), err.Error())
+		return storage.UpdateSwapStatus("verify", isSwapin, txid, storage.TxSenderNotRegistered, now(), err.Error())
 	case tokens.ErrTxWithWrongSender:
-		return mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.TxWithWrongSender, now(), err.Error())
+		return storage.UpdateSwapStatus("verify", isSwapin, txid, storage.TxWithWrongSender, now(), err.Error())
 	case tokens.ErrTxIncompatible:
-		return mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.TxIncompatible, now(), err.Error())
+		return storage.UpdateSwapStatus("verify", isSwapin, txid, storage.TxIncompatible, now(), err.Error())
 	case tokens.ErrTxWithWrongReceipt:
-		return mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.TxVerifyFailed, now(), err.Error())
+		return storage.UpdateSwapStatus("verify", isSwapin, txid, storage.TxVerifyFailed, now(), err.Error())
 	case tokens.ErrRPCQueryError:
-		return mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.RPCQueryError, now(), err.Error())
+		return storage.UpdateSwapStatus("verify", isSwapin, txid, storage.RPCQueryError, now(), err.Error())
 	default:
 		logWorkerWarn("verify", "maybe not considered tx verify error", "err", err)
-		return mongodb.UpdateSwapStatus(isSwapin, txid, mongodb.TxVerifyFailed, now(), err.Error())
+		return storage.UpdateSwapStatus("verify", isSwapin, txid, storage.TxVerifyFailed, now(), err.Error())
 	}
 
 	if err != nil {
 		logWorkerError("verify", "update swap status", err, "txid", txid, "isSwapin", isSwapin)
 		return err
 	}
-	return addInitialSwapResult(swapInfo, resultStatus, isSwapin)
+	return addInitialSwapResult("verify", swapInfo, resultStatus, isSwapin)
 }
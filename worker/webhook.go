@@ -0,0 +1,284 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/anyswap/CrossChain-Bridge/internal/swapapi"
+	"github.com/anyswap/CrossChain-Bridge/log"
+	"github.com/anyswap/CrossChain-Bridge/params"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+)
+
+const (
+	webhookSignatureHeader = "X-Bridge-Signature"
+	webhookTimestampHeader = "X-Bridge-Timestamp"
+	webhookDeliveryHeader  = "X-Bridge-Delivery"
+	webhookEventHeader     = "X-Bridge-Event"
+
+	webhookBatchSize      = 100
+	webhookBackoffBase    = 10 * time.Second
+	webhookBackoffMax     = time.Hour
+	maxWebhookErrorLength = 256
+
+	// outflowBreakerEvent webhook event of tripped outflow breaker (not a swap status)
+	outflowBreakerEvent = "OutflowBreakerTripped"
+)
+
+var (
+	webhookStarter sync.Once
+
+	restIntervalInWebhookJob = 3 * time.Second
+
+	webhookHTTPClient = &http.Client{}
+	webhookSequence   uint64
+
+	// these statuses are also set to the swap result when verifying,
+	// notify them with the swap result (which has more details) only.
+	mirroredSwapStatuses = map[storage.SwapStatus]bool{
+		storage.TxWithWrongMemo:    true,
+		storage.TxWithBigValue:     true,
+		storage.TxHeld:             true,
+		storage.TxWithWrongValue:   true,
+		storage.BindAddrIsContract: true,
+	}
+)
+
+// webhookPayload the posted json body
+type webhookPayload struct {
+	ID        string            `json:"id"`       // delivery id, unchanged when retrying
+	Event     string            `json:"event"`    // swap status name or outflowBreakerEvent
+	SwapType  string            `json:"swaptype"` // swapin or swapout
+	Timestamp int64             `json:"timestamp"`
+	Swap      *swapapi.SwapInfo `json:"swap,omitempty"`
+	PairID    string            `json:"pairid,omitempty"` // outflowBreakerEvent only
+	Reason    string            `json:"reason,omitempty"` // outflowBreakerEvent only
+}
+
+// StartWebhookJob start webhook delivery job
+func StartWebhookJob(ctx context.Context) {
+	webhooks := params.GetConfig().Webhooks
+	if len(webhooks) == 0 {
+		logWorker("webhook", "webhook job is disabled")
+		return
+	}
+	for _, webhook := range webhooks {
+		for _, event := range webhook.Events {
+			if event == outflowBreakerEvent {
+				continue
+			}
+			if _, err := storage.ParseSwapStatus(event); err != nil {
+				log.Fatal("wrong webhook event", "webhook", webhook.Name, "err", err)
+			}
+		}
+	}
+	storage.AddSwapUpdateHandler(addWebhookDeliveries)
+	startJob(ctx, startWebhookJob)
+}
+
+func startWebhookJob(ctx context.Context) {
+	webhookStarter.Do(func() {
+		logWorker("webhook", "start webhook delivery job")
+		defer logWorker("webhook", "stop webhook delivery job")
+		for {
+			deliveries, err := storage.FindPendingWebhookDeliveries(webhookBatchSize)
+			if err != nil {
+				logWorkerError("webhook", "find pending webhook deliveries error", err)
+			}
+			for _, delivery := range deliveries {
+				if ctx.Err() != nil {
+					return
+				}
+				processWebhookDelivery(delivery)
+			}
+			if !restInJob(ctx, restIntervalInWebhookJob) {
+				return
+			}
+		}
+	})
+}
+
+func addWebhookDeliveries(isSwapin bool, swap *storage.Swap, result *storage.SwapResult) {
+	var (
+		status    storage.SwapStatus
+		timestamp int64
+		info      *swapapi.SwapInfo
+	)
+	if swap != nil {
+		if mirroredSwapStatuses[swap.Status] {
+			return
+		}
+		status = swap.Status
+		timestamp = swap.Timestamp
+		if res, err := storage.FindSwapResult(isSwapin, swap.TxID); err == nil {
+			info = swapapi.ConvertMgoSwapResultToSwapInfo(res)
+			info.Status = swap.Status
+			info.StatusMsg = swap.Status.String()
+			info.Timestamp = swap.Timestamp
+			info.Memo = swap.Memo
+		} else {
+			info = swapapi.ConvertMgoSwapToSwapInfo(swap)
+		}
+	} else {
+		status = result.Status
+		timestamp = result.Timestamp
+		info = swapapi.ConvertMgoSwapResultToSwapInfo(result)
+	}
+	addWebhookEventDeliveries(&webhookPayload{
+		Event:     status.String(),
+		SwapType:  getWebhookSwapType(isSwapin),
+		Timestamp: timestamp,
+		Swap:      info,
+	})
+}
+
+// addOutflowBreakerDeliveries alert the tripped outflow breaker through the outbox
+func addOutflowBreakerDeliveries(pairID string, isSwapin bool, reason error) {
+	addWebhookEventDeliveries(&webhookPayload{
+		Event:     outflowBreakerEvent,
+		SwapType:  getWebhookSwapType(isSwapin),
+		Timestamp: now(),
+		PairID:    pairID,
+		Reason:    reason.Error(),
+	})
+}
+
+func getWebhookSwapType(isSwapin bool) string {
+	if isSwapin {
+		return "swapin"
+	}
+	return "swapout"
+}
+
+// addWebhookEventDeliveries add delivery of payload to outbox for every webhook wanting its event
+func addWebhookEventDeliveries(payload *webhookPayload) {
+	event := payload.Event
+	for _, webhook := range params.GetConfig().Webhooks {
+		if !isWebhookEventWanted(webhook, event) {
+			continue
+		}
+		key := fmt.Sprintf("%x-%x-%v", time.Now().UnixNano(), atomic.AddUint64(&webhookSequence, 1), webhook.Name)
+		payload.ID = key
+		data, err := json.Marshal(payload)
+		if err != nil {
+			logWorkerError("webhook", "marshal webhook payload error", err, "webhook", webhook.Name, "event", event)
+			continue
+		}
+		delivery := &storage.WebhookDelivery{
+			Key:       key,
+			Webhook:   webhook.Name,
+			Event:     event,
+			Payload:   string(data),
+			NextTime:  now(),
+			Timestamp: now(),
+		}
+		if err = storage.AddWebhookDelivery(delivery); err != nil {
+			logWorkerError("webhook", "add webhook delivery error", err, "webhook", webhook.Name, "event", event, "key", key)
+		}
+	}
+}
+
+func isWebhookEventWanted(webhook *params.WebhookConfig, event string) bool {
+	if len(webhook.Events) == 0 {
+		return true
+	}
+	for _, e := range webhook.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func processWebhookDelivery(delivery *storage.WebhookDelivery) {
+	webhook := params.GetWebhookConfig(delivery.Webhook)
+	var err error
+	if webhook == nil {
+		err = fmt.Errorf("webhook '%v' is not configed", delivery.Webhook)
+		delivery.Dead = true
+	} else {
+		err = postWebhook(webhook, delivery)
+		if err == nil {
+			logWorker("webhook", "deliver webhook success", "webhook", delivery.Webhook, "event", delivery.Event, "key", delivery.Key)
+			if errr := storage.RemoveWebhookDelivery(delivery.Key); errr != nil {
+				logWorkerError("webhook", "remove delivered webhook error", errr, "key", delivery.Key)
+			}
+			return
+		}
+		delivery.Attempts++
+		if delivery.Attempts >= webhook.MaxAttempts {
+			delivery.Dead = true
+		} else {
+			delivery.NextTime = now() + int64(getWebhookBackoff(delivery.Attempts)/time.Second)
+		}
+	}
+	delivery.LastError = err.Error()
+	if len(delivery.LastError) > maxWebhookErrorLength {
+		delivery.LastError = delivery.LastError[:maxWebhookErrorLength]
+	}
+	if delivery.Dead {
+		logWorkerWarn("webhook", "move webhook delivery to dead-letter list", "webhook", delivery.Webhook, "key", delivery.Key, "attempts", delivery.Attempts, "err", err)
+	} else {
+		logWorkerWarn("webhook", "deliver webhook failed", "webhook", delivery.Webhook, "key", delivery.Key, "attempts", delivery.Attempts, "nexttime", delivery.NextTime, "err", err)
+	}
+	if errr := storage.UpdateWebhookDelivery(delivery); errr != nil {
+		logWorkerError("webhook", "update webhook delivery error", errr, "key", delivery.Key)
+	}
+}
+
+// getWebhookBackoff exponential backoff, 10s, 20s, 40s, ... at most 1 hour
+func getWebhookBackoff(attempts int) time.Duration {
+	backoff := webhookBackoffBase
+	for i := 1; i < attempts && backoff < webhookBackoffMax; i++ {
+		backoff *= 2
+	}
+	if backoff > webhookBackoffMax {
+		backoff = webhookBackoffMax
+	}
+	return backoff
+}
+
+// signWebhookPayload hex encoded HMAC-SHA256 of "timestamp.payload"
+func signWebhookPayload(secret, timestamp, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write([]byte(timestamp + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func postWebhook(webhook *params.WebhookConfig, delivery *storage.WebhookDelivery) error {
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(now(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookDeliveryHeader, delivery.Key)
+	req.Header.Set(webhookEventHeader, delivery.Event)
+	req.Header.Set(webhookTimestampHeader, timestamp)
+	req.Header.Set(webhookSignatureHeader, "sha256="+signWebhookPayload(webhook.Secret, timestamp, delivery.Payload))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(webhook.Timeout)*time.Second)
+	defer cancel()
+	resp, err := webhookHTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("response status %v", resp.Status)
+	}
+	return nil
+}
@@ -1,40 +1,128 @@
 package worker
 
 import (
+	"context"
+	"sync"
 	"time"
 
+	"github.com/anyswap/CrossChain-Bridge/params"
 	"github.com/anyswap/CrossChain-Bridge/rpc/client"
+	"github.com/anyswap/CrossChain-Bridge/storage"
+	"github.com/anyswap/CrossChain-Bridge/tokens"
 	"github.com/anyswap/CrossChain-Bridge/tokens/bridge"
 )
 
 const interval = 10 * time.Millisecond
 
-// StartWork start swap server work
-func StartWork(isServer bool) {
+// jobsWaitGroup tracks all started jobs to wait them exit on shutdown
+var jobsWaitGroup sync.WaitGroup
+
+// StartWork start swap server work, all jobs stop picking up new work
+// when ctx is done, call WaitJobsFinish to wait in-flight work to finish.
+func StartWork(ctx context.Context, isServer bool) {
 	logWorker("worker", "start server worker")
 
 	client.InitHTTPClient()
 	bridge.InitCrossChainBridge(isServer)
 
-	go StartScanJob(isServer)
-	time.Sleep(interval)
+	if !isServer {
+		StartScanJob(ctx, isServer)
+		time.Sleep(interval)
 
-	go StartUpdateLatestBlockHeightJob()
+		startJob(ctx, StartUpdateLatestBlockHeightJob)
+		time.Sleep(interval)
+
+		startJob(ctx, StartAcceptSignJob)
+		return
+	}
+
+	// reserve swap nonces persistently (eth like chain)
+	tokens.SetNonceManager(&storage.NonceManager{})
+
+	// every instance serves the api and needs the latest block heights
+	startJob(ctx, StartUpdateLatestBlockHeightJob)
 	time.Sleep(interval)
 
-	if !isServer {
-		go StartAcceptSignJob()
+	if params.IsHAEnabled() {
+		// only the leader runs the server jobs
+		startJob(ctx, func(ctx context.Context) {
+			campaignLeadership(ctx, startServerJobs)
+		})
 		return
 	}
+	startServerJobs(ctx)
+}
+
+// startServerJobs start the server jobs which write swaps
+func startServerJobs(ctx context.Context) {
+	// restore the outflow breakers tripped before the restart or failover
+	syncOutflowBreakers()
+
+	StartScanJob(ctx, true)
+	time.Sleep(interval)
+
+	StartVerifyJob(ctx)
+	time.Sleep(interval)
+
+	// resolve the journaled swap txs before processing any new swap
+	ReconcileSwapAttempts(ctx)
+
+	// process the swaps enqueued by api, scanner and the sweepers
+	startPipeline(ctx)
+	time.Sleep(interval)
 
-	go StartVerifyJob()
+	StartSwapJob(ctx)
 	time.Sleep(interval)
 
-	go StartSwapJob()
+	StartStableJob(ctx)
 	time.Sleep(interval)
 
-	go StartStableJob()
+	StartReplaceJob(ctx)
 	time.Sleep(interval)
 
-	go StartAggregateJob()
+	StartReorgJob(ctx)
+	time.Sleep(interval)
+
+	StartNonceJob(ctx)
+	time.Sleep(interval)
+
+	StartOutflowJob(ctx)
+	time.Sleep(interval)
+
+	StartRefundJob(ctx)
+	time.Sleep(interval)
+
+	StartWebhookJob(ctx)
+	time.Sleep(interval)
+
+	startJob(ctx, StartUpdateSwapCountMetricsJob)
+	time.Sleep(interval)
+
+	startJob(ctx, StartAggregateJob)
+}
+
+// WaitJobsFinish wait all jobs exit after the context passed to StartWork is done,
+// returns false if timeout.
+func WaitJobsFinish(timeout time.Duration) bool {
+	finished := make(chan struct{})
+	go func() {
+		jobsWaitGroup.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+		logWorker("worker", "all jobs are finished")
+		return true
+	case <-time.After(timeout):
+		logWorkerWarn("worker", "wait jobs finish timeout", "timeout", timeout)
+		return false
+	}
+}
+
+func startJob(ctx context.Context, job func(ctx context.Context)) {
+	jobsWaitGroup.Add(1)
+	go func() {
+		defer jobsWaitGroup.Done()
+		job(ctx)
+	}()
 }